@@ -42,8 +42,11 @@ import (
 	"github.com/uber/cadence/common/log/tag"
 	"github.com/uber/cadence/common/membership"
 	"github.com/uber/cadence/common/messaging/kafka"
+	messagingPersistence "github.com/uber/cadence/common/messaging/persistence"
 	"github.com/uber/cadence/common/metrics"
 	"github.com/uber/cadence/common/peerprovider/ringpopprovider"
+	"github.com/uber/cadence/common/persistence"
+	persistenceClient "github.com/uber/cadence/common/persistence/client"
 	"github.com/uber/cadence/common/resource"
 	"github.com/uber/cadence/common/rpc"
 	"github.com/uber/cadence/common/service"
@@ -114,10 +117,13 @@ func (s *server) startService() common.Daemon {
 
 	params.PersistenceConfig = s.cfg.Persistence
 
+	params.MetricScope = svcCfg.Metrics.NewScope(params.Logger, params.Name)
+	params.MetricsClient = metrics.NewClient(params.MetricScope, service.GetMetricsServiceIdx(params.Name, params.Logger))
+
 	err = nil
 	if s.cfg.DynamicConfig.Client == "" {
 		//try to fallback to legacy dynamicClientConfig
-		params.DynamicConfig, err = dynamicconfig.NewFileBasedClient(&s.cfg.DynamicConfigClient, params.Logger, s.doneC)
+		params.DynamicConfig, err = dynamicconfig.NewFileBasedClient(&s.cfg.DynamicConfigClient, params.MetricsClient, params.Logger, s.doneC)
 	} else {
 		switch s.cfg.DynamicConfig.Client {
 		case dynamicconfig.DynamicConfigConfigStoreClient:
@@ -130,7 +136,10 @@ func (s *server) startService() common.Daemon {
 			)
 		case dynamicconfig.DynamicConfigFileBasedClient:
 			log.Printf("Trying to initialize File Based Dynamic Config Client\n")
-			params.DynamicConfig, err = dynamicconfig.NewFileBasedClient(&s.cfg.DynamicConfig.FileBased, params.Logger, s.doneC)
+			params.DynamicConfig, err = dynamicconfig.NewFileBasedClient(&s.cfg.DynamicConfig.FileBased, params.MetricsClient, params.Logger, s.doneC)
+		case dynamicconfig.DynamicConfigRemoteClient:
+			log.Printf("Trying to initialize Remote Dynamic Config Client\n")
+			params.DynamicConfig, err = dynamicconfig.NewRemoteClient(&s.cfg.DynamicConfig.Remote, params.MetricsClient, params.Logger, s.doneC)
 		default:
 			log.Printf("Trying to initialize Nop Config Client\n")
 			params.DynamicConfig = dynamicconfig.NewNopClient()
@@ -148,8 +157,6 @@ func (s *server) startService() common.Daemon {
 		dynamicconfig.ClusterNameFilter(clusterGroupMetadata.CurrentClusterName),
 	)
 
-	params.MetricScope = svcCfg.Metrics.NewScope(params.Logger, params.Name)
-
 	rpcParams, err := rpc.NewParams(params.Name, s.cfg, dc)
 	if err != nil {
 		log.Fatalf("error creating rpc factory params: %v", err)
@@ -187,8 +194,6 @@ func (s *server) startService() common.Daemon {
 
 	params.ClusterRedirectionPolicy = s.cfg.ClusterGroupMetadata.ClusterRedirectionPolicy
 
-	params.MetricsClient = metrics.NewClient(params.MetricScope, service.GetMetricsServiceIdx(params.Name, params.Logger))
-
 	params.ClusterMetadata = cluster.NewMetadata(
 		clusterGroupMetadata.FailoverVersionIncrement,
 		clusterGroupMetadata.PrimaryClusterName,
@@ -200,8 +205,33 @@ func (s *server) startService() common.Daemon {
 		dynamicconfig.AdvancedVisibilityWritingMode,
 	)()
 	isAdvancedVisEnabled := common.IsAdvancedVisibilityWritingEnabled(advancedVisMode, params.PersistenceConfig.IsAdvancedVisibilityConfigExist())
-	if isAdvancedVisEnabled {
-		params.MessagingClient = kafka.NewKafkaClient(&s.cfg.Kafka, params.MetricsClient, params.Logger, params.MetricScope, isAdvancedVisEnabled)
+	advancedVisPipeline := dc.GetStringProperty(
+		dynamicconfig.AdvancedVisibilityWritingPipeline,
+	)()
+	// Kafka is not needed when visibility records are written to ElasticSearch directly,
+	// the messaging client is then only created to publish rejected records to the visibility DLQ
+	needsMessaging := advancedVisPipeline != common.AdvancedVisibilityWritingPipelineDirect || s.isVisibilityDLQConfigured()
+	if isAdvancedVisEnabled && needsMessaging {
+		if s.cfg.Messaging.IsPersistence() {
+			// queue managers are created lazily, after the persistence config below is complete
+			persistenceFactory := persistenceClient.NewFactory(
+				&params.PersistenceConfig,
+				nil,
+				clusterGroupMetadata.CurrentClusterName,
+				params.MetricsClient,
+				params.Logger,
+				persistence.NewDynamicConfiguration(dc),
+			)
+			params.MessagingClient = messagingPersistence.NewPersistenceClient(
+				&s.cfg.Messaging.Persistence,
+				persistenceFactory.NewMessagingQueueManager,
+				params.MembershipResolver,
+				params.MetricsClient,
+				params.Logger,
+			)
+		} else {
+			params.MessagingClient = kafka.NewKafkaClient(&s.cfg.Kafka, params.MetricsClient, params.Logger, params.MetricScope, isAdvancedVisEnabled)
+		}
 	} else {
 		params.MessagingClient = nil
 	}
@@ -283,6 +313,15 @@ func (s *server) startService() common.Daemon {
 	return daemon
 }
 
+// isVisibilityDLQConfigured returns whether the messaging config has a DLQ for visibility records
+func (s *server) isVisibilityDLQConfigured() bool {
+	if s.cfg.Messaging.IsPersistence() {
+		_, ok := s.cfg.Messaging.Persistence.Applications[common.VisibilityAppName]
+		return ok
+	}
+	return s.cfg.Kafka.GetTopicsForApplication(common.VisibilityAppName).DLQTopic != ""
+}
+
 // execute runs the daemon in a separate go routine
 func execute(d common.Daemon, doneC chan struct{}) {
 	d.Start()
@@ -32,11 +32,12 @@ import (
 	"github.com/xwb1989/sqlparser"
 
 	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/archiver"
 	"github.com/uber/cadence/common/types"
 )
 
 type (
-	// QueryParser parses a limited SQL where clause into a struct
+	// QueryParser parses a SQL where clause and an optional ORDER BY clause into a struct
 	QueryParser interface {
 		Parse(query string) (*parsedQuery, error)
 	}
@@ -50,17 +51,24 @@ type (
 		runID             *string
 		workflowTypeName  *string
 		closeStatus       *types.WorkflowExecutionCloseStatus
-		emptyResult       bool
+		// filter matches the conditions which can't be expressed by the fields above, it's nil if there are none
+		filter           archiver.VisibilityQueryFilter
+		orderByStartTime bool
+		ascending        bool
+		emptyResult      bool
 	}
 )
 
-// All allowed fields for filtering
+// All allowed fields for filtering, other field names are matched against search attributes
 const (
-	WorkflowID   = "WorkflowID"
-	RunID        = "RunID"
-	WorkflowType = "WorkflowType"
-	CloseTime    = "CloseTime"
-	CloseStatus  = "CloseStatus"
+	WorkflowID    = "WorkflowID"
+	RunID         = "RunID"
+	WorkflowType  = "WorkflowType"
+	StartTime     = "StartTime"
+	ExecutionTime = "ExecutionTime"
+	CloseTime     = "CloseTime"
+	CloseStatus   = "CloseStatus"
+	HistoryLength = "HistoryLength"
 )
 
 const (
@@ -69,6 +77,17 @@ const (
 	defaultDateTimeFormat = time.RFC3339
 )
 
+var queryFields = archiver.VisibilityQueryFields{
+	WorkflowID:    archiver.WorkflowIDQueryField,
+	RunID:         archiver.RunIDQueryField,
+	WorkflowType:  archiver.WorkflowTypeQueryField,
+	StartTime:     archiver.StartTimeQueryField,
+	ExecutionTime: archiver.ExecutionTimeQueryField,
+	CloseTime:     archiver.CloseTimeQueryField,
+	CloseStatus:   archiver.CloseStatusQueryField,
+	HistoryLength: archiver.HistoryLengthQueryField,
+}
+
 // NewQueryParser creates a new query parser for filestore
 func NewQueryParser() QueryParser {
 	return &queryParser{}
@@ -79,96 +98,115 @@ func (p *queryParser) Parse(query string) (*parsedQuery, error) {
 	if err != nil {
 		return nil, err
 	}
-	whereExpr := stmt.(*sqlparser.Select).Where.Expr
+	selectStmt := stmt.(*sqlparser.Select)
+	whereExpr := selectStmt.Where.Expr
 	parsedQuery := &parsedQuery{
 		earliestCloseTime: 0,
 		latestCloseTime:   time.Now().UnixNano(),
 	}
-	if err := p.convertWhereExpr(whereExpr, parsedQuery); err != nil {
+	var filterExprs []sqlparser.Expr
+	if err := p.convertWhereExpr(whereExpr, parsedQuery, &filterExprs); err != nil {
+		return nil, err
+	}
+	if len(filterExprs) != 0 {
+		if parsedQuery.filter, err = archiver.CompileVisibilityQueryFilter(filterExprs, queryFields); err != nil {
+			return nil, err
+		}
+	}
+
+	// Narrow down the close time range as far as the whole expression allows it, so that fewer files are read.
+	// Workflows are closed after they are started, so a lower bound of the start time is one of the close time as well.
+	earliestCloseTime, latestCloseTime := archiver.VisibilityQueryTimeRange(whereExpr, CloseTime)
+	earliestStartTime, _ := archiver.VisibilityQueryTimeRange(whereExpr, StartTime)
+	parsedQuery.earliestCloseTime = common.MaxInt64(parsedQuery.earliestCloseTime, common.MaxInt64(earliestCloseTime, earliestStartTime))
+	parsedQuery.latestCloseTime = common.MinInt64(parsedQuery.latestCloseTime, latestCloseTime)
+	if parsedQuery.earliestCloseTime > parsedQuery.latestCloseTime {
+		parsedQuery.emptyResult = true
+	}
+
+	order, err := archiver.ParseVisibilityQueryOrder(selectStmt.OrderBy, CloseTime, StartTime)
+	if err != nil {
 		return nil, err
 	}
+	if order != nil {
+		parsedQuery.orderByStartTime = order.Field == StartTime
+		parsedQuery.ascending = order.Ascending
+	}
 	return parsedQuery, nil
 }
 
-func (p *queryParser) convertWhereExpr(expr sqlparser.Expr, parsedQuery *parsedQuery) error {
+// convertWhereExpr converts the top level conjunction of the where expression, conditions which
+// can't be converted into parsedQuery fields are added to filterExprs
+func (p *queryParser) convertWhereExpr(expr sqlparser.Expr, parsedQuery *parsedQuery, filterExprs *[]sqlparser.Expr) error {
 	if expr == nil {
 		return errors.New("where expression is nil")
 	}
 
 	switch expr := expr.(type) {
-	case *sqlparser.ComparisonExpr:
-		return p.convertComparisonExpr(expr, parsedQuery)
 	case *sqlparser.AndExpr:
-		return p.convertAndExpr(expr, parsedQuery)
+		if err := p.convertWhereExpr(expr.Left, parsedQuery, filterExprs); err != nil {
+			return err
+		}
+		return p.convertWhereExpr(expr.Right, parsedQuery, filterExprs)
 	case *sqlparser.ParenExpr:
-		return p.convertParenExpr(expr, parsedQuery)
-	default:
-		return errors.New("only comparison and \"and\" expression is supported")
-	}
-}
-
-func (p *queryParser) convertParenExpr(parenExpr *sqlparser.ParenExpr, parsedQuery *parsedQuery) error {
-	return p.convertWhereExpr(parenExpr.Expr, parsedQuery)
-}
-
-func (p *queryParser) convertAndExpr(andExpr *sqlparser.AndExpr, parsedQuery *parsedQuery) error {
-	if err := p.convertWhereExpr(andExpr.Left, parsedQuery); err != nil {
-		return err
+		return p.convertWhereExpr(expr.Expr, parsedQuery, filterExprs)
+	case *sqlparser.ComparisonExpr:
+		converted, err := p.convertComparisonExpr(expr, parsedQuery)
+		if err != nil || converted {
+			return err
+		}
 	}
-	return p.convertWhereExpr(andExpr.Right, parsedQuery)
+	*filterExprs = append(*filterExprs, expr)
+	return nil
 }
 
-func (p *queryParser) convertComparisonExpr(compExpr *sqlparser.ComparisonExpr, parsedQuery *parsedQuery) error {
+// convertComparisonExpr converts equality conditions and close time ranges into parsedQuery fields,
+// it returns false for conditions which need to be evaluated by the filter
+func (p *queryParser) convertComparisonExpr(compExpr *sqlparser.ComparisonExpr, parsedQuery *parsedQuery) (bool, error) {
 	colName, ok := compExpr.Left.(*sqlparser.ColName)
 	if !ok {
-		return fmt.Errorf("invalid filter name: %s", sqlparser.String(compExpr.Left))
+		return false, nil
 	}
 	colNameStr := sqlparser.String(colName)
 	op := compExpr.Operator
 	valExpr, ok := compExpr.Right.(*sqlparser.SQLVal)
 	if !ok {
-		return fmt.Errorf("invalid value: %s", sqlparser.String(compExpr.Right))
+		return false, nil
 	}
 	valStr := sqlparser.String(valExpr)
+	if op != "=" && colNameStr != CloseTime {
+		return false, nil
+	}
 
 	switch colNameStr {
 	case WorkflowID:
 		val, err := extractStringValue(valStr)
 		if err != nil {
-			return err
-		}
-		if op != "=" {
-			return fmt.Errorf("only operator = is supported for %s with file system", WorkflowID)
+			return false, err
 		}
 		if parsedQuery.workflowID != nil && *parsedQuery.workflowID != val {
 			parsedQuery.emptyResult = true
-			return nil
+			return true, nil
 		}
 		parsedQuery.workflowID = common.StringPtr(val)
 	case RunID:
 		val, err := extractStringValue(valStr)
 		if err != nil {
-			return err
-		}
-		if op != "=" {
-			return fmt.Errorf("only operator = is supported for %s with file system", RunID)
+			return false, err
 		}
 		if parsedQuery.runID != nil && *parsedQuery.runID != val {
 			parsedQuery.emptyResult = true
-			return nil
+			return true, nil
 		}
 		parsedQuery.runID = common.StringPtr(val)
 	case WorkflowType:
 		val, err := extractStringValue(valStr)
 		if err != nil {
-			return err
-		}
-		if op != "=" {
-			return fmt.Errorf("only operator = is supported for %s with file system", WorkflowType)
+			return false, err
 		}
 		if parsedQuery.workflowTypeName != nil && *parsedQuery.workflowTypeName != val {
 			parsedQuery.emptyResult = true
-			return nil
+			return true, nil
 		}
 		parsedQuery.workflowTypeName = common.StringPtr(val)
 	case CloseStatus:
@@ -177,29 +215,29 @@ func (p *queryParser) convertComparisonExpr(compExpr *sqlparser.ComparisonExpr,
 			// if failed to extract string value, it means user input close status as a number
 			val = valStr
 		}
-		if op != "=" {
-			return fmt.Errorf("only operator = is supported for %s with file system", CloseStatus)
-		}
 		status, err := convertStatusStr(val)
 		if err != nil {
-			return err
+			return false, err
 		}
 		if parsedQuery.closeStatus != nil && *parsedQuery.closeStatus != status {
 			parsedQuery.emptyResult = true
-			return nil
+			return true, nil
 		}
 		parsedQuery.closeStatus = status.Ptr()
 	case CloseTime:
 		timestamp, err := convertToTimestamp(valStr)
 		if err != nil {
-			return err
+			return false, err
 		}
-		return p.convertCloseTime(timestamp, op, parsedQuery)
+		if op == "!=" {
+			return false, nil
+		}
+		return true, p.convertCloseTime(timestamp, op, parsedQuery)
 	default:
-		return fmt.Errorf("unknown filter name: %s", colNameStr)
+		return false, nil
 	}
 
-	return nil
+	return true, nil
 }
 
 func (p *queryParser) convertCloseTime(timestamp int64, op string, parsedQuery *parsedQuery) error {
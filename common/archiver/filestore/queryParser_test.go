@@ -27,6 +27,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/archiver"
 	"github.com/uber/cadence/common/types"
 )
 
@@ -108,8 +109,9 @@ func (s *queryParserSuite) TestParseWorkflowID_RunID_WorkflowType() {
 			expectErr: true,
 		},
 		{
-			query:     "WorkflowID = \"random workflowID\" or WorkflowID = \"another workflowID\"",
-			expectErr: true,
+			query:       "WorkflowID = \"random workflowID\" or WorkflowID = \"another workflowID\"",
+			expectErr:   false,
+			parsedQuery: &parsedQuery{},
 		},
 		{
 			query:     "WorkflowID = \"random workflowID\" or runID = \"random runID\"",
@@ -187,8 +189,9 @@ func (s *queryParserSuite) TestParseCloseStatus() {
 			expectErr: true,
 		},
 		{
-			query:     "CloseStatus = \"Failed\" or CloseStatus = \"Failed\"",
-			expectErr: true,
+			query:       "CloseStatus = \"Failed\" or CloseStatus = \"Failed\"",
+			expectErr:   false,
+			parsedQuery: &parsedQuery{},
 		},
 		{
 			query:     "CloseStatus = \"unknown\"",
@@ -339,3 +342,136 @@ func (s *queryParserSuite) TestParse() {
 		}
 	}
 }
+
+func (s *queryParserSuite) TestParseFilter() {
+	record := &archiver.ArchiveVisibilityRequest{
+		WorkflowID:       "random workflowID",
+		RunID:            "random runID",
+		WorkflowTypeName: "random typeName",
+		StartTimestamp:   1000,
+		CloseTimestamp:   2000,
+		CloseStatus:      types.WorkflowExecutionCloseStatusFailed,
+		HistoryLength:    10,
+		SearchAttributes: map[string]string{"CustomKeywordField": `"keyword"`, "CustomIntField": "5"},
+	}
+	testCases := []struct {
+		query       string
+		expectErr   bool
+		shouldMatch bool
+	}{
+		{
+			query:       "WorkflowID = 'another workflowID' or RunID = 'random runID'",
+			shouldMatch: true,
+		},
+		{
+			query:       "NOT (WorkflowID = 'random workflowID')",
+			shouldMatch: false,
+		},
+		{
+			query:       "WorkflowType IN ('some typeName', 'random typeName') and CloseStatus NOT IN ('Completed', 'TimedOut')",
+			shouldMatch: true,
+		},
+		{
+			query:       "WorkflowID LIKE 'random%' and RunID NOT LIKE '%workflow%'",
+			shouldMatch: true,
+		},
+		{
+			query:       "StartTime BETWEEN 500 AND 1500 and HistoryLength > 10",
+			shouldMatch: false,
+		},
+		{
+			query:       "CustomKeywordField = 'keyword' and CustomIntField < 6 and CustomDoubleField IS NULL",
+			shouldMatch: true,
+		},
+		{
+			query:       "CustomIntField != 5",
+			shouldMatch: false,
+		},
+		{
+			query:     "CloseStatus > 'Failed'",
+			expectErr: true,
+		},
+		{
+			query:     "WorkflowID IN (1, 2)",
+			expectErr: true,
+		},
+		{
+			query:     "CloseTime != 2000 or closeTime = 2000",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		parsedQuery, err := s.parser.Parse(tc.query)
+		if tc.expectErr {
+			s.Error(err, tc.query)
+			continue
+		}
+		s.NoError(err, tc.query)
+		s.NotNil(parsedQuery.filter, tc.query)
+		s.Equal(tc.shouldMatch, parsedQuery.filter(record), tc.query)
+	}
+}
+
+func (s *queryParserSuite) TestParseTimeRangeAndOrder() {
+	testCases := []struct {
+		query       string
+		expectErr   bool
+		parsedQuery *parsedQuery
+	}{
+		{
+			query: "(CloseTime >= 1000 and CloseTime < 2000) or (CloseTime > 5000 and CloseTime <= 6000)",
+			parsedQuery: &parsedQuery{
+				earliestCloseTime: 1000,
+				latestCloseTime:   6000,
+			},
+		},
+		{
+			query: "StartTime >= 3000 and CloseTime < 10000 order by StartTime desc",
+			parsedQuery: &parsedQuery{
+				earliestCloseTime: 3000,
+				latestCloseTime:   9999,
+				orderByStartTime:  true,
+			},
+		},
+		{
+			query: "CloseTime <= 1000 and StartTime > 2000",
+			parsedQuery: &parsedQuery{
+				emptyResult: true,
+			},
+		},
+		{
+			query: "WorkflowID = 'random workflowID' order by CloseTime asc",
+			parsedQuery: &parsedQuery{
+				ascending: true,
+			},
+		},
+		{
+			query:     "WorkflowID = 'random workflowID' order by WorkflowID",
+			expectErr: true,
+		},
+		{
+			query:     "WorkflowID = 'random workflowID' order by CloseTime, StartTime",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		parsedQuery, err := s.parser.Parse(tc.query)
+		if tc.expectErr {
+			s.Error(err, tc.query)
+			continue
+		}
+		s.NoError(err, tc.query)
+		s.Equal(tc.parsedQuery.emptyResult, parsedQuery.emptyResult, tc.query)
+		if tc.parsedQuery.emptyResult {
+			continue
+		}
+		if tc.parsedQuery.latestCloseTime != 0 {
+			s.Equal(tc.parsedQuery.earliestCloseTime, parsedQuery.earliestCloseTime, tc.query)
+			s.Equal(tc.parsedQuery.latestCloseTime, parsedQuery.latestCloseTime, tc.query)
+		}
+		s.Equal(tc.parsedQuery.orderByStartTime, parsedQuery.orderByStartTime, tc.query)
+		s.Equal(tc.parsedQuery.ascending, parsedQuery.ascending, tc.query)
+	}
+}
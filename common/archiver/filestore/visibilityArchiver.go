@@ -52,6 +52,8 @@ type (
 	queryVisibilityToken struct {
 		LastCloseTime int64
 		LastRunID     string
+		// LastStartTime is only set when results are ordered by start time
+		LastStartTime int64 `json:",omitempty"`
 	}
 
 	visibilityRecord archiver.ArchiveVisibilityRequest
@@ -191,7 +193,11 @@ func (v *visibilityArchiver) query(
 		return nil, &types.InternalServiceError{Message: err.Error()}
 	}
 
-	files, err = sortAndFilterFiles(files, token)
+	if request.parsedQuery.orderByStartTime {
+		return v.queryOrderedByStartTime(dirPath, files, request, token)
+	}
+
+	files, err = sortAndFilterFiles(files, token, request.parsedQuery)
 	if err != nil {
 		return nil, &types.InternalServiceError{Message: err.Error()}
 	}
@@ -201,24 +207,15 @@ func (v *visibilityArchiver) query(
 
 	response := &archiver.QueryVisibilityResponse{}
 	for idx, file := range files {
-		encodedRecord, err := util.ReadFile(path.Join(dirPath, file))
+		record, err := readVisibilityRecord(path.Join(dirPath, file))
 		if err != nil {
 			return nil, &types.InternalServiceError{Message: err.Error()}
 		}
 
-		record, err := decodeVisibilityRecord(encodedRecord)
-		if err != nil {
-			return nil, &types.InternalServiceError{Message: err.Error()}
-		}
-
-		if record.CloseTimestamp < request.parsedQuery.earliestCloseTime {
-			break
-		}
-
 		if matchQuery(record, request.parsedQuery) {
 			response.Executions = append(response.Executions, convertToExecutionInfo(record))
 			if len(response.Executions) == request.pageSize {
-				if idx != len(files) {
+				if idx != len(files)-1 {
 					newToken := &queryVisibilityToken{
 						LastCloseTime: record.CloseTimestamp,
 						LastRunID:     record.RunID,
@@ -237,6 +234,75 @@ func (v *visibilityArchiver) query(
 	return response, nil
 }
 
+// queryOrderedByStartTime reads all records in the close time range of the query, as start times are not part of the file names
+func (v *visibilityArchiver) queryOrderedByStartTime(
+	dirPath string,
+	files []string,
+	request *queryVisibilityRequest,
+	token *queryVisibilityToken,
+) (*archiver.QueryVisibilityResponse, error) {
+	files, err := sortAndFilterFiles(files, nil, request.parsedQuery)
+	if err != nil {
+		return nil, &types.InternalServiceError{Message: err.Error()}
+	}
+
+	var records []*visibilityRecord
+	for _, file := range files {
+		record, err := readVisibilityRecord(path.Join(dirPath, file))
+		if err != nil {
+			return nil, &types.InternalServiceError{Message: err.Error()}
+		}
+		if matchQuery(record, request.parsedQuery) {
+			records = append(records, record)
+		}
+	}
+
+	ascending := request.parsedQuery.ascending
+	less := func(a, b *visibilityRecord) bool {
+		if a.StartTimestamp == b.StartTimestamp {
+			return a.RunID < b.RunID
+		}
+		return a.StartTimestamp < b.StartTimestamp
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if ascending {
+			return less(records[i], records[j])
+		}
+		return less(records[j], records[i])
+	})
+
+	startIdx := 0
+	if token != nil {
+		last := &visibilityRecord{StartTimestamp: token.LastStartTime, RunID: token.LastRunID}
+		startIdx = sort.Search(len(records), func(i int) bool {
+			if ascending {
+				return less(last, records[i])
+			}
+			return less(records[i], last)
+		})
+	}
+	records = records[startIdx:]
+
+	response := &archiver.QueryVisibilityResponse{}
+	if len(records) > request.pageSize {
+		records = records[:request.pageSize]
+		last := records[len(records)-1]
+		encodedToken, err := serializeToken(&queryVisibilityToken{
+			LastCloseTime: last.CloseTimestamp,
+			LastRunID:     last.RunID,
+			LastStartTime: last.StartTimestamp,
+		})
+		if err != nil {
+			return nil, &types.InternalServiceError{Message: err.Error()}
+		}
+		response.NextPageToken = encodedToken
+	}
+	for _, record := range records {
+		response.Executions = append(response.Executions, convertToExecutionInfo(record))
+	}
+	return response, nil
+}
+
 func (v *visibilityArchiver) ValidateURI(URI archiver.URI) error {
 	if URI.Scheme() != URIScheme {
 		return archiver.ErrURISchemeMismatch
@@ -251,9 +317,9 @@ type parsedVisFilename struct {
 	hashedRunID string
 }
 
-// sortAndFilterFiles sort visibility record file names based on close timestamp (desc) and use hashed runID to break ties.
-// if a nextPageToken is give, it only returns filenames that have a smaller close timestamp
-func sortAndFilterFiles(filenames []string, token *queryVisibilityToken) ([]string, error) {
+// sortAndFilterFiles sort visibility record file names based on close timestamp (desc unless the query is ascending) and use hashed runID to break ties.
+// Only file names with a close timestamp in the range of the query are returned, and if a nextPageToken is given, only those after it
+func sortAndFilterFiles(filenames []string, token *queryVisibilityToken, query *parsedQuery) ([]string, error) {
 	var parsedFilenames []*parsedVisFilename
 	for _, name := range filenames {
 		pieces := strings.FieldsFunc(name, func(r rune) bool {
@@ -267,6 +333,9 @@ func sortAndFilterFiles(filenames []string, token *queryVisibilityToken) ([]stri
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse visibility filename %s", name)
 		}
+		if closeTime < query.earliestCloseTime || closeTime > query.latestCloseTime {
+			continue
+		}
 		parsedFilenames = append(parsedFilenames, &parsedVisFilename{
 			name:        name,
 			closeTime:   closeTime,
@@ -274,21 +343,28 @@ func sortAndFilterFiles(filenames []string, token *queryVisibilityToken) ([]stri
 		})
 	}
 
-	sort.Slice(parsedFilenames, func(i, j int) bool {
-		if parsedFilenames[i].closeTime == parsedFilenames[j].closeTime {
-			return parsedFilenames[i].hashedRunID > parsedFilenames[j].hashedRunID
+	// after reports whether a is ordered after b
+	after := func(a, b *parsedVisFilename) bool {
+		if a.closeTime != b.closeTime {
+			return (a.closeTime > b.closeTime) == query.ascending
 		}
-		return parsedFilenames[i].closeTime > parsedFilenames[j].closeTime
+		if a.hashedRunID != b.hashedRunID {
+			return (a.hashedRunID > b.hashedRunID) == query.ascending
+		}
+		return false
+	}
+	sort.Slice(parsedFilenames, func(i, j int) bool {
+		return after(parsedFilenames[j], parsedFilenames[i])
 	})
 
 	startIdx := 0
 	if token != nil {
-		LastHashedRunID := hash(token.LastRunID)
+		last := &parsedVisFilename{
+			closeTime:   token.LastCloseTime,
+			hashedRunID: hash(token.LastRunID),
+		}
 		startIdx = sort.Search(len(parsedFilenames), func(i int) bool {
-			if parsedFilenames[i].closeTime == token.LastCloseTime {
-				return parsedFilenames[i].hashedRunID < LastHashedRunID
-			}
-			return parsedFilenames[i].closeTime < token.LastCloseTime
+			return after(parsedFilenames[i], last)
 		})
 	}
 
@@ -303,6 +379,14 @@ func sortAndFilterFiles(filenames []string, token *queryVisibilityToken) ([]stri
 	return filteredFilenames, nil
 }
 
+func readVisibilityRecord(filepath string) (*visibilityRecord, error) {
+	encodedRecord, err := util.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+	return decodeVisibilityRecord(encodedRecord)
+}
+
 func matchQuery(record *visibilityRecord, query *parsedQuery) bool {
 	if record.CloseTimestamp < query.earliestCloseTime || record.CloseTimestamp > query.latestCloseTime {
 		return false
@@ -319,6 +403,9 @@ func matchQuery(record *visibilityRecord, query *parsedQuery) bool {
 	if query.closeStatus != nil && record.CloseStatus != *query.closeStatus {
 		return false
 	}
+	if query.filter != nil && !query.filter((*archiver.ArchiveVisibilityRequest)(record)) {
+		return false
+	}
 	return true
 }
 
@@ -25,6 +25,7 @@ import (
 	"encoding/json"
 	"errors"
 	"io/ioutil"
+	"math"
 	"os"
 	"path"
 	"testing"
@@ -287,6 +288,7 @@ func (s *visibilityArchiverSuite) TestSortAndFilterFiles() {
 	testCases := []struct {
 		filenames      []string
 		token          *queryVisibilityToken
+		query          *parsedQuery
 		expectedResult []string
 	}{
 		{
@@ -314,10 +316,41 @@ func (s *visibilityArchiverSuite) TestSortAndFilterFiles() {
 			},
 			expectedResult: []string{"5_0.vis"},
 		},
+		{
+			filenames: []string{"9_12345.vis", "5_0.vis", "9_54321.vis", "1000_654.vis", "1000_78.vis"},
+			query: &parsedQuery{
+				earliestCloseTime: 6,
+				latestCloseTime:   999,
+			},
+			expectedResult: []string{"9_54321.vis", "9_12345.vis"},
+		},
+		{
+			filenames: []string{"9_12345.vis", "5_0.vis", "9_54321.vis", "1000_654.vis", "1000_78.vis"},
+			query: &parsedQuery{
+				latestCloseTime: math.MaxInt64,
+				ascending:       true,
+			},
+			expectedResult: []string{"5_0.vis", "9_12345.vis", "9_54321.vis", "1000_654.vis", "1000_78.vis"},
+		},
+		{
+			filenames: []string{"9_12345.vis", "5_0.vis", "9_54321.vis", "1000_654.vis", "1000_78.vis"},
+			token: &queryVisibilityToken{
+				LastCloseTime: 10,
+			},
+			query: &parsedQuery{
+				latestCloseTime: math.MaxInt64,
+				ascending:       true,
+			},
+			expectedResult: []string{"1000_654.vis", "1000_78.vis"},
+		},
 	}
 
 	for _, tc := range testCases {
-		result, err := sortAndFilterFiles(tc.filenames, tc.token)
+		query := tc.query
+		if query == nil {
+			query = &parsedQuery{latestCloseTime: math.MaxInt64}
+		}
+		result, err := sortAndFilterFiles(tc.filenames, tc.token, query)
 		s.NoError(err)
 		s.Equal(tc.expectedResult, result)
 	}
@@ -491,6 +524,111 @@ func (s *visibilityArchiverSuite) TestArchiveAndQuery() {
 	s.Equal(convertToExecutionInfo(s.visibilityRecords[1]), executions[1])
 }
 
+func (s *visibilityArchiverSuite) TestArchiveAndRichQuery() {
+	dir, err := ioutil.TempDir("", "TestArchiveAndRichQuery")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+
+	records := []*archiver.ArchiveVisibilityRequest{
+		{
+			DomainID:         testDomainID,
+			DomainName:       testDomainName,
+			WorkflowID:       "order-1",
+			RunID:            "run-1",
+			WorkflowTypeName: "order-workflow",
+			StartTimestamp:   100,
+			CloseTimestamp:   1000,
+			CloseStatus:      types.WorkflowExecutionCloseStatusFailed,
+			HistoryLength:    10,
+			SearchAttributes: map[string]string{"CustomerID": `"customer-1"`, "Amount": "42"},
+		},
+		{
+			DomainID:         testDomainID,
+			DomainName:       testDomainName,
+			WorkflowID:       "order-2",
+			RunID:            "run-2",
+			WorkflowTypeName: "order-workflow",
+			StartTimestamp:   300,
+			CloseTimestamp:   2000,
+			CloseStatus:      types.WorkflowExecutionCloseStatusCompleted,
+			HistoryLength:    20,
+			SearchAttributes: map[string]string{"CustomerID": `"customer-2"`, "Amount": "7"},
+		},
+		{
+			DomainID:         testDomainID,
+			DomainName:       testDomainName,
+			WorkflowID:       "payment-1",
+			RunID:            "run-3",
+			WorkflowTypeName: "payment-workflow",
+			StartTimestamp:   200,
+			CloseTimestamp:   3000,
+			CloseStatus:      types.WorkflowExecutionCloseStatusTimedOut,
+			HistoryLength:    30,
+		},
+	}
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	URI, err := archiver.NewURI("file://" + dir)
+	s.NoError(err)
+	for _, record := range records {
+		s.NoError(visibilityArchiver.Archive(context.Background(), URI, record))
+	}
+
+	testCases := []struct {
+		query       string
+		expectedIDs []string
+	}{
+		{
+			query:       "CloseStatus = 'Failed' or CloseStatus = 'TimedOut'",
+			expectedIDs: []string{"payment-1", "order-1"},
+		},
+		{
+			query:       "WorkflowID IN ('order-1', 'payment-1') AND NOT (CloseTime > 2500)",
+			expectedIDs: []string{"order-1"},
+		},
+		{
+			query:       "WorkflowType LIKE 'order%' ORDER BY CloseTime ASC",
+			expectedIDs: []string{"order-1", "order-2"},
+		},
+		{
+			query:       "CustomerID = 'customer-2' or Amount >= 40",
+			expectedIDs: []string{"order-2", "order-1"},
+		},
+		{
+			query:       "CustomerID IS NULL",
+			expectedIDs: []string{"payment-1"},
+		},
+		{
+			query:       "StartTime >= 150 ORDER BY StartTime",
+			expectedIDs: []string{"payment-1", "order-2"},
+		},
+		{
+			query:       "HistoryLength BETWEEN 10 AND 20 ORDER BY StartTime DESC",
+			expectedIDs: []string{"order-2", "order-1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		request := &archiver.QueryVisibilityRequest{
+			DomainID: testDomainID,
+			PageSize: 1,
+			Query:    tc.query,
+		}
+		var workflowIDs []string
+		for len(workflowIDs) == 0 || request.NextPageToken != nil {
+			response, err := visibilityArchiver.Query(context.Background(), URI, request)
+			s.NoError(err, tc.query)
+			for _, execution := range response.Executions {
+				workflowIDs = append(workflowIDs, execution.Execution.GetWorkflowID())
+			}
+			request.NextPageToken = response.NextPageToken
+			if len(response.Executions) == 0 {
+				break
+			}
+		}
+		s.Equal(tc.expectedIDs, workflowIDs, tc.query)
+	}
+}
+
 func (s *visibilityArchiverSuite) newTestVisibilityArchiver() *visibilityArchiver {
 	config := &config.FilestoreArchiver{
 		FileMode: testFileModeStr,
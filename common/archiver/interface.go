@@ -121,4 +121,10 @@ type (
 		Query(context.Context, URI, *QueryVisibilityRequest) (*QueryVisibilityResponse, error)
 		ValidateURI(URI) error
 	}
+
+	// Stoppable is implemented by history and visibility archivers which run work in the background,
+	// Stop blocks until that work is stopped
+	Stoppable interface {
+		Stop()
+	}
 )
@@ -0,0 +1,628 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// The codec writes flat tables as parquet files with one PLAIN encoded and uncompressed data page
+// per column and row group. This is the most basic layout defined by the format and can be read
+// by all common parquet readers. The reader accepts any file with a flat schema and
+// PLAIN encoded, uncompressed v1 data pages, which covers files written by this codec.
+
+const (
+	parquetMagic   = "PAR1"
+	parquetVersion = 1
+	createdBy      = "cadence archiver"
+)
+
+// parquet physical types, repetition types, encodings and page types, see parquet.thrift
+const (
+	physicalTypeInt64     = 2
+	physicalTypeByteArray = 6
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecUncompressed = 0
+
+	pageTypeData = 0
+
+	convertedTypeUTF8 = 0
+
+	logicalTypeString    = 1
+	logicalTypeTimestamp = 8
+	timeUnitNanos        = 3
+)
+
+const (
+	columnTypeString columnType = iota
+	columnTypeInt64
+	// columnTypeTimestamp is stored as INT64 nanoseconds since epoch in UTC
+	columnTypeTimestamp
+)
+
+var (
+	errNotParquetFile    = errors.New("data is not a parquet file")
+	errUnsupportedFormat = errors.New("unsupported parquet file layout")
+)
+
+type (
+	columnType int
+
+	column struct {
+		name     string
+		typ      columnType
+		optional bool
+	}
+
+	// row holds one value per column, values are string for string columns, int64 for int64 and
+	// timestamp columns and nil for null values of optional columns
+	row []interface{}
+)
+
+// encodeTable encodes the row groups as a parquet file with the given schema
+func encodeTable(columns []column, rowGroups ...[]row) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString(parquetMagic)
+
+	var numRows int64
+	encodedRowGroups := make([][]byte, 0, len(rowGroups))
+	for _, rows := range rowGroups {
+		rowGroup, err := encodeRowGroup(buf, columns, rows)
+		if err != nil {
+			return nil, err
+		}
+		encodedRowGroups = append(encodedRowGroups, rowGroup)
+		numRows += int64(len(rows))
+	}
+
+	footer := &compactWriter{}
+	footer.structBegin()
+	footer.i32Field(1, parquetVersion)
+	footer.listField(2, compactStruct, len(columns)+1)
+	footer.structBegin()
+	footer.stringField(4, "schema")
+	footer.i32Field(5, int32(len(columns)))
+	footer.structEnd()
+	for _, col := range columns {
+		writeSchemaElement(footer, col)
+	}
+	footer.i64Field(3, numRows)
+	footer.listField(4, compactStruct, len(encodedRowGroups))
+	for _, rowGroup := range encodedRowGroups {
+		footer.buf.Write(rowGroup)
+	}
+	footer.stringField(6, createdBy)
+	footer.structEnd()
+
+	buf.Write(footer.buf.Bytes())
+	var footerLength [4]byte
+	binary.LittleEndian.PutUint32(footerLength[:], uint32(footer.buf.Len()))
+	buf.Write(footerLength[:])
+	buf.WriteString(parquetMagic)
+	return buf.Bytes(), nil
+}
+
+// encodeRowGroup appends the column chunks of the rows to buf and returns the encoded row group metadata
+func encodeRowGroup(buf *bytes.Buffer, columns []column, rows []row) ([]byte, error) {
+	chunks := &compactWriter{}
+	var totalSize int64
+	for idx, col := range columns {
+		page, err := encodeDataPage(col, idx, rows)
+		if err != nil {
+			return nil, err
+		}
+		header := &compactWriter{}
+		header.structBegin()
+		header.i32Field(1, pageTypeData)
+		header.i32Field(2, int32(len(page)))
+		header.i32Field(3, int32(len(page)))
+		header.structField(5, func() {
+			header.i32Field(1, int32(len(rows)))
+			header.i32Field(2, encodingPlain)
+			header.i32Field(3, encodingRLE)
+			header.i32Field(4, encodingRLE)
+		})
+		header.structEnd()
+
+		offset := int64(buf.Len())
+		size := int64(header.buf.Len() + len(page))
+		totalSize += size
+		buf.Write(header.buf.Bytes())
+		buf.Write(page)
+
+		chunks.structBegin()
+		chunks.i64Field(2, offset)
+		chunks.structField(3, func() {
+			chunks.i32Field(1, physicalType(col.typ))
+			chunks.listField(2, compactI32, 2)
+			chunks.writeVarint(encodingPlain)
+			chunks.writeVarint(encodingRLE)
+			chunks.listField(3, compactBinary, 1)
+			chunks.writeString(col.name)
+			chunks.i32Field(4, codecUncompressed)
+			chunks.i64Field(5, int64(len(rows)))
+			chunks.i64Field(6, size)
+			chunks.i64Field(7, size)
+			chunks.i64Field(9, offset)
+		})
+		chunks.structEnd()
+	}
+
+	rowGroup := &compactWriter{}
+	rowGroup.structBegin()
+	rowGroup.listField(1, compactStruct, len(columns))
+	rowGroup.buf.Write(chunks.buf.Bytes())
+	rowGroup.i64Field(2, totalSize)
+	rowGroup.i64Field(3, int64(len(rows)))
+	rowGroup.structEnd()
+	return rowGroup.buf.Bytes(), nil
+}
+
+func encodeDataPage(col column, idx int, rows []row) ([]byte, error) {
+	page := &bytes.Buffer{}
+	if col.optional {
+		levels := make([]bool, len(rows))
+		for i, r := range rows {
+			levels[i] = r[idx] != nil
+		}
+		encoded := encodeDefinitionLevels(levels)
+		var length [4]byte
+		binary.LittleEndian.PutUint32(length[:], uint32(len(encoded)))
+		page.Write(length[:])
+		page.Write(encoded)
+	}
+
+	var scratch [8]byte
+	for _, r := range rows {
+		switch value := r[idx].(type) {
+		case nil:
+			if !col.optional {
+				return nil, fmt.Errorf("null value for required column %s", col.name)
+			}
+		case string:
+			if col.typ != columnTypeString {
+				return nil, fmt.Errorf("unexpected string value for column %s", col.name)
+			}
+			binary.LittleEndian.PutUint32(scratch[:4], uint32(len(value)))
+			page.Write(scratch[:4])
+			page.WriteString(value)
+		case int64:
+			if col.typ == columnTypeString {
+				return nil, fmt.Errorf("unexpected integer value for column %s", col.name)
+			}
+			binary.LittleEndian.PutUint64(scratch[:], uint64(value))
+			page.Write(scratch[:])
+		default:
+			return nil, fmt.Errorf("unsupported value type %T for column %s", value, col.name)
+		}
+	}
+	return page.Bytes(), nil
+}
+
+func writeSchemaElement(w *compactWriter, col column) {
+	w.structBegin()
+	w.i32Field(1, physicalType(col.typ))
+	if col.optional {
+		w.i32Field(3, repetitionOptional)
+	} else {
+		w.i32Field(3, repetitionRequired)
+	}
+	w.stringField(4, col.name)
+	switch col.typ {
+	case columnTypeString:
+		w.i32Field(6, convertedTypeUTF8)
+		w.structField(10, func() {
+			w.structField(logicalTypeString, func() {})
+		})
+	case columnTypeTimestamp:
+		w.structField(10, func() {
+			w.structField(logicalTypeTimestamp, func() {
+				w.boolField(1, true)
+				w.structField(2, func() {
+					w.structField(timeUnitNanos, func() {})
+				})
+			})
+		})
+	}
+	w.structEnd()
+}
+
+func physicalType(typ columnType) int32 {
+	if typ == columnTypeString {
+		return physicalTypeByteArray
+	}
+	return physicalTypeInt64
+}
+
+// encodeDefinitionLevels encodes levels of bit width 1 with the RLE part of the RLE/bit-packing hybrid
+func encodeDefinitionLevels(levels []bool) []byte {
+	buf := &bytes.Buffer{}
+	var scratch [binary.MaxVarintLen64]byte
+	for start := 0; start < len(levels); {
+		end := start + 1
+		for end < len(levels) && levels[end] == levels[start] {
+			end++
+		}
+		n := binary.PutUvarint(scratch[:], uint64(end-start)<<1)
+		buf.Write(scratch[:n])
+		if levels[start] {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+		start = end
+	}
+	return buf.Bytes()
+}
+
+// decodeTable reads the given columns from a parquet file, columns are looked up by name and
+// optional columns missing from the file are read as nulls
+func decodeTable(data []byte, columns []column) ([]row, error) {
+	metadata, err := readFileMetadata(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	if metadata.numRows < 0 || metadata.numRows > int64(len(data)) {
+		return nil, errNotParquetFile
+	}
+	rows := make([]row, 0, metadata.numRows)
+	for idx := range metadata.rowGroups {
+		rowGroupRows, err := metadata.readRowGroup(data, 0, idx, columns)
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(rows)+len(rowGroupRows)) > metadata.numRows {
+			return nil, errNotParquetFile
+		}
+		rows = append(rows, rowGroupRows...)
+	}
+	if int64(len(rows)) != metadata.numRows {
+		return nil, errNotParquetFile
+	}
+	return rows, nil
+}
+
+// decodeRowGroup reads the given columns of a single row group, only the footer and the column
+// chunks of the row group are read from the file
+func decodeRowGroup(file io.ReaderAt, size int64, columns []column, rowGroupIdx int) ([]row, error) {
+	metadata, err := readFileMetadata(file, size)
+	if err != nil {
+		return nil, err
+	}
+	if rowGroupIdx < 0 || rowGroupIdx >= len(metadata.rowGroups) {
+		return nil, fmt.Errorf("row group %v not found in parquet file", rowGroupIdx)
+	}
+	start, end := int64(math.MaxInt64), int64(0)
+	for _, c := range metadata.rowGroups[rowGroupIdx].list(1) {
+		chunk, ok := c.(thriftStruct)
+		if !ok {
+			return nil, errNotParquetFile
+		}
+		chunkMetadata, ok := chunk.strct(3)
+		if !ok {
+			return nil, errNotParquetFile
+		}
+		offset, _ := chunkMetadata.int64(9)
+		chunkSize, _ := chunkMetadata.int64(7)
+		if offset < int64(len(parquetMagic)) || chunkSize < 0 || offset+chunkSize > size {
+			return nil, errNotParquetFile
+		}
+		if offset < start {
+			start = offset
+		}
+		if offset+chunkSize > end {
+			end = offset + chunkSize
+		}
+	}
+	if start > end {
+		return metadata.readRowGroup(nil, 0, rowGroupIdx, columns)
+	}
+	data := make([]byte, end-start)
+	if _, err := file.ReadAt(data, start); err != nil {
+		return nil, err
+	}
+	return metadata.readRowGroup(data, start, rowGroupIdx, columns)
+}
+
+type fileMetadata struct {
+	columns   map[string]fileColumn
+	rowGroups []thriftStruct
+	numRows   int64
+}
+
+func readFileMetadata(file io.ReaderAt, size int64) (*fileMetadata, error) {
+	if size < 2*int64(len(parquetMagic))+4 {
+		return nil, errNotParquetFile
+	}
+	var magic [len(parquetMagic)]byte
+	if _, err := file.ReadAt(magic[:], 0); err != nil {
+		return nil, err
+	}
+	var tail [4 + len(parquetMagic)]byte
+	if _, err := file.ReadAt(tail[:], size-int64(len(tail))); err != nil {
+		return nil, err
+	}
+	if string(magic[:]) != parquetMagic || string(tail[4:]) != parquetMagic {
+		return nil, errNotParquetFile
+	}
+	footerEnd := size - int64(len(tail))
+	footerLength := int64(binary.LittleEndian.Uint32(tail[:4]))
+	if footerLength > footerEnd-int64(len(parquetMagic)) {
+		return nil, errNotParquetFile
+	}
+	footer := make([]byte, footerLength)
+	if _, err := file.ReadAt(footer, footerEnd-footerLength); err != nil {
+		return nil, err
+	}
+	metadata, err := (&compactReader{data: footer}).readStruct()
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := readSchema(metadata)
+	if err != nil {
+		return nil, err
+	}
+	var rowGroups []thriftStruct
+	for _, rg := range metadata.list(4) {
+		rowGroup, ok := rg.(thriftStruct)
+		if !ok {
+			return nil, errNotParquetFile
+		}
+		rowGroups = append(rowGroups, rowGroup)
+	}
+	numRows, _ := metadata.int64(3)
+	return &fileMetadata{
+		columns:   columns,
+		rowGroups: rowGroups,
+		numRows:   numRows,
+	}, nil
+}
+
+// readRowGroup decodes a row group from data, which holds the file contents starting at offset base
+func (m *fileMetadata) readRowGroup(data []byte, base int64, rowGroupIdx int, columns []column) ([]row, error) {
+	rowGroup := m.rowGroups[rowGroupIdx]
+	// every row of the schemas used by the archivers takes at least one byte, which bounds the
+	// allocation below for corrupted files
+	numRows, _ := rowGroup.int64(3)
+	if numRows < 0 || numRows > int64(len(data)) {
+		return nil, errNotParquetFile
+	}
+	rows := make([]row, numRows)
+	for i := range rows {
+		rows[i] = make(row, len(columns))
+	}
+
+	chunks := rowGroup.list(1)
+	for idx, col := range columns {
+		fileColumn, ok := m.columns[col.name]
+		if !ok {
+			if !col.optional {
+				return nil, fmt.Errorf("column %s not found in parquet file", col.name)
+			}
+			continue
+		}
+		if fileColumn.typ != physicalType(col.typ) {
+			return nil, fmt.Errorf("unexpected physical type of column %s", col.name)
+		}
+		if fileColumn.idx >= len(chunks) {
+			return nil, errNotParquetFile
+		}
+		chunk, ok := chunks[fileColumn.idx].(thriftStruct)
+		if !ok {
+			return nil, errNotParquetFile
+		}
+		values, err := readColumnChunk(data, base, chunk, numRows, fileColumn.optional, col.typ)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read column %s: %v", col.name, err)
+		}
+		for i, value := range values {
+			rows[i][idx] = value
+		}
+	}
+	return rows, nil
+}
+
+type fileColumn struct {
+	idx      int
+	typ      int32
+	optional bool
+}
+
+func readSchema(metadata thriftStruct) (map[string]fileColumn, error) {
+	schema := metadata.list(2)
+	if len(schema) == 0 {
+		return nil, errNotParquetFile
+	}
+	columns := make(map[string]fileColumn, len(schema)-1)
+	for idx, elem := range schema[1:] {
+		element, ok := elem.(thriftStruct)
+		if !ok {
+			return nil, errNotParquetFile
+		}
+		if children, _ := element.int64(5); children > 0 {
+			return nil, errUnsupportedFormat
+		}
+		repetition, _ := element.int64(3)
+		if repetition != repetitionRequired && repetition != repetitionOptional {
+			return nil, errUnsupportedFormat
+		}
+		typ, _ := element.int64(1)
+		columns[element.string(4)] = fileColumn{
+			idx:      idx,
+			typ:      int32(typ),
+			optional: repetition == repetitionOptional,
+		}
+	}
+	return columns, nil
+}
+
+func readColumnChunk(data []byte, base int64, chunk thriftStruct, numRows int64, optional bool, typ columnType) ([]interface{}, error) {
+	metadata, ok := chunk.strct(3)
+	if !ok {
+		return nil, errNotParquetFile
+	}
+	if codec, _ := metadata.int64(4); codec != codecUncompressed {
+		return nil, errUnsupportedFormat
+	}
+	if _, ok := metadata.int64(11); ok {
+		// dictionary page offset
+		return nil, errUnsupportedFormat
+	}
+	if numValues, _ := metadata.int64(5); numValues != numRows {
+		return nil, errNotParquetFile
+	}
+	offset, _ := metadata.int64(9)
+	offset -= base
+
+	var values []interface{}
+	for int64(len(values)) < numRows {
+		if offset < 0 || offset >= int64(len(data)) {
+			return nil, errNotParquetFile
+		}
+		reader := &compactReader{data: data[offset:]}
+		header, err := reader.readStruct()
+		if err != nil {
+			return nil, err
+		}
+		if pageType, _ := header.int64(1); pageType != pageTypeData {
+			return nil, errUnsupportedFormat
+		}
+		pageSize, _ := header.int64(3)
+		pageStart := offset + int64(reader.pos)
+		if pageSize < 0 || pageStart+pageSize > int64(len(data)) {
+			return nil, errNotParquetFile
+		}
+		dataPageHeader, ok := header.strct(5)
+		if !ok {
+			return nil, errNotParquetFile
+		}
+		if encoding, _ := dataPageHeader.int64(2); encoding != encodingPlain {
+			return nil, errUnsupportedFormat
+		}
+		pageValues, _ := dataPageHeader.int64(1)
+		if pageValues <= 0 || pageValues > numRows-int64(len(values)) {
+			return nil, errNotParquetFile
+		}
+		pageData := data[pageStart : pageStart+pageSize]
+		decoded, err := decodeDataPage(pageData, pageValues, optional, typ)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, decoded...)
+		offset = pageStart + pageSize
+	}
+	return values, nil
+}
+
+func decodeDataPage(page []byte, numValues int64, optional bool, typ columnType) ([]interface{}, error) {
+	defined := make([]bool, numValues)
+	if optional {
+		if len(page) < 4 {
+			return nil, errNotParquetFile
+		}
+		length := binary.LittleEndian.Uint32(page)
+		if uint64(length) > uint64(len(page)-4) {
+			return nil, errNotParquetFile
+		}
+		if err := decodeDefinitionLevels(page[4:4+length], defined); err != nil {
+			return nil, err
+		}
+		page = page[4+length:]
+	} else {
+		for i := range defined {
+			defined[i] = true
+		}
+	}
+
+	values := make([]interface{}, numValues)
+	for i := range values {
+		if !defined[i] {
+			continue
+		}
+		if typ == columnTypeString {
+			if len(page) < 4 {
+				return nil, errNotParquetFile
+			}
+			length := binary.LittleEndian.Uint32(page)
+			if uint64(length) > uint64(len(page)-4) {
+				return nil, errNotParquetFile
+			}
+			values[i] = string(page[4 : 4+length])
+			page = page[4+length:]
+		} else {
+			if len(page) < 8 {
+				return nil, errNotParquetFile
+			}
+			values[i] = int64(binary.LittleEndian.Uint64(page))
+			page = page[8:]
+		}
+	}
+	return values, nil
+}
+
+// decodeDefinitionLevels decodes levels of bit width 1 encoded with the RLE/bit-packing hybrid
+func decodeDefinitionLevels(data []byte, levels []bool) error {
+	for idx := 0; idx < len(levels); {
+		header, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errNotParquetFile
+		}
+		data = data[n:]
+		if header&1 == 0 {
+			// RLE run, the value takes one byte for bit width 1
+			if len(data) < 1 {
+				return errNotParquetFile
+			}
+			count := header >> 1
+			for ; count > 0 && idx < len(levels); count-- {
+				levels[idx] = data[0] != 0
+				idx++
+			}
+			data = data[1:]
+		} else {
+			// bit-packed groups of 8 values, one byte per group for bit width 1
+			groups := header >> 1
+			if groups > uint64(len(data)) {
+				return errNotParquetFile
+			}
+			for _, b := range data[:groups] {
+				for bit := 0; bit < 8 && idx < len(levels); bit++ {
+					levels[idx] = b&(1<<bit) != 0
+					idx++
+				}
+			}
+			data = data[groups:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package parquet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testColumns = []column{
+	{name: "id", typ: columnTypeInt64},
+	{name: "name", typ: columnTypeString},
+	{name: "time", typ: columnTypeTimestamp},
+	{name: "comment", typ: columnTypeString, optional: true},
+	{name: "count", typ: columnTypeInt64, optional: true},
+}
+
+func TestEncodeDecodeTable(t *testing.T) {
+	var rows []row
+	for i := 0; i < 50; i++ {
+		var comment, count interface{}
+		if i%3 == 0 {
+			comment = "comment"
+		}
+		if i > 20 {
+			count = int64(-i)
+		}
+		rows = append(rows, row{int64(i), "name", int64(1647266966000000000 + i), comment, count})
+	}
+	rows = append(rows, row{int64(-1), "", int64(0), "", int64(0)})
+
+	data, err := encodeTable(testColumns, rows)
+	require.NoError(t, err)
+	decoded, err := decodeTable(data, testColumns)
+	require.NoError(t, err)
+	require.Equal(t, rows, decoded)
+
+	// columns are looked up by name, missing optional ones are read as nulls
+	decoded, err = decodeTable(data, []column{
+		{name: "missing", typ: columnTypeString, optional: true},
+		{name: "name", typ: columnTypeString},
+	})
+	require.NoError(t, err)
+	require.Len(t, decoded, len(rows))
+	require.Equal(t, row{nil, "name"}, decoded[0])
+
+	_, err = decodeTable(data, []column{{name: "missing", typ: columnTypeString}})
+	require.Error(t, err)
+	_, err = decodeTable(data, []column{{name: "name", typ: columnTypeInt64}})
+	require.Error(t, err)
+}
+
+func TestEncodeDecodeTable_Empty(t *testing.T) {
+	data, err := encodeTable(testColumns, nil)
+	require.NoError(t, err)
+	decoded, err := decodeTable(data, testColumns)
+	require.NoError(t, err)
+	require.Empty(t, decoded)
+}
+
+func TestEncodeDecodeTable_RowGroups(t *testing.T) {
+	rowGroups := [][]row{
+		{{int64(1), "first", int64(1), nil, int64(1)}, {int64(2), "first", int64(2), "comment", nil}},
+		nil,
+		{{int64(3), "third", int64(3), nil, nil}},
+	}
+	data, err := encodeTable(testColumns, rowGroups...)
+	require.NoError(t, err)
+	decoded, err := decodeTable(data, testColumns)
+	require.NoError(t, err)
+	require.Equal(t, append(rowGroups[0], rowGroups[2]...), decoded)
+
+	for idx, rows := range rowGroups {
+		decoded, err := decodeRowGroup(bytes.NewReader(data), int64(len(data)), testColumns, idx)
+		require.NoError(t, err)
+		require.Equal(t, len(rows), len(decoded))
+		for i := range rows {
+			require.Equal(t, rows[i], decoded[i])
+		}
+	}
+	_, err = decodeRowGroup(bytes.NewReader(data), int64(len(data)), testColumns, len(rowGroups))
+	require.Error(t, err)
+	_, err = decodeRowGroup(bytes.NewReader(data[:len(data)-1]), int64(len(data)-1), testColumns, 0)
+	require.Equal(t, errNotParquetFile, err)
+}
+
+func TestEncodeTable_InvalidValues(t *testing.T) {
+	testCases := []row{
+		{nil, "name", int64(0), nil, nil},
+		{int64(0), int64(0), int64(0), nil, nil},
+		{int64(0), "name", "time", nil, nil},
+		{int64(0), "name", int64(0), nil, 1},
+	}
+	for _, r := range testCases {
+		_, err := encodeTable(testColumns, []row{r})
+		require.Error(t, err)
+	}
+}
+
+func TestDecodeTable_Corrupted(t *testing.T) {
+	data, err := encodeTable(testColumns, []row{{int64(1), "name", int64(2), "comment", int64(3)}})
+	require.NoError(t, err)
+
+	_, err = decodeTable(nil, testColumns)
+	require.Equal(t, errNotParquetFile, err)
+	_, err = decodeTable([]byte("PAR1xxxxPAR1"), testColumns)
+	require.Error(t, err)
+	for i := len(parquetMagic); i < len(data)-len(parquetMagic); i++ {
+		corrupted := append([]byte{}, data...)
+		corrupted[i] ^= 0xff
+		// must not panic, the result may or may not be an error depending on the byte
+		decodeTable(corrupted, testColumns) //nolint:errcheck
+	}
+	for i := len(parquetMagic); i < len(data)-len(parquetMagic); i++ {
+		_, err := decodeTable(append(data[:i:i], parquetMagic...), testColumns)
+		require.Error(t, err)
+	}
+}
+
+func TestDecodeDefinitionLevels(t *testing.T) {
+	levels := make([]bool, 20)
+	// bit-packed group of 8 values, two RLE runs of 5 and 7 values
+	err := decodeDefinitionLevels([]byte{0x03, 0xa5, 0x0a, 0x01, 0x0e, 0x00}, levels)
+	require.NoError(t, err)
+	require.Equal(t, []bool{
+		true, false, true, false, false, true, false, true,
+		true, true, true, true, true,
+		false, false, false, false, false, false, false,
+	}, levels)
+
+	decoded := make([]bool, len(levels))
+	require.NoError(t, decodeDefinitionLevels(encodeDefinitionLevels(levels), decoded))
+	require.Equal(t, levels, decoded)
+
+	require.Error(t, decodeDefinitionLevels([]byte{0x0a}, levels))
+	require.Error(t, decodeDefinitionLevels([]byte{0x05, 0x01}, levels))
+}
@@ -0,0 +1,329 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Parquet page headers and file footers are thrift structs serialized with the compact protocol.
+// Only the small subset needed to write and read back flat tables is implemented here, the thrift
+// library vendored by this repo predates the compact protocol API parquet libraries depend on.
+
+const (
+	compactStop   byte = 0
+	compactTrue   byte = 1
+	compactFalse  byte = 2
+	compactByte   byte = 3
+	compactI16    byte = 4
+	compactI32    byte = 5
+	compactI64    byte = 6
+	compactDouble byte = 7
+	compactBinary byte = 8
+	compactList   byte = 9
+	compactSet    byte = 10
+	compactMap    byte = 11
+	compactStruct byte = 12
+)
+
+var errCorruptedThrift = errors.New("corrupted thrift compact data")
+
+type (
+	compactWriter struct {
+		buf          bytes.Buffer
+		lastFieldID  int16
+		parentFields []int16
+	}
+
+	compactReader struct {
+		data []byte
+		pos  int
+	}
+
+	// thriftStruct holds a decoded struct keyed by field id, values are bool, int64, float64, []byte,
+	// []interface{} for lists and sets, and thriftStruct for nested structs
+	thriftStruct map[int16]interface{}
+)
+
+func (w *compactWriter) structBegin() {
+	w.parentFields = append(w.parentFields, w.lastFieldID)
+	w.lastFieldID = 0
+}
+
+func (w *compactWriter) structEnd() {
+	w.buf.WriteByte(compactStop)
+	w.lastFieldID = w.parentFields[len(w.parentFields)-1]
+	w.parentFields = w.parentFields[:len(w.parentFields)-1]
+}
+
+func (w *compactWriter) fieldBegin(id int16, fieldType byte) {
+	if delta := id - w.lastFieldID; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		w.buf.WriteByte(fieldType)
+		w.writeVarint(int64(id))
+	}
+	w.lastFieldID = id
+}
+
+func (w *compactWriter) i32Field(id int16, value int32) {
+	w.fieldBegin(id, compactI32)
+	w.writeVarint(int64(value))
+}
+
+func (w *compactWriter) i64Field(id int16, value int64) {
+	w.fieldBegin(id, compactI64)
+	w.writeVarint(value)
+}
+
+func (w *compactWriter) boolField(id int16, value bool) {
+	if value {
+		w.fieldBegin(id, compactTrue)
+	} else {
+		w.fieldBegin(id, compactFalse)
+	}
+}
+
+func (w *compactWriter) stringField(id int16, value string) {
+	w.fieldBegin(id, compactBinary)
+	w.writeString(value)
+}
+
+// structField writes a nested struct, the fields of which are written by writeFields
+func (w *compactWriter) structField(id int16, writeFields func()) {
+	w.fieldBegin(id, compactStruct)
+	w.structBegin()
+	writeFields()
+	w.structEnd()
+}
+
+func (w *compactWriter) listField(id int16, elemType byte, size int) {
+	w.fieldBegin(id, compactList)
+	w.listBegin(elemType, size)
+}
+
+func (w *compactWriter) listBegin(elemType byte, size int) {
+	if size < 15 {
+		w.buf.WriteByte(byte(size)<<4 | elemType)
+		return
+	}
+	w.buf.WriteByte(0xf0 | elemType)
+	w.writeUvarint(uint64(size))
+}
+
+func (w *compactWriter) writeString(value string) {
+	w.writeUvarint(uint64(len(value)))
+	w.buf.WriteString(value)
+}
+
+func (w *compactWriter) writeVarint(value int64) {
+	// zigzag encoding
+	w.writeUvarint(uint64(value<<1) ^ uint64(value>>63))
+}
+
+func (w *compactWriter) writeUvarint(value uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], value)
+	w.buf.Write(buf[:n])
+}
+
+func (r *compactReader) readStruct() (thriftStruct, error) {
+	result := thriftStruct{}
+	var lastFieldID int16
+	for {
+		header, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+		fieldType := header & 0x0f
+		if fieldType == compactStop {
+			return result, nil
+		}
+		if delta := int16(header >> 4); delta != 0 {
+			lastFieldID += delta
+		} else {
+			id, err := r.readVarint()
+			if err != nil {
+				return nil, err
+			}
+			lastFieldID = int16(id)
+		}
+
+		var value interface{}
+		switch fieldType {
+		case compactTrue:
+			value = true
+		case compactFalse:
+			value = false
+		default:
+			if value, err = r.readValue(fieldType); err != nil {
+				return nil, err
+			}
+		}
+		result[lastFieldID] = value
+	}
+}
+
+func (r *compactReader) readValue(valueType byte) (interface{}, error) {
+	switch valueType {
+	case compactTrue, compactFalse:
+		// booleans inside of collections are encoded as one byte
+		b, err := r.readByte()
+		return b == compactTrue, err
+	case compactByte:
+		b, err := r.readByte()
+		return int64(int8(b)), err
+	case compactI16, compactI32, compactI64:
+		return r.readVarint()
+	case compactDouble:
+		if r.pos+8 > len(r.data) {
+			return nil, errCorruptedThrift
+		}
+		value := math.Float64frombits(binary.LittleEndian.Uint64(r.data[r.pos:]))
+		r.pos += 8
+		return value, nil
+	case compactBinary:
+		size, err := r.readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		if size > uint64(len(r.data)-r.pos) {
+			return nil, errCorruptedThrift
+		}
+		value := r.data[r.pos : r.pos+int(size)]
+		r.pos += int(size)
+		return value, nil
+	case compactList, compactSet:
+		return r.readList()
+	case compactMap:
+		return r.readMap()
+	case compactStruct:
+		return r.readStruct()
+	default:
+		return nil, fmt.Errorf("unknown thrift compact type %d", valueType)
+	}
+}
+
+func (r *compactReader) readList() ([]interface{}, error) {
+	header, err := r.readByte()
+	if err != nil {
+		return nil, err
+	}
+	elemType := header & 0x0f
+	size := uint64(header >> 4)
+	if size == 15 {
+		if size, err = r.readUvarint(); err != nil {
+			return nil, err
+		}
+	}
+	if size > uint64(len(r.data)-r.pos) {
+		// every element takes at least one byte
+		return nil, errCorruptedThrift
+	}
+	list := make([]interface{}, 0, size)
+	for i := uint64(0); i < size; i++ {
+		elem, err := r.readValue(elemType)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, elem)
+	}
+	return list, nil
+}
+
+// readMap decodes a map into a list of alternating keys and values, maps are not used by the parquet
+// structs read by this package but need to be skipped over when present
+func (r *compactReader) readMap() ([]interface{}, error) {
+	size, err := r.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		return nil, nil
+	}
+	if size > uint64(len(r.data)-r.pos) {
+		return nil, errCorruptedThrift
+	}
+	types, err := r.readByte()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]interface{}, 0, 2*size)
+	for i := uint64(0); i < size; i++ {
+		key, err := r.readValue(types >> 4)
+		if err != nil {
+			return nil, err
+		}
+		value, err := r.readValue(types & 0x0f)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, key, value)
+	}
+	return entries, nil
+}
+
+func (r *compactReader) readByte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, errCorruptedThrift
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *compactReader) readVarint() (int64, error) {
+	value, err := r.readUvarint()
+	return int64(value>>1) ^ -int64(value&1), err
+}
+
+func (r *compactReader) readUvarint() (uint64, error) {
+	value, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		return 0, errCorruptedThrift
+	}
+	r.pos += n
+	return value, nil
+}
+
+func (s thriftStruct) int64(id int16) (int64, bool) {
+	value, ok := s[id].(int64)
+	return value, ok
+}
+
+func (s thriftStruct) string(id int16) string {
+	value, _ := s[id].([]byte)
+	return string(value)
+}
+
+func (s thriftStruct) strct(id int16) (thriftStruct, bool) {
+	value, ok := s[id].(thriftStruct)
+	return value, ok
+}
+
+func (s thriftStruct) list(id int16) []interface{} {
+	value, _ := s[id].([]interface{})
+	return value
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package parquet
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/types"
+)
+
+var updateGoldenFiles = flag.Bool("update", false, "update the golden files in testdata")
+
+// The golden files pin the output of the codec. After an intended change of the files, run this
+// test with -update and check the new files with a reference parquet implementation.
+func TestGoldenFiles(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "TestGoldenFiles")
+	require.NoError(t, err)
+	defer os.RemoveAll(dirPath)
+
+	goldenCloseTime := time.Date(2022, 3, 14, 15, 9, 26, 0, time.UTC)
+	var historyFiles []string
+	for _, version := range []int64{1, 2} {
+		request := &archiver.ArchiveHistoryRequest{
+			DomainID:             "domain-id",
+			DomainName:           "domain-name",
+			WorkflowID:           "workflow-id",
+			RunID:                "run-id",
+			CloseFailoverVersion: version,
+		}
+		started := &types.HistoryEvent{
+			ID:        1,
+			Timestamp: common.Int64Ptr(goldenCloseTime.Add(-time.Hour).UnixNano()),
+			EventType: types.EventTypeWorkflowExecutionStarted.Ptr(),
+			Version:   version,
+			TaskID:    10,
+			WorkflowExecutionStartedEventAttributes: &types.WorkflowExecutionStartedEventAttributes{
+				WorkflowType: &types.WorkflowType{Name: "workflow-type"},
+			},
+		}
+		completed := &types.HistoryEvent{
+			ID:        2,
+			Timestamp: common.Int64Ptr(goldenCloseTime.UnixNano()),
+			EventType: types.EventTypeWorkflowExecutionCompleted.Ptr(),
+			Version:   version,
+			TaskID:    20,
+			WorkflowExecutionCompletedEventAttributes: &types.WorkflowExecutionCompletedEventAttributes{},
+		}
+		historyBatches := []*types.History{{Events: []*types.HistoryEvent{started, completed}}}
+		if version == 1 {
+			historyBatches = []*types.History{{Events: []*types.HistoryEvent{started}}, {Events: []*types.HistoryEvent{completed}}}
+		}
+		data, err := encodeHistoryBatches(request, historyBatches)
+		require.NoError(t, err)
+		filename := constructHistoryFilename(request.DomainID, request.WorkflowID, request.RunID, version)
+		require.NoError(t, ioutil.WriteFile(path.Join(dirPath, filename), data, 0666))
+		historyFiles = append(historyFiles, filename)
+	}
+	history, _, err := mergeHistoryFiles(dirPath, historyFiles)
+	require.NoError(t, err)
+
+	var visibilityFiles []string
+	for _, request := range []*archiver.ArchiveVisibilityRequest{
+		{
+			DomainID:           "domain-id",
+			DomainName:         "domain-name",
+			WorkflowID:         "workflow-id",
+			RunID:              "run-id-1",
+			WorkflowTypeName:   "workflow-type",
+			StartTimestamp:     goldenCloseTime.Add(-time.Hour).UnixNano(),
+			ExecutionTimestamp: goldenCloseTime.Add(-time.Hour).UnixNano(),
+			CloseTimestamp:     goldenCloseTime.UnixNano(),
+			CloseStatus:        types.WorkflowExecutionCloseStatusCompleted,
+			HistoryLength:      2,
+			Memo:               &types.Memo{Fields: map[string][]byte{"memo": []byte("value")}},
+			SearchAttributes:   map[string]string{"CustomKeywordField": `"keyword"`},
+			HistoryArchivalURI: "parquet:///history",
+		},
+		{
+			DomainID:           "domain-id",
+			DomainName:         "domain-name",
+			WorkflowID:         "workflow-id",
+			RunID:              "run-id-2",
+			WorkflowTypeName:   "workflow-type",
+			StartTimestamp:     goldenCloseTime.Add(-time.Hour).UnixNano(),
+			ExecutionTimestamp: goldenCloseTime.UnixNano(),
+			CloseTimestamp:     goldenCloseTime.Add(time.Hour).UnixNano(),
+			CloseStatus:        types.WorkflowExecutionCloseStatusFailed,
+			HistoryLength:      3,
+		},
+	} {
+		data, err := encodeVisibilityRecord(request)
+		require.NoError(t, err)
+		filename := constructVisibilityFilename(request.CloseTimestamp, request.RunID)
+		require.NoError(t, ioutil.WriteFile(path.Join(dirPath, filename), data, 0666))
+		visibilityFiles = append(visibilityFiles, filename)
+	}
+	visibility, _, err := mergeVisibilityFiles(dirPath, visibilityFiles)
+	require.NoError(t, err)
+
+	for filename, data := range map[string][]byte{
+		"history.parquet":    history,
+		"visibility.parquet": visibility,
+	} {
+		goldenPath := path.Join("testdata", filename)
+		if *updateGoldenFiles {
+			require.NoError(t, ioutil.WriteFile(goldenPath, data, 0644))
+		}
+		expected, err := ioutil.ReadFile(goldenPath)
+		require.NoError(t, err)
+		require.Equal(t, expected, data, filename)
+	}
+}
@@ -0,0 +1,367 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Parquet History Archiver will archive workflow histories to local disk as parquet files.
+
+// Each Archive() request results in a staged file named in the format of
+// hash(domainID, workflowID, runID)_version.parquet being created in the close date partition
+// of the domain under the directory specified in the URI. The file contains one row per history
+// event, flattened into columns which can be queried by analytics tools, along with the JSON
+// encoded event so that the history can be read back without loss. Staged files are merged into
+// part files holding one row group per workflow run by the compactions of the partition.
+
+// The Get() method retrieves the archived histories from the directory specified in the
+// URI. It optionally takes in a NextPageToken which specifies the workflow close failover
+// version and the index of the first history batch that should be returned. Instead of
+// NextPageToken, caller can also provide a close failover version, in which case, Get() method
+// will return history batches starting from the beginning of that history version. If neither
+// of NextPageToken or close failover version is specified, the highest close failover version
+// will be picked.
+
+package parquet
+
+import (
+	"context"
+	"math"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/config"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/common/util"
+)
+
+const (
+	// URIScheme is the scheme for the parquet implementation
+	URIScheme = "parquet"
+
+	errEncodeHistory          = "failed to encode history batches"
+	errEncodeVisibilityRecord = "failed to encode visibility record"
+	errMakeDirectory          = "failed to make directory"
+	errWriteFile              = "failed to write file"
+
+	targetHistoryBlobSize = 2 * 1024 * 1024 // 2MB
+)
+
+type (
+	historyArchiver struct {
+		container *archiver.HistoryBootstrapContainer
+		fileMode  os.FileMode
+		dirMode   os.FileMode
+		compactor *partitionCompactor
+
+		// only set in test code
+		historyIterator archiver.HistoryIterator
+	}
+
+	getHistoryToken struct {
+		CloseFailoverVersion int64
+		NextBatchIdx         int
+	}
+)
+
+// NewHistoryArchiver creates a new archiver.HistoryArchiver based on parquet files
+func NewHistoryArchiver(
+	container *archiver.HistoryBootstrapContainer,
+	config *config.ParquetArchiver,
+) (archiver.HistoryArchiver, error) {
+	return newHistoryArchiver(container, config, nil)
+}
+
+func newHistoryArchiver(
+	container *archiver.HistoryBootstrapContainer,
+	config *config.ParquetArchiver,
+	historyIterator archiver.HistoryIterator,
+) (*historyArchiver, error) {
+	fileMode, dirMode, err := parseFileModes(config.FileMode, config.DirMode)
+	if err != nil {
+		return nil, err
+	}
+	return &historyArchiver{
+		container:       container,
+		fileMode:        fileMode,
+		dirMode:         dirMode,
+		compactor:       newPartitionCompactor(container.Logger, fileMode, config.CompactionBatchSize, mergeHistoryFiles),
+		historyIterator: historyIterator,
+	}, nil
+}
+
+func (h *historyArchiver) Archive(
+	ctx context.Context,
+	URI archiver.URI,
+	request *archiver.ArchiveHistoryRequest,
+	opts ...archiver.ArchiveOption,
+) (err error) {
+	featureCatalog := archiver.GetFeatureCatalog(opts...)
+	defer func() {
+		if err != nil && !persistence.IsTransientError(err) && featureCatalog.NonRetriableError != nil {
+			err = featureCatalog.NonRetriableError()
+		}
+	}()
+
+	logger := archiver.TagLoggerWithArchiveHistoryRequestAndURI(h.container.Logger, request, URI.String())
+
+	if err := h.ValidateURI(URI); err != nil {
+		logger.Error(archiver.ArchiveNonRetriableErrorMsg, tag.ArchivalArchiveFailReason(archiver.ErrReasonInvalidURI), tag.Error(err))
+		return err
+	}
+
+	if err := archiver.ValidateHistoryArchiveRequest(request); err != nil {
+		logger.Error(archiver.ArchiveNonRetriableErrorMsg, tag.ArchivalArchiveFailReason(archiver.ErrReasonInvalidArchiveRequest), tag.Error(err))
+		return err
+	}
+
+	historyIterator := h.historyIterator
+	if historyIterator == nil { // will only be set by testing code
+		historyIterator = archiver.NewHistoryIterator(ctx, request, h.container.HistoryV2Manager, targetHistoryBlobSize)
+	}
+
+	historyBatches := []*types.History{}
+	for historyIterator.HasNext() {
+		historyBlob, err := getNextHistoryBlob(ctx, historyIterator)
+		if err != nil {
+			if common.IsEntityNotExistsError(err) {
+				// workflow history no longer exists, may due to duplicated archival signal
+				// this may happen even in the middle of iterating history as two archival signals
+				// can be processed concurrently.
+				logger.Info(archiver.ArchiveSkippedInfoMsg)
+				return nil
+			}
+
+			logger := logger.WithTags(tag.ArchivalArchiveFailReason(archiver.ErrReasonReadHistory), tag.Error(err))
+			if !persistence.IsTransientError(err) {
+				logger.Error(archiver.ArchiveNonRetriableErrorMsg)
+			} else {
+				logger.Error(archiver.ArchiveTransientErrorMsg)
+			}
+			return err
+		}
+
+		if archiver.IsHistoryMutated(request, historyBlob.Body, *historyBlob.Header.IsLast, logger) {
+			if !featureCatalog.ArchiveIncompleteHistory() {
+				return archiver.ErrHistoryMutated
+			}
+		}
+
+		historyBatches = append(historyBatches, historyBlob.Body...)
+	}
+
+	encodedHistoryBatches, err := encodeHistoryBatches(request, historyBatches)
+	if err != nil {
+		logger.Error(archiver.ArchiveNonRetriableErrorMsg, tag.ArchivalArchiveFailReason(errEncodeHistory), tag.Error(err))
+		return err
+	}
+
+	// the last event closes the workflow, so its timestamp determines the close date partition
+	closeTimestamp := time.Now().UnixNano()
+	if len(historyBatches) != 0 {
+		if events := historyBatches[len(historyBatches)-1].Events; len(events) != 0 {
+			closeTimestamp = events[len(events)-1].GetTimestamp()
+		}
+	}
+	dirPath := path.Join(constructDomainDirPath(URI.Path(), historyDirName, request.DomainID), constructDateDirName(closeTimestamp))
+	if err = util.MkdirAll(dirPath, h.dirMode); err != nil {
+		logger.Error(archiver.ArchiveNonRetriableErrorMsg, tag.ArchivalArchiveFailReason(errMakeDirectory), tag.Error(err))
+		return err
+	}
+
+	filename := constructHistoryFilename(request.DomainID, request.WorkflowID, request.RunID, request.CloseFailoverVersion)
+	if err := writeFileAtomic(path.Join(dirPath, filename), encodedHistoryBatches, h.fileMode); err != nil {
+		logger.Error(archiver.ArchiveNonRetriableErrorMsg, tag.ArchivalArchiveFailReason(errWriteFile), tag.Error(err))
+		return err
+	}
+
+	h.compactor.maybeCompact(dirPath)
+	return nil
+}
+
+func (h *historyArchiver) Get(
+	ctx context.Context,
+	URI archiver.URI,
+	request *archiver.GetHistoryRequest,
+) (*archiver.GetHistoryResponse, error) {
+	if err := h.ValidateURI(URI); err != nil {
+		return nil, &types.BadRequestError{Message: archiver.ErrInvalidURI.Error()}
+	}
+
+	if err := archiver.ValidateGetRequest(request); err != nil {
+		return nil, &types.BadRequestError{Message: archiver.ErrInvalidGetHistoryRequest.Error()}
+	}
+
+	var token *getHistoryToken
+	if request.NextPageToken != nil {
+		var err error
+		token, err = deserializeGetHistoryToken(request.NextPageToken)
+		if err != nil {
+			return nil, &types.BadRequestError{Message: archiver.ErrNextPageTokenCorrupted.Error()}
+		}
+	} else if request.CloseFailoverVersion != nil {
+		token = &getHistoryToken{
+			CloseFailoverVersion: *request.CloseFailoverVersion,
+			NextBatchIdx:         0,
+		}
+	}
+
+	var historyBatches []*types.History
+	for attempt := 0; ; attempt++ {
+		// the close date of the workflow is not known, so all partitions of the domain are searched
+		locations, err := findHistoryLocations(URI.Path(), request)
+		if err != nil {
+			return nil, &types.InternalServiceError{Message: err.Error()}
+		}
+		if token == nil {
+			var highestVersion *int64
+			for version := range locations {
+				if highestVersion == nil || version > *highestVersion {
+					highestVersion = common.Int64Ptr(version)
+				}
+			}
+			if highestVersion == nil {
+				return nil, &types.EntityNotExistsError{Message: archiver.ErrHistoryNotExist.Error()}
+			}
+			token = &getHistoryToken{
+				CloseFailoverVersion: *highestVersion,
+				NextBatchIdx:         0,
+			}
+		}
+
+		location, ok := locations[token.CloseFailoverVersion]
+		if !ok {
+			return nil, &types.EntityNotExistsError{Message: archiver.ErrHistoryNotExist.Error()}
+		}
+		historyBatches, err = location.read()
+		if os.IsNotExist(err) && attempt == 0 {
+			// the staged file was merged into a part file after it was listed
+			continue
+		}
+		if err != nil {
+			return nil, &types.InternalServiceError{Message: err.Error()}
+		}
+		break
+	}
+	if token.NextBatchIdx > len(historyBatches) {
+		return nil, &types.BadRequestError{Message: archiver.ErrNextPageTokenCorrupted.Error()}
+	}
+	historyBatches = historyBatches[token.NextBatchIdx:]
+
+	response := &archiver.GetHistoryResponse{}
+	numOfEvents := 0
+	numOfBatches := 0
+	for _, batch := range historyBatches {
+		response.HistoryBatches = append(response.HistoryBatches, batch)
+		numOfBatches++
+		numOfEvents += len(batch.Events)
+		if numOfEvents >= request.PageSize {
+			break
+		}
+	}
+
+	if numOfBatches < len(historyBatches) {
+		token.NextBatchIdx += numOfBatches
+		nextToken, err := serializeToken(token)
+		if err != nil {
+			return nil, &types.InternalServiceError{Message: err.Error()}
+		}
+		response.NextPageToken = nextToken
+	}
+
+	return response, nil
+}
+
+// Stop stops the compactions started by Archive
+func (h *historyArchiver) Stop() {
+	h.compactor.stop()
+}
+
+func (h *historyArchiver) ValidateURI(URI archiver.URI) error {
+	if URI.Scheme() != URIScheme {
+		return archiver.ErrURISchemeMismatch
+	}
+
+	return validateDirPath(URI.Path())
+}
+
+// historyLocation is either a staged file or a row group of a part file
+type historyLocation struct {
+	staged string
+	part   partLocation
+}
+
+func (l historyLocation) read() ([]*types.History, error) {
+	if l.staged != "" {
+		data, err := util.ReadFile(l.staged)
+		if err != nil {
+			return nil, err
+		}
+		return decodeHistoryBatches(data)
+	}
+	rows, err := readPartFile(l.part, historyColumns)
+	if err != nil {
+		return nil, err
+	}
+	return convertRowsToHistoryBatches(rows)
+}
+
+// findHistoryLocations returns the locations of the archived histories of a workflow run by close failover version
+func findHistoryLocations(rootPath string, request *archiver.GetHistoryRequest) (map[int64]historyLocation, error) {
+	dirs, err := listDateDirs(constructDomainDirPath(rootPath, historyDirName, request.DomainID), 0, math.MaxInt64)
+	if err != nil {
+		return nil, err
+	}
+	prefix := constructHistoryFilenamePrefix(request.DomainID, request.WorkflowID, request.RunID) + "_"
+	locations := make(map[int64]historyLocation)
+	for _, dir := range dirs {
+		// staged files are listed before reading the index, so that a file compacted in between is
+		// found in the index
+		filenames, err := util.ListFilesByPrefix(dir, prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, filename := range filenames {
+			version, err := extractCloseFailoverVersion(filename)
+			if err != nil {
+				continue
+			}
+			locations[version] = historyLocation{staged: path.Join(dir, filename)}
+		}
+		index, err := readPartitionIndex(dir)
+		if err != nil {
+			return nil, err
+		}
+		for filename, part := range index {
+			if !strings.HasPrefix(filename, prefix) {
+				continue
+			}
+			version, err := extractCloseFailoverVersion(filename)
+			if err != nil {
+				continue
+			}
+			// part files are never removed, unlike staged ones
+			locations[version] = historyLocation{part: part}
+		}
+	}
+	return locations, nil
+}
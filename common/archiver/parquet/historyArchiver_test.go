@@ -0,0 +1,363 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package parquet
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/config"
+	"github.com/uber/cadence/common/log/loggerimpl"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/common/util"
+)
+
+const (
+	testDomainID             = "test-domain-id"
+	testDomainName           = "test-domain-name"
+	testWorkflowID           = "test-workflow-id"
+	testRunID                = "test-run-id"
+	testNextEventID          = 1800
+	testCloseFailoverVersion = 100
+	testPageSize             = 100
+
+	testFileModeStr = "0666"
+	testDirModeStr  = "0766"
+)
+
+var (
+	testBranchToken = []byte{1, 2, 3}
+	testCloseTime   = time.Date(2022, 3, 14, 15, 9, 26, 0, time.UTC)
+)
+
+type historyArchiverSuite struct {
+	*require.Assertions
+	suite.Suite
+
+	container *archiver.HistoryBootstrapContainer
+	testDir   string
+	URI       archiver.URI
+}
+
+func TestHistoryArchiverSuite(t *testing.T) {
+	suite.Run(t, new(historyArchiverSuite))
+}
+
+func (s *historyArchiverSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.container = &archiver.HistoryBootstrapContainer{
+		Logger: loggerimpl.NewLogger(zap.NewNop()),
+	}
+	var err error
+	s.testDir, err = ioutil.TempDir("", "TestParquetHistory")
+	s.NoError(err)
+	s.URI, err = archiver.NewURI("parquet://" + s.testDir)
+	s.NoError(err)
+}
+
+func (s *historyArchiverSuite) TearDownTest() {
+	os.RemoveAll(s.testDir)
+}
+
+func (s *historyArchiverSuite) TestValidateURI() {
+	testCases := []struct {
+		URI         string
+		expectedErr error
+	}{
+		{
+			URI:         "file:///a/b/c",
+			expectedErr: archiver.ErrURISchemeMismatch,
+		},
+		{
+			URI:         "parquet://",
+			expectedErr: errEmptyDirectoryPath,
+		},
+		{
+			URI:         "parquet:///a/b/c",
+			expectedErr: nil,
+		},
+	}
+
+	historyArchiver := s.newTestHistoryArchiver(nil)
+	for _, tc := range testCases {
+		URI, err := archiver.NewURI(tc.URI)
+		s.NoError(err)
+		s.Equal(tc.expectedErr, historyArchiver.ValidateURI(URI))
+	}
+}
+
+func (s *historyArchiverSuite) TestArchive_Fail_InvalidRequest() {
+	historyArchiver := s.newTestHistoryArchiver(nil)
+	request := &archiver.ArchiveHistoryRequest{
+		DomainID:             testDomainID,
+		DomainName:           testDomainName,
+		WorkflowID:           "", // an invalid request
+		RunID:                testRunID,
+		BranchToken:          testBranchToken,
+		NextEventID:          testNextEventID,
+		CloseFailoverVersion: testCloseFailoverVersion,
+	}
+	s.Error(historyArchiver.Archive(context.Background(), s.URI, request))
+}
+
+func (s *historyArchiverSuite) TestGet_Fail_NotExist() {
+	historyArchiver := s.newTestHistoryArchiver(nil)
+	request := &archiver.GetHistoryRequest{
+		DomainID:   testDomainID,
+		WorkflowID: testWorkflowID,
+		RunID:      testRunID,
+		PageSize:   testPageSize,
+	}
+	response, err := historyArchiver.Get(context.Background(), s.URI, request)
+	s.Nil(response)
+	s.IsType(&types.EntityNotExistsError{}, err)
+
+	request.NextPageToken = []byte{'r', 'a', 'n', 'd', 'o', 'm'}
+	response, err = historyArchiver.Get(context.Background(), s.URI, request)
+	s.Nil(response)
+	s.IsType(&types.BadRequestError{}, err)
+}
+
+func (s *historyArchiverSuite) TestArchiveAndGet() {
+	historyBatchesV1 := s.newHistoryBatches(1, 1)
+	historyBatchesV100 := s.newHistoryBatches(testCloseFailoverVersion, 3)
+	s.archive(historyBatchesV1, 1)
+	s.archive(historyBatchesV100, testCloseFailoverVersion)
+
+	expectedPath := path.Join(
+		s.testDir,
+		historyDirName,
+		"domain_id="+testDomainID,
+		"close_date=2022-03-14",
+		constructHistoryFilename(testDomainID, testWorkflowID, testRunID, testCloseFailoverVersion),
+	)
+	exists, err := util.FileExists(expectedPath)
+	s.NoError(err)
+	s.True(exists)
+
+	historyArchiver := s.newTestHistoryArchiver(nil)
+	request := &archiver.GetHistoryRequest{
+		DomainID:   testDomainID,
+		WorkflowID: testWorkflowID,
+		RunID:      testRunID,
+		PageSize:   testPageSize,
+	}
+
+	// highest version is picked
+	response, err := historyArchiver.Get(context.Background(), s.URI, request)
+	s.NoError(err)
+	s.Nil(response.NextPageToken)
+	s.Equal(historyBatchesV100, response.HistoryBatches)
+
+	// provided version is used
+	request.CloseFailoverVersion = common.Int64Ptr(1)
+	response, err = historyArchiver.Get(context.Background(), s.URI, request)
+	s.NoError(err)
+	s.Nil(response.NextPageToken)
+	s.Equal(historyBatchesV1, response.HistoryBatches)
+
+	// unknown version
+	request.CloseFailoverVersion = common.Int64Ptr(2)
+	_, err = historyArchiver.Get(context.Background(), s.URI, request)
+	s.IsType(&types.EntityNotExistsError{}, err)
+
+	// small page size
+	request.CloseFailoverVersion = nil
+	request.PageSize = 1
+	var batches []*types.History
+	for {
+		response, err = historyArchiver.Get(context.Background(), s.URI, request)
+		s.NoError(err)
+		s.Len(response.HistoryBatches, 1)
+		batches = append(batches, response.HistoryBatches...)
+		if response.NextPageToken == nil {
+			break
+		}
+		request.NextPageToken = response.NextPageToken
+	}
+	s.Equal(historyBatchesV100, batches)
+}
+
+func (s *historyArchiverSuite) TestArchiveAndGet_Compacted() {
+	historyBatchesV1 := s.newHistoryBatches(1, 1)
+	historyBatchesV100 := s.newHistoryBatches(testCloseFailoverVersion, 3)
+	s.archive(historyBatchesV1, 1)
+	s.archive(historyBatchesV100, testCloseFailoverVersion)
+
+	historyArchiver := s.newTestHistoryArchiver(nil)
+	dirPath := path.Join(constructDomainDirPath(s.testDir, historyDirName, testDomainID), "close_date=2022-03-14")
+	historyArchiver.compactor.batchSize = 2
+	historyArchiver.compactor.maybeCompact(dirPath)
+	historyArchiver.compactor.wait()
+
+	staged, parts, err := listPartitionFiles(dirPath)
+	s.NoError(err)
+	s.Empty(staged)
+	s.Len(parts, 1)
+	index, err := readPartitionIndex(dirPath)
+	s.NoError(err)
+	s.Len(index, 2)
+
+	// the compacted run is archived again, it's read from the part file
+	s.archive(historyBatchesV100, testCloseFailoverVersion)
+
+	request := &archiver.GetHistoryRequest{
+		DomainID:   testDomainID,
+		WorkflowID: testWorkflowID,
+		RunID:      testRunID,
+		PageSize:   1,
+	}
+	locations, err := findHistoryLocations(s.testDir, request)
+	s.NoError(err)
+	s.Equal(historyLocation{part: partLocation{part: path.Join(dirPath, parts[0]), rowGroup: 1}}, locations[testCloseFailoverVersion])
+	var batches []*types.History
+	for {
+		response, err := historyArchiver.Get(context.Background(), s.URI, request)
+		s.NoError(err)
+		batches = append(batches, response.HistoryBatches...)
+		if response.NextPageToken == nil {
+			break
+		}
+		request.NextPageToken = response.NextPageToken
+	}
+	s.Equal(historyBatchesV100, batches)
+
+	request.NextPageToken = nil
+	request.CloseFailoverVersion = common.Int64Ptr(1)
+	response, err := historyArchiver.Get(context.Background(), s.URI, request)
+	s.NoError(err)
+	s.Nil(response.NextPageToken)
+	s.Equal(historyBatchesV1, response.HistoryBatches)
+}
+
+func (s *historyArchiverSuite) TestHistoryColumns() {
+	historyBatches := s.newHistoryBatches(testCloseFailoverVersion, 3)
+	request := &archiver.ArchiveHistoryRequest{
+		DomainID:             testDomainID,
+		DomainName:           testDomainName,
+		WorkflowID:           testWorkflowID,
+		RunID:                testRunID,
+		CloseFailoverVersion: testCloseFailoverVersion,
+	}
+	data, err := encodeHistoryBatches(request, historyBatches)
+	s.NoError(err)
+
+	rows, err := decodeTable(data, historyColumns)
+	s.NoError(err)
+	s.Len(rows, 3)
+	last := rows[2]
+	s.Equal(testDomainID, last[0])
+	s.Equal(testDomainName, last[1])
+	s.Equal(testWorkflowID, last[2])
+	s.Equal(testRunID, last[3])
+	s.Equal(int64(testCloseFailoverVersion), last[4])
+	s.Equal(int64(1), last[5])
+	s.Equal(int64(testNextEventID-1), last[6])
+	s.Equal(testCloseTime.UnixNano(), last[7])
+	s.Equal("WorkflowExecutionCompleted", last[8])
+}
+
+func (s *historyArchiverSuite) archive(historyBatches []*types.History, version int64) {
+	mockCtrl := gomock.NewController(s.T())
+	defer mockCtrl.Finish()
+	historyIterator := archiver.NewMockHistoryIterator(mockCtrl)
+	historyBlob := &archiver.HistoryBlob{
+		Header: &archiver.HistoryBlobHeader{
+			IsLast: common.BoolPtr(true),
+		},
+		Body: historyBatches,
+	}
+	gomock.InOrder(
+		historyIterator.EXPECT().HasNext().Return(true),
+		historyIterator.EXPECT().Next().Return(historyBlob, nil),
+		historyIterator.EXPECT().HasNext().Return(false),
+	)
+
+	historyArchiver := s.newTestHistoryArchiver(historyIterator)
+	request := &archiver.ArchiveHistoryRequest{
+		DomainID:             testDomainID,
+		DomainName:           testDomainName,
+		WorkflowID:           testWorkflowID,
+		RunID:                testRunID,
+		BranchToken:          testBranchToken,
+		NextEventID:          testNextEventID,
+		CloseFailoverVersion: version,
+	}
+	s.NoError(historyArchiver.Archive(context.Background(), s.URI, request))
+}
+
+// newHistoryBatches returns a history of numEvents events, the last one closes the workflow in its own batch
+func (s *historyArchiverSuite) newHistoryBatches(version int64, numEvents int) []*types.History {
+	startTime := testCloseTime.Add(-time.Hour).UnixNano()
+	first := &types.History{}
+	for i := 1; i < numEvents; i++ {
+		first.Events = append(first.Events, &types.HistoryEvent{
+			ID:        int64(i),
+			Timestamp: common.Int64Ptr(startTime + int64(i)),
+			EventType: types.EventTypeWorkflowExecutionStarted.Ptr(),
+			Version:   version,
+			TaskID:    int64(i),
+			WorkflowExecutionStartedEventAttributes: &types.WorkflowExecutionStartedEventAttributes{
+				WorkflowType: &types.WorkflowType{Name: "test-workflow-type"},
+				Input:        []byte("input"),
+			},
+		})
+	}
+	last := &types.History{
+		Events: []*types.HistoryEvent{
+			{
+				ID:        testNextEventID - 1,
+				Timestamp: common.Int64Ptr(testCloseTime.UnixNano()),
+				EventType: types.EventTypeWorkflowExecutionCompleted.Ptr(),
+				Version:   version,
+				TaskID:    int64(numEvents),
+				WorkflowExecutionCompletedEventAttributes: &types.WorkflowExecutionCompletedEventAttributes{
+					Result: []byte("result"),
+				},
+			},
+		},
+	}
+	if len(first.Events) == 0 {
+		return []*types.History{last}
+	}
+	return []*types.History{first, last}
+}
+
+func (s *historyArchiverSuite) newTestHistoryArchiver(historyIterator archiver.HistoryIterator) *historyArchiver {
+	config := &config.ParquetArchiver{
+		FileMode: testFileModeStr,
+		DirMode:  testDirModeStr,
+	}
+	archiver, err := newHistoryArchiver(s.container, config, historyIterator)
+	s.NoError(err)
+	return archiver
+}
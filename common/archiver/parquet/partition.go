@@ -0,0 +1,481 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package parquet
+
+// Archive() writes every workflow run or visibility record to its own staged file first, so that
+// a request is durable once it returns without coordinating concurrent writers. When a close date
+// partition holds compactionBatchSize staged files, they are merged into a part file in the
+// background:
+// - history part files hold one row group per workflow run, the partition index maps the staged
+//   filenames to their part file and row group, so that Get() only reads the requested run
+// - visibility part files hold all of their records in a single row group
+//
+// Compactions of a partition are serialized by a lock file across hosts. The compaction intent is
+// written before the part file, so that a compaction which crashed after writing the part file is
+// completed by the next one instead of merging the same staged files twice. Readers may see a
+// record in both a staged and a part file while a compaction is running and deduplicate them.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/util"
+)
+
+const (
+	// files starting with an underscore are ignored by analytics tools following the Hive conventions
+	hiddenFilePrefix = "_"
+	partFilePrefix   = "part-"
+	tempFileSuffix   = ".tmp"
+
+	lockFilename   = "_compaction.lock"
+	intentFilename = "_compaction.json"
+	indexFilename  = "_index.json"
+
+	defaultCompactionBatchSize = 1000
+	// maxPartFileSize bounds the size of the staged files merged by a compaction, and so its memory usage
+	maxPartFileSize = 256 * 1024 * 1024 // 256MB
+	// compactionLockTimeout is the age after which the lock of a partition is considered to be left
+	// behind by a crashed compaction, it's also the age after which temporary files are removed
+	compactionLockTimeout = 10 * time.Minute
+	// compactionLockRefreshInterval is the interval at which a running compaction refreshes its lock
+	compactionLockRefreshInterval = compactionLockTimeout / 5
+)
+
+type (
+	// mergeFunc merges staged files into the contents of a part file, for history partitions it also
+	// returns the row group of each staged file in the part file
+	mergeFunc func(dirPath string, filenames []string) ([]byte, map[string]int, error)
+
+	partitionCompactor struct {
+		logger    log.Logger
+		fileMode  os.FileMode
+		batchSize int
+		merge     mergeFunc
+
+		// partitions compacted by this host
+		running sync.Map
+		wg      sync.WaitGroup
+		// stopLock guards stopped so that no compaction is started once stop waits for the running ones
+		stopLock sync.Mutex
+		stopped  bool
+		stopC    chan struct{}
+	}
+
+	compactionIntent struct {
+		Part      string
+		Files     []string
+		RowGroups map[string]int `json:",omitempty"`
+	}
+
+	// partitionIndexEntry is one line of the partition index, written by every history compaction
+	partitionIndexEntry struct {
+		Part      string
+		RowGroups map[string]int
+	}
+
+	partLocation struct {
+		part     string
+		rowGroup int
+	}
+)
+
+func newPartitionCompactor(
+	logger log.Logger,
+	fileMode os.FileMode,
+	batchSize int,
+	merge mergeFunc,
+) *partitionCompactor {
+	if batchSize <= 0 {
+		batchSize = defaultCompactionBatchSize
+	}
+	return &partitionCompactor{
+		logger:    logger,
+		fileMode:  fileMode,
+		batchSize: batchSize,
+		merge:     merge,
+		stopC:     make(chan struct{}),
+	}
+}
+
+// maybeCompact starts a compaction of the partition in the background if it holds enough staged files
+func (c *partitionCompactor) maybeCompact(dirPath string) {
+	staged, _, err := listPartitionFiles(dirPath)
+	if err != nil {
+		c.logger.Warn("failed to list parquet archival partition", tag.Value(dirPath), tag.Error(err))
+		return
+	}
+	if len(staged) < c.batchSize {
+		return
+	}
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+	if c.stopped {
+		return
+	}
+	if _, loaded := c.running.LoadOrStore(dirPath, struct{}{}); loaded {
+		return
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer c.running.Delete(dirPath)
+		if err := c.compact(dirPath); err != nil {
+			c.logger.Warn("failed to compact parquet archival partition", tag.Value(dirPath), tag.Error(err))
+		}
+	}()
+}
+
+// wait blocks until the compactions started by this host are done
+func (c *partitionCompactor) wait() {
+	c.wg.Wait()
+}
+
+// stop stops the compactions started by this host after their current batch and waits for them,
+// no compaction is started afterwards. The staged files of unfinished compactions are merged later on.
+func (c *partitionCompactor) stop() {
+	c.stopLock.Lock()
+	if !c.stopped {
+		c.stopped = true
+		close(c.stopC)
+	}
+	c.stopLock.Unlock()
+	c.wg.Wait()
+}
+
+func (c *partitionCompactor) compact(dirPath string) error {
+	unlock, locked, err := lockPartition(dirPath, c.fileMode)
+	if err != nil || !locked {
+		return err
+	}
+	defer unlock()
+
+	if err := c.recover(dirPath); err != nil {
+		return err
+	}
+	removeTempFiles(dirPath)
+
+	for {
+		select {
+		case <-c.stopC:
+			return nil
+		default:
+		}
+		staged, _, err := listPartitionFiles(dirPath)
+		if err != nil {
+			return err
+		}
+		if len(staged) < c.batchSize {
+			return nil
+		}
+		batch, err := selectBatch(dirPath, staged, c.batchSize)
+		if err != nil {
+			return err
+		}
+		data, rowGroups, err := c.merge(dirPath, batch)
+		if err != nil {
+			return err
+		}
+		part, err := newPartFilename(dirPath)
+		if err != nil {
+			return err
+		}
+		intent := &compactionIntent{
+			Part:      part,
+			Files:     batch,
+			RowGroups: rowGroups,
+		}
+		encodedIntent, err := json.Marshal(intent)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(path.Join(dirPath, intentFilename), encodedIntent, c.fileMode); err != nil {
+			return err
+		}
+		if err := writeFileAtomic(path.Join(dirPath, intent.Part), data, c.fileMode); err != nil {
+			return err
+		}
+		if err := c.complete(dirPath, intent); err != nil {
+			return err
+		}
+	}
+}
+
+// recover completes the compaction of the partition which crashed after writing its part file,
+// or drops its intent if the part file wasn't written
+func (c *partitionCompactor) recover(dirPath string) error {
+	data, err := util.ReadFile(path.Join(dirPath, intentFilename))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	intent := &compactionIntent{}
+	if err := json.Unmarshal(data, intent); err != nil {
+		return err
+	}
+	exists, err := util.FileExists(path.Join(dirPath, intent.Part))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return os.Remove(path.Join(dirPath, intentFilename))
+	}
+	return c.complete(dirPath, intent)
+}
+
+// complete indexes the part file of a compaction and removes the staged files merged into it
+func (c *partitionCompactor) complete(dirPath string, intent *compactionIntent) error {
+	if len(intent.RowGroups) != 0 {
+		if err := appendIndexEntry(dirPath, &partitionIndexEntry{
+			Part:      intent.Part,
+			RowGroups: intent.RowGroups,
+		}, c.fileMode); err != nil {
+			return err
+		}
+	}
+	for _, filename := range intent.Files {
+		if err := os.Remove(path.Join(dirPath, filename)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Remove(path.Join(dirPath, intentFilename))
+}
+
+// lockPartition creates the lock file of the partition, it returns false if the lock is held by another compaction.
+// The lock is refreshed until it's released, so that a long compaction isn't mistaken for a crashed one.
+func lockPartition(dirPath string, fileMode os.FileMode) (func(), bool, error) {
+	lockPath := path.Join(dirPath, lockFilename)
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, fileMode)
+		if err == nil {
+			if err := f.Close(); err != nil {
+				return nil, false, err
+			}
+			doneC := make(chan struct{})
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				refreshLock(lockPath, compactionLockRefreshInterval, doneC)
+			}()
+			return func() {
+				close(doneC)
+				wg.Wait()
+				os.Remove(lockPath)
+			}, true, nil
+		}
+		if !os.IsExist(err) {
+			return nil, false, err
+		}
+		info, err := os.Stat(lockPath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if time.Since(info.ModTime()) < compactionLockTimeout {
+			return nil, false, nil
+		}
+		// the lock was left behind by a crashed compaction
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return nil, false, err
+		}
+	}
+	return nil, false, nil
+}
+
+// refreshLock updates the modification time of the lock file every interval until doneC is closed
+func refreshLock(lockPath string, interval time.Duration, doneC <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-doneC:
+			return
+		case <-ticker.C:
+			now := time.Now()
+			os.Chtimes(lockPath, now, now)
+		}
+	}
+}
+
+// removeTempFiles removes the temporary files left behind by crashed writes
+func removeTempFiles(dirPath string) {
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), tempFileSuffix) {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > compactionLockTimeout {
+			os.Remove(path.Join(dirPath, entry.Name()))
+		}
+	}
+}
+
+// selectBatch returns the staged files merged by the next compaction, bounded by the batch size and maxPartFileSize
+func selectBatch(dirPath string, staged []string, batchSize int) ([]string, error) {
+	sort.Strings(staged)
+	var batch []string
+	var size int64
+	for _, filename := range staged {
+		info, err := os.Stat(path.Join(dirPath, filename))
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) != 0 && size+info.Size() > maxPartFileSize {
+			break
+		}
+		batch = append(batch, filename)
+		size += info.Size()
+		if len(batch) == batchSize {
+			break
+		}
+	}
+	return batch, nil
+}
+
+// newPartFilename returns the name of a new part file, named after the time of the compaction
+func newPartFilename(dirPath string) (string, error) {
+	for timestamp := time.Now().UnixNano(); ; timestamp++ {
+		filename := fmt.Sprintf("%s%v%s", partFilePrefix, timestamp, fileExtension)
+		exists, err := util.FileExists(path.Join(dirPath, filename))
+		if err != nil || !exists {
+			return filename, err
+		}
+	}
+}
+
+// listPartitionFiles returns the names of the staged and part files of a partition
+func listPartitionFiles(dirPath string) ([]string, []string, error) {
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	var staged, parts []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, hiddenFilePrefix) || !strings.HasSuffix(name, fileExtension) {
+			continue
+		}
+		if strings.HasPrefix(name, partFilePrefix) {
+			parts = append(parts, name)
+		} else {
+			staged = append(staged, name)
+		}
+	}
+	return staged, parts, nil
+}
+
+// readPartitionIndex returns the part file and row group of the compacted staged files of a partition
+func readPartitionIndex(dirPath string) (map[string]partLocation, error) {
+	data, err := util.ReadFile(path.Join(dirPath, indexFilename))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	locations := make(map[string]partLocation)
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		entry := &partitionIndexEntry{}
+		if err := json.Unmarshal(line, entry); err != nil {
+			// empty or partially written by a crashed compaction
+			continue
+		}
+		for filename, rowGroup := range entry.RowGroups {
+			locations[filename] = partLocation{
+				part:     path.Join(dirPath, entry.Part),
+				rowGroup: rowGroup,
+			}
+		}
+	}
+	return locations, nil
+}
+
+func appendIndexEntry(dirPath string, entry *partitionIndexEntry, fileMode os.FileMode) (retErr error) {
+	encodedEntry, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path.Join(dirPath, indexFilename), os.O_CREATE|os.O_RDWR|os.O_APPEND, fileMode)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := f.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	// a crashed compaction may have left a partial line behind
+	if info.Size() != 0 {
+		var last [1]byte
+		if _, err := f.ReadAt(last[:], info.Size()-1); err != nil {
+			return err
+		}
+		if last[0] != '\n' {
+			encodedEntry = append([]byte{'\n'}, encodedEntry...)
+		}
+	}
+	_, err = f.Write(append(encodedEntry, '\n'))
+	return err
+}
+
+// writeFileAtomic writes the file under a temporary name first, so that readers never see a partially written file
+func writeFileAtomic(filepath string, data []byte, fileMode os.FileMode) error {
+	tempPath := path.Join(path.Dir(filepath), hiddenFilePrefix+path.Base(filepath)+tempFileSuffix)
+	if err := util.WriteFile(tempPath, data, fileMode); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, filepath)
+}
+
+// readPartFile reads a single row group of a part file
+func readPartFile(location partLocation, columns []column) ([]row, error) {
+	f, err := os.Open(location.part)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return decodeRowGroup(f, info.Size(), columns, location.rowGroup)
+}
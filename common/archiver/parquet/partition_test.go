@@ -0,0 +1,250 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package parquet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/uber/cadence/common/log/loggerimpl"
+	"github.com/uber/cadence/common/util"
+)
+
+func TestLockPartition(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "TestLockPartition")
+	require.NoError(t, err)
+	defer os.RemoveAll(dirPath)
+
+	unlock, locked, err := lockPartition(dirPath, 0666)
+	require.NoError(t, err)
+	require.True(t, locked)
+	_, locked, err = lockPartition(dirPath, 0666)
+	require.NoError(t, err)
+	require.False(t, locked)
+	unlock()
+
+	unlock, locked, err = lockPartition(dirPath, 0666)
+	require.NoError(t, err)
+	require.True(t, locked)
+	// the lock of a crashed compaction expires
+	expired := time.Now().Add(-2 * compactionLockTimeout)
+	require.NoError(t, os.Chtimes(path.Join(dirPath, lockFilename), expired, expired))
+	_, locked, err = lockPartition(dirPath, 0666)
+	require.NoError(t, err)
+	require.True(t, locked)
+	unlock()
+}
+
+func TestRefreshLock(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "TestRefreshLock")
+	require.NoError(t, err)
+	defer os.RemoveAll(dirPath)
+
+	lockPath := path.Join(dirPath, lockFilename)
+	require.NoError(t, ioutil.WriteFile(lockPath, nil, 0666))
+	expired := time.Now().Add(-2 * compactionLockTimeout)
+	require.NoError(t, os.Chtimes(lockPath, expired, expired))
+
+	doneC := make(chan struct{})
+	go refreshLock(lockPath, time.Millisecond, doneC)
+	defer close(doneC)
+	require.Eventually(t, func() bool {
+		info, err := os.Stat(lockPath)
+		return err == nil && time.Since(info.ModTime()) < compactionLockTimeout
+	}, time.Second, time.Millisecond)
+	_, locked, err := lockPartition(dirPath, 0666)
+	require.NoError(t, err)
+	require.False(t, locked, "a refreshed lock must not be considered stale")
+}
+
+func TestPartitionCompactor_Stop(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "TestPartitionCompactor_Stop")
+	require.NoError(t, err)
+	defer os.RemoveAll(dirPath)
+	for i := 0; i < 4; i++ {
+		require.NoError(t, ioutil.WriteFile(path.Join(dirPath, fmt.Sprintf("staged-%v%s", i, fileExtension)), nil, 0666))
+	}
+
+	merged := make(chan struct{}, 2)
+	releaseC := make(chan struct{})
+	compactor := newPartitionCompactor(loggerimpl.NewLogger(zap.NewNop()), 0666, 2, func(string, []string) ([]byte, map[string]int, error) {
+		merged <- struct{}{}
+		<-releaseC
+		return nil, nil, nil
+	})
+	compactor.maybeCompact(dirPath)
+	<-merged
+	stopped := make(chan struct{})
+	go func() {
+		compactor.stop()
+		close(stopped)
+	}()
+	require.Eventually(t, func() bool {
+		compactor.stopLock.Lock()
+		defer compactor.stopLock.Unlock()
+		return compactor.stopped
+	}, time.Second, time.Millisecond)
+	select {
+	case <-stopped:
+		t.Fatal("stop returned while a compaction was running")
+	default:
+	}
+	// the stop is observed after the current batch
+	close(releaseC)
+	<-stopped
+	require.Len(t, merged, 0, "the second batch must not be merged after stop")
+
+	staged, parts, err := listPartitionFiles(dirPath)
+	require.NoError(t, err)
+	require.Len(t, staged, 2, "the second batch must not be compacted after stop")
+	require.Len(t, parts, 1)
+	exists, err := util.FileExists(path.Join(dirPath, lockFilename))
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	// no compaction is started once the compactor is stopped
+	compactor.maybeCompact(dirPath)
+	compactor.wait()
+	staged, _, err = listPartitionFiles(dirPath)
+	require.NoError(t, err)
+	require.Len(t, staged, 2)
+}
+
+func TestCompact_Recover(t *testing.T) {
+	testCases := []struct {
+		name           string
+		partWritten    bool
+		expectedStaged []string
+		expectedParts  int
+	}{
+		{
+			name:           "part file written",
+			partWritten:    true,
+			expectedStaged: []string{"c.parquet"},
+			expectedParts:  1,
+		},
+		{
+			name:           "part file not written",
+			partWritten:    false,
+			expectedStaged: []string{"a.parquet", "b.parquet", "c.parquet"},
+			expectedParts:  0,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dirPath, err := ioutil.TempDir("", "TestCompactRecover")
+			require.NoError(t, err)
+			defer os.RemoveAll(dirPath)
+
+			for i, filename := range []string{"a.parquet", "b.parquet", "c.parquet"} {
+				data, err := encodeTable(historyColumns, []row{newTestHistoryRow(i)})
+				require.NoError(t, err)
+				require.NoError(t, util.WriteFile(path.Join(dirPath, filename), data, 0666))
+			}
+			// the compaction crashed before removing the staged files it merged
+			intent := &compactionIntent{
+				Part:      "part-1.parquet",
+				Files:     []string{"a.parquet", "b.parquet"},
+				RowGroups: map[string]int{"a.parquet": 0, "b.parquet": 1},
+			}
+			encodedIntent, err := json.Marshal(intent)
+			require.NoError(t, err)
+			require.NoError(t, util.WriteFile(path.Join(dirPath, intentFilename), encodedIntent, 0666))
+			if tc.partWritten {
+				data, _, err := mergeHistoryFiles(dirPath, intent.Files)
+				require.NoError(t, err)
+				require.NoError(t, util.WriteFile(path.Join(dirPath, intent.Part), data, 0666))
+			}
+
+			compactor := newPartitionCompactor(loggerimpl.NewLogger(zap.NewNop()), 0666, 10, mergeHistoryFiles)
+			require.NoError(t, compactor.compact(dirPath))
+
+			staged, parts, err := listPartitionFiles(dirPath)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStaged, staged)
+			require.Len(t, parts, tc.expectedParts)
+			exists, err := util.FileExists(path.Join(dirPath, intentFilename))
+			require.NoError(t, err)
+			require.False(t, exists)
+			index, err := readPartitionIndex(dirPath)
+			require.NoError(t, err)
+			require.Len(t, index, len(intent.Files)*tc.expectedParts)
+		})
+	}
+}
+
+func TestPartitionIndex(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "TestPartitionIndex")
+	require.NoError(t, err)
+	defer os.RemoveAll(dirPath)
+
+	index, err := readPartitionIndex(dirPath)
+	require.NoError(t, err)
+	require.Empty(t, index)
+
+	require.NoError(t, appendIndexEntry(dirPath, &partitionIndexEntry{
+		Part:      "part-1.parquet",
+		RowGroups: map[string]int{"a.parquet": 0, "b.parquet": 1},
+	}, 0666))
+	// partial line written by a crashed compaction
+	f, err := os.OpenFile(path.Join(dirPath, indexFilename), os.O_APPEND|os.O_WRONLY, 0666)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"Part":"part-2.parquet","RowGro`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.NoError(t, appendIndexEntry(dirPath, &partitionIndexEntry{
+		Part:      "part-3.parquet",
+		RowGroups: map[string]int{"b.parquet": 0, "c.parquet": 1},
+	}, 0666))
+
+	index, err = readPartitionIndex(dirPath)
+	require.NoError(t, err)
+	require.Equal(t, map[string]partLocation{
+		"a.parquet": {part: path.Join(dirPath, "part-1.parquet"), rowGroup: 0},
+		"b.parquet": {part: path.Join(dirPath, "part-3.parquet"), rowGroup: 0},
+		"c.parquet": {part: path.Join(dirPath, "part-3.parquet"), rowGroup: 1},
+	}, index)
+}
+
+func newTestHistoryRow(idx int) row {
+	return row{
+		testDomainID,
+		testDomainName,
+		testWorkflowID,
+		fmt.Sprintf("run-%v", idx),
+		int64(testCloseFailoverVersion),
+		int64(0),
+		int64(1),
+		testCloseTime.UnixNano(),
+		"WorkflowExecutionStarted",
+		int64(testCloseFailoverVersion),
+		int64(1),
+		"{}",
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package parquet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xwb1989/sqlparser"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/archiver"
+)
+
+type (
+	// QueryParser parses a SQL where clause and an optional ORDER BY clause into a struct
+	QueryParser interface {
+		Parse(query string) (*parsedQuery, error)
+	}
+
+	queryParser struct{}
+
+	parsedQuery struct {
+		// earliestCloseTime and latestCloseTime bound the close date partitions which are read and the records returned
+		earliestCloseTime int64
+		latestCloseTime   int64
+		filter            archiver.VisibilityQueryFilter
+		ascending         bool
+		emptyResult       bool
+	}
+)
+
+// All allowed fields for filtering, other field names are matched against search attributes
+const (
+	WorkflowID    = "WorkflowID"
+	RunID         = "RunID"
+	WorkflowType  = "WorkflowType"
+	StartTime     = "StartTime"
+	ExecutionTime = "ExecutionTime"
+	CloseTime     = "CloseTime"
+	CloseStatus   = "CloseStatus"
+	HistoryLength = "HistoryLength"
+)
+
+const (
+	queryTemplate = "select * from dummy where %s"
+)
+
+var queryFields = archiver.VisibilityQueryFields{
+	WorkflowID:    archiver.WorkflowIDQueryField,
+	RunID:         archiver.RunIDQueryField,
+	WorkflowType:  archiver.WorkflowTypeQueryField,
+	StartTime:     archiver.StartTimeQueryField,
+	ExecutionTime: archiver.ExecutionTimeQueryField,
+	CloseTime:     archiver.CloseTimeQueryField,
+	CloseStatus:   archiver.CloseStatusQueryField,
+	HistoryLength: archiver.HistoryLengthQueryField,
+}
+
+// NewQueryParser creates a new query parser for the parquet archiver
+func NewQueryParser() QueryParser {
+	return &queryParser{}
+}
+
+func (p *queryParser) Parse(query string) (*parsedQuery, error) {
+	stmt, err := sqlparser.Parse(fmt.Sprintf(queryTemplate, query))
+	if err != nil {
+		return nil, err
+	}
+	selectStmt, ok := stmt.(*sqlparser.Select)
+	if !ok || selectStmt.Where == nil {
+		return nil, errors.New("where expression is nil")
+	}
+	whereExpr := selectStmt.Where.Expr
+
+	parsedQuery := &parsedQuery{}
+	if parsedQuery.filter, err = archiver.CompileVisibilityQueryFilter([]sqlparser.Expr{whereExpr}, queryFields); err != nil {
+		return nil, err
+	}
+
+	// Workflows are closed after they are started, so a lower bound of the start time is one of the close time as well.
+	earliestCloseTime, latestCloseTime := archiver.VisibilityQueryTimeRange(whereExpr, CloseTime)
+	earliestStartTime, _ := archiver.VisibilityQueryTimeRange(whereExpr, StartTime)
+	parsedQuery.earliestCloseTime = common.MaxInt64(earliestCloseTime, earliestStartTime)
+	parsedQuery.latestCloseTime = latestCloseTime
+	if parsedQuery.earliestCloseTime > parsedQuery.latestCloseTime {
+		parsedQuery.emptyResult = true
+	}
+
+	order, err := archiver.ParseVisibilityQueryOrder(selectStmt.OrderBy, CloseTime)
+	if err != nil {
+		return nil, err
+	}
+	if order != nil {
+		parsedQuery.ascending = order.Ascending
+	}
+	return parsedQuery, nil
+}
@@ -0,0 +1,441 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package parquet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgryski/go-farm"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/backoff"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/common/util"
+)
+
+const (
+	historyDirName    = "history"
+	visibilityDirName = "visibility"
+
+	domainPartitionPrefix = "domain_id="
+	datePartitionPrefix   = "close_date="
+	datePartitionFormat   = "2006-01-02"
+
+	fileExtension = ".parquet"
+)
+
+var (
+	errEmptyDirectoryPath = errors.New("directory path is empty")
+	errInvalidFileMode    = errors.New("invalid file mode")
+	errInvalidDirMode     = errors.New("invalid directory mode")
+)
+
+// Column names are shared by the history and visibility tables where they have the same meaning,
+// so that both can be joined by analytics tools.
+var (
+	historyColumns = []column{
+		{name: "domain_id", typ: columnTypeString},
+		{name: "domain_name", typ: columnTypeString},
+		{name: "workflow_id", typ: columnTypeString},
+		{name: "run_id", typ: columnTypeString},
+		{name: "close_failover_version", typ: columnTypeInt64},
+		{name: "batch_index", typ: columnTypeInt64},
+		{name: "event_id", typ: columnTypeInt64},
+		{name: "event_time", typ: columnTypeTimestamp},
+		{name: "event_type", typ: columnTypeString},
+		{name: "version", typ: columnTypeInt64},
+		{name: "task_id", typ: columnTypeInt64},
+		// event holds the JSON encoded event including its attributes, it's the source of truth when
+		// reading histories back, the other columns are for filtering and aggregating only
+		{name: "event", typ: columnTypeString},
+	}
+
+	visibilityColumns = []column{
+		{name: "domain_id", typ: columnTypeString},
+		{name: "domain_name", typ: columnTypeString},
+		{name: "workflow_id", typ: columnTypeString},
+		{name: "run_id", typ: columnTypeString},
+		{name: "workflow_type", typ: columnTypeString},
+		{name: "start_time", typ: columnTypeTimestamp},
+		{name: "execution_time", typ: columnTypeTimestamp},
+		{name: "close_time", typ: columnTypeTimestamp},
+		{name: "close_status", typ: columnTypeString},
+		{name: "history_length", typ: columnTypeInt64},
+		{name: "memo", typ: columnTypeString, optional: true},
+		{name: "search_attributes", typ: columnTypeString, optional: true},
+		{name: "history_archival_uri", typ: columnTypeString, optional: true},
+	}
+)
+
+// encoding & decoding util
+
+func encodeHistoryBatches(request *archiver.ArchiveHistoryRequest, historyBatches []*types.History) ([]byte, error) {
+	var rows []row
+	for batchIdx, batch := range historyBatches {
+		for _, event := range batch.Events {
+			encodedEvent, err := json.Marshal(event)
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, row{
+				request.DomainID,
+				request.DomainName,
+				request.WorkflowID,
+				request.RunID,
+				request.CloseFailoverVersion,
+				int64(batchIdx),
+				event.ID,
+				event.GetTimestamp(),
+				event.GetEventType().String(),
+				event.Version,
+				event.TaskID,
+				string(encodedEvent),
+			})
+		}
+	}
+	return encodeTable(historyColumns, rows)
+}
+
+func decodeHistoryBatches(data []byte) ([]*types.History, error) {
+	rows, err := decodeTable(data, historyColumns)
+	if err != nil {
+		return nil, err
+	}
+	return convertRowsToHistoryBatches(rows)
+}
+
+func convertRowsToHistoryBatches(rows []row) ([]*types.History, error) {
+	historyBatches := []*types.History{}
+	lastBatchIdx := int64(-1)
+	for _, r := range rows {
+		event := &types.HistoryEvent{}
+		if err := json.Unmarshal([]byte(r[11].(string)), event); err != nil {
+			return nil, err
+		}
+		if batchIdx := r[5].(int64); batchIdx != lastBatchIdx {
+			historyBatches = append(historyBatches, &types.History{})
+			lastBatchIdx = batchIdx
+		}
+		batch := historyBatches[len(historyBatches)-1]
+		batch.Events = append(batch.Events, event)
+	}
+	return historyBatches, nil
+}
+
+// mergeHistoryFiles merges staged history files into a part file with one row group per workflow run
+func mergeHistoryFiles(dirPath string, filenames []string) ([]byte, map[string]int, error) {
+	rowGroups := make([][]row, 0, len(filenames))
+	index := make(map[string]int, len(filenames))
+	for _, filename := range filenames {
+		data, err := util.ReadFile(path.Join(dirPath, filename))
+		if err != nil {
+			return nil, nil, err
+		}
+		rows, err := decodeTable(data, historyColumns)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode %s: %v", filename, err)
+		}
+		index[filename] = len(rowGroups)
+		rowGroups = append(rowGroups, rows)
+	}
+	data, err := encodeTable(historyColumns, rowGroups...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, index, nil
+}
+
+func encodeVisibilityRecord(request *archiver.ArchiveVisibilityRequest) ([]byte, error) {
+	var memo, searchAttributes, historyArchivalURI interface{}
+	if request.Memo != nil && len(request.Memo.Fields) != 0 {
+		encodedMemo, err := json.Marshal(request.Memo)
+		if err != nil {
+			return nil, err
+		}
+		memo = string(encodedMemo)
+	}
+	if len(request.SearchAttributes) != 0 {
+		encodedSearchAttributes, err := json.Marshal(request.SearchAttributes)
+		if err != nil {
+			return nil, err
+		}
+		searchAttributes = string(encodedSearchAttributes)
+	}
+	if request.HistoryArchivalURI != "" {
+		historyArchivalURI = request.HistoryArchivalURI
+	}
+	closeStatus, err := request.CloseStatus.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return encodeTable(visibilityColumns, []row{{
+		request.DomainID,
+		request.DomainName,
+		request.WorkflowID,
+		request.RunID,
+		request.WorkflowTypeName,
+		request.StartTimestamp,
+		request.ExecutionTimestamp,
+		request.CloseTimestamp,
+		string(closeStatus),
+		request.HistoryLength,
+		memo,
+		searchAttributes,
+		historyArchivalURI,
+	}})
+}
+
+func decodeVisibilityRecords(data []byte) ([]*archiver.ArchiveVisibilityRequest, error) {
+	rows, err := decodeTable(data, visibilityColumns)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*archiver.ArchiveVisibilityRequest, 0, len(rows))
+	for _, r := range rows {
+		record := &archiver.ArchiveVisibilityRequest{
+			DomainID:           r[0].(string),
+			DomainName:         r[1].(string),
+			WorkflowID:         r[2].(string),
+			RunID:              r[3].(string),
+			WorkflowTypeName:   r[4].(string),
+			StartTimestamp:     r[5].(int64),
+			ExecutionTimestamp: r[6].(int64),
+			CloseTimestamp:     r[7].(int64),
+			HistoryLength:      r[9].(int64),
+		}
+		if err := record.CloseStatus.UnmarshalText([]byte(r[8].(string))); err != nil {
+			return nil, err
+		}
+		if memo, ok := r[10].(string); ok {
+			record.Memo = &types.Memo{}
+			if err := json.Unmarshal([]byte(memo), record.Memo); err != nil {
+				return nil, err
+			}
+		}
+		if searchAttributes, ok := r[11].(string); ok {
+			if err := json.Unmarshal([]byte(searchAttributes), &record.SearchAttributes); err != nil {
+				return nil, err
+			}
+		}
+		if historyArchivalURI, ok := r[12].(string); ok {
+			record.HistoryArchivalURI = historyArchivalURI
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// mergeVisibilityFiles merges staged visibility files into a part file with a single row group
+func mergeVisibilityFiles(dirPath string, filenames []string) ([]byte, map[string]int, error) {
+	var rows []row
+	for _, filename := range filenames {
+		data, err := util.ReadFile(path.Join(dirPath, filename))
+		if err != nil {
+			return nil, nil, err
+		}
+		fileRows, err := decodeTable(data, visibilityColumns)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode %s: %v", filename, err)
+		}
+		rows = append(rows, fileRows...)
+	}
+	data, err := encodeTable(visibilityColumns, rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, nil, nil
+}
+
+func serializeToken(token interface{}) ([]byte, error) {
+	if token == nil {
+		return nil, nil
+	}
+	return json.Marshal(token)
+}
+
+func deserializeGetHistoryToken(bytes []byte) (*getHistoryToken, error) {
+	token := &getHistoryToken{}
+	err := json.Unmarshal(bytes, token)
+	return token, err
+}
+
+func deserializeQueryVisibilityToken(bytes []byte) (*queryVisibilityToken, error) {
+	token := &queryVisibilityToken{}
+	err := json.Unmarshal(bytes, token)
+	return token, err
+}
+
+// Path construction
+
+// The files are partitioned Hive style by domain and close date, so that analytics tools can
+// prune partitions when a query filters on them. Staged files are named after the record they
+// hold and merged into part files by compactions, see partition.go:
+// <URI path>/history/domain_id=<domainID>/close_date=<YYYY-MM-DD>/<hash>_<version>.parquet
+// <URI path>/visibility/domain_id=<domainID>/close_date=<YYYY-MM-DD>/<closeTimestamp>_<hash>.parquet
+// <URI path>/<history|visibility>/domain_id=<domainID>/close_date=<YYYY-MM-DD>/part-<compactionTimestamp>.parquet
+
+func constructDomainDirPath(rootPath, tableDirName, domainID string) string {
+	return path.Join(rootPath, tableDirName, domainPartitionPrefix+domainID)
+}
+
+func constructDateDirName(timestamp int64) string {
+	return datePartitionPrefix + time.Unix(0, timestamp).UTC().Format(datePartitionFormat)
+}
+
+// parseDateDirName returns the start of the day of a close date partition
+func parseDateDirName(dirName string) (int64, error) {
+	if !strings.HasPrefix(dirName, datePartitionPrefix) {
+		return 0, fmt.Errorf("unknown partition %s", dirName)
+	}
+	date, err := time.Parse(datePartitionFormat, strings.TrimPrefix(dirName, datePartitionPrefix))
+	if err != nil {
+		return 0, err
+	}
+	return date.UnixNano(), nil
+}
+
+func constructHistoryFilename(domainID, workflowID, runID string, version int64) string {
+	combinedHash := constructHistoryFilenamePrefix(domainID, workflowID, runID)
+	return fmt.Sprintf("%s_%v%s", combinedHash, version, fileExtension)
+}
+
+func constructHistoryFilenamePrefix(domainID, workflowID, runID string) string {
+	return strings.Join([]string{hash(domainID), hash(workflowID), hash(runID)}, "")
+}
+
+func constructVisibilityFilename(closeTimestamp int64, runID string) string {
+	return fmt.Sprintf("%v_%s%s", closeTimestamp, hash(runID), fileExtension)
+}
+
+func hash(s string) string {
+	return fmt.Sprintf("%v", farm.Fingerprint64([]byte(s)))
+}
+
+// Validation
+
+func validateDirPath(dirPath string) error {
+	if len(dirPath) == 0 {
+		return errEmptyDirectoryPath
+	}
+	info, err := os.Stat(dirPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return util.ErrDirectoryExpected
+	}
+	return nil
+}
+
+// Misc.
+
+func parseFileModes(fileModeStr, dirModeStr string) (os.FileMode, os.FileMode, error) {
+	fileMode, err := strconv.ParseUint(fileModeStr, 0, 32)
+	if err != nil {
+		return 0, 0, errInvalidFileMode
+	}
+	dirMode, err := strconv.ParseUint(dirModeStr, 0, 32)
+	if err != nil {
+		return 0, 0, errInvalidDirMode
+	}
+	return os.FileMode(fileMode), os.FileMode(dirMode), nil
+}
+
+// listDateDirs returns the close date partitions of a domain which may contain files closed in the given range
+func listDateDirs(domainDirPath string, earliestCloseTime, latestCloseTime int64) ([]string, error) {
+	exists, err := util.DirectoryExists(domainDirPath)
+	if err != nil || !exists {
+		return nil, err
+	}
+	entries, err := os.ReadDir(domainDirPath)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dayStart, err := parseDateDirName(entry.Name())
+		if err != nil {
+			continue
+		}
+		if dayStart > latestCloseTime || dayStart+int64(24*time.Hour) <= earliestCloseTime {
+			continue
+		}
+		dirs = append(dirs, path.Join(domainDirPath, entry.Name()))
+	}
+	return dirs, nil
+}
+
+func extractCloseFailoverVersion(filename string) (int64, error) {
+	filenameParts := strings.FieldsFunc(strings.TrimSuffix(filename, fileExtension), func(r rune) bool {
+		return r == '_'
+	})
+	if len(filenameParts) != 2 {
+		return -1, errors.New("unknown filename structure")
+	}
+	return strconv.ParseInt(filenameParts[1], 10, 64)
+}
+
+func getNextHistoryBlob(ctx context.Context, historyIterator archiver.HistoryIterator) (*archiver.HistoryBlob, error) {
+	historyBlob, err := historyIterator.Next()
+	op := func() error {
+		historyBlob, err = historyIterator.Next()
+		return err
+	}
+	throttleRetry := backoff.NewThrottleRetry(
+		backoff.WithRetryPolicy(common.CreatePersistenceRetryPolicy()),
+		backoff.WithRetryableError(persistence.IsTransientError),
+	)
+	for err != nil {
+		if contextExpired(ctx) {
+			return nil, archiver.ErrContextTimeout
+		}
+		if !persistence.IsTransientError(err) {
+			return nil, err
+		}
+		err = throttleRetry.Do(ctx, op)
+	}
+	return historyBlob, nil
+}
+
+func contextExpired(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
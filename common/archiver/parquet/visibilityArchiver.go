@@ -0,0 +1,322 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package parquet
+
+import (
+	"context"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/config"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/common/util"
+)
+
+type (
+	visibilityArchiver struct {
+		container   *archiver.VisibilityBootstrapContainer
+		fileMode    os.FileMode
+		dirMode     os.FileMode
+		queryParser QueryParser
+		compactor   *partitionCompactor
+	}
+
+	queryVisibilityToken struct {
+		LastCloseTime int64
+		LastRunID     string
+	}
+)
+
+// NewVisibilityArchiver creates a new archiver.VisibilityArchiver based on parquet files
+func NewVisibilityArchiver(
+	container *archiver.VisibilityBootstrapContainer,
+	config *config.ParquetArchiver,
+) (archiver.VisibilityArchiver, error) {
+	fileMode, dirMode, err := parseFileModes(config.FileMode, config.DirMode)
+	if err != nil {
+		return nil, err
+	}
+	return &visibilityArchiver{
+		container:   container,
+		fileMode:    fileMode,
+		dirMode:     dirMode,
+		queryParser: NewQueryParser(),
+		compactor:   newPartitionCompactor(container.Logger, fileMode, config.CompactionBatchSize, mergeVisibilityFiles),
+	}, nil
+}
+
+func (v *visibilityArchiver) Archive(
+	ctx context.Context,
+	URI archiver.URI,
+	request *archiver.ArchiveVisibilityRequest,
+	opts ...archiver.ArchiveOption,
+) (err error) {
+	featureCatalog := archiver.GetFeatureCatalog(opts...)
+	defer func() {
+		if err != nil && featureCatalog.NonRetriableError != nil {
+			err = featureCatalog.NonRetriableError()
+		}
+	}()
+
+	logger := archiver.TagLoggerWithArchiveVisibilityRequestAndURI(v.container.Logger, request, URI.String())
+
+	if err := v.ValidateURI(URI); err != nil {
+		logger.Error(archiver.ArchiveNonRetriableErrorMsg, tag.ArchivalArchiveFailReason(archiver.ErrReasonInvalidURI), tag.Error(err))
+		return err
+	}
+
+	if err := archiver.ValidateVisibilityArchivalRequest(request); err != nil {
+		logger.Error(archiver.ArchiveNonRetriableErrorMsg, tag.ArchivalArchiveFailReason(archiver.ErrReasonInvalidArchiveRequest), tag.Error(err))
+		return err
+	}
+
+	encodedVisibilityRecord, err := encodeVisibilityRecord(request)
+	if err != nil {
+		logger.Error(archiver.ArchiveNonRetriableErrorMsg, tag.ArchivalArchiveFailReason(errEncodeVisibilityRecord), tag.Error(err))
+		return err
+	}
+
+	dirPath := path.Join(constructDomainDirPath(URI.Path(), visibilityDirName, request.DomainID), constructDateDirName(request.CloseTimestamp))
+	if err = util.MkdirAll(dirPath, v.dirMode); err != nil {
+		logger.Error(archiver.ArchiveNonRetriableErrorMsg, tag.ArchivalArchiveFailReason(errMakeDirectory), tag.Error(err))
+		return err
+	}
+
+	// The staged filename has the format: closeTimestamp_hash(runID).parquet
+	filename := constructVisibilityFilename(request.CloseTimestamp, request.RunID)
+	if err := writeFileAtomic(path.Join(dirPath, filename), encodedVisibilityRecord, v.fileMode); err != nil {
+		logger.Error(archiver.ArchiveNonRetriableErrorMsg, tag.ArchivalArchiveFailReason(errWriteFile), tag.Error(err))
+		return err
+	}
+
+	v.compactor.maybeCompact(dirPath)
+	return nil
+}
+
+func (v *visibilityArchiver) Query(
+	ctx context.Context,
+	URI archiver.URI,
+	request *archiver.QueryVisibilityRequest,
+) (*archiver.QueryVisibilityResponse, error) {
+	if err := v.ValidateURI(URI); err != nil {
+		return nil, &types.BadRequestError{Message: archiver.ErrInvalidURI.Error()}
+	}
+
+	if err := archiver.ValidateQueryRequest(request); err != nil {
+		return nil, &types.BadRequestError{Message: archiver.ErrInvalidQueryVisibilityRequest.Error()}
+	}
+
+	parsedQuery, err := v.queryParser.Parse(request.Query)
+	if err != nil {
+		return nil, &types.BadRequestError{Message: err.Error()}
+	}
+
+	if parsedQuery.emptyResult {
+		return &archiver.QueryVisibilityResponse{}, nil
+	}
+
+	var token *queryVisibilityToken
+	if request.NextPageToken != nil {
+		token, err = deserializeQueryVisibilityToken(request.NextPageToken)
+		if err != nil {
+			return nil, &types.BadRequestError{Message: archiver.ErrNextPageTokenCorrupted.Error()}
+		}
+	}
+
+	domainDirPath := constructDomainDirPath(URI.Path(), visibilityDirName, request.DomainID)
+	dirs, err := listDateDirs(domainDirPath, parsedQuery.earliestCloseTime, parsedQuery.latestCloseTime)
+	if err != nil {
+		return nil, &types.InternalServiceError{Message: err.Error()}
+	}
+
+	// partitions are listed by ascending close date, and all records of a partition are ordered
+	// before or after the ones of the next partition
+	if !parsedQuery.ascending {
+		for i, j := 0, len(dirs)-1; i < j; i, j = i+1, j-1 {
+			dirs[i], dirs[j] = dirs[j], dirs[i]
+		}
+	}
+
+	response := &archiver.QueryVisibilityResponse{}
+	for dirIdx, dir := range dirs {
+		if token != nil && partitionBeforeToken(dir, token, parsedQuery) {
+			continue
+		}
+		records, err := readVisibilityPartition(dir)
+		if err != nil {
+			return nil, &types.InternalServiceError{Message: err.Error()}
+		}
+		records = sortAndFilterRecords(records, token, parsedQuery)
+
+		for idx, record := range records {
+			if !parsedQuery.filter(record) {
+				continue
+			}
+			response.Executions = append(response.Executions, convertToExecutionInfo(record))
+			if len(response.Executions) == request.PageSize {
+				if idx != len(records)-1 || dirIdx != len(dirs)-1 {
+					newToken := &queryVisibilityToken{
+						LastCloseTime: record.CloseTimestamp,
+						LastRunID:     record.RunID,
+					}
+					encodedToken, err := serializeToken(newToken)
+					if err != nil {
+						return nil, &types.InternalServiceError{Message: err.Error()}
+					}
+					response.NextPageToken = encodedToken
+				}
+				return response, nil
+			}
+		}
+	}
+
+	return response, nil
+}
+
+// Stop stops the compactions started by Archive
+func (v *visibilityArchiver) Stop() {
+	v.compactor.stop()
+}
+
+func (v *visibilityArchiver) ValidateURI(URI archiver.URI) error {
+	if URI.Scheme() != URIScheme {
+		return archiver.ErrURISchemeMismatch
+	}
+
+	return validateDirPath(URI.Path())
+}
+
+// readVisibilityPartition returns the records of the staged and part files of a partition
+func readVisibilityPartition(dirPath string) ([]*archiver.ArchiveVisibilityRequest, error) {
+	for attempt := 0; ; attempt++ {
+		records, err := readVisibilityFiles(dirPath)
+		if os.IsNotExist(err) && attempt < 2 {
+			// a staged file was merged into a part file after it was listed
+			continue
+		}
+		return records, err
+	}
+}
+
+func readVisibilityFiles(dirPath string) ([]*archiver.ArchiveVisibilityRequest, error) {
+	staged, parts, err := listPartitionFiles(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	type recordKey struct {
+		closeTime int64
+		runID     string
+	}
+	seen := make(map[recordKey]struct{})
+	var records []*archiver.ArchiveVisibilityRequest
+	for _, filename := range append(parts, staged...) {
+		data, err := util.ReadFile(path.Join(dirPath, filename))
+		if err != nil {
+			return nil, err
+		}
+		fileRecords, err := decodeVisibilityRecords(data)
+		if err != nil {
+			return nil, err
+		}
+		// a record is in both a staged and a part file while the partition is compacted
+		for _, record := range fileRecords {
+			key := recordKey{closeTime: record.CloseTimestamp, runID: record.RunID}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// partitionBeforeToken reports whether all records of a close date partition are ordered before the nextPageToken
+func partitionBeforeToken(dirPath string, token *queryVisibilityToken, query *parsedQuery) bool {
+	dayStart, err := parseDateDirName(path.Base(dirPath))
+	if err != nil {
+		return false
+	}
+	if query.ascending {
+		return dayStart+int64(24*time.Hour) <= token.LastCloseTime
+	}
+	return dayStart > token.LastCloseTime
+}
+
+// sortAndFilterRecords sort visibility records based on close timestamp (desc unless the query is ascending) and use runID to break ties.
+// Only records with a close timestamp in the range of the query are returned, and if a nextPageToken is given, only those after it
+func sortAndFilterRecords(records []*archiver.ArchiveVisibilityRequest, token *queryVisibilityToken, query *parsedQuery) []*archiver.ArchiveVisibilityRequest {
+	var filtered []*archiver.ArchiveVisibilityRequest
+	for _, record := range records {
+		if record.CloseTimestamp < query.earliestCloseTime || record.CloseTimestamp > query.latestCloseTime {
+			continue
+		}
+		filtered = append(filtered, record)
+	}
+
+	// after reports whether a is ordered after b
+	after := func(aCloseTime int64, aRunID string, bCloseTime int64, bRunID string) bool {
+		if aCloseTime != bCloseTime {
+			return (aCloseTime > bCloseTime) == query.ascending
+		}
+		if aRunID != bRunID {
+			return (aRunID > bRunID) == query.ascending
+		}
+		return false
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		return after(filtered[j].CloseTimestamp, filtered[j].RunID, filtered[i].CloseTimestamp, filtered[i].RunID)
+	})
+
+	if token == nil {
+		return filtered
+	}
+	startIdx := sort.Search(len(filtered), func(i int) bool {
+		return after(filtered[i].CloseTimestamp, filtered[i].RunID, token.LastCloseTime, token.LastRunID)
+	})
+	return filtered[startIdx:]
+}
+
+func convertToExecutionInfo(record *archiver.ArchiveVisibilityRequest) *types.WorkflowExecutionInfo {
+	return &types.WorkflowExecutionInfo{
+		Execution: &types.WorkflowExecution{
+			WorkflowID: record.WorkflowID,
+			RunID:      record.RunID,
+		},
+		Type: &types.WorkflowType{
+			Name: record.WorkflowTypeName,
+		},
+		StartTime:     common.Int64Ptr(record.StartTimestamp),
+		ExecutionTime: common.Int64Ptr(record.ExecutionTimestamp),
+		CloseTime:     common.Int64Ptr(record.CloseTimestamp),
+		CloseStatus:   record.CloseStatus.Ptr(),
+		HistoryLength: record.HistoryLength,
+		Memo:          record.Memo,
+		SearchAttributes: &types.SearchAttributes{
+			IndexedFields: archiver.ConvertSearchAttrToBytes(record.SearchAttributes),
+		},
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package parquet
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/config"
+	"github.com/uber/cadence/common/log/loggerimpl"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/common/util"
+)
+
+type visibilityArchiverSuite struct {
+	*require.Assertions
+	suite.Suite
+
+	container *archiver.VisibilityBootstrapContainer
+	testDir   string
+	URI       archiver.URI
+}
+
+func TestVisibilityArchiverSuite(t *testing.T) {
+	suite.Run(t, new(visibilityArchiverSuite))
+}
+
+func (s *visibilityArchiverSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.container = &archiver.VisibilityBootstrapContainer{
+		Logger: loggerimpl.NewLogger(zap.NewNop()),
+	}
+	var err error
+	s.testDir, err = ioutil.TempDir("", "TestParquetVisibility")
+	s.NoError(err)
+	s.URI, err = archiver.NewURI("parquet://" + s.testDir)
+	s.NoError(err)
+}
+
+func (s *visibilityArchiverSuite) TearDownTest() {
+	os.RemoveAll(s.testDir)
+}
+
+func (s *visibilityArchiverSuite) TestArchive_Fail_InvalidRequest() {
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	err := visibilityArchiver.Archive(context.Background(), s.URI, &archiver.ArchiveVisibilityRequest{})
+	s.Error(err)
+}
+
+func (s *visibilityArchiverSuite) TestArchive_Success() {
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	request := s.newVisibilityRecord(0, testCloseTime)
+	request.Memo = &types.Memo{
+		Fields: map[string][]byte{"memo": []byte("value")},
+	}
+	request.SearchAttributes = map[string]string{"CustomKeywordField": `"keyword"`}
+	request.HistoryArchivalURI = "parquet:///history"
+	s.NoError(visibilityArchiver.Archive(context.Background(), s.URI, request))
+
+	filepath := path.Join(
+		s.testDir,
+		visibilityDirName,
+		"domain_id="+testDomainID,
+		"close_date=2022-03-14",
+		constructVisibilityFilename(request.CloseTimestamp, request.RunID),
+	)
+	data, err := util.ReadFile(filepath)
+	s.NoError(err)
+	records, err := decodeVisibilityRecords(data)
+	s.NoError(err)
+	s.Equal([]*archiver.ArchiveVisibilityRequest{request}, records)
+}
+
+func (s *visibilityArchiverSuite) TestQuery_Fail_InvalidQuery() {
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	for _, query := range []string{"invalid query", "WorkflowId = 'id'", "WorkflowID = 'id' order by StartTime"} {
+		response, err := visibilityArchiver.Query(context.Background(), s.URI, &archiver.QueryVisibilityRequest{
+			DomainID: testDomainID,
+			PageSize: 1,
+			Query:    query,
+		})
+		s.Nil(response)
+		s.IsType(&types.BadRequestError{}, err)
+	}
+}
+
+func (s *visibilityArchiverSuite) TestQuery_Success_DirectoryNotExist() {
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	response, err := visibilityArchiver.Query(context.Background(), s.URI, &archiver.QueryVisibilityRequest{
+		DomainID: testDomainID,
+		PageSize: 1,
+		Query:    "CloseStatus = 'Completed'",
+	})
+	s.NoError(err)
+	s.Empty(response.Executions)
+	s.Nil(response.NextPageToken)
+}
+
+func (s *visibilityArchiverSuite) TestArchiveAndQuery() {
+	s.testArchiveAndQuery(0, [][2]int{{2, 0}, {4, 0}, {4, 0}})
+}
+
+func (s *visibilityArchiverSuite) TestArchiveAndQuery_Compacted() {
+	// the last two partitions have four records each, three of which are compacted, and one of the
+	// compacted records is staged again when it's archived a second time
+	s.testArchiveAndQuery(3, [][2]int{{2, 0}, {2, 1}, {1, 1}})
+}
+
+// testArchiveAndQuery archives the records and expects the partitions to hold the given number of staged and part files
+func (s *visibilityArchiverSuite) testArchiveAndQuery(batchSize int, expectedFiles [][2]int) {
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	if batchSize != 0 {
+		visibilityArchiver.compactor.batchSize = batchSize
+	}
+	// ten records closed every six hours, so they span three close date partitions
+	var records []*archiver.ArchiveVisibilityRequest
+	for i := 0; i < 10; i++ {
+		record := s.newVisibilityRecord(i, testCloseTime.Add(time.Duration(i)*6*time.Hour))
+		s.NoError(visibilityArchiver.Archive(context.Background(), s.URI, record))
+		visibilityArchiver.compactor.wait()
+		records = append(records, record)
+	}
+	// archived again after it was compacted
+	s.NoError(visibilityArchiver.Archive(context.Background(), s.URI, records[3]))
+	visibilityArchiver.compactor.wait()
+
+	dirs, err := listDateDirs(constructDomainDirPath(s.testDir, visibilityDirName, testDomainID), 0, testCloseTime.Add(time.Hour*24*365).UnixNano())
+	s.NoError(err)
+	s.Len(dirs, 3)
+	for i, dir := range dirs {
+		staged, parts, err := listPartitionFiles(dir)
+		s.NoError(err)
+		s.Len(staged, expectedFiles[i][0], dir)
+		s.Len(parts, expectedFiles[i][1], dir)
+	}
+
+	testCases := []struct {
+		query    string
+		expected []int
+	}{
+		{
+			query:    "CloseStatus = 'Completed'",
+			expected: []int{8, 6, 4, 2, 0},
+		},
+		{
+			query:    "CloseStatus = 'Completed' order by CloseTime asc",
+			expected: []int{0, 2, 4, 6, 8},
+		},
+		{
+			query:    fmt.Sprintf("CloseTime >= '%s' and HistoryLength > 3", testCloseTime.Add(24*time.Hour).Format(time.RFC3339)),
+			expected: []int{9, 8, 7, 6, 5, 4},
+		},
+		{
+			query:    fmt.Sprintf("CloseTime < %v or WorkflowID = 'workflow-9'", testCloseTime.Add(time.Hour).UnixNano()),
+			expected: []int{9, 0},
+		},
+		{
+			query:    "WorkflowType = 'type' and CustomIntField between 3 and 5",
+			expected: []int{5, 4, 3},
+		},
+		{
+			query:    "CloseStatus = 'Failed' and CloseStatus = 'Completed'",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		request := &archiver.QueryVisibilityRequest{
+			DomainID: testDomainID,
+			PageSize: 2,
+			Query:    tc.query,
+		}
+		var executions []*types.WorkflowExecutionInfo
+		for {
+			response, err := visibilityArchiver.Query(context.Background(), s.URI, request)
+			s.NoError(err, tc.query)
+			executions = append(executions, response.Executions...)
+			if response.NextPageToken == nil {
+				break
+			}
+			request.NextPageToken = response.NextPageToken
+		}
+
+		s.Len(executions, len(tc.expected), tc.query)
+		for i, idx := range tc.expected {
+			s.Equal(convertToExecutionInfo(records[idx]), executions[i], tc.query)
+		}
+	}
+}
+
+func (s *visibilityArchiverSuite) newVisibilityRecord(idx int, closeTime time.Time) *archiver.ArchiveVisibilityRequest {
+	closeStatus := types.WorkflowExecutionCloseStatusCompleted
+	if idx%2 == 1 {
+		closeStatus = types.WorkflowExecutionCloseStatusFailed
+	}
+	return &archiver.ArchiveVisibilityRequest{
+		DomainID:           testDomainID,
+		DomainName:         testDomainName,
+		WorkflowID:         fmt.Sprintf("workflow-%v", idx),
+		RunID:              fmt.Sprintf("run-%v", idx),
+		WorkflowTypeName:   "type",
+		StartTimestamp:     closeTime.Add(-time.Hour).UnixNano(),
+		ExecutionTimestamp: closeTime.Add(-time.Hour).UnixNano(),
+		CloseTimestamp:     closeTime.UnixNano(),
+		CloseStatus:        closeStatus,
+		HistoryLength:      int64(idx),
+		SearchAttributes:   map[string]string{"CustomIntField": fmt.Sprintf("%v", idx)},
+	}
+}
+
+func (s *visibilityArchiverSuite) newTestVisibilityArchiver() *visibilityArchiver {
+	config := &config.ParquetArchiver{
+		FileMode: testFileModeStr,
+		DirMode:  testDirModeStr,
+	}
+	archiver, err := NewVisibilityArchiver(s.container, config)
+	s.NoError(err)
+	return archiver.(*visibilityArchiver)
+}
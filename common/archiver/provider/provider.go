@@ -22,12 +22,14 @@ package provider
 
 import (
 	"errors"
+	"strings"
 	"sync"
 
 	"github.com/uber/cadence/common/archiver/gcloud"
 
 	"github.com/uber/cadence/common/archiver"
 	"github.com/uber/cadence/common/archiver/filestore"
+	"github.com/uber/cadence/common/archiver/parquet"
 	"github.com/uber/cadence/common/archiver/s3store"
 	"github.com/uber/cadence/common/config"
 )
@@ -56,6 +58,8 @@ type (
 		) error
 		GetHistoryArchiver(scheme, serviceName string) (archiver.HistoryArchiver, error)
 		GetVisibilityArchiver(scheme, serviceName string) (archiver.VisibilityArchiver, error)
+		// Stop stops the background work of the archivers created for the service
+		Stop(serviceName string)
 	}
 
 	archiverProvider struct {
@@ -151,6 +155,12 @@ func (p *archiverProvider) GetHistoryArchiver(scheme, serviceName string) (histo
 			return nil, ErrArchiverConfigNotFound
 		}
 		historyArchiver, err = s3store.NewHistoryArchiver(container, p.historyArchiverConfigs.S3store)
+
+	case parquet.URIScheme:
+		if p.historyArchiverConfigs.Parquet == nil {
+			return nil, ErrArchiverConfigNotFound
+		}
+		historyArchiver, err = parquet.NewHistoryArchiver(container, p.historyArchiverConfigs.Parquet)
 	default:
 		return nil, ErrUnknownScheme
 	}
@@ -201,6 +211,11 @@ func (p *archiverProvider) GetVisibilityArchiver(scheme, serviceName string) (ar
 			return nil, ErrArchiverConfigNotFound
 		}
 		visibilityArchiver, err = gcloud.NewVisibilityArchiver(container, p.visibilityArchiverConfigs.Gstorage)
+	case parquet.URIScheme:
+		if p.visibilityArchiverConfigs.Parquet == nil {
+			return nil, ErrArchiverConfigNotFound
+		}
+		visibilityArchiver, err = parquet.NewVisibilityArchiver(container, p.visibilityArchiverConfigs.Parquet)
 
 	default:
 		return nil, ErrUnknownScheme
@@ -219,6 +234,26 @@ func (p *archiverProvider) GetVisibilityArchiver(scheme, serviceName string) (ar
 
 }
 
+func (p *archiverProvider) Stop(serviceName string) {
+	var stoppables []archiver.Stoppable
+	p.RLock()
+	for key, historyArchiver := range p.historyArchivers {
+		if s, ok := historyArchiver.(archiver.Stoppable); ok && strings.HasSuffix(key, ":"+serviceName) {
+			stoppables = append(stoppables, s)
+		}
+	}
+	for key, visibilityArchiver := range p.visibilityArchivers {
+		if s, ok := visibilityArchiver.(archiver.Stoppable); ok && strings.HasSuffix(key, ":"+serviceName) {
+			stoppables = append(stoppables, s)
+		}
+	}
+	p.RUnlock()
+
+	for _, s := range stoppables {
+		s.Stop()
+	}
+}
+
 func (p *archiverProvider) getArchiverKey(scheme, serviceName string) string {
 	return scheme + ":" + serviceName
 }
@@ -92,3 +92,8 @@ func (_m *MockArchiverProvider) RegisterBootstrapContainer(serviceName string, h
 
 	return r0
 }
+
+// Stop provides a mock function with given fields: serviceName
+func (_m *MockArchiverProvider) Stop(serviceName string) {
+	_m.Called(serviceName)
+}
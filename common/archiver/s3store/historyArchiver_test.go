@@ -156,11 +156,9 @@ func setupFsEmulation(s3cli *mocks.S3API) {
 			}
 
 			if input.StartAfter != nil {
-				for k, v := range objects {
-					if *input.StartAfter == *v.Key {
-						start = k + 1
-					}
-				}
+				start = sort.Search(len(objects), func(k int) bool {
+					return *objects[k].Key > *input.StartAfter
+				})
 			}
 
 			isTruncated := false
@@ -31,10 +31,11 @@ import (
 	"github.com/xwb1989/sqlparser"
 
 	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/archiver"
 )
 
 type (
-	// QueryParser parses a limited SQL where clause into a struct
+	// QueryParser parses a SQL where clause and an optional ORDER BY clause into a struct
 	QueryParser interface {
 		Parse(query string) (*parsedQuery, error)
 	}
@@ -47,15 +48,26 @@ type (
 		startTime        *int64
 		closeTime        *int64
 		searchPrecision  *string
+		// filter matches the conditions which can't be expressed by the fields above, it's nil if there are none
+		filter archiver.VisibilityQueryFilter
+		// earliestTime and latestTime bound the time of the searched index (start or close time),
+		// they are used to skip over keys which can't match. Zero values mean unbounded.
+		earliestTime     int64
+		latestTime       int64
+		orderByStartTime bool
 	}
 )
 
-// All allowed fields for filtering
+// All allowed fields for filtering, other field names are matched against search attributes
 const (
 	WorkflowTypeName = "WorkflowTypeName"
 	WorkflowID       = "WorkflowID"
+	RunID            = "RunID"
 	StartTime        = "StartTime"
+	ExecutionTime    = "ExecutionTime"
 	CloseTime        = "CloseTime"
+	CloseStatus      = "CloseStatus"
+	HistoryLength    = "HistoryLength"
 	SearchPrecision  = "SearchPrecision"
 )
 
@@ -71,6 +83,18 @@ const (
 	defaultDateTimeFormat = time.RFC3339
 )
 
+var queryFields = archiver.VisibilityQueryFields{
+	WorkflowTypeName: archiver.WorkflowTypeQueryField,
+	WorkflowID:       archiver.WorkflowIDQueryField,
+	RunID:            archiver.RunIDQueryField,
+	StartTime:        archiver.StartTimeQueryField,
+	ExecutionTime:    archiver.ExecutionTimeQueryField,
+	CloseTime:        archiver.CloseTimeQueryField,
+	CloseStatus:      archiver.CloseStatusQueryField,
+	HistoryLength:    archiver.HistoryLengthQueryField,
+	SearchPrecision:  nil,
+}
+
 // NewQueryParser creates a new query parser for filestore
 func NewQueryParser() QueryParser {
 	return &queryParser{}
@@ -81,17 +105,16 @@ func (p *queryParser) Parse(query string) (*parsedQuery, error) {
 	if err != nil {
 		return nil, err
 	}
-	whereExpr := stmt.(*sqlparser.Select).Where.Expr
+	selectStmt := stmt.(*sqlparser.Select)
+	whereExpr := selectStmt.Where.Expr
 	parsedQuery := &parsedQuery{}
-	if err := p.convertWhereExpr(whereExpr, parsedQuery); err != nil {
+	var filterExprs []sqlparser.Expr
+	if err := p.convertWhereExpr(whereExpr, parsedQuery, &filterExprs); err != nil {
 		return nil, err
 	}
 	if parsedQuery.workflowID == nil && parsedQuery.workflowTypeName == nil {
 		return nil, errors.New("WorkflowID or WorkflowTypeName is required in query")
 	}
-	if parsedQuery.workflowID != nil && parsedQuery.workflowTypeName != nil {
-		return nil, errors.New("only one of WorkflowID or WorkflowTypeName can be specified in a query")
-	}
 	if parsedQuery.closeTime != nil && parsedQuery.startTime != nil {
 		return nil, errors.New("only one of StartTime or CloseTime can be specified in a query")
 	}
@@ -102,103 +125,132 @@ func (p *queryParser) Parse(query string) (*parsedQuery, error) {
 	if parsedQuery.closeTime == nil && parsedQuery.startTime == nil && parsedQuery.searchPrecision != nil {
 		return nil, errors.New("SearchPrecision requires a StartTime or CloseTime")
 	}
+	if len(filterExprs) != 0 {
+		if parsedQuery.filter, err = archiver.CompileVisibilityQueryFilter(filterExprs, queryFields); err != nil {
+			return nil, err
+		}
+	}
+
+	order, err := archiver.ParseVisibilityQueryOrder(selectStmt.OrderBy, CloseTime, StartTime)
+	if err != nil {
+		return nil, err
+	}
+	if order != nil {
+		if !order.Ascending {
+			return nil, errors.New("only ascending order is supported with Amazon S3")
+		}
+		if (order.Field == StartTime && parsedQuery.closeTime != nil) || (order.Field == CloseTime && parsedQuery.startTime != nil) {
+			return nil, fmt.Errorf("results can only be ordered by the time field used with %s", SearchPrecision)
+		}
+		parsedQuery.orderByStartTime = order.Field == StartTime
+	}
+	if parsedQuery.startTime != nil {
+		parsedQuery.orderByStartTime = true
+	}
+
+	// Keys of the close time index can be skipped based on start time conditions as well,
+	// as workflows are closed after they are started.
+	if parsedQuery.orderByStartTime {
+		parsedQuery.earliestTime, parsedQuery.latestTime = archiver.VisibilityQueryTimeRange(whereExpr, StartTime)
+	} else {
+		parsedQuery.earliestTime, parsedQuery.latestTime = archiver.VisibilityQueryTimeRange(whereExpr, CloseTime)
+		earliestStartTime, _ := archiver.VisibilityQueryTimeRange(whereExpr, StartTime)
+		parsedQuery.earliestTime = common.MaxInt64(parsedQuery.earliestTime, earliestStartTime)
+	}
 	return parsedQuery, nil
 }
 
-func (p *queryParser) convertWhereExpr(expr sqlparser.Expr, parsedQuery *parsedQuery) error {
+// convertWhereExpr converts the top level conjunction of the where expression, conditions which
+// can't be converted into parsedQuery fields are added to filterExprs
+func (p *queryParser) convertWhereExpr(expr sqlparser.Expr, parsedQuery *parsedQuery, filterExprs *[]sqlparser.Expr) error {
 	if expr == nil {
 		return errors.New("where expression is nil")
 	}
 
 	switch expr := expr.(type) {
-	case *sqlparser.ComparisonExpr:
-		return p.convertComparisonExpr(expr, parsedQuery)
 	case *sqlparser.AndExpr:
-		return p.convertAndExpr(expr, parsedQuery)
+		if err := p.convertWhereExpr(expr.Left, parsedQuery, filterExprs); err != nil {
+			return err
+		}
+		return p.convertWhereExpr(expr.Right, parsedQuery, filterExprs)
 	case *sqlparser.ParenExpr:
-		return p.convertParenExpr(expr, parsedQuery)
-	default:
-		return errors.New("only comparison and \"and\" expression is supported")
-	}
-}
-
-func (p *queryParser) convertParenExpr(parenExpr *sqlparser.ParenExpr, parsedQuery *parsedQuery) error {
-	return p.convertWhereExpr(parenExpr.Expr, parsedQuery)
-}
-
-func (p *queryParser) convertAndExpr(andExpr *sqlparser.AndExpr, parsedQuery *parsedQuery) error {
-	if err := p.convertWhereExpr(andExpr.Left, parsedQuery); err != nil {
-		return err
+		return p.convertWhereExpr(expr.Expr, parsedQuery, filterExprs)
+	case *sqlparser.ComparisonExpr:
+		converted, err := p.convertComparisonExpr(expr, parsedQuery)
+		if err != nil || converted {
+			return err
+		}
 	}
-	return p.convertWhereExpr(andExpr.Right, parsedQuery)
+	*filterExprs = append(*filterExprs, expr)
+	return nil
 }
 
-func (p *queryParser) convertComparisonExpr(compExpr *sqlparser.ComparisonExpr, parsedQuery *parsedQuery) error {
+// convertComparisonExpr converts the conditions which select the searched index into parsedQuery fields,
+// it returns false for conditions which need to be evaluated by the filter
+func (p *queryParser) convertComparisonExpr(compExpr *sqlparser.ComparisonExpr, parsedQuery *parsedQuery) (bool, error) {
 	colName, ok := compExpr.Left.(*sqlparser.ColName)
 	if !ok {
-		return fmt.Errorf("invalid filter name: %s", sqlparser.String(compExpr.Left))
+		return false, nil
 	}
 	colNameStr := sqlparser.String(colName)
 	op := compExpr.Operator
 	valExpr, ok := compExpr.Right.(*sqlparser.SQLVal)
 	if !ok {
-		return fmt.Errorf("invalid value: %s", sqlparser.String(compExpr.Right))
+		return false, nil
 	}
 	valStr := sqlparser.String(valExpr)
+	if op != "=" {
+		if colNameStr == SearchPrecision {
+			return false, fmt.Errorf("only operator = is supported for %s with Amazon S3", SearchPrecision)
+		}
+		return false, nil
+	}
 
 	switch colNameStr {
 	case WorkflowTypeName:
 		val, err := extractStringValue(valStr)
 		if err != nil {
-			return err
-		}
-		if op != "=" {
-			return fmt.Errorf("only operator = is supported for %s with Amazon S3", WorkflowTypeName)
+			return false, err
 		}
-		if parsedQuery.workflowTypeName != nil {
-			return fmt.Errorf("can not query %s multiple times", WorkflowTypeName)
+		if parsedQuery.workflowTypeName != nil || parsedQuery.workflowID != nil {
+			// only one index can be searched, the first condition selects it and further ones are evaluated by the filter
+			return false, nil
 		}
 		parsedQuery.workflowTypeName = common.StringPtr(val)
 	case WorkflowID:
 		val, err := extractStringValue(valStr)
 		if err != nil {
-			return err
+			return false, err
 		}
-		if op != "=" {
-			return fmt.Errorf("only operator = is supported for %s with Amazon S3", WorkflowID)
-		}
-		if parsedQuery.workflowID != nil {
-			return fmt.Errorf("can not query %s multiple times", WorkflowID)
+		if parsedQuery.workflowTypeName != nil || parsedQuery.workflowID != nil {
+			return false, nil
 		}
 		parsedQuery.workflowID = common.StringPtr(val)
 	case CloseTime:
 		timestamp, err := convertToTimestamp(valStr)
 		if err != nil {
-			return err
+			return false, err
 		}
-		if op != "=" {
-			return fmt.Errorf("only operator = is supported for %s with Amazon S3", CloseTime)
+		if parsedQuery.closeTime != nil {
+			return false, nil
 		}
 		parsedQuery.closeTime = &timestamp
 	case StartTime:
 		timestamp, err := convertToTimestamp(valStr)
 		if err != nil {
-			return err
+			return false, err
 		}
-		if op != "=" {
-			return fmt.Errorf("only operator = is supported for %s with Amazon S3", StartTime)
+		if parsedQuery.startTime != nil {
+			return false, nil
 		}
 		parsedQuery.startTime = &timestamp
 	case SearchPrecision:
 		val, err := extractStringValue(valStr)
 		if err != nil {
-			return err
-		}
-		if op != "=" {
-			return fmt.Errorf("only operator = is supported for %s with Amazon S3", SearchPrecision)
+			return false, err
 		}
 		if parsedQuery.searchPrecision != nil && *parsedQuery.searchPrecision != val {
-			return fmt.Errorf("only one expression is allowed for %s", SearchPrecision)
+			return false, fmt.Errorf("only one expression is allowed for %s", SearchPrecision)
 		}
 		switch val {
 		case PrecisionDay:
@@ -206,15 +258,14 @@ func (p *queryParser) convertComparisonExpr(compExpr *sqlparser.ComparisonExpr,
 		case PrecisionMinute:
 		case PrecisionSecond:
 		default:
-			return fmt.Errorf("invalid value for %s: %s", SearchPrecision, val)
+			return false, fmt.Errorf("invalid value for %s: %s", SearchPrecision, val)
 		}
 		parsedQuery.searchPrecision = common.StringPtr(val)
-
 	default:
-		return fmt.Errorf("unknown filter name: %s", colNameStr)
+		return false, nil
 	}
 
-	return nil
+	return true, nil
 }
 
 func convertToTimestamp(timeStr string) (int64, error) {
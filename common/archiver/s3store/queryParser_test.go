@@ -21,12 +21,15 @@
 package s3store
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/types"
 )
 
 type queryParserSuite struct {
@@ -67,11 +70,17 @@ func (s *queryParserSuite) TestParseWorkflowIDAndWorkflowTypeName() {
 		},
 		{
 			query:     "WorkflowID = \"random workflowID\" and WorkflowTypeName = \"random workflowTypeName\"",
-			expectErr: true,
+			expectErr: false,
+			parsedQuery: &parsedQuery{
+				workflowID: common.StringPtr("random workflowID"),
+			},
 		},
 		{
 			query:     "WorkflowID = \"random workflowID\" and WorkflowID = \"random workflowID\"",
-			expectErr: true,
+			expectErr: false,
+			parsedQuery: &parsedQuery{
+				workflowID: common.StringPtr("random workflowID"),
+			},
 		},
 		{
 			query:     "RunID = \"random runID\"",
@@ -268,3 +277,73 @@ func (s *queryParserSuite) TestParseStartTime() {
 		s.Equal(tc.parsedQuery.closeTime, parsedQuery.closeTime)
 	}
 }
+
+func (s *queryParserSuite) TestParseFilterAndOrder() {
+	record := &archiver.ArchiveVisibilityRequest{
+		WorkflowID:       "random workflowID",
+		RunID:            "random runID",
+		WorkflowTypeName: "random workflowTypeName",
+		StartTimestamp:   1000,
+		CloseTimestamp:   2000,
+		CloseStatus:      types.WorkflowExecutionCloseStatusFailed,
+		SearchAttributes: map[string]string{"CustomKeywordField": `["a","b"]`},
+	}
+	testCases := []struct {
+		query        string
+		expectErr    bool
+		shouldMatch  bool
+		earliestTime int64
+		latestTime   int64
+		byStartTime  bool
+	}{
+		{
+			query:        "WorkflowID = 'random workflowID' and (CloseStatus = 'Failed' or RunID IN ('another runID')) and CloseTime > 1500",
+			shouldMatch:  true,
+			earliestTime: 1501,
+			latestTime:   math.MaxInt64,
+		},
+		{
+			query:        "WorkflowTypeName = 'random workflowTypeName' and CustomKeywordField = 'b' and StartTime BETWEEN 500 AND 900 order by StartTime",
+			shouldMatch:  false,
+			earliestTime: 500,
+			latestTime:   900,
+			byStartTime:  true,
+		},
+		{
+			query:        "WorkflowID = 'random workflowID' and StartTime >= 1000 and NOT (CustomKeywordField = 'c')",
+			shouldMatch:  true,
+			earliestTime: 1000,
+			latestTime:   math.MaxInt64,
+		},
+		{
+			query:     "WorkflowID = 'random workflowID' or WorkflowID = 'another workflowID'",
+			expectErr: true,
+		},
+		{
+			query:     "WorkflowID = 'random workflowID' and (CloseTime = 1000 or SearchPrecision = 'Day')",
+			expectErr: true,
+		},
+		{
+			query:     "WorkflowID = 'random workflowID' and CloseStatus = 'Failed' order by CloseTime desc",
+			expectErr: true,
+		},
+		{
+			query:     "WorkflowID = 'random workflowID' and StartTime = 1000 and SearchPrecision = 'Day' order by CloseTime",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		parsedQuery, err := s.parser.Parse(tc.query)
+		if tc.expectErr {
+			s.Error(err, tc.query)
+			continue
+		}
+		s.NoError(err, tc.query)
+		s.NotNil(parsedQuery.filter, tc.query)
+		s.Equal(tc.shouldMatch, parsedQuery.filter(record), tc.query)
+		s.Equal(tc.earliestTime, parsedQuery.earliestTime, tc.query)
+		s.Equal(tc.latestTime, parsedQuery.latestTime, tc.query)
+		s.Equal(tc.byStartTime, parsedQuery.orderByStartTime, tc.query)
+	}
+}
@@ -22,6 +22,8 @@ package s3store
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/uber/cadence/common/metrics"
 
@@ -201,7 +203,12 @@ func (v *visibilityArchiver) query(
 		primaryIndex = primaryIndexKeyWorkflowID
 		primaryIndexValue = request.parsedQuery.workflowID
 	}
-	var prefix = constructVisibilitySearchPrefix(URI.Path(), request.domainID, primaryIndex, *primaryIndexValue, secondaryIndexKeyCloseTimeout) + "/"
+	secondaryIndex := secondaryIndexKeyCloseTimeout
+	if request.parsedQuery.orderByStartTime {
+		secondaryIndex = secondaryIndexKeyStartTimeout
+	}
+	indexPrefix := constructVisibilitySearchPrefix(URI.Path(), request.domainID, primaryIndex, *primaryIndexValue, secondaryIndex)
+	var prefix = indexPrefix + "/"
 	if request.parsedQuery.closeTime != nil {
 		prefix = constructTimeBasedSearchKey(URI.Path(), request.domainID, primaryIndex, *primaryIndexValue, secondaryIndexKeyCloseTimeout, *request.parsedQuery.closeTime, *request.parsedQuery.searchPrecision)
 	}
@@ -209,39 +216,70 @@ func (v *visibilityArchiver) query(
 		prefix = constructTimeBasedSearchKey(URI.Path(), request.domainID, primaryIndex, *primaryIndexValue, secondaryIndexKeyStartTimeout, *request.parsedQuery.startTime, *request.parsedQuery.searchPrecision)
 	}
 
-	results, err := v.s3cli.ListObjectsV2WithContext(ctx, &s3.ListObjectsV2Input{
-		Bucket:            aws.String(URI.Hostname()),
-		Prefix:            aws.String(prefix),
-		MaxKeys:           aws.Int64(int64(request.pageSize)),
-		ContinuationToken: token,
-	})
-	if err != nil {
-		if isRetryableError(err) {
-			return nil, &types.InternalServiceError{Message: err.Error()}
-		}
-		return nil, &types.BadRequestError{Message: err.Error()}
-	}
-	if len(results.Contents) == 0 {
-		return &archiver.QueryVisibilityResponse{}, nil
+	// Keys of an index are ordered by time, so the ones before the earliest time of the query are skipped
+	startAfter := token
+	if startAfter == nil && request.parsedQuery.earliestTime > 0 {
+		startAfter = aws.String(indexPrefix + "/" + time.Unix(0, request.parsedQuery.earliestTime).In(time.UTC).Format(time.RFC3339))
 	}
 
 	response := &archiver.QueryVisibilityResponse{}
-	if *results.IsTruncated {
-		response.NextPageToken = serializeQueryVisibilityToken(*results.NextContinuationToken)
-	}
-	for _, item := range results.Contents {
-		encodedRecord, err := download(ctx, v.s3cli, URI, *item.Key)
+	for {
+		results, err := v.s3cli.ListObjectsV2WithContext(ctx, &s3.ListObjectsV2Input{
+			Bucket:     aws.String(URI.Hostname()),
+			Prefix:     aws.String(prefix),
+			MaxKeys:    aws.Int64(int64(request.pageSize)),
+			StartAfter: startAfter,
+		})
 		if err != nil {
-			return nil, &types.InternalServiceError{Message: err.Error()}
+			if isRetryableError(err) {
+				return nil, &types.InternalServiceError{Message: err.Error()}
+			}
+			return nil, &types.BadRequestError{Message: err.Error()}
+		}
+		if len(results.Contents) == 0 {
+			return response, nil
 		}
 
-		record, err := decodeVisibilityRecord(encodedRecord)
-		if err != nil {
-			return nil, &types.InternalServiceError{Message: err.Error()}
+		for idx, item := range results.Contents {
+			if request.parsedQuery.latestTime != 0 && isKeyAfter(indexPrefix, *item.Key, request.parsedQuery.latestTime) {
+				return response, nil
+			}
+			encodedRecord, err := download(ctx, v.s3cli, URI, *item.Key)
+			if err != nil {
+				return nil, &types.InternalServiceError{Message: err.Error()}
+			}
+
+			record, err := decodeVisibilityRecord(encodedRecord)
+			if err != nil {
+				return nil, &types.InternalServiceError{Message: err.Error()}
+			}
+			if request.parsedQuery.filter != nil && !request.parsedQuery.filter((*archiver.ArchiveVisibilityRequest)(record)) {
+				continue
+			}
+			response.Executions = append(response.Executions, convertToExecutionInfo(record))
+			if len(response.Executions) == request.pageSize {
+				if idx != len(results.Contents)-1 || *results.IsTruncated {
+					response.NextPageToken = serializeQueryVisibilityToken(*item.Key)
+				}
+				return response, nil
+			}
 		}
-		response.Executions = append(response.Executions, convertToExecutionInfo(record))
+		if !*results.IsTruncated {
+			return response, nil
+		}
+		startAfter = results.Contents[len(results.Contents)-1].Key
+	}
+}
+
+// isKeyAfter reports whether the record of an index key has a time after the given one.
+// Timestamps in keys are truncated to seconds, so records of keys at the same second may still be after it.
+func isKeyAfter(indexPrefix string, key string, timestamp int64) bool {
+	pieces := strings.SplitN(strings.TrimPrefix(key, indexPrefix+"/"), "/", 2)
+	keyTime, err := time.Parse(time.RFC3339, pieces[0])
+	if err != nil {
+		return false
 	}
-	return response, nil
+	return keyTime.UnixNano() > timestamp
 }
 
 func (v *visibilityArchiver) ValidateURI(URI archiver.URI) error {
@@ -553,6 +553,66 @@ func (s *visibilityArchiverSuite) TestArchiveAndQuery() {
 	s.Equal(convertToExecutionInfo(s.visibilityRecords[2]), executions[2])
 }
 
+func (s *visibilityArchiverSuite) TestArchiveAndRichQuery() {
+	visibilityArchiver := s.newTestVisibilityArchiver()
+	URI, err := archiver.NewURI(testBucketURI + "/archive-and-rich-query")
+	s.NoError(err)
+	for i, status := range []types.WorkflowExecutionCloseStatus{
+		types.WorkflowExecutionCloseStatusFailed,
+		types.WorkflowExecutionCloseStatusCompleted,
+		types.WorkflowExecutionCloseStatusFailed,
+		types.WorkflowExecutionCloseStatusTimedOut,
+	} {
+		err := visibilityArchiver.Archive(context.Background(), URI, &archiver.ArchiveVisibilityRequest{
+			DomainID:         testDomainID,
+			DomainName:       testDomainName,
+			WorkflowID:       testWorkflowID,
+			RunID:            fmt.Sprintf("%s-%d", testRunID, i),
+			WorkflowTypeName: testWorkflowTypeName,
+			StartTimestamp:   int64(i)*int64(time.Hour) + int64(time.Minute),
+			CloseTimestamp:   int64(i+1) * int64(time.Hour),
+			CloseStatus:      status,
+			HistoryLength:    101,
+		})
+		s.NoError(err)
+	}
+
+	testCases := []struct {
+		query          string
+		expectedRunIDs []string
+	}{
+		{
+			query:          "WorkflowID = '" + testWorkflowID + "' and (CloseStatus = 'Failed' or CloseStatus = 'TimedOut')",
+			expectedRunIDs: []string{testRunID + "-0", testRunID + "-2", testRunID + "-3"},
+		},
+		{
+			query:          "WorkflowTypeName = '" + testWorkflowTypeName + "' and CloseTime > '1970-01-01T02:00:00Z' and CloseTime <= '1970-01-01T03:00:00Z'",
+			expectedRunIDs: []string{testRunID + "-2"},
+		},
+		{
+			query:          "WorkflowID = '" + testWorkflowID + "' and StartTime >= '1970-01-01T01:01:00Z' and CloseStatus != 'Completed' order by StartTime",
+			expectedRunIDs: []string{testRunID + "-2", testRunID + "-3"},
+		},
+	}
+	for _, tc := range testCases {
+		request := &archiver.QueryVisibilityRequest{
+			DomainID: testDomainID,
+			PageSize: 1,
+			Query:    tc.query,
+		}
+		var runIDs []string
+		for first := true; first || request.NextPageToken != nil; first = false {
+			response, err := visibilityArchiver.Query(context.Background(), URI, request)
+			s.NoError(err, tc.query)
+			for _, execution := range response.Executions {
+				runIDs = append(runIDs, execution.Execution.GetRunID())
+			}
+			request.NextPageToken = response.NextPageToken
+		}
+		s.Equal(tc.expectedRunIDs, runIDs, tc.query)
+	}
+}
+
 func (s *visibilityArchiverSuite) setupVisibilityDirectory() {
 	s.visibilityRecords = []*visibilityRecord{
 		{
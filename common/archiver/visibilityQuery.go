@@ -0,0 +1,642 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package archiver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xwb1989/sqlparser"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/types"
+)
+
+type (
+	// VisibilityQueryFieldType is the type of a field of archived visibility records
+	VisibilityQueryFieldType int
+
+	// VisibilityQueryField is a field of archived visibility records which can be used in visibility queries.
+	// String is set for string fields, Int for all other field types.
+	VisibilityQueryField struct {
+		Type   VisibilityQueryFieldType
+		String func(*ArchiveVisibilityRequest) string
+		Int    func(*ArchiveVisibilityRequest) int64
+	}
+
+	// VisibilityQueryFields maps the field names of a visibility query language to record fields.
+	// Fields mapped to nil are reserved by the query language and can not be used in filter expressions.
+	// Field names which are not in the map are matched against the search attributes of records.
+	VisibilityQueryFields map[string]*VisibilityQueryField
+
+	// VisibilityQueryFilter reports whether an archived visibility record matches a query
+	VisibilityQueryFilter func(*ArchiveVisibilityRequest) bool
+
+	// VisibilityQueryOrder is the order of visibility query results given by an ORDER BY clause
+	VisibilityQueryOrder struct {
+		Field     string
+		Ascending bool
+	}
+)
+
+// Visibility query field types
+const (
+	VisibilityQueryFieldTypeString VisibilityQueryFieldType = iota
+	VisibilityQueryFieldTypeInt
+	// VisibilityQueryFieldTypeTime values are unix nanos, they can be given either as integer or as RFC3339 string
+	VisibilityQueryFieldTypeTime
+	// VisibilityQueryFieldTypeCloseStatus values can be given either as integer or as status name
+	VisibilityQueryFieldTypeCloseStatus
+)
+
+// Fields of archived visibility records which can be exposed by the query languages of visibility archivers
+var (
+	WorkflowIDQueryField = &VisibilityQueryField{
+		Type:   VisibilityQueryFieldTypeString,
+		String: func(r *ArchiveVisibilityRequest) string { return r.WorkflowID },
+	}
+	RunIDQueryField = &VisibilityQueryField{
+		Type:   VisibilityQueryFieldTypeString,
+		String: func(r *ArchiveVisibilityRequest) string { return r.RunID },
+	}
+	WorkflowTypeQueryField = &VisibilityQueryField{
+		Type:   VisibilityQueryFieldTypeString,
+		String: func(r *ArchiveVisibilityRequest) string { return r.WorkflowTypeName },
+	}
+	StartTimeQueryField = &VisibilityQueryField{
+		Type: VisibilityQueryFieldTypeTime,
+		Int:  func(r *ArchiveVisibilityRequest) int64 { return r.StartTimestamp },
+	}
+	ExecutionTimeQueryField = &VisibilityQueryField{
+		Type: VisibilityQueryFieldTypeTime,
+		Int:  func(r *ArchiveVisibilityRequest) int64 { return r.ExecutionTimestamp },
+	}
+	CloseTimeQueryField = &VisibilityQueryField{
+		Type: VisibilityQueryFieldTypeTime,
+		Int:  func(r *ArchiveVisibilityRequest) int64 { return r.CloseTimestamp },
+	}
+	CloseStatusQueryField = &VisibilityQueryField{
+		Type: VisibilityQueryFieldTypeCloseStatus,
+		Int:  func(r *ArchiveVisibilityRequest) int64 { return int64(r.CloseStatus) },
+	}
+	HistoryLengthQueryField = &VisibilityQueryField{
+		Type: VisibilityQueryFieldTypeInt,
+		Int:  func(r *ArchiveVisibilityRequest) int64 { return r.HistoryLength },
+	}
+)
+
+// CompileVisibilityQueryFilter compiles the conjunction of where clause expressions into a filter.
+// Supported are AND, OR, NOT, parentheses, comparisons (=, !=, <, <=, >, >=), IN, NOT IN, BETWEEN, LIKE, NOT LIKE
+// and, for search attributes, IS NULL and IS NOT NULL.
+func CompileVisibilityQueryFilter(exprs []sqlparser.Expr, fields VisibilityQueryFields) (VisibilityQueryFilter, error) {
+	filters := make([]VisibilityQueryFilter, 0, len(exprs))
+	for _, expr := range exprs {
+		filter, err := compileFilterExpr(expr, fields)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return func(record *ArchiveVisibilityRequest) bool {
+		for _, filter := range filters {
+			if !filter(record) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// VisibilityQueryTimeRange returns the inclusive range of values the time field named fieldName can take
+// in records matching the where clause expression. The range is not necessarily tight, records outside of it
+// never match but records inside of it may not match either.
+func VisibilityQueryTimeRange(expr sqlparser.Expr, fieldName string) (earliest int64, latest int64) {
+	switch expr := expr.(type) {
+	case *sqlparser.AndExpr:
+		leftEarliest, leftLatest := VisibilityQueryTimeRange(expr.Left, fieldName)
+		rightEarliest, rightLatest := VisibilityQueryTimeRange(expr.Right, fieldName)
+		return common.MaxInt64(leftEarliest, rightEarliest), common.MinInt64(leftLatest, rightLatest)
+	case *sqlparser.OrExpr:
+		leftEarliest, leftLatest := VisibilityQueryTimeRange(expr.Left, fieldName)
+		rightEarliest, rightLatest := VisibilityQueryTimeRange(expr.Right, fieldName)
+		return common.MinInt64(leftEarliest, rightEarliest), common.MaxInt64(leftLatest, rightLatest)
+	case *sqlparser.ParenExpr:
+		return VisibilityQueryTimeRange(expr.Expr, fieldName)
+	case *sqlparser.ComparisonExpr:
+		if !isColumn(expr.Left, fieldName) {
+			break
+		}
+		if expr.Operator == sqlparser.InStr {
+			values, err := parseValues(expr.Right, VisibilityQueryFieldTypeTime)
+			if err != nil || len(values) == 0 {
+				break
+			}
+			earliest, latest = math.MaxInt64, 0
+			for _, value := range values {
+				earliest = common.MinInt64(earliest, value.(int64))
+				latest = common.MaxInt64(latest, value.(int64))
+			}
+			return earliest, latest
+		}
+		values, err := parseValues(expr.Right, VisibilityQueryFieldTypeTime)
+		if err != nil || len(values) != 1 {
+			break
+		}
+		timestamp := values[0].(int64)
+		switch expr.Operator {
+		case sqlparser.EqualStr:
+			return timestamp, timestamp
+		case sqlparser.LessThanStr:
+			return 0, timestamp - 1
+		case sqlparser.LessEqualStr:
+			return 0, timestamp
+		case sqlparser.GreaterThanStr:
+			return timestamp + 1, math.MaxInt64
+		case sqlparser.GreaterEqualStr:
+			return timestamp, math.MaxInt64
+		}
+	case *sqlparser.RangeCond:
+		if expr.Operator != sqlparser.BetweenStr || !isColumn(expr.Left, fieldName) {
+			break
+		}
+		from, errFrom := parseValues(expr.From, VisibilityQueryFieldTypeTime)
+		to, errTo := parseValues(expr.To, VisibilityQueryFieldTypeTime)
+		if errFrom != nil || errTo != nil || len(from) != 1 || len(to) != 1 {
+			break
+		}
+		return from[0].(int64), to[0].(int64)
+	}
+	return 0, math.MaxInt64
+}
+
+// ParseVisibilityQueryOrder parses the ORDER BY clause of a visibility query, results can be ordered by one of the given fields.
+// It returns nil if there is no ORDER BY clause.
+func ParseVisibilityQueryOrder(orderBy sqlparser.OrderBy, fieldNames ...string) (*VisibilityQueryOrder, error) {
+	if len(orderBy) == 0 {
+		return nil, nil
+	}
+	if len(orderBy) > 1 {
+		return nil, errors.New("only one field is supported in ORDER BY")
+	}
+	for _, fieldName := range fieldNames {
+		if isColumn(orderBy[0].Expr, fieldName) {
+			return &VisibilityQueryOrder{
+				Field:     fieldName,
+				Ascending: orderBy[0].Direction != sqlparser.DescScr,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("results can only be ordered by %s", strings.Join(fieldNames, " or "))
+}
+
+func compileFilterExpr(expr sqlparser.Expr, fields VisibilityQueryFields) (VisibilityQueryFilter, error) {
+	switch expr := expr.(type) {
+	case nil:
+		return nil, errors.New("where expression is nil")
+	case *sqlparser.AndExpr:
+		left, right, err := compileFilterExprs(expr.Left, expr.Right, fields)
+		if err != nil {
+			return nil, err
+		}
+		return func(record *ArchiveVisibilityRequest) bool {
+			return left(record) && right(record)
+		}, nil
+	case *sqlparser.OrExpr:
+		left, right, err := compileFilterExprs(expr.Left, expr.Right, fields)
+		if err != nil {
+			return nil, err
+		}
+		return func(record *ArchiveVisibilityRequest) bool {
+			return left(record) || right(record)
+		}, nil
+	case *sqlparser.NotExpr:
+		filter, err := compileFilterExpr(expr.Expr, fields)
+		if err != nil {
+			return nil, err
+		}
+		return func(record *ArchiveVisibilityRequest) bool {
+			return !filter(record)
+		}, nil
+	case *sqlparser.ParenExpr:
+		return compileFilterExpr(expr.Expr, fields)
+	case *sqlparser.ComparisonExpr:
+		return compileComparisonExpr(expr.Left, expr.Operator, expr.Right, fields)
+	case *sqlparser.RangeCond:
+		return compileRangeCond(expr, fields)
+	case *sqlparser.IsExpr:
+		return compileIsExpr(expr, fields)
+	default:
+		return nil, fmt.Errorf("unsupported expression: %s", sqlparser.String(expr))
+	}
+}
+
+func compileFilterExprs(left, right sqlparser.Expr, fields VisibilityQueryFields) (VisibilityQueryFilter, VisibilityQueryFilter, error) {
+	leftFilter, err := compileFilterExpr(left, fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	rightFilter, err := compileFilterExpr(right, fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	return leftFilter, rightFilter, nil
+}
+
+func compileComparisonExpr(left sqlparser.Expr, op string, right sqlparser.Expr, fields VisibilityQueryFields) (VisibilityQueryFilter, error) {
+	name, field, err := resolveField(left, fields)
+	if err != nil {
+		return nil, err
+	}
+	if field == nil {
+		return compileSearchAttributeComparison(name, op, right)
+	}
+
+	values, err := parseValues(right, field.Type)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for %s: %v", name, err)
+	}
+	isList := op == sqlparser.InStr || op == sqlparser.NotInStr
+	if isList != isValTuple(right) {
+		return nil, fmt.Errorf("invalid value for %s: %s", name, sqlparser.String(right))
+	}
+
+	if field.Type == VisibilityQueryFieldTypeString {
+		get := field.String
+		switch op {
+		case sqlparser.EqualStr, sqlparser.InStr:
+			return func(record *ArchiveVisibilityRequest) bool { return containsValue(values, get(record)) }, nil
+		case sqlparser.NotEqualStr, sqlparser.NotInStr:
+			return func(record *ArchiveVisibilityRequest) bool { return !containsValue(values, get(record)) }, nil
+		case sqlparser.LikeStr, sqlparser.NotLikeStr:
+			pattern := likePatternToRegexp(values[0].(string))
+			negate := op == sqlparser.NotLikeStr
+			return func(record *ArchiveVisibilityRequest) bool { return pattern.MatchString(get(record)) != negate }, nil
+		}
+		return nil, fmt.Errorf("operator %s is not supported for %s", op, name)
+	}
+
+	get := field.Int
+	switch op {
+	case sqlparser.EqualStr, sqlparser.InStr:
+		return func(record *ArchiveVisibilityRequest) bool { return containsValue(values, get(record)) }, nil
+	case sqlparser.NotEqualStr, sqlparser.NotInStr:
+		return func(record *ArchiveVisibilityRequest) bool { return !containsValue(values, get(record)) }, nil
+	}
+	if field.Type == VisibilityQueryFieldTypeCloseStatus {
+		return nil, fmt.Errorf("operator %s is not supported for %s", op, name)
+	}
+	value := values[0].(int64)
+	switch op {
+	case sqlparser.LessThanStr:
+		return func(record *ArchiveVisibilityRequest) bool { return get(record) < value }, nil
+	case sqlparser.LessEqualStr:
+		return func(record *ArchiveVisibilityRequest) bool { return get(record) <= value }, nil
+	case sqlparser.GreaterThanStr:
+		return func(record *ArchiveVisibilityRequest) bool { return get(record) > value }, nil
+	case sqlparser.GreaterEqualStr:
+		return func(record *ArchiveVisibilityRequest) bool { return get(record) >= value }, nil
+	}
+	return nil, fmt.Errorf("operator %s is not supported for %s", op, name)
+}
+
+func compileRangeCond(expr *sqlparser.RangeCond, fields VisibilityQueryFields) (VisibilityQueryFilter, error) {
+	from, err := compileComparisonExpr(expr.Left, sqlparser.GreaterEqualStr, expr.From, fields)
+	if err != nil {
+		return nil, err
+	}
+	to, err := compileComparisonExpr(expr.Left, sqlparser.LessEqualStr, expr.To, fields)
+	if err != nil {
+		return nil, err
+	}
+	negate := expr.Operator == sqlparser.NotBetweenStr
+	return func(record *ArchiveVisibilityRequest) bool {
+		return (from(record) && to(record)) != negate
+	}, nil
+}
+
+func compileIsExpr(expr *sqlparser.IsExpr, fields VisibilityQueryFields) (VisibilityQueryFilter, error) {
+	name, field, err := resolveField(expr.Expr, fields)
+	if err != nil {
+		return nil, err
+	}
+	if field != nil {
+		return nil, fmt.Errorf("operator %s is only supported for search attributes", expr.Operator)
+	}
+	switch expr.Operator {
+	case sqlparser.IsNullStr:
+		return func(record *ArchiveVisibilityRequest) bool {
+			_, ok := record.SearchAttributes[name]
+			return !ok
+		}, nil
+	case sqlparser.IsNotNullStr:
+		return func(record *ArchiveVisibilityRequest) bool {
+			_, ok := record.SearchAttributes[name]
+			return ok
+		}, nil
+	default:
+		return nil, fmt.Errorf("operator %s is not supported", expr.Operator)
+	}
+}
+
+// compileSearchAttributeComparison compares JSON encoded search attribute values with query values.
+// Records without the search attribute never match, keyword list attributes match if any of their values match.
+func compileSearchAttributeComparison(name string, op string, right sqlparser.Expr) (VisibilityQueryFilter, error) {
+	values, err := parseSearchAttributeValues(right)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for %s: %v", name, err)
+	}
+	isList := op == sqlparser.InStr || op == sqlparser.NotInStr
+	if isList != isValTuple(right) {
+		return nil, fmt.Errorf("invalid value for %s: %s", name, sqlparser.String(right))
+	}
+
+	var match func(attr interface{}) bool
+	switch op {
+	case sqlparser.EqualStr, sqlparser.InStr, sqlparser.NotEqualStr, sqlparser.NotInStr:
+		match = func(attr interface{}) bool {
+			for _, value := range values {
+				if cmp, ok := compareSearchAttribute(attr, value); ok && cmp == 0 {
+					return true
+				}
+			}
+			return false
+		}
+	case sqlparser.LikeStr, sqlparser.NotLikeStr:
+		pattern, ok := values[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("operator %s requires a string value for %s", op, name)
+		}
+		re := likePatternToRegexp(pattern)
+		match = func(attr interface{}) bool {
+			s, ok := attr.(string)
+			return ok && re.MatchString(s)
+		}
+	case sqlparser.LessThanStr, sqlparser.LessEqualStr, sqlparser.GreaterThanStr, sqlparser.GreaterEqualStr:
+		value := values[0]
+		match = func(attr interface{}) bool {
+			cmp, ok := compareSearchAttribute(attr, value)
+			if !ok {
+				return false
+			}
+			switch op {
+			case sqlparser.LessThanStr:
+				return cmp < 0
+			case sqlparser.LessEqualStr:
+				return cmp <= 0
+			case sqlparser.GreaterThanStr:
+				return cmp > 0
+			default:
+				return cmp >= 0
+			}
+		}
+	default:
+		return nil, fmt.Errorf("operator %s is not supported for %s", op, name)
+	}
+
+	negate := op == sqlparser.NotEqualStr || op == sqlparser.NotInStr || op == sqlparser.NotLikeStr
+	return func(record *ArchiveVisibilityRequest) bool {
+		encoded, ok := record.SearchAttributes[name]
+		if !ok {
+			return false
+		}
+		var attr interface{}
+		if err := json.Unmarshal([]byte(encoded), &attr); err != nil {
+			attr = encoded
+		}
+		if list, ok := attr.([]interface{}); ok {
+			for _, elem := range list {
+				if match(elem) {
+					return !negate
+				}
+			}
+			return negate
+		}
+		return match(attr) != negate
+	}, nil
+}
+
+// compareSearchAttribute compares a decoded search attribute value with a query value, ok is false if they are not comparable.
+// Datetime search attributes may be stored as RFC3339 string or unix nanos, both can be compared with either kind of query value.
+func compareSearchAttribute(attr interface{}, value interface{}) (cmp int, ok bool) {
+	switch attr := attr.(type) {
+	case string:
+		switch value := value.(type) {
+		case string:
+			return strings.Compare(attr, value), true
+		case float64:
+			t, err := time.Parse(time.RFC3339, attr)
+			if err != nil {
+				return 0, false
+			}
+			return compareFloat64(float64(t.UnixNano()), value), true
+		}
+	case float64:
+		switch value := value.(type) {
+		case float64:
+			return compareFloat64(attr, value), true
+		case string:
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return 0, false
+			}
+			return compareFloat64(attr, float64(t.UnixNano())), true
+		}
+	case bool:
+		if value, isBool := value.(bool); isBool && value == attr {
+			return 0, true
+		}
+		return 1, false
+	}
+	return 0, false
+}
+
+func resolveField(expr sqlparser.Expr, fields VisibilityQueryFields) (string, *VisibilityQueryField, error) {
+	colName, ok := expr.(*sqlparser.ColName)
+	if !ok || !colName.Qualifier.IsEmpty() {
+		return "", nil, fmt.Errorf("invalid filter name: %s", sqlparser.String(expr))
+	}
+	name := colName.Name.String()
+	field, ok := fields[name]
+	if ok {
+		if field == nil {
+			return "", nil, fmt.Errorf("%s can only be used in a top level condition", name)
+		}
+		return name, field, nil
+	}
+	for fieldName := range fields {
+		if strings.EqualFold(fieldName, name) {
+			return "", nil, fmt.Errorf("unknown filter name: %s", name)
+		}
+	}
+	return name, nil, nil
+}
+
+func isColumn(expr sqlparser.Expr, name string) bool {
+	colName, ok := expr.(*sqlparser.ColName)
+	return ok && colName.Qualifier.IsEmpty() && colName.Name.String() == name
+}
+
+func isValTuple(expr sqlparser.Expr) bool {
+	_, ok := expr.(sqlparser.ValTuple)
+	return ok
+}
+
+func parseValues(expr sqlparser.Expr, fieldType VisibilityQueryFieldType) ([]interface{}, error) {
+	exprs := sqlparser.Exprs{expr}
+	if tuple, ok := expr.(sqlparser.ValTuple); ok {
+		exprs = sqlparser.Exprs(tuple)
+	}
+	values := make([]interface{}, 0, len(exprs))
+	for _, expr := range exprs {
+		val, ok := expr.(*sqlparser.SQLVal)
+		if !ok {
+			return nil, fmt.Errorf("invalid value: %s", sqlparser.String(expr))
+		}
+		value, err := parseValue(val, fieldType)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func parseValue(val *sqlparser.SQLVal, fieldType VisibilityQueryFieldType) (interface{}, error) {
+	switch fieldType {
+	case VisibilityQueryFieldTypeString:
+		if val.Type != sqlparser.StrVal {
+			return nil, fmt.Errorf("value %s is not a string value", sqlparser.String(val))
+		}
+		return string(val.Val), nil
+	case VisibilityQueryFieldTypeInt:
+		if val.Type != sqlparser.IntVal {
+			return nil, fmt.Errorf("value %s is not an integer value", sqlparser.String(val))
+		}
+		return strconv.ParseInt(string(val.Val), 10, 64)
+	case VisibilityQueryFieldTypeTime:
+		if val.Type == sqlparser.IntVal {
+			return strconv.ParseInt(string(val.Val), 10, 64)
+		}
+		if val.Type != sqlparser.StrVal {
+			return nil, fmt.Errorf("value %s is not a timestamp", sqlparser.String(val))
+		}
+		t, err := time.Parse(time.RFC3339, string(val.Val))
+		if err != nil {
+			return nil, err
+		}
+		return t.UnixNano(), nil
+	case VisibilityQueryFieldTypeCloseStatus:
+		if val.Type != sqlparser.StrVal && val.Type != sqlparser.IntVal {
+			return nil, fmt.Errorf("value %s is not a close status", sqlparser.String(val))
+		}
+		return parseCloseStatus(string(val.Val))
+	default:
+		return nil, fmt.Errorf("unknown field type: %v", fieldType)
+	}
+}
+
+func parseSearchAttributeValues(expr sqlparser.Expr) ([]interface{}, error) {
+	exprs := sqlparser.Exprs{expr}
+	if tuple, ok := expr.(sqlparser.ValTuple); ok {
+		exprs = sqlparser.Exprs(tuple)
+	}
+	values := make([]interface{}, 0, len(exprs))
+	for _, expr := range exprs {
+		switch expr := expr.(type) {
+		case sqlparser.BoolVal:
+			values = append(values, bool(expr))
+		case *sqlparser.SQLVal:
+			switch expr.Type {
+			case sqlparser.StrVal:
+				values = append(values, string(expr.Val))
+			case sqlparser.IntVal, sqlparser.FloatVal:
+				value, err := strconv.ParseFloat(string(expr.Val), 64)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, value)
+			default:
+				return nil, fmt.Errorf("invalid value: %s", sqlparser.String(expr))
+			}
+		default:
+			return nil, fmt.Errorf("invalid value: %s", sqlparser.String(expr))
+		}
+	}
+	return values, nil
+}
+
+func parseCloseStatus(s string) (int64, error) {
+	var status types.WorkflowExecutionCloseStatus
+	normalized := strings.Replace(strings.TrimSpace(s), "_", "", -1)
+	for candidate := types.WorkflowExecutionCloseStatusCompleted; candidate <= types.WorkflowExecutionCloseStatusTimedOut; candidate++ {
+		if strings.EqualFold(strings.Replace(candidate.String(), "_", "", -1), normalized) {
+			return int64(candidate), nil
+		}
+	}
+	if err := status.UnmarshalText([]byte(s)); err != nil || status < types.WorkflowExecutionCloseStatusCompleted || status > types.WorkflowExecutionCloseStatusTimedOut {
+		return 0, fmt.Errorf("unknown workflow close status: %s", s)
+	}
+	return int64(status), nil
+}
+
+// likePatternToRegexp converts a SQL LIKE pattern, in which % matches any sequence of characters and _ matches a single one
+func likePatternToRegexp(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			sb.WriteString(".*")
+		case '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func compareFloat64(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package archiver
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"github.com/xwb1989/sqlparser"
+
+	"github.com/uber/cadence/common/types"
+)
+
+type VisibilityQuerySuite struct {
+	*require.Assertions
+	suite.Suite
+}
+
+var testQueryFields = VisibilityQueryFields{
+	"WorkflowID":      WorkflowIDQueryField,
+	"StartTime":       StartTimeQueryField,
+	"CloseTime":       CloseTimeQueryField,
+	"CloseStatus":     CloseStatusQueryField,
+	"HistoryLength":   HistoryLengthQueryField,
+	"SearchPrecision": nil,
+}
+
+func TestVisibilityQuerySuite(t *testing.T) {
+	suite.Run(t, new(VisibilityQuerySuite))
+}
+
+func (s *VisibilityQuerySuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+}
+
+func (s *VisibilityQuerySuite) TestCompileVisibilityQueryFilter() {
+	record := &ArchiveVisibilityRequest{
+		WorkflowID:     "workflow-id",
+		StartTimestamp: 1546300800000000000, // 2019-01-01T00:00:00Z
+		CloseTimestamp: 1546304400000000000, // 2019-01-01T01:00:00Z
+		CloseStatus:    types.WorkflowExecutionCloseStatusContinuedAsNew,
+		HistoryLength:  42,
+		SearchAttributes: map[string]string{
+			"CustomKeywordField":  `"keyword"`,
+			"CustomKeywordList":   `["a","b"]`,
+			"CustomIntField":      "7",
+			"CustomDoubleField":   "1.5",
+			"CustomBoolField":     "true",
+			"CustomDatetimeField": `"2019-01-01T00:30:00Z"`,
+			"NotJSONField":        "raw value",
+		},
+	}
+	testCases := []struct {
+		where       string
+		expectErr   bool
+		shouldMatch bool
+	}{
+		{where: "WorkflowID = 'workflow-id'", shouldMatch: true},
+		{where: "WorkflowID != 'workflow-id'", shouldMatch: false},
+		{where: "WorkflowID LIKE 'work_low-%'", shouldMatch: true},
+		{where: "WorkflowID LIKE 'flow%'", shouldMatch: false},
+		{where: "WorkflowID NOT IN ('a', 'b')", shouldMatch: true},
+		{where: "CloseTime = '2019-01-01T01:00:00Z'", shouldMatch: true},
+		{where: "CloseTime NOT BETWEEN '2019-01-01T00:30:00Z' AND '2019-01-01T02:00:00Z'", shouldMatch: false},
+		{where: "StartTime < 1546300800000000001 AND HistoryLength >= 42", shouldMatch: true},
+		{where: "CloseStatus = 'continued_as_new'", shouldMatch: true},
+		{where: "CloseStatus IN (0, 1, 4)", shouldMatch: true},
+		{where: "CloseStatus != 'ContinuedAsNew' OR HistoryLength < 10", shouldMatch: false},
+		{where: "NOT (HistoryLength > 40 AND HistoryLength < 50)", shouldMatch: false},
+		{where: "CustomKeywordField = 'keyword'", shouldMatch: true},
+		{where: "CustomKeywordList = 'b' AND CustomKeywordList != 'c'", shouldMatch: true},
+		{where: "CustomKeywordList NOT IN ('a')", shouldMatch: false},
+		{where: "CustomIntField BETWEEN 5 AND 10 AND CustomDoubleField > 1", shouldMatch: true},
+		{where: "CustomBoolField = true", shouldMatch: true},
+		{where: "CustomDatetimeField < '2019-01-01T01:00:00Z' AND CustomDatetimeField > 1546300800000000000", shouldMatch: true},
+		{where: "NotJSONField LIKE 'raw%'", shouldMatch: true},
+		{where: "MissingField != 'value'", shouldMatch: false},
+		{where: "MissingField IS NULL AND CustomIntField IS NOT NULL", shouldMatch: true},
+		{where: "workflowID = 'workflow-id'", expectErr: true},
+		{where: "CloseStatus > 1", expectErr: true},
+		{where: "CloseStatus = 'unknown'", expectErr: true},
+		{where: "HistoryLength = 'a lot'", expectErr: true},
+		{where: "WorkflowID = ('a', 'b')", expectErr: true},
+		{where: "WorkflowID < 'workflow-id'", expectErr: true},
+		{where: "CloseTime > '2019-01-01 00:00:00'", expectErr: true},
+		{where: "SearchPrecision = 'Day'", expectErr: true},
+		{where: "WorkflowID IS NULL", expectErr: true},
+		{where: "t.WorkflowID = 'workflow-id'", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		filter, err := CompileVisibilityQueryFilter([]sqlparser.Expr{s.parseWhere(tc.where)}, testQueryFields)
+		if tc.expectErr {
+			s.Error(err, tc.where)
+			continue
+		}
+		s.NoError(err, tc.where)
+		s.Equal(tc.shouldMatch, filter(record), tc.where)
+	}
+}
+
+func (s *VisibilityQuerySuite) TestVisibilityQueryTimeRange() {
+	testCases := []struct {
+		where    string
+		earliest int64
+		latest   int64
+	}{
+		{where: "WorkflowID = 'workflow-id'", earliest: 0, latest: math.MaxInt64},
+		{where: "CloseTime > 10 AND CloseTime <= 20", earliest: 11, latest: 20},
+		{where: "(CloseTime >= 10 AND CloseTime < 20) OR CloseTime = 30", earliest: 10, latest: 30},
+		{where: "CloseTime >= 10 OR WorkflowID = 'workflow-id'", earliest: 0, latest: math.MaxInt64},
+		{where: "NOT (CloseTime >= 10)", earliest: 0, latest: math.MaxInt64},
+		{where: "CloseTime IN (30, 10, 20) AND StartTime > 100", earliest: 10, latest: 30},
+		{where: "CloseTime BETWEEN '2019-01-01T00:00:00Z' AND 1546304400000000000", earliest: 1546300800000000000, latest: 1546304400000000000},
+	}
+
+	for _, tc := range testCases {
+		earliest, latest := VisibilityQueryTimeRange(s.parseWhere(tc.where), "CloseTime")
+		s.Equal(tc.earliest, earliest, tc.where)
+		s.Equal(tc.latest, latest, tc.where)
+	}
+}
+
+func (s *VisibilityQuerySuite) TestParseVisibilityQueryOrder() {
+	testCases := []struct {
+		orderBy   string
+		expectErr bool
+		order     *VisibilityQueryOrder
+	}{
+		{orderBy: "", order: nil},
+		{orderBy: "order by CloseTime", order: &VisibilityQueryOrder{Field: "CloseTime", Ascending: true}},
+		{orderBy: "order by StartTime desc", order: &VisibilityQueryOrder{Field: "StartTime", Ascending: false}},
+		{orderBy: "order by WorkflowID", expectErr: true},
+		{orderBy: "order by CloseTime, StartTime", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		stmt, err := sqlparser.Parse("select * from dummy where WorkflowID = 'workflow-id' " + tc.orderBy)
+		s.NoError(err)
+		order, err := ParseVisibilityQueryOrder(stmt.(*sqlparser.Select).OrderBy, "CloseTime", "StartTime")
+		if tc.expectErr {
+			s.Error(err, tc.orderBy)
+			continue
+		}
+		s.NoError(err, tc.orderBy)
+		s.Equal(tc.order, order, tc.orderBy)
+	}
+}
+
+func (s *VisibilityQuerySuite) parseWhere(where string) sqlparser.Expr {
+	stmt, err := sqlparser.Parse(fmt.Sprintf("select * from dummy where %s", where))
+	s.NoError(err, where)
+	return stmt.(*sqlparser.Select).Where.Expr
+}
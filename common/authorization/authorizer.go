@@ -85,6 +85,20 @@ func NewPermission(permission string) Permission {
 	}
 }
 
+type attributesContextKey struct{}
+
+// NewContextWithAttributes returns a context carrying the attributes of an authorized request,
+// so that handlers can tell who made the request
+func NewContextWithAttributes(ctx context.Context, attributes *Attributes) context.Context {
+	return context.WithValue(ctx, attributesContextKey{}, attributes)
+}
+
+// AttributesFromContext returns the attributes of the authorized request or nil if there are none
+func AttributesFromContext(ctx context.Context) *Attributes {
+	attributes, _ := ctx.Value(attributesContextKey{}).(*Attributes)
+	return attributes
+}
+
 // Authorizer is an interface for authorization
 type Authorizer interface {
 	Authorize(ctx context.Context, attributes *Attributes) (Result, error)
@@ -99,6 +99,10 @@ func (a *oauthAuthority) Authorize(
 		a.log.Debug("request is not authorized", tag.Error(err))
 		return Result{Decision: DecisionDeny}, nil
 	}
+	attributes.Actor = claims.Name
+	if attributes.Actor == "" {
+		attributes.Actor = claims.Sub
+	}
 	if claims.Admin {
 		return Result{Decision: DecisionAllow}, nil
 	}
@@ -137,6 +137,7 @@ func (s *oauthSuite) TestItIsAdmin() {
 	result, err := authorizer.Authorize(ctx, &s.att)
 	s.NoError(err)
 	s.Equal(result.Decision, DecisionAllow)
+	s.Equal("John Doe", s.att.Actor)
 }
 
 func (s *oauthSuite) TestEmptyToken() {
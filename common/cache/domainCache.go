@@ -438,10 +438,19 @@ func (c *domainCache) refreshDomainsLocked() error {
 	sort.Sort(domains)
 	var updatedEntries []*DomainCacheEntry
 
+	listedDomainIDs := make(map[string]struct{}, len(domains))
+	for _, domain := range domains {
+		listedDomainIDs[domain.info.ID] = struct{}{}
+	}
+
 	// make a copy of the existing domain cache, so we can calculate diff and do compare and swap
+	// domains which are no longer listed got deleted and are dropped from the copy
 	newCacheNameToID := newDomainCache()
 	newCacheByID := newDomainCache()
 	for _, domain := range c.GetAllDomain() {
+		if _, ok := listedDomainIDs[domain.info.ID]; !ok {
+			continue
+		}
 		newCacheNameToID.Put(domain.info.Name, domain.info.ID)
 		newCacheByID.Put(domain.info.ID, domain)
 	}
@@ -680,6 +689,15 @@ func copyResetBinary(bins types.BadBinaries) types.BadBinaries {
 	}
 }
 
+func copyDomainLimits(limits *types.DomainLimits) *types.DomainLimits {
+	if limits == nil {
+		return nil
+	}
+	// limit values are never mutated in place, so copying the struct is enough
+	copied := *limits
+	return &copied
+}
+
 func (entry *DomainCacheEntry) duplicate() *DomainCacheEntry {
 	// this is a deep copy
 	result := &DomainCacheEntry{}
@@ -702,6 +720,7 @@ func (entry *DomainCacheEntry) duplicate() *DomainCacheEntry {
 		VisibilityArchivalStatus: entry.config.VisibilityArchivalStatus,
 		VisibilityArchivalURI:    entry.config.VisibilityArchivalURI,
 		BadBinaries:              copyResetBinary(entry.config.BadBinaries),
+		Limits:                   copyDomainLimits(entry.config.Limits),
 	}
 	result.replicationConfig = &persistence.DomainReplicationConfig{
 		ActiveClusterName: entry.replicationConfig.ActiveClusterName,
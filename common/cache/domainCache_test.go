@@ -193,6 +193,43 @@ func (s *domainCacheSuite) TestListDomain() {
 	}, allDomains)
 }
 
+func (s *domainCacheSuite) TestRefreshDomains_DeletedDomain() {
+	newRecord := func(name string, notificationVersion int64) *persistence.GetDomainResponse {
+		return &persistence.GetDomainResponse{
+			Info:                &persistence.DomainInfo{ID: uuid.New(), Name: name, Data: make(map[string]string)},
+			Config:              &persistence.DomainConfig{Retention: 1},
+			ReplicationConfig:   &persistence.DomainReplicationConfig{ActiveClusterName: cluster.TestCurrentClusterName},
+			NotificationVersion: notificationVersion,
+		}
+	}
+	domainRecord1 := newRecord("some random domain name", 0)
+	domainRecord2 := newRecord("another random domain name", 1)
+
+	s.metadataMgr.On("GetMetadata", mock.Anything).Return(&persistence.GetMetadataResponse{NotificationVersion: 2}, nil).Once()
+	s.metadataMgr.On("ListDomains", mock.Anything, &persistence.ListDomainsRequest{
+		PageSize: domainCacheRefreshPageSize,
+	}).Return(&persistence.ListDomainsResponse{
+		Domains: []*persistence.GetDomainResponse{domainRecord1, domainRecord2},
+	}, nil).Once()
+	s.Nil(s.domainCache.refreshDomains())
+	s.Len(s.domainCache.GetAllDomain(), 2)
+
+	// domainRecord2 got deleted
+	s.metadataMgr.On("GetMetadata", mock.Anything).Return(&persistence.GetMetadataResponse{NotificationVersion: 3}, nil).Once()
+	s.metadataMgr.On("ListDomains", mock.Anything, &persistence.ListDomainsRequest{
+		PageSize: domainCacheRefreshPageSize,
+	}).Return(&persistence.ListDomainsResponse{
+		Domains: []*persistence.GetDomainResponse{domainRecord1},
+	}, nil).Once()
+	s.domainCache.timeSource.(*clock.EventTimeSource).Update(s.now.Add(domainCacheMinRefreshInterval))
+	s.Nil(s.domainCache.refreshDomains())
+
+	allDomains := s.domainCache.GetAllDomain()
+	s.Len(allDomains, 1)
+	s.Contains(allDomains, domainRecord1.Info.ID)
+	s.Nil(s.domainCache.cacheNameToID.Load().(Cache).Get(domainRecord2.Info.Name))
+}
+
 func (s *domainCacheSuite) TestGetDomain_NonLoaded_GetByName() {
 	domainNotificationVersion := int64(999999) // make this notification version really large for test
 	s.metadataMgr.On("GetMetadata", mock.Anything).Return(&persistence.GetMetadataResponse{NotificationVersion: domainNotificationVersion}, nil)
@@ -0,0 +1,93 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cache
+
+import (
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/types"
+)
+
+type (
+	// DomainLimitSelector returns one of the limits configured on a domain, zero if it is not set
+	DomainLimitSelector func(limits *types.DomainLimits) int
+)
+
+var (
+	// DomainUserRPSLimit selects the user RPS limit of a domain
+	DomainUserRPSLimit DomainLimitSelector = func(l *types.DomainLimits) int { return int(l.GetUserRPS()) }
+	// DomainWorkerRPSLimit selects the worker RPS limit of a domain
+	DomainWorkerRPSLimit DomainLimitSelector = func(l *types.DomainLimits) int { return int(l.GetWorkerRPS()) }
+	// DomainVisibilityRPSLimit selects the visibility RPS limit of a domain
+	DomainVisibilityRPSLimit DomainLimitSelector = func(l *types.DomainLimits) int { return int(l.GetVisibilityRPS()) }
+	// DomainBlobSizeLimit selects the blob size limit of a domain
+	DomainBlobSizeLimit DomainLimitSelector = func(l *types.DomainLimits) int { return int(l.GetBlobSizeLimit()) }
+	// DomainHistorySizeLimit selects the history size limit of a domain
+	DomainHistorySizeLimit DomainLimitSelector = func(l *types.DomainLimits) int { return int(l.GetHistorySizeLimit()) }
+	// DomainHistoryCountLimit selects the history count limit of a domain
+	DomainHistoryCountLimit DomainLimitSelector = func(l *types.DomainLimits) int { return int(l.GetHistoryCountLimit()) }
+	// DomainSearchAttributesNumberOfKeysLimit selects the search attributes number of keys limit of a domain
+	DomainSearchAttributesNumberOfKeysLimit DomainLimitSelector = func(l *types.DomainLimits) int {
+		return int(l.GetSearchAttributesNumberOfKeysLimit())
+	}
+	// DomainSearchAttributesSizeOfValueLimit selects the search attributes value size limit of a domain
+	DomainSearchAttributesSizeOfValueLimit DomainLimitSelector = func(l *types.DomainLimits) int {
+		return int(l.GetSearchAttributesSizeOfValueLimit())
+	}
+	// DomainSearchAttributesTotalSizeLimit selects the search attributes total size limit of a domain
+	DomainSearchAttributesTotalSizeLimit DomainLimitSelector = func(l *types.DomainLimits) int {
+		return int(l.GetSearchAttributesTotalSizeLimit())
+	}
+	// DomainMaxExecutionStartToCloseTimeoutLimit selects the max workflow execution timeout in seconds of a domain
+	DomainMaxExecutionStartToCloseTimeoutLimit DomainLimitSelector = func(l *types.DomainLimits) int {
+		return int(l.GetMaxExecutionStartToCloseTimeoutSeconds())
+	}
+)
+
+// NewDomainLimitFn returns a property function which prefers the limit stored in the domain config
+// and falls back to the dynamic config value when the domain has no such limit or cannot be loaded
+func NewDomainLimitFn(
+	domainCache DomainCache,
+	selector DomainLimitSelector,
+	fallback dynamicconfig.IntPropertyFnWithDomainFilter,
+) dynamicconfig.IntPropertyFnWithDomainFilter {
+	return func(domain string) int {
+		if limit := GetDomainLimit(domainCache, domain, selector); limit > 0 {
+			return limit
+		}
+		return fallback(domain)
+	}
+}
+
+// GetDomainLimit returns the limit stored in the domain config, zero if it is not set
+func GetDomainLimit(
+	domainCache DomainCache,
+	domain string,
+	selector DomainLimitSelector,
+) int {
+	if domainCache == nil || domain == "" {
+		return 0
+	}
+	entry, err := domainCache.GetDomain(domain)
+	if err != nil || entry.GetConfig() == nil {
+		return 0
+	}
+	return selector(entry.GetConfig().Limits)
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cache
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+func TestNewDomainLimitFn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	domainCache := NewMockDomainCache(ctrl)
+	fallback := func(domain string) int { return 100 }
+	fn := NewDomainLimitFn(domainCache, DomainUserRPSLimit, fallback)
+
+	withLimits := NewLocalDomainCacheEntryForTest(
+		&persistence.DomainInfo{Name: "with-limits"},
+		&persistence.DomainConfig{Limits: &types.DomainLimits{UserRPS: common.Int32Ptr(10)}},
+		"active",
+	)
+	withoutLimits := NewLocalDomainCacheEntryForTest(
+		&persistence.DomainInfo{Name: "without-limits"},
+		&persistence.DomainConfig{},
+		"active",
+	)
+	domainCache.EXPECT().GetDomain("with-limits").Return(withLimits, nil)
+	domainCache.EXPECT().GetDomain("without-limits").Return(withoutLimits, nil)
+	domainCache.EXPECT().GetDomain("unknown").Return(nil, errors.New("not found"))
+
+	assert.Equal(t, 10, fn("with-limits"))
+	assert.Equal(t, 100, fn("without-limits"))
+	assert.Equal(t, 100, fn("unknown"))
+	assert.Equal(t, 100, fn(""))
+}
@@ -54,6 +54,8 @@ type (
 		Services map[string]Service `yaml:"services"`
 		// Kafka is the config for connecting to kafka
 		Kafka KafkaConfig `yaml:"kafka"`
+		// Messaging selects the implementation of the messaging client, kafka or persistence
+		Messaging Messaging `yaml:"messaging"`
 		// Archival is the config for archival
 		Archival Archival `yaml:"archival"`
 		// PublicClient is config for sys worker service connecting to cadence frontend
@@ -83,6 +85,7 @@ type (
 		Client      string                              `yaml:"client"`
 		ConfigStore c.ClientConfig                      `yaml:"configstore"`
 		FileBased   dynamicconfig.FileBasedClientConfig `yaml:"filebased"`
+		Remote      dynamicconfig.RemoteClientConfig    `yaml:"remote"`
 	}
 
 	NoopAuthorizer struct {
@@ -427,6 +430,7 @@ type (
 		Filestore *FilestoreArchiver `yaml:"filestore"`
 		Gstorage  *GstorageArchiver  `yaml:"gstorage"`
 		S3store   *S3Archiver        `yaml:"s3store"`
+		Parquet   *ParquetArchiver   `yaml:"parquet"`
 	}
 
 	// VisibilityArchival contains the config for visibility archival
@@ -444,6 +448,7 @@ type (
 		Filestore *FilestoreArchiver `yaml:"filestore"`
 		S3store   *S3Archiver        `yaml:"s3store"`
 		Gstorage  *GstorageArchiver  `yaml:"gstorage"`
+		Parquet   *ParquetArchiver   `yaml:"parquet"`
 	}
 
 	// FilestoreArchiver contain the config for filestore archiver
@@ -452,6 +457,14 @@ type (
 		DirMode  string `yaml:"dirMode"`
 	}
 
+	// ParquetArchiver contains the config for the archiver writing parquet files to local disk
+	ParquetArchiver struct {
+		FileMode string `yaml:"fileMode"`
+		DirMode  string `yaml:"dirMode"`
+		// CompactionBatchSize is the number of staged files of a partition merged into one part file, defaults to 1000
+		CompactionBatchSize int `yaml:"compactionBatchSize"`
+	}
+
 	// GstorageArchiver contain the config for google storage archiver
 	GstorageArchiver struct {
 		CredentialsPath string `yaml:"credentialsPath"`
@@ -525,12 +538,16 @@ func (c *Config) validate() error {
 	if err := c.Archival.Validate(&c.DomainDefaults.Archival); err != nil {
 		return err
 	}
+	if err := c.Messaging.Validate(); err != nil {
+		return err
+	}
 
 	return c.Authorization.Validate()
 }
 
 func (c *Config) fillDefaults() {
 	c.Persistence.FillDefaults()
+	c.Messaging.FillDefaults()
 
 	// TODO: remove this at the point when we decided to make some breaking changes in config.
 	if c.ClusterGroupMetadata == nil && c.ClusterMetadata != nil {
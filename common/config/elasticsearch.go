@@ -50,6 +50,9 @@ type (
 		AWSSigning AWSSigning `yaml:"awsSigning"`
 		// optional to use Signed Certificates over https
 		TLS TLS `yaml:"tls"`
+		// optional to write visibility to time-based indices, which are rolled over and deleted by worker service.
+		// The visibility index name is then the read alias of the indices.
+		ManagedIndices bool `yaml:"managedIndices"`
 	}
 
 	// AWSSigning contains config to enable signing,
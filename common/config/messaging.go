@@ -0,0 +1,158 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// MessagingTypeKafka refers to kafka as the messaging implementation
+	MessagingTypeKafka = "kafka"
+	// MessagingTypePersistence refers to the persistence queue as the messaging implementation
+	MessagingTypePersistence = "persistence"
+
+	// MaxPersistenceMessagingPartitions is the maximum number of partitions of a persistence messaging queue
+	MaxPersistenceMessagingPartitions = 100
+
+	defaultPersistenceMessagingPollInterval           = time.Second
+	defaultPersistenceMessagingBatchSize              = 100
+	defaultPersistenceMessagingMaxRedeliveries        = 3
+	defaultPersistenceMessagingRedeliveryBackoff      = 5 * time.Second
+	defaultPersistenceMessagingAckLevelUpdateInterval = 5 * time.Second
+	defaultPersistenceMessagingPurgeInterval          = 5 * time.Minute
+)
+
+type (
+	// Messaging describes which implementation backs the messaging client
+	Messaging struct {
+		// Type is either kafka or persistence, default is kafka
+		Type string `yaml:"type"`
+		// Persistence is the config of the persistence queue backed implementation
+		Persistence PersistenceMessaging `yaml:"persistence"`
+	}
+
+	// PersistenceMessaging describes the configuration of the messaging client that
+	// is backed by the queue of the default persistence store
+	PersistenceMessaging struct {
+		// Applications describes the queue used by each application
+		Applications map[string]PersistenceQueue `yaml:"applications"`
+		// PollInterval is the interval between reads of a partition that has no new messages
+		PollInterval time.Duration `yaml:"pollInterval"`
+		// BatchSize is the max number of messages read from a partition at once
+		BatchSize int `yaml:"batchSize"`
+		// MaxRedeliveries is the number of times a nacked message is redelivered before it goes to the DLQ
+		MaxRedeliveries int `yaml:"maxRedeliveries"`
+		// RedeliveryBackoff is the delay before a nacked message is redelivered
+		RedeliveryBackoff time.Duration `yaml:"redeliveryBackoff"`
+		// AckLevelUpdateInterval is the interval to persist consumer ack levels
+		AckLevelUpdateInterval time.Duration `yaml:"ackLevelUpdateInterval"`
+		// PurgeInterval is the interval to delete messages acked by all consumers
+		PurgeInterval time.Duration `yaml:"purgeInterval"`
+	}
+
+	// PersistenceQueue describes the queue of a single application
+	PersistenceQueue struct {
+		// QueueID identifies the queue, it must be unique across applications
+		QueueID int `yaml:"queueID"`
+		// Partitions is the number of partitions of the queue, default is 1.
+		// Partitions are spread over the worker hosts, so worker hosts beyond the number
+		// of partitions do not consume the queue
+		Partitions int `yaml:"partitions"`
+	}
+)
+
+// IsPersistence returns true if messaging is backed by the persistence queue
+func (m *Messaging) IsPersistence() bool {
+	return m.Type == MessagingTypePersistence
+}
+
+// FillDefaults populates default values for unspecified fields in messaging config
+func (m *Messaging) FillDefaults() {
+	if m.Type == "" {
+		m.Type = MessagingTypeKafka
+	}
+	if !m.IsPersistence() {
+		return
+	}
+
+	p := &m.Persistence
+	if p.PollInterval <= 0 {
+		p.PollInterval = defaultPersistenceMessagingPollInterval
+	}
+	if p.BatchSize <= 0 {
+		p.BatchSize = defaultPersistenceMessagingBatchSize
+	}
+	if p.MaxRedeliveries <= 0 {
+		p.MaxRedeliveries = defaultPersistenceMessagingMaxRedeliveries
+	}
+	if p.RedeliveryBackoff <= 0 {
+		p.RedeliveryBackoff = defaultPersistenceMessagingRedeliveryBackoff
+	}
+	if p.AckLevelUpdateInterval <= 0 {
+		p.AckLevelUpdateInterval = defaultPersistenceMessagingAckLevelUpdateInterval
+	}
+	if p.PurgeInterval <= 0 {
+		p.PurgeInterval = defaultPersistenceMessagingPurgeInterval
+	}
+	for app, queue := range p.Applications {
+		if queue.Partitions == 0 {
+			queue.Partitions = 1
+			p.Applications[app] = queue
+		}
+	}
+}
+
+// Validate validates the messaging config
+func (m *Messaging) Validate() error {
+	switch m.Type {
+	case MessagingTypeKafka:
+		return nil
+	case MessagingTypePersistence:
+	default:
+		return fmt.Errorf("messaging config: unknown type %v", m.Type)
+	}
+
+	queueIDs := make(map[int]string)
+	for app, queue := range m.Persistence.Applications {
+		if queue.QueueID < 0 {
+			return fmt.Errorf("messaging config: application %v: queueID must not be negative", app)
+		}
+		if queue.Partitions <= 0 || queue.Partitions > MaxPersistenceMessagingPartitions {
+			return fmt.Errorf("messaging config: application %v: partitions must be between 1 and %v", app, MaxPersistenceMessagingPartitions)
+		}
+		if other, ok := queueIDs[queue.QueueID]; ok {
+			return fmt.Errorf("messaging config: applications %v and %v use the same queueID %v", app, other, queue.QueueID)
+		}
+		queueIDs[queue.QueueID] = app
+	}
+	return nil
+}
+
+// GetQueueForApplication returns the queue of the given application
+func (m *PersistenceMessaging) GetQueueForApplication(app string) (PersistenceQueue, error) {
+	queue, ok := m.Applications[app]
+	if !ok {
+		return PersistenceQueue{}, fmt.Errorf("messaging config: missing queue for application %v", app)
+	}
+	return queue, nil
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessagingFillDefaults(t *testing.T) {
+	m := &Messaging{}
+	m.FillDefaults()
+	assert.Equal(t, MessagingTypeKafka, m.Type)
+	assert.Zero(t, m.Persistence.PollInterval)
+
+	m = &Messaging{
+		Type: MessagingTypePersistence,
+		Persistence: PersistenceMessaging{
+			Applications: map[string]PersistenceQueue{"visibility": {QueueID: 1}},
+		},
+	}
+	m.FillDefaults()
+	assert.True(t, m.IsPersistence())
+	assert.Equal(t, defaultPersistenceMessagingPollInterval, m.Persistence.PollInterval)
+	assert.Equal(t, defaultPersistenceMessagingBatchSize, m.Persistence.BatchSize)
+	assert.Equal(t, defaultPersistenceMessagingMaxRedeliveries, m.Persistence.MaxRedeliveries)
+	assert.Equal(t, 1, m.Persistence.Applications["visibility"].Partitions)
+	assert.NoError(t, m.Validate())
+}
+
+func TestMessagingValidate(t *testing.T) {
+	tests := map[string]struct {
+		messaging Messaging
+		wantErr   bool
+	}{
+		"kafka": {
+			messaging: Messaging{Type: MessagingTypeKafka},
+		},
+		"unknown type": {
+			messaging: Messaging{Type: "rabbitmq"},
+			wantErr:   true,
+		},
+		"valid persistence": {
+			messaging: Messaging{
+				Type: MessagingTypePersistence,
+				Persistence: PersistenceMessaging{
+					Applications: map[string]PersistenceQueue{
+						"visibility": {QueueID: 0, Partitions: 4},
+						"other":      {QueueID: 1, Partitions: 1},
+					},
+				},
+			},
+		},
+		"duplicate queue ID": {
+			messaging: Messaging{
+				Type: MessagingTypePersistence,
+				Persistence: PersistenceMessaging{
+					Applications: map[string]PersistenceQueue{
+						"visibility": {QueueID: 1, Partitions: 4},
+						"other":      {QueueID: 1, Partitions: 1},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		"too many partitions": {
+			messaging: Messaging{
+				Type: MessagingTypePersistence,
+				Persistence: PersistenceMessaging{
+					Applications: map[string]PersistenceQueue{
+						"visibility": {QueueID: 0, Partitions: MaxPersistenceMessagingPartitions + 1},
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tt.messaging.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGetQueueForApplication(t *testing.T) {
+	m := &PersistenceMessaging{
+		Applications: map[string]PersistenceQueue{"visibility": {QueueID: 2, Partitions: 3}},
+	}
+	queue, err := m.GetQueueForApplication("visibility")
+	assert.NoError(t, err)
+	assert.Equal(t, PersistenceQueue{QueueID: 2, Partitions: 3}, queue)
+
+	_, err = m.GetQueueForApplication("unknown")
+	assert.Error(t, err)
+}
@@ -151,6 +151,14 @@ const (
 	AdvancedVisibilityWritingModeDual = "dual"
 )
 
+// enum for dynamic config AdvancedVisibilityWritingPipeline
+const (
+	// AdvancedVisibilityWritingPipelineKafka means publish visibility records to Kafka, to be indexed by the worker indexer
+	AdvancedVisibilityWritingPipelineKafka = "kafka"
+	// AdvancedVisibilityWritingPipelineDirect means write visibility records to advanced visibility store directly
+	AdvancedVisibilityWritingPipelineDirect = "direct"
+)
+
 const (
 	// DomainDataKeyForManagedFailover is key of DomainData for managed failover
 	DomainDataKeyForManagedFailover = "IsManagedByCadence"
@@ -109,3 +109,38 @@ func IsSystemIndexedKey(key string) bool {
 	_, ok := systemIndexedKeys[key]
 	return ok
 }
+
+// ResolveSearchAttributeAlias returns the search attribute the key is an alias of,
+// or the key itself if it is not an alias
+func ResolveSearchAttributeAlias(aliases map[string]interface{}, key string) string {
+	if target, ok := aliases[key].(string); ok && len(target) != 0 {
+		return target
+	}
+	return key
+}
+
+// IsSearchAttributeAllowedForDomain returns true if the search attribute is not scoped
+// or the domain is one of the domains it is scoped to
+func IsSearchAttributeAllowedForDomain(domainScopes map[string]interface{}, key string, domain string) bool {
+	scope, ok := domainScopes[key]
+	if !ok {
+		return true
+	}
+	switch domains := scope.(type) {
+	case []interface{}:
+		for _, d := range domains {
+			if d == domain {
+				return true
+			}
+		}
+	case []string:
+		for _, d := range domains {
+			if d == domain {
+				return true
+			}
+		}
+	case string:
+		return domains == domain
+	}
+	return false
+}
@@ -0,0 +1,311 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:generate mockgen -package $GOPACKAGE -source $GOFILE -destination audit_mock.go -self_package github.com/uber/cadence/common/domain
+
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dgryski/go-farm"
+	"go.uber.org/yarpc"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/authorization"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+// Operations recorded in the domain audit log
+const (
+	AuditOperationRegister  = "RegisterDomain"
+	AuditOperationUpdate    = "UpdateDomain"
+	AuditOperationFailover  = "FailoverDomain"
+	AuditOperationDeprecate = "DeprecateDomain"
+	AuditOperationDelete    = "DeleteDomain"
+
+	unknownActor = "unknown"
+
+	defaultAuditPageSize = 100
+)
+
+var _ AuditLog = (*auditLogImpl)(nil)
+
+type (
+	// AuditLog records the changes made to domains
+	AuditLog interface {
+		Append(ctx context.Context, event *types.DomainAuditEvent) error
+		List(ctx context.Context, request *types.ListDomainAuditEventsRequest) (*types.ListDomainAuditEventsResponse, error)
+	}
+
+	// AuditQueueProvider returns the queue of a bucket of the audit log
+	AuditQueueProvider func(bucket int) (persistence.QueueManager, error)
+
+	auditLogImpl struct {
+		queueProvider AuditQueueProvider
+	}
+
+	auditPageToken struct {
+		LastMessageID int64 `json:"lastMessageID"`
+	}
+
+	// domainSnapshot is the flattened state of a domain, used to diff it before and after a change
+	domainSnapshot map[string]string
+)
+
+// NewAuditLog creates a new AuditLog backed by the domain audit queues, the events
+// of a domain are kept in the queue of its bucket
+func NewAuditLog(queueProvider AuditQueueProvider) AuditLog {
+	return &auditLogImpl{
+		queueProvider: queueProvider,
+	}
+}
+
+// Append adds an event to the audit log, the event ID is assigned by the queue
+func (a *auditLogImpl) Append(
+	ctx context.Context,
+	event *types.DomainAuditEvent,
+) error {
+	queue, err := a.getQueue(event.DomainName)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode domain audit event: %v", err)
+	}
+	return queue.EnqueueMessage(ctx, payload)
+}
+
+// List returns the audit events of a domain, oldest first
+func (a *auditLogImpl) List(
+	ctx context.Context,
+	request *types.ListDomainAuditEventsRequest,
+) (*types.ListDomainAuditEventsResponse, error) {
+	if request.GetDomain() == "" {
+		return nil, &types.BadRequestError{Message: "Domain is not set on request."}
+	}
+	pageSize := int(request.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = defaultAuditPageSize
+	}
+	token := auditPageToken{LastMessageID: common.EmptyMessageID}
+	if len(request.NextPageToken) != 0 {
+		if err := json.Unmarshal(request.NextPageToken, &token); err != nil {
+			return nil, &types.BadRequestError{Message: "Invalid next page token."}
+		}
+	}
+
+	queue, err := a.getQueue(request.GetDomain())
+	if err != nil {
+		return nil, err
+	}
+
+	// the bucket of the domain is only shared with the few domains hashed to it,
+	// so reading it until the page is filled does not scan the events of all domains
+	response := &types.ListDomainAuditEventsResponse{}
+	for {
+		messages, err := queue.ReadMessages(ctx, token.LastMessageID, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, message := range messages {
+			token.LastMessageID = message.ID
+			event := &types.DomainAuditEvent{}
+			if err := json.Unmarshal(message.Payload, event); err != nil {
+				return nil, fmt.Errorf("failed to decode domain audit event %v: %v", message.ID, err)
+			}
+			if event.DomainName != request.GetDomain() {
+				continue
+			}
+			event.EventID = message.ID
+			response.Events = append(response.Events, event)
+			if len(response.Events) == pageSize {
+				break
+			}
+		}
+		if len(messages) < pageSize && len(response.Events) < pageSize {
+			// reached the end of the queue
+			return response, nil
+		}
+		if len(response.Events) == pageSize {
+			break
+		}
+	}
+
+	nextPageToken, err := json.Marshal(token)
+	if err != nil {
+		return nil, err
+	}
+	response.NextPageToken = nextPageToken
+	return response, nil
+}
+
+func (a *auditLogImpl) getQueue(domainName string) (persistence.QueueManager, error) {
+	return a.queueProvider(auditBucket(domainName))
+}
+
+// auditBucket returns the bucket which keeps the audit events of a domain
+func auditBucket(domainName string) int {
+	return int(farm.Fingerprint32([]byte(domainName)) % uint32(persistence.DomainAuditQueueBuckets))
+}
+
+// newAuditEvent builds the audit event of a domain change, before or after is nil
+// when the domain was created or deleted
+func newAuditEvent(
+	ctx context.Context,
+	operation string,
+	cluster string,
+	timestamp int64,
+	request interface{},
+	before domainSnapshot,
+	after domainSnapshot,
+) *types.DomainAuditEvent {
+	event := &types.DomainAuditEvent{
+		Operation: operation,
+		Actor:     auditActor(ctx),
+		Cluster:   cluster,
+		Timestamp: timestamp,
+		Request:   encodeAuditRequest(request),
+		Changes:   before.diff(after),
+	}
+	for _, snapshot := range []domainSnapshot{after, before} {
+		if snapshot != nil {
+			event.DomainID = snapshot["id"]
+			event.DomainName = snapshot["name"]
+			break
+		}
+	}
+	return event
+}
+
+// auditActor returns who made the request, as identified by the authorizer,
+// or the calling service when the request was not authorized with an identity
+func auditActor(ctx context.Context) string {
+	if attributes := authorization.AttributesFromContext(ctx); attributes != nil && attributes.Actor != "" {
+		return attributes.Actor
+	}
+	if caller := yarpc.CallFromContext(ctx).Caller(); caller != "" {
+		return caller
+	}
+	return unknownActor
+}
+
+// encodeAuditRequest returns the JSON encoded request without its security token
+func encodeAuditRequest(request interface{}) string {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return ""
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return string(data)
+	}
+	delete(fields, "securityToken")
+	data, err = json.Marshal(fields)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func newDomainSnapshot(
+	info *persistence.DomainInfo,
+	config *persistence.DomainConfig,
+	replicationConfig *persistence.DomainReplicationConfig,
+	isGlobalDomain bool,
+	failoverVersion int64,
+) domainSnapshot {
+	snapshot := domainSnapshot{
+		"id":             info.ID,
+		"name":           info.Name,
+		"status":         strconv.Itoa(info.Status),
+		"description":    info.Description,
+		"ownerEmail":     info.OwnerEmail,
+		"isGlobalDomain": strconv.FormatBool(isGlobalDomain),
+	}
+	if status := getDomainStatus(info); status != nil {
+		snapshot["status"] = status.String()
+	}
+	for key, value := range info.Data {
+		snapshot["data."+key] = value
+	}
+	if config != nil {
+		snapshot["retentionDays"] = strconv.Itoa(int(config.Retention))
+		snapshot["emitMetric"] = strconv.FormatBool(config.EmitMetric)
+		snapshot["historyArchivalStatus"] = config.HistoryArchivalStatus.String()
+		snapshot["historyArchivalURI"] = config.HistoryArchivalURI
+		snapshot["visibilityArchivalStatus"] = config.VisibilityArchivalStatus.String()
+		snapshot["visibilityArchivalURI"] = config.VisibilityArchivalURI
+		for checksum, info := range config.BadBinaries.Binaries {
+			snapshot["badBinaries."+checksum] = fmt.Sprintf("reason: %v, operator: %v", info.GetReason(), info.GetOperator())
+		}
+		if config.Limits != nil {
+			if limits, err := json.Marshal(config.Limits); err == nil {
+				snapshot["limits"] = string(limits)
+			}
+		}
+	}
+	if replicationConfig != nil {
+		clusters := make([]string, 0, len(replicationConfig.Clusters))
+		for _, cluster := range replicationConfig.Clusters {
+			clusters = append(clusters, cluster.ClusterName)
+		}
+		sort.Strings(clusters)
+		snapshot["activeClusterName"] = replicationConfig.ActiveClusterName
+		snapshot["clusters"] = strings.Join(clusters, ",")
+		snapshot["failoverVersion"] = strconv.FormatInt(failoverVersion, 10)
+	}
+	return snapshot
+}
+
+// diff returns the fields that differ between the two snapshots, sorted by field name
+func (s domainSnapshot) diff(other domainSnapshot) []*types.DomainAuditChange {
+	fields := make(map[string]struct{}, len(s)+len(other))
+	for field := range s {
+		fields[field] = struct{}{}
+	}
+	for field := range other {
+		fields[field] = struct{}{}
+	}
+
+	var changes []*types.DomainAuditChange
+	for field := range fields {
+		before, after := s[field], other[field]
+		if before == after {
+			continue
+		}
+		changes = append(changes, &types.DomainAuditChange{
+			Field:  field,
+			Before: before,
+			After:  after,
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
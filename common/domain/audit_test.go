@@ -0,0 +1,241 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/authorization"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+type (
+	auditLogSuite struct {
+		suite.Suite
+
+		controller *gomock.Controller
+		mockQueue  *persistence.MockQueueManager
+		auditLog   AuditLog
+	}
+)
+
+func TestAuditLogSuite(t *testing.T) {
+	suite.Run(t, new(auditLogSuite))
+}
+
+func (s *auditLogSuite) SetupTest() {
+	s.controller = gomock.NewController(s.T())
+	s.mockQueue = persistence.NewMockQueueManager(s.controller)
+	s.auditLog = NewAuditLog(func(bucket int) (persistence.QueueManager, error) {
+		s.Equal(auditBucket("domain"), bucket)
+		return s.mockQueue, nil
+	})
+}
+
+func (s *auditLogSuite) TearDownTest() {
+	s.controller.Finish()
+}
+
+func (s *auditLogSuite) TestAppend() {
+	event := &types.DomainAuditEvent{
+		DomainID:   "domain-id",
+		DomainName: "domain",
+		Operation:  AuditOperationUpdate,
+		Actor:      "alice",
+	}
+	s.mockQueue.EXPECT().EnqueueMessage(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, payload []byte) error {
+			decoded := &types.DomainAuditEvent{}
+			s.NoError(json.Unmarshal(payload, decoded))
+			s.Equal(event, decoded)
+			return nil
+		})
+	s.NoError(s.auditLog.Append(context.Background(), event))
+}
+
+func (s *auditLogSuite) TestList_FiltersByDomain() {
+	messages := []*persistence.QueueMessage{
+		s.newMessage(0, "domain"),
+		s.newMessage(1, "other"),
+		s.newMessage(2, "domain"),
+	}
+	s.mockQueue.EXPECT().ReadMessages(gomock.Any(), int64(common.EmptyMessageID), 10).Return(messages, nil)
+
+	response, err := s.auditLog.List(context.Background(), &types.ListDomainAuditEventsRequest{
+		Domain:   "domain",
+		PageSize: 10,
+	})
+	s.NoError(err)
+	s.Nil(response.NextPageToken)
+	s.Len(response.Events, 2)
+	s.Equal(int64(0), response.Events[0].EventID)
+	s.Equal(int64(2), response.Events[1].EventID)
+}
+
+func (s *auditLogSuite) TestList_Paging() {
+	s.mockQueue.EXPECT().ReadMessages(gomock.Any(), int64(common.EmptyMessageID), 2).Return([]*persistence.QueueMessage{
+		s.newMessage(0, "other"),
+		s.newMessage(1, "domain"),
+	}, nil)
+	s.mockQueue.EXPECT().ReadMessages(gomock.Any(), int64(1), 2).Return([]*persistence.QueueMessage{
+		s.newMessage(2, "domain"),
+		s.newMessage(3, "domain"),
+	}, nil)
+	s.mockQueue.EXPECT().ReadMessages(gomock.Any(), int64(2), 2).Return([]*persistence.QueueMessage{
+		s.newMessage(3, "domain"),
+	}, nil)
+
+	request := &types.ListDomainAuditEventsRequest{
+		Domain:   "domain",
+		PageSize: 2,
+	}
+	response, err := s.auditLog.List(context.Background(), request)
+	s.NoError(err)
+	s.Len(response.Events, 2)
+	s.Equal(int64(1), response.Events[0].EventID)
+	s.Equal(int64(2), response.Events[1].EventID)
+	s.NotNil(response.NextPageToken)
+
+	request.NextPageToken = response.NextPageToken
+	response, err = s.auditLog.List(context.Background(), request)
+	s.NoError(err)
+	s.Len(response.Events, 1)
+	s.Equal(int64(3), response.Events[0].EventID)
+	s.Nil(response.NextPageToken)
+}
+
+func (s *auditLogSuite) TestList_ReadsUntilPageIsFilled() {
+	// events of the domains sharing the bucket are skipped however many reads it takes
+	for i := int64(0); i < 30; i++ {
+		s.mockQueue.EXPECT().ReadMessages(gomock.Any(), i-1, 1).Return([]*persistence.QueueMessage{
+			s.newMessage(i, "other"),
+		}, nil)
+	}
+	s.mockQueue.EXPECT().ReadMessages(gomock.Any(), int64(29), 1).Return([]*persistence.QueueMessage{
+		s.newMessage(30, "domain"),
+	}, nil)
+
+	response, err := s.auditLog.List(context.Background(), &types.ListDomainAuditEventsRequest{
+		Domain:   "domain",
+		PageSize: 1,
+	})
+	s.NoError(err)
+	s.Len(response.Events, 1)
+	s.Equal(int64(30), response.Events[0].EventID)
+	s.NotNil(response.NextPageToken)
+}
+
+func (s *auditLogSuite) TestAuditBucket() {
+	buckets := make(map[int]struct{})
+	for i := 0; i < 1000; i++ {
+		bucket := auditBucket(fmt.Sprintf("domain-%v", i))
+		s.True(bucket >= 0 && bucket < persistence.DomainAuditQueueBuckets)
+		s.Equal(bucket, auditBucket(fmt.Sprintf("domain-%v", i)))
+		buckets[bucket] = struct{}{}
+	}
+	// domains are spread over the buckets
+	s.Len(buckets, persistence.DomainAuditQueueBuckets)
+}
+
+func (s *auditLogSuite) TestList_InvalidRequest() {
+	_, err := s.auditLog.List(context.Background(), &types.ListDomainAuditEventsRequest{})
+	s.IsType(&types.BadRequestError{}, err)
+
+	_, err = s.auditLog.List(context.Background(), &types.ListDomainAuditEventsRequest{
+		Domain:        "domain",
+		NextPageToken: []byte("invalid"),
+	})
+	s.IsType(&types.BadRequestError{}, err)
+}
+
+func (s *auditLogSuite) TestNewAuditEvent() {
+	info := &persistence.DomainInfo{
+		ID:     "domain-id",
+		Name:   "domain",
+		Status: persistence.DomainStatusRegistered,
+		Data:   map[string]string{"k": "v"},
+	}
+	config := &persistence.DomainConfig{
+		Retention: 3,
+		BadBinaries: types.BadBinaries{Binaries: map[string]*types.BadBinaryInfo{
+			"checksum": {Reason: "bad", Operator: "bob"},
+		}},
+	}
+	replicationConfig := &persistence.DomainReplicationConfig{
+		ActiveClusterName: "active",
+		Clusters: []*persistence.ClusterReplicationConfig{
+			{ClusterName: "standby"},
+			{ClusterName: "active"},
+		},
+	}
+	before := newDomainSnapshot(info, config, replicationConfig, true, 1)
+
+	config.Retention = 7
+	config.BadBinaries.Binaries = map[string]*types.BadBinaryInfo{}
+	replicationConfig.ActiveClusterName = "standby"
+	after := newDomainSnapshot(info, config, replicationConfig, true, 11)
+
+	ctx := authorization.NewContextWithAttributes(context.Background(), &authorization.Attributes{Actor: "alice"})
+	request := &types.UpdateDomainRequest{
+		Name:              "domain",
+		SecurityToken:     "secret",
+		ActiveClusterName: common.StringPtr("standby"),
+	}
+	event := newAuditEvent(ctx, AuditOperationFailover, "active", 123, request, before, after)
+	s.Equal("domain-id", event.DomainID)
+	s.Equal("domain", event.DomainName)
+	s.Equal(AuditOperationFailover, event.Operation)
+	s.Equal("alice", event.Actor)
+	s.Equal("active", event.Cluster)
+	s.Equal(int64(123), event.Timestamp)
+	s.NotContains(event.Request, "secret")
+	s.Contains(event.Request, `"activeClusterName":"standby"`)
+	s.Equal([]*types.DomainAuditChange{
+		{Field: "activeClusterName", Before: "active", After: "standby"},
+		{Field: "badBinaries.checksum", Before: "reason: bad, operator: bob"},
+		{Field: "failoverVersion", Before: "1", After: "11"},
+		{Field: "retentionDays", Before: "3", After: "7"},
+	}, event.Changes)
+
+	// registration has no previous state and the actor is unknown without authorization
+	event = newAuditEvent(context.Background(), AuditOperationRegister, "active", 123, request, nil, after)
+	s.Equal("domain", event.DomainName)
+	s.Equal(unknownActor, event.Actor)
+	s.Contains(event.Changes, &types.DomainAuditChange{Field: "clusters", After: "active,standby"})
+}
+
+func (s *auditLogSuite) newMessage(id int64, domainName string) *persistence.QueueMessage {
+	payload, err := json.Marshal(&types.DomainAuditEvent{DomainName: domainName})
+	s.NoError(err)
+	return &persistence.QueueMessage{
+		ID:        id,
+		QueueType: persistence.DomainAuditQueueTypeBase + persistence.QueueType(auditBucket(domainName)),
+		Payload:   payload,
+	}
+}
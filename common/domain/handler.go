@@ -49,6 +49,10 @@ var (
 type (
 	// Handler is the domain operation handler
 	Handler interface {
+		DeleteDomain(
+			ctx context.Context,
+			deleteRequest *types.DeleteDomainRequest,
+		) error
 		DeprecateDomain(
 			ctx context.Context,
 			deprecateRequest *types.DeprecateDomainRequest,
@@ -79,6 +83,7 @@ type (
 		domainAttrValidator *AttrValidatorImpl
 		archivalMetadata    archiver.ArchivalMetadata
 		archiverProvider    provider.ArchiverProvider
+		auditLog            AuditLog
 		timeSource          clock.TimeSource
 		config              Config
 		logger              log.Logger
@@ -105,6 +110,7 @@ func NewHandler(
 	domainReplicator Replicator,
 	archivalMetadata archiver.ArchivalMetadata,
 	archiverProvider provider.ArchiverProvider,
+	auditLog AuditLog,
 	timeSource clock.TimeSource,
 ) Handler {
 	return &handlerImpl{
@@ -115,6 +121,7 @@ func NewHandler(
 		domainAttrValidator: newAttrValidator(clusterMetadata, int32(config.MinRetentionDays())),
 		archivalMetadata:    archivalMetadata,
 		archiverProvider:    archiverProvider,
+		auditLog:            auditLog,
 		timeSource:          timeSource,
 		config:              config,
 	}
@@ -278,6 +285,15 @@ func (d *handlerImpl) RegisterDomain(
 		tag.WorkflowDomainID(domainResponse.ID),
 	)
 
+	d.recordAuditEvent(
+		ctx,
+		AuditOperationRegister,
+		domainRequest.LastUpdatedTime,
+		registerRequest,
+		nil,
+		newDomainSnapshot(info, config, replicationConfig, isGlobalDomain, failoverVersion),
+	)
+
 	return nil
 }
 
@@ -382,6 +398,7 @@ func (d *handlerImpl) UpdateDomain(
 	currentActiveCluster := replicationConfig.ActiveClusterName
 	previousFailoverVersion := getResponse.PreviousFailoverVersion
 	lastUpdatedTime := time.Unix(0, getResponse.LastUpdatedTime)
+	before := newDomainSnapshot(info, config, replicationConfig, isGlobalDomain, failoverVersion)
 
 	// whether history archival config changed
 	historyArchivalConfigChanged := false
@@ -569,6 +586,21 @@ func (d *handlerImpl) UpdateDomain(
 		tag.WorkflowDomainName(info.Name),
 		tag.WorkflowDomainID(info.ID),
 	)
+
+	if configurationChanged || activeClusterChanged {
+		operation := AuditOperationUpdate
+		if activeClusterChanged {
+			operation = AuditOperationFailover
+		}
+		d.recordAuditEvent(
+			ctx,
+			operation,
+			lastUpdatedTime.UnixNano(),
+			updateRequest,
+			before,
+			newDomainSnapshot(info, config, replicationConfig, isGlobalDomain, failoverVersion),
+		)
+	}
 	return response, nil
 }
 
@@ -596,6 +628,7 @@ func (d *handlerImpl) DeprecateDomain(
 	if isGlobalDomain && !d.clusterMetadata.IsPrimaryCluster() {
 		return errNotPrimaryCluster
 	}
+	before := newDomainSnapshot(getResponse.Info, getResponse.Config, getResponse.ReplicationConfig, isGlobalDomain, getResponse.FailoverVersion)
 	getResponse.ConfigVersion = getResponse.ConfigVersion + 1
 	getResponse.Info.Status = persistence.DomainStatusDeprecated
 
@@ -636,9 +669,83 @@ func (d *handlerImpl) DeprecateDomain(
 		tag.WorkflowDomainName(getResponse.Info.Name),
 		tag.WorkflowDomainID(getResponse.Info.ID),
 	)
+
+	d.recordAuditEvent(
+		ctx,
+		AuditOperationDeprecate,
+		updateReq.LastUpdatedTime,
+		deprecateRequest,
+		before,
+		newDomainSnapshot(getResponse.Info, getResponse.Config, getResponse.ReplicationConfig, isGlobalDomain, getResponse.FailoverVersion),
+	)
+	return nil
+}
+
+// DeleteDomain removes the record of a deprecated local domain. Global domains are rejected as their
+// deletion is not replicated to the other clusters.
+// The executions, histories, visibility records and task lists of the domain are not touched, they are
+// expected to be purged before, see the domain deletion workflow of the worker service.
+func (d *handlerImpl) DeleteDomain(
+	ctx context.Context,
+	deleteRequest *types.DeleteDomainRequest,
+) error {
+
+	getResponse, err := d.domainManager.GetDomain(ctx, &persistence.GetDomainRequest{Name: deleteRequest.GetName()})
+	if err != nil {
+		return err
+	}
+
+	isGlobalDomain := getResponse.IsGlobalDomain
+	if isGlobalDomain {
+		return errGlobalDomainDeletion
+	}
+	if getResponse.Info.Status != persistence.DomainStatusDeprecated {
+		return errDomainNotDeprecated
+	}
+
+	if err := d.domainManager.DeleteDomain(ctx, &persistence.DeleteDomainRequest{ID: getResponse.Info.ID}); err != nil {
+		return err
+	}
+
+	d.logger.Info("DeleteDomain domain succeeded",
+		tag.WorkflowDomainName(getResponse.Info.Name),
+		tag.WorkflowDomainID(getResponse.Info.ID),
+	)
+
+	d.recordAuditEvent(
+		ctx,
+		AuditOperationDelete,
+		d.timeSource.Now().UnixNano(),
+		deleteRequest,
+		newDomainSnapshot(getResponse.Info, getResponse.Config, getResponse.ReplicationConfig, isGlobalDomain, getResponse.FailoverVersion),
+		nil,
+	)
 	return nil
 }
 
+// recordAuditEvent appends a domain change to the audit log, failures are only logged
+// as the change itself has already been persisted
+func (d *handlerImpl) recordAuditEvent(
+	ctx context.Context,
+	operation string,
+	timestamp int64,
+	request interface{},
+	before domainSnapshot,
+	after domainSnapshot,
+) {
+	if d.auditLog == nil {
+		return
+	}
+	event := newAuditEvent(ctx, operation, d.clusterMetadata.GetCurrentClusterName(), timestamp, request, before, after)
+	if err := d.auditLog.Append(ctx, event); err != nil {
+		d.logger.Error("Failed to record domain audit event",
+			tag.WorkflowDomainName(event.DomainName),
+			tag.WorkflowDomainID(event.DomainID),
+			tag.Error(err),
+		)
+	}
+}
+
 func (d *handlerImpl) createResponse(
 	info *persistence.DomainInfo,
 	config *persistence.DomainConfig,
@@ -662,6 +769,7 @@ func (d *handlerImpl) createResponse(
 		VisibilityArchivalStatus:               config.VisibilityArchivalStatus.Ptr(),
 		VisibilityArchivalURI:                  config.VisibilityArchivalURI,
 		BadBinaries:                            &config.BadBinaries,
+		Limits:                                 config.Limits,
 	}
 
 	clusters := []*types.ClusterReplicationConfiguration{}
@@ -697,6 +805,54 @@ func (d *handlerImpl) mergeBadBinaries(
 	}
 }
 
+// mergeDomainLimits applies the limits set in the update on top of the existing ones.
+// A non-positive value removes the override so that dynamic config applies again.
+func mergeDomainLimits(
+	old *types.DomainLimits,
+	new *types.DomainLimits,
+) *types.DomainLimits {
+
+	merged := types.DomainLimits{}
+	if old != nil {
+		merged = *old
+	}
+	merged.UserRPS = mergeInt32Limit(merged.UserRPS, new.UserRPS)
+	merged.WorkerRPS = mergeInt32Limit(merged.WorkerRPS, new.WorkerRPS)
+	merged.VisibilityRPS = mergeInt32Limit(merged.VisibilityRPS, new.VisibilityRPS)
+	merged.BlobSizeLimit = mergeInt64Limit(merged.BlobSizeLimit, new.BlobSizeLimit)
+	merged.HistorySizeLimit = mergeInt64Limit(merged.HistorySizeLimit, new.HistorySizeLimit)
+	merged.HistoryCountLimit = mergeInt64Limit(merged.HistoryCountLimit, new.HistoryCountLimit)
+	merged.SearchAttributesNumberOfKeysLimit = mergeInt32Limit(merged.SearchAttributesNumberOfKeysLimit, new.SearchAttributesNumberOfKeysLimit)
+	merged.SearchAttributesSizeOfValueLimit = mergeInt64Limit(merged.SearchAttributesSizeOfValueLimit, new.SearchAttributesSizeOfValueLimit)
+	merged.SearchAttributesTotalSizeLimit = mergeInt64Limit(merged.SearchAttributesTotalSizeLimit, new.SearchAttributesTotalSizeLimit)
+	merged.MaxExecutionStartToCloseTimeoutSeconds = mergeInt32Limit(merged.MaxExecutionStartToCloseTimeoutSeconds, new.MaxExecutionStartToCloseTimeoutSeconds)
+
+	if merged == (types.DomainLimits{}) {
+		return nil
+	}
+	return &merged
+}
+
+func mergeInt32Limit(old *int32, new *int32) *int32 {
+	if new == nil {
+		return old
+	}
+	if *new <= 0 {
+		return nil
+	}
+	return common.Int32Ptr(*new)
+}
+
+func mergeInt64Limit(old *int64, new *int64) *int64 {
+	if new == nil {
+		return old
+	}
+	if *new <= 0 {
+		return nil
+	}
+	return common.Int64Ptr(*new)
+}
+
 func (d *handlerImpl) mergeDomainData(
 	old map[string]string,
 	new map[string]string,
@@ -872,6 +1028,10 @@ func (d *handlerImpl) updateDomainConfiguration(
 			}
 		}
 	}
+	if updateRequest.Limits != nil {
+		isConfigChanged = true
+		config.Limits = mergeDomainLimits(config.Limits, updateRequest.Limits)
+	}
 	return config, isConfigChanged, nil
 }
 
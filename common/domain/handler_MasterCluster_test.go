@@ -115,6 +115,7 @@ func (s *domainHandlerGlobalDomainEnabledPrimaryClusterSuite) SetupTest() {
 		s.mockDomainReplicator,
 		s.archivalMetadata,
 		s.mockArchiverProvider,
+		nil,
 		clock.NewRealTimeSource(),
 	).(*handlerImpl)
 }
@@ -877,6 +878,7 @@ func (s *domainHandlerGlobalDomainEnabledPrimaryClusterSuite) TestUpdateDomain_C
 		s.mockDomainReplicator,
 		s.archivalMetadata,
 		s.mockArchiverProvider,
+		nil,
 		clock.NewRealTimeSource(),
 	).(*handlerImpl)
 
@@ -958,6 +960,28 @@ func (s *domainHandlerGlobalDomainEnabledPrimaryClusterSuite) TestDeprecateGetDo
 	assertDomainEqual(s.Suite, getResp, expectedResp)
 }
 
+func (s *domainHandlerGlobalDomainEnabledPrimaryClusterSuite) TestDeleteDomain_GlobalDomain() {
+	domainName := s.getRandomDomainName()
+	s.setupGlobalDomain(domainName)
+
+	s.mockProducer.On("Publish", mock.Anything, mock.Anything).Return(nil).Once()
+
+	err := s.handler.DeprecateDomain(context.Background(), &types.DeprecateDomainRequest{
+		Name: domainName,
+	})
+	s.Nil(err)
+
+	err = s.handler.DeleteDomain(context.Background(), &types.DeleteDomainRequest{
+		Name: domainName,
+	})
+	s.Equal(errGlobalDomainDeletion, err)
+
+	_, err = s.handler.DescribeDomain(context.Background(), &types.DescribeDomainRequest{
+		Name: common.StringPtr(domainName),
+	})
+	s.Nil(err)
+}
+
 func (s *domainHandlerGlobalDomainEnabledPrimaryClusterSuite) getRandomDomainName() string {
 	return "domain" + uuid.New()
 }
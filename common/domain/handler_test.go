@@ -118,6 +118,7 @@ func (s *domainHandlerCommonSuite) SetupTest() {
 		s.mockDomainReplicator,
 		s.archivalMetadata,
 		s.mockArchiverProvider,
+		nil,
 		clock.NewRealTimeSource(),
 	).(*handlerImpl)
 }
@@ -263,6 +264,47 @@ func (s *domainHandlerCommonSuite) TestMergeBadBinaries_Nil() {
 	}, out)
 }
 
+func (s *domainHandlerCommonSuite) TestMergeDomainLimits_Merging() {
+	out := mergeDomainLimits(
+		&types.DomainLimits{
+			UserRPS:          common.Int32Ptr(100),
+			HistorySizeLimit: common.Int64Ptr(1024),
+		},
+		&types.DomainLimits{
+			UserRPS:   common.Int32Ptr(200),
+			WorkerRPS: common.Int32Ptr(50),
+		},
+	)
+
+	assert.Equal(s.T(), &types.DomainLimits{
+		UserRPS:          common.Int32Ptr(200),
+		WorkerRPS:        common.Int32Ptr(50),
+		HistorySizeLimit: common.Int64Ptr(1024),
+	}, out)
+}
+
+func (s *domainHandlerCommonSuite) TestMergeDomainLimits_Removing() {
+	out := mergeDomainLimits(
+		&types.DomainLimits{
+			UserRPS:          common.Int32Ptr(100),
+			HistorySizeLimit: common.Int64Ptr(1024),
+		},
+		&types.DomainLimits{
+			UserRPS: common.Int32Ptr(0),
+		},
+	)
+	assert.Equal(s.T(), &types.DomainLimits{HistorySizeLimit: common.Int64Ptr(1024)}, out)
+
+	out = mergeDomainLimits(out, &types.DomainLimits{HistorySizeLimit: common.Int64Ptr(-1)})
+	assert.Nil(s.T(), out)
+}
+
+func (s *domainHandlerCommonSuite) TestMergeDomainLimits_Nil() {
+	out := mergeDomainLimits(nil, &types.DomainLimits{BlobSizeLimit: common.Int64Ptr(2048)})
+
+	assert.Equal(s.T(), &types.DomainLimits{BlobSizeLimit: common.Int64Ptr(2048)}, out)
+}
+
 func (s *domainHandlerCommonSuite) TestListDomain() {
 	domainName1 := s.getRandomDomainName()
 	description1 := "some random description 1"
@@ -422,6 +464,34 @@ func (s *domainHandlerCommonSuite) TestUpdateDomain_InvalidRetentionPeriod() {
 	s.Equal(errInvalidRetentionPeriod, err)
 }
 
+func (s *domainHandlerCommonSuite) TestDeleteDomain() {
+	domain := s.getRandomDomainName()
+	registerRequest := &types.RegisterDomainRequest{
+		Name:                                   domain,
+		Description:                            domain,
+		WorkflowExecutionRetentionPeriodInDays: int32(10),
+		IsGlobalDomain:                         false,
+	}
+	err := s.handler.RegisterDomain(context.Background(), registerRequest)
+	s.NoError(err)
+
+	deleteRequest := &types.DeleteDomainRequest{Name: domain}
+	err = s.handler.DeleteDomain(context.Background(), deleteRequest)
+	s.Equal(errDomainNotDeprecated, err)
+
+	err = s.handler.DeprecateDomain(context.Background(), &types.DeprecateDomainRequest{Name: domain})
+	s.NoError(err)
+	err = s.handler.DeleteDomain(context.Background(), deleteRequest)
+	s.NoError(err)
+
+	_, err = s.domainManager.GetDomain(context.Background(), &persistence.GetDomainRequest{Name: domain})
+	s.IsType(&types.EntityNotExistsError{}, err)
+
+	// the name can be reused once the domain is deleted
+	err = s.handler.RegisterDomain(context.Background(), registerRequest)
+	s.NoError(err)
+}
+
 func (s *domainHandlerCommonSuite) TestUpdateDomain_GracefulFailover_Success() {
 	s.mockProducer.On("Publish", mock.Anything, mock.Anything).Return(nil).Twice()
 	domain := uuid.New()
@@ -163,6 +163,7 @@ func (s *domainReplicationTaskExecutorSuite) TestExecute_RegisterDomainTask() {
 	clusterStandby := "some random standby cluster name"
 	configVersion := int64(0)
 	failoverVersion := int64(59)
+	limits := &types.DomainLimits{UserRPS: common.Int32Ptr(100)}
 	clusters := []*types.ClusterReplicationConfiguration{
 		{
 			ClusterName: clusterActive,
@@ -189,6 +190,7 @@ func (s *domainReplicationTaskExecutorSuite) TestExecute_RegisterDomainTask() {
 			HistoryArchivalURI:                     historyArchivalURI,
 			VisibilityArchivalStatus:               visibilityArchivalStatus.Ptr(),
 			VisibilityArchivalURI:                  visibilityArchivalURI,
+			Limits:                                 limits,
 		},
 		ReplicationConfig: &types.DomainReplicationConfiguration{
 			ActiveClusterName: clusterActive,
@@ -219,6 +221,7 @@ func (s *domainReplicationTaskExecutorSuite) TestExecute_RegisterDomainTask() {
 	s.Equal(historyArchivalURI, resp.Config.HistoryArchivalURI)
 	s.Equal(visibilityArchivalStatus, resp.Config.VisibilityArchivalStatus)
 	s.Equal(visibilityArchivalURI, resp.Config.VisibilityArchivalURI)
+	s.Equal(limits, resp.Config.Limits)
 	s.Equal(clusterActive, resp.ReplicationConfig.ActiveClusterName)
 	s.Equal(s.domainReplicator.convertClusterReplicationConfigFromThrift(clusters), resp.ReplicationConfig.Clusters)
 	s.Equal(configVersion, resp.ConfigVersion)
@@ -519,6 +522,7 @@ func (s *domainReplicationTaskExecutorSuite) TestExecute_UpdateDomainTask_Update
 	updateClusterStandby := "other random standby cluster name"
 	updateConfigVersion := configVersion + 1
 	updateFailoverVersion := failoverVersion - 1
+	updateLimits := &types.DomainLimits{UserRPS: common.Int32Ptr(100), BlobSizeLimit: common.Int64Ptr(1024)}
 	updateClusters := []*types.ClusterReplicationConfiguration{
 		{
 			ClusterName: updateClusterActive,
@@ -544,6 +548,7 @@ func (s *domainReplicationTaskExecutorSuite) TestExecute_UpdateDomainTask_Update
 			HistoryArchivalURI:                     updateHistoryArchivalURI,
 			VisibilityArchivalStatus:               updateVisibilityArchivalStatus.Ptr(),
 			VisibilityArchivalURI:                  updateVisibilityArchivalURI,
+			Limits:                                 updateLimits,
 		},
 		ReplicationConfig: &types.DomainReplicationConfiguration{
 			ActiveClusterName: updateClusterActive,
@@ -573,6 +578,7 @@ func (s *domainReplicationTaskExecutorSuite) TestExecute_UpdateDomainTask_Update
 	s.Equal(updateHistoryArchivalURI, resp.Config.HistoryArchivalURI)
 	s.Equal(updateVisibilityArchivalStatus, resp.Config.VisibilityArchivalStatus)
 	s.Equal(updateVisibilityArchivalURI, resp.Config.VisibilityArchivalURI)
+	s.Equal(updateLimits, resp.Config.Limits)
 	s.Equal(clusterActive, resp.ReplicationConfig.ActiveClusterName)
 	s.Equal(s.domainReplicator.convertClusterReplicationConfigFromThrift(updateClusters), resp.ReplicationConfig.Clusters)
 	s.Equal(updateConfigVersion, resp.ConfigVersion)
@@ -33,6 +33,7 @@ const (
 	DynamicConfigFileBasedClient   = "filebased"
 	DynamicConfigInMemoryClient    = "memory"
 	DynamicConfigNopClient         = "nop"
+	DynamicConfigRemoteClient      = "remote"
 )
 
 // Client allows fetching values from a dynamic configuration system NOTE: This does not have async
@@ -170,6 +170,9 @@ type IntPropertyFnWithWorkflowTypeFilter func(domainName string, workflowType st
 // DurationPropertyFnWithDomainFilter is a wrapper to get duration property from dynamic config with domain as filter
 type DurationPropertyFnWithWorkflowTypeFilter func(domainName string, workflowType string) time.Duration
 
+// StringPropertyFnWithWorkflowTypeFilter is a wrapper to get string property from dynamic config with domain as filter
+type StringPropertyFnWithWorkflowTypeFilter func(domainName string, workflowType string) string
+
 // GetProperty gets a interface property and returns defaultValue if property is not found
 func (c *Collection) GetProperty(key Key) PropertyFn {
 	return func() interface{} {
@@ -257,6 +260,26 @@ func (c *Collection) GetDurationPropertyFilteredByWorkflowType(key DurationKey)
 	}
 }
 
+// GetStringPropertyFilteredByWorkflowType gets property with workflow type filter and asserts that it's a string
+func (c *Collection) GetStringPropertyFilteredByWorkflowType(key StringKey) StringPropertyFnWithWorkflowTypeFilter {
+	return func(domainName string, workflowType string) string {
+		filters := c.toFilterMap(
+			DomainFilter(domainName),
+			WorkflowTypeFilter(workflowType),
+		)
+		val, err := c.client.GetStringValue(
+			key,
+			filters,
+		)
+		if err != nil {
+			c.logError(key, filters, err)
+			return key.DefaultString()
+		}
+		c.logValue(key, filters, val, key.DefaultValue(), stringCompareEquals)
+		return val
+	}
+}
+
 // GetIntPropertyFilteredByTaskListInfo gets property with taskListInfo as filters and asserts that it's an integer
 func (c *Collection) GetIntPropertyFilteredByTaskListInfo(key IntKey) IntPropertyFnWithTaskListInfoFilters {
 	return func(domain string, taskList string, taskType int) int {
@@ -99,6 +99,11 @@ func GetStringPropertyFn(value string) func(opts ...FilterOption) string {
 	return func(...FilterOption) string { return value }
 }
 
+// GetStringPropertyFilteredByWorkflowType returns value as StringPropertyFnWithWorkflowTypeFilter
+func GetStringPropertyFilteredByWorkflowType(value string) func(domainName string, workflowType string) string {
+	return func(domainName string, workflowType string) string { return value }
+}
+
 // GetMapPropertyFn returns value as MapPropertyFn
 func GetMapPropertyFn(value map[string]interface{}) func(opts ...FilterOption) map[string]interface{} {
 	return func(...FilterOption) map[string]interface{} { return value }
@@ -81,6 +81,16 @@ func (s *configSuite) TestGetStringPropertyFnWithDomainFilter() {
 	s.Equal("efg", value(domain))
 }
 
+func (s *configSuite) TestGetStringPropertyFilteredByWorkflowType() {
+	key := ESAnalyzerStuckWorkflowMitigation
+	domain := "testDomain"
+	workflowType := "testWorkflowType"
+	value := s.cln.GetStringPropertyFilteredByWorkflowType(key)
+	s.Equal(key.DefaultString(), value(domain, workflowType))
+	s.client.SetValue(key, "terminate")
+	s.Equal("terminate", value(domain, workflowType))
+}
+
 func (s *configSuite) TestGetIntPropertyFilteredByTaskListInfo() {
 	key := TestGetIntPropertyFilteredByTaskListInfoKey
 	domain := "testDomain"
@@ -323,6 +323,9 @@ func (csc *configStoreClient) updateValue(name dc.Key, dcValues []*types.Dynamic
 		if err := validateKeyDataBlobPair(name, dcValue.Value); err != nil {
 			return err
 		}
+		if err := validateConstrainedValue(name, dcValue); err != nil {
+			return err
+		}
 	}
 	loaded := csc.values.Load()
 	var currentCached cacheEntry
@@ -607,6 +610,24 @@ func convertFromDataBlob(blob *types.DataBlob) (interface{}, error) {
 	}
 }
 
+// validateConstrainedValue checks the value against the metadata of the key, e.g. range and allowed filters
+func validateConstrainedValue(key dc.Key, dcValue *types.DynamicConfigValue) error {
+	value, err := convertFromDataBlob(dcValue.Value)
+	if err != nil {
+		return err
+	}
+	constraints := make(map[string]interface{}, len(dcValue.Filters))
+	for _, filter := range dcValue.Filters {
+		if filter == nil || filter.Value == nil {
+			return errors.New("filter value is not set")
+		}
+		if constraints[filter.Name], err = convertFromDataBlob(filter.Value); err != nil {
+			return err
+		}
+	}
+	return dc.ValidateConstrainedValue(key, value, constraints)
+}
+
 func validateKeyDataBlobPair(key dc.Key, blob *types.DataBlob) error {
 	value, err := convertFromDataBlob(blob)
 	if err != nil {
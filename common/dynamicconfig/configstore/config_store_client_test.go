@@ -732,6 +732,41 @@ func (s *configStoreClientSuite) TestUpdateValue_NoRetrySuccess() {
 	s.Equal(true, v)
 }
 
+func (s *configStoreClientSuite) TestUpdateValue_InvalidValue() {
+	defaultTestSetup(s)
+
+	s.mockManager.EXPECT().UpdateDynamicConfig(gomock.Any(), gomock.Any()).Times(0)
+
+	outOfRange := []*types.DynamicConfigValue{
+		{
+			Value: &types.DataBlob{
+				EncodingType: types.EncodingTypeJSON.Ptr(),
+				Data:         jsonMarshalHelper(-1),
+			},
+		},
+	}
+	s.Error(s.client.UpdateValue(dc.FrontendUserRPS, outOfRange))
+
+	unsupportedFilter := []*types.DynamicConfigValue{
+		{
+			Value: &types.DataBlob{
+				EncodingType: types.EncodingTypeJSON.Ptr(),
+				Data:         jsonMarshalHelper(100),
+			},
+			Filters: []*types.DynamicConfigFilter{
+				{
+					Name: "domainName",
+					Value: &types.DataBlob{
+						EncodingType: types.EncodingTypeJSON.Ptr(),
+						Data:         jsonMarshalHelper("samples-domain"),
+					},
+				},
+			},
+		},
+	}
+	s.Error(s.client.UpdateValue(dc.FrontendUserRPS, unsupportedFilter))
+}
+
 func (s *configStoreClientSuite) TestUpdateValue_SuccessNewKey() {
 	values := []*types.DynamicConfigValue{
 		{
@@ -33,40 +33,72 @@ type (
 	// DynamicInt defines the properties for a dynamic config with int value type
 	DynamicInt struct {
 		KeyName      string
+		Filters      []Filter
 		Description  string
 		DefaultValue int
+		// Range is the inclusive range of valid values, nil means any value is valid
+		Range *IntRange
 	}
 
 	DynamicBool struct {
 		KeyName      string
+		Filters      []Filter
 		Description  string
 		DefaultValue bool
 	}
 
 	DynamicFloat struct {
 		KeyName      string
+		Filters      []Filter
 		Description  string
 		DefaultValue float64
+		// Range is the inclusive range of valid values, nil means any value is valid
+		Range *FloatRange
 	}
 
 	DynamicString struct {
 		KeyName      string
+		Filters      []Filter
 		Description  string
 		DefaultValue string
+		// AllowedValues lists the valid values, empty means any value is valid
+		AllowedValues []string
 	}
 
 	DynamicDuration struct {
 		KeyName      string
+		Filters      []Filter
 		Description  string
 		DefaultValue time.Duration
+		// Range is the inclusive range of valid values, nil means any value is valid
+		Range *DurationRange
 	}
 
 	DynamicMap struct {
 		KeyName      string
+		Filters      []Filter
 		Description  string
 		DefaultValue map[string]interface{}
 	}
 
+	// IntRange is an inclusive range of int values
+	IntRange struct {
+		Min int
+		Max int
+	}
+
+	// FloatRange is an inclusive range of float values
+	FloatRange struct {
+		Min float64
+		Max float64
+	}
+
+	// DurationRange is an inclusive range of durations
+	DurationRange struct {
+		Min time.Duration
+		Max time.Duration
+	}
+
 	IntKey      int
 	BoolKey     int
 	FloatKey    int
@@ -78,6 +110,8 @@ type (
 		String() string
 		Description() string
 		DefaultValue() interface{}
+		// Filters returns the filters the key can be constrained by, in addition to the cluster name
+		Filters() []Filter
 	}
 )
 
@@ -143,7 +177,7 @@ func ValidateKeyValuePair(key Key, value interface{}) error {
 	default:
 		return fmt.Errorf("unknown key type: %T", key)
 	}
-	return nil
+	return ValidateValue(key, value)
 }
 
 func (k IntKey) String() string {
@@ -154,6 +188,10 @@ func (k IntKey) Description() string {
 	return IntKeys[k].Description
 }
 
+func (k IntKey) Filters() []Filter {
+	return IntKeys[k].Filters
+}
+
 func (k IntKey) DefaultValue() interface{} {
 	return IntKeys[k].DefaultValue
 }
@@ -170,6 +208,10 @@ func (k BoolKey) Description() string {
 	return BoolKeys[k].Description
 }
 
+func (k BoolKey) Filters() []Filter {
+	return BoolKeys[k].Filters
+}
+
 func (k BoolKey) DefaultValue() interface{} {
 	return BoolKeys[k].DefaultValue
 }
@@ -186,6 +228,10 @@ func (k FloatKey) Description() string {
 	return FloatKeys[k].Description
 }
 
+func (k FloatKey) Filters() []Filter {
+	return FloatKeys[k].Filters
+}
+
 func (k FloatKey) DefaultValue() interface{} {
 	return FloatKeys[k].DefaultValue
 }
@@ -202,6 +248,10 @@ func (k StringKey) Description() string {
 	return StringKeys[k].Description
 }
 
+func (k StringKey) Filters() []Filter {
+	return StringKeys[k].Filters
+}
+
 func (k StringKey) DefaultValue() interface{} {
 	return StringKeys[k].DefaultValue
 }
@@ -218,6 +268,10 @@ func (k DurationKey) Description() string {
 	return DurationKeys[k].Description
 }
 
+func (k DurationKey) Filters() []Filter {
+	return DurationKeys[k].Filters
+}
+
 func (k DurationKey) DefaultValue() interface{} {
 	return DurationKeys[k].DefaultValue
 }
@@ -234,6 +288,10 @@ func (k MapKey) Description() string {
 	return MapKeys[k].Description
 }
 
+func (k MapKey) Filters() []Filter {
+	return MapKeys[k].Filters
+}
+
 func (k MapKey) DefaultValue() interface{} {
 	return MapKeys[k].DefaultValue
 }
@@ -246,7 +304,8 @@ func (k MapKey) DefaultMap() map[string]interface{} {
 //
 // Since our ratelimiters do int/float conversions, and zero or negative values
 // result in not allowing any requests, math.MaxInt is unsafe:
-//   int(float64(math.MaxInt)) // -9223372036854775808
+//
+//	int(float64(math.MaxInt)) // -9223372036854775808
 //
 // Much higher values are possible, but we can't handle 2 billion RPS, this is good enough.
 const UnlimitedRPS = math.MaxInt32
@@ -527,6 +586,12 @@ const (
 	// Default value: 40960 (40*1024)
 	// Allowed filters: DomainName
 	SearchAttributesTotalSizeLimit
+	// FrontendMaxExecutionStartToCloseTimeoutSeconds is the max execution start to close timeout a workflow can be started with
+	// KeyName: frontend.maxExecutionStartToCloseTimeoutSeconds
+	// Value type: Int
+	// Default value: 0 (no limit)
+	// Allowed filters: DomainName
+	FrontendMaxExecutionStartToCloseTimeoutSeconds
 	// VisibilityArchivalQueryMaxPageSize is the maximum page size for a visibility archival query
 	// KeyName: frontend.visibilityArchivalQueryMaxPageSize
 	// Value type: Int
@@ -552,13 +617,13 @@ const (
 	// KeyName: matching.domainrps
 	// Value type: Int
 	// Default value: 0
-	// Allowed filters: N/A
+	// Allowed filters: DomainName
 	MatchingDomainUserRPS
 	// MatchingDomainWorkerRPS is background-processing request rate per domain per second for each matching host
 	// KeyName: matching.domainworkerrps
 	// Value type: Int
 	// Default value: UnlimitedRPS
-	// Allowed filters: N/A
+	// Allowed filters: DomainName
 	MatchingDomainWorkerRPS
 	// MatchingPersistenceMaxQPS is the max qps matching host can query DB
 	// KeyName: matching.persistenceMaxQPS
@@ -937,7 +1002,7 @@ const (
 	// KeyName: history.replicatorTaskBatchSize
 	// Value type: Int
 	// Default value: 25
-	// Allowed filters: N/A
+	// Allowed filters: ShardID
 	ReplicatorTaskBatchSize
 	// ReplicatorTaskDeleteBatchSize is batch size for ReplicatorProcessor to delete replication tasks
 	// KeyName: history.replicatorTaskDeleteBatchSize
@@ -1085,6 +1150,24 @@ const (
 	// Default value: 0
 	// Allowed filters: DomainName
 	MaxActivityCountDispatchByDomain
+	// HistoryESProcessorNumOfWorkers is num of workers of the bulk processor writing visibility records to ElasticSearch when AdvancedVisibilityWritingPipeline is direct
+	// KeyName: history.ESProcessorNumOfWorkers
+	// Value type: Int
+	// Default value: 1
+	// Allowed filters: N/A
+	HistoryESProcessorNumOfWorkers
+	// HistoryESProcessorBulkActions is max number of requests in bulk of the bulk processor writing visibility records to ElasticSearch
+	// KeyName: history.ESProcessorBulkActions
+	// Value type: Int
+	// Default value: 1000
+	// Allowed filters: N/A
+	HistoryESProcessorBulkActions
+	// HistoryESProcessorBulkSize is max total size of bulk in bytes of the bulk processor writing visibility records to ElasticSearch
+	// KeyName: history.ESProcessorBulkSize
+	// Value type: Int
+	// Default value: 2<<24 // 16MB
+	// Allowed filters: N/A
+	HistoryESProcessorBulkSize
 
 	// key for history replication
 
@@ -1265,6 +1348,54 @@ const (
 	// Default value: 3
 	// Allowed filters: N/A
 	TimersScannerPeriodEnd
+	// TaskQueuesScannerConcurrency is the concurrency of transfer and replication task scanners
+	// KeyName: worker.taskQueuesScannerConcurrency
+	// Value type: Int
+	// Default value: 5
+	// Allowed filters: N/A
+	TaskQueuesScannerConcurrency
+	// TaskQueuesScannerPersistencePageSize is the page size of task persistence fetches in transfer and replication task scanners
+	// KeyName: worker.taskQueuesScannerPersistencePageSize
+	// Value type: Int
+	// Default value: 1000
+	// Allowed filters: N/A
+	TaskQueuesScannerPersistencePageSize
+	// TaskQueuesScannerBlobstoreFlushThreshold is threshold to flush blob store in transfer and replication task scanners
+	// KeyName: worker.taskQueuesScannerBlobstoreFlushThreshold
+	// Value type: Int
+	// Default value: 100
+	// Allowed filters: N/A
+	TaskQueuesScannerBlobstoreFlushThreshold
+	// TaskQueuesScannerActivityBatchSize is the number of shards scanned by one activity of transfer and replication task scanners
+	// KeyName: worker.taskQueuesScannerActivityBatchSize
+	// Value type: Int
+	// Default value: 25
+	// Allowed filters: N/A
+	TaskQueuesScannerActivityBatchSize
+	// VisibilityScannerConcurrency is the concurrency of visibility scanner
+	// KeyName: worker.visibilityScannerConcurrency
+	// Value type: Int
+	// Default value: 5
+	// Allowed filters: N/A
+	VisibilityScannerConcurrency
+	// VisibilityScannerPersistencePageSize is the page size of execution persistence fetches in visibility scanner
+	// KeyName: worker.visibilityScannerPersistencePageSize
+	// Value type: Int
+	// Default value: 1000
+	// Allowed filters: N/A
+	VisibilityScannerPersistencePageSize
+	// VisibilityScannerBlobstoreFlushThreshold is the flush threshold of blobstore in visibility scanner
+	// KeyName: worker.visibilityScannerBlobstoreFlushThreshold
+	// Value type: Int
+	// Default value: 100
+	// Allowed filters: N/A
+	VisibilityScannerBlobstoreFlushThreshold
+	// VisibilityScannerActivityBatchSize is the batch size of visibility scanner activities
+	// KeyName: worker.visibilityScannerActivityBatchSize
+	// Value type: Int
+	// Default value: 25
+	// Allowed filters: N/A
+	VisibilityScannerActivityBatchSize
 	// ESAnalyzerMaxNumDomains defines how many domains to check
 	// KeyName: worker.ESAnalyzerMaxNumDomains
 	// Value type: int
@@ -1285,6 +1416,11 @@ const (
 	// Value type: Int
 	// Default value: 100
 	ESAnalyzerMinNumWorkflowsForAvg
+	// ESIndexRolloverMaxDocs is the number of documents after which the managed visibility write index is rolled over
+	// KeyName: worker.ESIndexRolloverMaxDocs
+	// Value type: Int
+	// Default value: 0 => means no limitation
+	ESIndexRolloverMaxDocs
 	// Usage: VisibilityArchivalQueryMaxRangeInDays is the maximum number of days for a visibility archival query
 	// KeyName: N/A
 	// Default value: N/A
@@ -1574,6 +1710,18 @@ const (
 	// Default value: false
 	// Allowed filters: N/A
 	HistoryScannerEnabled
+	// TaskListScannerReportOnly indicates if task list scanner should only report abandoned task lists instead of deleting them
+	// KeyName: worker.taskListScannerReportOnly
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: N/A
+	TaskListScannerReportOnly
+	// HistoryScannerReportOnly indicates if history scanner should only report history branches without an owning execution instead of deleting them
+	// KeyName: worker.historyScannerReportOnly
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: N/A
+	HistoryScannerReportOnly
 	// ConcreteExecutionsScannerEnabled is indicates if executions scanner should be started as part of worker.Scanner
 	// KeyName: worker.executionsScannerEnabled
 	// Value type: Bool
@@ -1592,6 +1740,12 @@ const (
 	// Default value: true
 	// Allowed filters: N/A
 	ConcreteExecutionsScannerInvariantCollectionHistory
+	// ConcreteExecutionsScannerInvariantCollectionWorkflowState is indicates if workflow state invariant checks should be run
+	// KeyName: worker.executionsScannerInvariantCollectionWorkflowState
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: N/A
+	ConcreteExecutionsScannerInvariantCollectionWorkflowState
 	// CurrentExecutionsScannerEnabled is indicates if current executions scanner should be started as part of worker.Scanner
 	// KeyName: worker.currentExecutionsScannerEnabled
 	// Value type: Bool
@@ -1652,12 +1806,25 @@ const (
 	// Default value: true
 	// Allowed filters: N/A
 	EnableWorkflowShadower
+	// EnableDomainDeletion indicates if the worker which deletes deprecated domains is enabled
+	// KeyName: system.enableDomainDeletion
+	// Value type: Bool
+	// Default value: true
+	// Allowed filters: N/A
+	EnableDomainDeletion
 	// ConcreteExecutionFixerDomainAllow is which domains are allowed to be fixed by concrete fixer workflow
 	// KeyName: worker.concreteExecutionFixerDomainAllow
 	// Value type: Bool
 	// Default value: false
 	// Allowed filters: DomainName
 	ConcreteExecutionFixerDomainAllow
+	// ConcreteExecutionFixerTerminateDomainAllow is which domains are allowed to have executions terminated by concrete fixer workflow,
+	// when the execution cannot be repaired by resetting it
+	// KeyName: worker.concreteExecutionFixerTerminateDomainAllow
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: DomainName
+	ConcreteExecutionFixerTerminateDomainAllow
 	// CurrentExecutionFixerDomainAllow is which domains are allowed to be fixed by current fixer workflow
 	// KeyName: worker.currentExecutionFixerDomainAllow
 	// Value type: Bool
@@ -1682,6 +1849,60 @@ const (
 	// Default value: false
 	// Allowed filters: DomainName
 	TimersFixerDomainAllow
+	// TransferTasksScannerEnabled is if transfer tasks scanner should be started as part of worker.Scanner
+	// KeyName: worker.transferTasksScannerEnabled
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: N/A
+	TransferTasksScannerEnabled
+	// TransferTasksFixerEnabled is if transfer tasks fixer should be started as part of worker.Scanner
+	// KeyName: worker.transferTasksFixerEnabled
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: N/A
+	TransferTasksFixerEnabled
+	// TransferTasksFixerDomainAllow is which domains are allowed to be fixed by transfer tasks fixer workflow
+	// KeyName: worker.transferTasksFixerDomainAllow
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: DomainName
+	TransferTasksFixerDomainAllow
+	// ReplicationTasksScannerEnabled is if replication tasks scanner should be started as part of worker.Scanner
+	// KeyName: worker.replicationTasksScannerEnabled
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: N/A
+	ReplicationTasksScannerEnabled
+	// ReplicationTasksFixerEnabled is if replication tasks fixer should be started as part of worker.Scanner
+	// KeyName: worker.replicationTasksFixerEnabled
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: N/A
+	ReplicationTasksFixerEnabled
+	// ReplicationTasksFixerDomainAllow is which domains are allowed to be fixed by replication tasks fixer workflow
+	// KeyName: worker.replicationTasksFixerDomainAllow
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: DomainName
+	ReplicationTasksFixerDomainAllow
+	// VisibilityScannerEnabled is if visibility scanner should be started as part of worker.Scanner
+	// KeyName: worker.visibilityScannerEnabled
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: N/A
+	VisibilityScannerEnabled
+	// VisibilityFixerEnabled is if visibility fixer should be started as part of worker.Scanner
+	// KeyName: worker.visibilityFixerEnabled
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: N/A
+	VisibilityFixerEnabled
+	// VisibilityFixerDomainAllow is which domains are allowed to be fixed by visibility fixer workflow
+	// KeyName: worker.visibilityFixerDomainAllow
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: DomainName
+	VisibilityFixerDomainAllow
 	// ConcreteExecutionFixerEnabled is if concrete execution fixer workflow is enabled
 	// KeyName: worker.concreteExecutionFixerEnabled
 	// Value type: Bool
@@ -1725,6 +1946,29 @@ const (
 	// Value type: Bool
 	// Default value: false
 	ESAnalyzerEnableAvgDurationBasedChecks
+	// ESAnalyzerMitigationDomainAllow is which domains are allowed to have long running workflows signaled, reset or terminated by ESAnalyzer,
+	// those mitigations are only reported in other domains
+	// KeyName: worker.ESAnalyzerMitigationDomainAllow
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: DomainName
+	ESAnalyzerMitigationDomainAllow
+	// ESAnalyzerMitigationDryRun controls if ESAnalyzer only reports the workflows it would mitigate to the reconciliation store
+	// KeyName: worker.ESAnalyzerMitigationDryRun
+	// Value type: Bool
+	// Default value: false
+	// Allowed filters: DomainName
+	ESAnalyzerMitigationDryRun
+	// ESIndexManagerPause defines if we want to dynamically pause the rollover and retention of managed visibility indices
+	// KeyName: worker.ESIndexManagerPause
+	// Value type: Bool
+	// Default value: false
+	ESIndexManagerPause
+	// ESIndexRetentionEnabled controls if managed visibility indices are deleted once all their workflows passed the domain retention
+	// KeyName: worker.ESIndexRetentionEnabled
+	// Value type: Bool
+	// Default value: false
+	ESIndexRetentionEnabled
 
 	// CorruptWorkflowWatchdogPause defines if we want to dynamically pause the watchdog workflow
 	// KeyName: worker.CorruptWorkflowWatchdogPause
@@ -1956,6 +2200,14 @@ const (
 	// Default value: "on"
 	// Allowed filters: N/A
 	AdvancedVisibilityWritingMode
+	// AdvancedVisibilityWritingPipeline is key for how visibility records reach advanced visibility store when it's written to.
+	// "kafka" publishes them to Kafka to be indexed by the worker indexer, "direct" writes them to ElasticSearch through a bulk processor in the writing service.
+	// It is read on service start up.
+	// KeyName: system.advancedVisibilityWritingPipeline
+	// Value type: String enum: "kafka" or "direct"
+	// Default value: "kafka"
+	// Allowed filters: N/A
+	AdvancedVisibilityWritingPipeline
 	// HistoryArchivalStatus is key for the status of history archival to override the value from static config.
 	// KeyName: system.historyArchivalStatus
 	// Value type: string enum: "enabled" or "disabled"
@@ -1992,9 +2244,27 @@ const (
 	ESAnalyzerLimitToDomains
 	// ESAnalyzerWorkflowDurationWarnThresholds defines the warning execution thresholds for workflow types
 	// KeyName: worker.ESAnalyzerWorkflowDurationWarnThresholds
-	// Value type: string [{"DomainName":"<domain>", "WorkflowType":"<workflowType>", "Threshold":"<duration>", "Refresh":<shouldRefresh>, "MaxNumWorkflows":<maxNumber>}]
+	// Value type: string [{"DomainName":"<domain>", "WorkflowType":"<workflowType>", "Threshold":"<duration>", "Refresh":<shouldRefresh>, "MaxNumWorkflows":<maxNumber>,
+	// "Mitigation":"<report|refresh|signal|reset|terminate>", "SignalName":"<signal name for signal mitigation>"}]
 	// Default value: ""
 	ESAnalyzerWorkflowDurationWarnThresholds
+	// ESAnalyzerStuckWorkflowMitigation is the mitigation applied by ESAnalyzer to workflows running much longer than the average of their type
+	// KeyName: worker.ESAnalyzerStuckWorkflowMitigation
+	// Value type: String, one of report, refresh, signal, reset or terminate
+	// Default value: refresh
+	// Allowed filters: DomainName, WorkflowType
+	ESAnalyzerStuckWorkflowMitigation
+	// ESAnalyzerStuckWorkflowSignalName is the name of the signal sent by the signal mitigation of stuck workflows
+	// KeyName: worker.ESAnalyzerStuckWorkflowSignalName
+	// Value type: String
+	// Default value: cadence-sys-es-analyzer-long-running
+	// Allowed filters: DomainName, WorkflowType
+	ESAnalyzerStuckWorkflowSignalName
+	// ESIndexRolloverMaxSize is the primary shards size after which the managed visibility write index is rolled over
+	// KeyName: worker.ESIndexRolloverMaxSize
+	// Value type: String
+	// Default value: "50gb" ("" => means no limitation)
+	ESIndexRolloverMaxSize
 
 	// LastStringKey must be the last one in this const group
 	LastStringKey
@@ -2332,6 +2602,13 @@ const (
 	// Default value: 30m (30*time.Minute)
 	// Allowed filters: DomainName
 	ActivityMaxScheduleToStartTimeoutForRetry
+	// HistoryESProcessorFlushInterval is flush interval of the bulk processor writing visibility records to ElasticSearch.
+	// Each visibility transfer task waits for its record to be flushed, so it takes up to this interval unless the bulk is filled earlier
+	// KeyName: history.ESProcessorFlushInterval
+	// Value type: Duration
+	// Default value: 1s (1*time.Second)
+	// Allowed filters: N/A
+	HistoryESProcessorFlushInterval
 	// ReplicationTaskFetcherAggregationInterval determines how frequently the fetch requests are sent
 	// KeyName: history.ReplicationTaskFetcherAggregationInterval
 	// Value type: Duration
@@ -2420,6 +2697,11 @@ const (
 	// Value type: Duration
 	// Default value: 30 minutes
 	ESAnalyzerBufferWaitTime
+	// ESIndexRolloverMaxAge is the age after which the managed visibility write index is rolled over
+	// KeyName: worker.ESIndexRolloverMaxAge
+	// Value type: Duration
+	// Default value: 7 days (0 => means no limitation)
+	ESIndexRolloverMaxAge
 
 	// LastDurationKey must be the last one in this const group
 	LastDurationKey
@@ -2448,6 +2730,27 @@ const (
 	// Default value: the default attributes of this release version, see definition.GetDefaultIndexedKeys()
 	// Allowed filters: N/A
 	ValidSearchAttributes
+	// SearchAttributeAliases maps an alias to the search attribute it stands for. Aliases are accepted for writes and queries
+	// and always stored under the target attribute, which allows renaming a search attribute without breaking callers
+	// KeyName: frontend.searchAttributeAliases
+	// Value type: Map
+	// Default value: nil
+	// Allowed filters: N/A
+	SearchAttributeAliases
+	// DeprecatedSearchAttributes is the search attributes which are going to be removed, mapped to a note for their users.
+	// Deprecated attributes are still accepted, but a warning is logged every time one is written
+	// KeyName: frontend.deprecatedSearchAttributes
+	// Value type: Map
+	// Default value: nil
+	// Allowed filters: N/A
+	DeprecatedSearchAttributes
+	// DomainScopedSearchAttributes maps a custom search attribute to the list of domains allowed to use it.
+	// Attributes not in the map can be used by any domain
+	// KeyName: frontend.domainScopedSearchAttributes
+	// Value type: Map
+	// Default value: nil
+	// Allowed filters: N/A
+	DomainScopedSearchAttributes
 
 	// key for history
 
@@ -2494,21 +2797,25 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "system.transactionSizeLimit",
 		Description:  "TransactionSizeLimit is the largest allowed transaction size to persistence",
 		DefaultValue: 14680064,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	MaxRetentionDays: DynamicInt{
 		KeyName:      "system.maxRetentionDays",
 		Description:  "MaxRetentionDays is the maximum allowed retention days for domain",
 		DefaultValue: 30,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	MinRetentionDays: DynamicInt{
 		KeyName:      "system.minRetentionDays",
 		Description:  "MinRetentionDays is the minimal allowed retention days for domain",
 		DefaultValue: 1,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	MaxDecisionStartToCloseSeconds: DynamicInt{
 		KeyName:      "system.maxDecisionStartToCloseSeconds",
 		Description:  "MaxDecisionStartToCloseSeconds is the maximum allowed value for decision start to close timeout in seconds",
 		DefaultValue: 240,
+		Filters:      []Filter{DomainName},
 	},
 	GRPCMaxSizeInByte: DynamicInt{
 		KeyName:      "system.grpcMaxSizeInByte",
@@ -2519,31 +2826,37 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "limit.blobSize.error",
 		Description:  "BlobSizeLimitError is the per event blob size limit",
 		DefaultValue: 2 * 1024 * 1024,
+		Filters:      []Filter{DomainName},
 	},
 	BlobSizeLimitWarn: DynamicInt{
 		KeyName:      "limit.blobSize.warn",
 		Description:  "BlobSizeLimitWarn is the per event blob size limit for warning",
 		DefaultValue: 256 * 1024,
+		Filters:      []Filter{DomainName},
 	},
 	HistorySizeLimitError: DynamicInt{
 		KeyName:      "limit.historySize.error",
 		Description:  "HistorySizeLimitError is the per workflow execution history size limit",
 		DefaultValue: 200 * 1024 * 1024,
+		Filters:      []Filter{DomainName},
 	},
 	HistorySizeLimitWarn: DynamicInt{
 		KeyName:      "limit.historySize.warn",
 		Description:  "HistorySizeLimitWarn is the per workflow execution history size limit for warning",
 		DefaultValue: 50 * 1024 * 1024,
+		Filters:      []Filter{DomainName},
 	},
 	HistoryCountLimitError: DynamicInt{
 		KeyName:      "limit.historyCount.error",
 		Description:  "HistoryCountLimitError is the per workflow execution history event count limit",
 		DefaultValue: 200 * 1024,
+		Filters:      []Filter{DomainName},
 	},
 	HistoryCountLimitWarn: DynamicInt{
 		KeyName:      "limit.historyCount.warn",
 		Description:  "HistoryCountLimitWarn is the per workflow execution history event count limit for warning",
 		DefaultValue: 50 * 1024,
+		Filters:      []Filter{DomainName},
 	},
 	PendingActivitiesCountLimitError: DynamicInt{
 		KeyName:      "limit.pendingActivityCount.error",
@@ -2559,56 +2872,67 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "limit.domainNameLength",
 		Description:  "DomainNameMaxLength is the length limit for domain name",
 		DefaultValue: 1000,
+		Filters:      []Filter{DomainName},
 	},
 	IdentityMaxLength: DynamicInt{
 		KeyName:      "limit.identityLength",
 		Description:  "IdentityMaxLength is the length limit for identity",
 		DefaultValue: 1000,
+		Filters:      []Filter{DomainName},
 	},
 	WorkflowIDMaxLength: DynamicInt{
 		KeyName:      "limit.workflowIDLength",
 		Description:  "WorkflowIDMaxLength is the length limit for workflowID",
 		DefaultValue: 1000,
+		Filters:      []Filter{DomainName},
 	},
 	SignalNameMaxLength: DynamicInt{
 		KeyName:      "limit.signalNameLength",
 		Description:  "SignalNameMaxLength is the length limit for signal name",
 		DefaultValue: 1000,
+		Filters:      []Filter{DomainName},
 	},
 	WorkflowTypeMaxLength: DynamicInt{
 		KeyName:      "limit.workflowTypeLength",
 		Description:  "WorkflowTypeMaxLength is the length limit for workflow type",
 		DefaultValue: 1000,
+		Filters:      []Filter{DomainName},
 	},
 	RequestIDMaxLength: DynamicInt{
 		KeyName:      "limit.requestIDLength",
 		Description:  "RequestIDMaxLength is the length limit for requestID",
 		DefaultValue: 1000,
+		Filters:      []Filter{DomainName},
 	},
 	TaskListNameMaxLength: DynamicInt{
 		KeyName:      "limit.taskListNameLength",
 		Description:  "TaskListNameMaxLength is the length limit for task list name",
 		DefaultValue: 1000,
+		Filters:      []Filter{DomainName},
 	},
 	ActivityIDMaxLength: DynamicInt{
 		KeyName:      "limit.activityIDLength",
 		Description:  "ActivityIDMaxLength is the length limit for activityID",
 		DefaultValue: 1000,
+		Filters:      []Filter{DomainName},
 	},
 	ActivityTypeMaxLength: DynamicInt{
 		KeyName:      "limit.activityTypeLength",
 		Description:  "ActivityTypeMaxLength is the length limit for activity type",
 		DefaultValue: 1000,
+		Filters:      []Filter{DomainName},
 	},
 	MarkerNameMaxLength: DynamicInt{
 		KeyName:      "limit.markerNameLength",
 		Description:  "MarkerNameMaxLength is the length limit for marker name",
 		DefaultValue: 1000,
+		Filters:      []Filter{DomainName},
 	},
 	TimerIDMaxLength: DynamicInt{
 		KeyName:      "limit.timerIDLength",
 		Description:  "TimerIDMaxLength is the length limit for timerID",
 		DefaultValue: 1000,
+		Filters:      []Filter{DomainName},
 	},
 	MaxIDLengthWarnLimit: DynamicInt{
 		KeyName:      "limit.maxIDWarnLength",
@@ -2619,28 +2943,35 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "frontend.persistenceMaxQPS",
 		Description:  "FrontendPersistenceMaxQPS is the max qps frontend host can query DB",
 		DefaultValue: 2000,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	FrontendPersistenceGlobalMaxQPS: DynamicInt{
 		KeyName:      "frontend.persistenceGlobalMaxQPS",
 		Description:  "FrontendPersistenceGlobalMaxQPS is the max qps frontend cluster can query DB",
 		DefaultValue: 0,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	FrontendVisibilityMaxPageSize: DynamicInt{
 		KeyName:      "frontend.visibilityMaxPageSize",
 		Description:  "FrontendVisibilityMaxPageSize is default max size for ListWorkflowExecutions in one page",
 		DefaultValue: 1000,
+		Filters:      []Filter{DomainName},
 	},
 	FrontendVisibilityListMaxQPS: DynamicInt{
 		KeyName: "frontend.visibilityListMaxQPS",
 		Description: "deprecated: never used for ratelimiting, only sampling-based failure injection, and only on database-based visibility.\n" +
 			"FrontendVisibilityListMaxQPS is max qps frontend can list open/close workflows",
 		DefaultValue: 10,
+		Filters:      []Filter{DomainName},
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	FrontendESVisibilityListMaxQPS: DynamicInt{
 		KeyName: "frontend.esVisibilityListMaxQPS",
 		Description: "deprecated: never read from, all ES reads and writes erroneously use PersistenceMaxQPS.\n" +
 			"FrontendESVisibilityListMaxQPS is max qps frontend can list open/close workflows from ElasticSearch",
 		DefaultValue: 30,
+		Filters:      []Filter{DomainName},
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	FrontendESIndexMaxResultWindow: DynamicInt{
 		KeyName:      "frontend.esIndexMaxResultWindow",
@@ -2651,56 +2982,70 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "frontend.historyMaxPageSize",
 		Description:  "FrontendHistoryMaxPageSize is default max size for GetWorkflowExecutionHistory in one page",
 		DefaultValue: 1000,
+		Filters:      []Filter{DomainName},
 	},
 	FrontendUserRPS: DynamicInt{
 		KeyName:      "frontend.rps",
 		Description:  "FrontendUserRPS is workflow rate limit per second",
 		DefaultValue: 1200,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	FrontendWorkerRPS: DynamicInt{
 		KeyName:      "frontend.workerrps",
 		Description:  "FrontendWorkerRPS is background-processing workflow rate limit per second",
 		DefaultValue: UnlimitedRPS,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	FrontendVisibilityRPS: DynamicInt{
 		KeyName:      "frontend.visibilityrps",
 		Description:  "FrontendVisibilityRPS is the global workflow List*WorkflowExecutions request rate limit per second",
 		DefaultValue: UnlimitedRPS,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	FrontendMaxDomainUserRPSPerInstance: DynamicInt{
 		KeyName:      "frontend.domainrps",
 		Description:  "FrontendMaxDomainUserRPSPerInstance is workflow domain rate limit per second",
 		DefaultValue: 1200,
+		Filters:      []Filter{DomainName},
 	},
 	FrontendMaxDomainWorkerRPSPerInstance: DynamicInt{
 		KeyName:      "frontend.domainworkerrps",
 		Description:  "FrontendMaxDomainWorkerRPSPerInstance is background-processing workflow domain rate limit per second",
 		DefaultValue: UnlimitedRPS,
+		Filters:      []Filter{DomainName},
 	},
 	FrontendMaxDomainVisibilityRPSPerInstance: DynamicInt{
 		KeyName:      "frontend.domainvisibilityrps",
 		Description:  "FrontendMaxDomainVisibilityRPSPerInstance is the per-instance List*WorkflowExecutions request rate limit per second",
 		DefaultValue: UnlimitedRPS,
+		Filters:      []Filter{DomainName},
 	},
 	FrontendGlobalDomainUserRPS: DynamicInt{
 		KeyName:      "frontend.globalDomainrps",
 		Description:  "FrontendGlobalDomainUserRPS is workflow domain rate limit per second for the whole Cadence cluster",
 		DefaultValue: 0,
+		Filters:      []Filter{DomainName},
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	FrontendGlobalDomainWorkerRPS: DynamicInt{
 		KeyName:      "frontend.globalDomainWorkerrps",
 		Description:  "FrontendGlobalDomainWorkerRPS is background-processing workflow domain rate limit per second for the whole Cadence cluster",
 		DefaultValue: UnlimitedRPS,
+		Filters:      []Filter{DomainName},
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	FrontendGlobalDomainVisibilityRPS: DynamicInt{
 		KeyName:      "frontend.globalDomainVisibilityrps",
 		Description:  "FrontendGlobalDomainVisibilityRPS is the per-domain List*WorkflowExecutions request rate limit per second",
 		DefaultValue: UnlimitedRPS,
+		Filters:      []Filter{DomainName},
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	FrontendDecisionResultCountLimit: DynamicInt{
 		KeyName:      "frontend.decisionResultCountLimit",
 		Description:  "FrontendDecisionResultCountLimit is max number of decisions per RespondDecisionTaskCompleted request",
 		DefaultValue: 0,
+		Filters:      []Filter{DomainName},
 	},
 	FrontendHistoryMgrNumConns: DynamicInt{
 		KeyName:      "frontend.historyMgrNumConns",
@@ -2711,26 +3056,38 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "frontend.throttledLogRPS",
 		Description:  "FrontendThrottledLogRPS is the rate limit on number of log messages emitted per second for throttled logger",
 		DefaultValue: 20,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	FrontendMaxBadBinaries: DynamicInt{
 		KeyName:      "frontend.maxBadBinaries",
 		Description:  "FrontendMaxBadBinaries is the max number of bad binaries in domain config",
 		DefaultValue: 10,
+		Filters:      []Filter{DomainName},
 	},
 	SearchAttributesNumberOfKeysLimit: DynamicInt{
 		KeyName:      "frontend.searchAttributesNumberOfKeysLimit",
 		Description:  "SearchAttributesNumberOfKeysLimit is the limit of number of keys",
 		DefaultValue: 100,
+		Filters:      []Filter{DomainName},
 	},
 	SearchAttributesSizeOfValueLimit: DynamicInt{
 		KeyName:      "frontend.searchAttributesSizeOfValueLimit",
 		Description:  "SearchAttributesSizeOfValueLimit is the size limit of each value",
 		DefaultValue: 2048,
+		Filters:      []Filter{DomainName},
 	},
 	SearchAttributesTotalSizeLimit: DynamicInt{
 		KeyName:      "frontend.searchAttributesTotalSizeLimit",
 		Description:  "SearchAttributesTotalSizeLimit is the size limit of the whole map",
 		DefaultValue: 40 * 1024,
+		Filters:      []Filter{DomainName},
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
+	},
+	FrontendMaxExecutionStartToCloseTimeoutSeconds: DynamicInt{
+		KeyName:      "frontend.maxExecutionStartToCloseTimeoutSeconds",
+		Description:  "FrontendMaxExecutionStartToCloseTimeoutSeconds is the max execution start to close timeout a workflow can be started with, 0 means no limit",
+		DefaultValue: 0,
+		Filters:      []Filter{DomainName},
 	},
 	VisibilityArchivalQueryMaxPageSize: DynamicInt{
 		KeyName:      "frontend.visibilityArchivalQueryMaxPageSize",
@@ -2741,116 +3098,143 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "matching.rps",
 		Description:  "MatchingUserRPS is request rate per second for each matching host",
 		DefaultValue: 1200,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	MatchingWorkerRPS: DynamicInt{
 		KeyName:      "matching.workerrps",
 		Description:  "MatchingWorkerRPS is background-processing request rate per second for each matching host",
 		DefaultValue: UnlimitedRPS,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	MatchingDomainUserRPS: DynamicInt{
 		KeyName:      "matching.domainrps",
 		Description:  "MatchingDomainUserRPS is request rate per domain per second for each matching host",
 		DefaultValue: 0,
+		Filters:      []Filter{DomainName},
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	MatchingDomainWorkerRPS: DynamicInt{
 		KeyName:      "matching.domainworkerrps",
 		Description:  "MatchingDomainWorkerRPS is background-processing request rate per domain per second for each matching host",
 		DefaultValue: UnlimitedRPS,
+		Filters:      []Filter{DomainName},
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	MatchingPersistenceMaxQPS: DynamicInt{
 		KeyName:      "matching.persistenceMaxQPS",
 		Description:  "MatchingPersistenceMaxQPS is the max qps matching host can query DB",
 		DefaultValue: 3000,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	MatchingPersistenceGlobalMaxQPS: DynamicInt{
 		KeyName:      "matching.persistenceGlobalMaxQPS",
 		Description:  "MatchingPersistenceGlobalMaxQPS is the max qps matching cluster can query DB",
 		DefaultValue: 0,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	MatchingMinTaskThrottlingBurstSize: DynamicInt{
 		KeyName:      "matching.minTaskThrottlingBurstSize",
 		Description:  "MatchingMinTaskThrottlingBurstSize is the minimum burst size for task list throttling",
 		DefaultValue: 1,
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
 	},
 	MatchingGetTasksBatchSize: DynamicInt{
 		KeyName:      "matching.getTasksBatchSize",
 		Description:  "MatchingGetTasksBatchSize is the maximum batch size to fetch from the task buffer",
 		DefaultValue: 1000,
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
 	},
 	MatchingOutstandingTaskAppendsThreshold: DynamicInt{
 		KeyName:      "matching.outstandingTaskAppendsThreshold",
 		Description:  "MatchingOutstandingTaskAppendsThreshold is the threshold for outstanding task appends",
 		DefaultValue: 250,
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
 	},
 	MatchingMaxTaskBatchSize: DynamicInt{
 		KeyName:      "matching.maxTaskBatchSize",
 		Description:  "MatchingMaxTaskBatchSize is max batch size for task writer",
 		DefaultValue: 100,
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
 	},
 	MatchingMaxTaskDeleteBatchSize: DynamicInt{
 		KeyName:      "matching.maxTaskDeleteBatchSize",
 		Description:  "MatchingMaxTaskDeleteBatchSize is the max batch size for range deletion of tasks",
 		DefaultValue: 100,
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
 	},
 	MatchingThrottledLogRPS: DynamicInt{
 		KeyName:      "matching.throttledLogRPS",
 		Description:  "MatchingThrottledLogRPS is the rate limit on number of log messages emitted per second for throttled logger",
 		DefaultValue: 20,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	MatchingNumTasklistWritePartitions: DynamicInt{
 		KeyName:      "matching.numTasklistWritePartitions",
 		Description:  "MatchingNumTasklistWritePartitions is the number of write partitions for a task list",
 		DefaultValue: 1,
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
 	},
 	MatchingNumTasklistReadPartitions: DynamicInt{
 		KeyName:      "matching.numTasklistReadPartitions",
 		Description:  "MatchingNumTasklistReadPartitions is the number of read partitions for a task list",
 		DefaultValue: 1,
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
 	},
 	MatchingForwarderMaxOutstandingPolls: DynamicInt{
 		KeyName:      "matching.forwarderMaxOutstandingPolls",
 		Description:  "MatchingForwarderMaxOutstandingPolls is the max number of inflight polls from the forwarder",
 		DefaultValue: 1,
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
 	},
 	MatchingForwarderMaxOutstandingTasks: DynamicInt{
 		KeyName:      "matching.forwarderMaxOutstandingTasks",
 		Description:  "MatchingForwarderMaxOutstandingTasks is the max number of inflight addTask/queryTask from the forwarder",
 		DefaultValue: 1,
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
 	},
 	MatchingForwarderMaxRatePerSecond: DynamicInt{
 		KeyName:      "matching.forwarderMaxRatePerSecond",
 		Description:  "MatchingForwarderMaxRatePerSecond is the max rate at which add/query can be forwarded",
 		DefaultValue: 10,
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
 	},
 	MatchingForwarderMaxChildrenPerNode: DynamicInt{
 		KeyName:      "matching.forwarderMaxChildrenPerNode",
 		Description:  "MatchingForwarderMaxChildrenPerNode is the max number of children per node in the task list partition tree",
 		DefaultValue: 20,
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
 	},
 	HistoryRPS: DynamicInt{
 		KeyName:      "history.rps",
 		Description:  "HistoryRPS is request rate per second for each history host",
 		DefaultValue: 3000,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	HistoryPersistenceMaxQPS: DynamicInt{
 		KeyName:      "history.persistenceMaxQPS",
 		Description:  "HistoryPersistenceMaxQPS is the max qps history host can query DB",
 		DefaultValue: 9000,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	HistoryPersistenceGlobalMaxQPS: DynamicInt{
 		KeyName:      "history.persistenceGlobalMaxQPS",
 		Description:  "HistoryPersistenceGlobalMaxQPS is the max qps history cluster can query DB",
 		DefaultValue: 0,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	HistoryVisibilityOpenMaxQPS: DynamicInt{
 		KeyName:      "history.historyVisibilityOpenMaxQPS",
 		Description:  "HistoryVisibilityOpenMaxQPS is max qps one history host can write visibility open_executions",
 		DefaultValue: 300,
+		Filters:      []Filter{DomainName},
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	HistoryVisibilityClosedMaxQPS: DynamicInt{
 		KeyName:      "history.historyVisibilityClosedMaxQPS",
 		Description:  "HistoryVisibilityClosedMaxQPS is max qps one history host can write visibility closed_executions",
 		DefaultValue: 300,
+		Filters:      []Filter{DomainName},
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	HistoryCacheInitialSize: DynamicInt{
 		KeyName:      "history.cacheInitialSize",
@@ -2896,6 +3280,8 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "history.taskProcessRPS",
 		Description:  "TaskProcessRPS is the task processing rate per second for each domain",
 		DefaultValue: 1000,
+		Filters:      []Filter{DomainName},
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	TaskSchedulerType: DynamicInt{
 		KeyName:      "history.taskSchedulerType",
@@ -2961,11 +3347,13 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "history.timerProcessorFailoverMaxPollRPS",
 		Description:  "TimerProcessorFailoverMaxPollRPS is max poll rate per second for timer processor",
 		DefaultValue: 1,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	TimerProcessorMaxPollRPS: DynamicInt{
 		KeyName:      "history.timerProcessorMaxPollRPS",
 		Description:  "TimerProcessorMaxPollRPS is max poll rate per second for timer processor",
 		DefaultValue: 20,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	TimerProcessorMaxRedispatchQueueSize: DynamicInt{
 		KeyName:      "history.timerProcessorMaxRedispatchQueueSize",
@@ -2976,6 +3364,7 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "history.timerProcessorHistoryArchivalSizeLimit",
 		Description:  "TimerProcessorHistoryArchivalSizeLimit is the max history size for inline archival",
 		DefaultValue: 500 * 1024,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	TransferTaskBatchSize: DynamicInt{
 		KeyName:      "history.transferTaskBatchSize",
@@ -2991,11 +3380,13 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "history.transferProcessorFailoverMaxPollRPS",
 		Description:  "TransferProcessorFailoverMaxPollRPS is max poll rate per second for transferQueueProcessor",
 		DefaultValue: 1,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	TransferProcessorMaxPollRPS: DynamicInt{
 		KeyName:      "history.transferProcessorMaxPollRPS",
 		Description:  "TransferProcessorMaxPollRPS is max poll rate per second for transferQueueProcessor",
 		DefaultValue: 20,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	TransferProcessorCompleteTransferFailureRetryCount: DynamicInt{
 		KeyName:      "history.transferProcessorCompleteTransferFailureRetryCount",
@@ -3021,11 +3412,13 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "history.crossClusterTaskFetchBatchSize",
 		Description:  "CrossClusterTaskFetchBatchSize is batch size for dispatching cross cluster tasks to target cluster in crossClusterQueueProcessor",
 		DefaultValue: 100,
+		Filters:      []Filter{ShardID},
 	},
 	CrossClusterSourceProcessorMaxPollRPS: DynamicInt{
 		KeyName:      "history.crossClusterSourceProcessorMaxPollRPS",
 		Description:  "CrossClusterSourceProcessorMaxPollRPS is max poll rate per second for crossClusterQueueProcessor",
 		DefaultValue: 20,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	CrossClusterSourceProcessorCompleteTaskFailureRetryCount: DynamicInt{
 		KeyName:      "history.crossClusterSourceProcessorCompleteTaskFailureRetryCount",
@@ -3061,6 +3454,7 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "history.replicatorTaskBatchSize",
 		Description:  "ReplicatorTaskBatchSize is batch size for ReplicatorProcessor",
 		DefaultValue: 25,
+		Filters:      []Filter{ShardID},
 	},
 	ReplicatorTaskDeleteBatchSize: DynamicInt{
 		KeyName:      "history.replicatorTaskDeleteBatchSize",
@@ -3096,6 +3490,7 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "history.maximumSignalsPerExecution",
 		Description:  "MaximumSignalsPerExecution is max number of signals supported by single execution",
 		DefaultValue: 10000, // 10K signals should big enough given workflow execution has 200K history lengh limit. It needs to be non-zero to protect continueAsNew from infinit loop
+		Filters:      []Filter{DomainName},
 	},
 	NumArchiveSystemWorkflows: DynamicInt{
 		KeyName:      "history.numArchiveSystemWorkflows",
@@ -3106,36 +3501,43 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "history.archiveRequestRPS",
 		Description:  "ArchiveRequestRPS is the rate limit on the number of archive request per second",
 		DefaultValue: 300, // should be much smaller than frontend RPS
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	ArchiveInlineHistoryRPS: DynamicInt{
 		KeyName:      "history.archiveInlineHistoryRPS",
 		Description:  "ArchiveInlineHistoryRPS is the (per instance) rate limit on the number of inline history archival attempts per second",
 		DefaultValue: 1000,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	ArchiveInlineHistoryGlobalRPS: DynamicInt{
 		KeyName:      "history.archiveInlineHistoryGlobalRPS",
 		Description:  "ArchiveInlineHistoryGlobalRPS is the global rate limit on the number of inline history archival attempts per second",
 		DefaultValue: 10000,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	ArchiveInlineVisibilityRPS: DynamicInt{
 		KeyName:      "history.archiveInlineVisibilityRPS",
 		Description:  "ArchiveInlineVisibilityRPS is the (per instance) rate limit on the number of inline visibility archival attempts per second",
 		DefaultValue: 1000,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	ArchiveInlineVisibilityGlobalRPS: DynamicInt{
 		KeyName:      "history.archiveInlineVisibilityGlobalRPS",
 		Description:  "ArchiveInlineVisibilityGlobalRPS is the global rate limit on the number of inline visibility archival attempts per second",
 		DefaultValue: 10000,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	HistoryMaxAutoResetPoints: DynamicInt{
 		KeyName:      "history.historyMaxAutoResetPoints",
 		Description:  "HistoryMaxAutoResetPoints is the key for max number of auto reset points stored in mutableState",
 		DefaultValue: 20,
+		Filters:      []Filter{DomainName},
 	},
 	ParentClosePolicyThreshold: DynamicInt{
 		KeyName:      "history.parentClosePolicyThreshold",
 		Description:  "ParentClosePolicyThreshold is decides that parent close policy will be processed by sys workers(if enabled) ifthe number of children greater than or equal to this threshold",
 		DefaultValue: 10,
+		Filters:      []Filter{DomainName},
 	},
 	NumParentClosePolicySystemWorkflows: DynamicInt{
 		KeyName:      "history.numParentClosePolicySystemWorkflows",
@@ -3146,6 +3548,7 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "history.throttledLogRPS",
 		Description:  "HistoryThrottledLogRPS is the rate limit on number of log messages emitted per second for throttled logger",
 		DefaultValue: 4,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	DecisionRetryCriticalAttempts: DynamicInt{
 		KeyName:      "history.decisionRetryCriticalAttempts",
@@ -3156,11 +3559,13 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "history.decisionRetryMaxAttempts",
 		Description:  "DecisionRetryMaxAttempts is the max limit for decision retry attempts. 0 indicates infinite number of attempts.",
 		DefaultValue: 1000,
+		Filters:      []Filter{DomainName},
 	},
 	NormalDecisionScheduleToStartMaxAttempts: DynamicInt{
 		KeyName:      "history.normalDecisionScheduleToStartMaxAttempts",
 		Description:  "NormalDecisionScheduleToStartMaxAttempts is the maximum decision attempt for creating a scheduleToStart timeout timer for normal (non-sticky) decision",
 		DefaultValue: 0,
+		Filters:      []Filter{DomainName},
 	},
 	MaxBufferedQueryCount: DynamicInt{
 		KeyName:      "history.MaxBufferedQueryCount",
@@ -3171,16 +3576,34 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "history.mutableStateChecksumGenProbability",
 		Description:  "MutableStateChecksumGenProbability is the probability [0-100] that checksum will be generated for mutable state",
 		DefaultValue: 0,
+		Filters:      []Filter{DomainName},
 	},
 	MutableStateChecksumVerifyProbability: DynamicInt{
 		KeyName:      "history.mutableStateChecksumVerifyProbability",
 		Description:  "MutableStateChecksumVerifyProbability is the probability [0-100] that checksum will be verified for mutable state",
 		DefaultValue: 0,
+		Filters:      []Filter{DomainName},
 	},
 	MaxActivityCountDispatchByDomain: DynamicInt{
 		KeyName:      "history.maxActivityCountDispatchByDomain",
 		Description:  "MaxActivityCountDispatchByDomain max # of activity tasks to dispatch to matching before creating transfer tasks. This is an performance optimization to skip activity scheduling efforts.",
 		DefaultValue: 0,
+		Filters:      []Filter{DomainName},
+	},
+	HistoryESProcessorNumOfWorkers: DynamicInt{
+		KeyName:      "history.ESProcessorNumOfWorkers",
+		Description:  "HistoryESProcessorNumOfWorkers is num of workers of the bulk processor writing visibility records to ElasticSearch when AdvancedVisibilityWritingPipeline is direct",
+		DefaultValue: 1,
+	},
+	HistoryESProcessorBulkActions: DynamicInt{
+		KeyName:      "history.ESProcessorBulkActions",
+		Description:  "HistoryESProcessorBulkActions is max number of requests in bulk of the bulk processor writing visibility records to ElasticSearch",
+		DefaultValue: 1000,
+	},
+	HistoryESProcessorBulkSize: DynamicInt{
+		KeyName:      "history.ESProcessorBulkSize",
+		Description:  "HistoryESProcessorBulkSize is max total size of bulk in bytes of the bulk processor writing visibility records to ElasticSearch",
+		DefaultValue: 2 << 24, // 16MB
 	},
 	ReplicationTaskFetcherParallelism: DynamicInt{
 		KeyName:      "history.ReplicationTaskFetcherParallelism",
@@ -3191,16 +3614,19 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "history.ReplicationTaskProcessorErrorRetryMaxAttempts",
 		Description:  "ReplicationTaskProcessorErrorRetryMaxAttempts is the max retry attempts for applying replication tasks",
 		DefaultValue: 10,
+		Filters:      []Filter{ShardID},
 	},
 	WorkerPersistenceMaxQPS: DynamicInt{
 		KeyName:      "worker.persistenceMaxQPS",
 		Description:  "WorkerPersistenceMaxQPS is the max qps worker host can query DB",
 		DefaultValue: 500,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	WorkerPersistenceGlobalMaxQPS: DynamicInt{
 		KeyName:      "worker.persistenceGlobalMaxQPS",
 		Description:  "WorkerPersistenceGlobalMaxQPS is the max qps worker cluster can query DB",
 		DefaultValue: 0,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	WorkerIndexerConcurrency: DynamicInt{
 		KeyName:      "worker.indexerConcurrency",
@@ -3236,11 +3662,13 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "worker.throttledLogRPS",
 		Description:  "WorkerThrottledLogRPS is the rate limit on number of log messages emitted per second for throttled logger",
 		DefaultValue: 20,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	ScannerPersistenceMaxQPS: DynamicInt{
 		KeyName:      "worker.scannerPersistenceMaxQPS",
 		Description:  "ScannerPersistenceMaxQPS is the maximum rate of persistence calls from worker.Scanner",
 		DefaultValue: 5,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	ScannerGetOrphanTasksPageSize: DynamicInt{
 		KeyName:      "worker.scannerGetOrphanTasksPageSize",
@@ -3327,6 +3755,46 @@ var IntKeys = map[IntKey]DynamicInt{
 		Description:  "TimersScannerPeriodEnd is interval end for fetching scheduled timers",
 		DefaultValue: 3,
 	},
+	TaskQueuesScannerConcurrency: DynamicInt{
+		KeyName:      "worker.taskQueuesScannerConcurrency",
+		Description:  "TaskQueuesScannerConcurrency is the concurrency of transfer and replication task scanners",
+		DefaultValue: 5,
+	},
+	TaskQueuesScannerPersistencePageSize: DynamicInt{
+		KeyName:      "worker.taskQueuesScannerPersistencePageSize",
+		Description:  "TaskQueuesScannerPersistencePageSize is the page size of task persistence fetches in transfer and replication task scanners",
+		DefaultValue: 1000,
+	},
+	TaskQueuesScannerBlobstoreFlushThreshold: DynamicInt{
+		KeyName:      "worker.taskQueuesScannerBlobstoreFlushThreshold",
+		Description:  "TaskQueuesScannerBlobstoreFlushThreshold is threshold to flush blob store in transfer and replication task scanners",
+		DefaultValue: 100,
+	},
+	TaskQueuesScannerActivityBatchSize: DynamicInt{
+		KeyName:      "worker.taskQueuesScannerActivityBatchSize",
+		Description:  "TaskQueuesScannerActivityBatchSize is the number of shards scanned by one activity of transfer and replication task scanners",
+		DefaultValue: 25,
+	},
+	VisibilityScannerConcurrency: DynamicInt{
+		KeyName:      "worker.visibilityScannerConcurrency",
+		Description:  "VisibilityScannerConcurrency is the concurrency of visibility scanner",
+		DefaultValue: 5,
+	},
+	VisibilityScannerPersistencePageSize: DynamicInt{
+		KeyName:      "worker.visibilityScannerPersistencePageSize",
+		Description:  "VisibilityScannerPersistencePageSize is the page size of execution persistence fetches in visibility scanner",
+		DefaultValue: 1000,
+	},
+	VisibilityScannerBlobstoreFlushThreshold: DynamicInt{
+		KeyName:      "worker.visibilityScannerBlobstoreFlushThreshold",
+		Description:  "VisibilityScannerBlobstoreFlushThreshold is the flush threshold of blobstore in visibility scanner",
+		DefaultValue: 100,
+	},
+	VisibilityScannerActivityBatchSize: DynamicInt{
+		KeyName:      "worker.visibilityScannerActivityBatchSize",
+		Description:  "VisibilityScannerActivityBatchSize is the batch size of visibility scanner activities",
+		DefaultValue: 25,
+	},
 	ESAnalyzerMaxNumDomains: DynamicInt{
 		KeyName:      "worker.ESAnalyzerMaxNumDomains",
 		Description:  "ESAnalyzerMaxNumDomains defines how many domains to check",
@@ -3341,11 +3809,18 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "worker.ESAnalyzerNumWorkflowsToRefresh",
 		Description:  "ESAnalyzerNumWorkflowsToRefresh controls how many workflows per workflow type should be refreshed per workflow type",
 		DefaultValue: 100,
+		Filters:      []Filter{DomainName, WorkflowType},
 	},
 	ESAnalyzerMinNumWorkflowsForAvg: DynamicInt{
 		KeyName:      "worker.ESAnalyzerMinNumWorkflowsForAvg",
 		Description:  "ESAnalyzerMinNumWorkflowsForAvg controls how many workflows to have at least to rely on workflow run time avg per type",
 		DefaultValue: 100,
+		Filters:      []Filter{DomainName, WorkflowType},
+	},
+	ESIndexRolloverMaxDocs: DynamicInt{
+		KeyName:      "worker.ESIndexRolloverMaxDocs",
+		Description:  "ESIndexRolloverMaxDocs is the number of documents after which the managed visibility write index is rolled over",
+		DefaultValue: 0,
 	},
 	VisibilityArchivalQueryMaxRangeInDays: DynamicInt{
 		KeyName:      "frontend.visibilityArchivalQueryMaxRangeInDays",
@@ -3356,11 +3831,13 @@ var IntKeys = map[IntKey]DynamicInt{
 		KeyName:      "frontend.visibilityArchivalQueryMaxQPS",
 		Description:  "VisibilityArchivalQueryMaxQPS is the timeout for a visibility archival query",
 		DefaultValue: 1,
+		Range:        &IntRange{Min: 0, Max: math.MaxInt32},
 	},
 	WorkflowDeletionJitterRange: DynamicInt{
 		KeyName:      "system.workflowDeletionJitterRange",
 		Description:  "WorkflowDeletionJitterRange defines the duration in minutes for workflow close tasks jittering",
 		DefaultValue: 1,
+		Filters:      []Filter{DomainName},
 	},
 }
 
@@ -3394,6 +3871,7 @@ var BoolKeys = map[BoolKey]DynamicBool{
 		KeyName:      "system.enableReadVisibilityFromES",
 		Description:  "EnableReadVisibilityFromES is key for enable read from elastic search or db visibility, usually using with AdvancedVisibilityWritingMode for seamless migration from db visibility to advanced visibility",
 		DefaultValue: true,
+		Filters:      []Filter{DomainName},
 	},
 	EmitShardDiffLog: DynamicBool{
 		KeyName:      "history.emitShardDiffLog",
@@ -3404,11 +3882,13 @@ var BoolKeys = map[BoolKey]DynamicBool{
 		KeyName:      "history.enableRecordWorkflowExecutionUninitialized",
 		Description:  "EnableRecordWorkflowExecutionUninitialized enables record workflow execution uninitialized state in ElasticSearch",
 		DefaultValue: false,
+		Filters:      []Filter{DomainName},
 	},
 	DisableListVisibilityByFilter: DynamicBool{
 		KeyName:      "frontend.disableListVisibilityByFilter",
 		Description:  "DisableListVisibilityByFilter is config to disable list open/close workflow using filter",
 		DefaultValue: false,
+		Filters:      []Filter{DomainName},
 	},
 	EnableReadFromHistoryArchival: DynamicBool{
 		KeyName:      "system.enableReadFromHistoryArchival",
@@ -3424,6 +3904,7 @@ var BoolKeys = map[BoolKey]DynamicBool{
 		KeyName:      "system.enableDomainNotActiveAutoForwarding",
 		Description:  "EnableDomainNotActiveAutoForwarding decides requests form which domain will be forwarded to active cluster if domain is not active in current cluster. Only when selected-api-forwarding or all-domain-apis-forwarding is the policy in ClusterRedirectionPolicy(in static config). If the policy is noop(default) this flag is not doing anything.",
 		DefaultValue: true,
+		Filters:      []Filter{DomainName},
 	},
 	EnableGracefulFailover: DynamicBool{
 		KeyName:      "system.enableGracefulFailover",
@@ -3434,6 +3915,7 @@ var BoolKeys = map[BoolKey]DynamicBool{
 		KeyName:      "system.disallowQuery",
 		Description:  "DisallowQuery is the key to disallow query for a domain",
 		DefaultValue: false,
+		Filters:      []Filter{DomainName},
 	},
 	EnableDebugMode: DynamicBool{
 		KeyName:      "system.enableDebugMode",
@@ -3459,21 +3941,25 @@ var BoolKeys = map[BoolKey]DynamicBool{
 		KeyName:      "frontend.sendRawWorkflowHistory",
 		Description:  "SendRawWorkflowHistory is whether to enable raw history retrieving",
 		DefaultValue: false,
+		Filters:      []Filter{DomainName},
 	},
 	FrontendEmitSignalNameMetricsTag: DynamicBool{
 		KeyName:      "frontend.emitSignalNameMetricsTag",
 		Description:  "FrontendEmitSignalNameMetricsTag enables emitting signal name tag in metrics in frontend client",
 		DefaultValue: false,
+		Filters:      []Filter{DomainName},
 	},
 	MatchingEnableSyncMatch: DynamicBool{
 		KeyName:      "matching.enableSyncMatch",
 		Description:  "MatchingEnableSyncMatch is to enable sync match",
 		DefaultValue: true,
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
 	},
 	MatchingEnableTaskInfoLogByDomainID: DynamicBool{
 		KeyName:      "matching.enableTaskInfoLogByDomainID",
 		Description:  "MatchingEnableTaskInfoLogByDomainID is enables info level logs for decision/activity task based on the request domainID",
 		DefaultValue: false,
+		Filters:      []Filter{DomainID},
 	},
 	EventsCacheGlobalEnable: DynamicBool{
 		KeyName:      "history.eventsCacheGlobalEnable",
@@ -3489,16 +3975,19 @@ var BoolKeys = map[BoolKey]DynamicBool{
 		KeyName:      "history.queueProcessorEnableRandomSplitByDomainID",
 		Description:  "QueueProcessorEnableRandomSplitByDomainID is indicates whether random queue split policy should be enabled for a domain",
 		DefaultValue: false,
+		Filters:      []Filter{DomainID},
 	},
 	QueueProcessorEnablePendingTaskSplitByDomainID: DynamicBool{
 		KeyName:      "history.queueProcessorEnablePendingTaskSplitByDomainID",
 		Description:  "ueueProcessorEnablePendingTaskSplitByDomainID is indicates whether pending task split policy should be enabled",
 		DefaultValue: false,
+		Filters:      []Filter{DomainID},
 	},
 	QueueProcessorEnableStuckTaskSplitByDomainID: DynamicBool{
 		KeyName:      "history.queueProcessorEnableStuckTaskSplitByDomainID",
 		Description:  "QueueProcessorEnableStuckTaskSplitByDomainID is indicates whether stuck task split policy should be enabled",
 		DefaultValue: false,
+		Filters:      []Filter{DomainID},
 	},
 	QueueProcessorEnablePersistQueueStates: DynamicBool{
 		KeyName:      "history.queueProcessorEnablePersistQueueStates",
@@ -3524,11 +4013,13 @@ var BoolKeys = map[BoolKey]DynamicBool{
 		KeyName:      "history.enableParentClosePolicy",
 		Description:  "EnableParentClosePolicy is whether to  ParentClosePolicy",
 		DefaultValue: true,
+		Filters:      []Filter{DomainName},
 	},
 	EnableDropStuckTaskByDomainID: DynamicBool{
 		KeyName:      "history.DropStuckTaskByDomain",
 		Description:  "EnableDropStuckTaskByDomainID is whether stuck timer/transfer task should be dropped for a domain",
 		DefaultValue: false,
+		Filters:      []Filter{DomainID},
 	},
 	EnableConsistentQuery: DynamicBool{
 		KeyName:      "history.EnableConsistentQuery",
@@ -3539,31 +4030,37 @@ var BoolKeys = map[BoolKey]DynamicBool{
 		KeyName:      "history.EnableConsistentQueryByDomain",
 		Description:  "EnableConsistentQueryByDomain indicates if consistent query is enabled for a domain",
 		DefaultValue: false,
+		Filters:      []Filter{DomainName},
 	},
 	EnableCrossClusterOperations: DynamicBool{
 		KeyName:      "history.enableCrossClusterOperations",
 		Description:  "EnableCrossClusterOperations indicates if cross cluster operations can be scheduled for a domain",
 		DefaultValue: false,
+		Filters:      []Filter{DomainName},
 	},
 	EnableHistoryCorruptionCheck: DynamicBool{
 		KeyName:      "history.enableHistoryCorruptionCheck",
 		Description:  "EnableHistoryCorruptionCheck enables additional sanity check for corrupted history. This allows early catches of DB corruptions but potiantally increased latency.",
 		DefaultValue: false,
+		Filters:      []Filter{DomainName},
 	},
 	EnableActivityLocalDispatchByDomain: DynamicBool{
 		KeyName:      "history.enableActivityLocalDispatchByDomain",
 		Description:  "EnableActivityLocalDispatchByDomain is allows worker to dispatch activity tasks through local tunnel after decisions are made. This is an performance optimization to skip activity scheduling efforts",
 		DefaultValue: true,
+		Filters:      []Filter{DomainName},
 	},
 	HistoryEnableTaskInfoLogByDomainID: DynamicBool{
 		KeyName:      "history.enableTaskInfoLogByDomainID",
 		Description:  "HistoryEnableTaskInfoLogByDomainID is enables info level logs for decision/activity task based on the request domainID",
 		DefaultValue: false,
+		Filters:      []Filter{DomainID},
 	},
 	EnableReplicationTaskGeneration: DynamicBool{
 		KeyName:      "history.enableReplicationTaskGeneration",
 		Description:  "EnableReplicationTaskGeneration is the flag to control replication generation",
 		DefaultValue: true,
+		Filters:      []Filter{DomainID, WorkflowID},
 	},
 	AllowArchivingIncompleteHistory: DynamicBool{
 		KeyName:      "worker.AllowArchivingIncompleteHistory",
@@ -3585,6 +4082,16 @@ var BoolKeys = map[BoolKey]DynamicBool{
 		Description:  "HistoryScannerEnabled is indicates if history scanner should be started as part of worker.Scanner",
 		DefaultValue: false,
 	},
+	TaskListScannerReportOnly: DynamicBool{
+		KeyName:      "worker.taskListScannerReportOnly",
+		Description:  "TaskListScannerReportOnly indicates if task list scanner should only report abandoned task lists instead of deleting them",
+		DefaultValue: false,
+	},
+	HistoryScannerReportOnly: DynamicBool{
+		KeyName:      "worker.historyScannerReportOnly",
+		Description:  "HistoryScannerReportOnly indicates if history scanner should only report history branches without an owning execution instead of deleting them",
+		DefaultValue: false,
+	},
 	ConcreteExecutionsScannerEnabled: DynamicBool{
 		KeyName:      "worker.executionsScannerEnabled",
 		Description:  "ConcreteExecutionsScannerEnabled is indicates if executions scanner should be started as part of worker.Scanner",
@@ -3600,6 +4107,11 @@ var BoolKeys = map[BoolKey]DynamicBool{
 		Description:  "ConcreteExecutionsScannerInvariantCollectionHistory is indicates if history invariant checks should be run",
 		DefaultValue: true,
 	},
+	ConcreteExecutionsScannerInvariantCollectionWorkflowState: DynamicBool{
+		KeyName:      "worker.executionsScannerInvariantCollectionWorkflowState",
+		Description:  "ConcreteExecutionsScannerInvariantCollectionWorkflowState is indicates if workflow state invariant checks should be run",
+		DefaultValue: false,
+	},
 	CurrentExecutionsScannerEnabled: DynamicBool{
 		KeyName:      "worker.currentExecutionsScannerEnabled",
 		Description:  "CurrentExecutionsScannerEnabled is indicates if current executions scanner should be started as part of worker.Scanner",
@@ -3639,6 +4151,7 @@ var BoolKeys = map[BoolKey]DynamicBool{
 		KeyName:      "system.enableStickyQuery",
 		Description:  "EnableStickyQuery is indicates if sticky query should be enabled per domain",
 		DefaultValue: true,
+		Filters:      []Filter{DomainName},
 	},
 	EnableFailoverManager: DynamicBool{
 		KeyName:      "system.enableFailoverManager",
@@ -3650,15 +4163,28 @@ var BoolKeys = map[BoolKey]DynamicBool{
 		Description:  "EnableWorkflowShadower indicates if workflow shadower is enabled",
 		DefaultValue: true,
 	},
+	EnableDomainDeletion: DynamicBool{
+		KeyName:      "system.enableDomainDeletion",
+		Description:  "EnableDomainDeletion indicates if the worker which deletes deprecated domains is enabled",
+		DefaultValue: true,
+	},
 	ConcreteExecutionFixerDomainAllow: DynamicBool{
 		KeyName:      "worker.concreteExecutionFixerDomainAllow",
 		Description:  "ConcreteExecutionFixerDomainAllow is which domains are allowed to be fixed by concrete fixer workflow",
 		DefaultValue: false,
+		Filters:      []Filter{DomainName},
+	},
+	ConcreteExecutionFixerTerminateDomainAllow: DynamicBool{
+		KeyName:      "worker.concreteExecutionFixerTerminateDomainAllow",
+		Description:  "ConcreteExecutionFixerTerminateDomainAllow is which domains are allowed to have executions terminated by concrete fixer workflow, when the execution cannot be repaired by resetting it",
+		DefaultValue: false,
+		Filters:      []Filter{DomainName},
 	},
 	CurrentExecutionFixerDomainAllow: DynamicBool{
 		KeyName:      "worker.currentExecutionFixerDomainAllow",
 		Description:  "CurrentExecutionFixerDomainAllow is which domains are allowed to be fixed by current fixer workflow",
 		DefaultValue: false,
+		Filters:      []Filter{DomainName},
 	},
 	TimersScannerEnabled: DynamicBool{
 		KeyName:      "worker.timersScannerEnabled",
@@ -3674,6 +4200,55 @@ var BoolKeys = map[BoolKey]DynamicBool{
 		KeyName:      "worker.timersFixerDomainAllow",
 		Description:  "TimersFixerDomainAllow is which domains are allowed to be fixed by timer fixer workflow",
 		DefaultValue: false,
+		Filters:      []Filter{DomainName},
+	},
+	TransferTasksScannerEnabled: DynamicBool{
+		KeyName:      "worker.transferTasksScannerEnabled",
+		Description:  "TransferTasksScannerEnabled is if transfer tasks scanner should be started as part of worker.Scanner",
+		DefaultValue: false,
+	},
+	TransferTasksFixerEnabled: DynamicBool{
+		KeyName:      "worker.transferTasksFixerEnabled",
+		Description:  "TransferTasksFixerEnabled is if transfer tasks fixer should be started as part of worker.Scanner",
+		DefaultValue: false,
+	},
+	TransferTasksFixerDomainAllow: DynamicBool{
+		KeyName:      "worker.transferTasksFixerDomainAllow",
+		Description:  "TransferTasksFixerDomainAllow is which domains are allowed to be fixed by transfer tasks fixer workflow",
+		DefaultValue: false,
+		Filters:      []Filter{DomainName},
+	},
+	ReplicationTasksScannerEnabled: DynamicBool{
+		KeyName:      "worker.replicationTasksScannerEnabled",
+		Description:  "ReplicationTasksScannerEnabled is if replication tasks scanner should be started as part of worker.Scanner",
+		DefaultValue: false,
+	},
+	ReplicationTasksFixerEnabled: DynamicBool{
+		KeyName:      "worker.replicationTasksFixerEnabled",
+		Description:  "ReplicationTasksFixerEnabled is if replication tasks fixer should be started as part of worker.Scanner",
+		DefaultValue: false,
+	},
+	ReplicationTasksFixerDomainAllow: DynamicBool{
+		KeyName:      "worker.replicationTasksFixerDomainAllow",
+		Description:  "ReplicationTasksFixerDomainAllow is which domains are allowed to be fixed by replication tasks fixer workflow",
+		DefaultValue: false,
+		Filters:      []Filter{DomainName},
+	},
+	VisibilityScannerEnabled: DynamicBool{
+		KeyName:      "worker.visibilityScannerEnabled",
+		Description:  "VisibilityScannerEnabled is if visibility scanner should be started as part of worker.Scanner",
+		DefaultValue: false,
+	},
+	VisibilityFixerEnabled: DynamicBool{
+		KeyName:      "worker.visibilityFixerEnabled",
+		Description:  "VisibilityFixerEnabled is if visibility fixer should be started as part of worker.Scanner",
+		DefaultValue: false,
+	},
+	VisibilityFixerDomainAllow: DynamicBool{
+		KeyName:      "worker.visibilityFixerDomainAllow",
+		Description:  "VisibilityFixerDomainAllow is which domains are allowed to be fixed by visibility fixer workflow",
+		DefaultValue: false,
+		Filters:      []Filter{DomainName},
 	},
 	ConcreteExecutionFixerEnabled: DynamicBool{
 		KeyName:      "worker.concreteExecutionFixerEnabled",
@@ -3715,6 +4290,28 @@ var BoolKeys = map[BoolKey]DynamicBool{
 		Description:  "ESAnalyzerEnableAvgDurationBasedChecks controls if we want to enable avg duration based task refreshes",
 		DefaultValue: false,
 	},
+	ESAnalyzerMitigationDomainAllow: DynamicBool{
+		KeyName:      "worker.ESAnalyzerMitigationDomainAllow",
+		Description:  "ESAnalyzerMitigationDomainAllow is which domains are allowed to have long running workflows signaled, reset or terminated by ESAnalyzer",
+		DefaultValue: false,
+		Filters:      []Filter{DomainName},
+	},
+	ESAnalyzerMitigationDryRun: DynamicBool{
+		KeyName:      "worker.ESAnalyzerMitigationDryRun",
+		Description:  "ESAnalyzerMitigationDryRun controls if ESAnalyzer only reports the workflows it would mitigate to the reconciliation store",
+		DefaultValue: false,
+		Filters:      []Filter{DomainName},
+	},
+	ESIndexManagerPause: DynamicBool{
+		KeyName:      "worker.ESIndexManagerPause",
+		Description:  "ESIndexManagerPause defines if we want to dynamically pause the rollover and retention of managed visibility indices",
+		DefaultValue: false,
+	},
+	ESIndexRetentionEnabled: DynamicBool{
+		KeyName:      "worker.ESIndexRetentionEnabled",
+		Description:  "ESIndexRetentionEnabled controls if managed visibility indices are deleted once all their workflows passed the domain retention",
+		DefaultValue: false,
+	},
 	CorruptWorkflowWatchdogPause: DynamicBool{
 		KeyName:      "worker.CorruptWorkflowWatchdogPause",
 		Description:  "CorruptWorkflowWatchdogPause defines if we want to dynamically pause the watchdog workflow",
@@ -3724,6 +4321,7 @@ var BoolKeys = map[BoolKey]DynamicBool{
 		KeyName:      "system.Lockdown",
 		Description:  "Lockdown defines if we want to allow failovers of domains to this cluster",
 		DefaultValue: false,
+		Filters:      []Filter{DomainName},
 	},
 	EnablePendingActivityValidation: DynamicBool{
 		KeyName:      "limit.pendingActivityCount.enabled",
@@ -3742,101 +4340,123 @@ var FloatKeys = map[FloatKey]DynamicFloat{
 		KeyName:      "system.persistenceErrorInjectionRate",
 		Description:  "PersistenceErrorInjectionRate is rate for injecting random error in persistence",
 		DefaultValue: 0,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	AdminErrorInjectionRate: DynamicFloat{
 		KeyName:      "admin.errorInjectionRate",
 		Description:  "dminErrorInjectionRate is the rate for injecting random error in admin client",
 		DefaultValue: 0,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	DomainFailoverRefreshTimerJitterCoefficient: DynamicFloat{
 		KeyName:      "frontend.domainFailoverRefreshTimerJitterCoefficient",
 		Description:  "DomainFailoverRefreshTimerJitterCoefficient is the jitter for domain failover refresh timer jitter",
 		DefaultValue: 0.1,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	FrontendErrorInjectionRate: DynamicFloat{
 		KeyName:      "frontend.errorInjectionRate",
 		Description:  "FrontendErrorInjectionRate is rate for injecting random error in frontend client",
 		DefaultValue: 0,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	MatchingErrorInjectionRate: DynamicFloat{
 		KeyName:      "matching.errorInjectionRate",
 		Description:  "MatchingErrorInjectionRate is rate for injecting random error in matching client",
 		DefaultValue: 0,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	TaskRedispatchIntervalJitterCoefficient: DynamicFloat{
 		KeyName:      "history.taskRedispatchIntervalJitterCoefficient",
 		Description:  "TaskRedispatchIntervalJitterCoefficient is the task redispatch interval jitter coefficient",
 		DefaultValue: 0.15,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	QueueProcessorRandomSplitProbability: DynamicFloat{
 		KeyName:      "history.queueProcessorRandomSplitProbability",
 		Description:  "QueueProcessorRandomSplitProbability is the probability for a domain to be split to a new processing queue",
 		DefaultValue: 0.01,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	QueueProcessorPollBackoffIntervalJitterCoefficient: DynamicFloat{
 		KeyName:      "history.queueProcessorPollBackoffIntervalJitterCoefficient",
 		Description:  "QueueProcessorPollBackoffIntervalJitterCoefficient is backoff interval jitter coefficient",
 		DefaultValue: 0.15,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	TimerProcessorUpdateAckIntervalJitterCoefficient: DynamicFloat{
 		KeyName:      "history.timerProcessorUpdateAckIntervalJitterCoefficient",
 		Description:  "TimerProcessorUpdateAckIntervalJitterCoefficient is the update interval jitter coefficient",
 		DefaultValue: 0.15,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	TimerProcessorMaxPollIntervalJitterCoefficient: DynamicFloat{
 		KeyName:      "history.timerProcessorMaxPollIntervalJitterCoefficient",
 		Description:  "TimerProcessorMaxPollIntervalJitterCoefficient is the max poll interval jitter coefficient",
 		DefaultValue: 0.15,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	TimerProcessorSplitQueueIntervalJitterCoefficient: DynamicFloat{
 		KeyName:      "history.timerProcessorSplitQueueIntervalJitterCoefficient",
 		Description:  "TimerProcessorSplitQueueIntervalJitterCoefficient is the split processing queue interval jitter coefficient",
 		DefaultValue: 0.15,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	TransferProcessorMaxPollIntervalJitterCoefficient: DynamicFloat{
 		KeyName:      "history.transferProcessorMaxPollIntervalJitterCoefficient",
 		Description:  "TransferProcessorMaxPollIntervalJitterCoefficient is the max poll interval jitter coefficient",
 		DefaultValue: 0.15,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	TransferProcessorSplitQueueIntervalJitterCoefficient: DynamicFloat{
 		KeyName:      "history.transferProcessorSplitQueueIntervalJitterCoefficient",
 		Description:  "TransferProcessorSplitQueueIntervalJitterCoefficient is the split processing queue interval jitter coefficient",
 		DefaultValue: 0.15,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	TransferProcessorUpdateAckIntervalJitterCoefficient: DynamicFloat{
 		KeyName:      "history.transferProcessorUpdateAckIntervalJitterCoefficient",
 		Description:  "TransferProcessorUpdateAckIntervalJitterCoefficient is the update interval jitter coefficient",
 		DefaultValue: 0.15,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	CrossClusterSourceProcessorMaxPollIntervalJitterCoefficient: DynamicFloat{
 		KeyName:      "history.crossClusterSourceProcessorMaxPollIntervalJitterCoefficient",
 		Description:  "CrossClusterSourceProcessorMaxPollIntervalJitterCoefficient is the max poll interval jitter coefficient",
 		DefaultValue: 0.15,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	CrossClusterSourceProcessorUpdateAckIntervalJitterCoefficient: DynamicFloat{
 		KeyName:      "history.crossClusterSourceProcessorUpdateAckIntervalJitterCoefficient",
 		Description:  "CrossClusterSourceProcessorUpdateAckIntervalJitterCoefficient is the update interval jitter coefficient",
 		DefaultValue: 0.15,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	CrossClusterTargetProcessorJitterCoefficient: DynamicFloat{
 		KeyName:      "history.crossClusterTargetProcessorJitterCoefficient",
 		Description:  "CrossClusterTargetProcessorJitterCoefficient is the jitter coefficient used in cross cluster task processor",
 		DefaultValue: 0.15,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	CrossClusterFetcherJitterCoefficient: DynamicFloat{
 		KeyName:      "history.crossClusterFetcherJitterCoefficient",
 		Description:  "CrossClusterFetcherJitterCoefficient is the jitter coefficient used in cross cluster task fetcher",
 		DefaultValue: 0.15,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	ReplicationTaskProcessorCleanupJitterCoefficient: DynamicFloat{
 		KeyName:      "history.ReplicationTaskProcessorCleanupJitterCoefficient",
 		Description:  "ReplicationTaskProcessorCleanupJitterCoefficient is the jitter for cleanup timer",
 		DefaultValue: 0.15,
+		Filters:      []Filter{ShardID},
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	ReplicationTaskProcessorStartWaitJitterCoefficient: DynamicFloat{
 		KeyName:      "history.ReplicationTaskProcessorStartWaitJitterCoefficient",
 		Description:  "ReplicationTaskProcessorStartWaitJitterCoefficient is the jitter for batch start wait timer",
 		DefaultValue: 0.9,
+		Filters:      []Filter{ShardID},
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	ReplicationTaskProcessorHostQPS: DynamicFloat{
 		KeyName:      "history.ReplicationTaskProcessorHostQPS",
@@ -3862,26 +4482,31 @@ var FloatKeys = map[FloatKey]DynamicFloat{
 		KeyName:      "history.NotifyFailoverMarkerTimerJitterCoefficient",
 		Description:  "NotifyFailoverMarkerTimerJitterCoefficient is the jitter for failover marker notifier timer",
 		DefaultValue: 0.15,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	HistoryErrorInjectionRate: DynamicFloat{
 		KeyName:      "history.errorInjectionRate",
 		Description:  "HistoryErrorInjectionRate is rate for injecting random error in history client",
 		DefaultValue: 0,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	ReplicationTaskFetcherTimerJitterCoefficient: DynamicFloat{
 		KeyName:      "history.ReplicationTaskFetcherTimerJitterCoefficient",
 		Description:  "ReplicationTaskFetcherTimerJitterCoefficient is the jitter for fetcher timer",
 		DefaultValue: 0.15,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	WorkerDeterministicConstructionCheckProbability: DynamicFloat{
 		KeyName:      "worker.DeterministicConstructionCheckProbability",
 		Description:  "WorkerDeterministicConstructionCheckProbability controls the probability of running a deterministic construction check for any given archival",
 		DefaultValue: 0.002,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 	WorkerBlobIntegrityCheckProbability: DynamicFloat{
 		KeyName:      "worker.BlobIntegrityCheckProbability",
 		Description:  "WorkerBlobIntegrityCheckProbability controls the probability of running an integrity check for any given archival",
 		DefaultValue: 0.002,
+		Range:        &FloatRange{Min: 0, Max: 1},
 	},
 }
 
@@ -3892,24 +4517,35 @@ var StringKeys = map[StringKey]DynamicString{
 		DefaultValue: "",
 	},
 	AdvancedVisibilityWritingMode: DynamicString{
-		KeyName:      "system.advancedVisibilityWritingMode",
-		Description:  "AdvancedVisibilityWritingMode is key for how to write to advanced visibility. The most useful option is dual, which can be used for seamless migration from db visibility to advanced visibility, usually using with EnableReadVisibilityFromES",
-		DefaultValue: "on",
+		KeyName:       "system.advancedVisibilityWritingMode",
+		Description:   "AdvancedVisibilityWritingMode is key for how to write to advanced visibility. The most useful option is dual, which can be used for seamless migration from db visibility to advanced visibility, usually using with EnableReadVisibilityFromES",
+		DefaultValue:  "on",
+		AllowedValues: []string{common.AdvancedVisibilityWritingModeOff, common.AdvancedVisibilityWritingModeOn, common.AdvancedVisibilityWritingModeDual},
+	},
+	AdvancedVisibilityWritingPipeline: DynamicString{
+		KeyName:       "system.advancedVisibilityWritingPipeline",
+		Description:   "AdvancedVisibilityWritingPipeline is key for how visibility records reach advanced visibility store, kafka publishes them to be indexed by worker indexer and direct writes them to ElasticSearch through a bulk processor. It is read on service start up",
+		DefaultValue:  common.AdvancedVisibilityWritingPipelineKafka,
+		AllowedValues: []string{common.AdvancedVisibilityWritingPipelineKafka, common.AdvancedVisibilityWritingPipelineDirect},
 	},
 	HistoryArchivalStatus: DynamicString{
-		KeyName:      "system.historyArchivalStatus",
-		Description:  "HistoryArchivalStatus is key for the status of history archival to override the value from static config.",
-		DefaultValue: "enabled",
+		KeyName:       "system.historyArchivalStatus",
+		Description:   "HistoryArchivalStatus is key for the status of history archival to override the value from static config.",
+		DefaultValue:  "enabled",
+		AllowedValues: []string{"", common.ArchivalDisabled, common.ArchivalPaused, common.ArchivalEnabled},
 	},
 	VisibilityArchivalStatus: DynamicString{
-		KeyName:      "system.visibilityArchivalStatus",
-		Description:  "VisibilityArchivalStatus is key for the status of visibility archival to override the value from static config.",
-		DefaultValue: "enabled",
+		KeyName:       "system.visibilityArchivalStatus",
+		Description:   "VisibilityArchivalStatus is key for the status of visibility archival to override the value from static config.",
+		DefaultValue:  "enabled",
+		AllowedValues: []string{"", common.ArchivalDisabled, common.ArchivalPaused, common.ArchivalEnabled},
 	},
 	DefaultEventEncoding: DynamicString{
-		KeyName:      "history.defaultEventEncoding",
-		Description:  "DefaultEventEncoding is the encoding type for history events",
-		DefaultValue: string(common.EncodingTypeThriftRW),
+		KeyName:       "history.defaultEventEncoding",
+		Description:   "DefaultEventEncoding is the encoding type for history events",
+		DefaultValue:  string(common.EncodingTypeThriftRW),
+		Filters:       []Filter{DomainName},
+		AllowedValues: []string{string(common.EncodingTypeThriftRW), string(common.EncodingTypeJSON)},
 	},
 	AdminOperationToken: DynamicString{
 		KeyName:      "history.adminOperationToken",
@@ -3931,6 +4567,23 @@ var StringKeys = map[StringKey]DynamicString{
 		Description:  "ESAnalyzerWorkflowDurationWarnThresholds defines the warning execution thresholds for workflow types",
 		DefaultValue: "",
 	},
+	ESAnalyzerStuckWorkflowMitigation: DynamicString{
+		KeyName:      "worker.ESAnalyzerStuckWorkflowMitigation",
+		Description:  "ESAnalyzerStuckWorkflowMitigation is the mitigation applied by ESAnalyzer to workflows running much longer than the average of their type",
+		DefaultValue: "refresh",
+		Filters:      []Filter{DomainName, WorkflowType},
+	},
+	ESAnalyzerStuckWorkflowSignalName: DynamicString{
+		KeyName:      "worker.ESAnalyzerStuckWorkflowSignalName",
+		Description:  "ESAnalyzerStuckWorkflowSignalName is the name of the signal sent by the signal mitigation of stuck workflows",
+		DefaultValue: "cadence-sys-es-analyzer-long-running",
+		Filters:      []Filter{DomainName, WorkflowType},
+	},
+	ESIndexRolloverMaxSize: DynamicString{
+		KeyName:      "worker.ESIndexRolloverMaxSize",
+		Description:  "ESIndexRolloverMaxSize is the primary shards size after which the managed visibility write index is rolled over",
+		DefaultValue: "50gb",
+	},
 }
 
 var DurationKeys = map[DurationKey]DynamicDuration{
@@ -3958,6 +4611,7 @@ var DurationKeys = map[DurationKey]DynamicDuration{
 		KeyName:      "frontend.failoverCoolDown",
 		Description:  "FrontendFailoverCoolDown is duration between two domain failvoers",
 		DefaultValue: time.Minute,
+		Filters:      []Filter{DomainName},
 	},
 	DomainFailoverRefreshInterval: DynamicDuration{
 		KeyName:      "frontend.domainFailoverRefreshInterval",
@@ -3968,21 +4622,25 @@ var DurationKeys = map[DurationKey]DynamicDuration{
 		KeyName:      "matching.longPollExpirationInterval",
 		Description:  "MatchingLongPollExpirationInterval is the long poll expiration interval in the matching service",
 		DefaultValue: time.Minute,
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
 	},
 	MatchingUpdateAckInterval: DynamicDuration{
 		KeyName:      "matching.updateAckInterval",
 		Description:  "MatchingUpdateAckInterval is the interval for update ack",
 		DefaultValue: time.Minute,
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
 	},
 	MatchingIdleTasklistCheckInterval: DynamicDuration{
 		KeyName:      "matching.idleTasklistCheckInterval",
 		Description:  "MatchingIdleTasklistCheckInterval is the IdleTasklistCheckInterval",
 		DefaultValue: time.Minute * 5,
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
 	},
 	MaxTasklistIdleTime: DynamicDuration{
 		KeyName:      "matching.maxTasklistIdleTime",
 		Description:  "MaxTasklistIdleTime is the max time tasklist being idle",
 		DefaultValue: time.Minute * 5,
+		Filters:      []Filter{DomainName, TaskListName, TaskType},
 	},
 	MatchingShutdownDrainDuration: DynamicDuration{
 		KeyName:      "matching.shutdownDrainDuration",
@@ -3993,11 +4651,13 @@ var DurationKeys = map[DurationKey]DynamicDuration{
 		KeyName:      "matching.activityTaskSyncMatchWaitTime",
 		Description:  "MatchingActivityTaskSyncMatchWaitTime is the amount of time activity task will wait to be sync matched",
 		DefaultValue: time.Millisecond * 50,
+		Filters:      []Filter{DomainName},
 	},
 	HistoryLongPollExpirationInterval: DynamicDuration{
 		KeyName:      "history.longPollExpirationInterval",
 		Description:  "HistoryLongPollExpirationInterval is the long poll expiration interval in the history service",
 		DefaultValue: time.Second * 20, // history client: client/history/client.go set the client timeout 20s
+		Filters:      []Filter{DomainName},
 	},
 	HistoryCacheTTL: DynamicDuration{
 		KeyName:      "history.cacheTTL",
@@ -4048,16 +4708,19 @@ var DurationKeys = map[DurationKey]DynamicDuration{
 		KeyName:      "history.standbyTaskReReplicationContextTimeout",
 		Description:  "StandbyTaskReReplicationContextTimeout is the context timeout for standby task re-replication",
 		DefaultValue: time.Minute * 3,
+		Filters:      []Filter{DomainID},
 	},
 	ResurrectionCheckMinDelay: DynamicDuration{
 		KeyName:      "history.resurrectionCheckMinDelay",
 		Description:  "ResurrectionCheckMinDelay is the minimal timer processing delay before scanning history to see if there's a resurrected timer/activity",
 		DefaultValue: time.Hour * 24,
+		Filters:      []Filter{DomainName},
 	},
 	QueueProcessorSplitLookAheadDurationByDomainID: DynamicDuration{
 		KeyName:      "history.queueProcessorSplitLookAheadDurationByDomainID",
 		Description:  "QueueProcessorSplitLookAheadDurationByDomainID is the look ahead duration when spliting a domain to a new processing queue",
 		DefaultValue: time.Minute * 20,
+		Filters:      []Filter{DomainID},
 	},
 	QueueProcessorPollBackoffInterval: DynamicDuration{
 		KeyName:      "history.queueProcessorPollBackoffInterval",
@@ -4188,16 +4851,19 @@ var DurationKeys = map[DurationKey]DynamicDuration{
 		KeyName:      "history.stickyTTL",
 		Description:  "StickyTTL is to expire a sticky tasklist if no update more than this duration",
 		DefaultValue: time.Hour * 24 * 365,
+		Filters:      []Filter{DomainName},
 	},
 	DecisionHeartbeatTimeout: DynamicDuration{
 		KeyName:      "history.decisionHeartbeatTimeout",
 		Description:  "DecisionHeartbeatTimeout is for decision heartbeat",
 		DefaultValue: time.Minute * 30, // about 30m
+		Filters:      []Filter{DomainName},
 	},
 	NormalDecisionScheduleToStartTimeout: DynamicDuration{
 		KeyName:      "history.normalDecisionScheduleToStartTimeout",
 		Description:  "NormalDecisionScheduleToStartTimeout is scheduleToStart timeout duration for normal (non-sticky) decision task",
 		DefaultValue: time.Minute * 5,
+		Filters:      []Filter{DomainName},
 	},
 	NotifyFailoverMarkerInterval: DynamicDuration{
 		KeyName:      "history.NotifyFailoverMarkerInterval",
@@ -4208,6 +4874,12 @@ var DurationKeys = map[DurationKey]DynamicDuration{
 		KeyName:      "history.activityMaxScheduleToStartTimeoutForRetry",
 		Description:  "ActivityMaxScheduleToStartTimeoutForRetry is maximum value allowed when overwritting the schedule to start timeout for activities with retry policy",
 		DefaultValue: time.Minute * 30,
+		Filters:      []Filter{DomainName},
+	},
+	HistoryESProcessorFlushInterval: DynamicDuration{
+		KeyName:      "history.ESProcessorFlushInterval",
+		Description:  "HistoryESProcessorFlushInterval is flush interval of the bulk processor writing visibility records to ElasticSearch. Each visibility transfer task waits for its record to be flushed, so it takes up to this interval unless the bulk is filled earlier",
+		DefaultValue: time.Second,
 	},
 	ReplicationTaskFetcherAggregationInterval: DynamicDuration{
 		KeyName:      "history.ReplicationTaskFetcherAggregationInterval",
@@ -4228,36 +4900,43 @@ var DurationKeys = map[DurationKey]DynamicDuration{
 		KeyName:      "history.ReplicationTaskProcessorErrorRetryWait",
 		Description:  "ReplicationTaskProcessorErrorRetryWait is the initial retry wait when we see errors in applying replication tasks",
 		DefaultValue: time.Millisecond * 50,
+		Filters:      []Filter{ShardID},
 	},
 	ReplicationTaskProcessorErrorSecondRetryWait: DynamicDuration{
 		KeyName:      "history.ReplicationTaskProcessorErrorSecondRetryWait",
 		Description:  "ReplicationTaskProcessorErrorSecondRetryWait is the initial retry wait for the second phase retry",
 		DefaultValue: time.Second * 5,
+		Filters:      []Filter{ShardID},
 	},
 	ReplicationTaskProcessorErrorSecondRetryMaxWait: DynamicDuration{
 		KeyName:      "history.ReplicationTaskProcessorErrorSecondRetryMaxWait",
 		Description:  "ReplicationTaskProcessorErrorSecondRetryMaxWait is the max wait time for the second phase retry",
 		DefaultValue: time.Second * 30,
+		Filters:      []Filter{ShardID},
 	},
 	ReplicationTaskProcessorErrorSecondRetryExpiration: DynamicDuration{
 		KeyName:      "history.ReplicationTaskProcessorErrorSecondRetryExpiration",
 		Description:  "ReplicationTaskProcessorErrorSecondRetryExpiration is the expiration duration for the second phase retry",
 		DefaultValue: time.Minute * 5,
+		Filters:      []Filter{ShardID},
 	},
 	ReplicationTaskProcessorNoTaskInitialWait: DynamicDuration{
 		KeyName:      "history.ReplicationTaskProcessorNoTaskInitialWait",
 		Description:  "ReplicationTaskProcessorNoTaskInitialWait is the wait time when not ask is returned",
 		DefaultValue: time.Second * 2,
+		Filters:      []Filter{ShardID},
 	},
 	ReplicationTaskProcessorCleanupInterval: DynamicDuration{
 		KeyName:      "history.ReplicationTaskProcessorCleanupInterval",
 		Description:  "ReplicationTaskProcessorCleanupInterval determines how frequently the cleanup replication queue",
 		DefaultValue: time.Minute,
+		Filters:      []Filter{ShardID},
 	},
 	ReplicationTaskProcessorStartWait: DynamicDuration{
 		KeyName:      "history.ReplicationTaskProcessorStartWait",
 		Description:  "ReplicationTaskProcessorStartWait is the wait time before each task processing batch",
 		DefaultValue: time.Second * 5,
+		Filters:      []Filter{ShardID},
 	},
 	WorkerESProcessorFlushInterval: DynamicDuration{
 		KeyName:      "worker.ESProcessorFlushInterval",
@@ -4283,6 +4962,12 @@ var DurationKeys = map[DurationKey]DynamicDuration{
 		KeyName:      "worker.ESAnalyzerBufferWaitTime",
 		Description:  "ESAnalyzerBufferWaitTime controls min time required to consider a worklow stuck",
 		DefaultValue: time.Minute * 30,
+		Filters:      []Filter{DomainName, WorkflowType},
+	},
+	ESIndexRolloverMaxAge: DynamicDuration{
+		KeyName:      "worker.ESIndexRolloverMaxAge",
+		Description:  "ESIndexRolloverMaxAge is the age after which the managed visibility write index is rolled over",
+		DefaultValue: time.Hour * 24 * 7,
 	},
 }
 
@@ -4302,6 +4987,21 @@ var MapKeys = map[MapKey]DynamicMap{
 		Description:  "ValidSearchAttributes is legal indexed keys that can be used in list APIs. When overriding, ensure to include the existing default attributes of the current release",
 		DefaultValue: definition.GetDefaultIndexedKeys(),
 	},
+	SearchAttributeAliases: DynamicMap{
+		KeyName:      "frontend.searchAttributeAliases",
+		Description:  "SearchAttributeAliases maps an alias to the search attribute it stands for. Aliases are accepted for writes and queries and always stored under the target attribute",
+		DefaultValue: nil,
+	},
+	DeprecatedSearchAttributes: DynamicMap{
+		KeyName:      "frontend.deprecatedSearchAttributes",
+		Description:  "DeprecatedSearchAttributes is the search attributes which are going to be removed, mapped to a note for their users",
+		DefaultValue: nil,
+	},
+	DomainScopedSearchAttributes: DynamicMap{
+		KeyName:      "frontend.domainScopedSearchAttributes",
+		Description:  "DomainScopedSearchAttributes maps a custom search attribute to the list of domains allowed to use it",
+		DefaultValue: nil,
+	},
 	TaskSchedulerRoundRobinWeights: DynamicMap{
 		KeyName:     "history.taskSchedulerRoundRobinWeight",
 		Description: "TaskSchedulerRoundRobinWeights is the priority weight for weighted round robin task scheduler",
@@ -4324,6 +5024,7 @@ var MapKeys = map[MapKey]DynamicMap{
 }
 
 var _keyNames map[string]Key
+var _productionKeyNames map[string]Key
 
 func init() {
 	panicIfKeyInvalid := func(name string, key Key) {
@@ -4359,4 +5060,8 @@ func init() {
 		panicIfKeyInvalid(v.KeyName, k)
 		_keyNames[v.KeyName] = k
 	}
+	_productionKeyNames = make(map[string]Key)
+	for _, k := range ListAllProductionKeys() {
+		_productionKeyNames[k.String()] = k
+	}
 }
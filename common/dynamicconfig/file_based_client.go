@@ -32,6 +32,7 @@ import (
 
 	"github.com/uber/cadence/common/log"
 	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/metrics"
 	"github.com/uber/cadence/common/types"
 )
 
@@ -60,19 +61,22 @@ type fileBasedClient struct {
 	lastUpdatedTime time.Time
 	config          *FileBasedClientConfig
 	doneCh          chan struct{}
+	metricsScope    metrics.Scope
 	logger          log.Logger
 }
 
 // NewFileBasedClient creates a file based client.
-func NewFileBasedClient(config *FileBasedClientConfig, logger log.Logger, doneCh chan struct{}) (Client, error) {
+// Entries with unknown keys are logged and ignored, any other invalid entry rejects the file.
+func NewFileBasedClient(config *FileBasedClientConfig, metricsClient metrics.Client, logger log.Logger, doneCh chan struct{}) (Client, error) {
 	if err := validateConfig(config); err != nil {
 		return nil, err
 	}
 
 	client := &fileBasedClient{
-		config: config,
-		doneCh: doneCh,
-		logger: logger,
+		config:       config,
+		doneCh:       doneCh,
+		metricsScope: metricsClient.Scope(metrics.DynamicConfigFileBasedClientScope),
+		logger:       logger,
 	}
 	if err := client.update(); err != nil {
 		return nil, err
@@ -267,6 +271,11 @@ func (fc *fileBasedClient) storeValues(newValues map[string][]*constrainedValue)
 			}
 		}
 	}
+	invalid, unknown := validateValues(newValues).SplitUnknownKeys()
+	if len(invalid) > 0 {
+		return invalid
+	}
+	logUnknownKeys(fc.logger, fc.metricsScope, unknown)
 
 	fc.values.Store(newValues)
 	fc.logger.Info("Updated dynamic config")
@@ -29,6 +29,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/metrics"
 )
 
 type fileBasedClientSuite struct {
@@ -49,7 +50,7 @@ func (s *fileBasedClientSuite) SetupSuite() {
 	s.client, err = NewFileBasedClient(&FileBasedClientConfig{
 		Filepath:     "config/testConfig.yaml",
 		PollInterval: time.Second * 5,
-	}, log.NewNoop(), s.doneCh)
+	}, metrics.NewNoopMetricsClient(), log.NewNoop(), s.doneCh)
 	s.Require().NoError(err)
 }
 
@@ -213,7 +214,7 @@ func (s *fileBasedClientSuite) TestGetDurationValue_ParseFailed() {
 }
 
 func (s *fileBasedClientSuite) TestValidateConfig_ConfigNotExist() {
-	_, err := NewFileBasedClient(nil, nil, nil)
+	_, err := NewFileBasedClient(nil, nil, nil, nil)
 	s.Error(err)
 }
 
@@ -221,7 +222,7 @@ func (s *fileBasedClientSuite) TestValidateConfig_FileNotExist() {
 	_, err := NewFileBasedClient(&FileBasedClientConfig{
 		Filepath:     "file/not/exist.yaml",
 		PollInterval: time.Second * 10,
-	}, nil, nil)
+	}, nil, nil, nil)
 	s.Error(err)
 }
 
@@ -229,7 +230,7 @@ func (s *fileBasedClientSuite) TestValidateConfig_ShortPollInterval() {
 	_, err := NewFileBasedClient(&FileBasedClientConfig{
 		Filepath:     "config/testConfig.yaml",
 		PollInterval: time.Second,
-	}, nil, nil)
+	}, nil, nil, nil)
 	s.Error(err)
 }
 
@@ -0,0 +1,537 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dynamicconfig
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/types"
+)
+
+var _ Client = (*remoteClient)(nil)
+
+const (
+	// RemoteConfigIndexHeader is the response header carrying the index (version) of the returned snapshot
+	RemoteConfigIndexHeader = "X-Cadence-Config-Index"
+	// RemoteConfigIndexParam is the query parameter carrying the index of the snapshot the client already has
+	RemoteConfigIndexParam = "index"
+	// RemoteConfigWaitParam is the query parameter carrying how long the server may block waiting for a change
+	RemoteConfigWaitParam = "wait"
+
+	remoteDefaultLongPollTimeout  = time.Second * 30
+	remoteDefaultPollInterval     = time.Second * 10
+	remoteDefaultRequestTimeout   = time.Second * 5
+	remoteDefaultRetryInterval    = time.Second * 5
+	remoteMinPollInterval         = time.Second
+	remoteStalenessReportInterval = time.Second * 10
+	remoteMaxErrorBodySize        = 1024
+)
+
+// RemoteClientConfig is the config for the remote dynamic config client.
+// The client pulls a snapshot, in the same format as the file based client's config file, from
+// an HTTP endpoint and keeps it up to date with blocking requests in the style of etcd or consul watches:
+// the client sends the index of the snapshot it has and the server either responds with a newer
+// snapshot or with 304 Not Modified once the wait time elapses. Endpoints which don't return an index
+// are polled every PollInterval instead.
+// The last good snapshot is written to CacheFilepath, so the service can start with the previous
+// configuration when the endpoint is unreachable.
+type RemoteClientConfig struct {
+	URL             string            `yaml:"url"`
+	Headers         map[string]string `yaml:"headers"`
+	LongPollTimeout time.Duration     `yaml:"longPollTimeout"`
+	PollInterval    time.Duration     `yaml:"pollInterval"`
+	RequestTimeout  time.Duration     `yaml:"requestTimeout"`
+	RetryInterval   time.Duration     `yaml:"retryInterval"`
+	CacheFilepath   string            `yaml:"cacheFilepath"`
+	// MaxStaleness is the age of the snapshot after which the client starts logging warnings, 0 disables it
+	MaxStaleness time.Duration `yaml:"maxStaleness"`
+}
+
+type remoteSnapshot struct {
+	index   uint64
+	content []byte
+}
+
+type remoteClient struct {
+	values       atomic.Value
+	lastSyncTime int64
+	// index and content are only accessed by the goroutine polling the endpoint
+	index   uint64
+	content []byte
+
+	config       *RemoteClientConfig
+	url          *url.URL
+	httpClient   *http.Client
+	ctx          context.Context
+	cancel       context.CancelFunc
+	doneCh       chan struct{}
+	metricsScope metrics.Scope
+	logger       log.Logger
+}
+
+// NewRemoteClient creates a client which pulls dynamic config from a remote key-value/HTTP endpoint.
+// It fails only if neither the endpoint nor the cached snapshot can be read.
+func NewRemoteClient(
+	config *RemoteClientConfig,
+	metricsClient metrics.Client,
+	logger log.Logger,
+	doneCh chan struct{},
+) (Client, error) {
+	client, err := newRemoteClient(config, metricsClient, logger, doneCh)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := client.sync(0); err != nil {
+		logger.Warn("Failed to fetch dynamic config from remote endpoint, loading cached snapshot", tag.Error(err))
+		if cacheErr := client.loadCache(); cacheErr != nil {
+			client.cancel()
+			return nil, fmt.Errorf("failed to fetch dynamic config: %v, failed to load cached snapshot: %v", err, cacheErr)
+		}
+	}
+	go client.pollLoop()
+	go client.reportLoop()
+	return client, nil
+}
+
+func newRemoteClient(
+	config *RemoteClientConfig,
+	metricsClient metrics.Client,
+	logger log.Logger,
+	doneCh chan struct{},
+) (*remoteClient, error) {
+	if err := validateRemoteConfig(config); err != nil {
+		return nil, err
+	}
+	endpoint, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote dynamic config url %v: %v", config.URL, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &remoteClient{
+		config:       withRemoteDefaults(config),
+		url:          endpoint,
+		httpClient:   &http.Client{},
+		ctx:          ctx,
+		cancel:       cancel,
+		doneCh:       doneCh,
+		metricsScope: metricsClient.Scope(metrics.DynamicConfigRemoteClientScope),
+		logger:       logger,
+	}, nil
+}
+
+func (rc *remoteClient) GetValue(name Key) (interface{}, error) {
+	return rc.getValueWithFilters(name, nil, name.DefaultValue())
+}
+
+func (rc *remoteClient) GetValueWithFilters(name Key, filters map[Filter]interface{}) (interface{}, error) {
+	return rc.getValueWithFilters(name, filters, name.DefaultValue())
+}
+
+func (rc *remoteClient) GetIntValue(name IntKey, filters map[Filter]interface{}) (int, error) {
+	defaultValue := name.DefaultInt()
+	val, err := rc.getValueWithFilters(name, filters, defaultValue)
+	if err != nil {
+		return defaultValue, err
+	}
+
+	if intVal, ok := val.(int); ok {
+		return intVal, nil
+	}
+	return defaultValue, fmt.Errorf("value type is not int but is: %T", val)
+}
+
+func (rc *remoteClient) GetFloatValue(name FloatKey, filters map[Filter]interface{}) (float64, error) {
+	defaultValue := name.DefaultFloat()
+	val, err := rc.getValueWithFilters(name, filters, defaultValue)
+	if err != nil {
+		return defaultValue, err
+	}
+
+	if floatVal, ok := val.(float64); ok {
+		return floatVal, nil
+	} else if intVal, ok := val.(int); ok {
+		return float64(intVal), nil
+	}
+	return defaultValue, fmt.Errorf("value type is not float64 but is: %T", val)
+}
+
+func (rc *remoteClient) GetBoolValue(name BoolKey, filters map[Filter]interface{}) (bool, error) {
+	defaultValue := name.DefaultBool()
+	val, err := rc.getValueWithFilters(name, filters, defaultValue)
+	if err != nil {
+		return defaultValue, err
+	}
+
+	if boolVal, ok := val.(bool); ok {
+		return boolVal, nil
+	}
+	return defaultValue, fmt.Errorf("value type is not bool but is: %T", val)
+}
+
+func (rc *remoteClient) GetStringValue(name StringKey, filters map[Filter]interface{}) (string, error) {
+	defaultValue := name.DefaultString()
+	val, err := rc.getValueWithFilters(name, filters, defaultValue)
+	if err != nil {
+		return defaultValue, err
+	}
+
+	if stringVal, ok := val.(string); ok {
+		return stringVal, nil
+	}
+	return defaultValue, fmt.Errorf("value type is not string but is: %T", val)
+}
+
+func (rc *remoteClient) GetMapValue(name MapKey, filters map[Filter]interface{}) (map[string]interface{}, error) {
+	defaultValue := name.DefaultMap()
+	val, err := rc.getValueWithFilters(name, filters, defaultValue)
+	if err != nil {
+		return defaultValue, err
+	}
+	if mapVal, ok := val.(map[string]interface{}); ok {
+		return mapVal, nil
+	}
+	return defaultValue, fmt.Errorf("value type is not map but is: %T", val)
+}
+
+func (rc *remoteClient) GetDurationValue(name DurationKey, filters map[Filter]interface{}) (time.Duration, error) {
+	defaultValue := name.DefaultDuration()
+	val, err := rc.getValueWithFilters(name, filters, defaultValue)
+	if err != nil {
+		return defaultValue, err
+	}
+
+	durationString, ok := val.(string)
+	if !ok {
+		return defaultValue, fmt.Errorf("value type is not string but is: %T", val)
+	}
+
+	durationVal, err := time.ParseDuration(durationString)
+	if err != nil {
+		return defaultValue, fmt.Errorf("failed to parse duration: %v", err)
+	}
+	return durationVal, nil
+}
+
+// UpdateValue is not supported as the remote key-value store is the source of truth
+func (rc *remoteClient) UpdateValue(name Key, value interface{}) error {
+	return errors.New("not supported for remote client")
+}
+
+func (rc *remoteClient) RestoreValue(name Key, filters map[Filter]interface{}) error {
+	return errors.New("not supported for remote client")
+}
+
+func (rc *remoteClient) ListValue(name Key) ([]*types.DynamicConfigEntry, error) {
+	return nil, errors.New("not supported for remote client")
+}
+
+func (rc *remoteClient) getValueWithFilters(key Key, filters map[Filter]interface{}, defaultValue interface{}) (interface{}, error) {
+	keyName := key.String()
+	values := rc.values.Load().(map[string][]*constrainedValue)
+	found := false
+	for _, constrainedValue := range values[keyName] {
+		if len(constrainedValue.Constraints) == 0 {
+			// special handling for default value (value without any constraints)
+			defaultValue = constrainedValue.Value
+			found = true
+			continue
+		}
+		if match(constrainedValue, filters) {
+			return constrainedValue.Value, nil
+		}
+	}
+	if !found {
+		return defaultValue, NotFoundError
+	}
+	return defaultValue, nil
+}
+
+func (rc *remoteClient) pollLoop() {
+	for {
+		select {
+		case <-rc.doneCh:
+			return
+		default:
+		}
+
+		// without an index from the server the endpoint can't block, so fall back to plain polling
+		wait := time.Duration(0)
+		if rc.index != 0 {
+			wait = rc.config.LongPollTimeout
+		}
+		requestedIndex := rc.index
+		notModified, err := rc.sync(wait)
+
+		var delay time.Duration
+		switch {
+		case err != nil:
+			if rc.ctx.Err() != nil {
+				return
+			}
+			rc.logger.Error("Failed to update dynamic config from remote endpoint", tag.Error(err))
+			delay = rc.config.RetryInterval
+		case wait == 0:
+			delay = rc.config.PollInterval
+		case !notModified && rc.index == requestedIndex:
+			// the server ignored the index and answered right away, avoid a busy loop
+			delay = rc.config.PollInterval
+		}
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-rc.doneCh:
+				return
+			}
+		}
+	}
+}
+
+func (rc *remoteClient) reportLoop() {
+	ticker := time.NewTicker(remoteStalenessReportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rc.reportStaleness()
+		case <-rc.doneCh:
+			rc.cancel()
+			return
+		}
+	}
+}
+
+func (rc *remoteClient) reportStaleness() {
+	staleness := rc.staleness()
+	rc.metricsScope.UpdateGauge(metrics.DynamicConfigRemoteStalenessGauge, staleness.Seconds())
+	if rc.config.MaxStaleness > 0 && staleness > rc.config.MaxStaleness {
+		rc.logger.Warn("Dynamic config snapshot is stale",
+			tag.Value(staleness.String()),
+			tag.Address(rc.config.URL),
+		)
+	}
+}
+
+// staleness returns how long ago the snapshot was last confirmed to be up to date
+func (rc *remoteClient) staleness() time.Duration {
+	return time.Since(time.Unix(0, atomic.LoadInt64(&rc.lastSyncTime)))
+}
+
+// sync fetches the snapshot from the endpoint and stores it if it changed.
+// It returns true if the server reported that the snapshot was not modified.
+func (rc *remoteClient) sync(wait time.Duration) (bool, error) {
+	snapshot, err := rc.fetch(rc.index, wait)
+	if err != nil {
+		rc.metricsScope.IncCounter(metrics.DynamicConfigRemoteFetchFailures)
+		return false, err
+	}
+	if snapshot == nil {
+		atomic.StoreInt64(&rc.lastSyncTime, time.Now().UnixNano())
+		return true, nil
+	}
+
+	if rc.content == nil || !bytes.Equal(snapshot.content, rc.content) {
+		if err := rc.storeValues(snapshot.content); err != nil {
+			rc.metricsScope.IncCounter(metrics.DynamicConfigRemoteFetchFailures)
+			return false, err
+		}
+		rc.metricsScope.IncCounter(metrics.DynamicConfigRemoteUpdates)
+		rc.logger.Info("Updated dynamic config from remote endpoint", tag.Counter(int(snapshot.index)))
+		rc.writeCache(snapshot.content)
+	}
+	rc.index = snapshot.index
+	rc.content = snapshot.content
+	atomic.StoreInt64(&rc.lastSyncTime, time.Now().UnixNano())
+	return false, nil
+}
+
+// fetch returns the snapshot served by the endpoint, or nil if it didn't change since the given index
+func (rc *remoteClient) fetch(index uint64, wait time.Duration) (*remoteSnapshot, error) {
+	rc.metricsScope.IncCounter(metrics.DynamicConfigRemoteFetchRequests)
+	sw := rc.metricsScope.StartTimer(metrics.DynamicConfigRemoteFetchLatency)
+	defer sw.Stop()
+
+	endpoint := *rc.url
+	if wait > 0 {
+		query := endpoint.Query()
+		query.Set(RemoteConfigIndexParam, strconv.FormatUint(index, 10))
+		query.Set(RemoteConfigWaitParam, wait.String())
+		endpoint.RawQuery = query.Encode()
+	}
+
+	ctx, cancel := context.WithTimeout(rc.ctx, wait+rc.config.RequestTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range rc.config.Headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := rc.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch dynamic config: %v", err)
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, nil
+	default:
+		body, _ := ioutil.ReadAll(io.LimitReader(response.Body, remoteMaxErrorBodySize))
+		return nil, fmt.Errorf("failed to fetch dynamic config, status: %v, body: %s", response.Status, body)
+	}
+
+	content, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dynamic config: %v", err)
+	}
+	snapshot := &remoteSnapshot{content: content}
+	if header := response.Header.Get(RemoteConfigIndexHeader); header != "" {
+		if snapshot.index, err = strconv.ParseUint(header, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid dynamic config index %v: %v", header, err)
+		}
+	}
+	return snapshot, nil
+}
+
+func (rc *remoteClient) storeValues(content []byte) error {
+	newValues := make(map[string][]*constrainedValue)
+	if err := yaml.Unmarshal(content, newValues); err != nil {
+		return fmt.Errorf("failed to decode dynamic config %v", err)
+	}
+	for _, s := range newValues {
+		for _, cv := range s {
+			var err error
+			cv.Value, err = convertKeyTypeToString(cv.Value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	invalid, unknown := validateValues(newValues).SplitUnknownKeys()
+	if len(invalid) > 0 {
+		return invalid
+	}
+	logUnknownKeys(rc.logger, rc.metricsScope, unknown)
+
+	rc.values.Store(newValues)
+	return nil
+}
+
+func (rc *remoteClient) loadCache() error {
+	if rc.config.CacheFilepath == "" {
+		return errors.New("no cache file configured")
+	}
+	info, err := os.Stat(rc.config.CacheFilepath)
+	if err != nil {
+		return err
+	}
+	content, err := ioutil.ReadFile(rc.config.CacheFilepath)
+	if err != nil {
+		return err
+	}
+	if err := rc.storeValues(content); err != nil {
+		return err
+	}
+
+	rc.content = content
+	// the snapshot is as old as the cache file
+	atomic.StoreInt64(&rc.lastSyncTime, info.ModTime().UnixNano())
+	rc.metricsScope.IncCounter(metrics.DynamicConfigRemoteSnapshotCacheLoads)
+	rc.logger.Warn("Loaded dynamic config from cached snapshot", tag.Value(rc.config.CacheFilepath))
+	return nil
+}
+
+func (rc *remoteClient) writeCache(content []byte) {
+	if rc.config.CacheFilepath == "" {
+		return
+	}
+	if err := writeFileAtomically(rc.config.CacheFilepath, content); err != nil {
+		rc.metricsScope.IncCounter(metrics.DynamicConfigRemoteSnapshotCacheFailures)
+		rc.logger.Error("Failed to cache dynamic config snapshot", tag.Error(err))
+	}
+}
+
+// writeFileAtomically makes sure a crash never leaves a partially written snapshot behind
+func writeFileAtomically(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, content, fileMode); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func withRemoteDefaults(config *RemoteClientConfig) *RemoteClientConfig {
+	result := *config
+	if result.LongPollTimeout == 0 {
+		result.LongPollTimeout = remoteDefaultLongPollTimeout
+	}
+	if result.PollInterval == 0 {
+		result.PollInterval = remoteDefaultPollInterval
+	}
+	if result.RequestTimeout == 0 {
+		result.RequestTimeout = remoteDefaultRequestTimeout
+	}
+	if result.RetryInterval == 0 {
+		result.RetryInterval = remoteDefaultRetryInterval
+	}
+	return &result
+}
+
+func validateRemoteConfig(config *RemoteClientConfig) error {
+	if config == nil {
+		return errors.New("no config found for remote dynamic config client")
+	}
+	if config.URL == "" {
+		return errors.New("url is required for remote dynamic config client")
+	}
+	if config.PollInterval != 0 && config.PollInterval < remoteMinPollInterval {
+		return fmt.Errorf("poll interval should be at least %v", remoteMinPollInterval)
+	}
+	if config.LongPollTimeout < 0 || config.RequestTimeout < 0 || config.RetryInterval < 0 {
+		return errors.New("timeouts and intervals of remote dynamic config client can't be negative")
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dynamicconfig
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/uber/cadence/common/dynamicconfig/remoteconfigtest"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/metrics"
+)
+
+const (
+	testRemoteInitialConfig = `
+testGetIntPropertyKey:
+- value: 1000
+  constraints: {}
+- value: 1001
+  constraints:
+    domainName: samples-domain
+`
+	testRemoteUpdatedConfig = `
+testGetIntPropertyKey:
+- value: 2000
+  constraints: {}
+`
+)
+
+type remoteClientSuite struct {
+	suite.Suite
+	*require.Assertions
+	server    *remoteconfigtest.Server
+	cacheFile string
+	doneCh    chan struct{}
+}
+
+func TestRemoteClientSuite(t *testing.T) {
+	s := new(remoteClientSuite)
+	suite.Run(t, s)
+}
+
+func (s *remoteClientSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.server = remoteconfigtest.NewServer([]byte(testRemoteInitialConfig))
+	dir, err := ioutil.TempDir("", "remote_client_test")
+	s.NoError(err)
+	s.cacheFile = filepath.Join(dir, "snapshot", "dynamicconfig.yaml")
+	s.doneCh = make(chan struct{})
+}
+
+func (s *remoteClientSuite) TearDownTest() {
+	close(s.doneCh)
+	s.server.Close()
+	os.RemoveAll(filepath.Dir(filepath.Dir(s.cacheFile)))
+}
+
+func (s *remoteClientSuite) newClient(url string) (Client, error) {
+	return NewRemoteClient(&RemoteClientConfig{
+		URL:             url,
+		LongPollTimeout: time.Second,
+		PollInterval:    time.Second,
+		RetryInterval:   time.Millisecond * 100,
+		CacheFilepath:   s.cacheFile,
+	}, metrics.NewNoopMetricsClient(), log.NewNoop(), s.doneCh)
+}
+
+func (s *remoteClientSuite) TestGetValues() {
+	client, err := s.newClient(s.server.URL())
+	s.NoError(err)
+
+	v, err := client.GetIntValue(TestGetIntPropertyKey, nil)
+	s.NoError(err)
+	s.Equal(1000, v)
+
+	v, err = client.GetIntValue(TestGetIntPropertyKey, map[Filter]interface{}{DomainName: "samples-domain"})
+	s.NoError(err)
+	s.Equal(1001, v)
+
+	_, err = client.GetBoolValue(TestGetBoolPropertyKey, nil)
+	s.Equal(NotFoundError, err)
+}
+
+func (s *remoteClientSuite) TestWatchUpdates() {
+	client, err := s.newClient(s.server.URL())
+	s.NoError(err)
+
+	s.server.Update([]byte(testRemoteUpdatedConfig))
+	s.Eventually(func() bool {
+		v, err := client.GetIntValue(TestGetIntPropertyKey, nil)
+		return err == nil && v == 2000
+	}, time.Second*5, time.Millisecond*10)
+
+	cached, err := ioutil.ReadFile(s.cacheFile)
+	s.NoError(err)
+	s.Equal(testRemoteUpdatedConfig, string(cached))
+}
+
+func (s *remoteClientSuite) TestKeepsLastGoodSnapshot() {
+	client, err := s.newClient(s.server.URL())
+	s.NoError(err)
+
+	s.server.Update([]byte("testGetIntPropertyKey: [not a list of values"))
+	s.Eventually(func() bool {
+		return s.server.Requests() > 3
+	}, time.Second*5, time.Millisecond*10)
+
+	v, err := client.GetIntValue(TestGetIntPropertyKey, nil)
+	s.NoError(err)
+	s.Equal(1000, v)
+}
+
+func (s *remoteClientSuite) TestFallbackToCachedSnapshot() {
+	_, err := s.newClient(s.server.URL())
+	s.NoError(err)
+
+	s.server.SetStatus(http.StatusServiceUnavailable)
+	client, err := s.newClient(s.server.URL())
+	s.NoError(err)
+
+	v, err := client.GetIntValue(TestGetIntPropertyKey, nil)
+	s.NoError(err)
+	s.Equal(1000, v)
+
+	// the client recovers once the endpoint is back
+	s.server.SetStatus(http.StatusOK)
+	s.server.Update([]byte(testRemoteUpdatedConfig))
+	s.Eventually(func() bool {
+		v, err := client.GetIntValue(TestGetIntPropertyKey, nil)
+		return err == nil && v == 2000
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func (s *remoteClientSuite) TestNoEndpointAndNoCache() {
+	s.server.SetStatus(http.StatusInternalServerError)
+	_, err := s.newClient(s.server.URL())
+	s.Error(err)
+}
+
+func (s *remoteClientSuite) TestStaleness() {
+	_, err := s.newClient(s.server.URL())
+	s.NoError(err)
+
+	lastModified := time.Now().Add(-time.Hour)
+	s.NoError(os.Chtimes(s.cacheFile, lastModified, lastModified))
+	s.server.SetStatus(http.StatusServiceUnavailable)
+	client, err := s.newClient(s.server.URL())
+	s.NoError(err)
+	s.True(client.(*remoteClient).staleness() >= time.Hour)
+
+	s.server.SetStatus(http.StatusOK)
+	s.server.Update([]byte(testRemoteUpdatedConfig))
+	s.Eventually(func() bool {
+		return client.(*remoteClient).staleness() < time.Minute
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func (s *remoteClientSuite) TestEndpointWithoutIndex() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.Empty(r.URL.Query().Get(RemoteConfigWaitParam))
+		_, _ = w.Write([]byte(testRemoteInitialConfig))
+	}))
+	defer server.Close()
+
+	client, err := s.newClient(server.URL)
+	s.NoError(err)
+
+	v, err := client.GetIntValue(TestGetIntPropertyKey, nil)
+	s.NoError(err)
+	s.Equal(1000, v)
+}
+
+func (s *remoteClientSuite) TestUpdateValueNotSupported() {
+	client, err := s.newClient(s.server.URL())
+	s.NoError(err)
+	s.Error(client.UpdateValue(TestGetIntPropertyKey, 1))
+}
+
+func (s *remoteClientSuite) TestValidateConfig() {
+	_, err := NewRemoteClient(nil, metrics.NewNoopMetricsClient(), log.NewNoop(), s.doneCh)
+	s.Error(err)
+	_, err = NewRemoteClient(&RemoteClientConfig{}, metrics.NewNoopMetricsClient(), log.NewNoop(), s.doneCh)
+	s.Error(err)
+	_, err = NewRemoteClient(&RemoteClientConfig{
+		URL:          s.server.URL(),
+		PollInterval: time.Millisecond,
+	}, metrics.NewNoopMetricsClient(), log.NewNoop(), s.doneCh)
+	s.Error(err)
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package remoteconfigtest provides a local stand-in for the key-value/HTTP endpoint
+// used by the remote dynamic config client, for tests and local development.
+package remoteconfigtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	indexHeader = "X-Cadence-Config-Index"
+	indexParam  = "index"
+	waitParam   = "wait"
+
+	maxWait = time.Minute
+)
+
+// Server serves a dynamic config snapshot and implements blocking queries:
+// a request with an index equal to the current one is held until the snapshot
+// changes or the requested wait time elapses, in which case 304 Not Modified is returned.
+type Server struct {
+	sync.Mutex
+
+	server    *httptest.Server
+	content   []byte
+	index     uint64
+	changedCh chan struct{}
+	status    int
+	requests  int
+}
+
+// NewServer starts a server serving the given snapshot at index 1
+func NewServer(content []byte) *Server {
+	s := &Server{
+		content:   content,
+		index:     1,
+		changedCh: make(chan struct{}),
+		status:    http.StatusOK,
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// URL returns the address of the server
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// Close shuts down the server
+func (s *Server) Close() {
+	s.server.CloseClientConnections()
+	s.server.Close()
+}
+
+// Update replaces the snapshot, bumps the index and wakes up blocked requests
+func (s *Server) Update(content []byte) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.content = content
+	s.index++
+	close(s.changedCh)
+	s.changedCh = make(chan struct{})
+}
+
+// SetStatus makes the server answer every request with the given status code,
+// http.StatusOK restores normal operation
+func (s *Server) SetStatus(status int) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.status = status
+}
+
+// Requests returns the number of requests the server received
+func (s *Server) Requests() int {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.requests
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	s.requests++
+	status, index, changedCh := s.status, s.index, s.changedCh
+	s.Unlock()
+
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	query := r.URL.Query()
+	if requested, err := strconv.ParseUint(query.Get(indexParam), 10, 64); err == nil && requested == index {
+		wait, err := time.ParseDuration(query.Get(waitParam))
+		if err != nil || wait > maxWait {
+			wait = maxWait
+		}
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-changedCh:
+		case <-timer.C:
+			w.WriteHeader(http.StatusNotModified)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	s.Lock()
+	content, index := s.content, s.index
+	s.Unlock()
+	w.Header().Set(indexHeader, strconv.FormatUint(index, 10))
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write(content)
+}
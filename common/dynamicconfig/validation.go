@@ -0,0 +1,350 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dynamicconfig
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/metrics"
+)
+
+// ValidationError describes an invalid entry of a dynamic config
+type ValidationError struct {
+	KeyName     string
+	Constraints map[string]interface{}
+	Message     string
+	// UnknownKey is true if the key is not defined, e.g. it was removed in this version or has a typo.
+	// Clients ignore such entries instead of rejecting the config.
+	UnknownKey bool
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Constraints) == 0 {
+		return fmt.Sprintf("%v: %v", e.KeyName, e.Message)
+	}
+	return fmt.Sprintf("%v %v: %v", e.KeyName, FormatConstraints(e.Constraints), e.Message)
+}
+
+// ValidationErrors is a list of invalid entries, it's returned when a dynamic config is rejected
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("invalid dynamic config: %v", strings.Join(messages, "; "))
+}
+
+// SplitUnknownKeys returns the entries which make a config invalid and the entries with unknown keys separately
+func (e ValidationErrors) SplitUnknownKeys() (invalid ValidationErrors, unknown ValidationErrors) {
+	for _, err := range e {
+		if err.UnknownKey {
+			unknown = append(unknown, err)
+		} else {
+			invalid = append(invalid, err)
+		}
+	}
+	return invalid, unknown
+}
+
+// ValidateConfig parses a dynamic config in the format of the file based client
+// and returns all invalid entries. It returns an error if the config can't be parsed.
+func ValidateConfig(content []byte) (ValidationErrors, error) {
+	values := make(map[string][]*constrainedValue)
+	if err := yaml.Unmarshal(content, values); err != nil {
+		return nil, fmt.Errorf("failed to decode dynamic config %v", err)
+	}
+	for _, s := range values {
+		for _, cv := range s {
+			var err error
+			cv.Value, err = convertKeyTypeToString(cv.Value)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return validateValues(values), nil
+}
+
+// ValidateValue checks that the value has the type of the key and is within the allowed range or values of the key.
+// Values decoded from YAML or JSON are accepted as well, e.g. durations as strings and ints as integral floats.
+func ValidateValue(key Key, value interface{}) error {
+	switch k := key.(type) {
+	case IntKey:
+		intVal, ok := toInt(value)
+		if !ok {
+			return fmt.Errorf("value type is not int but is: %T", value)
+		}
+		if r := IntKeys[k].Range; r != nil && (intVal < r.Min || intVal > r.Max) {
+			return fmt.Errorf("value %v is out of range [%v, %v]", intVal, r.Min, r.Max)
+		}
+	case BoolKey:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("value type is not bool but is: %T", value)
+		}
+	case FloatKey:
+		floatVal, ok := value.(float64)
+		if intVal, isInt := value.(int); isInt {
+			floatVal, ok = float64(intVal), true
+		}
+		if !ok {
+			return fmt.Errorf("value type is not float64 but is: %T", value)
+		}
+		if r := FloatKeys[k].Range; r != nil && (floatVal < r.Min || floatVal > r.Max) {
+			return fmt.Errorf("value %v is out of range [%v, %v]", floatVal, r.Min, r.Max)
+		}
+	case StringKey:
+		stringVal, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("value type is not string but is: %T", value)
+		}
+		if allowed := StringKeys[k].AllowedValues; len(allowed) > 0 && !containsString(allowed, stringVal) {
+			return fmt.Errorf("value %q is not one of the allowed values %q", stringVal, allowed)
+		}
+	case DurationKey:
+		durationVal, ok := value.(time.Duration)
+		if !ok {
+			durationString, isString := value.(string)
+			if !isString {
+				return fmt.Errorf("value type is not duration string but is: %T", value)
+			}
+			var err error
+			if durationVal, err = time.ParseDuration(durationString); err != nil {
+				return fmt.Errorf("failed to parse duration: %v", err)
+			}
+		}
+		if r := DurationKeys[k].Range; r != nil && (durationVal < r.Min || durationVal > r.Max) {
+			return fmt.Errorf("value %v is out of range [%v, %v]", durationVal, r.Min, r.Max)
+		}
+	case MapKey:
+		if _, ok := value.(map[string]interface{}); !ok {
+			return fmt.Errorf("value type is not map but is: %T", value)
+		}
+	default:
+		return fmt.Errorf("unknown key type: %T", key)
+	}
+	return nil
+}
+
+// ValidateFilter checks that the key can be constrained by the filter with the given value.
+// Cluster name is accepted for all keys as every lookup is filtered by the current cluster.
+func ValidateFilter(key Key, filter Filter, value interface{}) error {
+	if filter == UnknownFilter {
+		return fmt.Errorf("unknown filter, valid filters: %v", strings.Join(filters[1:], ", "))
+	}
+	if filter != ClusterName && !containsFilter(key.Filters(), filter) {
+		return fmt.Errorf("filter %v is not supported by the key, supported filters: %v", filter, formatFilters(key.Filters()))
+	}
+	switch filter {
+	case ShardID, TaskType:
+		if _, ok := toInt(value); !ok {
+			return fmt.Errorf("value of filter %v is not int but is: %T", filter, value)
+		}
+	default:
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("value of filter %v is not string but is: %T", filter, value)
+		}
+	}
+	return nil
+}
+
+// ValidateConstrainedValue checks a value of the key together with the constraints it applies to.
+// Keys for tests are not validated.
+func ValidateConstrainedValue(key Key, value interface{}, constraints map[string]interface{}) error {
+	if errs := validateConstrainedValue(key, &constrainedValue{Value: value, Constraints: constraints}); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// FormatConstraints returns a stable, human readable representation of constraints
+func FormatConstraints(constraints map[string]interface{}) string {
+	if len(constraints) == 0 {
+		return "{}"
+	}
+	parts := make([]string, 0, len(constraints))
+	for name, value := range constraints {
+		parts = append(parts, fmt.Sprintf("%v:%v", name, value))
+	}
+	sort.Strings(parts)
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func validateValues(values map[string][]*constrainedValue) ValidationErrors {
+	keyNames := make([]string, 0, len(values))
+	for keyName := range values {
+		keyNames = append(keyNames, keyName)
+	}
+	sort.Strings(keyNames)
+
+	var result ValidationErrors
+	for _, keyName := range keyNames {
+		key, err := GetKeyFromKeyName(keyName)
+		if err != nil {
+			message := "unknown key"
+			if suggestion := closestKeyName(keyName); suggestion != "" {
+				message = fmt.Sprintf("unknown key, did you mean %v?", suggestion)
+			}
+			result = append(result, &ValidationError{KeyName: keyName, Message: message, UnknownKey: true})
+			continue
+		}
+		defaults := 0
+		for _, cv := range values[keyName] {
+			if cv == nil {
+				continue
+			}
+			if len(cv.Constraints) == 0 {
+				defaults++
+			}
+			result = append(result, validateConstrainedValue(key, cv)...)
+		}
+		if defaults > 1 && isProductionKey(key) {
+			result = append(result, &ValidationError{KeyName: keyName, Message: "multiple values without constraints"})
+		}
+	}
+	return result
+}
+
+func validateConstrainedValue(key Key, cv *constrainedValue) ValidationErrors {
+	if !isProductionKey(key) {
+		// keys for tests are used to exercise the clients with invalid values
+		return nil
+	}
+
+	var result ValidationErrors
+	if err := ValidateValue(key, cv.Value); err != nil {
+		result = append(result, &ValidationError{KeyName: key.String(), Constraints: cv.Constraints, Message: err.Error()})
+	}
+	for name, value := range cv.Constraints {
+		if err := ValidateFilter(key, ParseFilter(name), value); err != nil {
+			result = append(result, &ValidationError{
+				KeyName:     key.String(),
+				Constraints: cv.Constraints,
+				Message:     fmt.Sprintf("invalid constraint %v: %v", name, err),
+			})
+		}
+	}
+	return result
+}
+
+// logUnknownKeys warns about entries which are ignored because their key is not defined,
+// they are usually left over from an older version and should be removed from the config
+func logUnknownKeys(logger log.Logger, scope metrics.Scope, unknown ValidationErrors) {
+	scope.UpdateGauge(metrics.DynamicConfigUnknownKeys, float64(len(unknown)))
+	for _, err := range unknown {
+		logger.Warn("Ignoring unknown dynamic config key", tag.Key(err.KeyName), tag.Error(err))
+	}
+}
+
+func isProductionKey(key Key) bool {
+	_, ok := _productionKeyNames[key.String()]
+	return ok
+}
+
+// closestKeyName returns the key name most similar to the given one, if it's a likely typo
+func closestKeyName(keyName string) string {
+	best, bestDistance := "", math.MaxInt32
+	for candidate := range _productionKeyNames {
+		if distance := editDistance(strings.ToLower(keyName), strings.ToLower(candidate)); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	if bestDistance > len(keyName)/4+1 {
+		return ""
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func toInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case float64:
+		// JSON decodes all numbers as float64
+		if v != math.Trunc(v) {
+			return 0, false
+		}
+		return int(v), true
+	}
+	return 0, false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFilter(values []Filter, value Filter) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func formatFilters(values []Filter) string {
+	names := []string{ClusterName.String()}
+	for _, f := range values {
+		names = append(names, f.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dynamicconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/metrics"
+)
+
+func TestValidateConfig(t *testing.T) {
+	tests := map[string]struct {
+		config   string
+		messages []string
+	}{
+		"valid": {
+			config: `
+frontend.rps:
+- value: 1200
+  constraints: {}
+- value: 1500
+  constraints:
+    clusterName: cluster0
+history.defaultEventEncoding:
+- value: json
+  constraints:
+    domainName: samples-domain
+system.advancedVisibilityWritingMode:
+- value: dual
+history.timerProcessorMaxPollIntervalJitterCoefficient:
+- value: 0.15
+matching.numTasklistWritePartitions:
+- value: 3
+  constraints:
+    domainName: samples-domain
+    taskListName: tasklist
+    taskType: 0
+`,
+		},
+		"unknown key": {
+			config: `
+frontend.rpss:
+- value: 1200
+`,
+			messages: []string{"unknown key, did you mean frontend.rps?"},
+		},
+		"wrong type": {
+			config: `
+frontend.rps:
+- value: "1200"
+`,
+			messages: []string{"value type is not int but is: string"},
+		},
+		"out of range": {
+			config: `
+history.timerProcessorMaxPollIntervalJitterCoefficient:
+- value: 1.5
+`,
+			messages: []string{"value 1.5 is out of range [0, 1]"},
+		},
+		"not allowed value": {
+			config: `
+system.advancedVisibilityWritingMode:
+- value: both
+`,
+			messages: []string{`value "both" is not one of the allowed values ["off" "on" "dual"]`},
+		},
+		"invalid duration": {
+			config: `
+frontend.domainFailoverRefreshInterval:
+- value: 20 seconds
+`,
+			messages: []string{`failed to parse duration: time: unknown unit " seconds" in duration "20 seconds"`},
+		},
+		"filter not supported": {
+			config: `
+frontend.rps:
+- value: 1200
+  constraints:
+    domainName: samples-domain
+`,
+			messages: []string{"invalid constraint domainName: filter domainName is not supported by the key, supported filters: clusterName"},
+		},
+		"unknown filter": {
+			config: `
+frontend.rps:
+- value: 1200
+  constraints:
+    domain: samples-domain
+`,
+			messages: []string{"invalid constraint domain: unknown filter, valid filters: domainName, domainID, taskListName, taskType, shardID, clusterName, workflowID, workflowType"},
+		},
+		"invalid filter value": {
+			config: `
+history.replicatorTaskBatchSize:
+- value: 10
+  constraints:
+    shardID: one
+`,
+			messages: []string{"invalid constraint shardID: value of filter shardID is not int but is: string"},
+		},
+		"multiple defaults": {
+			config: `
+frontend.rps:
+- value: 1200
+- value: 1300
+`,
+			messages: []string{"multiple values without constraints"},
+		},
+		"test keys are not validated": {
+			config: `
+testGetIntPropertyKey:
+- value: wrong type
+  constraints:
+    domainName: samples-domain
+`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			errs, err := ValidateConfig([]byte(test.config))
+			require.NoError(t, err)
+			var messages []string
+			for _, e := range errs {
+				messages = append(messages, e.Message)
+			}
+			assert.Equal(t, test.messages, messages)
+		})
+	}
+}
+
+func TestValidateConfig_ParseError(t *testing.T) {
+	_, err := ValidateConfig([]byte("frontend.rps: [value"))
+	assert.Error(t, err)
+}
+
+func TestValidateKeyValuePair(t *testing.T) {
+	assert.NoError(t, ValidateKeyValuePair(FrontendUserRPS, 100))
+	assert.Error(t, ValidateKeyValuePair(FrontendUserRPS, -1))
+	assert.Error(t, ValidateKeyValuePair(FrontendUserRPS, "100"))
+	assert.NoError(t, ValidateKeyValuePair(AdvancedVisibilityWritingMode, "off"))
+	assert.Error(t, ValidateKeyValuePair(AdvancedVisibilityWritingMode, "none"))
+	assert.NoError(t, ValidateKeyValuePair(DomainFailoverRefreshInterval, time.Second))
+}
+
+func TestValidateConstrainedValue(t *testing.T) {
+	// values decoded from JSON
+	assert.NoError(t, ValidateConstrainedValue(ReplicatorTaskBatchSize, float64(10), map[string]interface{}{"shardID": float64(1)}))
+	assert.Error(t, ValidateConstrainedValue(ReplicatorTaskBatchSize, 10.5, nil))
+	assert.Error(t, ValidateConstrainedValue(ReplicatorTaskBatchSize, float64(10), map[string]interface{}{"domainName": "samples-domain"}))
+}
+
+func TestKeyFiltersMatchDocumentation(t *testing.T) {
+	assert.Equal(t, []Filter{DomainName}, BlobSizeLimitWarn.Filters())
+	assert.Equal(t, []Filter{DomainName, TaskListName, TaskType}, MatchingNumTasklistWritePartitions.Filters())
+	assert.Equal(t, []Filter{ShardID}, ReplicatorTaskBatchSize.Filters())
+	assert.Empty(t, FrontendUserRPS.Filters())
+}
+
+func TestFileBasedClient_RejectsInvalidConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validation_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "dynamicconfig.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte("frontend.rps:\n- value: fast\nfrontend.rpss:\n- value: 1200\n"), fileMode))
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	_, err = NewFileBasedClient(&FileBasedClientConfig{
+		Filepath:     path,
+		PollInterval: time.Second * 5,
+	}, metrics.NewNoopMetricsClient(), log.NewNoop(), doneCh)
+	assert.EqualError(t, err, "invalid dynamic config: frontend.rps: value type is not int but is: string")
+}
+
+func TestFileBasedClient_IgnoresStaleKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validation_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "dynamicconfig.yaml")
+	config := "frontend.rps:\n- value: 1500\nfrontend.rpss:\n- value: 1200\nhistory.removedInOlderVersion:\n- value: true\n"
+	require.NoError(t, ioutil.WriteFile(path, []byte(config), fileMode))
+
+	scope := tally.NewTestScope("", nil)
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	client, err := NewFileBasedClient(&FileBasedClientConfig{
+		Filepath:     path,
+		PollInterval: time.Second * 5,
+	}, metrics.NewClient(scope, metrics.Common), log.NewNoop(), doneCh)
+	require.NoError(t, err)
+
+	rps, err := client.GetIntValue(FrontendUserRPS, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1500, rps)
+	gauges := scope.Snapshot().Gauges()
+	require.Len(t, gauges, 1)
+	for _, gauge := range gauges {
+		assert.Equal(t, "dynamicconfig_unknown_keys", gauge.Name())
+		assert.Equal(t, float64(2), gauge.Value())
+	}
+
+	errs, err := ValidateConfig([]byte(config))
+	require.NoError(t, err)
+	invalid, unknown := errs.SplitUnknownKeys()
+	assert.Empty(t, invalid)
+	require.Len(t, unknown, 2)
+	assert.Equal(t, "frontend.rpss: unknown key, did you mean frontend.rps?", unknown[0].Error())
+	assert.Equal(t, "history.removedInOlderVersion: unknown key", unknown[1].Error())
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package elasticsearch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/uber/cadence/.gen/go/indexer"
+	"github.com/uber/cadence/common/definition"
+)
+
+var (
+	// ErrUnregisteredField is reported for message fields that are not registered search attributes
+	ErrUnregisteredField = errors.New("unregistered field")
+	// ErrUnknownFieldType is reported for message fields with an unknown field type
+	ErrUnknownFieldType = errors.New("unknown field type")
+)
+
+// IsResponseSuccess returns true if a bulk response item is considered done,
+// 409 - Version Conflict
+// 404 - Not Found
+func IsResponseSuccess(status int) bool {
+	if status >= 200 && status < 300 || status == 409 || status == 404 {
+		return true
+	}
+	return false
+}
+
+// IsResponseRetriable is complaint with GenericBulkProcessorService.RetryItemStatusCodes
+// responses with these status will be kept in queue and retried until success
+// 408 - Request Timeout
+// 429 - Too Many Requests
+// 500 - Node not connected
+// 503 - Service Unavailable
+// 507 - Insufficient Storage
+func IsResponseRetriable(status int) bool {
+	_, ok := retryableStatusCode[status]
+	return ok
+}
+
+var retryableStatusCode = map[int]struct{}{408: {}, 429: {}, 500: {}, 503: {}, 507: {}}
+
+// GetErrorMsgFromBulkResponse returns the error message of a bulk response item, if any
+func GetErrorMsgFromBulkResponse(resp *GenericBulkResponseItem) string {
+	var errMsg string
+	if resp.Error != nil {
+		errMsg = fmt.Sprintf("%v", resp.Error)
+	}
+	return errMsg
+}
+
+// mappingConflictErrors are the ES error types of documents that don't match the index mapping
+var mappingConflictErrors = []string{"mapper_parsing_exception", "strict_dynamic_mapping_exception"}
+
+// IsMappingConflict returns true if a bulk response item failed because
+// the document doesn't match the mapping of the index
+func IsMappingConflict(resp *GenericBulkResponseItem) bool {
+	if resp.Status != http.StatusBadRequest {
+		return false
+	}
+	errMsg := GetErrorMsgFromBulkResponse(resp)
+	for _, errType := range mappingConflictErrors {
+		if strings.Contains(errMsg, errType) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidVisibilityField returns true if the field can be written to visibility index
+func IsValidVisibilityField(field string, validSearchAttributes map[string]interface{}) bool {
+	if _, ok := validSearchAttributes[field]; ok {
+		return true
+	}
+	if field == definition.Memo || field == definition.KafkaKey || field == definition.Encoding || field == VisibilityOperation {
+		return true
+	}
+	return false
+}
+
+// GenerateVisibilityDoc converts an indexer message into the document stored in visibility index.
+// Search attribute aliases are stored under the attribute resolveAlias returns for them.
+// Fields rejected by isValidField are left out and, like fields which cannot be decoded,
+// reported to onInvalidField.
+func GenerateVisibilityDoc(
+	msg *indexer.Message,
+	key string,
+	resolveAlias func(field string) string,
+	isValidField func(field string) bool,
+	onInvalidField func(field string, err error),
+) map[string]interface{} {
+	doc := make(map[string]interface{})
+	attr := make(map[string]interface{})
+	for k, v := range msg.Fields {
+		k = resolveAlias(k)
+		if !isValidField(k) {
+			onInvalidField(k, ErrUnregisteredField)
+			continue
+		}
+
+		// skip VisibilityOperation since it’s not being used for advanced visibility
+		if k == VisibilityOperation {
+			continue
+		}
+
+		switch v.GetType() {
+		case indexer.FieldTypeString:
+			doc[k] = v.GetStringData()
+		case indexer.FieldTypeInt:
+			doc[k] = v.GetIntData()
+		case indexer.FieldTypeBool:
+			doc[k] = v.GetBoolData()
+		case indexer.FieldTypeBinary:
+			if k == definition.Memo {
+				doc[k] = v.GetBinaryData()
+			} else { // custom search attributes
+				var val interface{}
+				if err := json.Unmarshal(v.GetBinaryData(), &val); err != nil {
+					onInvalidField(k, err)
+				}
+				attr[k] = val
+			}
+		default:
+			onInvalidField(k, ErrUnknownFieldType)
+		}
+	}
+	doc[definition.Attr] = attr
+	doc[definition.DomainID] = msg.GetDomainID()
+	doc[definition.WorkflowID] = msg.GetWorkflowID()
+	doc[definition.RunID] = msg.GetRunID()
+	doc[definition.KafkaKey] = key
+	return doc
+}
@@ -0,0 +1,447 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package elasticsearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pborman/uuid"
+
+	"github.com/uber/cadence/.gen/go/indexer"
+	"github.com/uber/cadence/common/codec"
+	"github.com/uber/cadence/common/definition"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/messaging"
+	"github.com/uber/cadence/common/metrics"
+)
+
+type (
+	// BulkProducerConfig contains the configs of a bulk producer
+	BulkProducerConfig struct {
+		NumOfWorkers          dynamicconfig.IntPropertyFn
+		BulkActions           dynamicconfig.IntPropertyFn // max number of requests in bulk
+		BulkSize              dynamicconfig.IntPropertyFn // max total size of bytes in bulk
+		FlushInterval         dynamicconfig.DurationPropertyFn
+		ValidSearchAttributes dynamicconfig.MapPropertyFn
+		// SearchAttributeAliases maps aliases to the search attributes they are written as
+		SearchAttributeAliases dynamicconfig.MapPropertyFn
+	}
+
+	// bulkProducer writes visibility messages to ElasticSearch through a GenericBulkProcessor,
+	// so advanced visibility can be written without Kafka and the worker indexer.
+	// Publish blocks until the bulk request of the message is committed, which takes up to
+	// the flush interval of the processor, documents are written with external versioning
+	// so retried messages are idempotent. Messages rejected with a non retryable status
+	// are published to the visibility DLQ, as retrying the publish would fail the same way forever.
+	bulkProducer struct {
+		sync.Mutex
+		client        GenericClient
+		indexResolver IndexResolver
+		config        *BulkProducerConfig
+		dlqProducer   messaging.Producer
+		msgEncoder    codec.BinaryEncoder
+		logger        log.Logger
+		metricsClient metrics.Client
+
+		processor GenericBulkProcessor
+		pending   map[string][]chan error // keyed by the key of the ES request
+		isClosed  bool
+	}
+
+	// rejectedRequestError completes the publishes of a request rejected with a non retryable status
+	rejectedRequestError struct {
+		status int
+		reason string
+	}
+)
+
+const (
+	bulkProducerName = "visibility-bulk-producer"
+
+	versionTypeExternal = "external"
+
+	// retry configs for bulk processor
+	bulkProducerInitialRetryInterval = 200 * time.Millisecond
+	bulkProducerMaxRetryInterval     = 20 * time.Second
+)
+
+var (
+	errUnknownMessageType = errors.New("unknown producer message type")
+	errBulkProducerClosed = errors.New("bulk producer is closed")
+	errBulkRequestDropped = errors.New("bulk request is dropped by bulk processor")
+)
+
+var _ messaging.CloseableProducer = (*bulkProducer)(nil)
+
+// NewBulkProducer creates a producer writing visibility messages to the indices of given resolver
+// through a bulk processor, which is started with the resolver on first publish.
+// Rejected messages are published to dlqProducer, or dropped if it is nil.
+func NewBulkProducer(
+	client GenericClient,
+	indexResolver IndexResolver,
+	config *BulkProducerConfig,
+	dlqProducer messaging.Producer,
+	logger log.Logger,
+	metricsClient metrics.Client,
+) messaging.CloseableProducer {
+	return &bulkProducer{
+		client:        client,
+		indexResolver: indexResolver,
+		config:        config,
+		dlqProducer:   dlqProducer,
+		msgEncoder:    codec.NewThriftRWEncoder(),
+		logger:        logger.WithTags(tag.ComponentIndexerESProcessor),
+		metricsClient: metricsClient,
+		pending:       make(map[string][]chan error),
+	}
+}
+
+// Publish writes an indexer message to ElasticSearch and waits for the result
+func (p *bulkProducer) Publish(ctx context.Context, message interface{}) error {
+	msg, ok := message.(*indexer.Message)
+	if !ok {
+		return errUnknownMessageType
+	}
+
+	processor, err := p.getProcessor()
+	if err != nil {
+		return err
+	}
+	requests, keys, err := p.getBulkRequests(msg)
+	if err != nil || len(requests) == 0 {
+		return err
+	}
+
+	// a message is written to several indices during online reindex or deleted from all indices,
+	// it is published when the requests of all indices are committed
+	dones := make([]chan error, len(requests))
+	for i, request := range requests {
+		dones[i] = make(chan error, 1)
+		if err := p.addPending(keys[i], dones[i]); err != nil {
+			p.removeAllPending(keys[:i], dones[:i])
+			return err
+		}
+		processor.Add(request)
+	}
+
+	for i, done := range dones {
+		select {
+		case err := <-done:
+			if err != nil {
+				p.removeAllPending(keys[i+1:], dones[i+1:])
+				if rejected, ok := err.(*rejectedRequestError); ok {
+					return p.publishToDLQ(ctx, msg, rejected)
+				}
+				return err
+			}
+		case <-ctx.Done():
+			p.removeAllPending(keys[i:], dones[i:])
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Close stops the bulk processor, publishes still waiting for their requests fail
+func (p *bulkProducer) Close() error {
+	p.Lock()
+	if p.isClosed {
+		p.Unlock()
+		return nil
+	}
+	p.isClosed = true
+	processor := p.processor
+	p.Unlock()
+
+	p.indexResolver.Stop()
+
+	var err error
+	if processor != nil {
+		// stopping flushes requests in the processor, which completes their publishes
+		err = processor.Stop()
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	for key, waiters := range p.pending {
+		for _, done := range waiters {
+			done <- errBulkProducerClosed
+		}
+		delete(p.pending, key)
+	}
+	return err
+}
+
+func (p *bulkProducer) getBulkRequests(msg *indexer.Message) ([]*GenericBulkableAddRequest, []string, error) {
+	docID := GenerateDocID(msg.GetWorkflowID(), msg.GetRunID())
+	// check and skip invalid docID
+	if len(docID) >= GetESDocIDSizeLimit() {
+		p.logger.Error("Index message is too long",
+			tag.WorkflowDomainID(msg.GetDomainID()),
+			tag.WorkflowID(msg.GetWorkflowID()),
+			tag.WorkflowRunID(msg.GetRunID()))
+		return nil, nil, nil
+	}
+
+	var indices []string
+	switch msg.GetMessageType() {
+	case indexer.MessageTypeIndex, indexer.MessageTypeCreate:
+		indices = p.indexResolver.GetWriteIndices(msg.Fields[StartTime].GetIntData())
+	case indexer.MessageTypeDelete:
+		indices = p.indexResolver.GetDeleteIndices()
+	default:
+		p.metricsClient.IncCounter(metrics.ESProcessorScope, metrics.ESProcessorCorruptedData)
+		return nil, nil, errUnknownMessageType
+	}
+
+	requests := make([]*GenericBulkableAddRequest, 0, len(indices))
+	keys := make([]string, 0, len(indices))
+	for _, index := range indices {
+		var key string
+		request := &GenericBulkableAddRequest{
+			Index:       index,
+			Type:        GetESDocType(),
+			ID:          docID,
+			VersionType: versionTypeExternal,
+			Version:     msg.GetVersion(),
+		}
+		switch msg.GetMessageType() {
+		case indexer.MessageTypeIndex:
+			key = uuid.New()
+			request.Doc = GenerateVisibilityDoc(msg, key, p.resolveAlias, p.isValidField, p.onInvalidField)
+			request.RequestType = BulkableIndexRequest
+		case indexer.MessageTypeDelete:
+			// the key of delete requests is retrieved from their index and doc ID
+			key = GenerateDeleteKey(index, docID)
+			request.RequestType = BulkableDeleteRequest
+		case indexer.MessageTypeCreate:
+			key = uuid.New()
+			request.Doc = GenerateVisibilityDoc(msg, key, p.resolveAlias, p.isValidField, p.onInvalidField)
+			request.RequestType = BulkableCreateRequest
+		}
+		requests = append(requests, request)
+		keys = append(keys, key)
+	}
+	return requests, keys, nil
+}
+
+func (p *bulkProducer) resolveAlias(field string) string {
+	return definition.ResolveSearchAttributeAlias(p.config.SearchAttributeAliases(), field)
+}
+
+func (p *bulkProducer) isValidField(field string) bool {
+	return IsValidVisibilityField(field, p.config.ValidSearchAttributes())
+}
+
+func (p *bulkProducer) onInvalidField(field string, err error) {
+	p.logger.Error("Invalid visibility message field.", tag.ESField(field), tag.Error(err))
+	p.metricsClient.IncCounter(metrics.ESProcessorScope, metrics.ESProcessorCorruptedData)
+}
+
+// getProcessor returns the bulk processor, which is started with the index resolver on first call
+func (p *bulkProducer) getProcessor() (GenericBulkProcessor, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.isClosed {
+		return nil, errBulkProducerClosed
+	}
+	if p.processor == nil {
+		params := &BulkProcessorParameters{
+			Name:          bulkProducerName,
+			NumOfWorkers:  p.config.NumOfWorkers(),
+			BulkActions:   p.config.BulkActions(),
+			BulkSize:      p.config.BulkSize(),
+			FlushInterval: p.config.FlushInterval(),
+			Backoff:       NewExponentialBackoff(bulkProducerInitialRetryInterval, bulkProducerMaxRetryInterval),
+			BeforeFunc:    p.bulkBeforeAction,
+			AfterFunc:     p.bulkAfterAction,
+		}
+		processor, err := p.client.RunBulkProcessor(context.Background(), params)
+		if err != nil {
+			return nil, err
+		}
+		p.processor = processor
+		p.indexResolver.Start()
+	}
+	return p.processor, nil
+}
+
+func (p *bulkProducer) addPending(key string, done chan error) error {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.isClosed {
+		return errBulkProducerClosed
+	}
+	p.pending[key] = append(p.pending[key], done)
+	return nil
+}
+
+func (p *bulkProducer) removeAllPending(keys []string, dones []chan error) {
+	for i, key := range keys {
+		p.removePending(key, dones[i])
+	}
+}
+
+func (p *bulkProducer) removePending(key string, done chan error) {
+	p.Lock()
+	defer p.Unlock()
+
+	waiters := p.pending[key]
+	for i, ch := range waiters {
+		if ch == done {
+			waiters = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(waiters) == 0 {
+		delete(p.pending, key)
+	} else {
+		p.pending[key] = waiters
+	}
+}
+
+// complete notifies all publishes waiting for the request with given key
+func (p *bulkProducer) complete(key string, err error) {
+	p.Lock()
+	defer p.Unlock()
+
+	for _, done := range p.pending[key] {
+		done <- err
+	}
+	delete(p.pending, key)
+}
+
+// bulkBeforeAction is triggered before bulk processor commit
+func (p *bulkProducer) bulkBeforeAction(executionID int64, requests []GenericBulkableRequest) {
+	p.metricsClient.AddCounter(metrics.ESProcessorScope, metrics.ESProcessorRequests, int64(len(requests)))
+}
+
+// bulkAfterAction is triggered after bulk processor commit
+func (p *bulkProducer) bulkAfterAction(id int64, requests []GenericBulkableRequest, response *GenericBulkResponse, err *GenericError) {
+	if err != nil {
+		// This happens after configured retry, the requests are dropped by the bulk processor
+		// and have to be published again
+		p.logger.Error("Error commit bulk request.", tag.Error(err.Details))
+		isRetryable := IsResponseRetriable(err.Status)
+		for _, request := range requests {
+			p.metricsClient.IncCounter(metrics.ESProcessorScope, metrics.ESProcessorFailures)
+			key := p.processor.RetrieveKafkaKey(request, p.logger, p.metricsClient)
+			if key == "" {
+				continue
+			}
+			if !isRetryable {
+				p.drop(key, request, err.Status, fmt.Sprintf("%v", err.Details))
+				continue
+			}
+			p.complete(key, fmt.Errorf("%v: %v", errBulkRequestDropped, err.Details))
+		}
+		return
+	}
+
+	for i := 0; i < len(requests); i++ {
+		key := p.processor.RetrieveKafkaKey(requests[i], p.logger, p.metricsClient)
+		if key == "" {
+			continue
+		}
+		for _, resp := range response.Items[i] {
+			switch {
+			case IsResponseSuccess(resp.Status):
+				p.complete(key, nil)
+			case !IsResponseRetriable(resp.Status):
+				errMsg := GetErrorMsgFromBulkResponse(resp)
+				if IsMappingConflict(resp) {
+					p.metricsClient.IncCounter(metrics.ESProcessorScope, metrics.ESProcessorMappingConflicts)
+					errMsg = "mapping conflict: " + errMsg
+				}
+				p.metricsClient.IncCounter(metrics.ESProcessorScope, metrics.ESProcessorFailures)
+				p.drop(key, requests[i], resp.Status, errMsg)
+			default: // bulk processor will retry
+				p.logger.Info("ES request retried.", tag.ESResponseStatus(resp.Status))
+				p.metricsClient.IncCounter(metrics.ESProcessorScope, metrics.ESProcessorRetries)
+			}
+		}
+	}
+}
+
+// drop completes the publishes waiting for a request that ElasticSearch rejected with a non retryable status.
+// Failing them would retry the transfer tasks forever, so their messages are published to the DLQ instead.
+func (p *bulkProducer) drop(key string, request GenericBulkableRequest, status int, errMsg string) {
+	p.logger.Error("ES request failed with non retryable status.",
+		tag.ESResponseStatus(status), tag.ESResponseError(errMsg), tag.ESRequest(request.String()))
+	p.complete(key, &rejectedRequestError{status: status, reason: errMsg})
+}
+
+// publishToDLQ publishes a rejected message to the visibility DLQ with the reason of the rejection.
+// Without a DLQ the record is lost until the workflow is updated again or refreshed by the visibility scanner.
+func (p *bulkProducer) publishToDLQ(ctx context.Context, msg *indexer.Message, rejected *rejectedRequestError) error {
+	if p.dlqProducer == nil {
+		p.logger.Error("Visibility DLQ is not configured, dropping visibility record.",
+			tag.WorkflowDomainID(msg.GetDomainID()),
+			tag.WorkflowID(msg.GetWorkflowID()),
+			tag.WorkflowRunID(msg.GetRunID()),
+			tag.Value(rejected.reason))
+		p.metricsClient.IncCounter(metrics.ESProcessorScope, metrics.ESProcessorDroppedRequests)
+		return nil
+	}
+
+	payload, err := p.msgEncoder.Encode(msg)
+	if err != nil {
+		return err
+	}
+	dlqMsg := &messaging.DLQMessage{
+		Reason:     rejected.reason,
+		Status:     rejected.status,
+		DomainID:   msg.GetDomainID(),
+		WorkflowID: msg.GetWorkflowID(),
+		RunID:      msg.GetRunID(),
+		FailedAt:   time.Now(),
+		Payload:    payload,
+	}
+	// a failed publish fails the transfer task, which writes the record again when it is retried
+	if err := p.dlqProducer.Publish(ctx, dlqMsg); err != nil {
+		p.metricsClient.IncCounter(metrics.ESProcessorScope, metrics.ESProcessorDLQFailures)
+		p.logger.Error("Failed to publish message to visibility DLQ.",
+			tag.WorkflowDomainID(msg.GetDomainID()),
+			tag.WorkflowID(msg.GetWorkflowID()),
+			tag.WorkflowRunID(msg.GetRunID()),
+			tag.Error(err))
+		return err
+	}
+	p.metricsClient.IncCounter(metrics.ESProcessorScope, metrics.ESProcessorDLQMessages)
+	p.logger.Warn("Published message to visibility DLQ.",
+		tag.WorkflowDomainID(msg.GetDomainID()),
+		tag.WorkflowID(msg.GetWorkflowID()),
+		tag.WorkflowRunID(msg.GetRunID()),
+		tag.Value(rejected.reason))
+	return nil
+}
+
+func (e *rejectedRequestError) Error() string {
+	return fmt.Sprintf("ES request rejected with status %v: %v", e.status, e.reason)
+}
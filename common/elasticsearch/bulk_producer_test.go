@@ -0,0 +1,334 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package elasticsearch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+
+	"github.com/uber/cadence/.gen/go/indexer"
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/codec"
+	"github.com/uber/cadence/common/definition"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/loggerimpl"
+	"github.com/uber/cadence/common/messaging"
+	"github.com/uber/cadence/common/metrics"
+)
+
+type (
+	// fakeClient is a GenericClient running fakeBulkProcessor
+	fakeClient struct {
+		GenericClient
+		params    *BulkProcessorParameters
+		processor *fakeBulkProcessor
+	}
+
+	// fakeBulkProcessor records added requests, which are committed by calling AfterFunc of fakeClient.params
+	fakeBulkProcessor struct {
+		GenericBulkProcessor
+		requests chan *GenericBulkableAddRequest
+		stopped  bool
+	}
+
+	fakeBulkableRequest struct {
+		GenericBulkableRequest
+		key string
+	}
+
+	// fakeProducer records published messages and fails them with err
+	fakeProducer struct {
+		messages []interface{}
+		err      error
+	}
+)
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		processor: &fakeBulkProcessor{requests: make(chan *GenericBulkableAddRequest, 10)},
+	}
+}
+
+func (c *fakeClient) RunBulkProcessor(_ context.Context, params *BulkProcessorParameters) (GenericBulkProcessor, error) {
+	c.params = params
+	return c.processor, nil
+}
+
+// commit completes the request with a bulk response of given status
+func (c *fakeClient) commit(request *GenericBulkableAddRequest, status int) {
+	c.params.AfterFunc(
+		1,
+		[]GenericBulkableRequest{newFakeBulkableRequest(request)},
+		&GenericBulkResponse{Items: []map[string]*GenericBulkResponseItem{{"index": {Status: status}}}},
+		nil,
+	)
+}
+
+func (p *fakeBulkProcessor) Add(request *GenericBulkableAddRequest) {
+	p.requests <- request
+}
+
+func (p *fakeBulkProcessor) Stop() error {
+	p.stopped = true
+	return nil
+}
+
+func (p *fakeBulkProcessor) RetrieveKafkaKey(request GenericBulkableRequest, _ log.Logger, _ metrics.Client) string {
+	return request.(*fakeBulkableRequest).key
+}
+
+func newFakeBulkableRequest(request *GenericBulkableAddRequest) *fakeBulkableRequest {
+	if request.RequestType == BulkableDeleteRequest {
+		return &fakeBulkableRequest{key: GenerateDeleteKey(request.Index, request.ID)}
+	}
+	return &fakeBulkableRequest{key: request.Doc.(map[string]interface{})[definition.KafkaKey].(string)}
+}
+
+func (r *fakeBulkableRequest) String() string {
+	return r.key
+}
+
+func (p *fakeProducer) Publish(_ context.Context, message interface{}) error {
+	p.messages = append(p.messages, message)
+	return p.err
+}
+
+func newTestBulkProducer(client GenericClient) *bulkProducer {
+	config := &BulkProducerConfig{
+		NumOfWorkers:           dynamicconfig.GetIntPropertyFn(1),
+		BulkActions:            dynamicconfig.GetIntPropertyFn(10),
+		BulkSize:               dynamicconfig.GetIntPropertyFn(2 << 20),
+		FlushInterval:          dynamicconfig.GetDurationPropertyFn(time.Second),
+		ValidSearchAttributes:  dynamicconfig.GetMapPropertyFn(definition.GetDefaultIndexedKeys()),
+		SearchAttributeAliases: dynamicconfig.GetMapPropertyFn(nil),
+	}
+	return NewBulkProducer(client, &staticIndexResolver{indices: []string{"test-index"}}, config, nil, loggerimpl.NewNopLogger(), metrics.NewNoopMetricsClient()).(*bulkProducer)
+}
+
+func newTestIndexMessage(messageType indexer.MessageType) *indexer.Message {
+	return &indexer.Message{
+		MessageType: messageType.Ptr(),
+		DomainID:    common.StringPtr("domain-id"),
+		WorkflowID:  common.StringPtr("workflow-id"),
+		RunID:       common.StringPtr("run-id"),
+		Version:     common.Int64Ptr(123),
+		Fields: map[string]*indexer.Field{
+			WorkflowType: {Type: &FieldTypeString, StringData: common.StringPtr("workflow-type")},
+			StartTime:    {Type: &FieldTypeInt, IntData: common.Int64Ptr(456)},
+		},
+	}
+}
+
+func publishAsync(ctx context.Context, producer *bulkProducer, msg *indexer.Message) chan error {
+	result := make(chan error, 1)
+	go func() {
+		result <- producer.Publish(ctx, msg)
+	}()
+	return result
+}
+
+func TestBulkProducer_Publish(t *testing.T) {
+	tests := map[string]struct {
+		messageType indexer.MessageType
+		statuses    []int
+	}{
+		"index": {
+			messageType: indexer.MessageTypeIndex,
+			statuses:    []int{200},
+		},
+		"create with version conflict": {
+			messageType: indexer.MessageTypeCreate,
+			statuses:    []int{409},
+		},
+		"delete": {
+			messageType: indexer.MessageTypeDelete,
+			statuses:    []int{404},
+		},
+		"retried": {
+			messageType: indexer.MessageTypeIndex,
+			statuses:    []int{429, 503, 201},
+		},
+		"rejected": {
+			messageType: indexer.MessageTypeIndex,
+			statuses:    []int{400},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := newFakeClient()
+			producer := newTestBulkProducer(client)
+			result := publishAsync(context.Background(), producer, newTestIndexMessage(test.messageType))
+
+			request := <-client.processor.requests
+			require.Equal(t, "test-index", request.Index)
+			require.Equal(t, GenerateDocID("workflow-id", "run-id"), request.ID)
+			require.Equal(t, versionTypeExternal, request.VersionType)
+			require.Equal(t, int64(123), request.Version)
+			switch test.messageType {
+			case indexer.MessageTypeDelete:
+				require.Equal(t, BulkableDeleteRequest, request.RequestType)
+				require.Nil(t, request.Doc)
+			default:
+				doc := request.Doc.(map[string]interface{})
+				require.Equal(t, "workflow-type", doc[WorkflowType])
+				require.Equal(t, int64(456), doc[StartTime])
+				require.Equal(t, "domain-id", doc[DomainID])
+				require.NotEmpty(t, doc[KafkaKey])
+			}
+
+			for _, status := range test.statuses {
+				select {
+				case err := <-result:
+					require.FailNow(t, "publish returned before the request was committed", "%v", err)
+				default:
+				}
+				client.commit(request, status)
+			}
+			require.NoError(t, <-result)
+			require.Empty(t, producer.pending)
+		})
+	}
+}
+
+func TestBulkProducer_Publish_BulkError(t *testing.T) {
+	client := newFakeClient()
+	producer := newTestBulkProducer(client)
+	result := publishAsync(context.Background(), producer, newTestIndexMessage(indexer.MessageTypeIndex))
+
+	request := <-client.processor.requests
+	client.params.AfterFunc(1, []GenericBulkableRequest{newFakeBulkableRequest(request)}, nil, &GenericError{Status: 503})
+	require.Error(t, <-result)
+	require.Empty(t, producer.pending)
+}
+
+func TestBulkProducer_Publish_NonRetryableStatus(t *testing.T) {
+	tests := map[string]func(client *fakeClient, request *GenericBulkableAddRequest){
+		"item status": func(client *fakeClient, request *GenericBulkableAddRequest) {
+			client.commit(request, 400)
+		},
+		"bulk error": func(client *fakeClient, request *GenericBulkableAddRequest) {
+			client.params.AfterFunc(1, []GenericBulkableRequest{newFakeBulkableRequest(request)}, nil, &GenericError{Status: 403})
+		},
+	}
+	for name, commit := range tests {
+		t.Run(name, func(t *testing.T) {
+			scope := tally.NewTestScope("test", nil)
+			client := newFakeClient()
+			producer := newTestBulkProducer(client)
+			producer.metricsClient = metrics.NewClient(scope, metrics.History)
+			result := publishAsync(context.Background(), producer, newTestIndexMessage(indexer.MessageTypeIndex))
+
+			commit(client, <-client.processor.requests)
+			// the record is dropped so that the transfer task completes instead of being retried forever
+			require.NoError(t, <-result)
+			require.Empty(t, producer.pending)
+			counters := scope.Snapshot().Counters()
+			require.Equal(t, int64(1), counters["test.es_processor_dropped_requests+operation=ESProcessor"].Value())
+			require.Equal(t, int64(1), counters["test.es_processor_errors+operation=ESProcessor"].Value())
+		})
+	}
+}
+
+func TestBulkProducer_Publish_NonRetryableStatus_DLQ(t *testing.T) {
+	scope := tally.NewTestScope("test", nil)
+	client := newFakeClient()
+	dlq := &fakeProducer{}
+	producer := newTestBulkProducer(client)
+	producer.dlqProducer = dlq
+	producer.metricsClient = metrics.NewClient(scope, metrics.History)
+	msg := newTestIndexMessage(indexer.MessageTypeIndex)
+	result := publishAsync(context.Background(), producer, msg)
+
+	request := <-client.processor.requests
+	client.params.AfterFunc(
+		1,
+		[]GenericBulkableRequest{newFakeBulkableRequest(request)},
+		&GenericBulkResponse{Items: []map[string]*GenericBulkResponseItem{{"index": {
+			Status: 400,
+			Error:  map[string]interface{}{"type": "mapper_parsing_exception", "reason": "failed to parse field"},
+		}}}},
+		nil,
+	)
+	require.NoError(t, <-result)
+	require.Empty(t, producer.pending)
+
+	require.Len(t, dlq.messages, 1)
+	dlqMsg := dlq.messages[0].(*messaging.DLQMessage)
+	require.Equal(t, 400, dlqMsg.Status)
+	require.Contains(t, dlqMsg.Reason, "mapping conflict: ")
+	require.Equal(t, "domain-id", dlqMsg.DomainID)
+	require.Equal(t, "workflow-id", dlqMsg.WorkflowID)
+	require.Equal(t, "run-id", dlqMsg.RunID)
+	var payload indexer.Message
+	require.NoError(t, codec.NewThriftRWEncoder().Decode(dlqMsg.Payload, &payload))
+	require.Equal(t, msg, &payload)
+
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(1), counters["test.es_processor_dlq_messages+operation=ESProcessor"].Value())
+	require.Equal(t, int64(1), counters["test.es_processor_mapping_conflicts+operation=ESProcessor"].Value())
+	require.NotContains(t, counters, "test.es_processor_dropped_requests+operation=ESProcessor")
+
+	// the publish fails if the message can't be published to the DLQ, so the record is written again
+	dlq.err = errors.New("dlq unavailable")
+	result = publishAsync(context.Background(), producer, msg)
+	client.commit(<-client.processor.requests, 400)
+	require.Equal(t, dlq.err, <-result)
+	require.Empty(t, producer.pending)
+}
+
+func TestBulkProducer_Publish_ContextTimeout(t *testing.T) {
+	client := newFakeClient()
+	producer := newTestBulkProducer(client)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := producer.Publish(ctx, newTestIndexMessage(indexer.MessageTypeIndex))
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.Empty(t, producer.pending)
+
+	// late response of the request is ignored
+	client.commit(<-client.processor.requests, 200)
+}
+
+func TestBulkProducer_Publish_UnknownMessage(t *testing.T) {
+	producer := newTestBulkProducer(newFakeClient())
+	require.Equal(t, errUnknownMessageType, producer.Publish(context.Background(), "message"))
+	require.Equal(t, errUnknownMessageType, producer.Publish(context.Background(), newTestIndexMessage(indexer.MessageType(-1))))
+}
+
+func TestBulkProducer_Close(t *testing.T) {
+	client := newFakeClient()
+	producer := newTestBulkProducer(client)
+	result := publishAsync(context.Background(), producer, newTestIndexMessage(indexer.MessageTypeIndex))
+	<-client.processor.requests
+
+	require.NoError(t, producer.Close())
+	require.True(t, client.processor.stopped)
+	require.Equal(t, errBulkProducerClosed, <-result)
+	require.Equal(t, errBulkProducerClosed, producer.Publish(context.Background(), newTestIndexMessage(indexer.MessageTypeIndex)))
+	require.NoError(t, producer.Close())
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package elasticsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/.gen/go/indexer"
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/definition"
+)
+
+func TestGenerateVisibilityDoc(t *testing.T) {
+	msg := &indexer.Message{
+		DomainID:   common.StringPtr("domain-id"),
+		WorkflowID: common.StringPtr("workflow-id"),
+		RunID:      common.StringPtr("run-id"),
+		Fields: map[string]*indexer.Field{
+			WorkflowType:         {Type: &FieldTypeString, StringData: common.StringPtr("workflow-type")},
+			StartTime:            {Type: &FieldTypeInt, IntData: common.Int64Ptr(123)},
+			IsCron:               {Type: &FieldTypeBool, BoolData: common.BoolPtr(true)},
+			Memo:                 {Type: &FieldTypeBinary, BinaryData: []byte("memo")},
+			VisibilityOperation:  {Type: &FieldTypeString, StringData: common.StringPtr("operation")},
+			"CustomKeywordField": {Type: &FieldTypeBinary, BinaryData: []byte(`"keyword"`)},
+			"CustomIntField":     {Type: &FieldTypeBinary, BinaryData: []byte("not json")},
+			"OldDoubleField":     {Type: &FieldTypeBinary, BinaryData: []byte("1.5")},
+			"Unregistered":       {Type: &FieldTypeString, StringData: common.StringPtr("value")},
+		},
+	}
+	validSearchAttributes := definition.GetDefaultIndexedKeys()
+	aliases := map[string]interface{}{"OldDoubleField": "CustomDoubleField"}
+	resolveAlias := func(field string) string {
+		return definition.ResolveSearchAttributeAlias(aliases, field)
+	}
+	isValidField := func(field string) bool {
+		return IsValidVisibilityField(field, validSearchAttributes)
+	}
+	invalidFields := make(map[string]error)
+	onInvalidField := func(field string, err error) {
+		invalidFields[field] = err
+	}
+
+	doc := GenerateVisibilityDoc(msg, "key", resolveAlias, isValidField, onInvalidField)
+	require.Equal(t, map[string]interface{}{
+		DomainID:     "domain-id",
+		WorkflowID:   "workflow-id",
+		RunID:        "run-id",
+		WorkflowType: "workflow-type",
+		StartTime:    int64(123),
+		IsCron:       true,
+		Memo:         []byte("memo"),
+		KafkaKey:     "key",
+		definition.Attr: map[string]interface{}{
+			"CustomKeywordField": "keyword",
+			"CustomIntField":     nil,
+			"CustomDoubleField":  1.5,
+		},
+	}, doc)
+	require.Len(t, invalidFields, 2)
+	require.Equal(t, ErrUnregisteredField, invalidFields["Unregistered"])
+	require.Error(t, invalidFields["CustomIntField"])
+}
+
+func TestIsMappingConflict(t *testing.T) {
+	require.True(t, IsMappingConflict(&GenericBulkResponseItem{
+		Status: 400,
+		Error:  map[string]interface{}{"type": "mapper_parsing_exception", "reason": "failed to parse field [CustomIntField]"},
+	}))
+	require.True(t, IsMappingConflict(&GenericBulkResponseItem{
+		Status: 400,
+		Error:  map[string]interface{}{"type": "strict_dynamic_mapping_exception"},
+	}))
+	require.False(t, IsMappingConflict(&GenericBulkResponseItem{
+		Status: 400,
+		Error:  map[string]interface{}{"type": "action_request_validation_exception"},
+	}))
+	require.False(t, IsMappingConflict(&GenericBulkResponseItem{
+		Status: 429,
+		Error:  map[string]interface{}{"type": "mapper_parsing_exception"},
+	}))
+}
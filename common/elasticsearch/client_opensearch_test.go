@@ -0,0 +1,47 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package elasticsearch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common/log/loggerimpl"
+)
+
+func TestOpenSearchClient(t *testing.T) {
+	server, requests := newRecordingServer(t, map[string]string{
+		"/test-index/_search": `{"took":1,"timed_out":false,"hits":{"total":{"value":0,"relation":"eq"},"hits":[]}}`,
+	})
+	defer server.Close()
+
+	client, err := NewOpenSearchClient(newTestClientConfig(t, server), loggerimpl.NewNopLogger())
+	require.NoError(t, err)
+
+	_, err = client.SearchRaw(context.Background(), "test-index", `{"query":{"range":{"CloseTime":{"from":"1","include_lower":false}}}}`)
+	require.NoError(t, err)
+	request := <-requests
+	require.Equal(t, "/test-index/_search", request.path)
+	require.Equal(t, "application/json", request.contentType)
+	require.JSONEq(t, `{"query":{"range":{"CloseTime":{"gt":"1"}}}}`, request.body)
+}
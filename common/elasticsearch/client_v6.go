@@ -171,6 +171,101 @@ func (c *elasticV6) CreateIndex(ctx context.Context, index string) error {
 	return err
 }
 
+func (c *elasticV6) DeleteIndex(ctx context.Context, index string) error {
+	_, err := c.client.DeleteIndex(index).Do(ctx)
+	return err
+}
+
+func (c *elasticV6) GetMapping(ctx context.Context, index, root string) (map[string]string, error) {
+	resp, err := c.client.GetMapping().Index(index).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	indexMapping, ok := resp[index].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("mapping of index %v not found", index)
+	}
+	mappings, _ := indexMapping["mappings"].(map[string]interface{})
+	mapping, _ := mappings[esDocType].(map[string]interface{})
+	return getMappingFieldTypes(mapping, root), nil
+}
+
+func (c *elasticV6) GetAliasIndices(ctx context.Context, alias string) (map[string]bool, error) {
+	resp, err := c.client.Aliases().Alias(alias).Do(ctx)
+	if err != nil {
+		if c.IsNotFoundError(err) {
+			return map[string]bool{}, nil
+		}
+		return nil, err
+	}
+	indices := make(map[string]bool)
+	for index, result := range resp.Indices {
+		for _, aliasResult := range result.Aliases {
+			if aliasResult.AliasName == alias {
+				indices[index] = aliasResult.IsWriteIndex
+			}
+		}
+	}
+	return indices, nil
+}
+
+func (c *elasticV6) UpdateAliases(ctx context.Context, actions []*AliasAction) error {
+	service := c.client.Alias()
+	for _, action := range actions {
+		if action.Remove {
+			service.Action(elastic.NewAliasRemoveAction(action.Alias).Index(action.Index))
+		} else {
+			service.Action(elastic.NewAliasAddAction(action.Alias).Index(action.Index).IsWriteIndex(action.IsWriteIndex))
+		}
+	}
+	_, err := service.Do(ctx)
+	return err
+}
+
+func (c *elasticV6) RolloverIndex(ctx context.Context, request *RolloverIndexRequest) (*RolloverIndexResponse, error) {
+	resp, err := c.client.RolloverIndex(request.Alias).
+		NewIndex(request.NewIndex).
+		Conditions(getRolloverConditions(request)).
+		DryRun(request.DryRun).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &RolloverIndexResponse{
+		OldIndex:   resp.OldIndex,
+		NewIndex:   resp.NewIndex,
+		RolledOver: resp.RolledOver,
+		Conditions: resp.Conditions,
+	}, nil
+}
+
+func (c *elasticV6) ReindexAsync(ctx context.Context, source, dest string, script *ReindexScript) (string, error) {
+	reindex := c.client.Reindex().
+		Source(elastic.NewReindexSource().Index(source)).
+		Destination(elastic.NewReindexDestination().Index(dest).VersionType(versionTypeExternal)).
+		Conflicts("proceed")
+	if script != nil {
+		reindex = reindex.Script(elastic.NewScript(script.Source).Lang("painless").Params(script.Params))
+	}
+	resp, err := reindex.DoAsync(ctx)
+	if err != nil {
+		return "", err
+	}
+	return resp.TaskId, nil
+}
+
+func (c *elasticV6) GetTask(ctx context.Context, taskID string) (*TaskStatus, error) {
+	resp, err := c.client.TasksGetTask().TaskId(taskID).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var status interface{}
+	if resp.Task != nil {
+		status = resp.Task.Status
+	}
+	return getTaskStatus(resp.Completed, status)
+}
+
 func (c *elasticV6) CountByQuery(ctx context.Context, index, query string) (int64, error) {
 	return c.client.Count(index).BodyString(query).Do(ctx)
 }
@@ -337,7 +432,9 @@ func (v *v6BulkProcessor) RetrieveKafkaKey(request GenericBulkableRequest, logge
 			// must be bug in code and bad deployment, check processor that add es requests
 			panic("_id not found in request opMap")
 		}
-		key, _ = k.(string)
+		docID, _ := k.(string)
+		index, _ := opMap["_index"].(string)
+		key = GenerateDeleteKey(index, docID)
 	}
 	return key
 }
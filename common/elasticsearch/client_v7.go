@@ -55,6 +55,9 @@ type (
 		client     *elastic.Client
 		logger     log.Logger
 		serializer p.PayloadSerializer
+		// typeless is set for servers which removed mapping types, documents are written
+		// without type and query DSL deprecated by them is rewritten
+		typeless bool
 	}
 
 	// searchParametersV7 holds all required and optional parameters for executing a search
@@ -86,6 +89,17 @@ func NewV7Client(
 	logger log.Logger,
 	clientOptFuncs ...elastic.ClientOptionFunc,
 ) (GenericClient, error) {
+	return newV7Client(connectConfig, logger, nil, clientOptFuncs...)
+}
+
+// newV7Client returns a client of servers with the REST API of ElasticSearch 7,
+// wrapTransport is applied to the HTTP transport if set
+func newV7Client(
+	connectConfig *config.ElasticSearchConfig,
+	logger log.Logger,
+	wrapTransport func(http.RoundTripper) http.RoundTripper,
+	clientOptFuncs ...elastic.ClientOptionFunc,
+) (*elasticV7, error) {
 	clientOptFuncs = append(clientOptFuncs,
 		elastic.SetURL(connectConfig.URL.String()),
 		elastic.SetRetrier(elastic.NewBackoffRetrier(elastic.NewExponentialBackoff(128*time.Millisecond, 513*time.Millisecond))),
@@ -97,30 +111,42 @@ func NewV7Client(
 	if connectConfig.DisableHealthCheck {
 		clientOptFuncs = append(clientOptFuncs, elastic.SetHealthcheck(false))
 	}
+	var httpClient *http.Client
 	if connectConfig.AWSSigning.Enable {
 		if err := config.CheckAWSSigningConfig(connectConfig.AWSSigning); err != nil {
 			return nil, err
 		}
-		var signingClient *http.Client
 		var err error
 		if connectConfig.AWSSigning.EnvironmentCredential != nil {
-			signingClient, err = buildSigningHTTPClientFromEnvironmentCredentialV7(*connectConfig.AWSSigning.EnvironmentCredential)
+			httpClient, err = buildSigningHTTPClientFromEnvironmentCredentialV7(*connectConfig.AWSSigning.EnvironmentCredential)
 		} else {
-			signingClient, err = buildSigningHTTPClientFromStaticCredentialV7(*connectConfig.AWSSigning.StaticCredential)
+			httpClient, err = buildSigningHTTPClientFromStaticCredentialV7(*connectConfig.AWSSigning.StaticCredential)
 		}
 		if err != nil {
 			return nil, err
 		}
-		clientOptFuncs = append(clientOptFuncs, elastic.SetHttpClient(signingClient))
 	}
 	if connectConfig.TLS.Enabled {
-		var tlsClient *http.Client
 		var err error
-		tlsClient, err = buildTLSHTTPClient(connectConfig.TLS)
+		httpClient, err = buildTLSHTTPClient(connectConfig.TLS)
 		if err != nil {
 			return nil, err
 		}
-		clientOptFuncs = append(clientOptFuncs, elastic.SetHttpClient(tlsClient))
+	}
+	if wrapTransport != nil {
+		wrappedClient := http.Client{}
+		if httpClient != nil {
+			wrappedClient = *httpClient
+		}
+		transport := wrappedClient.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		wrappedClient.Transport = wrapTransport(transport)
+		httpClient = &wrappedClient
+	}
+	if httpClient != nil {
+		clientOptFuncs = append(clientOptFuncs, elastic.SetHttpClient(httpClient))
 	}
 	client, err := elastic.NewClient(clientOptFuncs...)
 	if err != nil {
@@ -170,7 +196,105 @@ func (c *elasticV7) CreateIndex(ctx context.Context, index string) error {
 	return err
 }
 
+func (c *elasticV7) DeleteIndex(ctx context.Context, index string) error {
+	_, err := c.client.DeleteIndex(index).Do(ctx)
+	return err
+}
+
+func (c *elasticV7) GetMapping(ctx context.Context, index, root string) (map[string]string, error) {
+	resp, err := c.client.GetMapping().Index(index).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	indexMapping, ok := resp[index].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("mapping of index %v not found", index)
+	}
+	mapping, _ := indexMapping["mappings"].(map[string]interface{})
+	return getMappingFieldTypes(mapping, root), nil
+}
+
+func (c *elasticV7) GetAliasIndices(ctx context.Context, alias string) (map[string]bool, error) {
+	resp, err := c.client.Aliases().Alias(alias).Do(ctx)
+	if err != nil {
+		if c.IsNotFoundError(err) {
+			return map[string]bool{}, nil
+		}
+		return nil, err
+	}
+	indices := make(map[string]bool)
+	for index, result := range resp.Indices {
+		for _, aliasResult := range result.Aliases {
+			if aliasResult.AliasName == alias {
+				indices[index] = aliasResult.IsWriteIndex
+			}
+		}
+	}
+	return indices, nil
+}
+
+func (c *elasticV7) UpdateAliases(ctx context.Context, actions []*AliasAction) error {
+	service := c.client.Alias()
+	for _, action := range actions {
+		if action.Remove {
+			service.Action(elastic.NewAliasRemoveAction(action.Alias).Index(action.Index))
+		} else {
+			service.Action(elastic.NewAliasAddAction(action.Alias).Index(action.Index).IsWriteIndex(action.IsWriteIndex))
+		}
+	}
+	_, err := service.Do(ctx)
+	return err
+}
+
+func (c *elasticV7) RolloverIndex(ctx context.Context, request *RolloverIndexRequest) (*RolloverIndexResponse, error) {
+	resp, err := c.client.RolloverIndex(request.Alias).
+		NewIndex(request.NewIndex).
+		Conditions(getRolloverConditions(request)).
+		DryRun(request.DryRun).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &RolloverIndexResponse{
+		OldIndex:   resp.OldIndex,
+		NewIndex:   resp.NewIndex,
+		RolledOver: resp.RolledOver,
+		Conditions: resp.Conditions,
+	}, nil
+}
+
+func (c *elasticV7) ReindexAsync(ctx context.Context, source, dest string, script *ReindexScript) (string, error) {
+	reindex := c.client.Reindex().
+		Source(elastic.NewReindexSource().Index(source)).
+		Destination(elastic.NewReindexDestination().Index(dest).VersionType(versionTypeExternal)).
+		Conflicts("proceed")
+	if script != nil {
+		reindex = reindex.Script(elastic.NewScript(script.Source).Lang("painless").Params(script.Params))
+	}
+	resp, err := reindex.DoAsync(ctx)
+	if err != nil {
+		return "", err
+	}
+	return resp.TaskId, nil
+}
+
+func (c *elasticV7) GetTask(ctx context.Context, taskID string) (*TaskStatus, error) {
+	resp, err := c.client.TasksGetTask().TaskId(taskID).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var status interface{}
+	if resp.Task != nil {
+		status = resp.Task.Status
+	}
+	return getTaskStatus(resp.Completed, status)
+}
+
 func (c *elasticV7) CountByQuery(ctx context.Context, index, query string) (int64, error) {
+	query, err := c.getQuery(query)
+	if err != nil {
+		return 0, err
+	}
 	return c.client.Count(index).BodyString(query).Do(ctx)
 }
 
@@ -336,7 +460,9 @@ func (v *v7BulkProcessor) RetrieveKafkaKey(request GenericBulkableRequest, logge
 			// must be bug in code and bad deployment, check processor that add es requests
 			panic("_id not found in request opMap")
 		}
-		key, _ = k.(string)
+		docID, _ := k.(string)
+		index, _ := opMap["_index"].(string)
+		key = GenerateDeleteKey(index, docID)
 	}
 	return key
 }
@@ -431,26 +557,37 @@ func fromV7ToGenericBulkableRequests(requests []elastic.BulkableRequest) []Gener
 }
 
 func (c *elasticV7) search(ctx context.Context, p *searchParametersV7) (*elastic.SearchResult, error) {
-	searchService := c.client.Search(p.Index).
+	searchSource := elastic.NewSearchSource().
 		Query(p.Query).
 		From(p.From).
 		SortBy(p.Sorter...)
 
 	if p.PageSize != 0 {
-		searchService.Size(p.PageSize)
+		searchSource.Size(p.PageSize)
 	}
 
 	if len(p.SearchAfter) != 0 {
-		searchService.SearchAfter(p.SearchAfter...)
+		searchSource.SearchAfter(p.SearchAfter...)
 	}
 
-	return searchService.Do(ctx)
+	if !c.typeless {
+		return c.client.Search(p.Index).SearchSource(searchSource).Do(ctx)
+	}
+	source, err := searchSource.Source()
+	if err != nil {
+		return nil, err
+	}
+	query, err := json.Marshal(source)
+	if err != nil {
+		return nil, err
+	}
+	return c.searchWithDSL(ctx, p.Index, string(query))
 }
 
 func (c *elasticV7) SearchRaw(ctx context.Context, index string, query string) (*RawResponse, error) {
 	// There's slight differences between the v6 and v7 response preventing us to move
 	// this to a common function
-	esResult, err := c.client.Search(index).Source(query).Do(ctx)
+	esResult, err := c.searchWithDSL(ctx, index, query)
 	if err != nil {
 		return nil, err
 	}
@@ -485,6 +622,10 @@ func (c *elasticV7) SearchRaw(ctx context.Context, index string, query string) (
 }
 
 func (c *elasticV7) searchWithDSL(ctx context.Context, index, query string) (*elastic.SearchResult, error) {
+	query, err := c.getQuery(query)
+	if err != nil {
+		return nil, err
+	}
 	return c.client.Search(index).Source(query).Do(ctx)
 }
 
@@ -499,13 +640,26 @@ func (c *elasticV7) scroll(ctx context.Context, scrollID string) (
 func (c *elasticV7) scrollFirstPage(ctx context.Context, index, query string) (
 	*elastic.SearchResult, *elastic.ScrollService, error) {
 
+	query, err := c.getQuery(query)
+	if err != nil {
+		return nil, nil, err
+	}
 	scrollService := elastic.NewScrollService(c.client)
 	result, err := scrollService.Index(index).Body(query).Do(ctx)
 	return result, scrollService, err
 }
 
+// getQuery returns the query DSL to send, rewriting range queries deprecated by servers without mapping types
+func (c *elasticV7) getQuery(query string) (string, error) {
+	if !c.typeless {
+		return query, nil
+	}
+	return normalizeRangeQueries(query)
+}
+
 type v7BulkProcessor struct {
 	processor *elastic.BulkProcessor
+	typeless  bool
 }
 
 func (v *v7BulkProcessor) Start(ctx context.Context) error {
@@ -521,19 +675,23 @@ func (v *v7BulkProcessor) Close() error {
 }
 
 func (v *v7BulkProcessor) Add(request *GenericBulkableAddRequest) {
+	docType := request.Type
+	if v.typeless {
+		docType = ""
+	}
 	var req elastic.BulkableRequest
 	switch request.RequestType {
 	case BulkableDeleteRequest:
 		req = elastic.NewBulkDeleteRequest().
 			Index(request.Index).
-			Type(request.Type).
+			Type(docType).
 			Id(request.ID).
 			VersionType(request.VersionType).
 			Version(request.Version)
 	case BulkableIndexRequest:
 		req = elastic.NewBulkIndexRequest().
 			Index(request.Index).
-			Type(request.Type).
+			Type(docType).
 			Id(request.ID).
 			VersionType(request.VersionType).
 			Version(request.Version).
@@ -544,7 +702,7 @@ func (v *v7BulkProcessor) Add(request *GenericBulkableAddRequest) {
 		req = elastic.NewBulkIndexRequest().
 			OpType("create").
 			Index(request.Index).
-			Type(request.Type).
+			Type(docType).
 			Id(request.ID).
 			VersionType("internal").
 			Doc(request.Doc)
@@ -572,6 +730,7 @@ func (c *elasticV7) runBulkProcessor(ctx context.Context, p *bulkProcessorParame
 	}
 	return &v7BulkProcessor{
 		processor: processor,
+		typeless:  c.typeless,
 	}, nil
 }
 
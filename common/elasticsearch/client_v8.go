@@ -0,0 +1,72 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package elasticsearch
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/uber/cadence/common/config"
+	"github.com/uber/cadence/common/log"
+)
+
+const (
+	v7CompatibleJSON   = "application/vnd.elasticsearch+json;compatible-with=7"
+	v7CompatibleNDJSON = "application/vnd.elasticsearch+x-ndjson;compatible-with=7"
+)
+
+type (
+	// v7CompatibleTransport requests the REST API of ElasticSearch 7 from ElasticSearch 8
+	v7CompatibleTransport struct {
+		base http.RoundTripper
+	}
+)
+
+// NewV8Client returns a new implementation of GenericClient for ElasticSearch 8.
+// Requests are sent with REST API compatibility headers of version 7 and without mapping types.
+func NewV8Client(
+	connectConfig *config.ElasticSearchConfig,
+	logger log.Logger,
+) (GenericClient, error) {
+	client, err := newV7Client(connectConfig, logger, NewV7CompatibleTransport)
+	if err != nil {
+		return nil, err
+	}
+	client.typeless = true
+	return client, nil
+}
+
+// NewV7CompatibleTransport returns a transport requesting the REST API of ElasticSearch 7 from ElasticSearch 8
+func NewV7CompatibleTransport(base http.RoundTripper) http.RoundTripper {
+	return &v7CompatibleTransport{base: base}
+}
+
+func (t *v7CompatibleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrip must not modify the request
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept", v7CompatibleJSON)
+	if contentType := req.Header.Get("Content-Type"); strings.Contains(contentType, "ndjson") {
+		req.Header.Set("Content-Type", v7CompatibleNDJSON)
+	} else if contentType != "" {
+		req.Header.Set("Content-Type", v7CompatibleJSON)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package elasticsearch
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common/config"
+	"github.com/uber/cadence/common/log/loggerimpl"
+)
+
+type recordedRequest struct {
+	path        string
+	accept      string
+	contentType string
+	body        string
+}
+
+func newRecordingServer(t *testing.T, responses map[string]string) (*httptest.Server, chan recordedRequest) {
+	requests := make(chan recordedRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		requests <- recordedRequest{
+			path:        r.URL.Path,
+			accept:      r.Header.Get("Accept"),
+			contentType: r.Header.Get("Content-Type"),
+			body:        string(body),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(responses[r.URL.Path]))
+	}))
+	return server, requests
+}
+
+func newTestClientConfig(t *testing.T, server *httptest.Server) *config.ElasticSearchConfig {
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	return &config.ElasticSearchConfig{
+		URL:                *serverURL,
+		DisableSniff:       true,
+		DisableHealthCheck: true,
+	}
+}
+
+func TestV8Client(t *testing.T) {
+	server, requests := newRecordingServer(t, map[string]string{
+		"/test-index/_count": `{"count":3}`,
+		"/_bulk":             `{"took":1,"errors":false,"items":[{"index":{"_index":"test-index","_id":"wid~rid","status":201}}]}`,
+	})
+	defer server.Close()
+
+	client, err := NewV8Client(newTestClientConfig(t, server), loggerimpl.NewNopLogger())
+	require.NoError(t, err)
+
+	count, err := client.CountByQuery(context.Background(), "test-index", `{"query":{"range":{"StartTime":{"from":"1","to":"2"}}}}`)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), count)
+	request := <-requests
+	require.Equal(t, "/test-index/_count", request.path)
+	require.Equal(t, v7CompatibleJSON, request.accept)
+	require.Equal(t, v7CompatibleJSON, request.contentType)
+	require.JSONEq(t, `{"query":{"range":{"StartTime":{"gte":"1","lte":"2"}}}}`, request.body)
+
+	processor, err := client.RunBulkProcessor(context.Background(), &BulkProcessorParameters{
+		Name:          "test",
+		NumOfWorkers:  1,
+		BulkActions:   1,
+		BulkSize:      -1,
+		FlushInterval: time.Minute,
+		BeforeFunc:    func(int64, []GenericBulkableRequest) {},
+		AfterFunc:     func(int64, []GenericBulkableRequest, *GenericBulkResponse, *GenericError) {},
+	})
+	require.NoError(t, err)
+	defer processor.Close()
+	processor.Add(&GenericBulkableAddRequest{
+		Index:       "test-index",
+		Type:        esDocType,
+		ID:          "wid~rid",
+		VersionType: versionTypeExternal,
+		Version:     1,
+		RequestType: BulkableIndexRequest,
+		Doc:         map[string]interface{}{"WorkflowID": "wid"},
+	})
+	select {
+	case request = <-requests:
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "bulk request not sent")
+	}
+	require.Equal(t, "/_bulk", request.path)
+	require.Equal(t, v7CompatibleJSON, request.accept)
+	require.Equal(t, v7CompatibleNDJSON, request.contentType)
+	require.NotContains(t, request.body, `"_type"`)
+}
@@ -21,7 +21,10 @@
 package elasticsearch
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/uber/cadence/common/config"
@@ -67,3 +70,126 @@ func GetESDocDelimiter() string {
 func GenerateDocID(wid, rid string) string {
 	return wid + esDocIDDelimiter + rid
 }
+
+// GenerateDeleteKey returns the key of a delete request, as a document may be deleted from several indices
+func GenerateDeleteKey(index, docID string) string {
+	return index + esDocIDDelimiter + docID
+}
+
+// getMappingFieldTypes returns the types of the fields in mapping properties, or in properties of the nested object root
+func getMappingFieldTypes(mapping map[string]interface{}, root string) map[string]string {
+	properties, _ := mapping["properties"].(map[string]interface{})
+	if root != "" {
+		rootMapping, _ := properties[root].(map[string]interface{})
+		properties, _ = rootMapping["properties"].(map[string]interface{})
+	}
+	fieldTypes := make(map[string]string, len(properties))
+	for field, value := range properties {
+		fieldMapping, _ := value.(map[string]interface{})
+		if valueType, ok := fieldMapping["type"].(string); ok {
+			fieldTypes[field] = valueType
+		}
+	}
+	return fieldTypes
+}
+
+// getRolloverConditions returns the conditions of rollover request in ElasticSearch format
+func getRolloverConditions(request *RolloverIndexRequest) map[string]interface{} {
+	conditions := make(map[string]interface{})
+	if request.MaxAge > 0 {
+		conditions["max_age"] = fmt.Sprintf("%ds", int64(request.MaxAge/time.Second))
+	}
+	if request.MaxSize != "" {
+		conditions["max_size"] = request.MaxSize
+	}
+	if request.MaxDocs > 0 {
+		conditions["max_docs"] = request.MaxDocs
+	}
+	return conditions
+}
+
+// getTaskStatus decodes the status of a reindex task
+func getTaskStatus(completed bool, status interface{}) (*TaskStatus, error) {
+	result := &TaskStatus{}
+	if status != nil {
+		data, err := json.Marshal(status)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, result); err != nil {
+			return nil, err
+		}
+	}
+	result.Completed = completed
+	return result, nil
+}
+
+// normalizeRangeQueries rewrites the deprecated from, to, include_lower and include_upper parameters
+// of range queries, as generated by elasticsql and olivere range queries, to gt, gte, lt and lte
+func normalizeRangeQueries(query string) (string, error) {
+	if !strings.Contains(query, `"range"`) {
+		return query, nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(query))
+	decoder.UseNumber() // keep int64 values precise
+	var dsl interface{}
+	if err := decoder.Decode(&dsl); err != nil {
+		return "", err
+	}
+	normalizeRangeQueriesInValue(dsl)
+	result, err := json.Marshal(dsl)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+func normalizeRangeQueriesInValue(value interface{}) {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			normalizeRangeQueriesInValue(item)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			if fields, ok := item.(map[string]interface{}); ok && key == "range" {
+				for _, params := range fields {
+					if params, ok := params.(map[string]interface{}); ok {
+						normalizeRangeParams(params)
+					}
+				}
+				continue
+			}
+			normalizeRangeQueriesInValue(item)
+		}
+	}
+}
+
+func normalizeRangeParams(params map[string]interface{}) {
+	includeLower, includeUpper := true, true
+	if include, ok := params["include_lower"].(bool); ok {
+		includeLower = include
+	}
+	if include, ok := params["include_upper"].(bool); ok {
+		includeUpper = include
+	}
+	delete(params, "include_lower")
+	delete(params, "include_upper")
+
+	if from, ok := params["from"]; ok {
+		delete(params, "from")
+		if from != nil && includeLower {
+			params["gte"] = from
+		} else if from != nil {
+			params["gt"] = from
+		}
+	}
+	if to, ok := params["to"]; ok {
+		delete(params, "to")
+		if to != nil && includeUpper {
+			params["lte"] = to
+		} else if to != nil {
+			params["lt"] = to
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package elasticsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeRangeQueries(t *testing.T) {
+	tests := map[string]struct {
+		query    string
+		expected string
+	}{
+		"no range": {
+			query:    `{"query":{"match_phrase":{"WorkflowID":{"query":"wid"}}}}`,
+			expected: `{"query":{"match_phrase":{"WorkflowID":{"query":"wid"}}}}`,
+		},
+		"inclusive": {
+			query:    `{"query":{"range":{"StartTime":{"from":"1","to":"2"}}}}`,
+			expected: `{"query":{"range":{"StartTime":{"gte":"1","lte":"2"}}}}`,
+		},
+		"exclusive": {
+			query:    `{"query":{"range":{"StartTime":{"from":1,"include_lower":false,"include_upper":false,"to":9223372036854775807}}}}`,
+			expected: `{"query":{"range":{"StartTime":{"gt":1,"lt":9223372036854775807}}}}`,
+		},
+		"unbounded": {
+			query:    `{"query":{"range":{"CloseTime":{"from":null,"include_lower":true,"include_upper":false,"to":10}}}}`,
+			expected: `{"query":{"range":{"CloseTime":{"lt":10}}}}`,
+		},
+		"nested": {
+			query:    `{"query":{"bool":{"must":[{"range":{"ExecutionTime":{"gt":"0"}}},{"range":{"StartTime":{"from":"1"}}}]}},"size":10}`,
+			expected: `{"query":{"bool":{"must":[{"range":{"ExecutionTime":{"gt":"0"}}},{"range":{"StartTime":{"gte":"1"}}}]}},"size":10}`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			query, err := normalizeRangeQueries(test.query)
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, query)
+		})
+	}
+
+	_, err := normalizeRangeQueries(`{"range":`)
+	require.Error(t, err)
+}
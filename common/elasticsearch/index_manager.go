@@ -0,0 +1,414 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/definition"
+)
+
+const (
+	openWorkflowsQuery = `{"query":{"bool":{"must":{"exists":{"field":"StartTime"}},"must_not":{"exists":{"field":"CloseStatus"}}}}}`
+	maxCloseTimeQuery  = `{"size":0,"aggs":{"maxCloseTime":{"max":{"field":"CloseTime"}}}}`
+	maxCloseTimeAgg    = "maxCloseTime"
+
+	// searchAttributeMigrationScript moves or drops custom search attributes of a document,
+	// values already stored under the new name are kept
+	searchAttributeMigrationScript = `if (ctx._source.Attr != null) {
+  for (m in params.migrations) {
+    if (ctx._source.Attr.containsKey(m.from)) {
+      def val = ctx._source.Attr.remove(m.from);
+      if (m.to != null && !ctx._source.Attr.containsKey(m.to)) {
+        ctx._source.Attr[m.to] = val;
+      }
+    }
+  }
+}`
+)
+
+type (
+	// IndexManager manages the time-based visibility indices behind a read alias
+	IndexManager struct {
+		client     GenericClient
+		alias      string
+		timeSource clock.TimeSource
+	}
+
+	// IndexInfo is a managed index with its aliases
+	IndexInfo struct {
+		ManagedIndex
+		// IsWriteIndex is true for the index receiving rollover
+		IsWriteIndex bool
+		// IsReindexing is true for the index filled by an online reindex
+		IsReindexing bool
+	}
+
+	// SearchAttributeMigration moves the value of a custom search attribute to another one when documents
+	// are reindexed, e.g. after renaming the search attribute. The value is dropped if To is empty.
+	SearchAttributeMigration struct {
+		From string
+		To   string
+	}
+
+	// RolloverConditions are the conditions to roll over the write index, zero values are not checked
+	RolloverConditions struct {
+		MaxAge  time.Duration
+		MaxSize string
+		MaxDocs int64
+	}
+)
+
+// NewIndexManager returns a manager of the indices behind given read alias
+func NewIndexManager(client GenericClient, alias string) *IndexManager {
+	return &IndexManager{
+		client:     client,
+		alias:      alias,
+		timeSource: clock.NewRealTimeSource(),
+	}
+}
+
+// Init creates the first managed index with the read and write aliases. Indices which were used
+// before indices were managed can be added to the read alias, they keep the workflows started before
+// the first managed index and are deleted by retention like managed indices.
+func (m *IndexManager) Init(ctx context.Context, existingIndices []string) (ManagedIndex, error) {
+	indices, err := m.client.GetAliasIndices(ctx, m.alias)
+	if err != nil {
+		return ManagedIndex{}, err
+	}
+	if len(indices) != 0 {
+		return ManagedIndex{}, fmt.Errorf("alias %v already exists", m.alias)
+	}
+
+	index := NewManagedIndex(m.alias, m.timeSource.Now(), 1)
+	if len(existingIndices) != 0 {
+		// workflows keep being written to existing indices until all writers know the managed index
+		index = NewManagedIndex(m.alias, m.timeSource.Now().Add(ManagedIndexRoutingDelay), 1)
+	}
+	if err := m.client.CreateIndex(ctx, index.Name); err != nil {
+		return ManagedIndex{}, err
+	}
+	actions := []*AliasAction{
+		{Index: index.Name, Alias: m.alias},
+		{Index: index.Name, Alias: GetWriteAlias(m.alias), IsWriteIndex: true},
+	}
+	for _, existing := range existingIndices {
+		if err := m.copyAttrMapping(ctx, existing, index.Name); err != nil {
+			return ManagedIndex{}, err
+		}
+		actions = append(actions, &AliasAction{Index: existing, Alias: m.alias})
+	}
+	return index, m.client.UpdateAliases(ctx, actions)
+}
+
+// ListIndices returns the indices behind the read alias and the indices being reindexed, sorted by start time
+func (m *IndexManager) ListIndices(ctx context.Context) ([]IndexInfo, error) {
+	readIndices, err := m.client.GetAliasIndices(ctx, m.alias)
+	if err != nil {
+		return nil, err
+	}
+	writeIndices, err := m.client.GetAliasIndices(ctx, GetWriteAlias(m.alias))
+	if err != nil {
+		return nil, err
+	}
+	reindexIndices, err := m.client.GetAliasIndices(ctx, GetReindexAlias(m.alias))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]struct{})
+	for name := range readIndices {
+		names[name] = struct{}{}
+	}
+	for name := range reindexIndices {
+		names[name] = struct{}{}
+	}
+	indices := make([]ManagedIndex, 0, len(names))
+	for name := range names {
+		indices = append(indices, ParseManagedIndex(m.alias, name))
+	}
+	SortManagedIndices(indices)
+
+	result := make([]IndexInfo, 0, len(indices))
+	for _, index := range indices {
+		_, isReindexing := reindexIndices[index.Name]
+		result = append(result, IndexInfo{
+			ManagedIndex: index,
+			IsWriteIndex: isWriteIndex(writeIndices, index.Name),
+			IsReindexing: isReindexing,
+		})
+	}
+	return result, nil
+}
+
+// Rollover creates a new write index if any of the conditions is met by the current write index.
+// Workflows are routed to the new index after ManagedIndexRoutingDelay, so the mapping of custom search
+// attributes is copied to it and all writers know it before it receives documents.
+func (m *IndexManager) Rollover(ctx context.Context, conditions RolloverConditions, dryRun bool) (*RolloverIndexResponse, error) {
+	writeIndex, err := m.getWriteIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// repair a rollover which failed after the new index was created
+	if err := m.ensureReadable(ctx, writeIndex); err != nil {
+		return nil, err
+	}
+
+	newIndex := NewManagedIndex(m.alias, m.timeSource.Now().Add(ManagedIndexRoutingDelay), 1)
+	resp, err := m.client.RolloverIndex(ctx, &RolloverIndexRequest{
+		Alias:    GetWriteAlias(m.alias),
+		NewIndex: newIndex.Name,
+		MaxAge:   conditions.MaxAge,
+		MaxSize:  conditions.MaxSize,
+		MaxDocs:  conditions.MaxDocs,
+		DryRun:   dryRun,
+	})
+	if err != nil || !resp.RolledOver || dryRun {
+		return resp, err
+	}
+
+	if err := m.copyAttrMapping(ctx, writeIndex, newIndex.Name); err != nil {
+		return nil, err
+	}
+	return resp, m.ensureReadable(ctx, newIndex.Name)
+}
+
+// GetExpiredIndices returns the indices which can be deleted, as all their workflows closed before retention.
+// The index of the latest workflows is never expired.
+func (m *IndexManager) GetExpiredIndices(ctx context.Context, retention time.Duration) ([]string, error) {
+	indices, err := m.ListIndices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var routed []IndexInfo
+	for _, index := range indices {
+		if !index.IsReindexing {
+			routed = append(routed, index)
+		}
+	}
+	now := m.timeSource.Now()
+	var expired []string
+	for i := 0; i < len(routed)-1; i++ {
+		if routed[i+1].StartTime.After(now) {
+			// workflows are still routed to the index
+			break
+		}
+		isExpired, err := m.isExpired(ctx, routed[i].Name, now.Add(-retention))
+		if err != nil {
+			return nil, err
+		}
+		if isExpired {
+			expired = append(expired, routed[i].Name)
+		}
+	}
+	return expired, nil
+}
+
+// DeleteIndex deletes a managed index
+func (m *IndexManager) DeleteIndex(ctx context.Context, index string) error {
+	return m.client.DeleteIndex(ctx, index)
+}
+
+// StartReindex creates the index which replaces given index and adds it to the reindex alias,
+// so documents of the index are written to both indices. The documents written before have to be
+// copied by Backfill after all writers know the new index.
+func (m *IndexManager) StartReindex(ctx context.Context, index string) (ManagedIndex, error) {
+	readIndices, err := m.client.GetAliasIndices(ctx, m.alias)
+	if err != nil {
+		return ManagedIndex{}, err
+	}
+	if _, ok := readIndices[index]; !ok {
+		return ManagedIndex{}, fmt.Errorf("index %v is not behind alias %v", index, m.alias)
+	}
+
+	dest := ParseManagedIndex(m.alias, index).NextGeneration(m.alias)
+	if err := m.client.CreateIndex(ctx, dest.Name); err != nil {
+		return ManagedIndex{}, err
+	}
+	if err := m.copyAttrMapping(ctx, index, dest.Name); err != nil {
+		return ManagedIndex{}, err
+	}
+	return dest, m.client.UpdateAliases(ctx, []*AliasAction{
+		{Index: dest.Name, Alias: GetReindexAlias(m.alias)},
+	})
+}
+
+// Backfill starts to copy the documents of given index to the index replacing it and returns the task ID.
+// Documents are copied with their version, so documents written to both indices are not overwritten.
+// Search attribute migrations are applied to the copied documents.
+func (m *IndexManager) Backfill(ctx context.Context, index string, migrations []SearchAttributeMigration) (string, error) {
+	dest, err := m.getReindexDest(ctx, index)
+	if err != nil {
+		return "", err
+	}
+	return m.client.ReindexAsync(ctx, index, dest, newSearchAttributeMigrationScript(migrations))
+}
+
+// newSearchAttributeMigrationScript returns the reindex script applying the migrations, or nil if there is none
+func newSearchAttributeMigrationScript(migrations []SearchAttributeMigration) *ReindexScript {
+	if len(migrations) == 0 {
+		return nil
+	}
+	params := make([]interface{}, 0, len(migrations))
+	for _, migration := range migrations {
+		param := map[string]interface{}{"from": migration.From}
+		if len(migration.To) != 0 {
+			param["to"] = migration.To
+		}
+		params = append(params, param)
+	}
+	return &ReindexScript{
+		Source: searchAttributeMigrationScript,
+		Params: map[string]interface{}{"migrations": params},
+	}
+}
+
+// GetTask returns the status of a backfill
+func (m *IndexManager) GetTask(ctx context.Context, taskID string) (*TaskStatus, error) {
+	return m.client.GetTask(ctx, taskID)
+}
+
+// SwapReindex replaces given index with the index filled by reindex in the read and write aliases atomically.
+// Given index is no longer written to after writers refresh their indices and can then be deleted.
+func (m *IndexManager) SwapReindex(ctx context.Context, index string) (string, error) {
+	dest, err := m.getReindexDest(ctx, index)
+	if err != nil {
+		return "", err
+	}
+	writeIndices, err := m.client.GetAliasIndices(ctx, GetWriteAlias(m.alias))
+	if err != nil {
+		return "", err
+	}
+
+	actions := []*AliasAction{
+		{Index: dest, Alias: m.alias},
+		{Index: index, Alias: m.alias, Remove: true},
+		{Index: dest, Alias: GetReindexAlias(m.alias), Remove: true},
+	}
+	if isWriteIndex(writeIndices, index) {
+		actions = append(actions, &AliasAction{Index: dest, Alias: GetWriteAlias(m.alias), IsWriteIndex: true})
+	}
+	if _, ok := writeIndices[index]; ok {
+		actions = append(actions, &AliasAction{Index: index, Alias: GetWriteAlias(m.alias), Remove: true})
+	}
+	return dest, m.client.UpdateAliases(ctx, actions)
+}
+
+func (m *IndexManager) getWriteIndex(ctx context.Context) (string, error) {
+	writeIndices, err := m.client.GetAliasIndices(ctx, GetWriteAlias(m.alias))
+	if err != nil {
+		return "", err
+	}
+	for name := range writeIndices {
+		if isWriteIndex(writeIndices, name) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no write index found for alias %v", GetWriteAlias(m.alias))
+}
+
+func (m *IndexManager) getReindexDest(ctx context.Context, index string) (string, error) {
+	reindexIndices, err := m.client.GetAliasIndices(ctx, GetReindexAlias(m.alias))
+	if err != nil {
+		return "", err
+	}
+	source := ParseManagedIndex(m.alias, index)
+	for name := range reindexIndices {
+		if dest := ParseManagedIndex(m.alias, name); dest.StartTime.Equal(source.StartTime) && dest.Name != index {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no reindex of index %v found", index)
+}
+
+func (m *IndexManager) ensureReadable(ctx context.Context, index string) error {
+	readIndices, err := m.client.GetAliasIndices(ctx, m.alias)
+	if err != nil {
+		return err
+	}
+	if _, ok := readIndices[index]; ok {
+		return nil
+	}
+	return m.client.UpdateAliases(ctx, []*AliasAction{{Index: index, Alias: m.alias}})
+}
+
+// copyAttrMapping adds the custom search attributes of source index which are not in the index template to dest index
+func (m *IndexManager) copyAttrMapping(ctx context.Context, source, dest string) error {
+	sourceAttrs, err := m.client.GetMapping(ctx, source, definition.Attr)
+	if err != nil {
+		return err
+	}
+	destAttrs, err := m.client.GetMapping(ctx, dest, definition.Attr)
+	if err != nil {
+		return err
+	}
+	for key, valueType := range sourceAttrs {
+		if _, ok := destAttrs[key]; ok {
+			continue
+		}
+		if err := m.client.PutMapping(ctx, dest, definition.Attr, key, valueType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isExpired returns true if the index has no open workflow and all its workflows closed before given time
+func (m *IndexManager) isExpired(ctx context.Context, index string, closedBefore time.Time) (bool, error) {
+	openCount, err := m.client.CountByQuery(ctx, index, openWorkflowsQuery)
+	if err != nil {
+		return false, err
+	}
+	if openCount > 0 {
+		return false, nil
+	}
+
+	resp, err := m.client.SearchRaw(ctx, index, maxCloseTimeQuery)
+	if err != nil {
+		return false, err
+	}
+	var agg struct {
+		Value *float64 `json:"value"`
+	}
+	if data, ok := resp.Aggregations[maxCloseTimeAgg]; ok {
+		if err := json.Unmarshal(data, &agg); err != nil {
+			return false, err
+		}
+	}
+	if agg.Value == nil {
+		// index is empty
+		return true, nil
+	}
+	return int64(*agg.Value) < closedBefore.UnixNano(), nil
+}
+
+// isWriteIndex returns true if given index receives the rollover of the write alias.
+// Indices rolled over stay behind the write alias, so the only index is the write index
+// unless the write index is flagged.
+func isWriteIndex(writeIndices map[string]bool, index string) bool {
+	isWrite, ok := writeIndices[index]
+	return ok && (isWrite || len(writeIndices) == 1)
+}
@@ -0,0 +1,307 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/definition"
+)
+
+type (
+	// fakeAliasClient is a GenericClient keeping indices, aliases and mappings in memory
+	fakeAliasClient struct {
+		GenericClient
+		mappings     map[string]map[string]string
+		aliases      map[string]map[string]bool
+		openCounts   map[string]int64
+		maxCloseTime map[string]int64
+		reindexed    map[string]*ReindexScript
+	}
+)
+
+func newFakeAliasClient() *fakeAliasClient {
+	return &fakeAliasClient{
+		mappings:     make(map[string]map[string]string),
+		aliases:      make(map[string]map[string]bool),
+		openCounts:   make(map[string]int64),
+		maxCloseTime: make(map[string]int64),
+		reindexed:    make(map[string]*ReindexScript),
+	}
+}
+
+func (c *fakeAliasClient) CreateIndex(_ context.Context, index string) error {
+	if _, ok := c.mappings[index]; ok {
+		return fmt.Errorf("index %v already exists", index)
+	}
+	c.mappings[index] = make(map[string]string)
+	return nil
+}
+
+func (c *fakeAliasClient) DeleteIndex(_ context.Context, index string) error {
+	delete(c.mappings, index)
+	for _, indices := range c.aliases {
+		delete(indices, index)
+	}
+	return nil
+}
+
+func (c *fakeAliasClient) GetMapping(_ context.Context, index, _ string) (map[string]string, error) {
+	return c.mappings[index], nil
+}
+
+func (c *fakeAliasClient) PutMapping(_ context.Context, index, _, key, valueType string) error {
+	c.mappings[index][key] = valueType
+	return nil
+}
+
+func (c *fakeAliasClient) GetAliasIndices(_ context.Context, alias string) (map[string]bool, error) {
+	indices := make(map[string]bool)
+	for index, isWriteIndex := range c.aliases[alias] {
+		indices[index] = isWriteIndex
+	}
+	return indices, nil
+}
+
+func (c *fakeAliasClient) UpdateAliases(_ context.Context, actions []*AliasAction) error {
+	for _, action := range actions {
+		if _, ok := c.mappings[action.Index]; !ok {
+			return fmt.Errorf("index %v not found", action.Index)
+		}
+		if action.Remove {
+			delete(c.aliases[action.Alias], action.Index)
+			continue
+		}
+		if c.aliases[action.Alias] == nil {
+			c.aliases[action.Alias] = make(map[string]bool)
+		}
+		c.aliases[action.Alias][action.Index] = action.IsWriteIndex
+	}
+	return nil
+}
+
+func (c *fakeAliasClient) RolloverIndex(ctx context.Context, request *RolloverIndexRequest) (*RolloverIndexResponse, error) {
+	var oldIndex string
+	for index, isWriteIndex := range c.aliases[request.Alias] {
+		if isWriteIndex {
+			oldIndex = index
+		}
+	}
+	resp := &RolloverIndexResponse{OldIndex: oldIndex, NewIndex: request.NewIndex, RolledOver: !request.DryRun}
+	if request.DryRun {
+		return resp, nil
+	}
+	if err := c.CreateIndex(ctx, request.NewIndex); err != nil {
+		return nil, err
+	}
+	c.aliases[request.Alias][oldIndex] = false
+	c.aliases[request.Alias][request.NewIndex] = true
+	return resp, nil
+}
+
+func (c *fakeAliasClient) ReindexAsync(_ context.Context, source, dest string, script *ReindexScript) (string, error) {
+	if _, ok := c.mappings[dest]; !ok {
+		return "", errors.New("index not found")
+	}
+	c.reindexed[source] = script
+	return "task-" + source, nil
+}
+
+func (c *fakeAliasClient) CountByQuery(_ context.Context, index, _ string) (int64, error) {
+	return c.openCounts[index], nil
+}
+
+func (c *fakeAliasClient) SearchRaw(_ context.Context, index, _ string) (*RawResponse, error) {
+	resp := &RawResponse{Aggregations: map[string]json.RawMessage{maxCloseTimeAgg: json.RawMessage(`{"value":null}`)}}
+	if closeTime, ok := c.maxCloseTime[index]; ok {
+		resp.Aggregations[maxCloseTimeAgg] = json.RawMessage(fmt.Sprintf(`{"value":%d}`, closeTime))
+	}
+	return resp, nil
+}
+
+func newTestIndexManager(client GenericClient, now time.Time) (*IndexManager, *clock.EventTimeSource) {
+	timeSource := clock.NewEventTimeSource()
+	timeSource.Update(now)
+	manager := NewIndexManager(client, "alias")
+	manager.timeSource = timeSource
+	return manager, timeSource
+}
+
+func TestIndexManagerInitAndRollover(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 3, 4, 0, 0, 0, 0, time.UTC)
+	client := newFakeAliasClient()
+	client.mappings["legacy"] = map[string]string{"CustomKeywordField": "keyword"}
+	manager, timeSource := newTestIndexManager(client, now)
+
+	first, err := manager.Init(ctx, []string{"legacy"})
+	require.NoError(t, err)
+	require.Equal(t, NewManagedIndex("alias", now.Add(ManagedIndexRoutingDelay), 1), first)
+	require.Equal(t, map[string]bool{"legacy": false, first.Name: false}, client.aliases["alias"])
+	require.Equal(t, map[string]bool{first.Name: true}, client.aliases["alias-write"])
+	require.Equal(t, map[string]string{"CustomKeywordField": "keyword"}, client.mappings[first.Name])
+
+	_, err = manager.Init(ctx, nil)
+	require.Error(t, err)
+
+	resp, err := manager.Rollover(ctx, RolloverConditions{MaxAge: time.Hour}, true)
+	require.NoError(t, err)
+	require.False(t, resp.RolledOver)
+	require.Len(t, client.mappings, 2)
+
+	client.mappings[first.Name]["CustomIntField"] = "long"
+	timeSource.Update(now.Add(time.Hour))
+	resp, err = manager.Rollover(ctx, RolloverConditions{MaxAge: time.Hour}, false)
+	require.NoError(t, err)
+	require.True(t, resp.RolledOver)
+	second := NewManagedIndex("alias", now.Add(time.Hour+ManagedIndexRoutingDelay), 1)
+	require.Equal(t, second.Name, resp.NewIndex)
+	require.Equal(t, map[string]string{"CustomKeywordField": "keyword", "CustomIntField": "long"}, client.mappings[second.Name])
+
+	indices, err := manager.ListIndices(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []IndexInfo{
+		{ManagedIndex: ManagedIndex{Name: "legacy"}},
+		{ManagedIndex: first},
+		{ManagedIndex: second, IsWriteIndex: true},
+	}, indices)
+}
+
+func TestIndexManagerGetExpiredIndices(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 3, 4, 0, 0, 0, 0, time.UTC)
+	retention := 7 * 24 * time.Hour
+	client := newFakeAliasClient()
+	manager, timeSource := newTestIndexManager(client, now)
+
+	first, err := manager.Init(ctx, nil)
+	require.NoError(t, err)
+	timeSource.Update(now.Add(24 * time.Hour))
+	resp, err := manager.Rollover(ctx, RolloverConditions{}, false)
+	require.NoError(t, err)
+
+	// workflows are still routed to the first index
+	client.maxCloseTime[first.Name] = now.UnixNano()
+	expired, err := manager.GetExpiredIndices(ctx, 0)
+	require.NoError(t, err)
+	require.Empty(t, expired)
+
+	timeSource.Update(now.Add(30 * 24 * time.Hour))
+	expired, err = manager.GetExpiredIndices(ctx, retention)
+	require.NoError(t, err)
+	require.Equal(t, []string{first.Name}, expired)
+
+	client.openCounts[first.Name] = 1
+	expired, err = manager.GetExpiredIndices(ctx, retention)
+	require.NoError(t, err)
+	require.Empty(t, expired)
+
+	client.openCounts[first.Name] = 0
+	client.maxCloseTime[first.Name] = now.Add(25 * 24 * time.Hour).UnixNano()
+	expired, err = manager.GetExpiredIndices(ctx, retention)
+	require.NoError(t, err)
+	require.Empty(t, expired)
+
+	// index of the latest workflows is never expired
+	require.NoError(t, manager.DeleteIndex(ctx, first.Name))
+	expired, err = manager.GetExpiredIndices(ctx, retention)
+	require.NoError(t, err)
+	require.Empty(t, expired)
+	require.Equal(t, map[string]bool{resp.NewIndex: false}, client.aliases["alias"])
+}
+
+func TestIndexManagerReindex(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 3, 4, 0, 0, 0, 0, time.UTC)
+	client := newFakeAliasClient()
+	manager, timeSource := newTestIndexManager(client, now)
+
+	first, err := manager.Init(ctx, nil)
+	require.NoError(t, err)
+	client.mappings[first.Name][definition.CustomStringField] = "text"
+	timeSource.Update(now.Add(time.Hour))
+	_, err = manager.Rollover(ctx, RolloverConditions{}, false)
+	require.NoError(t, err)
+	second := NewManagedIndex("alias", now.Add(time.Hour+ManagedIndexRoutingDelay), 1)
+
+	_, err = manager.StartReindex(ctx, "unknown")
+	require.Error(t, err)
+
+	for _, index := range []ManagedIndex{first, second} {
+		dest, err := manager.StartReindex(ctx, index.Name)
+		require.NoError(t, err)
+		require.Equal(t, index.NextGeneration("alias"), dest)
+		require.Equal(t, client.mappings[index.Name], client.mappings[dest.Name])
+		require.Contains(t, client.aliases["alias-reindex"], dest.Name)
+
+		taskID, err := manager.Backfill(ctx, index.Name, nil)
+		require.NoError(t, err)
+		require.Equal(t, "task-"+index.Name, taskID)
+		require.Nil(t, client.reindexed[index.Name])
+
+		swapped, err := manager.SwapReindex(ctx, index.Name)
+		require.NoError(t, err)
+		require.Equal(t, dest.Name, swapped)
+		require.NotContains(t, client.aliases["alias"], index.Name)
+		require.Contains(t, client.aliases["alias"], dest.Name)
+		require.Empty(t, client.aliases["alias-reindex"])
+	}
+	// only the reindexed write index receives rollover
+	require.Equal(t, map[string]bool{second.NextGeneration("alias").Name: true}, client.aliases["alias-write"])
+
+	_, err = manager.SwapReindex(ctx, first.Name)
+	require.Error(t, err)
+}
+
+func TestIndexManagerBackfillSearchAttributeMigrations(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2022, 3, 4, 0, 0, 0, 0, time.UTC)
+	client := newFakeAliasClient()
+	manager, _ := newTestIndexManager(client, now)
+
+	index, err := manager.Init(ctx, nil)
+	require.NoError(t, err)
+	_, err = manager.StartReindex(ctx, index.Name)
+	require.NoError(t, err)
+
+	_, err = manager.Backfill(ctx, index.Name, []SearchAttributeMigration{
+		{From: "OldField", To: "NewField"},
+		{From: "RemovedField"},
+	})
+	require.NoError(t, err)
+	script := client.reindexed[index.Name]
+	require.NotNil(t, script)
+	require.Equal(t, searchAttributeMigrationScript, script.Source)
+	require.Equal(t, map[string]interface{}{
+		"migrations": []interface{}{
+			map[string]interface{}{"from": "OldField", "to": "NewField"},
+			map[string]interface{}{"from": "RemovedField"},
+		},
+	}, script.Params)
+}
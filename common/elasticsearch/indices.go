@@ -0,0 +1,308 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package elasticsearch
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/config"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+)
+
+// Managed visibility indices are time-based indices behind aliases. The configured visibility index name
+// is the read alias of all indices. Each index holds the workflows started from the time in its name
+// until the time of the next index, so all documents of a workflow are written to the same index.
+//
+// The write alias points to the newest index and is moved by rollover. The reindex alias points to indices
+// which are filled by an online reindex, documents are written to them in addition to the index they replace.
+const (
+	writeAliasSuffix   = "-write"
+	reindexAliasSuffix = "-reindex"
+
+	managedIndexTimeLayout = "20060102150405"
+
+	// ManagedIndexRefreshInterval is how often writers refresh the indices behind the aliases
+	ManagedIndexRefreshInterval = 30 * time.Second
+	// ManagedIndexRoutingDelay is how long after creation a managed index starts to receive workflows.
+	// It is longer than the refresh interval so all writers know an index before routing to it.
+	ManagedIndexRoutingDelay = 5 * time.Minute
+)
+
+type (
+	// ManagedIndex is a time-based visibility index
+	ManagedIndex struct {
+		Name string
+		// StartTime is the start time of the first workflow in the index,
+		// it is zero for indices not created by Cadence, e.g. an index used before indices were managed.
+		StartTime time.Time
+		// Generation is increased by each reindex of the workflows started from StartTime
+		Generation int
+	}
+
+	// IndexResolver resolves the indices visibility documents are written to
+	IndexResolver interface {
+		common.Daemon
+		// GetWriteIndices returns the indices the document of a workflow started at startTime
+		// in unix nanoseconds is written to. Start time is now if it is unknown.
+		GetWriteIndices(startTime int64) []string
+		// GetDeleteIndices returns the indices a document is deleted from,
+		// as the start time of deleted workflows is not known.
+		GetDeleteIndices() []string
+	}
+
+	staticIndexResolver struct {
+		indices []string
+	}
+
+	managedIndexResolver struct {
+		status     int32
+		client     GenericClient
+		alias      string
+		logger     log.Logger
+		routes     atomic.Value // []indexRoute
+		shutdownCh chan struct{}
+		shutdownWG sync.WaitGroup
+	}
+
+	// indexRoute is the indices of workflows started from start in unix nanoseconds
+	indexRoute struct {
+		start   int64
+		indices []string
+	}
+)
+
+// GetWriteAlias returns the write alias of the managed indices behind the given read alias
+func GetWriteAlias(alias string) string {
+	return alias + writeAliasSuffix
+}
+
+// GetReindexAlias returns the alias of indices filled by online reindex for the given read alias
+func GetReindexAlias(alias string) string {
+	return alias + reindexAliasSuffix
+}
+
+// NewManagedIndex returns the index of workflows started from startTime behind the given read alias
+func NewManagedIndex(alias string, startTime time.Time, generation int) ManagedIndex {
+	return ManagedIndex{
+		Name:       fmt.Sprintf("%v-%v-%03d", alias, startTime.UTC().Format(managedIndexTimeLayout), generation),
+		StartTime:  startTime.UTC().Truncate(time.Second),
+		Generation: generation,
+	}
+}
+
+// ParseManagedIndex parses the name of an index behind the given read alias.
+// Indices which are not named by Cadence are returned with zero start time and generation.
+func ParseManagedIndex(alias, name string) ManagedIndex {
+	index := ManagedIndex{Name: name}
+	parts := strings.Split(strings.TrimPrefix(name, alias+"-"), "-")
+	if !strings.HasPrefix(name, alias+"-") || len(parts) != 2 {
+		return index
+	}
+	startTime, err := time.Parse(managedIndexTimeLayout, parts[0])
+	if err != nil {
+		return index
+	}
+	generation, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return index
+	}
+	index.StartTime = startTime
+	index.Generation = generation
+	return index
+}
+
+// NextGeneration returns the index which replaces this index in an online reindex
+func (m ManagedIndex) NextGeneration(alias string) ManagedIndex {
+	return NewManagedIndex(alias, m.StartTime, m.Generation+1)
+}
+
+// SortManagedIndices sorts indices by start time and generation
+func SortManagedIndices(indices []ManagedIndex) {
+	sort.Slice(indices, func(i, j int) bool {
+		if !indices[i].StartTime.Equal(indices[j].StartTime) {
+			return indices[i].StartTime.Before(indices[j].StartTime)
+		}
+		return indices[i].Generation < indices[j].Generation
+	})
+}
+
+// NewIndexResolver returns the resolver of the visibility indices in given config.
+// Documents are written to the visibility index unless indices are managed.
+func NewIndexResolver(
+	client GenericClient,
+	esConfig *config.ElasticSearchConfig,
+	logger log.Logger,
+) IndexResolver {
+	if !esConfig.ManagedIndices {
+		return &staticIndexResolver{indices: []string{esConfig.GetVisibilityIndex()}}
+	}
+	return &managedIndexResolver{
+		status:     common.DaemonStatusInitialized,
+		client:     client,
+		alias:      esConfig.GetVisibilityIndex(),
+		logger:     logger,
+		shutdownCh: make(chan struct{}),
+	}
+}
+
+func (r *staticIndexResolver) Start() {}
+
+func (r *staticIndexResolver) Stop() {}
+
+func (r *staticIndexResolver) GetWriteIndices(_ int64) []string {
+	return r.indices
+}
+
+func (r *staticIndexResolver) GetDeleteIndices() []string {
+	return r.indices
+}
+
+func (r *managedIndexResolver) Start() {
+	if !atomic.CompareAndSwapInt32(&r.status, common.DaemonStatusInitialized, common.DaemonStatusStarted) {
+		return
+	}
+	if err := r.refresh(); err != nil {
+		r.logger.Error("Failed to refresh managed visibility indices.", tag.Error(err))
+	}
+	r.shutdownWG.Add(1)
+	go r.refreshLoop()
+}
+
+func (r *managedIndexResolver) Stop() {
+	if !atomic.CompareAndSwapInt32(&r.status, common.DaemonStatusStarted, common.DaemonStatusStopped) {
+		return
+	}
+	close(r.shutdownCh)
+	r.shutdownWG.Wait()
+}
+
+func (r *managedIndexResolver) GetWriteIndices(startTime int64) []string {
+	routes := r.getRoutes()
+	if len(routes) == 0 {
+		// indices are unknown until first refresh succeeds
+		return []string{GetWriteAlias(r.alias)}
+	}
+	if startTime <= 0 {
+		startTime = time.Now().UnixNano()
+	}
+	route := routes[0]
+	for _, rt := range routes[1:] {
+		if rt.start > startTime {
+			break
+		}
+		route = rt
+	}
+	return route.indices
+}
+
+func (r *managedIndexResolver) GetDeleteIndices() []string {
+	routes := r.getRoutes()
+	if len(routes) == 0 {
+		return []string{GetWriteAlias(r.alias)}
+	}
+	var indices []string
+	for _, route := range routes {
+		indices = append(indices, route.indices...)
+	}
+	return indices
+}
+
+func (r *managedIndexResolver) getRoutes() []indexRoute {
+	routes, _ := r.routes.Load().([]indexRoute)
+	return routes
+}
+
+func (r *managedIndexResolver) refreshLoop() {
+	defer r.shutdownWG.Done()
+
+	ticker := time.NewTicker(ManagedIndexRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.shutdownCh:
+			return
+		case <-ticker.C:
+			if err := r.refresh(); err != nil {
+				r.logger.Error("Failed to refresh managed visibility indices.", tag.Error(err))
+			}
+		}
+	}
+}
+
+func (r *managedIndexResolver) refresh() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ManagedIndexRefreshInterval)
+	defer cancel()
+
+	readIndices, err := r.client.GetAliasIndices(ctx, r.alias)
+	if err != nil {
+		return err
+	}
+	reindexIndices, err := r.client.GetAliasIndices(ctx, GetReindexAlias(r.alias))
+	if err != nil {
+		return err
+	}
+	if len(readIndices) == 0 {
+		return fmt.Errorf("no index found for alias %v", r.alias)
+	}
+	r.routes.Store(getIndexRoutes(r.alias, readIndices, reindexIndices))
+	return nil
+}
+
+// getIndexRoutes returns the routes sorted by start time. Workflows are written to the newest generation
+// of the read alias indices with their start time, and to the reindex alias index with same start time.
+func getIndexRoutes(alias string, readIndices, reindexIndices map[string]bool) []indexRoute {
+	primary := make(map[time.Time]ManagedIndex)
+	for name := range readIndices {
+		index := ParseManagedIndex(alias, name)
+		if current, ok := primary[index.StartTime]; !ok || index.Generation > current.Generation {
+			primary[index.StartTime] = index
+		}
+	}
+
+	routes := make([]indexRoute, 0, len(primary))
+	for startTime, index := range primary {
+		route := indexRoute{start: startTime.UnixNano(), indices: []string{index.Name}}
+		if startTime.IsZero() {
+			route.start = 0
+		}
+		for name := range reindexIndices {
+			dest := ParseManagedIndex(alias, name)
+			if dest.StartTime.Equal(startTime) && dest.Name != index.Name {
+				route.indices = append(route.indices, dest.Name)
+			}
+		}
+		sort.Strings(route.indices[1:])
+		routes = append(routes, route)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].start < routes[j].start
+	})
+	return routes
+}
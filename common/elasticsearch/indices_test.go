@@ -0,0 +1,92 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package elasticsearch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagedIndexName(t *testing.T) {
+	startTime := time.Date(2022, 3, 4, 5, 6, 7, 8, time.UTC)
+	index := NewManagedIndex("cadence-visibility", startTime, 2)
+	require.Equal(t, "cadence-visibility-20220304050607-002", index.Name)
+	require.Equal(t, index, ParseManagedIndex("cadence-visibility", index.Name))
+
+	next := index.NextGeneration("cadence-visibility")
+	require.Equal(t, "cadence-visibility-20220304050607-003", next.Name)
+	require.True(t, next.StartTime.Equal(index.StartTime))
+
+	for _, name := range []string{
+		"cadence-visibility",
+		"cadence-visibility-dev",
+		"cadence-visibility-2022-001",
+		"cadence-visibility-20220304050607-abc",
+		"other-20220304050607-001",
+	} {
+		require.Equal(t, ManagedIndex{Name: name}, ParseManagedIndex("cadence-visibility", name), name)
+	}
+}
+
+func TestSortManagedIndices(t *testing.T) {
+	startTime := time.Now()
+	first := NewManagedIndex("alias", startTime, 1)
+	reindexed := NewManagedIndex("alias", startTime, 2)
+	second := NewManagedIndex("alias", startTime.Add(time.Hour), 1)
+	legacy := ParseManagedIndex("alias", "legacy")
+
+	indices := []ManagedIndex{second, reindexed, legacy, first}
+	SortManagedIndices(indices)
+	require.Equal(t, []ManagedIndex{legacy, first, reindexed, second}, indices)
+}
+
+func TestManagedIndexResolver(t *testing.T) {
+	startTime := time.Date(2022, 3, 4, 0, 0, 0, 0, time.UTC)
+	first := NewManagedIndex("alias", startTime, 1)
+	reindexed := NewManagedIndex("alias", startTime, 2)
+	second := NewManagedIndex("alias", startTime.Add(time.Hour), 1)
+
+	resolver := &managedIndexResolver{alias: "alias"}
+	require.Equal(t, []string{"alias-write"}, resolver.GetWriteIndices(startTime.UnixNano()))
+	require.Equal(t, []string{"alias-write"}, resolver.GetDeleteIndices())
+
+	resolver.routes.Store(getIndexRoutes(
+		"alias",
+		map[string]bool{"legacy": false, first.Name: false, second.Name: true},
+		map[string]bool{reindexed.Name: false},
+	))
+	require.Equal(t, []string{"legacy"}, resolver.GetWriteIndices(startTime.Add(-time.Second).UnixNano()))
+	require.Equal(t, []string{first.Name, reindexed.Name}, resolver.GetWriteIndices(startTime.UnixNano()))
+	require.Equal(t, []string{first.Name, reindexed.Name}, resolver.GetWriteIndices(startTime.Add(time.Minute).UnixNano()))
+	require.Equal(t, []string{second.Name}, resolver.GetWriteIndices(startTime.Add(time.Hour).UnixNano()))
+	require.Equal(t, []string{second.Name}, resolver.GetWriteIndices(0))
+	require.Equal(t, []string{"legacy", first.Name, reindexed.Name, second.Name}, resolver.GetDeleteIndices())
+
+	// reindexed index replaces the first index after swap
+	resolver.routes.Store(getIndexRoutes(
+		"alias",
+		map[string]bool{first.Name: false, reindexed.Name: false, second.Name: true},
+		map[string]bool{},
+	))
+	require.Equal(t, []string{reindexed.Name}, resolver.GetWriteIndices(startTime.UnixNano()))
+}
@@ -46,6 +46,10 @@ func NewGenericClient(
 		return NewV6Client(connectConfig, logger)
 	case "v7":
 		return NewV7Client(connectConfig, logger)
+	case "v8":
+		return NewV8Client(connectConfig, logger)
+	case "opensearch":
+		return NewOpenSearchClient(connectConfig, logger)
 	default:
 		return nil, fmt.Errorf("not supported ElasticSearch version: %v", connectConfig.Version)
 	}
@@ -84,6 +88,22 @@ type (
 		PutMapping(ctx context.Context, index, root, key, valueType string) error
 		// CreateIndex creates a new index
 		CreateIndex(ctx context.Context, index string) error
+		// DeleteIndex deletes an index
+		DeleteIndex(ctx context.Context, index string) error
+		// GetMapping returns the types of the fields of an index, or of the nested object root if it is not empty
+		GetMapping(ctx context.Context, index, root string) (map[string]string, error)
+		// GetAliasIndices returns the indices of an alias and whether each is the write index of the alias
+		GetAliasIndices(ctx context.Context, alias string) (map[string]bool, error)
+		// UpdateAliases applies alias actions atomically
+		UpdateAliases(ctx context.Context, actions []*AliasAction) error
+		// RolloverIndex creates a new index as the write index of an alias if any of the conditions is met
+		RolloverIndex(ctx context.Context, request *RolloverIndexRequest) (*RolloverIndexResponse, error)
+		// ReindexAsync starts to copy documents from source index to dest index and returns the task ID.
+		// Documents are copied with their external version, so newer documents in dest index are kept.
+		// The script, if not nil, is applied to every copied document.
+		ReindexAsync(ctx context.Context, source, dest string, script *ReindexScript) (string, error)
+		// GetTask returns the status of a task
+		GetTask(ctx context.Context, taskID string) (*TaskStatus, error)
 
 		IsNotFoundError(err error) bool
 	}
@@ -98,6 +118,47 @@ type (
 		MaxResultWindow int
 	}
 
+	// AliasAction adds an index to or removes an index from an alias
+	AliasAction struct {
+		Remove       bool
+		Index        string
+		Alias        string
+		IsWriteIndex bool
+	}
+
+	// RolloverIndexRequest is request for RolloverIndex, conditions with zero value are not checked
+	RolloverIndexRequest struct {
+		Alias    string
+		NewIndex string
+		MaxAge   time.Duration
+		MaxSize  string // e.g. 50gb
+		MaxDocs  int64
+		DryRun   bool
+	}
+
+	// RolloverIndexResponse is response for RolloverIndex
+	RolloverIndexResponse struct {
+		OldIndex   string
+		NewIndex   string
+		RolledOver bool
+		Conditions map[string]bool
+	}
+
+	// ReindexScript is a painless script applied to the documents copied by reindex
+	ReindexScript struct {
+		Source string
+		Params map[string]interface{}
+	}
+
+	// TaskStatus is the status of a reindex task
+	TaskStatus struct {
+		Completed        bool
+		Total            int64 `json:"total"`
+		Created          int64 `json:"created"`
+		Updated          int64 `json:"updated"`
+		VersionConflicts int64 `json:"version_conflicts"`
+	}
+
 	// GenericMatch is a match struct
 	GenericMatch struct {
 		Name string
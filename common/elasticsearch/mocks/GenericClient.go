@@ -72,6 +72,89 @@ func (_m *GenericClient) CreateIndex(ctx context.Context, index string) error {
 	return r0
 }
 
+// DeleteIndex provides a mock function with given fields: ctx, index
+func (_m *GenericClient) DeleteIndex(ctx context.Context, index string) error {
+	ret := _m.Called(ctx, index)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, index)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAliasIndices provides a mock function with given fields: ctx, alias
+func (_m *GenericClient) GetAliasIndices(ctx context.Context, alias string) (map[string]bool, error) {
+	ret := _m.Called(ctx, alias)
+
+	var r0 map[string]bool
+	if rf, ok := ret.Get(0).(func(context.Context, string) map[string]bool); ok {
+		r0 = rf(ctx, alias)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]bool)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMapping provides a mock function with given fields: ctx, index, root
+func (_m *GenericClient) GetMapping(ctx context.Context, index string, root string) (map[string]string, error) {
+	ret := _m.Called(ctx, index, root)
+
+	var r0 map[string]string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) map[string]string); ok {
+		r0 = rf(ctx, index, root)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, index, root)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTask provides a mock function with given fields: ctx, taskID
+func (_m *GenericClient) GetTask(ctx context.Context, taskID string) (*elasticsearch.TaskStatus, error) {
+	ret := _m.Called(ctx, taskID)
+
+	var r0 *elasticsearch.TaskStatus
+	if rf, ok := ret.Get(0).(func(context.Context, string) *elasticsearch.TaskStatus); ok {
+		r0 = rf(ctx, taskID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*elasticsearch.TaskStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, taskID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // IsNotFoundError provides a mock function with given fields: err
 func (_m *GenericClient) IsNotFoundError(err error) bool {
 	ret := _m.Called(err)
@@ -100,6 +183,50 @@ func (_m *GenericClient) PutMapping(ctx context.Context, index string, root stri
 	return r0
 }
 
+// ReindexAsync provides a mock function with given fields: ctx, source, dest, script
+func (_m *GenericClient) ReindexAsync(ctx context.Context, source string, dest string, script *elasticsearch.ReindexScript) (string, error) {
+	ret := _m.Called(ctx, source, dest, script)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *elasticsearch.ReindexScript) string); ok {
+		r0 = rf(ctx, source, dest, script)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *elasticsearch.ReindexScript) error); ok {
+		r1 = rf(ctx, source, dest, script)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RolloverIndex provides a mock function with given fields: ctx, request
+func (_m *GenericClient) RolloverIndex(ctx context.Context, request *elasticsearch.RolloverIndexRequest) (*elasticsearch.RolloverIndexResponse, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 *elasticsearch.RolloverIndexResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *elasticsearch.RolloverIndexRequest) *elasticsearch.RolloverIndexResponse); ok {
+		r0 = rf(ctx, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*elasticsearch.RolloverIndexResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *elasticsearch.RolloverIndexRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RunBulkProcessor provides a mock function with given fields: ctx, p
 func (_m *GenericClient) RunBulkProcessor(ctx context.Context, p *elasticsearch.BulkProcessorParameters) (elasticsearch.GenericBulkProcessor, error) {
 	ret := _m.Called(ctx, p)
@@ -236,3 +363,17 @@ func (_m *GenericClient) SearchForOneClosedExecution(ctx context.Context, index
 
 	return r0, r1
 }
+
+// UpdateAliases provides a mock function with given fields: ctx, actions
+func (_m *GenericClient) UpdateAliases(ctx context.Context, actions []*elasticsearch.AliasAction) error {
+	ret := _m.Called(ctx, actions)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*elasticsearch.AliasAction) error); ok {
+		r0 = rf(ctx, actions)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -23,25 +23,36 @@ package validator
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/xwb1989/sqlparser"
 
+	"github.com/uber/cadence/.gen/go/shared"
 	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/definition"
 	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/types"
 )
 
+const groupByHistogramFunc = "histogram"
+
 // VisibilityQueryValidator for sql query validation
 type VisibilityQueryValidator struct {
-	validSearchAttributes dynamicconfig.MapPropertyFn
+	validSearchAttributes  dynamicconfig.MapPropertyFn
+	searchAttributeAliases dynamicconfig.MapPropertyFn
 }
 
 // NewQueryValidator create VisibilityQueryValidator
-func NewQueryValidator(validSearchAttributes dynamicconfig.MapPropertyFn) *VisibilityQueryValidator {
+func NewQueryValidator(
+	validSearchAttributes dynamicconfig.MapPropertyFn,
+	searchAttributeAliases dynamicconfig.MapPropertyFn,
+) *VisibilityQueryValidator {
 	return &VisibilityQueryValidator{
-		validSearchAttributes: validSearchAttributes,
+		validSearchAttributes:  validSearchAttributes,
+		searchAttributeAliases: searchAttributeAliases,
 	}
 }
 
@@ -90,6 +101,52 @@ func (qv *VisibilityQueryValidator) ValidateQuery(whereClause string) (string, e
 	return whereClause, nil
 }
 
+// ValidateCountQuery validates a CountWorkflowExecutions query, which may end with
+// a GROUP BY clause. It returns the validated query without the GROUP BY clause,
+// and the parsed GROUP BY terms.
+func (qv *VisibilityQueryValidator) ValidateCountQuery(query string) (string, []*persistence.VisibilityGroupBy, error) {
+	query = strings.TrimSpace(query)
+	if len(query) == 0 {
+		return query, nil, nil
+	}
+
+	// IMPORTANT: This query is never executed, it is just used to parse and validate the query
+	var placeholderQuery string
+	// #nosec
+	if common.IsJustOrderByClause(query) || isJustGroupByClause(query) {
+		placeholderQuery = fmt.Sprintf("SELECT * FROM dummy %s", query)
+	} else {
+		placeholderQuery = fmt.Sprintf("SELECT * FROM dummy WHERE %s", query)
+	}
+
+	stmt, err := sqlparser.Parse(placeholderQuery)
+	if err != nil {
+		return "", nil, &types.BadRequestError{Message: "Invalid query."}
+	}
+
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok {
+		return "", nil, &types.BadRequestError{Message: "Invalid select query."}
+	}
+	if sel.Having != nil {
+		return "", nil, &types.BadRequestError{Message: "HAVING is not supported."}
+	}
+
+	groupBy, err := qv.validateGroupByExpr(sel.GroupBy)
+	if err != nil {
+		return "", nil, &types.BadRequestError{Message: err.Error()}
+	}
+
+	buf := sqlparser.NewTrackedBuffer(nil)
+	if sel.Where != nil {
+		if err := qv.validateWhereExpr(sel.Where.Expr); err != nil {
+			return "", nil, &types.BadRequestError{Message: err.Error()}
+		}
+		sel.Where.Expr.Format(buf)
+	}
+	return buf.String(), groupBy, nil
+}
+
 func (qv *VisibilityQueryValidator) validateWhereExpr(expr sqlparser.Expr) error {
 	if expr == nil {
 		return nil
@@ -135,19 +192,12 @@ func (qv *VisibilityQueryValidator) validateComparisonExpr(expr sqlparser.Expr)
 	if !ok {
 		return errors.New("invalid comparison expression")
 	}
-	colNameStr := colName.Name.String()
+	colNameStr := qv.resolveAlias(colName.Name.String())
 	if !qv.isValidSearchAttributes(colNameStr) {
-		return fmt.Errorf("invalid search attribute %q", colNameStr)
-	}
-
-	if !definition.IsSystemIndexedKey(colNameStr) { // add search attribute prefix
-		comparisonExpr.Left = &sqlparser.ColName{
-			Metadata:  colName.Metadata,
-			Name:      sqlparser.NewColIdent(definition.Attr + "." + colNameStr),
-			Qualifier: colName.Qualifier,
-		}
+		return fmt.Errorf("invalid search attribute %q", colName.Name.String())
 	}
 
+	comparisonExpr.Left = qv.rewriteColName(colName, colNameStr)
 	return nil
 }
 
@@ -157,20 +207,13 @@ func (qv *VisibilityQueryValidator) validateRangeExpr(expr sqlparser.Expr) error
 	if !ok {
 		return errors.New("invalid range expression")
 	}
-	colNameStr := colName.Name.String()
+	colNameStr := qv.resolveAlias(colName.Name.String())
 
 	if !qv.isValidSearchAttributes(colNameStr) {
-		return fmt.Errorf("invalid search attribute %q", colNameStr)
-	}
-
-	if !definition.IsSystemIndexedKey(colNameStr) { // add search attribute prefix
-		rangeCond.Left = &sqlparser.ColName{
-			Metadata:  colName.Metadata,
-			Name:      sqlparser.NewColIdent(definition.Attr + "." + colNameStr),
-			Qualifier: colName.Qualifier,
-		}
+		return fmt.Errorf("invalid search attribute %q", colName.Name.String())
 	}
 
+	rangeCond.Left = qv.rewriteColName(colName, colNameStr)
 	return nil
 }
 
@@ -180,15 +223,9 @@ func (qv *VisibilityQueryValidator) validateOrderByExpr(orderBy sqlparser.OrderB
 		if !ok {
 			return errors.New("invalid order by expression")
 		}
-		colNameStr := colName.Name.String()
+		colNameStr := qv.resolveAlias(colName.Name.String())
 		if qv.isValidSearchAttributes(colNameStr) {
-			if !definition.IsSystemIndexedKey(colNameStr) { // add search attribute prefix
-				orderByExpr.Expr = &sqlparser.ColName{
-					Metadata:  colName.Metadata,
-					Name:      sqlparser.NewColIdent(definition.Attr + "." + colNameStr),
-					Qualifier: colName.Qualifier,
-				}
-			}
+			orderByExpr.Expr = qv.rewriteColName(colName, colNameStr)
 		} else {
 			return errors.New("invalid order by attribute")
 		}
@@ -196,6 +233,143 @@ func (qv *VisibilityQueryValidator) validateOrderByExpr(orderBy sqlparser.OrderB
 	return nil
 }
 
+func (qv *VisibilityQueryValidator) validateGroupByExpr(groupBy sqlparser.GroupBy) ([]*persistence.VisibilityGroupBy, error) {
+	if len(groupBy) == 0 {
+		return nil, nil
+	}
+
+	result := make([]*persistence.VisibilityGroupBy, 0, len(groupBy))
+	seen := make(map[string]struct{}, len(groupBy))
+	for _, expr := range groupBy {
+		var term *persistence.VisibilityGroupBy
+		var err error
+		switch expr := expr.(type) {
+		case *sqlparser.ColName:
+			term, err = qv.validateGroupByColName(expr)
+		case *sqlparser.FuncExpr:
+			term, err = qv.validateGroupByHistogram(expr)
+		default:
+			err = errors.New("invalid group by expression")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := seen[term.Key]; ok {
+			return nil, fmt.Errorf("duplicate group by attribute %q", term.Key)
+		}
+		seen[term.Key] = struct{}{}
+		result = append(result, term)
+	}
+	return result, nil
+}
+
+func (qv *VisibilityQueryValidator) validateGroupByColName(colName *sqlparser.ColName) (*persistence.VisibilityGroupBy, error) {
+	colNameStr := qv.resolveAlias(colName.Name.String())
+	if !qv.isValidSearchAttributes(colNameStr) {
+		return nil, fmt.Errorf("invalid search attribute %q", colName.Name.String())
+	}
+
+	switch colNameStr {
+	case definition.WorkflowType, definition.TaskList, definition.CloseStatus:
+	default:
+		if definition.IsSystemIndexedKey(colNameStr) || !qv.isKeywordSearchAttribute(colNameStr) {
+			return nil, fmt.Errorf("cannot group by %q, only WorkflowType, TaskList, CloseStatus and keyword search attributes are supported", colNameStr)
+		}
+	}
+	return &persistence.VisibilityGroupBy{Key: colNameStr}, nil
+}
+
+// validateGroupByHistogram validates a time histogram term, e.g. histogram(StartTime, '1h')
+func (qv *VisibilityQueryValidator) validateGroupByHistogram(funcExpr *sqlparser.FuncExpr) (*persistence.VisibilityGroupBy, error) {
+	if !funcExpr.Name.EqualString(groupByHistogramFunc) || len(funcExpr.Exprs) != 2 {
+		return nil, fmt.Errorf("invalid group by function, expected %s(<time attribute>, '<interval>')", groupByHistogramFunc)
+	}
+
+	var args []sqlparser.Expr
+	for _, selectExpr := range funcExpr.Exprs {
+		aliasedExpr, ok := selectExpr.(*sqlparser.AliasedExpr)
+		if !ok {
+			return nil, errors.New("invalid histogram argument")
+		}
+		args = append(args, aliasedExpr.Expr)
+	}
+
+	colName, ok := args[0].(*sqlparser.ColName)
+	if !ok {
+		return nil, errors.New("invalid histogram attribute")
+	}
+	colNameStr := qv.resolveAlias(colName.Name.String())
+	switch colNameStr {
+	case definition.StartTime, definition.ExecutionTime, definition.CloseTime, definition.UpdateTime:
+	default:
+		return nil, fmt.Errorf("cannot build histogram on %q, only StartTime, ExecutionTime, CloseTime and UpdateTime are supported", colNameStr)
+	}
+
+	val, ok := args[1].(*sqlparser.SQLVal)
+	if !ok || val.Type != sqlparser.StrVal {
+		return nil, errors.New("histogram interval must be a string like '1h'")
+	}
+	interval, err := parseHistogramInterval(string(val.Val))
+	if err != nil {
+		return nil, err
+	}
+	return &persistence.VisibilityGroupBy{Key: colNameStr, Interval: interval}, nil
+}
+
+// parseHistogramInterval parses a duration with an optional "d" (days) unit.
+// Intervals must be a whole number of seconds.
+func parseHistogramInterval(s string) (time.Duration, error) {
+	var interval time.Duration
+	var err error
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		var n int
+		n, err = strconv.Atoi(days)
+		interval = time.Duration(n) * 24 * time.Hour
+	} else {
+		interval, err = time.ParseDuration(s)
+	}
+	if err != nil || interval < time.Second || interval%time.Second != 0 {
+		return 0, fmt.Errorf("invalid histogram interval %q, must be a whole number of seconds like '30s', '1h' or '1d'", s)
+	}
+	return interval, nil
+}
+
+func (qv *VisibilityQueryValidator) isKeywordSearchAttribute(key string) bool {
+	switch t := qv.validSearchAttributes()[key].(type) {
+	case float64:
+		return shared.IndexedValueType(t) == shared.IndexedValueTypeKeyword
+	case int:
+		return shared.IndexedValueType(t) == shared.IndexedValueTypeKeyword
+	case shared.IndexedValueType:
+		return t == shared.IndexedValueTypeKeyword
+	default:
+		return false
+	}
+}
+
+func isJustGroupByClause(clause string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(clause)), "group by")
+}
+
+// resolveAlias returns the search attribute the key is an alias of, or the key itself
+func (qv *VisibilityQueryValidator) resolveAlias(key string) string {
+	return definition.ResolveSearchAttributeAlias(qv.searchAttributeAliases(), key)
+}
+
+// rewriteColName replaces the column with the resolved search attribute name,
+// adding the search attribute prefix for custom search attributes
+func (qv *VisibilityQueryValidator) rewriteColName(colName *sqlparser.ColName, name string) *sqlparser.ColName {
+	if !definition.IsSystemIndexedKey(name) {
+		name = definition.Attr + "." + name
+	}
+	return &sqlparser.ColName{
+		Metadata:  colName.Metadata,
+		Name:      sqlparser.NewColIdent(name),
+		Qualifier: colName.Qualifier,
+	}
+}
+
 // isValidSearchAttributes return true if key is registered
 func (qv *VisibilityQueryValidator) isValidSearchAttributes(key string) bool {
 	validAttr := qv.validSearchAttributes()
@@ -22,13 +22,20 @@ package validator
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/uber/cadence/common/definition"
 	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/persistence"
 )
 
+var testSearchAttributeAliases = dynamicconfig.GetMapPropertyFn(map[string]interface{}{
+	"OldKeywordField": definition.CustomKeywordField,
+	"Started":         definition.StartTime,
+})
+
 func TestValidateQuery(t *testing.T) {
 	tests := []struct {
 		msg       string
@@ -56,6 +63,16 @@ func TestValidateQuery(t *testing.T) {
 			query:     "WorkflowID = 'wid' and ((CustomStringField = 'custom') or CustomIntField between 1 and 10)",
 			validated: "WorkflowID = 'wid' and ((`Attr.CustomStringField` = 'custom') or `Attr.CustomIntField` between 1 and 10)",
 		},
+		{
+			msg:       "alias of custom field",
+			query:     "OldKeywordField = 'keyword' order by OldKeywordField desc",
+			validated: "`Attr.CustomKeywordField` = 'keyword' order by `Attr.CustomKeywordField` desc",
+		},
+		{
+			msg:       "alias of system field",
+			query:     "Started between 1 and 10",
+			validated: "StartTime between 1 and 10",
+		},
 		{
 			msg:   "invalid SQL",
 			query: "Invalid SQL",
@@ -131,7 +148,7 @@ func TestValidateQuery(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.msg, func(t *testing.T) {
 			validSearchAttr := dynamicconfig.GetMapPropertyFn(definition.GetDefaultIndexedKeys())
-			qv := NewQueryValidator(validSearchAttr)
+			qv := NewQueryValidator(validSearchAttr, testSearchAttributeAliases)
 			validated, err := qv.ValidateQuery(tt.query)
 			if err != nil {
 				assert.Equal(t, tt.err, err.Error())
@@ -141,3 +158,113 @@ func TestValidateQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateCountQuery(t *testing.T) {
+	tests := []struct {
+		msg       string
+		query     string
+		validated string
+		groupBy   []*persistence.VisibilityGroupBy
+		err       string
+	}{
+		{
+			msg:       "empty query",
+			query:     "",
+			validated: "",
+		},
+		{
+			msg:       "no group by",
+			query:     "WorkflowID = 'wid'",
+			validated: "WorkflowID = 'wid'",
+		},
+		{
+			msg:       "just group by",
+			query:     "GROUP BY WorkflowType",
+			validated: "",
+			groupBy:   []*persistence.VisibilityGroupBy{{Key: definition.WorkflowType}},
+		},
+		{
+			msg:       "condition + group by",
+			query:     "CloseTime = missing GROUP BY WorkflowType, TaskList",
+			validated: "CloseTime = missing",
+			groupBy: []*persistence.VisibilityGroupBy{
+				{Key: definition.WorkflowType},
+				{Key: definition.TaskList},
+			},
+		},
+		{
+			msg:       "group by keyword attribute and histogram",
+			query:     "CustomStringField = 'custom' group by CloseStatus, CustomKeywordField, histogram(StartTime, '1d')",
+			validated: "`Attr.CustomStringField` = 'custom'",
+			groupBy: []*persistence.VisibilityGroupBy{
+				{Key: definition.CloseStatus},
+				{Key: definition.CustomKeywordField},
+				{Key: definition.StartTime, Interval: 24 * time.Hour},
+			},
+		},
+		{
+			msg:       "group by alias",
+			query:     "GROUP BY OldKeywordField, histogram(Started, '1h')",
+			validated: "",
+			groupBy: []*persistence.VisibilityGroupBy{
+				{Key: definition.CustomKeywordField},
+				{Key: definition.StartTime, Interval: time.Hour},
+			},
+		},
+		{
+			msg:   "group by non keyword attribute",
+			query: "GROUP BY CustomIntField",
+			err:   `cannot group by "CustomIntField", only WorkflowType, TaskList, CloseStatus and keyword search attributes are supported`,
+		},
+		{
+			msg:   "group by invalid attribute",
+			query: "GROUP BY InvalidField",
+			err:   `invalid search attribute "InvalidField"`,
+		},
+		{
+			msg:   "duplicate group by",
+			query: "GROUP BY WorkflowType, WorkflowType",
+			err:   `duplicate group by attribute "WorkflowType"`,
+		},
+		{
+			msg:   "histogram on non time attribute",
+			query: "GROUP BY histogram(WorkflowType, '1h')",
+			err:   `cannot build histogram on "WorkflowType", only StartTime, ExecutionTime, CloseTime and UpdateTime are supported`,
+		},
+		{
+			msg:   "histogram with invalid interval",
+			query: "GROUP BY histogram(StartTime, '10ms')",
+			err:   `invalid histogram interval "10ms", must be a whole number of seconds like '30s', '1h' or '1d'`,
+		},
+		{
+			msg:   "unknown group by function",
+			query: "GROUP BY max(StartTime)",
+			err:   "invalid group by function, expected histogram(<time attribute>, '<interval>')",
+		},
+		{
+			msg:   "having",
+			query: "GROUP BY WorkflowType HAVING count(*) > 1",
+			err:   "HAVING is not supported.",
+		},
+		{
+			msg:   "invalid where clause",
+			query: "InvalidField = 'x' GROUP BY WorkflowType",
+			err:   `invalid search attribute "InvalidField"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			validSearchAttr := dynamicconfig.GetMapPropertyFn(definition.GetDefaultIndexedKeys())
+			qv := NewQueryValidator(validSearchAttr, testSearchAttributeAliases)
+			validated, groupBy, err := qv.ValidateCountQuery(tt.query)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.validated, validated)
+				assert.Equal(t, tt.groupBy, groupBy)
+			}
+		})
+	}
+}
@@ -36,6 +36,9 @@ type SearchAttributesValidator struct {
 	logger log.Logger
 
 	validSearchAttributes             dynamicconfig.MapPropertyFn
+	searchAttributeAliases            dynamicconfig.MapPropertyFn
+	deprecatedSearchAttributes        dynamicconfig.MapPropertyFn
+	domainScopedSearchAttributes      dynamicconfig.MapPropertyFn
 	searchAttributesNumberOfKeysLimit dynamicconfig.IntPropertyFnWithDomainFilter
 	searchAttributesSizeOfValueLimit  dynamicconfig.IntPropertyFnWithDomainFilter
 	searchAttributesTotalSizeLimit    dynamicconfig.IntPropertyFnWithDomainFilter
@@ -45,6 +48,9 @@ type SearchAttributesValidator struct {
 func NewSearchAttributesValidator(
 	logger log.Logger,
 	validSearchAttributes dynamicconfig.MapPropertyFn,
+	searchAttributeAliases dynamicconfig.MapPropertyFn,
+	deprecatedSearchAttributes dynamicconfig.MapPropertyFn,
+	domainScopedSearchAttributes dynamicconfig.MapPropertyFn,
 	searchAttributesNumberOfKeysLimit dynamicconfig.IntPropertyFnWithDomainFilter,
 	searchAttributesSizeOfValueLimit dynamicconfig.IntPropertyFnWithDomainFilter,
 	searchAttributesTotalSizeLimit dynamicconfig.IntPropertyFnWithDomainFilter,
@@ -52,6 +58,9 @@ func NewSearchAttributesValidator(
 	return &SearchAttributesValidator{
 		logger:                            logger,
 		validSearchAttributes:             validSearchAttributes,
+		searchAttributeAliases:            searchAttributeAliases,
+		deprecatedSearchAttributes:        deprecatedSearchAttributes,
+		domainScopedSearchAttributes:      domainScopedSearchAttributes,
 		searchAttributesNumberOfKeysLimit: searchAttributesNumberOfKeysLimit,
 		searchAttributesSizeOfValueLimit:  searchAttributesSizeOfValueLimit,
 		searchAttributesTotalSizeLimit:    searchAttributesTotalSizeLimit,
@@ -75,25 +84,40 @@ func (sv *SearchAttributesValidator) ValidateSearchAttributes(input *types.Searc
 
 	totalSize := 0
 	validAttr := sv.validSearchAttributes()
+	aliases := sv.searchAttributeAliases()
+	deprecated := sv.deprecatedSearchAttributes()
+	domainScopes := sv.domainScopedSearchAttributes()
 	for key, val := range fields {
+		// aliases are validated as the attribute they stand for
+		name := definition.ResolveSearchAttributeAlias(aliases, key)
 		// verify: key is whitelisted
-		if !sv.isValidSearchAttributesKey(validAttr, key) {
+		if !sv.isValidSearchAttributesKey(validAttr, name) {
 			sv.logger.WithTags(tag.ESKey(key), tag.WorkflowDomainName(domain)).
 				Error("invalid search attribute key")
 			return &types.BadRequestError{Message: fmt.Sprintf("%s is not a valid search attribute key", key)}
 		}
 		// verify: value has the correct type
-		if !sv.isValidSearchAttributesValue(validAttr, key, val) {
+		if !sv.isValidSearchAttributesValue(validAttr, name, val) {
 			sv.logger.WithTags(tag.ESKey(key), tag.ESValue(val), tag.WorkflowDomainName(domain)).
 				Error("invalid search attribute value")
 			return &types.BadRequestError{Message: fmt.Sprintf("%s is not a valid search attribute value for key %s", val, key)}
 		}
 		// verify: key is not system reserved
-		if definition.IsSystemIndexedKey(key) {
+		if definition.IsSystemIndexedKey(name) {
 			sv.logger.WithTags(tag.ESKey(key), tag.WorkflowDomainName(domain)).
 				Error("illegal update of system reserved attribute")
 			return &types.BadRequestError{Message: fmt.Sprintf("%s is read-only Cadence reservered attribute", key)}
 		}
+		// verify: key is allowed for the domain
+		if !definition.IsSearchAttributeAllowedForDomain(domainScopes, name, domain) {
+			sv.logger.WithTags(tag.ESKey(key), tag.WorkflowDomainName(domain)).
+				Error("search attribute is not allowed for domain")
+			return &types.BadRequestError{Message: fmt.Sprintf("%s is not allowed to be used by domain %s", key, domain)}
+		}
+		if note, ok := deprecated[name]; ok {
+			sv.logger.WithTags(tag.ESKey(key), tag.WorkflowDomainName(domain), tag.Value(note)).
+				Warn("deprecated search attribute is used")
+		}
 		// verify: size of single value <= limit
 		if len(val) > sv.searchAttributesSizeOfValueLimit(domain) {
 			sv.logger.WithTags(tag.ESKey(key), tag.Number(int64(len(val))), tag.WorkflowDomainName(domain)).
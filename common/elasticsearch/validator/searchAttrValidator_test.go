@@ -47,6 +47,9 @@ func (s *searchAttributesValidatorSuite) TestValidateSearchAttributes() {
 
 	validator := NewSearchAttributesValidator(log.NewNoop(),
 		dynamicconfig.GetMapPropertyFn(definition.GetDefaultIndexedKeys()),
+		dynamicconfig.GetMapPropertyFn(nil),
+		dynamicconfig.GetMapPropertyFn(nil),
+		dynamicconfig.GetMapPropertyFn(nil),
 		dynamicconfig.GetIntPropertyFilteredByDomain(numOfKeysLimit),
 		dynamicconfig.GetIntPropertyFilteredByDomain(sizeOfValueLimit),
 		dynamicconfig.GetIntPropertyFilteredByDomain(sizeOfTotalLimit))
@@ -119,3 +122,50 @@ func (s *searchAttributesValidatorSuite) TestValidateSearchAttributes() {
 	err = validator.ValidateSearchAttributes(attr, domain)
 	s.Equal(`total size 44 exceed limit`, err.Error())
 }
+
+func (s *searchAttributesValidatorSuite) TestValidateSearchAttributes_Lifecycle() {
+	validator := NewSearchAttributesValidator(log.NewNoop(),
+		dynamicconfig.GetMapPropertyFn(definition.GetDefaultIndexedKeys()),
+		dynamicconfig.GetMapPropertyFn(map[string]interface{}{
+			"OldKeywordField": "CustomKeywordField",
+			"OldStartTime":    "StartTime",
+		}),
+		dynamicconfig.GetMapPropertyFn(map[string]interface{}{
+			"CustomStringField": "use CustomKeywordField instead",
+		}),
+		dynamicconfig.GetMapPropertyFn(map[string]interface{}{
+			"CustomIntField": []interface{}{"domain-a", "domain-b"},
+		}),
+		dynamicconfig.GetIntPropertyFilteredByDomain(10),
+		dynamicconfig.GetIntPropertyFilteredByDomain(100),
+		dynamicconfig.GetIntPropertyFilteredByDomain(1000))
+
+	attr := &types.SearchAttributes{IndexedFields: map[string][]byte{
+		"OldKeywordField": []byte(`"keyword"`),
+	}}
+	s.NoError(validator.ValidateSearchAttributes(attr, "domain"))
+
+	attr.IndexedFields = map[string][]byte{
+		"OldKeywordField": []byte(`123`),
+	}
+	err := validator.ValidateSearchAttributes(attr, "domain")
+	s.Equal(`123 is not a valid search attribute value for key OldKeywordField`, err.Error())
+
+	attr.IndexedFields = map[string][]byte{
+		"OldStartTime": []byte(`1`),
+	}
+	err = validator.ValidateSearchAttributes(attr, "domain")
+	s.Equal(`OldStartTime is read-only Cadence reservered attribute`, err.Error())
+
+	attr.IndexedFields = map[string][]byte{
+		"CustomStringField": []byte(`"deprecated"`),
+	}
+	s.NoError(validator.ValidateSearchAttributes(attr, "domain"))
+
+	attr.IndexedFields = map[string][]byte{
+		"CustomIntField": []byte(`1`),
+	}
+	s.NoError(validator.ValidateSearchAttributes(attr, "domain-a"))
+	err = validator.ValidateSearchAttributes(attr, "domain")
+	s.Equal(`CustomIntField is not allowed to be used by domain domain`, err.Error())
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package messaging
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type (
+	// DLQMessage is published to a DLQ when a message fails to be processed,
+	// it keeps the original message together with the reason of the failure
+	DLQMessage struct {
+		Reason     string    `json:"reason"`
+		Status     int       `json:"status,omitempty"`
+		DomainID   string    `json:"domainID,omitempty"`
+		WorkflowID string    `json:"workflowID,omitempty"`
+		RunID      string    `json:"runID,omitempty"`
+		Partition  int32     `json:"partition"`
+		Offset     int64     `json:"offset"`
+		FailedAt   time.Time `json:"failedAt"`
+		Payload    []byte    `json:"payload"`
+	}
+)
+
+var errInvalidDLQMessage = errors.New("not a DLQ message")
+
+// NewDLQMessage creates the DLQ message of a message that failed to be processed
+func NewDLQMessage(msg Message, reason string) *DLQMessage {
+	return &DLQMessage{
+		Reason:    reason,
+		Partition: msg.Partition(),
+		Offset:    msg.Offset(),
+		FailedAt:  time.Now(),
+		Payload:   msg.Value(),
+	}
+}
+
+// Encode encodes the DLQ message into the value published to the DLQ
+func (m *DLQMessage) Encode() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// DecodeDLQMessage decodes a value read from a DLQ. Messages nacked by consumers
+// are published to the DLQ as is, they fail to decode with an error.
+func DecodeDLQMessage(value []byte) (*DLQMessage, error) {
+	var msg DLQMessage
+	if err := json.Unmarshal(value, &msg); err != nil {
+		return nil, errInvalidDLQMessage
+	}
+	if msg.Reason == "" || len(msg.Payload) == 0 {
+		return nil, errInvalidDLQMessage
+	}
+	return &msg, nil
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package messaging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testMessage struct {
+	value     []byte
+	partition int32
+	offset    int64
+}
+
+func (m *testMessage) Value() []byte    { return m.value }
+func (m *testMessage) Partition() int32 { return m.partition }
+func (m *testMessage) Offset() int64    { return m.offset }
+func (m *testMessage) Ack() error       { return nil }
+func (m *testMessage) Nack() error      { return nil }
+
+func TestDLQMessageEncodeDecode(t *testing.T) {
+	msg := NewDLQMessage(&testMessage{value: []byte{0x0c, 0x00}, partition: 3, offset: 42}, "mapping conflict")
+	msg.Status = 400
+	msg.WorkflowID = "wid"
+
+	value, err := msg.Encode()
+	require.NoError(t, err)
+	decoded, err := DecodeDLQMessage(value)
+	require.NoError(t, err)
+	assert.Equal(t, "mapping conflict", decoded.Reason)
+	assert.Equal(t, 400, decoded.Status)
+	assert.Equal(t, "wid", decoded.WorkflowID)
+	assert.Equal(t, int32(3), decoded.Partition)
+	assert.Equal(t, int64(42), decoded.Offset)
+	assert.Equal(t, []byte{0x0c, 0x00}, decoded.Payload)
+	assert.True(t, msg.FailedAt.Equal(decoded.FailedAt))
+}
+
+func TestDecodeDLQMessage_RawMessage(t *testing.T) {
+	_, err := DecodeDLQMessage([]byte{0x0c, 0x00, 0x01})
+	assert.Error(t, err)
+	_, err = DecodeDLQMessage([]byte(`{"workflowID":"wid"}`))
+	assert.Error(t, err)
+}
@@ -29,6 +29,8 @@ type (
 	Client interface {
 		NewConsumer(appName, consumerName string) (Consumer, error)
 		NewProducer(appName string) (Producer, error)
+		// NewDLQProducer returns a producer publishing to the DLQ of the application
+		NewDLQProducer(appName string) (Producer, error)
 	}
 
 	// Consumer is the unified interface for both internal and external kafka clients
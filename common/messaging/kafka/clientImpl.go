@@ -121,6 +121,12 @@ func (c *clientImpl) NewProducer(app string) (messaging.Producer, error) {
 	return c.newProducerByTopic(topics.Topic)
 }
 
+// NewDLQProducer is used to create a Kafka producer for the DLQ topic of the application
+func (c *clientImpl) NewDLQProducer(app string) (messaging.Producer, error) {
+	topics := c.config.GetTopicsForApplication(app)
+	return c.newProducerByTopic(topics.DLQTopic)
+}
+
 func (c *clientImpl) newProducerByTopic(topic string) (messaging.Producer, error) {
 	kafkaClusterName := c.config.GetKafkaClusterForTopic(topic)
 	brokers := c.config.GetBrokersForKafkaCluster(kafkaClusterName)
@@ -228,6 +228,8 @@ func (h *consumerHandlerImpl) ConsumeClaim(session sarama.ConsumerGroupSession,
 	// The `ConsumeClaim` itself is called within a goroutine:
 	for message := range claim.Messages() {
 		h.manager.AddMessage(message.Partition, message.Offset)
+		// the high water mark is the offset of the next message that will be produced to the partition
+		h.manager.UpdateLag(message.Partition, claim.HighWaterMarkOffset()-message.Offset-1)
 		h.msgChan <- &messageImpl{
 			saramaMsg: message,
 			session:   session,
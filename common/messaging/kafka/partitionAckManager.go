@@ -77,6 +77,25 @@ func (pam *partitionAckManager) AddMessage(partitionID int32, messageID int64) {
 	if err != nil {
 		pam.logger.Warn("potential bug when adding message to ackManager", tag.Error(err), tag.KafkaPartition(partitionID), tag.TaskID(messageID))
 	}
+	pam.updateBacklog(partitionID)
+}
+
+// UpdateLag reports the number of messages of a partition that are not read yet
+func (pam *partitionAckManager) UpdateLag(partitionID int32, lag int64) {
+	pam.RLock()
+	defer pam.RUnlock()
+	if scope, ok := pam.scopes[partitionID]; ok {
+		scope.UpdateGauge(metrics.KafkaConsumerLag, float64(lag))
+	}
+}
+
+// updateBacklog reports the number of messages of a partition that are read but not completed yet
+func (pam *partitionAckManager) updateBacklog(partitionID int32) {
+	pam.RLock()
+	defer pam.RUnlock()
+	if am, ok := pam.ackMgrs[partitionID]; ok {
+		pam.scopes[partitionID].UpdateGauge(metrics.KafkaConsumerBacklog, float64(am.GetBacklogCount()))
+	}
 }
 
 // CompleteMessage complete the message from ack/nack kafka message
@@ -96,5 +115,8 @@ func (pam *partitionAckManager) CompleteMessage(partitionID int32, messageID int
 			tag.KafkaOffset(messageID))
 		ackLevel = -1
 	}
+	if am, ok := pam.ackMgrs[partitionID]; ok {
+		pam.scopes[partitionID].UpdateGauge(metrics.KafkaConsumerBacklog, float64(am.GetBacklogCount()))
+	}
 	return ackLevel
 }
@@ -0,0 +1,137 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package persistence
+
+import (
+	"sync"
+
+	"github.com/uber/cadence/common/config"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/membership"
+	"github.com/uber/cadence/common/messaging"
+	"github.com/uber/cadence/common/metrics"
+	p "github.com/uber/cadence/common/persistence"
+)
+
+type (
+	// QueueManagerProvider returns the queue manager of the given queue type
+	QueueManagerProvider func(queueType p.QueueType) (p.QueueManager, error)
+
+	// clientImpl implements the messaging client on top of the persistence queue.
+	// Every partition of an application is stored as a separate queue type.
+	clientImpl struct {
+		sync.Mutex
+		config        *config.PersistenceMessaging
+		queueProvider QueueManagerProvider
+		queues        map[p.QueueType]p.QueueManager
+		resolver      membership.Resolver
+
+		metricsClient metrics.Client
+		logger        log.Logger
+	}
+)
+
+var _ messaging.Client = (*clientImpl)(nil)
+
+// NewPersistenceClient is used to create a messaging client backed by the persistence queue,
+// consumers spread the partitions over the worker hosts known by the resolver or read all
+// partitions when the resolver is nil
+func NewPersistenceClient(
+	cfg *config.PersistenceMessaging,
+	queueProvider QueueManagerProvider,
+	resolver membership.Resolver,
+	metricsClient metrics.Client,
+	logger log.Logger,
+) messaging.Client {
+	return &clientImpl{
+		config:        cfg,
+		queueProvider: queueProvider,
+		queues:        make(map[p.QueueType]p.QueueManager),
+		resolver:      resolver,
+		metricsClient: metricsClient,
+		logger:        logger,
+	}
+}
+
+// NewConsumer is used to create a consumer reading the partitions of the application queue owned by this host
+func (c *clientImpl) NewConsumer(app, consumerName string) (messaging.Consumer, error) {
+	queues, err := c.getQueuesForApplication(app)
+	if err != nil {
+		return nil, err
+	}
+	logger := c.logger.WithTags(tag.KafkaTopicName(app), tag.KafkaConsumerName(consumerName))
+	return newConsumer(c.config, app, queues, consumerName, c.resolver, c.metricsClient, logger), nil
+}
+
+// NewProducer is used to create a producer writing to the application queue
+func (c *clientImpl) NewProducer(app string) (messaging.Producer, error) {
+	return c.newProducer(app, false)
+}
+
+// NewDLQProducer is used to create a producer writing to the DLQ of the application queue
+func (c *clientImpl) NewDLQProducer(app string) (messaging.Producer, error) {
+	return c.newProducer(app, true)
+}
+
+func (c *clientImpl) newProducer(app string, toDLQ bool) (messaging.Producer, error) {
+	queues, err := c.getQueuesForApplication(app)
+	if err != nil {
+		return nil, err
+	}
+	producer := newProducer(queues, toDLQ, c.logger.WithTags(tag.KafkaTopicName(app)))
+	if c.metricsClient != nil {
+		c.logger.Info("Create producer with metricsClient")
+		return messaging.NewMetricProducer(producer, c.metricsClient), nil
+	}
+	return producer, nil
+}
+
+func (c *clientImpl) getQueuesForApplication(app string) ([]p.QueueManager, error) {
+	queueConfig, err := c.config.GetQueueForApplication(app)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	queues := make([]p.QueueManager, queueConfig.Partitions)
+	for partition := range queues {
+		queueType := GetQueueType(queueConfig.QueueID, partition)
+		queue, ok := c.queues[queueType]
+		if !ok {
+			queue, err = c.queueProvider(queueType)
+			if err != nil {
+				return nil, err
+			}
+			c.queues[queueType] = queue
+		}
+		queues[partition] = queue
+	}
+	return queues, nil
+}
+
+// GetQueueType returns the persistence queue type of a partition,
+// the negative of it is used by persistence as the DLQ of the partition
+func GetQueueType(queueID int, partition int) p.QueueType {
+	return p.MessagingQueueTypeBase + p.QueueType(queueID*config.MaxPersistenceMessagingPartitions+partition)
+}
@@ -0,0 +1,393 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package persistence
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/uber/cadence/.gen/go/indexer"
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/codec"
+	"github.com/uber/cadence/common/config"
+	"github.com/uber/cadence/common/log/loggerimpl"
+	"github.com/uber/cadence/common/membership"
+	"github.com/uber/cadence/common/messaging"
+	"github.com/uber/cadence/common/metrics"
+	p "github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/service"
+)
+
+type (
+	clientSuite struct {
+		suite.Suite
+
+		config *config.PersistenceMessaging
+		queues map[p.QueueType]*fakeQueue
+		client messaging.Client
+	}
+
+	// fakeQueue is an in-memory queue manager
+	fakeQueue struct {
+		sync.Mutex
+		messages    []*p.QueueMessage
+		dlq         [][]byte
+		ackLevels   map[string]int64
+		nextID      int64
+		enqueueErrs []error
+	}
+)
+
+const testApp = "visibility"
+
+func TestClientSuite(t *testing.T) {
+	suite.Run(t, new(clientSuite))
+}
+
+func (s *clientSuite) SetupTest() {
+	s.config = &config.PersistenceMessaging{
+		Applications: map[string]config.PersistenceQueue{
+			testApp: {QueueID: 1, Partitions: 2},
+		},
+		PollInterval:           10 * time.Millisecond,
+		BatchSize:              10,
+		MaxRedeliveries:        2,
+		RedeliveryBackoff:      time.Millisecond,
+		AckLevelUpdateInterval: time.Hour,
+		PurgeInterval:          time.Hour,
+	}
+	s.queues = make(map[p.QueueType]*fakeQueue)
+	s.client = NewPersistenceClient(s.config, func(queueType p.QueueType) (p.QueueManager, error) {
+		queue := newFakeQueue()
+		s.queues[queueType] = queue
+		return queue, nil
+	}, nil, metrics.NewNoopMetricsClient(), loggerimpl.NewNopLogger())
+}
+
+func (s *clientSuite) TestQueueType() {
+	s.Equal(p.MessagingQueueTypeBase+100, GetQueueType(1, 0))
+	s.Equal(p.MessagingQueueTypeBase+103, GetQueueType(1, 3))
+}
+
+func (s *clientSuite) TestUnknownApplication() {
+	_, err := s.client.NewProducer("unknown")
+	s.Error(err)
+	_, err = s.client.NewConsumer("unknown", "consumer")
+	s.Error(err)
+}
+
+func (s *clientSuite) TestPublishAndConsume() {
+	producer, err := s.client.NewProducer(testApp)
+	s.NoError(err)
+	consumer, err := s.client.NewConsumer(testApp, "consumer")
+	s.NoError(err)
+	s.Len(s.queues, 2, "producer and consumer should share the queues")
+
+	workflowIDs := []string{"wid1", "wid2", "wid3", "wid4"}
+	for _, workflowID := range workflowIDs {
+		s.NoError(producer.Publish(context.Background(), newIndexerMessage(workflowID)))
+	}
+
+	s.NoError(consumer.Start())
+	received := make(map[string]int32)
+	for range workflowIDs {
+		msg := s.receive(consumer)
+		received[s.decode(msg).GetWorkflowID()] = msg.Partition()
+		s.NoError(msg.Ack())
+	}
+	consumer.Stop()
+
+	for _, workflowID := range workflowIDs {
+		partition, ok := received[workflowID]
+		s.True(ok)
+		s.Equal(int32(common.WorkflowIDToHistoryShard(workflowID, 2)), partition)
+	}
+	for queueType, queue := range s.queues {
+		s.Equal(int64(len(queue.messages)-1), queue.ackLevels["consumer"], "queue type %v", queueType)
+	}
+
+	// a restarted consumer resumes from the persisted ack level
+	consumer, err = s.client.NewConsumer(testApp, "consumer")
+	s.NoError(err)
+	s.NoError(producer.Publish(context.Background(), newIndexerMessage("wid5")))
+	s.NoError(consumer.Start())
+	s.Equal("wid5", s.decode(s.receive(consumer)).GetWorkflowID())
+	consumer.Stop()
+}
+
+func (s *clientSuite) TestPublishRetriesConflicts() {
+	producer, err := s.client.NewProducer(testApp)
+	s.NoError(err)
+	for _, queue := range s.queues {
+		queue.enqueueErrs = []error{&p.ConditionFailedError{}}
+	}
+
+	s.NoError(producer.Publish(context.Background(), newIndexerMessage("wid")))
+	total := 0
+	for _, queue := range s.queues {
+		total += len(queue.messages)
+	}
+	s.Equal(1, total)
+}
+
+func (s *clientSuite) TestNackRedeliversThenDLQ() {
+	producer, err := s.client.NewProducer(testApp)
+	s.NoError(err)
+	consumer, err := s.client.NewConsumer(testApp, "consumer")
+	s.NoError(err)
+	s.NoError(producer.Publish(context.Background(), newIndexerMessage("wid")))
+	s.NoError(consumer.Start())
+
+	var offset int64
+	for i := 0; i <= s.config.MaxRedeliveries; i++ {
+		msg := s.receive(consumer)
+		s.Equal("wid", s.decode(msg).GetWorkflowID())
+		offset = msg.Offset()
+		s.NoError(msg.Nack())
+	}
+	consumer.Stop()
+
+	queue := s.queues[GetQueueType(1, common.WorkflowIDToHistoryShard("wid", 2))]
+	s.Len(queue.dlq, 1)
+	s.Equal(offset, queue.ackLevels["consumer"])
+}
+
+func (s *clientSuite) TestPurgeAckedMessages() {
+	producer, err := s.client.NewProducer(testApp)
+	s.NoError(err)
+	for i := 0; i < 3; i++ {
+		s.NoError(producer.Publish(context.Background(), newIndexerMessage("wid")))
+	}
+	queue := s.queues[GetQueueType(1, common.WorkflowIDToHistoryShard("wid", 2))]
+	queue.ackLevels["consumer1"] = 2
+	queue.ackLevels["consumer2"] = 1
+
+	consumer, err := s.client.NewConsumer(testApp, "consumer1")
+	s.NoError(err)
+	s.NoError(consumer.Start())
+	defer consumer.Stop()
+	consumer.(*consumerImpl).purgeAckedMessages()
+	s.Len(queue.messages, 2)
+	s.Equal(int64(1), queue.messages[0].ID)
+}
+
+func (s *clientSuite) TestPartitionsSpreadOverWorkers() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	hosts := []membership.HostInfo{membership.NewHostInfo("host1"), membership.NewHostInfo("host2")}
+	var ownersLock sync.Mutex
+	owners := map[string]membership.HostInfo{
+		testApp + "/0": hosts[0],
+		testApp + "/1": hosts[1],
+	}
+	queueProvider := func(queueType p.QueueType) (p.QueueManager, error) {
+		if _, ok := s.queues[queueType]; !ok {
+			s.queues[queueType] = newFakeQueue()
+		}
+		return s.queues[queueType], nil
+	}
+	updateChs := make([]chan<- *membership.ChangedEvent, len(hosts))
+	consumers := make([]messaging.Consumer, len(hosts))
+	for i, host := range hosts {
+		i, host := i, host
+		resolver := membership.NewMockResolver(ctrl)
+		resolver.EXPECT().WhoAmI().Return(host, nil).AnyTimes()
+		resolver.EXPECT().Lookup(service.Worker, gomock.Any()).DoAndReturn(func(_, key string) (membership.HostInfo, error) {
+			ownersLock.Lock()
+			defer ownersLock.Unlock()
+			return owners[key], nil
+		}).AnyTimes()
+		resolver.EXPECT().Subscribe(service.Worker, gomock.Any(), gomock.Any()).DoAndReturn(func(_, _ string, ch chan<- *membership.ChangedEvent) error {
+			updateChs[i] = ch
+			return nil
+		})
+		resolver.EXPECT().Unsubscribe(service.Worker, gomock.Any()).Return(nil)
+
+		client := NewPersistenceClient(s.config, queueProvider, resolver, metrics.NewNoopMetricsClient(), loggerimpl.NewNopLogger())
+		consumer, err := client.NewConsumer(testApp, "consumer")
+		s.NoError(err)
+		s.NoError(consumer.Start())
+		consumers[i] = consumer
+	}
+
+	producer, err := NewPersistenceClient(s.config, queueProvider, nil, metrics.NewNoopMetricsClient(), loggerimpl.NewNopLogger()).NewProducer(testApp)
+	s.NoError(err)
+	workflowIDs := []string{"wid1", "wid2", "wid3", "wid4"}
+	for _, workflowID := range workflowIDs {
+		s.NoError(producer.Publish(context.Background(), newIndexerMessage(workflowID)))
+	}
+	for range workflowIDs {
+		select {
+		case msg := <-consumers[0].Messages():
+			s.Equal(int32(0), msg.Partition())
+			s.NoError(msg.Ack())
+		case msg := <-consumers[1].Messages():
+			s.Equal(int32(1), msg.Partition())
+			s.NoError(msg.Ack())
+		case <-time.After(5 * time.Second):
+			s.FailNow("timed out waiting for message")
+		}
+	}
+
+	// the partition of a removed host moves to the remaining host and is read from its persisted ack level
+	consumers[1].Stop()
+	ownersLock.Lock()
+	owners[testApp+"/1"] = hosts[0]
+	ownersLock.Unlock()
+	updateChs[0] <- &membership.ChangedEvent{HostsRemoved: []string{hosts[1].GetAddress()}}
+
+	s.NoError(producer.Publish(context.Background(), newIndexerMessage("wid5")))
+	s.NoError(producer.Publish(context.Background(), newIndexerMessage("wid1")))
+	received := make(map[string]int32)
+	for i := 0; i < 2; i++ {
+		msg := s.receive(consumers[0])
+		received[s.decode(msg).GetWorkflowID()] = msg.Partition()
+		s.NoError(msg.Ack())
+	}
+	s.Equal(map[string]int32{"wid5": 0, "wid1": 1}, received)
+	consumers[0].Stop()
+}
+
+func (s *clientSuite) receive(consumer messaging.Consumer) messaging.Message {
+	select {
+	case msg := <-consumer.Messages():
+		return msg
+	case <-time.After(5 * time.Second):
+		s.FailNow("timed out waiting for message")
+	}
+	return nil
+}
+
+func (s *clientSuite) decode(msg messaging.Message) *indexer.Message {
+	var result indexer.Message
+	s.NoError(codec.NewThriftRWEncoder().Decode(msg.Value(), &result))
+	return &result
+}
+
+func newIndexerMessage(workflowID string) *indexer.Message {
+	return &indexer.Message{
+		MessageType: indexer.MessageTypeIndex.Ptr(),
+		DomainID:    common.StringPtr("domainID"),
+		WorkflowID:  common.StringPtr(workflowID),
+		RunID:       common.StringPtr("runID"),
+		Version:     common.Int64Ptr(1),
+	}
+}
+
+func newFakeQueue() *fakeQueue {
+	return &fakeQueue{ackLevels: make(map[string]int64)}
+}
+
+func (q *fakeQueue) Close() {}
+
+func (q *fakeQueue) EnqueueMessage(_ context.Context, payload []byte) error {
+	q.Lock()
+	defer q.Unlock()
+	if len(q.enqueueErrs) > 0 {
+		err := q.enqueueErrs[0]
+		q.enqueueErrs = q.enqueueErrs[1:]
+		return err
+	}
+	q.messages = append(q.messages, &p.QueueMessage{ID: q.nextID, Payload: payload})
+	q.nextID++
+	return nil
+}
+
+func (q *fakeQueue) ReadMessages(_ context.Context, lastMessageID int64, maxCount int) ([]*p.QueueMessage, error) {
+	q.Lock()
+	defer q.Unlock()
+	var result []*p.QueueMessage
+	for _, message := range q.messages {
+		if message.ID > lastMessageID && len(result) < maxCount {
+			result = append(result, message)
+		}
+	}
+	return result, nil
+}
+
+func (q *fakeQueue) DeleteMessagesBefore(_ context.Context, messageID int64) error {
+	q.Lock()
+	defer q.Unlock()
+	var remaining []*p.QueueMessage
+	for _, message := range q.messages {
+		if message.ID >= messageID {
+			remaining = append(remaining, message)
+		}
+	}
+	q.messages = remaining
+	return nil
+}
+
+func (q *fakeQueue) UpdateAckLevel(_ context.Context, messageID int64, clusterName string) error {
+	q.Lock()
+	defer q.Unlock()
+	if ackLevel, ok := q.ackLevels[clusterName]; !ok || ackLevel < messageID {
+		q.ackLevels[clusterName] = messageID
+	}
+	return nil
+}
+
+func (q *fakeQueue) GetAckLevels(_ context.Context) (map[string]int64, error) {
+	q.Lock()
+	defer q.Unlock()
+	result := make(map[string]int64, len(q.ackLevels))
+	for name, ackLevel := range q.ackLevels {
+		result[name] = ackLevel
+	}
+	return result, nil
+}
+
+func (q *fakeQueue) EnqueueMessageToDLQ(_ context.Context, payload []byte) error {
+	q.Lock()
+	defer q.Unlock()
+	q.dlq = append(q.dlq, payload)
+	return nil
+}
+
+func (q *fakeQueue) ReadMessagesFromDLQ(context.Context, int64, int64, int, []byte) ([]*p.QueueMessage, []byte, error) {
+	return nil, nil, nil
+}
+
+func (q *fakeQueue) DeleteMessageFromDLQ(context.Context, int64) error {
+	return nil
+}
+
+func (q *fakeQueue) RangeDeleteMessagesFromDLQ(context.Context, int64, int64) error {
+	return nil
+}
+
+func (q *fakeQueue) UpdateDLQAckLevel(context.Context, int64, string) error {
+	return nil
+}
+
+func (q *fakeQueue) GetDLQAckLevels(context.Context) (map[string]int64, error) {
+	return nil, nil
+}
+
+func (q *fakeQueue) GetDLQSize(context.Context) (int64, error) {
+	return int64(len(q.dlq)), nil
+}
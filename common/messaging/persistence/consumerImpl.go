@@ -0,0 +1,503 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package persistence
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/backoff"
+	"github.com/uber/cadence/common/config"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/membership"
+	"github.com/uber/cadence/common/messaging"
+	"github.com/uber/cadence/common/metrics"
+	p "github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/service"
+)
+
+const (
+	rcvBufferSize      = 2 * 1024
+	persistenceTimeout = 10 * time.Second
+	dlqPublishTimeout  = time.Minute
+
+	ownershipRefreshInterval = 10 * time.Second
+)
+
+type (
+	// consumerImpl reads the partitions of a queue owned by this host from the ack level of the consumer.
+	// Partitions are assigned to the hosts of the worker ring by hashing, so every message is read by one host.
+	// Delivery is at-least-once: messages after the persisted ack level are delivered again when
+	// the consumer restarts or when a partition moves to another host
+	consumerImpl struct {
+		sync.Mutex
+		status       int32
+		config       *config.PersistenceMessaging
+		app          string
+		consumerName string
+		queues       []p.QueueManager
+		readers      []*partitionReader // reader of each partition, nil if the partition is not owned
+		scopes       []metrics.Scope
+		msgChan      chan messaging.Message
+		shutdownCh   chan struct{}
+		shutdownWG   sync.WaitGroup
+
+		resolver           membership.Resolver
+		membershipUpdateCh chan *membership.ChangedEvent
+
+		metricsClient metrics.Client
+		logger        log.Logger
+		throttleRetry *backoff.ThrottleRetry
+	}
+
+	// partitionReader reads one owned partition, it is replaced when the partition is acquired again
+	partitionReader struct {
+		partition  int
+		ackMgr     messaging.AckManager
+		ackLevel   int64 // persisted ack level of the partition
+		shutdownCh chan struct{}
+		shutdownWG sync.WaitGroup
+	}
+
+	messageImpl struct {
+		consumer *consumerImpl
+		reader   *partitionReader
+		id       int64
+		payload  []byte
+		attempt  int
+	}
+)
+
+var _ messaging.Message = (*messageImpl)(nil)
+var _ messaging.Consumer = (*consumerImpl)(nil)
+
+func newConsumer(
+	cfg *config.PersistenceMessaging,
+	app string,
+	queues []p.QueueManager,
+	consumerName string,
+	resolver membership.Resolver,
+	metricsClient metrics.Client,
+	logger log.Logger,
+) messaging.Consumer {
+	scopes := make([]metrics.Scope, len(queues))
+	for partition := range queues {
+		scopes[partition] = metricsClient.Scope(metrics.MessagingClientConsumerScope, metrics.KafkaPartitionTag(int32(partition)))
+	}
+
+	return &consumerImpl{
+		status:       common.DaemonStatusInitialized,
+		config:       cfg,
+		app:          app,
+		consumerName: consumerName,
+		queues:       queues,
+		readers:      make([]*partitionReader, len(queues)),
+		scopes:       scopes,
+		msgChan:      make(chan messaging.Message, rcvBufferSize),
+		shutdownCh:   make(chan struct{}),
+
+		resolver:           resolver,
+		membershipUpdateCh: make(chan *membership.ChangedEvent, 1),
+
+		metricsClient: metricsClient,
+		logger:        logger,
+		throttleRetry: backoff.NewThrottleRetry(
+			backoff.WithRetryPolicy(common.CreateDlqPublishRetryPolicy()),
+			backoff.WithRetryableError(func(_ error) bool { return true }),
+		),
+	}
+}
+
+// Start starts reading the partitions owned by this host from their ack levels
+func (c *consumerImpl) Start() error {
+	if !atomic.CompareAndSwapInt32(&c.status, common.DaemonStatusInitialized, common.DaemonStatusStarted) {
+		return nil
+	}
+
+	if err := c.refreshOwnership(); err != nil {
+		c.releasePartitions()
+		return err
+	}
+	if c.resolver != nil {
+		if err := c.resolver.Subscribe(service.Worker, c.membershipListenerName(), c.membershipUpdateCh); err != nil {
+			c.logger.Error("subscribing to membership resolver", tag.Error(err))
+		}
+	}
+
+	c.shutdownWG.Add(1)
+	go c.managementLoop()
+	return nil
+}
+
+// Stop stops the consumer, persists the ack levels and closes the message channel
+func (c *consumerImpl) Stop() {
+	c.Lock()
+	if !atomic.CompareAndSwapInt32(&c.status, common.DaemonStatusStarted, common.DaemonStatusStopped) {
+		c.Unlock()
+		return
+	}
+	c.logger.Info("Stopping consumer")
+	close(c.shutdownCh)
+	c.Unlock()
+
+	if c.resolver != nil {
+		if err := c.resolver.Unsubscribe(service.Worker, c.membershipListenerName()); err != nil {
+			c.logger.Error("unsubscribing from membership resolver", tag.Error(err), tag.OperationFailed)
+		}
+	}
+	c.shutdownWG.Wait()
+	c.releasePartitions()
+	close(c.msgChan)
+}
+
+// Messages return the message channel for this consumer
+func (c *consumerImpl) Messages() <-chan messaging.Message {
+	return c.msgChan
+}
+
+func (c *consumerImpl) membershipListenerName() string {
+	return c.app + "-" + c.consumerName
+}
+
+// managementLoop is the only goroutine changing the partition readers after Start
+func (c *consumerImpl) managementLoop() {
+	defer c.shutdownWG.Done()
+
+	ownershipTicker := time.NewTicker(ownershipRefreshInterval)
+	defer ownershipTicker.Stop()
+	updateTicker := time.NewTicker(c.config.AckLevelUpdateInterval)
+	defer updateTicker.Stop()
+	purgeTicker := time.NewTicker(c.config.PurgeInterval)
+	defer purgeTicker.Stop()
+
+	for {
+		select {
+		case <-c.shutdownCh:
+			return
+		case <-ownershipTicker.C:
+			c.refreshOwnershipAndLog()
+		case <-c.membershipUpdateCh:
+			c.refreshOwnershipAndLog()
+		case <-updateTicker.C:
+			c.updateAckLevels()
+		case <-purgeTicker.C:
+			c.purgeAckedMessages()
+		}
+	}
+}
+
+func (c *consumerImpl) refreshOwnershipAndLog() {
+	if err := c.refreshOwnership(); err != nil {
+		c.logger.Warn("Failed to refresh partition ownership of persistence queue", tag.Error(err))
+	}
+}
+
+// refreshOwnership starts reading the partitions newly owned by this host and
+// stops reading the partitions moved to other hosts
+func (c *consumerImpl) refreshOwnership() error {
+	var firstErr error
+	for partition := range c.queues {
+		owned, err := c.isOwner(partition)
+		if err == nil && owned && c.readers[partition] == nil {
+			err = c.acquirePartition(partition)
+		}
+		if err == nil && !owned && c.readers[partition] != nil {
+			c.releasePartition(partition)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// isOwner returns whether this host owns the partition, a consumer without resolver owns all partitions
+func (c *consumerImpl) isOwner(partition int) (bool, error) {
+	if c.resolver == nil {
+		return true, nil
+	}
+	owner, err := c.resolver.Lookup(service.Worker, c.app+"/"+strconv.Itoa(partition))
+	if err != nil {
+		return false, err
+	}
+	self, err := c.resolver.WhoAmI()
+	if err != nil {
+		return false, err
+	}
+	return owner.Identity() == self.Identity(), nil
+}
+
+func (c *consumerImpl) acquirePartition(partition int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), persistenceTimeout)
+	ackLevels, err := c.queues[partition].GetAckLevels(ctx)
+	cancel()
+	if err != nil {
+		return err
+	}
+	ackLevel, ok := ackLevels[c.consumerName]
+	if !ok {
+		ackLevel = -1
+	}
+
+	reader := &partitionReader{
+		partition:  partition,
+		ackMgr:     messaging.NewContinuousAckManager(c.logger.WithTags(tag.KafkaPartition(int32(partition)))),
+		ackLevel:   ackLevel,
+		shutdownCh: make(chan struct{}),
+	}
+	reader.ackMgr.SetAckLevel(ackLevel)
+	c.readers[partition] = reader
+
+	c.logger.Info("Acquired partition of persistence queue", tag.KafkaPartition(int32(partition)))
+	reader.shutdownWG.Add(1)
+	go c.pollLoop(reader)
+	return nil
+}
+
+// releasePartition stops reading the partition and persists its ack level,
+// messages delivered but not acked yet are delivered again by the next owner
+func (c *consumerImpl) releasePartition(partition int) {
+	reader := c.readers[partition]
+	close(reader.shutdownCh)
+	reader.shutdownWG.Wait()
+	c.updateAckLevel(reader)
+	c.readers[partition] = nil
+	c.logger.Info("Released partition of persistence queue", tag.KafkaPartition(int32(partition)))
+}
+
+func (c *consumerImpl) releasePartitions() {
+	for partition, reader := range c.readers {
+		if reader != nil {
+			c.releasePartition(partition)
+		}
+	}
+}
+
+func (c *consumerImpl) pollLoop(reader *partitionReader) {
+	defer reader.shutdownWG.Done()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-c.shutdownCh:
+			return
+		case <-reader.shutdownCh:
+			return
+		case <-timer.C:
+		}
+
+		count, err := c.poll(reader)
+		if err != nil {
+			c.logger.Warn("Failed to read messages from persistence queue", tag.KafkaPartition(int32(reader.partition)), tag.Error(err))
+		}
+		if err != nil || count < c.config.BatchSize {
+			timer.Reset(c.config.PollInterval)
+		} else {
+			timer.Reset(0)
+		}
+	}
+}
+
+func (c *consumerImpl) poll(reader *partitionReader) (int, error) {
+	ackMgr := reader.ackMgr
+	ctx, cancel := context.WithTimeout(context.Background(), persistenceTimeout)
+	messages, err := c.queues[reader.partition].ReadMessages(ctx, ackMgr.GetReadLevel(), c.config.BatchSize)
+	cancel()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, message := range messages {
+		if err := ackMgr.ReadItem(message.ID); err != nil {
+			c.logger.Warn("potential bug when adding message to ackManager", tag.Error(err), tag.KafkaPartition(int32(reader.partition)), tag.TaskID(message.ID))
+		}
+		c.scopes[reader.partition].IncCounter(metrics.KafkaConsumerMessageIn)
+
+		msg := &messageImpl{
+			consumer: c,
+			reader:   reader,
+			id:       message.ID,
+			payload:  message.Payload,
+		}
+		select {
+		case c.msgChan <- msg:
+		case <-c.shutdownCh:
+			return 0, nil
+		case <-reader.shutdownCh:
+			return 0, nil
+		}
+	}
+	return len(messages), nil
+}
+
+func (c *consumerImpl) updateAckLevels() {
+	for _, reader := range c.readers {
+		if reader != nil {
+			c.updateAckLevel(reader)
+		}
+	}
+}
+
+func (c *consumerImpl) updateAckLevel(reader *partitionReader) {
+	ackLevel := reader.ackMgr.GetAckLevel()
+	if ackLevel <= reader.ackLevel {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), persistenceTimeout)
+	err := c.queues[reader.partition].UpdateAckLevel(ctx, ackLevel, c.consumerName)
+	cancel()
+	if err != nil {
+		c.logger.Warn("Failed to update ack level of persistence queue", tag.KafkaPartition(int32(reader.partition)), tag.Error(err))
+		return
+	}
+	reader.ackLevel = ackLevel
+}
+
+// purgeAckedMessages deletes the messages acked by all consumers of the owned partitions
+func (c *consumerImpl) purgeAckedMessages() {
+	for partition, queue := range c.queues {
+		if c.readers[partition] == nil {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), persistenceTimeout)
+		ackLevels, err := queue.GetAckLevels(ctx)
+		if err != nil || len(ackLevels) == 0 {
+			cancel()
+			continue
+		}
+
+		minAckLevel := int64(math.MaxInt64)
+		for _, ackLevel := range ackLevels {
+			if ackLevel < minAckLevel {
+				minAckLevel = ackLevel
+			}
+		}
+		if err := queue.DeleteMessagesBefore(ctx, minAckLevel); err != nil {
+			c.logger.Warn("Failed to purge acked messages of persistence queue", tag.KafkaPartition(int32(partition)), tag.Error(err))
+		}
+		cancel()
+	}
+}
+
+func (c *consumerImpl) completeMessage(message *messageImpl, isAck bool) {
+	message.reader.ackMgr.AckItem(message.id)
+	if isAck {
+		c.scopes[message.reader.partition].IncCounter(metrics.KafkaConsumerMessageAck)
+	} else {
+		c.scopes[message.reader.partition].IncCounter(metrics.KafkaConsumerMessageNack)
+	}
+}
+
+func (c *consumerImpl) nackMessage(message *messageImpl) {
+	if message.attempt < c.config.MaxRedeliveries && c.redeliver(message) {
+		return
+	}
+
+	op := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), dlqPublishTimeout)
+		defer cancel()
+		return c.queues[message.reader.partition].EnqueueMessageToDLQ(ctx, message.payload)
+	}
+	if err := c.throttleRetry.Do(context.Background(), op); err != nil {
+		c.metricsClient.IncCounter(metrics.MessagingClientConsumerScope, metrics.KafkaConsumerMessageNackDlqErr)
+		c.logger.Error("Fail to publish message to DLQ when nacking message, please take action!!",
+			tag.KafkaPartition(message.Partition()),
+			tag.KafkaOffset(message.id))
+	} else {
+		c.logger.Warn("nack message and publish to DLQ",
+			tag.KafkaPartition(message.Partition()),
+			tag.KafkaOffset(message.id))
+	}
+	c.completeMessage(message, false)
+}
+
+// redeliver puts the message back to the message channel after the redelivery backoff,
+// it returns false if the consumer is stopped. The message is dropped if its partition
+// is released meanwhile, the next owner reads it again from the persisted ack level
+func (c *consumerImpl) redeliver(message *messageImpl) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	if atomic.LoadInt32(&c.status) != common.DaemonStatusStarted {
+		return false
+	}
+	c.scopes[message.reader.partition].IncCounter(metrics.MessagingConsumerMessageRedeliver)
+
+	c.shutdownWG.Add(1)
+	go func() {
+		defer c.shutdownWG.Done()
+
+		timer := time.NewTimer(c.config.RedeliveryBackoff)
+		defer timer.Stop()
+		select {
+		case <-c.shutdownCh:
+			return
+		case <-message.reader.shutdownCh:
+			return
+		case <-timer.C:
+		}
+
+		select {
+		case c.msgChan <- &messageImpl{
+			consumer: c,
+			reader:   message.reader,
+			id:       message.id,
+			payload:  message.payload,
+			attempt:  message.attempt + 1,
+		}:
+		case <-c.shutdownCh:
+		case <-message.reader.shutdownCh:
+		}
+	}()
+	return true
+}
+
+func (m *messageImpl) Value() []byte {
+	return m.payload
+}
+
+func (m *messageImpl) Partition() int32 {
+	return int32(m.reader.partition)
+}
+
+func (m *messageImpl) Offset() int64 {
+	return m.id
+}
+
+func (m *messageImpl) Ack() error {
+	m.consumer.completeMessage(m, true)
+	return nil
+}
+
+func (m *messageImpl) Nack() error {
+	m.consumer.nackMessage(m)
+	return nil
+}
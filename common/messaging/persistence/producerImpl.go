@@ -0,0 +1,117 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package persistence
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dgryski/go-farm"
+
+	"github.com/uber/cadence/.gen/go/indexer"
+	"github.com/uber/cadence/common/backoff"
+	"github.com/uber/cadence/common/codec"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/messaging"
+	p "github.com/uber/cadence/common/persistence"
+)
+
+type (
+	producerImpl struct {
+		queues        []p.QueueManager
+		toDLQ         bool // publish to the DLQ of the partitions
+		msgEncoder    codec.BinaryEncoder
+		throttleRetry *backoff.ThrottleRetry
+		logger        log.Logger
+	}
+)
+
+var _ messaging.Producer = (*producerImpl)(nil)
+
+func newProducer(queues []p.QueueManager, toDLQ bool, logger log.Logger) messaging.Producer {
+	return &producerImpl{
+		queues:     queues,
+		toDLQ:      toDLQ,
+		msgEncoder: codec.NewThriftRWEncoder(),
+		throttleRetry: backoff.NewThrottleRetry(
+			backoff.WithRetryableError(isRetryableEnqueueError),
+		),
+		logger: logger,
+	}
+}
+
+// Publish is used to enqueue the message to the partition of its key
+func (pr *producerImpl) Publish(ctx context.Context, msg interface{}) error {
+	partition, payload, err := pr.getProducerMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	op := func() error {
+		if pr.toDLQ {
+			return pr.queues[partition].EnqueueMessageToDLQ(ctx, payload)
+		}
+		return pr.queues[partition].EnqueueMessage(ctx, payload)
+	}
+	if err := pr.throttleRetry.Do(ctx, op); err != nil {
+		pr.logger.Warn("Failed to publish message to persistence queue",
+			tag.KafkaPartition(int32(partition)),
+			tag.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (pr *producerImpl) getProducerMessage(message interface{}) (int, []byte, error) {
+	switch message := message.(type) {
+	case *indexer.Message:
+		payload, err := pr.msgEncoder.Encode(message)
+		if err != nil {
+			pr.logger.Error("Failed to serialize thrift object", tag.Error(err))
+			return 0, nil, err
+		}
+		return pr.getPartition(message.GetWorkflowID()), payload, nil
+	case *messaging.DLQMessage:
+		payload, err := message.Encode()
+		if err != nil {
+			return 0, nil, err
+		}
+		return pr.getPartition(message.WorkflowID), payload, nil
+	case messaging.Message:
+		return int(message.Partition()) % len(pr.queues), message.Value(), nil
+	default:
+		return 0, nil, errors.New("unknown producer message type")
+	}
+}
+
+func (pr *producerImpl) getPartition(key string) int {
+	return int(farm.Fingerprint32([]byte(key)) % uint32(len(pr.queues)))
+}
+
+// isRetryableEnqueueError retries conflicting concurrent enqueues
+// of the same partition as well as transient persistence errors
+func isRetryableEnqueueError(err error) bool {
+	if _, ok := err.(*p.ConditionFailedError); ok {
+		return true
+	}
+	return p.IsTransientError(err)
+}
@@ -727,6 +727,12 @@ const (
 	DomainFailoverScope
 	// DomainReplicationQueueScope is used in domainreplication queue
 	DomainReplicationQueueScope
+	// DynamicConfigRemoteClientScope is used by the remote dynamic config client
+	DynamicConfigRemoteClientScope
+	// DynamicConfigFileBasedClientScope is used by the file based dynamic config client
+	DynamicConfigFileBasedClientScope
+	// ESProcessorScope is scope used by all metric emitted by esProcessor and the visibility bulk producer
+	ESProcessorScope
 
 	NumCommonScopes
 )
@@ -789,6 +795,8 @@ const (
 	AdminDeleteWorkflowScope
 	// MaintainCorruptWorkflowScope is the metric scope for admin.MaintainCorruptWorkflow
 	MaintainCorruptWorkflowScope
+	// AdminListDomainAuditEventsScope is the metric scope for admin.ListDomainAuditEvents
+	AdminListDomainAuditEventsScope
 
 	NumAdminScopes
 )
@@ -1185,8 +1193,6 @@ const (
 	ReplicatorScope = iota + NumCommonScopes
 	// DomainReplicationTaskScope is the scope used by domain task replication processing
 	DomainReplicationTaskScope
-	// ESProcessorScope is scope used by all metric emitted by esProcessor
-	ESProcessorScope
 	// IndexProcessorScope is scope used by all metric emitted by index processor
 	IndexProcessorScope
 	// ArchiverDeleteHistoryActivityScope is scope used by all metrics emitted by archiver.DeleteHistoryActivity
@@ -1526,6 +1532,10 @@ var ScopeDefs = map[ServiceIdx]map[int]scopeDefinition{
 
 		DomainFailoverScope:         {operation: "DomainFailover"},
 		DomainReplicationQueueScope: {operation: "DomainReplicationQueue"},
+
+		DynamicConfigRemoteClientScope:    {operation: "DynamicConfigRemoteClient"},
+		DynamicConfigFileBasedClientScope: {operation: "DynamicConfigFileBasedClient"},
+		ESProcessorScope:                  {operation: "ESProcessor"},
 	},
 	// Frontend Scope Names
 	Frontend: {
@@ -1558,6 +1568,7 @@ var ScopeDefs = map[ServiceIdx]map[int]scopeDefinition{
 		AdminListDynamicConfigScope:                 {operation: "AdminListDynamicConfig"},
 		AdminDeleteWorkflowScope:                    {operation: "AdminDeleteWorkflow"},
 		MaintainCorruptWorkflowScope:                {operation: "MaintainCorruptWorkflow"},
+		AdminListDomainAuditEventsScope:             {operation: "AdminListDomainAuditEvents"},
 
 		FrontendRestartWorkflowExecutionScope:           {operation: "RestartWorkflowExecution"},
 		FrontendStartWorkflowExecutionScope:             {operation: "StartWorkflowExecution"},
@@ -1754,7 +1765,6 @@ var ScopeDefs = map[ServiceIdx]map[int]scopeDefinition{
 	Worker: {
 		ReplicatorScope:                        {operation: "Replicator"},
 		DomainReplicationTaskScope:             {operation: "DomainReplicationTask"},
-		ESProcessorScope:                       {operation: "ESProcessor"},
 		IndexProcessorScope:                    {operation: "IndexProcessor"},
 		ArchiverDeleteHistoryActivityScope:     {operation: "ArchiverDeleteHistoryActivity"},
 		ArchiverUploadHistoryActivityScope:     {operation: "ArchiverUploadHistoryActivity"},
@@ -1878,6 +1888,9 @@ const (
 	KafkaConsumerMessageNack
 	KafkaConsumerMessageNackDlqErr
 	KafkaConsumerSessionStart
+	KafkaConsumerLag
+	KafkaConsumerBacklog
+	MessagingConsumerMessageRedeliver
 
 	GracefulFailoverLatency
 	GracefulFailoverFailure
@@ -1939,6 +1952,25 @@ const (
 	ParentClosePolicyProcessorSuccess
 	ParentClosePolicyProcessorFailures
 
+	DynamicConfigRemoteFetchRequests
+	DynamicConfigRemoteFetchFailures
+	DynamicConfigRemoteFetchLatency
+	DynamicConfigRemoteUpdates
+	DynamicConfigRemoteSnapshotCacheLoads
+	DynamicConfigRemoteSnapshotCacheFailures
+	DynamicConfigRemoteStalenessGauge
+	DynamicConfigUnknownKeys
+
+	ESProcessorRequests
+	ESProcessorRetries
+	ESProcessorFailures
+	ESProcessorCorruptedData
+	ESProcessorProcessMsgLatency
+	ESProcessorMappingConflicts
+	ESProcessorDroppedRequests
+	ESProcessorDLQMessages
+	ESProcessorDLQFailures
+
 	NumCommonMetrics // Needs to be last on this list for iota numbering
 )
 
@@ -2236,13 +2268,10 @@ const (
 	ReplicatorMessagesDropped
 	ReplicatorLatency
 	ReplicatorDLQFailures
-	ESProcessorRequests
-	ESProcessorRetries
-	ESProcessorFailures
-	ESProcessorCorruptedData
-	ESProcessorProcessMsgLatency
 	IndexProcessorCorruptedData
 	IndexProcessorProcessMsgLatency
+	IndexProcessorDLQMessages
+	IndexProcessorDLQFailures
 	ArchiverNonRetryableErrorCount
 	ArchiverStartedCount
 	ArchiverStoppedCount
@@ -2307,6 +2336,9 @@ const (
 	ESAnalyzerNumStuckWorkflowsRefreshed
 	ESAnalyzerNumStuckWorkflowsFailedToRefresh
 	ESAnalyzerNumLongRunningWorkflows
+	ESAnalyzerNumWorkflowsMitigated
+	ESAnalyzerNumWorkflowsFailedToMitigate
+	ESAnalyzerNumWorkflowsReported
 	WatchDogNumDeletedCorruptWorkflows
 	WatchDogNumFailedToDeleteCorruptWorkflows
 	WatchDogNumCorruptWorkflowProcessed
@@ -2406,6 +2438,9 @@ var MetricDefs = map[ServiceIdx]map[int]metricDefinition{
 		KafkaConsumerMessageNack:                            {metricName: "kafka_consumer_message_nack", metricType: Counter},
 		KafkaConsumerMessageNackDlqErr:                      {metricName: "kafka_consumer_message_nack_dlq_err", metricType: Counter},
 		KafkaConsumerSessionStart:                           {metricName: "kafka_consumer_session_start", metricType: Counter},
+		KafkaConsumerLag:                                    {metricName: "kafka_consumer_lag", metricType: Gauge},
+		KafkaConsumerBacklog:                                {metricName: "kafka_consumer_backlog", metricType: Gauge},
+		MessagingConsumerMessageRedeliver:                   {metricName: "messaging_consumer_message_redeliver", metricType: Counter},
 		GracefulFailoverLatency:                             {metricName: "graceful_failover_latency", metricType: Timer},
 		GracefulFailoverFailure:                             {metricName: "graceful_failover_failures", metricType: Counter},
 
@@ -2502,6 +2537,24 @@ var MetricDefs = map[ServiceIdx]map[int]metricDefinition{
 		DomainReplicationQueueSizeErrorCount: {metricName: "domain_replication_queue_failed", metricType: Counter},
 		ParentClosePolicyProcessorSuccess:    {metricName: "parent_close_policy_processor_requests", metricType: Counter},
 		ParentClosePolicyProcessorFailures:   {metricName: "parent_close_policy_processor_errors", metricType: Counter},
+
+		DynamicConfigRemoteFetchRequests:         {metricName: "dynamicconfig_remote_fetch_requests", metricType: Counter},
+		DynamicConfigRemoteFetchFailures:         {metricName: "dynamicconfig_remote_fetch_errors", metricType: Counter},
+		DynamicConfigRemoteFetchLatency:          {metricName: "dynamicconfig_remote_fetch_latency", metricType: Timer},
+		DynamicConfigRemoteUpdates:               {metricName: "dynamicconfig_remote_updates", metricType: Counter},
+		DynamicConfigRemoteSnapshotCacheLoads:    {metricName: "dynamicconfig_remote_snapshot_cache_loads", metricType: Counter},
+		DynamicConfigRemoteSnapshotCacheFailures: {metricName: "dynamicconfig_remote_snapshot_cache_errors", metricType: Counter},
+		DynamicConfigRemoteStalenessGauge:        {metricName: "dynamicconfig_remote_staleness_seconds", metricType: Gauge},
+		DynamicConfigUnknownKeys:                 {metricName: "dynamicconfig_unknown_keys", metricType: Gauge},
+		ESProcessorRequests:                      {metricName: "es_processor_requests"},
+		ESProcessorRetries:                       {metricName: "es_processor_retries"},
+		ESProcessorFailures:                      {metricName: "es_processor_errors"},
+		ESProcessorCorruptedData:                 {metricName: "es_processor_corrupted_data"},
+		ESProcessorProcessMsgLatency:             {metricName: "es_processor_process_msg_latency", metricType: Timer},
+		ESProcessorMappingConflicts:              {metricName: "es_processor_mapping_conflicts", metricType: Counter},
+		ESProcessorDroppedRequests:               {metricName: "es_processor_dropped_requests", metricType: Counter},
+		ESProcessorDLQMessages:                   {metricName: "es_processor_dlq_messages", metricType: Counter},
+		ESProcessorDLQFailures:                   {metricName: "es_processor_dlq_enqueue_fails", metricType: Counter},
 	},
 	History: {
 		TaskRequests:             {metricName: "task_requests", metricType: Counter},
@@ -2784,13 +2837,10 @@ var MetricDefs = map[ServiceIdx]map[int]metricDefinition{
 		ReplicatorMessagesDropped:                     {metricName: "replicator_messages_dropped"},
 		ReplicatorLatency:                             {metricName: "replicator_latency"},
 		ReplicatorDLQFailures:                         {metricName: "replicator_dlq_enqueue_fails", metricType: Counter},
-		ESProcessorRequests:                           {metricName: "es_processor_requests"},
-		ESProcessorRetries:                            {metricName: "es_processor_retries"},
-		ESProcessorFailures:                           {metricName: "es_processor_errors"},
-		ESProcessorCorruptedData:                      {metricName: "es_processor_corrupted_data"},
-		ESProcessorProcessMsgLatency:                  {metricName: "es_processor_process_msg_latency", metricType: Timer},
 		IndexProcessorCorruptedData:                   {metricName: "index_processor_corrupted_data"},
 		IndexProcessorProcessMsgLatency:               {metricName: "index_processor_process_msg_latency", metricType: Timer},
+		IndexProcessorDLQMessages:                     {metricName: "index_processor_dlq_messages", metricType: Counter},
+		IndexProcessorDLQFailures:                     {metricName: "index_processor_dlq_enqueue_fails", metricType: Counter},
 		ArchiverNonRetryableErrorCount:                {metricName: "archiver_non_retryable_error"},
 		ArchiverStartedCount:                          {metricName: "archiver_started"},
 		ArchiverStoppedCount:                          {metricName: "archiver_stopped"},
@@ -2855,6 +2905,9 @@ var MetricDefs = map[ServiceIdx]map[int]metricDefinition{
 		ESAnalyzerNumStuckWorkflowsRefreshed:          {metricName: "es_analyzer_num_stuck_workflows_refreshed", metricType: Counter},
 		ESAnalyzerNumStuckWorkflowsFailedToRefresh:    {metricName: "es_analyzer_num_stuck_workflows_failed_to_refresh", metricType: Counter},
 		ESAnalyzerNumLongRunningWorkflows:             {metricName: "es_analyzer_num_long_running_workflows", metricType: Counter},
+		ESAnalyzerNumWorkflowsMitigated:               {metricName: "es_analyzer_num_workflows_mitigated", metricType: Counter},
+		ESAnalyzerNumWorkflowsFailedToMitigate:        {metricName: "es_analyzer_num_workflows_failed_to_mitigate", metricType: Counter},
+		ESAnalyzerNumWorkflowsReported:                {metricName: "es_analyzer_num_workflows_reported", metricType: Counter},
 		WatchDogNumDeletedCorruptWorkflows:            {metricName: "watchdog_num_deleted_corrupt_workflows", metricType: Counter},
 		WatchDogNumFailedToDeleteCorruptWorkflows:     {metricName: "watchdog_num_failed_to_delete_corrupt_workflows", metricType: Counter},
 		WatchDogNumCorruptWorkflowProcessed:           {metricName: "watchdog_num_corrupt_workflows_processed", metricType: Counter},
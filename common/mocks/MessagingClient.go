@@ -51,3 +51,8 @@ func (c *MessagingClient) NewConsumer(appName, consumerName string) (messaging.C
 func (c *MessagingClient) NewProducer(appName string) (messaging.Producer, error) {
 	return c.publisherMock, nil
 }
+
+// NewDLQProducer generates a dummy implementation of kafka producer
+func (c *MessagingClient) NewDLQProducer(appName string) (messaging.Producer, error) {
+	return c.publisherMock, nil
+}
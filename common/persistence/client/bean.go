@@ -50,6 +50,9 @@ type (
 		GetDomainReplicationQueueManager() persistence.QueueManager
 		SetDomainReplicationQueueManager(persistence.QueueManager)
 
+		GetDomainAuditQueueManager(int) (persistence.QueueManager, error)
+		SetDomainAuditQueueManager(int, persistence.QueueManager)
+
 		GetShardManager() persistence.ShardManager
 		SetShardManager(persistence.ShardManager)
 
@@ -73,9 +76,16 @@ type (
 		historyManager                persistence.HistoryManager
 		configStoreManager            persistence.ConfigStoreManager
 		executionManagerFactory       persistence.ExecutionManagerFactory
+		domainAuditQueueFactory       DomainAuditQueueFactory
 
 		sync.RWMutex
-		shardIDToExecutionManager map[int]persistence.ExecutionManager
+		shardIDToExecutionManager       map[int]persistence.ExecutionManager
+		bucketToDomainAuditQueueManager map[int]persistence.QueueManager
+	}
+
+	// DomainAuditQueueFactory creates the queues of the domain audit log
+	DomainAuditQueueFactory interface {
+		NewDomainAuditQueueManager(bucket int) (persistence.QueueManager, error)
 	}
 
 	// Params contains dependencies for persistence
@@ -144,6 +154,7 @@ func NewBeanFromFactory(
 		historyMgr,
 		configStoreMgr,
 		factory,
+		factory,
 	), nil
 }
 
@@ -157,6 +168,7 @@ func NewBean(
 	historyManager persistence.HistoryManager,
 	configStoreManager persistence.ConfigStoreManager,
 	executionManagerFactory persistence.ExecutionManagerFactory,
+	domainAuditQueueFactory DomainAuditQueueFactory,
 ) *BeanImpl {
 	return &BeanImpl{
 		domainManager:                 domainManager,
@@ -167,8 +179,10 @@ func NewBean(
 		historyManager:                historyManager,
 		configStoreManager:            configStoreManager,
 		executionManagerFactory:       executionManagerFactory,
+		domainAuditQueueFactory:       domainAuditQueueFactory,
 
-		shardIDToExecutionManager: make(map[int]persistence.ExecutionManager),
+		shardIDToExecutionManager:       make(map[int]persistence.ExecutionManager),
+		bucketToDomainAuditQueueManager: make(map[int]persistence.QueueManager),
 	}
 }
 
@@ -252,6 +266,48 @@ func (s *BeanImpl) SetDomainReplicationQueueManager(
 	s.domainReplicationQueueManager = domainReplicationQueueManager
 }
 
+// GetDomainAuditQueueManager gets the domain audit QueueManager of a bucket
+func (s *BeanImpl) GetDomainAuditQueueManager(
+	bucket int,
+) (persistence.QueueManager, error) {
+
+	s.RLock()
+	queueManager, ok := s.bucketToDomainAuditQueueManager[bucket]
+	if ok {
+		s.RUnlock()
+		return queueManager, nil
+	}
+	s.RUnlock()
+
+	s.Lock()
+	defer s.Unlock()
+
+	queueManager, ok = s.bucketToDomainAuditQueueManager[bucket]
+	if ok {
+		return queueManager, nil
+	}
+
+	queueManager, err := s.domainAuditQueueFactory.NewDomainAuditQueueManager(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	s.bucketToDomainAuditQueueManager[bucket] = queueManager
+	return queueManager, nil
+}
+
+// SetDomainAuditQueueManager sets the domain audit QueueManager of a bucket
+func (s *BeanImpl) SetDomainAuditQueueManager(
+	bucket int,
+	queueManager persistence.QueueManager,
+) {
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.bucketToDomainAuditQueueManager[bucket] = queueManager
+}
+
 // GetShardManager get ShardManager
 func (s *BeanImpl) GetShardManager() persistence.ShardManager {
 
@@ -373,4 +429,7 @@ func (s *BeanImpl) Close() {
 	for _, executionMgr := range s.shardIDToExecutionManager {
 		executionMgr.Close()
 	}
+	for _, queueManager := range s.bucketToDomainAuditQueueManager {
+		queueManager.Close()
+	}
 }
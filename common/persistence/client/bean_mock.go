@@ -83,6 +83,21 @@ func (mr *MockBeanMockRecorder) GetConfigStoreManager() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigStoreManager", reflect.TypeOf((*MockBean)(nil).GetConfigStoreManager))
 }
 
+// GetDomainAuditQueueManager mocks base method.
+func (m *MockBean) GetDomainAuditQueueManager(arg0 int) (persistence.QueueManager, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDomainAuditQueueManager", arg0)
+	ret0, _ := ret[0].(persistence.QueueManager)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDomainAuditQueueManager indicates an expected call of GetDomainAuditQueueManager.
+func (mr *MockBeanMockRecorder) GetDomainAuditQueueManager(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDomainAuditQueueManager", reflect.TypeOf((*MockBean)(nil).GetDomainAuditQueueManager), arg0)
+}
+
 // GetDomainManager mocks base method.
 func (m *MockBean) GetDomainManager() persistence.DomainManager {
 	m.ctrl.T.Helper()
@@ -194,6 +209,18 @@ func (mr *MockBeanMockRecorder) SetConfigStoreManager(arg0 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetConfigStoreManager", reflect.TypeOf((*MockBean)(nil).SetConfigStoreManager), arg0)
 }
 
+// SetDomainAuditQueueManager mocks base method.
+func (m *MockBean) SetDomainAuditQueueManager(arg0 int, arg1 persistence.QueueManager) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetDomainAuditQueueManager", arg0, arg1)
+}
+
+// SetDomainAuditQueueManager indicates an expected call of SetDomainAuditQueueManager.
+func (mr *MockBeanMockRecorder) SetDomainAuditQueueManager(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDomainAuditQueueManager", reflect.TypeOf((*MockBean)(nil).SetDomainAuditQueueManager), arg0, arg1)
+}
+
 // SetDomainManager mocks base method.
 func (m *MockBean) SetDomainManager(arg0 persistence.DomainManager) {
 	m.ctrl.T.Helper()
@@ -277,3 +304,41 @@ func (mr *MockBeanMockRecorder) SetVisibilityManager(arg0 interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVisibilityManager", reflect.TypeOf((*MockBean)(nil).SetVisibilityManager), arg0)
 }
+
+// MockDomainAuditQueueFactory is a mock of DomainAuditQueueFactory interface.
+type MockDomainAuditQueueFactory struct {
+	ctrl     *gomock.Controller
+	recorder *MockDomainAuditQueueFactoryMockRecorder
+}
+
+// MockDomainAuditQueueFactoryMockRecorder is the mock recorder for MockDomainAuditQueueFactory.
+type MockDomainAuditQueueFactoryMockRecorder struct {
+	mock *MockDomainAuditQueueFactory
+}
+
+// NewMockDomainAuditQueueFactory creates a new mock instance.
+func NewMockDomainAuditQueueFactory(ctrl *gomock.Controller) *MockDomainAuditQueueFactory {
+	mock := &MockDomainAuditQueueFactory{ctrl: ctrl}
+	mock.recorder = &MockDomainAuditQueueFactoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDomainAuditQueueFactory) EXPECT() *MockDomainAuditQueueFactoryMockRecorder {
+	return m.recorder
+}
+
+// NewDomainAuditQueueManager mocks base method.
+func (m *MockDomainAuditQueueFactory) NewDomainAuditQueueManager(bucket int) (persistence.QueueManager, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewDomainAuditQueueManager", bucket)
+	ret0, _ := ret[0].(persistence.QueueManager)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewDomainAuditQueueManager indicates an expected call of NewDomainAuditQueueManager.
+func (mr *MockDomainAuditQueueFactoryMockRecorder) NewDomainAuditQueueManager(bucket interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewDomainAuditQueueManager", reflect.TypeOf((*MockDomainAuditQueueFactory)(nil).NewDomainAuditQueueManager), bucket)
+}
@@ -21,6 +21,7 @@
 package client
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/uber/cadence/common"
@@ -60,6 +61,10 @@ type (
 		NewVisibilityManager(params *Params, serviceConfig *service.Config) (p.VisibilityManager, error)
 		// NewDomainReplicationQueueManager returns a new queue for domain replication
 		NewDomainReplicationQueueManager() (p.QueueManager, error)
+		// NewDomainAuditQueueManager returns a new queue for the domain audit events of a bucket
+		NewDomainAuditQueueManager(bucket int) (p.QueueManager, error)
+		// NewMessagingQueueManager returns a new queue for the persistence backed messaging client
+		NewMessagingQueueManager(queueType p.QueueType) (p.QueueManager, error)
 		// NewConfigStoreManager returns a new config store manager
 		NewConfigStoreManager() (p.ConfigStoreManager, error)
 	}
@@ -275,7 +280,7 @@ func (f *factoryImpl) NewVisibilityManager(
 	}
 	if params.PersistenceConfig.AdvancedVisibilityStore != "" {
 		visibilityIndexName := params.ESConfig.Indices[common.VisibilityAppName]
-		visibilityProducer, err := params.MessagingClient.NewProducer(common.VisibilityAppName)
+		visibilityProducer, err := f.newESVisibilityProducer(params, resourceConfig)
 		if err != nil {
 			f.logger.Fatal("Creating visibility producer failed", tag.Error(err))
 		}
@@ -292,6 +297,45 @@ func (f *factoryImpl) NewVisibilityManager(
 	), nil
 }
 
+// newESVisibilityProducer creates the producer writing to ElasticSearch visibility store,
+// which publishes to Kafka unless AdvancedVisibilityWritingPipeline is direct
+func (f *factoryImpl) newESVisibilityProducer(
+	params *Params,
+	resourceConfig *service.Config,
+) (messaging.Producer, error) {
+	if resourceConfig.AdvancedVisibilityWritingPipeline != nil &&
+		resourceConfig.AdvancedVisibilityWritingPipeline() == common.AdvancedVisibilityWritingPipelineDirect {
+		// the messaging client is only set up for the visibility DLQ when writing directly
+		var dlqProducer messaging.Producer
+		if params.MessagingClient != nil {
+			var err error
+			if dlqProducer, err = params.MessagingClient.NewDLQProducer(common.VisibilityAppName); err != nil {
+				return nil, err
+			}
+		}
+		return es.NewBulkProducer(
+			params.ESClient,
+			es.NewIndexResolver(params.ESClient, params.ESConfig, f.logger),
+			&es.BulkProducerConfig{
+				NumOfWorkers:           resourceConfig.ESProcessorNumOfWorkers,
+				BulkActions:            resourceConfig.ESProcessorBulkActions,
+				BulkSize:               resourceConfig.ESProcessorBulkSize,
+				FlushInterval:          resourceConfig.ESProcessorFlushInterval,
+				ValidSearchAttributes:  resourceConfig.ValidSearchAttributes,
+				SearchAttributeAliases: resourceConfig.SearchAttributeAliases,
+			},
+			dlqProducer,
+			f.logger,
+			params.MetricsClient,
+		), nil
+	}
+	if params.MessagingClient == nil {
+		// services that only read from advanced visibility store don't need a producer
+		return nil, nil
+	}
+	return params.MessagingClient.NewProducer(common.VisibilityAppName)
+}
+
 // NewESVisibilityManager create a visibility manager for ElasticSearch
 // In history, it only needs kafka producer for writing data;
 // In frontend, it only needs ES client and related config for reading data
@@ -357,8 +401,26 @@ func (f *factoryImpl) newDBVisibilityManager(
 }
 
 func (f *factoryImpl) NewDomainReplicationQueueManager() (p.QueueManager, error) {
+	return f.newQueueManager(p.DomainReplicationQueueType)
+}
+
+func (f *factoryImpl) NewDomainAuditQueueManager(bucket int) (p.QueueManager, error) {
+	if bucket < 0 || bucket >= p.DomainAuditQueueBuckets {
+		return nil, fmt.Errorf("domain audit queue bucket %v is out of range", bucket)
+	}
+	return f.newQueueManager(p.DomainAuditQueueTypeBase + p.QueueType(bucket))
+}
+
+func (f *factoryImpl) NewMessagingQueueManager(queueType p.QueueType) (p.QueueManager, error) {
+	if queueType < p.MessagingQueueTypeBase {
+		return nil, fmt.Errorf("queue type %v is not a messaging queue type", queueType)
+	}
+	return f.newQueueManager(queueType)
+}
+
+func (f *factoryImpl) newQueueManager(queueType p.QueueType) (p.QueueManager, error) {
 	ds := f.datastores[storeTypeQueue]
-	store, err := ds.factory.NewQueue(p.DomainReplicationQueueType)
+	store, err := ds.factory.NewQueue(queueType)
 	if err != nil {
 		return nil, err
 	}
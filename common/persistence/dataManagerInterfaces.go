@@ -66,6 +66,17 @@ const (
 	DomainReplicationQueueType QueueType = iota + 1
 )
 
+// DomainAuditQueueTypeBase is the first queue type used by the domain audit log, the events of a domain
+// are kept in one of the DomainAuditQueueBuckets queue types from here on, picked by the domain name
+const (
+	DomainAuditQueueTypeBase QueueType = 100
+	DomainAuditQueueBuckets            = 64
+)
+
+// MessagingQueueTypeBase is the first queue type used by the persistence backed
+// messaging client, every partition of its queues takes one queue type from here on
+const MessagingQueueTypeBase QueueType = 1000
+
 // Create Workflow Execution Mode
 const (
 	// Fail if current record exists
@@ -1367,6 +1378,7 @@ type (
 		VisibilityArchivalStatus types.ArchivalStatus
 		VisibilityArchivalURI    string
 		BadBinaries              types.BadBinaries
+		Limits                   *types.DomainLimits
 	}
 
 	// DomainReplicationConfig describes the cross DC domain replication configuration
@@ -776,6 +776,7 @@ type (
 		VisibilityArchivalStatus types.ArchivalStatus
 		VisibilityArchivalURI    string
 		BadBinaries              *DataBlob
+		Limits                   *DataBlob
 	}
 
 	// InternalCreateDomainRequest is used to create the domain
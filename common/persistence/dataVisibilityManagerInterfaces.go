@@ -22,6 +22,9 @@ package persistence
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/uber/cadence/common/definition"
 	"github.com/uber/cadence/common/types"
@@ -141,11 +144,32 @@ type (
 		DomainUUID string
 		Domain     string // domain name is not persisted, but used as config filter key
 		Query      string
+		// GroupBy is the GROUP BY clause of the query, in the order it was written.
+		// Query never contains the GROUP BY clause itself.
+		GroupBy []*VisibilityGroupBy
+	}
+
+	// VisibilityGroupBy is a single term of the GROUP BY clause of a count query
+	VisibilityGroupBy struct {
+		// Key is the search attribute to group by
+		Key string
+		// Interval is the width of the time histogram buckets, only set when
+		// grouping a time attribute
+		Interval time.Duration
 	}
 
 	// CountWorkflowExecutionsResponse is response to CountWorkflowExecutions
 	CountWorkflowExecutionsResponse struct {
 		Count int64
+		// Buckets is only set when the request has a GROUP BY clause
+		Buckets []*VisibilityCountBucket
+	}
+
+	// VisibilityCountBucket is the count of workflows for one combination of GROUP BY values
+	VisibilityCountBucket struct {
+		// GroupValues are the values of the GROUP BY terms, in the order of the terms
+		GroupValues []string
+		Count       int64
 	}
 
 	// ListWorkflowExecutionsByTypeRequest is used to list executions of
@@ -221,3 +245,27 @@ func IsNopUpsertWorkflowRequest(request *InternalUpsertWorkflowExecutionRequest)
 	_, exist := request.SearchAttributes[definition.CadenceChangeVersion]
 	return exist
 }
+
+// FormatVisibilityGroupValue formats the value of a GROUP BY term for a count bucket.
+// Close statuses are returned by name, time histogram buckets as the RFC3339 start time
+// of the bucket in UTC and missing values as an empty string.
+func FormatVisibilityGroupValue(groupBy *VisibilityGroupBy, value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case int64:
+		if groupBy.Interval > 0 {
+			return time.Unix(0, v).UTC().Format(time.RFC3339)
+		}
+		if groupBy.Key == definition.CloseStatus {
+			return types.WorkflowExecutionCloseStatus(v).String()
+		}
+		return strconv.FormatInt(v, 10)
+	case string:
+		return v
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
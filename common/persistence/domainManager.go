@@ -191,6 +191,10 @@ func (m *domainManagerImpl) toInternalDomainConfig(c *DomainConfig) (InternalDom
 	if err != nil {
 		return InternalDomainConfig{}, err
 	}
+	limits, err := m.serializer.SerializeDomainLimits(c.Limits, common.EncodingTypeJSON)
+	if err != nil {
+		return InternalDomainConfig{}, err
+	}
 	return InternalDomainConfig{
 		Retention:                common.DaysToDuration(c.Retention),
 		EmitMetric:               c.EmitMetric,
@@ -199,6 +203,7 @@ func (m *domainManagerImpl) toInternalDomainConfig(c *DomainConfig) (InternalDom
 		VisibilityArchivalStatus: c.VisibilityArchivalStatus,
 		VisibilityArchivalURI:    c.VisibilityArchivalURI,
 		BadBinaries:              badBinaries,
+		Limits:                   limits,
 	}, nil
 }
 
@@ -213,6 +218,10 @@ func (m *domainManagerImpl) fromInternalDomainConfig(ic *InternalDomainConfig) (
 	if badBinaries.Binaries == nil {
 		badBinaries.Binaries = map[string]*types.BadBinaryInfo{}
 	}
+	limits, err := m.serializer.DeserializeDomainLimits(ic.Limits)
+	if err != nil {
+		return DomainConfig{}, err
+	}
 	return DomainConfig{
 		Retention:                common.DurationToDays(ic.Retention),
 		EmitMetric:               ic.EmitMetric,
@@ -221,6 +230,7 @@ func (m *domainManagerImpl) fromInternalDomainConfig(ic *InternalDomainConfig) (
 		VisibilityArchivalStatus: ic.VisibilityArchivalStatus,
 		VisibilityArchivalURI:    ic.VisibilityArchivalURI,
 		BadBinaries:              *badBinaries,
+		Limits:                   limits,
 	}, nil
 }
 
@@ -21,6 +21,7 @@
 package elasticsearch
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -81,7 +82,13 @@ func NewElasticSearchVisibilityStore(
 	}
 }
 
-func (v *esVisibilityStore) Close() {}
+func (v *esVisibilityStore) Close() {
+	if producer, ok := v.producer.(messaging.CloseableProducer); ok {
+		if err := producer.Close(); err != nil {
+			v.logger.Warn("Failed to close visibility producer", tag.Error(err))
+		}
+	}
+}
 
 func (v *esVisibilityStore) GetName() string {
 	return esPersistenceName
@@ -473,6 +480,10 @@ func (v *esVisibilityStore) CountWorkflowExecutions(
 		return nil, &types.BadRequestError{Message: fmt.Sprintf("Error when parse query: %v", err)}
 	}
 
+	if len(request.GroupBy) != 0 {
+		return v.countWorkflowExecutionsByGroup(ctx, request, queryDSL)
+	}
+
 	count, err := v.esClient.CountByQuery(ctx, v.index, queryDSL)
 	if err != nil {
 		return nil, &types.InternalServiceError{
@@ -484,6 +495,139 @@ func (v *esVisibilityStore) CountWorkflowExecutions(
 	return response, nil
 }
 
+// countWorkflowExecutionsByGroup pages through a composite aggregation over the GROUP BY terms.
+// Every document falls into exactly one bucket, so the total count is the sum of the buckets.
+func (v *esVisibilityStore) countWorkflowExecutionsByGroup(
+	ctx context.Context,
+	request *p.CountWorkflowExecutionsRequest,
+	queryDSL string,
+) (*p.CountWorkflowExecutionsResponse, error) {
+
+	response := &p.CountWorkflowExecutionsResponse{}
+	var afterKey json.RawMessage
+	for {
+		dsl, err := getESQueryDSLForCountByGroup(queryDSL, request.GroupBy, afterKey)
+		if err != nil {
+			return nil, &types.BadRequestError{Message: fmt.Sprintf("Error when parse query: %v", err)}
+		}
+
+		resp, err := v.esClient.SearchRaw(ctx, v.index, dsl)
+		if err != nil {
+			return nil, &types.InternalServiceError{
+				Message: fmt.Sprintf("CountWorkflowExecutions failed. Error: %v", err),
+			}
+		}
+
+		var groups compositeAggregation
+		if raw, ok := resp.Aggregations[countGroupByAggName]; ok {
+			decoder := json.NewDecoder(bytes.NewReader(raw))
+			decoder.UseNumber()
+			if err := decoder.Decode(&groups); err != nil {
+				return nil, &types.InternalServiceError{
+					Message: fmt.Sprintf("CountWorkflowExecutions failed to decode aggregation. Error: %v", err),
+				}
+			}
+		}
+
+		for _, bucket := range groups.Buckets {
+			values := make([]string, len(request.GroupBy))
+			for i, groupBy := range request.GroupBy {
+				values[i] = p.FormatVisibilityGroupValue(groupBy, convertGroupValue(groupBy, bucket.Key[groupBySourceName(i)]))
+			}
+			response.Count += bucket.DocCount
+			response.Buckets = append(response.Buckets, &p.VisibilityCountBucket{
+				GroupValues: values,
+				Count:       bucket.DocCount,
+			})
+		}
+		if len(response.Buckets) > countGroupByMaxBuckets {
+			return nil, &types.BadRequestError{
+				Message: fmt.Sprintf("CountWorkflowExecutions query matches more than %d groups, please narrow down the query", countGroupByMaxBuckets),
+			}
+		}
+
+		if len(groups.Buckets) < countGroupByPageSize || len(groups.AfterKey) == 0 {
+			return response, nil
+		}
+		afterKey = groups.AfterKey
+	}
+}
+
+type compositeAggregation struct {
+	AfterKey json.RawMessage `json:"after_key"`
+	Buckets  []struct {
+		Key      map[string]interface{} `json:"key"`
+		DocCount int64                  `json:"doc_count"`
+	} `json:"buckets"`
+}
+
+// convertGroupValue converts a composite aggregation key into the value expected by p.FormatVisibilityGroupValue
+func convertGroupValue(groupBy *p.VisibilityGroupBy, value interface{}) interface{} {
+	number, ok := value.(json.Number)
+	if !ok {
+		return value
+	}
+	if groupBy.Interval > 0 {
+		// histogram keys are doubles
+		f, err := number.Float64()
+		if err != nil {
+			return value
+		}
+		return int64(math.Round(f/float64(time.Second))) * int64(time.Second)
+	}
+	if n, err := number.Int64(); err == nil {
+		return n
+	}
+	return number.String()
+}
+
+func getESQueryDSLForCountByGroup(queryDSL string, groupBy []*p.VisibilityGroupBy, afterKey json.RawMessage) (string, error) {
+	sources := make([]map[string]interface{}, 0, len(groupBy))
+	for i, term := range groupBy {
+		field := term.Key
+		if !definition.IsSystemIndexedKey(field) {
+			field = definition.Attr + "." + field
+		}
+		var source map[string]interface{}
+		if term.Interval > 0 {
+			source = map[string]interface{}{
+				"histogram": map[string]interface{}{"field": field, "interval": term.Interval.Nanoseconds(), "missing_bucket": true},
+			}
+		} else {
+			source = map[string]interface{}{
+				"terms": map[string]interface{}{"field": field, "missing_bucket": true},
+			}
+		}
+		sources = append(sources, map[string]interface{}{groupBySourceName(i): source})
+	}
+
+	composite := map[string]interface{}{
+		"size":    countGroupByPageSize,
+		"sources": sources,
+	}
+	if len(afterKey) != 0 {
+		composite["after"] = afterKey
+	}
+	aggs, err := json.Marshal(map[string]interface{}{
+		countGroupByAggName: map[string]interface{}{"composite": composite},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	dsl, err := fastjson.Parse(queryDSL)
+	if err != nil {
+		return "", err
+	}
+	dsl.Set(dslFieldSize, fastjson.MustParse("0"))
+	dsl.Set(dslFieldAggs, fastjson.MustParseBytes(aggs))
+	return dsl.String(), nil
+}
+
+func groupBySourceName(i int) string {
+	return fmt.Sprintf("group_%d", i)
+}
+
 const (
 	jsonMissingCloseTime     = `{"missing":{"field":"CloseTime"}}`
 	jsonRangeOnExecutionTime = `{"range":{"ExecutionTime":`
@@ -494,6 +638,11 @@ const (
 	dslFieldSearchAfter = "search_after"
 	dslFieldFrom        = "from"
 	dslFieldSize        = "size"
+	dslFieldAggs        = "aggs"
+
+	countGroupByAggName    = "groups"
+	countGroupByPageSize   = 1000
+	countGroupByMaxBuckets = 10000
 
 	defaultDateTimeFormat = time.RFC3339 // used for converting UnixNano to string like 2018-02-15T16:16:36-08:00
 )
@@ -748,6 +748,23 @@ func (s *ESVisibilitySuite) TestGetESQueryDSLForCount() {
 	s.Equal(`{"query":{"bool":{"must":[{"match_phrase":{"DomainID":{"query":"bfd5c907-f899-4baf-a7b2-2ab85e623ebd"}}},{"bool":{"must":[{"range":{"ExecutionTime":{"gt":"0"}}},{"bool":{"must":[{"range":{"ExecutionTime":{"lt":"1000"}}}]}}]}}]}}}`, dsl)
 }
 
+func (s *ESVisibilitySuite) TestGetESQueryDSLForCountByGroup() {
+	queryDSL := `{"query":{"bool":{"must":[{"match_all":{}}]}}}`
+	groupBy := []*p.VisibilityGroupBy{
+		{Key: definition.WorkflowType},
+		{Key: definition.CustomKeywordField},
+		{Key: definition.StartTime, Interval: time.Hour},
+	}
+
+	dsl, err := getESQueryDSLForCountByGroup(queryDSL, groupBy, nil)
+	s.NoError(err)
+	s.Equal(`{"query":{"bool":{"must":[{"match_all":{}}]}},"size":0,"aggs":{"groups":{"composite":{"size":1000,"sources":[{"group_0":{"terms":{"field":"WorkflowType","missing_bucket":true}}},{"group_1":{"terms":{"field":"Attr.CustomKeywordField","missing_bucket":true}}},{"group_2":{"histogram":{"field":"StartTime","interval":3600000000000,"missing_bucket":true}}}]}}}}`, dsl)
+
+	dsl, err = getESQueryDSLForCountByGroup(queryDSL, groupBy[:1], json.RawMessage(`{"group_0":"type-a"}`))
+	s.NoError(err)
+	s.Equal(`{"query":{"bool":{"must":[{"match_all":{}}]}},"size":0,"aggs":{"groups":{"composite":{"after":{"group_0":"type-a"},"size":1000,"sources":[{"group_0":{"terms":{"field":"WorkflowType","missing_bucket":true}}}]}}}}`, dsl)
+}
+
 func (s *ESVisibilitySuite) TestAddDomainToQuery() {
 	dsl := fastjson.MustParse(`{}`)
 	dslStr := dsl.String()
@@ -869,6 +886,50 @@ func (s *ESVisibilitySuite) TestCountWorkflowExecutions() {
 	s.True(strings.Contains(err.Error(), "Error when parse query"))
 }
 
+func (s *ESVisibilitySuite) TestCountWorkflowExecutions_GroupBy() {
+	s.mockESClient.On("SearchRaw", mock.Anything, testIndex, mock.MatchedBy(func(input string) bool {
+		return strings.Contains(input, `"composite"`) && !strings.Contains(input, `"after"`)
+	})).Return(&es.RawResponse{
+		Aggregations: map[string]json.RawMessage{
+			"groups": json.RawMessage(`{"buckets":[
+				{"key":{"group_0":"type-a","group_1":null,"group_2":1.5283584E18},"doc_count":3},
+				{"key":{"group_0":"type-a","group_1":2,"group_2":1.5283584E18},"doc_count":1}
+			]}`),
+		},
+	}, nil).Once()
+
+	request := &p.CountWorkflowExecutionsRequest{
+		DomainUUID: testDomainID,
+		Domain:     testDomain,
+		GroupBy: []*p.VisibilityGroupBy{
+			{Key: definition.WorkflowType},
+			{Key: definition.CloseStatus},
+			{Key: definition.StartTime, Interval: time.Hour},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testContextTimeout)
+	defer cancel()
+
+	resp, err := s.visibilityStore.CountWorkflowExecutions(ctx, request)
+	s.NoError(err)
+	s.Equal(&p.CountWorkflowExecutionsResponse{
+		Count: 4,
+		Buckets: []*p.VisibilityCountBucket{
+			{GroupValues: []string{"type-a", "", "2018-06-07T08:00:00Z"}, Count: 3},
+			{GroupValues: []string{"type-a", "CANCELED", "2018-06-07T08:00:00Z"}, Count: 1},
+		},
+	}, resp)
+
+	// test internal error
+	s.mockESClient.On("SearchRaw", mock.Anything, testIndex, mock.Anything).Return(nil, errTestESSearch).Once()
+	_, err = s.visibilityStore.CountWorkflowExecutions(ctx, request)
+	s.Error(err)
+	_, ok := err.(*types.InternalServiceError)
+	s.True(ok)
+	s.True(strings.Contains(err.Error(), "CountWorkflowExecutions failed"))
+}
+
 func (s *ESVisibilitySuite) TestTimeProcessFunc() {
 	cases := []struct {
 		key   string
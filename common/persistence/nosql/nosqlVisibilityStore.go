@@ -143,7 +143,36 @@ func (v *nosqlVisibilityStore) UpsertWorkflowExecution(
 	if p.IsNopUpsertWorkflowRequest(request) {
 		return nil
 	}
-	return p.ErrVisibilityOperationNotSupported
+	// search attributes are not supported, only memo is kept in sync
+	if request.Memo == nil || len(request.Memo.Data) == 0 {
+		return p.ErrVisibilityOperationNotSupported
+	}
+
+	// the open record is rewritten by recording the start again, which would bring it back
+	// if the workflow closed in the meantime, so the upsert is dropped once it is closed
+	closed, err := v.db.SelectOneClosedWorkflow(ctx, request.DomainUUID, request.WorkflowID, request.RunID)
+	if err != nil {
+		return convertCommonErrors(v.db, "UpsertWorkflowExecution", err)
+	}
+	if closed != nil {
+		return nil
+	}
+
+	return v.RecordWorkflowExecutionStarted(ctx, &p.InternalRecordWorkflowExecutionStartedRequest{
+		DomainUUID:         request.DomainUUID,
+		WorkflowID:         request.WorkflowID,
+		RunID:              request.RunID,
+		WorkflowTypeName:   request.WorkflowTypeName,
+		StartTimestamp:     request.StartTimestamp,
+		ExecutionTimestamp: request.ExecutionTimestamp,
+		WorkflowTimeout:    request.WorkflowTimeout,
+		TaskID:             request.TaskID,
+		Memo:               request.Memo,
+		TaskList:           request.TaskList,
+		IsCron:             request.IsCron,
+		NumClusters:        request.NumClusters,
+		SearchAttributes:   request.SearchAttributes,
+	})
 }
 
 func (v *nosqlVisibilityStore) ListOpenWorkflowExecutions(
@@ -59,7 +59,9 @@ const (
 		`visibility_archival_status: ?, ` +
 		`visibility_archival_uri: ?, ` +
 		`bad_binaries: ?,` +
-		`bad_binaries_encoding: ?` +
+		`bad_binaries_encoding: ?,` +
+		`limits: ?,` +
+		`limits_encoding: ?` +
 		`}`
 
 	templateDomainReplicationConfigType = `{` +
@@ -88,6 +90,7 @@ const (
 		`config.history_archival_status, config.history_archival_uri, ` +
 		`config.visibility_archival_status, config.visibility_archival_uri, ` +
 		`config.bad_binaries, config.bad_binaries_encoding, ` +
+		`config.limits, config.limits_encoding, ` +
 		`replication_config.active_cluster_name, replication_config.clusters, ` +
 		`is_global_domain, ` +
 		`config_version, ` +
@@ -136,6 +139,7 @@ const (
 		`config.history_archival_status, config.history_archival_uri, ` +
 		`config.visibility_archival_status, config.visibility_archival_uri, ` +
 		`config.bad_binaries, config.bad_binaries_encoding, ` +
+		`config.limits, config.limits_encoding, ` +
 		`replication_config.active_cluster_name, replication_config.clusters, ` +
 		`is_global_domain, ` +
 		`config_version, ` +
@@ -175,6 +179,7 @@ func (db *cdb) InsertDomain(
 	if row.FailoverEndTime != nil {
 		failoverEndTime = row.FailoverEndTime.UnixNano()
 	}
+	limitsData, limitsEncoding := p.FromDataBlob(row.Config.Limits)
 	batch.Query(templateCreateDomainByNameQueryWithinBatchV2,
 		constDomainPartition,
 		row.Info.Name,
@@ -194,6 +199,8 @@ func (db *cdb) InsertDomain(
 		row.Config.VisibilityArchivalURI,
 		row.Config.BadBinaries.Data,
 		string(row.Config.BadBinaries.Encoding),
+		limitsData,
+		limitsEncoding,
 		row.ReplicationConfig.ActiveClusterName,
 		p.SerializeClusterConfigs(row.ReplicationConfig.Clusters),
 		row.IsGlobalDomain,
@@ -275,6 +282,7 @@ func (db *cdb) UpdateDomain(
 	if row.FailoverEndTime != nil {
 		failoverEndTime = row.FailoverEndTime.UnixNano()
 	}
+	limitsData, limitsEncoding := p.FromDataBlob(row.Config.Limits)
 	batch.Query(templateUpdateDomainByNameQueryWithinBatchV2,
 		row.Info.ID,
 		row.Info.Name,
@@ -292,6 +300,8 @@ func (db *cdb) UpdateDomain(
 		row.Config.VisibilityArchivalURI,
 		row.Config.BadBinaries.Data,
 		string(row.Config.BadBinaries.Encoding),
+		limitsData,
+		limitsEncoding,
 		row.ReplicationConfig.ActiveClusterName,
 		p.SerializeClusterConfigs(row.ReplicationConfig.Clusters),
 		row.ConfigVersion,
@@ -352,6 +362,8 @@ func (db *cdb) SelectDomain(
 	// because of encoding/types, we can't directly read from config struct
 	var badBinariesData []byte
 	var badBinariesDataEncoding string
+	var limitsData []byte
+	var limitsDataEncoding string
 	var replicationClusters []map[string]interface{}
 
 	var failoverNotificationVersion int64
@@ -382,6 +394,8 @@ func (db *cdb) SelectDomain(
 		&config.VisibilityArchivalURI,
 		&badBinariesData,
 		&badBinariesDataEncoding,
+		&limitsData,
+		&limitsDataEncoding,
 		&replicationConfig.ActiveClusterName,
 		&replicationClusters,
 		&isGlobalDomain,
@@ -399,6 +413,7 @@ func (db *cdb) SelectDomain(
 	}
 
 	config.BadBinaries = p.NewDataBlob(badBinariesData, common.EncodingType(badBinariesDataEncoding))
+	config.Limits = p.NewDataBlob(limitsData, common.EncodingType(limitsDataEncoding))
 	config.Retention = common.DaysToDuration(retentionDays)
 	replicationConfig.Clusters = p.DeserializeClusterConfigs(replicationClusters)
 
@@ -444,6 +459,8 @@ func (db *cdb) SelectAllDomains(
 	var replicationClusters []map[string]interface{}
 	var badBinariesData []byte
 	var badBinariesDataEncoding string
+	var limitsData []byte
+	var limitsDataEncoding string
 	var retentionDays int32
 	var failoverEndTime int64
 	var lastUpdateTime int64
@@ -466,6 +483,8 @@ func (db *cdb) SelectAllDomains(
 		&domain.Config.VisibilityArchivalURI,
 		&badBinariesData,
 		&badBinariesDataEncoding,
+		&limitsData,
+		&limitsDataEncoding,
 		&domain.ReplicationConfig.ActiveClusterName,
 		&replicationClusters,
 		&domain.IsGlobalDomain,
@@ -480,6 +499,7 @@ func (db *cdb) SelectAllDomains(
 		if name != domainMetadataRecordName {
 			// do not include the metadata record
 			domain.Config.BadBinaries = p.NewDataBlob(badBinariesData, common.EncodingType(badBinariesDataEncoding))
+			domain.Config.Limits = p.NewDataBlob(limitsData, common.EncodingType(limitsDataEncoding))
 			domain.ReplicationConfig.Clusters = p.DeserializeClusterConfigs(replicationClusters)
 			domain.Config.Retention = common.DaysToDuration(retentionDays)
 			domain.LastUpdatedTime = time.Unix(0, lastUpdateTime)
@@ -491,6 +511,8 @@ func (db *cdb) SelectAllDomains(
 		replicationClusters = []map[string]interface{}{}
 		badBinariesData = []byte("")
 		badBinariesDataEncoding = ""
+		limitsData = nil
+		limitsDataEncoding = ""
 		failoverEndTime = 0
 		lastUpdateTime = 0
 		retentionDays = 0
@@ -851,6 +851,184 @@ func (s *DBVisibilityPersistenceSuite) TestUpsertWorkflowExecution() {
 	}
 }
 
+// TestUpsertWorkflowExecutionMemo test
+func (s *DBVisibilityPersistenceSuite) TestUpsertWorkflowExecutionMemo() {
+	ctx, cancel := context.WithTimeout(context.Background(), testContextTimeout)
+	defer cancel()
+
+	testDomainUUID := uuid.New()
+
+	workflowExecution := types.WorkflowExecution{
+		WorkflowID: "visibility-workflow-test-upsert-memo",
+		RunID:      "1b2c3d4e-5f60-4718-8293-a4b5c6d7e8f9",
+	}
+
+	startTime := time.Now().Add(time.Second * -5).UnixNano()
+	err0 := s.VisibilityMgr.RecordWorkflowExecutionStarted(ctx, &p.RecordWorkflowExecutionStartedRequest{
+		DomainUUID:       testDomainUUID,
+		Execution:        workflowExecution,
+		WorkflowTypeName: "visibility-workflow",
+		StartTimestamp:   startTime,
+		WorkflowTimeout:  3600,
+		Memo:             &types.Memo{Fields: map[string][]byte{"progress": []byte("10%")}},
+	})
+	s.Nil(err0)
+
+	upsertedMemo := &types.Memo{Fields: map[string][]byte{"progress": []byte("50%")}}
+	err1 := s.VisibilityMgr.UpsertWorkflowExecution(ctx, &p.UpsertWorkflowExecutionRequest{
+		DomainUUID:       testDomainUUID,
+		Execution:        workflowExecution,
+		WorkflowTypeName: "visibility-workflow",
+		StartTimestamp:   startTime,
+		WorkflowTimeout:  3600,
+		Memo:             upsertedMemo,
+	})
+	s.Nil(err1)
+
+	resp, err2 := s.VisibilityMgr.ListOpenWorkflowExecutions(ctx, &p.ListWorkflowExecutionsRequest{
+		DomainUUID:   testDomainUUID,
+		PageSize:     1,
+		EarliestTime: startTime,
+		LatestTime:   startTime,
+	})
+	s.Nil(err2)
+	s.Equal(1, len(resp.Executions))
+	s.Equal(upsertedMemo, resp.Executions[0].Memo)
+}
+
+// TestUpsertWorkflowExecutionMemo_Closed test
+func (s *DBVisibilityPersistenceSuite) TestUpsertWorkflowExecutionMemo_Closed() {
+	ctx, cancel := context.WithTimeout(context.Background(), testContextTimeout)
+	defer cancel()
+
+	testDomainUUID := uuid.New()
+
+	workflowExecution := types.WorkflowExecution{
+		WorkflowID: "visibility-workflow-test-upsert-memo-closed",
+		RunID:      "2c3d4e5f-6071-4829-93a4-b5c6d7e8f9a0",
+	}
+
+	startTime := time.Now().Add(time.Second * -5).UnixNano()
+	err0 := s.VisibilityMgr.RecordWorkflowExecutionStarted(ctx, &p.RecordWorkflowExecutionStartedRequest{
+		DomainUUID:       testDomainUUID,
+		Execution:        workflowExecution,
+		WorkflowTypeName: "visibility-workflow",
+		StartTimestamp:   startTime,
+		WorkflowTimeout:  3600,
+		Memo:             &types.Memo{Fields: map[string][]byte{"progress": []byte("10%")}},
+	})
+	s.Nil(err0)
+
+	closeReq := &p.RecordWorkflowExecutionClosedRequest{
+		DomainUUID:       testDomainUUID,
+		Execution:        workflowExecution,
+		WorkflowTypeName: "visibility-workflow",
+		StartTimestamp:   startTime,
+		Status:           types.WorkflowExecutionCloseStatusCompleted,
+		CloseTimestamp:   time.Now().UnixNano(),
+		HistoryLength:    5,
+	}
+	err1 := s.VisibilityMgr.RecordWorkflowExecutionClosed(ctx, closeReq)
+	s.Nil(err1)
+
+	// an upsert processed after the close must not bring the open record back
+	err2 := s.VisibilityMgr.UpsertWorkflowExecution(ctx, &p.UpsertWorkflowExecutionRequest{
+		DomainUUID:       testDomainUUID,
+		Execution:        workflowExecution,
+		WorkflowTypeName: "visibility-workflow",
+		StartTimestamp:   startTime,
+		WorkflowTimeout:  3600,
+		Memo:             &types.Memo{Fields: map[string][]byte{"progress": []byte("50%")}},
+	})
+	s.Nil(err2)
+
+	resp, err3 := s.VisibilityMgr.ListOpenWorkflowExecutions(ctx, &p.ListWorkflowExecutionsRequest{
+		DomainUUID:   testDomainUUID,
+		PageSize:     1,
+		EarliestTime: startTime,
+		LatestTime:   startTime,
+	})
+	s.Nil(err3)
+	s.Equal(0, len(resp.Executions))
+}
+
+func (s *DBVisibilityPersistenceSuite) TestCountWorkflowExecutionsGroupBy() {
+	ctx, cancel := context.WithTimeout(context.Background(), testContextTimeout)
+	defer cancel()
+
+	if s.VisibilityMgr.GetName() == "cassandra" {
+		// count is not supported by cassandra visibility
+		return
+	}
+	testDomainUUID := uuid.New()
+	startTime := time.Now().Add(time.Second * -5).UnixNano()
+	for i, workflowType := range []string{"type-a", "type-a", "type-b"} {
+		startReq := &p.RecordWorkflowExecutionStartedRequest{
+			DomainUUID: testDomainUUID,
+			Execution: types.WorkflowExecution{
+				WorkflowID: uuid.New(),
+				RunID:      uuid.New(),
+			},
+			WorkflowTypeName: workflowType,
+			StartTimestamp:   startTime,
+			TaskList:         "count-tasklist",
+		}
+		s.Nil(s.VisibilityMgr.RecordWorkflowExecutionStarted(ctx, startReq))
+		if i == 0 {
+			s.Nil(s.VisibilityMgr.RecordWorkflowExecutionClosed(ctx, &p.RecordWorkflowExecutionClosedRequest{
+				DomainUUID:       testDomainUUID,
+				Execution:        startReq.Execution,
+				WorkflowTypeName: workflowType,
+				StartTimestamp:   startTime,
+				CloseTimestamp:   time.Now().UnixNano(),
+				Status:           types.WorkflowExecutionCloseStatusFailed,
+				HistoryLength:    3,
+				TaskList:         "count-tasklist",
+			}))
+		}
+	}
+
+	resp, err := s.VisibilityMgr.CountWorkflowExecutions(ctx, &p.CountWorkflowExecutionsRequest{
+		DomainUUID: testDomainUUID,
+		Query:      "CloseTime = missing",
+	})
+	s.Nil(err)
+	s.Equal(int64(2), resp.Count)
+	s.Nil(resp.Buckets)
+
+	resp, err = s.VisibilityMgr.CountWorkflowExecutions(ctx, &p.CountWorkflowExecutionsRequest{
+		DomainUUID: testDomainUUID,
+		GroupBy: []*p.VisibilityGroupBy{
+			{Key: definition.WorkflowType},
+			{Key: definition.CloseStatus},
+			{Key: definition.TaskList},
+		},
+	})
+	s.Nil(err)
+	s.Equal(int64(3), resp.Count)
+	s.ElementsMatch([]*p.VisibilityCountBucket{
+		{GroupValues: []string{"type-a", "", "count-tasklist"}, Count: 1},
+		{GroupValues: []string{"type-a", "FAILED", "count-tasklist"}, Count: 1},
+		{GroupValues: []string{"type-b", "", "count-tasklist"}, Count: 1},
+	}, resp.Buckets)
+
+	resp, err = s.VisibilityMgr.CountWorkflowExecutions(ctx, &p.CountWorkflowExecutionsRequest{
+		DomainUUID: testDomainUUID,
+		Query:      "WorkflowType = 'type-a'",
+		GroupBy:    []*p.VisibilityGroupBy{{Key: definition.StartTime, Interval: time.Hour}},
+	})
+	s.Nil(err)
+	s.Equal(int64(2), resp.Count)
+	s.Equal(1, len(resp.Buckets))
+	s.Equal([]string{time.Unix(0, startTime).UTC().Truncate(time.Hour).Format(time.RFC3339)}, resp.Buckets[0].GroupValues)
+
+	_, err = s.VisibilityMgr.CountWorkflowExecutions(ctx, &p.CountWorkflowExecutionsRequest{
+		DomainUUID: testDomainUUID,
+		Query:      "WorkflowType = 'type-a' or WorkflowType = 'type-b'",
+	})
+	s.IsType(&types.BadRequestError{}, err)
+}
+
 func (s *DBVisibilityPersistenceSuite) assertClosedExecutionEquals(
 	req *p.RecordWorkflowExecutionClosedRequest, resp *types.WorkflowExecutionInfo) {
 	s.Equal(req.Execution.RunID, resp.Execution.RunID)
@@ -41,6 +41,10 @@ type Retryer interface {
 	GetShardID() int
 	GetTimerIndexTasks(context.Context, *GetTimerIndexTasksRequest) (*GetTimerIndexTasksResponse, error)
 	CompleteTimerTask(ctx context.Context, request *CompleteTimerTaskRequest) error
+	GetTransferTasks(context.Context, *GetTransferTasksRequest) (*GetTransferTasksResponse, error)
+	CompleteTransferTask(context.Context, *CompleteTransferTaskRequest) error
+	GetReplicationTasks(context.Context, *GetReplicationTasksRequest) (*GetReplicationTasksResponse, error)
+	CompleteReplicationTask(context.Context, *CompleteReplicationTaskRequest) error
 }
 
 type (
@@ -232,3 +236,65 @@ func (pr *persistenceRetryer) CompleteTimerTask(
 
 	return pr.throttleRetry.Do(ctx, op)
 }
+
+// GetTransferTasks retries GetTransferTasks
+func (pr *persistenceRetryer) GetTransferTasks(
+	ctx context.Context,
+	req *GetTransferTasksRequest,
+) (*GetTransferTasksResponse, error) {
+	var resp *GetTransferTasksResponse
+	op := func() error {
+		var err error
+		resp, err = pr.execManager.GetTransferTasks(ctx, req)
+		return err
+	}
+	err := pr.throttleRetry.Do(ctx, op)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// CompleteTransferTask is a retryable version of CompleteTransferTask method
+func (pr *persistenceRetryer) CompleteTransferTask(
+	ctx context.Context,
+	request *CompleteTransferTaskRequest,
+) error {
+	op := func() error {
+		return pr.execManager.CompleteTransferTask(ctx, request)
+	}
+
+	return pr.throttleRetry.Do(ctx, op)
+}
+
+// GetReplicationTasks retries GetReplicationTasks
+func (pr *persistenceRetryer) GetReplicationTasks(
+	ctx context.Context,
+	req *GetReplicationTasksRequest,
+) (*GetReplicationTasksResponse, error) {
+	var resp *GetReplicationTasksResponse
+	op := func() error {
+		var err error
+		resp, err = pr.execManager.GetReplicationTasks(ctx, req)
+		return err
+	}
+	err := pr.throttleRetry.Do(ctx, op)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// CompleteReplicationTask is a retryable version of CompleteReplicationTask method
+func (pr *persistenceRetryer) CompleteReplicationTask(
+	ctx context.Context,
+	request *CompleteReplicationTaskRequest,
+) error {
+	op := func() error {
+		return pr.execManager.CompleteReplicationTask(ctx, request)
+	}
+
+	return pr.throttleRetry.Do(ctx, op)
+}
@@ -316,6 +316,22 @@ func (d *DomainInfo) GetBadBinariesEncoding() (o string) {
 	return
 }
 
+// GetLimits internal sql blob getter
+func (d *DomainInfo) GetLimits() (o []byte) {
+	if d != nil {
+		return d.Limits
+	}
+	return
+}
+
+// GetLimitsEncoding internal sql blob getter
+func (d *DomainInfo) GetLimitsEncoding() (o string) {
+	if d != nil {
+		return d.LimitsEncoding
+	}
+	return
+}
+
 // GetHistoryArchivalStatus internal sql blob getter
 func (d *DomainInfo) GetHistoryArchivalStatus() (o int16) {
 	if d != nil {
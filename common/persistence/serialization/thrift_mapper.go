@@ -126,6 +126,8 @@ func domainInfoToThrift(info *DomainInfo) *sqlblobs.DomainInfo {
 		RetentionDays:               durationToDaysInt16Ptr(info.Retention),
 		FailoverEndTime:             unixNanoPtr(info.FailoverEndTimestamp),
 		LastUpdatedTime:             timeToUnixNanoPtr(info.LastUpdatedTimestamp),
+		Limits:                      info.Limits,
+		LimitsEncoding:              &info.LimitsEncoding,
 	}
 }
 
@@ -158,6 +160,8 @@ func domainInfoFromThrift(info *sqlblobs.DomainInfo) *DomainInfo {
 		Retention:                   common.DaysToDuration(int32(info.GetRetentionDays())),
 		FailoverEndTimestamp:        timePtr(info.FailoverEndTime),
 		LastUpdatedTimestamp:        timeFromUnixNano(info.GetLastUpdatedTime()),
+		Limits:                      info.Limits,
+		LimitsEncoding:              info.GetLimitsEncoding(),
 	}
 }
 
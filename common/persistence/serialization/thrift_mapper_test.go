@@ -104,6 +104,8 @@ func TestDomainInfo(t *testing.T) {
 		FailoverEndTimestamp:        common.TimePtr(time.Now()),
 		PreviousFailoverVersion:     int64(rand.Intn(1000)),
 		LastUpdatedTimestamp:        time.Now(),
+		Limits:                      []byte("Limits"),
+		LimitsEncoding:              "LimitsEncoding",
 	}
 	actual := domainInfoFromThrift(domainInfoToThrift(expected))
 	assert.Equal(t, expected.Name, actual.Name)
@@ -129,6 +131,8 @@ func TestDomainInfo(t *testing.T) {
 	assert.Equal(t, expected.FailoverEndTimestamp.Sub(*actual.FailoverEndTimestamp), time.Duration(0))
 	assert.Equal(t, expected.PreviousFailoverVersion, actual.PreviousFailoverVersion)
 	assert.Equal(t, expected.LastUpdatedTimestamp.Sub(actual.LastUpdatedTimestamp), time.Duration(0))
+	assert.Equal(t, expected.Limits, actual.Limits)
+	assert.Equal(t, expected.LimitsEncoding, actual.LimitsEncoding)
 }
 
 func TestHistoryTreeInfo(t *testing.T) {
@@ -58,6 +58,10 @@ type (
 		SerializeBadBinaries(event *types.BadBinaries, encodingType common.EncodingType) (*DataBlob, error)
 		DeserializeBadBinaries(data *DataBlob) (*types.BadBinaries, error)
 
+		// serialize/deserialize domain limits
+		SerializeDomainLimits(limits *types.DomainLimits, encodingType common.EncodingType) (*DataBlob, error)
+		DeserializeDomainLimits(data *DataBlob) (*types.DomainLimits, error)
+
 		// serialize/deserialize version histories
 		SerializeVersionHistories(histories *types.VersionHistories, encodingType common.EncodingType) (*DataBlob, error)
 		DeserializeVersionHistories(data *DataBlob) (*types.VersionHistories, error)
@@ -160,6 +164,23 @@ func (t *serializerImpl) DeserializeBadBinaries(data *DataBlob) (*types.BadBinar
 	return &bb, err
 }
 
+func (t *serializerImpl) SerializeDomainLimits(limits *types.DomainLimits, encodingType common.EncodingType) (*DataBlob, error) {
+	if limits == nil {
+		return nil, nil
+	}
+	// domain limits have no thrift definition, they are always stored as JSON
+	return t.serialize(limits, common.EncodingTypeJSON)
+}
+
+func (t *serializerImpl) DeserializeDomainLimits(data *DataBlob) (*types.DomainLimits, error) {
+	if data == nil || len(data.Data) == 0 {
+		return nil, nil
+	}
+	var limits types.DomainLimits
+	err := t.deserialize(data, &limits)
+	return &limits, err
+}
+
 func (t *serializerImpl) SerializeVisibilityMemo(memo *types.Memo, encodingType common.EncodingType) (*DataBlob, error) {
 	if memo == nil {
 		// Return nil here to be consistent with Event
@@ -528,3 +528,28 @@ func (s *cadenceSerializerSuite) TestSerializer() {
 	succ := common.AwaitWaitGroup(&doneWG, 10*time.Second)
 	s.True(succ, "test timed out")
 }
+
+func (s *cadenceSerializerSuite) TestDomainLimits() {
+	serializer := NewPayloadSerializer()
+
+	nilLimits, err := serializer.SerializeDomainLimits(nil, common.EncodingTypeThriftRW)
+	s.Nil(err)
+	s.Nil(nilLimits)
+
+	dNilLimits, err := serializer.DeserializeDomainLimits(nil)
+	s.Nil(err)
+	s.Nil(dNilLimits)
+
+	limits := &types.DomainLimits{
+		UserRPS:          common.Int32Ptr(100),
+		HistorySizeLimit: common.Int64Ptr(1024),
+	}
+	// thriftrw is not supported for domain limits so JSON is always used
+	blob, err := serializer.SerializeDomainLimits(limits, common.EncodingTypeThriftRW)
+	s.Nil(err)
+	s.Equal(common.EncodingTypeJSON, blob.Encoding)
+
+	dLimits, err := serializer.DeserializeDomainLimits(blob)
+	s.Nil(err)
+	s.Equal(limits, dLimits)
+}
@@ -104,6 +104,7 @@ func (m *sqlDomainStore) CreateDomain(
 		badBinaries = request.Config.BadBinaries.Data
 		badBinariesEncoding = string(request.Config.BadBinaries.GetEncoding())
 	}
+	limits, limitsEncoding := persistence.FromDataBlob(request.Config.Limits)
 
 	domainInfo := &serialization.DomainInfo{
 		Name:                        request.Info.Name,
@@ -129,6 +130,8 @@ func (m *sqlDomainStore) CreateDomain(
 		LastUpdatedTimestamp:        request.LastUpdatedTime,
 		BadBinaries:                 badBinaries,
 		BadBinariesEncoding:         badBinariesEncoding,
+		Limits:                      limits,
+		LimitsEncoding:              limitsEncoding,
 	}
 
 	blob, err := m.parser.DomainInfoToBlob(domainInfo)
@@ -245,6 +248,7 @@ func (m *sqlDomainStore) domainRowToGetDomainResponse(row *sqlplugin.DomainRow)
 			VisibilityArchivalStatus: types.ArchivalStatus(domainInfo.GetVisibilityArchivalStatus()),
 			VisibilityArchivalURI:    domainInfo.GetVisibilityArchivalURI(),
 			BadBinaries:              badBinaries,
+			Limits:                   persistence.NewDataBlob(domainInfo.GetLimits(), common.EncodingType(domainInfo.GetLimitsEncoding())),
 		},
 		ReplicationConfig: &persistence.DomainReplicationConfig{
 			ActiveClusterName: cluster.GetOrUseDefaultActiveCluster(m.activeClusterName, domainInfo.GetActiveClusterName()),
@@ -277,6 +281,7 @@ func (m *sqlDomainStore) UpdateDomain(
 		badBinaries = request.Config.BadBinaries.Data
 		badBinariesEncoding = string(request.Config.BadBinaries.GetEncoding())
 	}
+	limits, limitsEncoding := persistence.FromDataBlob(request.Config.Limits)
 
 	domainInfo := &serialization.DomainInfo{
 		Status:                      int32(request.Info.Status),
@@ -302,6 +307,8 @@ func (m *sqlDomainStore) UpdateDomain(
 		LastUpdatedTimestamp:        request.LastUpdatedTime,
 		BadBinaries:                 badBinaries,
 		BadBinariesEncoding:         badBinariesEncoding,
+		Limits:                      limits,
+		LimitsEncoding:              limitsEncoding,
 	}
 
 	blob, err := m.parser.DomainInfoToBlob(domainInfo)
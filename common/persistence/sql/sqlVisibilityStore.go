@@ -77,6 +77,7 @@ func (s *sqlVisibilityStore) RecordWorkflowExecutionStarted(
 		Encoding:         string(request.Memo.GetEncoding()),
 		IsCron:           request.IsCron,
 		NumClusters:      request.NumClusters,
+		TaskList:         request.TaskList,
 	})
 
 	if err != nil {
@@ -104,6 +105,7 @@ func (s *sqlVisibilityStore) RecordWorkflowExecutionClosed(
 		Encoding:         string(request.Memo.GetEncoding()),
 		IsCron:           request.IsCron,
 		NumClusters:      request.NumClusters,
+		TaskList:         request.TaskList,
 	})
 	if err != nil {
 		return convertCommonErrors(s.db, "RecordWorkflowExecutionClosed", "", err)
@@ -131,13 +133,27 @@ func (s *sqlVisibilityStore) RecordWorkflowExecutionUninitialized(
 }
 
 func (s *sqlVisibilityStore) UpsertWorkflowExecution(
-	_ context.Context,
+	ctx context.Context,
 	request *p.InternalUpsertWorkflowExecutionRequest,
 ) error {
 	if p.IsNopUpsertWorkflowRequest(request) {
 		return nil
 	}
-	return p.ErrVisibilityOperationNotSupported
+	// search attributes are not supported, only memo is kept in sync
+	if request.Memo == nil || len(request.Memo.Data) == 0 {
+		return p.ErrVisibilityOperationNotSupported
+	}
+
+	_, err := s.db.UpdateVisibilityMemo(ctx, &sqlplugin.VisibilityRow{
+		DomainID: request.DomainUUID,
+		RunID:    request.RunID,
+		Memo:     request.Memo.Data,
+		Encoding: string(request.Memo.GetEncoding()),
+	})
+	if err != nil {
+		return convertCommonErrors(s.db, "UpsertWorkflowExecution", "", err)
+	}
+	return nil
 }
 
 func (s *sqlVisibilityStore) ListOpenWorkflowExecutions(
@@ -315,10 +331,19 @@ func (s *sqlVisibilityStore) ScanWorkflowExecutions(
 }
 
 func (s *sqlVisibilityStore) CountWorkflowExecutions(
-	_ context.Context,
-	_ *p.CountWorkflowExecutionsRequest,
+	ctx context.Context,
+	request *p.CountWorkflowExecutionsRequest,
 ) (*p.CountWorkflowExecutionsResponse, error) {
-	return nil, p.ErrVisibilityOperationNotSupported
+	filter, err := getVisibilityCountFilter(request)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.CountFromVisibility(ctx, filter)
+	if err != nil {
+		return nil, convertCommonErrors(s.db, "CountWorkflowExecutions", "", err)
+	}
+	return getCountWorkflowExecutionsResponse(request.GroupBy, rows)
 }
 
 func (s *sqlVisibilityStore) rowToInfo(row *sqlplugin.VisibilityRow) *p.InternalVisibilityWorkflowExecutionInfo {
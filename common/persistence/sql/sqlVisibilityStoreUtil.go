@@ -0,0 +1,284 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sql
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xwb1989/sqlparser"
+
+	"github.com/uber/cadence/common/definition"
+	p "github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/persistence/sql/sqlplugin"
+	"github.com/uber/cadence/common/types"
+)
+
+// missingValue is the value used by visibility queries to match unset attributes, e.g. CloseTime = missing
+const missingValue = "missing"
+
+// visibilityColumns maps the search attributes that can be queried on SQL visibility to their column
+var visibilityColumns = map[string]string{
+	definition.WorkflowID:    "workflow_id",
+	definition.RunID:         "run_id",
+	definition.WorkflowType:  "workflow_type_name",
+	definition.TaskList:      "task_list",
+	definition.CloseStatus:   "close_status",
+	definition.StartTime:     "start_time",
+	definition.ExecutionTime: "execution_time",
+	definition.CloseTime:     "close_time",
+	definition.HistoryLength: "history_length",
+	definition.IsCron:        "is_cron",
+}
+
+// getVisibilityCountFilter translates a validated count query into a filter for the visibility table.
+// SQL visibility only supports conjunctions of comparisons on the columns of the visibility table.
+func getVisibilityCountFilter(request *p.CountWorkflowExecutionsRequest) (*sqlplugin.VisibilityCountFilter, error) {
+	filter := &sqlplugin.VisibilityCountFilter{DomainID: request.DomainUUID}
+
+	if len(request.GroupBy) > sqlplugin.MaxVisibilityGroupBy {
+		return nil, newUnsupportedQueryError(fmt.Sprintf("at most %d GROUP BY terms are supported", sqlplugin.MaxVisibilityGroupBy))
+	}
+	for _, groupBy := range request.GroupBy {
+		column, err := getVisibilityColumn(groupBy.Key)
+		if err != nil {
+			return nil, err
+		}
+		switch column {
+		case "workflow_type_name", "task_list", "close_status":
+		case "start_time", "execution_time", "close_time":
+			if groupBy.Interval == 0 {
+				return nil, newUnsupportedQueryError(fmt.Sprintf("cannot group by %s without histogram", groupBy.Key))
+			}
+		default:
+			return nil, newUnsupportedQueryError(fmt.Sprintf("cannot group by %s", groupBy.Key))
+		}
+		filter.GroupBy = append(filter.GroupBy, sqlplugin.VisibilityGroupBy{Column: column, Interval: groupBy.Interval})
+	}
+
+	query := strings.TrimSpace(request.Query)
+	if len(query) == 0 || isJustOrderByClause(query) {
+		return filter, nil
+	}
+	// IMPORTANT: This query is never executed, it is just used to parse the where clause
+	stmt, err := sqlparser.Parse("SELECT * FROM dummy WHERE " + query)
+	if err != nil {
+		return nil, &types.BadRequestError{Message: fmt.Sprintf("Error when parse query: %v", err)}
+	}
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok || sel.Where == nil {
+		return nil, &types.BadRequestError{Message: "Invalid select query."}
+	}
+	if err := appendVisibilityConditions(filter, sel.Where.Expr); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+func appendVisibilityConditions(filter *sqlplugin.VisibilityCountFilter, expr sqlparser.Expr) error {
+	switch expr := expr.(type) {
+	case *sqlparser.AndExpr:
+		if err := appendVisibilityConditions(filter, expr.Left); err != nil {
+			return err
+		}
+		return appendVisibilityConditions(filter, expr.Right)
+	case *sqlparser.ParenExpr:
+		return appendVisibilityConditions(filter, expr.Expr)
+	case *sqlparser.ComparisonExpr:
+		return appendVisibilityComparison(filter, expr)
+	case *sqlparser.RangeCond:
+		return appendVisibilityRange(filter, expr)
+	default:
+		return newUnsupportedQueryError(fmt.Sprintf("%s is not supported", sqlparser.String(expr)))
+	}
+}
+
+func appendVisibilityComparison(filter *sqlplugin.VisibilityCountFilter, expr *sqlparser.ComparisonExpr) error {
+	colName, ok := expr.Left.(*sqlparser.ColName)
+	if !ok {
+		return newUnsupportedQueryError(fmt.Sprintf("%s is not supported", sqlparser.String(expr)))
+	}
+	column, err := getVisibilityColumn(colName.Name.String())
+	if err != nil {
+		return err
+	}
+
+	if isMissingValue(expr.Right) {
+		switch expr.Operator {
+		case sqlparser.EqualStr:
+			filter.Conditions = append(filter.Conditions, sqlplugin.VisibilityCondition{Column: column, Operator: "IS NULL"})
+			return nil
+		case sqlparser.NotEqualStr:
+			filter.Conditions = append(filter.Conditions, sqlplugin.VisibilityCondition{Column: column, Operator: "IS NOT NULL"})
+			return nil
+		}
+		return newUnsupportedQueryError(fmt.Sprintf("%s is not supported", sqlparser.String(expr)))
+	}
+
+	switch expr.Operator {
+	case sqlparser.EqualStr, sqlparser.NotEqualStr,
+		sqlparser.LessThanStr, sqlparser.LessEqualStr,
+		sqlparser.GreaterThanStr, sqlparser.GreaterEqualStr:
+	default:
+		return newUnsupportedQueryError(fmt.Sprintf("operator %s is not supported", expr.Operator))
+	}
+	value, err := getVisibilityValue(column, expr.Right)
+	if err != nil {
+		return err
+	}
+	filter.Conditions = append(filter.Conditions, sqlplugin.VisibilityCondition{Column: column, Operator: expr.Operator, Value: value})
+	return nil
+}
+
+func appendVisibilityRange(filter *sqlplugin.VisibilityCountFilter, expr *sqlparser.RangeCond) error {
+	colName, ok := expr.Left.(*sqlparser.ColName)
+	if !ok || expr.Operator != sqlparser.BetweenStr {
+		return newUnsupportedQueryError(fmt.Sprintf("%s is not supported", sqlparser.String(expr)))
+	}
+	column, err := getVisibilityColumn(colName.Name.String())
+	if err != nil {
+		return err
+	}
+	from, err := getVisibilityValue(column, expr.From)
+	if err != nil {
+		return err
+	}
+	to, err := getVisibilityValue(column, expr.To)
+	if err != nil {
+		return err
+	}
+	filter.Conditions = append(filter.Conditions,
+		sqlplugin.VisibilityCondition{Column: column, Operator: sqlparser.GreaterEqualStr, Value: from},
+		sqlplugin.VisibilityCondition{Column: column, Operator: sqlparser.LessEqualStr, Value: to},
+	)
+	return nil
+}
+
+func getVisibilityColumn(key string) (string, error) {
+	column, ok := visibilityColumns[key]
+	if !ok {
+		return "", newUnsupportedQueryError(fmt.Sprintf("search attribute %s is not supported", strings.TrimPrefix(key, definition.Attr+".")))
+	}
+	return column, nil
+}
+
+func getVisibilityValue(column string, expr sqlparser.Expr) (interface{}, error) {
+	if boolVal, ok := expr.(sqlparser.BoolVal); ok && column == "is_cron" {
+		return bool(boolVal), nil
+	}
+	val, ok := expr.(*sqlparser.SQLVal)
+	if !ok {
+		return nil, newUnsupportedQueryError(fmt.Sprintf("value %s is not supported", sqlparser.String(expr)))
+	}
+
+	str := string(val.Val)
+	var value interface{}
+	var err error
+	switch column {
+	case "start_time", "execution_time", "close_time":
+		value, err = parseVisibilityTime(str)
+	case "close_status":
+		value, err = strconv.ParseInt(str, 10, 32)
+	case "history_length":
+		value, err = strconv.ParseInt(str, 10, 64)
+	case "is_cron":
+		value, err = strconv.ParseBool(str)
+	default:
+		value = str
+	}
+	if err != nil {
+		return nil, &types.BadRequestError{Message: fmt.Sprintf("invalid value %q for %s", str, column)}
+	}
+	return value, nil
+}
+
+// parseVisibilityTime parses unix nanoseconds or RFC3339 strings, as Elasticsearch visibility does
+func parseVisibilityTime(str string) (time.Time, error) {
+	if nanos, err := strconv.ParseInt(str, 10, 64); err == nil {
+		return time.Unix(0, nanos).UTC(), nil
+	}
+	return time.Parse(time.RFC3339, str)
+}
+
+func isMissingValue(expr sqlparser.Expr) bool {
+	colName, ok := expr.(*sqlparser.ColName)
+	return ok && colName.Name.EqualString(missingValue)
+}
+
+func isJustOrderByClause(query string) bool {
+	return strings.HasPrefix(strings.ToLower(query), "order by")
+}
+
+func getCountWorkflowExecutionsResponse(
+	groupBy []*p.VisibilityGroupBy,
+	rows []sqlplugin.VisibilityCountRow,
+) (*p.CountWorkflowExecutionsResponse, error) {
+	response := &p.CountWorkflowExecutionsResponse{}
+	for _, row := range rows {
+		response.Count += row.Count
+		if len(groupBy) == 0 {
+			continue
+		}
+
+		rawValues := []sql.NullString{row.FirstGroupValue, row.SecondGroupValue, row.ThirdGroupValue}
+		values := make([]string, len(groupBy))
+		for i, term := range groupBy {
+			value, err := convertGroupValue(term, rawValues[i])
+			if err != nil {
+				return nil, &types.InternalServiceError{
+					Message: fmt.Sprintf("CountWorkflowExecutions failed to convert group value. Error: %v", err),
+				}
+			}
+			values[i] = p.FormatVisibilityGroupValue(term, value)
+		}
+		response.Buckets = append(response.Buckets, &p.VisibilityCountBucket{
+			GroupValues: values,
+			Count:       row.Count,
+		})
+	}
+	return response, nil
+}
+
+// convertGroupValue converts a group value into the value expected by p.FormatVisibilityGroupValue
+func convertGroupValue(groupBy *p.VisibilityGroupBy, value sql.NullString) (interface{}, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	switch {
+	case groupBy.Interval > 0:
+		secs, err := strconv.ParseInt(value.String, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return time.Unix(secs, 0), nil
+	case groupBy.Key == definition.CloseStatus:
+		return strconv.ParseInt(value.String, 10, 32)
+	default:
+		return value.String, nil
+	}
+}
+
+func newUnsupportedQueryError(reason string) error {
+	return &types.BadRequestError{Message: fmt.Sprintf("Query is not supported by SQL visibility: %s.", reason)}
+}
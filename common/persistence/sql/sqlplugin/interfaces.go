@@ -538,6 +538,7 @@ type (
 		Encoding         string
 		IsCron           bool
 		NumClusters      int16
+		TaskList         string
 	}
 
 	// VisibilityFilter contains the column names within executions_visibility table that
@@ -554,6 +555,39 @@ type (
 		PageSize         *int
 	}
 
+	// VisibilityCountFilter contains the conditions and the GROUP BY columns used
+	// to count rows of executions_visibility table
+	VisibilityCountFilter struct {
+		DomainID   string
+		Conditions []VisibilityCondition
+		GroupBy    []VisibilityGroupBy
+	}
+
+	// VisibilityCondition compares a column of executions_visibility table with a value.
+	// IS NULL and IS NOT NULL operators take no value.
+	VisibilityCondition struct {
+		Column   string
+		Operator string
+		Value    interface{}
+	}
+
+	// VisibilityGroupBy is a column of executions_visibility table to group by.
+	// A non-zero Interval groups a time column into buckets of that width.
+	VisibilityGroupBy struct {
+		Column   string
+		Interval time.Duration
+	}
+
+	// VisibilityCountRow is a group returned by CountFromVisibility. Group values are
+	// set in the order of the GROUP BY columns, time buckets are returned as the unix
+	// timestamp in seconds of the start of the bucket.
+	VisibilityCountRow struct {
+		FirstGroupValue  sql.NullString
+		SecondGroupValue sql.NullString
+		ThirdGroupValue  sql.NullString
+		Count            int64
+	}
+
 	// QueueRow represents a row in queue table
 	QueueRow struct {
 		QueueType      persistence.QueueType
@@ -775,6 +809,9 @@ type (
 		InsertIntoVisibility(ctx context.Context, row *VisibilityRow) (sql.Result, error)
 		// ReplaceIntoVisibility deletes old row (if it exist) and inserts new row into visibility table
 		ReplaceIntoVisibility(ctx context.Context, row *VisibilityRow) (sql.Result, error)
+		// UpdateVisibilityMemo updates the memo of an open row in visibility table,
+		// closed rows are left as such
+		UpdateVisibilityMemo(ctx context.Context, row *VisibilityRow) (sql.Result, error)
 		// SelectFromVisibility returns one or more rows from visibility table
 		// Required filter params:
 		// - getClosedWorkflowExecution - retrieves single row - {domainID, runID, closed=true}
@@ -785,6 +822,10 @@ type (
 		//     - workflowID, workflowTypeName, closeStatus (along with closed=true)
 		SelectFromVisibility(ctx context.Context, filter *VisibilityFilter) ([]VisibilityRow, error)
 		DeleteFromVisibility(ctx context.Context, filter *VisibilityFilter) (sql.Result, error)
+		// CountFromVisibility counts the rows of visibility table matching the conditions,
+		// grouped by up to MaxVisibilityGroupBy columns
+		// Required filter params - {domainID}
+		CountFromVisibility(ctx context.Context, filter *VisibilityCountFilter) ([]VisibilityCountRow, error)
 
 		InsertIntoQueue(ctx context.Context, row *QueueRow) (sql.Result, error)
 		GetLastEnqueuedMessageIDForUpdate(ctx context.Context, queueType persistence.QueueType) (int64, error)
@@ -25,18 +25,22 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/uber/cadence/common/persistence/sql/sqlplugin"
 )
 
 const (
 	templateCreateWorkflowExecutionStarted = `INSERT IGNORE INTO executions_visibility (` +
-		`domain_id, workflow_id, run_id, start_time, execution_time, workflow_type_name, memo, encoding, is_cron, num_clusters) ` +
-		`VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		`domain_id, workflow_id, run_id, start_time, execution_time, workflow_type_name, memo, encoding, is_cron, num_clusters, task_list) ` +
+		`VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	templateCreateWorkflowExecutionClosed = `REPLACE INTO executions_visibility (` +
-		`domain_id, workflow_id, run_id, start_time, execution_time, workflow_type_name, close_time, close_status, history_length, memo, encoding, is_cron, num_clusters) ` +
-		`VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		`domain_id, workflow_id, run_id, start_time, execution_time, workflow_type_name, close_time, close_status, history_length, memo, encoding, is_cron, num_clusters, task_list) ` +
+		`VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	templateUpdateWorkflowExecutionMemo = `UPDATE executions_visibility SET memo = ?, encoding = ? ` +
+		`WHERE domain_id = ? AND run_id = ? AND close_status IS NULL`
 
 	// RunID condition is needed for correct pagination
 	templateConditions = ` AND domain_id = ?
@@ -93,7 +97,20 @@ func (mdb *db) InsertIntoVisibility(ctx context.Context, row *sqlplugin.Visibili
 		row.Memo,
 		row.Encoding,
 		row.IsCron,
-		row.NumClusters)
+		row.NumClusters,
+		row.TaskList)
+}
+
+// UpdateVisibilityMemo updates the memo of an open row in visibility table
+func (mdb *db) UpdateVisibilityMemo(ctx context.Context, row *sqlplugin.VisibilityRow) (sql.Result, error) {
+	dbShardID := sqlplugin.GetDBShardIDFromDomainID(row.DomainID, mdb.GetTotalNumDBShards())
+	return mdb.driver.ExecContext(ctx,
+		dbShardID,
+		templateUpdateWorkflowExecutionMemo,
+		row.Memo,
+		row.Encoding,
+		row.DomainID,
+		row.RunID)
 }
 
 // ReplaceIntoVisibility replaces an existing row if it exist or creates a new row in visibility table
@@ -118,7 +135,8 @@ func (mdb *db) ReplaceIntoVisibility(ctx context.Context, row *sqlplugin.Visibil
 			row.Memo,
 			row.Encoding,
 			row.IsCron,
-			row.NumClusters)
+			row.NumClusters,
+			row.TaskList)
 	default:
 		return nil, errCloseParams
 	}
@@ -223,3 +241,27 @@ func (mdb *db) SelectFromVisibility(ctx context.Context, filter *sqlplugin.Visib
 	}
 	return rows, err
 }
+
+// CountFromVisibility counts the rows of visibility table matching the filter
+func (mdb *db) CountFromVisibility(ctx context.Context, filter *sqlplugin.VisibilityCountFilter) ([]sqlplugin.VisibilityCountRow, error) {
+	dbShardID := sqlplugin.GetDBShardIDFromDomainID(filter.DomainID, mdb.GetTotalNumDBShards())
+	for i := range filter.Conditions {
+		if t, ok := filter.Conditions[i].Value.(time.Time); ok {
+			filter.Conditions[i].Value = mdb.converter.ToMySQLDateTime(t)
+		}
+	}
+	query, args, err := sqlplugin.BuildVisibilityCountQuery(
+		filter,
+		func(int) string { return "?" },
+		func(column string, intervalSecs int64) string {
+			return fmt.Sprintf("FLOOR(TIMESTAMPDIFF(SECOND, '1970-01-01 00:00:00', %s) / %d) * %d", column, intervalSecs, intervalSecs)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []sqlplugin.VisibilityCountRow
+	err = mdb.driver.SelectContext(ctx, dbShardID, &rows, query, args...)
+	return rows, err
+}
@@ -26,19 +26,20 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/uber/cadence/common/persistence/sql/sqlplugin"
 )
 
 const (
 	templateCreateWorkflowExecutionStarted = `INSERT INTO executions_visibility (` +
-		`domain_id, workflow_id, run_id, start_time, execution_time, workflow_type_name, memo, encoding, is_cron, num_clusters) ` +
-		`VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`domain_id, workflow_id, run_id, start_time, execution_time, workflow_type_name, memo, encoding, is_cron, num_clusters, task_list) ` +
+		`VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
          ON CONFLICT (domain_id, run_id) DO NOTHING`
 
 	templateCreateWorkflowExecutionClosed = `INSERT INTO executions_visibility (` +
-		`domain_id, workflow_id, run_id, start_time, execution_time, workflow_type_name, close_time, close_status, history_length, memo, encoding, is_cron, num_clusters) ` +
-		`VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		`domain_id, workflow_id, run_id, start_time, execution_time, workflow_type_name, close_time, close_status, history_length, memo, encoding, is_cron, num_clusters, task_list) ` +
+		`VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		ON CONFLICT (domain_id, run_id) DO UPDATE
 		  SET workflow_id = excluded.workflow_id,
 		      start_time = excluded.start_time,
@@ -50,7 +51,11 @@ const (
 			  memo = excluded.memo,
 			  encoding = excluded.encoding,
 				is_cron = excluded.is_cron,
-				num_clusters = excluded.num_clusters`
+				num_clusters = excluded.num_clusters,
+				task_list = excluded.task_list`
+
+	templateUpdateWorkflowExecutionMemo = `UPDATE executions_visibility SET memo = $1, encoding = $2 ` +
+		`WHERE domain_id = $3 AND run_id = $4 AND close_status IS NULL`
 
 	// RunID condition is needed for correct pagination
 	templateConditions1 = ` AND domain_id = $1
@@ -112,7 +117,18 @@ func (pdb *db) InsertIntoVisibility(ctx context.Context, row *sqlplugin.Visibili
 		row.Memo,
 		row.Encoding,
 		row.IsCron,
-		row.NumClusters)
+		row.NumClusters,
+		row.TaskList)
+}
+
+// UpdateVisibilityMemo updates the memo of an open row in visibility table
+func (pdb *db) UpdateVisibilityMemo(ctx context.Context, row *sqlplugin.VisibilityRow) (sql.Result, error) {
+	dbShardID := sqlplugin.GetDBShardIDFromDomainID(row.DomainID, pdb.GetTotalNumDBShards())
+	return pdb.driver.ExecContext(ctx, dbShardID, templateUpdateWorkflowExecutionMemo,
+		row.Memo,
+		row.Encoding,
+		row.DomainID,
+		row.RunID)
 }
 
 // ReplaceIntoVisibility replaces an existing row if it exist or creates a new row in visibility table
@@ -135,7 +151,8 @@ func (pdb *db) ReplaceIntoVisibility(ctx context.Context, row *sqlplugin.Visibil
 			row.Memo,
 			row.Encoding,
 			row.IsCron,
-			row.NumClusters)
+			row.NumClusters,
+			row.TaskList)
 	default:
 		return nil, errCloseParams
 	}
@@ -236,3 +253,29 @@ func (pdb *db) SelectFromVisibility(ctx context.Context, filter *sqlplugin.Visib
 	}
 	return rows, err
 }
+
+// CountFromVisibility counts the rows of visibility table matching the filter
+func (pdb *db) CountFromVisibility(ctx context.Context, filter *sqlplugin.VisibilityCountFilter) ([]sqlplugin.VisibilityCountRow, error) {
+	dbShardID := sqlplugin.GetDBShardIDFromDomainID(filter.DomainID, pdb.GetTotalNumDBShards())
+	for i := range filter.Conditions {
+		if t, ok := filter.Conditions[i].Value.(time.Time); ok {
+			filter.Conditions[i].Value = pdb.converter.ToPostgresDateTime(t)
+		}
+	}
+	// timestamps are stored in the local time zone of the server, see DataConverter
+	offsetSecs := int64(localOffset / time.Second)
+	query, args, err := sqlplugin.BuildVisibilityCountQuery(
+		filter,
+		func(n int) string { return fmt.Sprintf("$%d", n) },
+		func(column string, intervalSecs int64) string {
+			return fmt.Sprintf("CAST(FLOOR((EXTRACT(EPOCH FROM %s) - %d) / %d) * %d AS BIGINT)", column, offsetSecs, intervalSecs, intervalSecs)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []sqlplugin.VisibilityCountRow
+	err = pdb.driver.SelectContext(ctx, dbShardID, &rows, query, args...)
+	return rows, err
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sqlplugin
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxVisibilityGroupBy is the maximum number of GROUP BY columns supported by CountFromVisibility
+const MaxVisibilityGroupBy = 3
+
+var (
+	visibilityCountColumns = map[string]struct{}{
+		"workflow_id":        {},
+		"run_id":             {},
+		"workflow_type_name": {},
+		"task_list":          {},
+		"close_status":       {},
+		"start_time":         {},
+		"execution_time":     {},
+		"close_time":         {},
+		"history_length":     {},
+		"is_cron":            {},
+	}
+	visibilityTimeColumns = map[string]struct{}{
+		"start_time":     {},
+		"execution_time": {},
+		"close_time":     {},
+	}
+	visibilityCountOperators = map[string]struct{}{
+		"=":           {},
+		"!=":          {},
+		"<":           {},
+		"<=":          {},
+		">":           {},
+		">=":          {},
+		"IS NULL":     {},
+		"IS NOT NULL": {},
+	}
+	visibilityGroupAliases = []string{"first_group_value", "second_group_value", "third_group_value"}
+)
+
+// BuildVisibilityCountQuery builds the query used by CountFromVisibility.
+// placeholder returns the placeholder of the n-th (1-based) argument and timeBucket returns
+// an expression of the start of the bucket, in unix seconds, a time column falls into.
+// Columns and operators are checked against an allow list as they are not passed as arguments.
+func BuildVisibilityCountQuery(
+	filter *VisibilityCountFilter,
+	placeholder func(n int) string,
+	timeBucket func(column string, intervalSecs int64) string,
+) (string, []interface{}, error) {
+	if len(filter.GroupBy) > MaxVisibilityGroupBy {
+		return "", nil, fmt.Errorf("at most %d group by columns are supported", MaxVisibilityGroupBy)
+	}
+
+	args := []interface{}{filter.DomainID}
+	conditions := []string{"domain_id = " + placeholder(len(args))}
+	for _, condition := range filter.Conditions {
+		if _, ok := visibilityCountColumns[condition.Column]; !ok {
+			return "", nil, fmt.Errorf("unsupported column %q", condition.Column)
+		}
+		if _, ok := visibilityCountOperators[condition.Operator]; !ok {
+			return "", nil, fmt.Errorf("unsupported operator %q", condition.Operator)
+		}
+		if condition.Operator == "IS NULL" || condition.Operator == "IS NOT NULL" {
+			conditions = append(conditions, condition.Column+" "+condition.Operator)
+			continue
+		}
+		args = append(args, condition.Value)
+		conditions = append(conditions, condition.Column+" "+condition.Operator+" "+placeholder(len(args)))
+	}
+
+	selects := make([]string, 0, len(visibilityGroupAliases)+1)
+	groups := make([]string, 0, len(filter.GroupBy))
+	for i, alias := range visibilityGroupAliases {
+		if i >= len(filter.GroupBy) {
+			selects = append(selects, "NULL AS "+alias)
+			continue
+		}
+		groupBy := filter.GroupBy[i]
+		if _, ok := visibilityCountColumns[groupBy.Column]; !ok {
+			return "", nil, fmt.Errorf("unsupported column %q", groupBy.Column)
+		}
+		expr := groupBy.Column
+		if groupBy.Interval > 0 {
+			if _, ok := visibilityTimeColumns[groupBy.Column]; !ok {
+				return "", nil, fmt.Errorf("column %q is not a time column", groupBy.Column)
+			}
+			intervalSecs := int64(groupBy.Interval / time.Second)
+			if intervalSecs <= 0 {
+				return "", nil, fmt.Errorf("invalid interval %v", groupBy.Interval)
+			}
+			expr = timeBucket(groupBy.Column, intervalSecs)
+		}
+		selects = append(selects, expr+" AS "+alias)
+		groups = append(groups, expr)
+	}
+	selects = append(selects, "COUNT(*) AS count")
+
+	query := "SELECT " + strings.Join(selects, ", ") +
+		" FROM executions_visibility WHERE " + strings.Join(conditions, " AND ")
+	if len(groups) != 0 {
+		query += " GROUP BY " + strings.Join(groups, ", ") + " ORDER BY " + strings.Join(groups, ", ")
+	}
+	return query, args, nil
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sqlplugin
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildVisibilityCountQuery(t *testing.T) {
+	placeholder := func(n int) string { return fmt.Sprintf("$%d", n) }
+	timeBucket := func(column string, intervalSecs int64) string {
+		return fmt.Sprintf("bucket(%s, %d)", column, intervalSecs)
+	}
+
+	query, args, err := BuildVisibilityCountQuery(&VisibilityCountFilter{DomainID: "domain"}, placeholder, timeBucket)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT NULL AS first_group_value, NULL AS second_group_value, NULL AS third_group_value, COUNT(*) AS count "+
+		"FROM executions_visibility WHERE domain_id = $1", query)
+	assert.Equal(t, []interface{}{"domain"}, args)
+
+	query, args, err = BuildVisibilityCountQuery(&VisibilityCountFilter{
+		DomainID: "domain",
+		Conditions: []VisibilityCondition{
+			{Column: "workflow_type_name", Operator: "=", Value: "type"},
+			{Column: "close_time", Operator: "IS NULL"},
+		},
+		GroupBy: []VisibilityGroupBy{
+			{Column: "task_list"},
+			{Column: "start_time", Interval: time.Hour},
+		},
+	}, placeholder, timeBucket)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT task_list AS first_group_value, bucket(start_time, 3600) AS second_group_value, NULL AS third_group_value, COUNT(*) AS count "+
+		"FROM executions_visibility WHERE domain_id = $1 AND workflow_type_name = $2 AND close_time IS NULL "+
+		"GROUP BY task_list, bucket(start_time, 3600) ORDER BY task_list, bucket(start_time, 3600)", query)
+	assert.Equal(t, []interface{}{"domain", "type"}, args)
+
+	_, _, err = BuildVisibilityCountQuery(&VisibilityCountFilter{
+		Conditions: []VisibilityCondition{{Column: "memo", Operator: "=", Value: "x"}},
+	}, placeholder, timeBucket)
+	assert.EqualError(t, err, `unsupported column "memo"`)
+
+	_, _, err = BuildVisibilityCountQuery(&VisibilityCountFilter{
+		Conditions: []VisibilityCondition{{Column: "run_id", Operator: "= 1 OR 1 =", Value: "x"}},
+	}, placeholder, timeBucket)
+	assert.EqualError(t, err, `unsupported operator "= 1 OR 1 ="`)
+
+	_, _, err = BuildVisibilityCountQuery(&VisibilityCountFilter{
+		GroupBy: []VisibilityGroupBy{{Column: "task_list", Interval: time.Hour}},
+	}, placeholder, timeBucket)
+	assert.EqualError(t, err, `column "task_list" is not a time column`)
+}
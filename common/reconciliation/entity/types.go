@@ -68,6 +68,43 @@ type (
 		ScheduleAttempt     int64
 		Version             int64
 	}
+
+	// TransferTask is a transfer task which might not have an owning execution
+	TransferTask struct {
+		ShardID    int
+		DomainID   string
+		WorkflowID string
+		RunID      string
+		TaskID     int64
+		TaskType   int
+	}
+
+	// ReplicationTask is a replication task which might not have an owning execution
+	ReplicationTask struct {
+		ShardID    int
+		DomainID   string
+		WorkflowID string
+		RunID      string
+		TaskID     int64
+		TaskType   int
+	}
+
+	// HistoryBranch is a history branch which might not have an owning execution
+	HistoryBranch struct {
+		DomainID   string
+		WorkflowID string
+		RunID      string
+		TreeID     string
+		BranchID   string
+	}
+
+	// TaskList is a task list row which might be abandoned
+	TaskList struct {
+		DomainID string
+		Name     string
+		TaskType int
+		RangeID  int64
+	}
 )
 
 func (t *Timer) Validate() error {
@@ -99,6 +136,126 @@ func (t *Timer) GetDomainID() string {
 	return t.DomainID
 }
 
+// Validate returns an error if TransferTask is not valid, nil otherwise.
+func (t *TransferTask) Validate() error {
+	return validateTask(t.ShardID, t.DomainID, t.WorkflowID, t.RunID)
+}
+
+// Clone will return a new copy of TransferTask
+func (t *TransferTask) Clone() Entity {
+	return &TransferTask{}
+}
+
+// GetShardID returns shard id
+func (t *TransferTask) GetShardID() int {
+	return t.ShardID
+}
+
+// GetDomainID returns domain id
+func (t *TransferTask) GetDomainID() string {
+	return t.DomainID
+}
+
+// Validate returns an error if ReplicationTask is not valid, nil otherwise.
+func (t *ReplicationTask) Validate() error {
+	return validateTask(t.ShardID, t.DomainID, t.WorkflowID, t.RunID)
+}
+
+// Clone will return a new copy of ReplicationTask
+func (t *ReplicationTask) Clone() Entity {
+	return &ReplicationTask{}
+}
+
+// GetShardID returns shard id
+func (t *ReplicationTask) GetShardID() int {
+	return t.ShardID
+}
+
+// GetDomainID returns domain id
+func (t *ReplicationTask) GetDomainID() string {
+	return t.DomainID
+}
+
+func validateTask(shardID int, domainID, workflowID, runID string) error {
+	if shardID < 0 {
+		return fmt.Errorf("invalid ShardID: %v", shardID)
+	}
+	if len(domainID) == 0 {
+		return errors.New("empty DomainID")
+	}
+	if len(workflowID) == 0 {
+		return errors.New("empty WorkflowID")
+	}
+	if len(runID) == 0 {
+		return errors.New("empty RunID")
+	}
+	return nil
+}
+
+// Validate returns an error if HistoryBranch is not valid, nil otherwise.
+func (hb *HistoryBranch) Validate() error {
+	if len(hb.DomainID) == 0 {
+		return errors.New("empty DomainID")
+	}
+	if len(hb.WorkflowID) == 0 {
+		return errors.New("empty WorkflowID")
+	}
+	if len(hb.RunID) == 0 {
+		return errors.New("empty RunID")
+	}
+	if len(hb.TreeID) == 0 {
+		return errors.New("empty TreeID")
+	}
+	if len(hb.BranchID) == 0 {
+		return errors.New("empty BranchID")
+	}
+	return nil
+}
+
+// Clone will return a new copy of HistoryBranch
+func (hb *HistoryBranch) Clone() Entity {
+	return &HistoryBranch{}
+}
+
+// GetShardID returns shard id, history branches do not belong to a shard
+func (hb *HistoryBranch) GetShardID() int {
+	return 0
+}
+
+// GetDomainID returns the domain id
+func (hb *HistoryBranch) GetDomainID() string {
+	return hb.DomainID
+}
+
+// Validate returns an error if TaskList is not valid, nil otherwise.
+func (tl *TaskList) Validate() error {
+	if len(tl.DomainID) == 0 {
+		return errors.New("empty DomainID")
+	}
+	if len(tl.Name) == 0 {
+		return errors.New("empty Name")
+	}
+	if tl.TaskType != persistence.TaskListTypeDecision && tl.TaskType != persistence.TaskListTypeActivity {
+		return fmt.Errorf("unknown task list type: %v", tl.TaskType)
+	}
+	return nil
+}
+
+// Clone will return a new copy of TaskList
+func (tl *TaskList) Clone() Entity {
+	return &TaskList{}
+}
+
+// GetShardID returns shard id, task lists do not belong to a shard
+func (tl *TaskList) GetShardID() int {
+	return 0
+}
+
+// GetDomainID returns the domain id
+func (tl *TaskList) GetDomainID() string {
+	return tl.DomainID
+}
+
 // ValidateExecution returns an error if Execution is not valid, nil otherwise.
 func validateExecution(execution *Execution) error {
 	if execution.ShardID < 0 {
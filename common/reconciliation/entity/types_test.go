@@ -188,3 +188,23 @@ func (t *TypeSuite) TestValidateExecution() {
 		}
 	}
 }
+
+func (t *TypeSuite) TestValidateHistoryBranch() {
+	t.Error((&HistoryBranch{}).Validate())
+	t.Error((&HistoryBranch{DomainID: domainID, WorkflowID: workflowID, RunID: runID, TreeID: treeID}).Validate())
+	t.NoError((&HistoryBranch{DomainID: domainID, WorkflowID: workflowID, RunID: runID, TreeID: treeID, BranchID: branchID}).Validate())
+}
+
+func (t *TypeSuite) TestValidateTaskList() {
+	t.Error((&TaskList{}).Validate())
+	t.Error((&TaskList{DomainID: domainID, Name: "tl", TaskType: 5}).Validate())
+	t.NoError((&TaskList{DomainID: domainID, Name: "tl", TaskType: persistence.TaskListTypeActivity}).Validate())
+}
+
+func (t *TypeSuite) TestValidateQueueTasks() {
+	t.Error((&TransferTask{}).Validate())
+	t.Error((&TransferTask{ShardID: -1, DomainID: domainID, WorkflowID: workflowID, RunID: runID}).Validate())
+	t.NoError((&TransferTask{DomainID: domainID, WorkflowID: workflowID, RunID: runID}).Validate())
+	t.Error((&ReplicationTask{DomainID: domainID, WorkflowID: workflowID}).Validate())
+	t.NoError((&ReplicationTask{DomainID: domainID, WorkflowID: workflowID, RunID: runID}).Validate())
+}
@@ -42,6 +42,30 @@ func ConcreteExecutionIterator(
 	return pagination.NewIterator(ctx, nil, getConcreteExecutions(retryer, pageSize, codec.NewThriftRWEncoder()))
 }
 
+// DomainConcreteExecutionIterator is used to retrieve Concrete executions of a single domain.
+func DomainConcreteExecutionIterator(
+	ctx context.Context,
+	retryer persistence.Retryer,
+	pageSize int,
+	domainID string,
+) pagination.Iterator {
+	fetchFn := getConcreteExecutions(retryer, pageSize, codec.NewThriftRWEncoder())
+	return pagination.NewIterator(ctx, nil, func(ctx context.Context, token pagination.PageToken) (pagination.Page, error) {
+		page, err := fetchFn(ctx, token)
+		if err != nil {
+			return pagination.Page{}, err
+		}
+		var executions []pagination.Entity
+		for _, e := range page.Entities {
+			if e.(*entity.ConcreteExecution).DomainID == domainID {
+				executions = append(executions, e)
+			}
+		}
+		page.Entities = executions
+		return page, nil
+	})
+}
+
 // ConcreteExecution returns a single ConcreteExecution from persistence
 func ConcreteExecution(
 	ctx context.Context,
@@ -0,0 +1,134 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+
+package fetcher
+
+import (
+	"context"
+	"math"
+
+	"github.com/uber/cadence/common/pagination"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/reconciliation/entity"
+)
+
+// TransferTaskIterator is used to retrieve all transfer tasks of a shard.
+func TransferTaskIterator(
+	ctx context.Context,
+	retryer persistence.Retryer,
+	pageSize int,
+) pagination.Iterator {
+	return pagination.NewIterator(ctx, nil, getTransferTasks(retryer, pageSize))
+}
+
+// ReplicationTaskIterator is used to retrieve all replication tasks of a shard.
+func ReplicationTaskIterator(
+	ctx context.Context,
+	retryer persistence.Retryer,
+	pageSize int,
+) pagination.Iterator {
+	return pagination.NewIterator(ctx, nil, getReplicationTasks(retryer, pageSize))
+}
+
+func getTransferTasks(
+	pr persistence.Retryer,
+	pageSize int,
+) pagination.FetchFn {
+	return func(ctx context.Context, token pagination.PageToken) (pagination.Page, error) {
+		req := &persistence.GetTransferTasksRequest{
+			ReadLevel:    0,
+			MaxReadLevel: math.MaxInt64,
+			BatchSize:    pageSize,
+		}
+		if token != nil {
+			req.NextPageToken = token.([]byte)
+		}
+		resp, err := pr.GetTransferTasks(ctx, req)
+		if err != nil {
+			return pagination.Page{}, err
+		}
+
+		var tasks []pagination.Entity
+		for _, t := range resp.Tasks {
+			task := &entity.TransferTask{
+				ShardID:    pr.GetShardID(),
+				DomainID:   t.DomainID,
+				WorkflowID: t.WorkflowID,
+				RunID:      t.RunID,
+				TaskID:     t.TaskID,
+				TaskType:   t.TaskType,
+			}
+			if err := task.Validate(); err != nil {
+				return pagination.Page{}, err
+			}
+			tasks = append(tasks, task)
+		}
+		return taskPage(token, resp.NextPageToken, tasks), nil
+	}
+}
+
+func getReplicationTasks(
+	pr persistence.Retryer,
+	pageSize int,
+) pagination.FetchFn {
+	return func(ctx context.Context, token pagination.PageToken) (pagination.Page, error) {
+		req := &persistence.GetReplicationTasksRequest{
+			ReadLevel:    0,
+			MaxReadLevel: math.MaxInt64,
+			BatchSize:    pageSize,
+		}
+		if token != nil {
+			req.NextPageToken = token.([]byte)
+		}
+		resp, err := pr.GetReplicationTasks(ctx, req)
+		if err != nil {
+			return pagination.Page{}, err
+		}
+
+		var tasks []pagination.Entity
+		for _, t := range resp.Tasks {
+			task := &entity.ReplicationTask{
+				ShardID:    pr.GetShardID(),
+				DomainID:   t.DomainID,
+				WorkflowID: t.WorkflowID,
+				RunID:      t.RunID,
+				TaskID:     t.TaskID,
+				TaskType:   t.TaskType,
+			}
+			if err := task.Validate(); err != nil {
+				return pagination.Page{}, err
+			}
+			tasks = append(tasks, task)
+		}
+		return taskPage(token, resp.NextPageToken, tasks), nil
+	}
+}
+
+func taskPage(token pagination.PageToken, nextPageToken []byte, tasks []pagination.Entity) pagination.Page {
+	var nextToken interface{} = nextPageToken
+	if len(nextPageToken) == 0 {
+		nextToken = nil
+	}
+	return pagination.Page{
+		CurrentToken: token,
+		NextToken:    nextToken,
+		Entities:     tasks,
+	}
+}
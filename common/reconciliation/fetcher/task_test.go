@@ -0,0 +1,93 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+
+package fetcher
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/mocks"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/reconciliation/entity"
+)
+
+func TestTransferTaskIterator(t *testing.T) {
+	execManager := &mocks.ExecutionManager{}
+	execManager.On("GetShardID").Return(3)
+	execManager.On("GetTransferTasks", mock.Anything, mock.MatchedBy(func(req *persistence.GetTransferTasksRequest) bool {
+		return req.NextPageToken == nil
+	})).Return(&persistence.GetTransferTasksResponse{
+		Tasks:         []*persistence.TransferTaskInfo{{DomainID: "d", WorkflowID: "w", RunID: "r1", TaskID: 1}},
+		NextPageToken: []byte("next"),
+	}, nil).Once()
+	execManager.On("GetTransferTasks", mock.Anything, mock.MatchedBy(func(req *persistence.GetTransferTasksRequest) bool {
+		return string(req.NextPageToken) == "next"
+	})).Return(&persistence.GetTransferTasksResponse{
+		Tasks: []*persistence.TransferTaskInfo{{DomainID: "d", WorkflowID: "w", RunID: "r2", TaskID: 2, TaskType: persistence.TransferTaskTypeCloseExecution}},
+	}, nil).Once()
+	pr := persistence.NewPersistenceRetryer(execManager, nil, common.CreatePersistenceRetryPolicy())
+
+	it := TransferTaskIterator(context.Background(), pr, 10)
+	var tasks []*entity.TransferTask
+	for it.HasNext() {
+		e, err := it.Next()
+		require.NoError(t, err)
+		tasks = append(tasks, e.(*entity.TransferTask))
+	}
+	require.Equal(t, []*entity.TransferTask{
+		{ShardID: 3, DomainID: "d", WorkflowID: "w", RunID: "r1", TaskID: 1},
+		{ShardID: 3, DomainID: "d", WorkflowID: "w", RunID: "r2", TaskID: 2, TaskType: persistence.TransferTaskTypeCloseExecution},
+	}, tasks)
+}
+
+func TestReplicationTaskIterator(t *testing.T) {
+	execManager := &mocks.ExecutionManager{}
+	execManager.On("GetShardID").Return(3)
+	execManager.On("GetReplicationTasks", mock.Anything, mock.Anything).Return(&persistence.GetReplicationTasksResponse{
+		Tasks: []*persistence.ReplicationTaskInfo{{DomainID: "d", WorkflowID: "w", RunID: "r", TaskID: 5}},
+	}, nil).Once()
+	pr := persistence.NewPersistenceRetryer(execManager, nil, common.CreatePersistenceRetryPolicy())
+
+	it := ReplicationTaskIterator(context.Background(), pr, 10)
+	require.True(t, it.HasNext())
+	e, err := it.Next()
+	require.NoError(t, err)
+	require.Equal(t, &entity.ReplicationTask{ShardID: 3, DomainID: "d", WorkflowID: "w", RunID: "r", TaskID: 5}, e)
+	require.False(t, it.HasNext())
+}
+
+func TestReplicationTaskIterator_InvalidTask(t *testing.T) {
+	execManager := &mocks.ExecutionManager{}
+	execManager.On("GetShardID").Return(3)
+	execManager.On("GetReplicationTasks", mock.Anything, mock.Anything).Return(&persistence.GetReplicationTasksResponse{
+		Tasks: []*persistence.ReplicationTaskInfo{{DomainID: "d", WorkflowID: "w"}},
+	}, nil).Once()
+	pr := persistence.NewPersistenceRetryer(execManager, nil, common.CreatePersistenceRetryPolicy())
+
+	it := ReplicationTaskIterator(context.Background(), pr, 10)
+	require.False(t, it.HasNext())
+	_, err := it.Next()
+	require.Error(t, err)
+}
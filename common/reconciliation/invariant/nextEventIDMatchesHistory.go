@@ -0,0 +1,175 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+
+package invariant
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uber/cadence/client/history"
+	c "github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/reconciliation/entity"
+	"github.com/uber/cadence/common/types"
+)
+
+type (
+	nextEventIDMatchesHistory struct {
+		pr               persistence.Retryer
+		dc               cache.DomainCache
+		hc               history.Client
+		terminateAllowed dynamicconfig.BoolPropertyFnWithDomainFilter
+	}
+)
+
+// NewNextEventIDMatchesHistory returns a new invariant which checks that the last event batch
+// in history of an open execution ends right before mutable state NextEventID.
+// Executions which cannot be reset are only terminated by Fix in domains for which terminateAllowed is true.
+func NewNextEventIDMatchesHistory(
+	pr persistence.Retryer,
+	dc cache.DomainCache,
+	hc history.Client,
+	terminateAllowed dynamicconfig.BoolPropertyFnWithDomainFilter,
+) Invariant {
+	return &nextEventIDMatchesHistory{
+		pr:               pr,
+		dc:               dc,
+		hc:               hc,
+		terminateAllowed: terminateAllowed,
+	}
+}
+
+func (n *nextEventIDMatchesHistory) Check(
+	ctx context.Context,
+	execution interface{},
+) CheckResult {
+	if checkResult := validateCheckContext(ctx, n.Name()); checkResult != nil {
+		return *checkResult
+	}
+
+	concreteExecution, state, checkResult := getOpenExecutionState(ctx, execution, n.Name(), n.pr, n.dc)
+	if checkResult != nil {
+		return *checkResult
+	}
+
+	executionInfo := state.ExecutionInfo
+	events, err := readHistoryEvents(ctx, n.pr, n.dc, concreteExecution, executionInfo.LastFirstEventID, executionInfo.NextEventID)
+	if err != nil {
+		switch err.(type) {
+		case *types.EntityNotExistsError:
+			return CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   n.Name(),
+				Info:            "last event batch is missing from history",
+				InfoDetails:     err.Error(),
+			}
+		default:
+			return CheckResult{
+				CheckResultType: CheckResultTypeFailed,
+				InvariantName:   n.Name(),
+				Info:            "failed to read history",
+				InfoDetails:     err.Error(),
+			}
+		}
+	}
+	if len(events) == 0 || events[len(events)-1].ID != executionInfo.NextEventID-1 {
+		lastEventID := executionInfo.LastFirstEventID - 1
+		if len(events) != 0 {
+			lastEventID = events[len(events)-1].ID
+		}
+		return CheckResult{
+			CheckResultType: CheckResultTypeCorrupted,
+			InvariantName:   n.Name(),
+			Info:            "last event in history does not match NextEventID",
+			InfoDetails:     fmt.Sprintf("NextEventID: %v, LastEventID: %v", executionInfo.NextEventID, lastEventID),
+		}
+	}
+	return CheckResult{
+		CheckResultType: CheckResultTypeHealthy,
+		InvariantName:   n.Name(),
+	}
+}
+
+// Fix resets the execution to the last completed decision in the complete part of its history.
+// Neither refreshing tasks nor scheduling a decision repairs an incomplete history, as workers cannot
+// read it to replay the workflow, while a reset rebuilds mutable state from the events which exist and
+// schedules a new decision on top of them. Executions without a completed decision are terminated
+// if termination is allowed for their domain, and skipped otherwise.
+func (n *nextEventIDMatchesHistory) Fix(
+	ctx context.Context,
+	execution interface{},
+) FixResult {
+	if fixResult := validateFixContext(ctx, n.Name()); fixResult != nil {
+		return *fixResult
+	}
+
+	fixResult, checkResult := checkBeforeFix(ctx, n, execution)
+	if fixResult != nil {
+		return *fixResult
+	}
+	concreteExecution, _ := execution.(*entity.ConcreteExecution)
+	fixResult = n.resetToLastCompletedDecision(ctx, concreteExecution)
+	if fixResult == nil {
+		fixResult = TerminateExecutionIfAllowed(ctx, concreteExecution, n.Name(), n.hc, n.dc, n.terminateAllowed)
+	}
+	fixResult.CheckResult = *checkResult
+	fixResult.InvariantName = n.Name()
+	return *fixResult
+}
+
+// resetToLastCompletedDecision returns nil if the complete part of history has no completed decision to reset to
+func (n *nextEventIDMatchesHistory) resetToLastCompletedDecision(
+	ctx context.Context,
+	execution *entity.ConcreteExecution,
+) *FixResult {
+	_, state, checkResult := getOpenExecutionState(ctx, execution, n.Name(), n.pr, n.dc)
+	if checkResult != nil {
+		return &FixResult{
+			FixResultType: FixResultTypeFailed,
+			Info:          "failed to load mutable state",
+			InfoDetails:   checkResult.InfoDetails,
+		}
+	}
+	events, err := readHistoryEvents(ctx, n.pr, n.dc, execution, c.FirstEventID, state.ExecutionInfo.NextEventID)
+	if err != nil {
+		if _, ok := err.(*types.EntityNotExistsError); ok {
+			return nil
+		}
+		return &FixResult{
+			FixResultType: FixResultTypeFailed,
+			Info:          "failed to read history",
+			InfoDetails:   err.Error(),
+		}
+	}
+
+	decisionFinishEventID := lastCompletedDecisionID(events, state.ExecutionInfo.NextEventID)
+	if decisionFinishEventID == c.EmptyEventID {
+		return nil
+	}
+	return ResetExecution(ctx, execution, n.Name(), decisionFinishEventID, n.hc, n.dc)
+}
+
+func (n *nextEventIDMatchesHistory) Name() Name {
+	return NextEventIDMatchesHistory
+}
@@ -0,0 +1,260 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+
+package invariant
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/uber/cadence/client/history"
+	c2 "github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/mocks"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+type NextEventIDMatchesHistorySuite struct {
+	*require.Assertions
+	suite.Suite
+}
+
+func TestNextEventIDMatchesHistorySuite(t *testing.T) {
+	suite.Run(t, new(NextEventIDMatchesHistorySuite))
+}
+
+func (s *NextEventIDMatchesHistorySuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+}
+
+func (s *NextEventIDMatchesHistorySuite) TestCheck() {
+	testCases := []struct {
+		name           string
+		getHistoryResp *persistence.ReadHistoryBranchResponse
+		getHistoryErr  error
+		expectedResult CheckResult
+	}{
+		{
+			name:          "history read failure",
+			getHistoryErr: errors.New("history read failure"),
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeFailed,
+				InvariantName:   NextEventIDMatchesHistory,
+				Info:            "failed to read history",
+				InfoDetails:     "history read failure",
+			},
+		},
+		{
+			name:          "last batch does not exist",
+			getHistoryErr: &types.EntityNotExistsError{Message: "history not found"},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   NextEventIDMatchesHistory,
+				Info:            "last event batch is missing from history",
+				InfoDetails:     "history not found",
+			},
+		},
+		{
+			name: "history is behind mutable state",
+			getHistoryResp: &persistence.ReadHistoryBranchResponse{
+				HistoryEvents: []*types.HistoryEvent{{ID: 8}},
+			},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   NextEventIDMatchesHistory,
+				Info:            "last event in history does not match NextEventID",
+				InfoDetails:     "NextEventID: 10, LastEventID: 8",
+			},
+		},
+		{
+			name: "history matches mutable state",
+			getHistoryResp: &persistence.ReadHistoryBranchResponse{
+				HistoryEvents: []*types.HistoryEvent{{ID: 8}, {ID: 9}},
+			},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   NextEventIDMatchesHistory,
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	domainCache := cache.NewMockDomainCache(ctrl)
+	domainCache.EXPECT().GetDomainName(gomock.Any()).Return("test-domain-name", nil).AnyTimes()
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			execManager := &mocks.ExecutionManager{}
+			historyManager := &mocks.HistoryV2Manager{}
+			execManager.On("GetWorkflowExecution", mock.Anything, mock.Anything).Return(getExecutionResponse(&persistence.WorkflowMutableState{}), nil)
+			historyManager.On("ReadHistoryBranch", mock.Anything, mock.MatchedBy(func(request *persistence.ReadHistoryBranchRequest) bool {
+				return request.MinEventID == 8 && request.MaxEventID == 10
+			})).Return(tc.getHistoryResp, tc.getHistoryErr)
+			i := NewNextEventIDMatchesHistory(
+				persistence.NewPersistenceRetryer(execManager, historyManager, c2.CreatePersistenceRetryPolicy()),
+				domainCache,
+				history.NewMockClient(ctrl),
+				nil,
+			)
+			s.Equal(tc.expectedResult, i.Check(context.Background(), getOpenConcreteExecution()))
+		})
+	}
+}
+
+func (s *NextEventIDMatchesHistorySuite) TestFix() {
+	completedDecisionHistory := []*types.HistoryEvent{
+		{ID: 1, EventType: types.EventTypeWorkflowExecutionStarted.Ptr()},
+		{ID: 2, EventType: types.EventTypeDecisionTaskScheduled.Ptr()},
+		{ID: 3, EventType: types.EventTypeDecisionTaskStarted.Ptr()},
+		{ID: 4, EventType: types.EventTypeDecisionTaskCompleted.Ptr()},
+		{ID: 5, EventType: types.EventTypeActivityTaskScheduled.Ptr()},
+		{ID: 7, EventType: types.EventTypeDecisionTaskCompleted.Ptr()},
+		{ID: 8, EventType: types.EventTypeActivityTaskScheduled.Ptr()},
+	}
+	noCompletedDecisionHistory := []*types.HistoryEvent{
+		{ID: 1, EventType: types.EventTypeWorkflowExecutionStarted.Ptr()},
+		{ID: 2, EventType: types.EventTypeDecisionTaskScheduled.Ptr()},
+		{ID: 3, EventType: types.EventTypeDecisionTaskStarted.Ptr()},
+		{ID: 8, EventType: types.EventTypeActivityTaskScheduled.Ptr()},
+	}
+	testCases := []struct {
+		name             string
+		history          []*types.HistoryEvent
+		historyErr       error
+		terminateAllowed bool
+		resetErr         error
+		expectReset      bool
+		expectTerminate  bool
+		expectedResult   FixResult
+	}{
+		{
+			name:        "reset to last completed decision before the gap",
+			history:     completedDecisionHistory,
+			expectReset: true,
+			expectedResult: FixResult{
+				FixResultType: FixResultTypeFixed,
+				InvariantName: NextEventIDMatchesHistory,
+				Info:          "reset to DecisionTaskCompleted event 4",
+			},
+		},
+		{
+			name:        "reset failure",
+			history:     completedDecisionHistory,
+			resetErr:    errors.New("reset failure"),
+			expectReset: true,
+			expectedResult: FixResult{
+				FixResultType: FixResultTypeFailed,
+				InvariantName: NextEventIDMatchesHistory,
+				Info:          "failed to reset workflow execution",
+				InfoDetails:   "reset failure",
+			},
+		},
+		{
+			name:    "no completed decision and terminate is not allowed",
+			history: noCompletedDecisionHistory,
+			expectedResult: FixResult{
+				FixResultType: FixResultTypeSkipped,
+				InvariantName: NextEventIDMatchesHistory,
+				Info:          "execution can only be fixed by terminating it, which is not allowed for the domain",
+			},
+		},
+		{
+			name:             "no completed decision and terminate is allowed",
+			history:          noCompletedDecisionHistory,
+			terminateAllowed: true,
+			expectTerminate:  true,
+			expectedResult: FixResult{
+				FixResultType: FixResultTypeFixed,
+				InvariantName: NextEventIDMatchesHistory,
+			},
+		},
+		{
+			name:             "history does not exist and terminate is allowed",
+			historyErr:       &types.EntityNotExistsError{Message: "history not found"},
+			terminateAllowed: true,
+			expectTerminate:  true,
+			expectedResult: FixResult{
+				FixResultType: FixResultTypeFixed,
+				InvariantName: NextEventIDMatchesHistory,
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	domainCache := cache.NewMockDomainCache(ctrl)
+	domainCache.EXPECT().GetDomainName(gomock.Any()).Return("test-domain-name", nil).AnyTimes()
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			execManager := &mocks.ExecutionManager{}
+			historyManager := &mocks.HistoryV2Manager{}
+			execManager.On("GetWorkflowExecution", mock.Anything, mock.Anything).Return(getExecutionResponse(&persistence.WorkflowMutableState{}), nil)
+			// the check reads the last batch, the fix reads the whole history
+			historyManager.On("ReadHistoryBranch", mock.Anything, mock.MatchedBy(func(request *persistence.ReadHistoryBranchRequest) bool {
+				return request.MinEventID == 8
+			})).Return(&persistence.ReadHistoryBranchResponse{
+				HistoryEvents: []*types.HistoryEvent{{ID: 8}},
+			}, nil)
+			var historyResp *persistence.ReadHistoryBranchResponse
+			if tc.historyErr == nil {
+				historyResp = &persistence.ReadHistoryBranchResponse{HistoryEvents: tc.history}
+			}
+			historyManager.On("ReadHistoryBranch", mock.Anything, mock.MatchedBy(func(request *persistence.ReadHistoryBranchRequest) bool {
+				return request.MinEventID == c2.FirstEventID
+			})).Return(historyResp, tc.historyErr)
+			historyClient := history.NewMockClient(ctrl)
+			if tc.expectReset {
+				historyClient.EXPECT().ResetWorkflowExecution(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, request *types.HistoryResetWorkflowExecutionRequest, _ ...interface{}) (*types.ResetWorkflowExecutionResponse, error) {
+						s.Equal(domainID, request.DomainUUID)
+						s.Equal(runID, request.ResetRequest.WorkflowExecution.RunID)
+						s.Equal(int64(4), request.ResetRequest.DecisionFinishEventID)
+						return &types.ResetWorkflowExecutionResponse{}, tc.resetErr
+					},
+				)
+			}
+			if tc.expectTerminate {
+				historyClient.EXPECT().TerminateWorkflowExecution(gomock.Any(), gomock.Any()).Return(nil)
+			}
+			i := NewNextEventIDMatchesHistory(
+				persistence.NewPersistenceRetryer(execManager, historyManager, c2.CreatePersistenceRetryPolicy()),
+				domainCache,
+				historyClient,
+				dynamicconfig.GetBoolPropertyFnFilteredByDomain(tc.terminateAllowed),
+			)
+			tc.expectedResult.CheckResult = CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   NextEventIDMatchesHistory,
+				Info:            "last event in history does not match NextEventID",
+				InfoDetails:     "NextEventID: 10, LastEventID: 8",
+			}
+			s.Equal(tc.expectedResult, i.Fix(context.Background(), getOpenConcreteExecution()))
+		})
+	}
+}
@@ -0,0 +1,225 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+
+package invariant
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/reconciliation/entity"
+)
+
+type (
+	orphanedTask struct {
+		name     Name
+		pr       persistence.Retryer
+		cache    cache.DomainCache
+		ackLevel ReplicationAckLevelFn
+	}
+
+	// ReplicationAckLevelFn returns the replication ack level of a shard which all remote clusters have reached
+	ReplicationAckLevelFn func(ctx context.Context, shardID int) (int64, error)
+
+	// queueTask is the owning execution and id of a transfer or replication task
+	queueTask struct {
+		domainID   string
+		workflowID string
+		runID      string
+		taskID     int64
+	}
+)
+
+// NewOrphanedTransferTask returns an invariant which flags transfer tasks of executions which no longer exist
+func NewOrphanedTransferTask(
+	pr persistence.Retryer,
+	cache cache.DomainCache,
+) Invariant {
+	return &orphanedTask{
+		name:  OrphanedTransferTask,
+		pr:    pr,
+		cache: cache,
+	}
+}
+
+// NewOrphanedReplicationTask returns an invariant which flags replication tasks of executions which no longer exist.
+// Since standby clusters may still need a task after its execution is gone, Fix only completes tasks
+// at or below the shard ack level returned by ackLevel. Fix skips every task if ackLevel is nil.
+func NewOrphanedReplicationTask(
+	pr persistence.Retryer,
+	cache cache.DomainCache,
+	ackLevel ReplicationAckLevelFn,
+) Invariant {
+	return &orphanedTask{
+		name:     OrphanedReplicationTask,
+		pr:       pr,
+		cache:    cache,
+		ackLevel: ackLevel,
+	}
+}
+
+// Check checks that the execution owning the task exists
+func (o *orphanedTask) Check(
+	ctx context.Context,
+	e interface{},
+) CheckResult {
+	if checkResult := validateCheckContext(ctx, o.Name()); checkResult != nil {
+		return *checkResult
+	}
+
+	task, ok := o.toQueueTask(e)
+	if !ok {
+		return CheckResult{
+			CheckResultType: CheckResultTypeFailed,
+			InvariantName:   o.Name(),
+			Info:            "failed to check: unexpected entity type",
+		}
+	}
+	domainName, err := o.cache.GetDomainName(task.domainID)
+	if err != nil {
+		return CheckResult{
+			CheckResultType: CheckResultTypeFailed,
+			InvariantName:   o.Name(),
+			Info:            "failed to check: expected Domain Name",
+			InfoDetails:     err.Error(),
+		}
+	}
+	resp, err := o.pr.IsWorkflowExecutionExists(ctx, &persistence.IsWorkflowExecutionExistsRequest{
+		DomainID:   task.domainID,
+		DomainName: domainName,
+		WorkflowID: task.workflowID,
+		RunID:      task.runID,
+	})
+	if err != nil {
+		return CheckResult{
+			CheckResultType: CheckResultTypeFailed,
+			InvariantName:   o.Name(),
+			Info:            "failed to check if concrete execution exists",
+			InfoDetails:     err.Error(),
+		}
+	}
+	if !resp.Exists {
+		return CheckResult{
+			CheckResultType: CheckResultTypeCorrupted,
+			InvariantName:   o.Name(),
+			Info:            "task of non existing workflow",
+		}
+	}
+	return CheckResult{
+		CheckResultType: CheckResultTypeHealthy,
+		InvariantName:   o.Name(),
+	}
+}
+
+// Fix completes the task if its owning execution still does not exist.
+// Replication tasks are completed only once all remote clusters acknowledged them.
+func (o *orphanedTask) Fix(
+	ctx context.Context,
+	e interface{},
+) FixResult {
+	if fixResult := validateFixContext(ctx, o.Name()); fixResult != nil {
+		return *fixResult
+	}
+
+	fixResult, checkResult := checkBeforeFix(ctx, o, e)
+	if fixResult != nil {
+		return *fixResult
+	}
+
+	task, _ := o.toQueueTask(e)
+	var err error
+	if o.name == OrphanedTransferTask {
+		err = o.pr.CompleteTransferTask(ctx, &persistence.CompleteTransferTaskRequest{TaskID: task.taskID})
+	} else {
+		if fixResult := o.checkReplicationAckLevel(ctx, e.(*entity.ReplicationTask), checkResult); fixResult != nil {
+			return *fixResult
+		}
+		err = o.pr.CompleteReplicationTask(ctx, &persistence.CompleteReplicationTaskRequest{TaskID: task.taskID})
+	}
+	if err != nil {
+		return FixResult{
+			FixResultType: FixResultTypeFailed,
+			InvariantName: o.Name(),
+			Info:          "failed to complete task",
+			InfoDetails:   err.Error(),
+		}
+	}
+	return FixResult{
+		FixResultType: FixResultTypeFixed,
+		InvariantName: o.Name(),
+		CheckResult:   *checkResult,
+	}
+}
+
+// checkReplicationAckLevel returns a non nil FixResult if the task may still be read by a remote cluster
+func (o *orphanedTask) checkReplicationAckLevel(
+	ctx context.Context,
+	task *entity.ReplicationTask,
+	checkResult *CheckResult,
+) *FixResult {
+	if o.ackLevel == nil {
+		return &FixResult{
+			FixResultType: FixResultTypeSkipped,
+			InvariantName: o.Name(),
+			CheckResult:   *checkResult,
+			Info:          "skipped fix because replication ack levels are unknown",
+		}
+	}
+	ackLevel, err := o.ackLevel(ctx, task.ShardID)
+	if err != nil {
+		return &FixResult{
+			FixResultType: FixResultTypeFailed,
+			InvariantName: o.Name(),
+			CheckResult:   *checkResult,
+			Info:          "failed to get replication ack level",
+			InfoDetails:   err.Error(),
+		}
+	}
+	if task.TaskID > ackLevel {
+		return &FixResult{
+			FixResultType: FixResultTypeSkipped,
+			InvariantName: o.Name(),
+			CheckResult:   *checkResult,
+			Info:          "skipped fix because the task is not acknowledged by all remote clusters",
+			InfoDetails:   fmt.Sprintf("TaskID: %v, AckLevel: %v", task.TaskID, ackLevel),
+		}
+	}
+	return nil
+}
+
+func (o *orphanedTask) Name() Name {
+	return o.name
+}
+
+func (o *orphanedTask) toQueueTask(e interface{}) (queueTask, bool) {
+	switch t := e.(type) {
+	case *entity.TransferTask:
+		if o.name == OrphanedTransferTask {
+			return queueTask{domainID: t.DomainID, workflowID: t.WorkflowID, runID: t.RunID, taskID: t.TaskID}, true
+		}
+	case *entity.ReplicationTask:
+		if o.name == OrphanedReplicationTask {
+			return queueTask{domainID: t.DomainID, workflowID: t.WorkflowID, runID: t.RunID, taskID: t.TaskID}, true
+		}
+	}
+	return queueTask{}, false
+}
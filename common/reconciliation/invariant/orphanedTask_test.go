@@ -0,0 +1,150 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+
+package invariant
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/mocks"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/reconciliation/entity"
+)
+
+func TestOrphanedTask(t *testing.T) {
+	transferTask := &entity.TransferTask{DomainID: "d", WorkflowID: "w", RunID: "r", TaskID: 10}
+	replicationTask := &entity.ReplicationTask{ShardID: 1, DomainID: "d", WorkflowID: "w", RunID: "r", TaskID: 20}
+	newReplication := func(ackLevel int64, err error) func(persistence.Retryer, cache.DomainCache) Invariant {
+		return func(pr persistence.Retryer, cache cache.DomainCache) Invariant {
+			return NewOrphanedReplicationTask(pr, cache, func(_ context.Context, shardID int) (int64, error) {
+				require.Equal(t, 1, shardID)
+				return ackLevel, err
+			})
+		}
+	}
+	testCases := []struct {
+		name        string
+		newFn       func(persistence.Retryer, cache.DomainCache) Invariant
+		entity      interface{}
+		exists      bool
+		existsErr   error
+		completeErr error
+		check       CheckResultType
+		fix         FixResultType
+	}{
+		{
+			name:   "transfer task of existing execution",
+			newFn:  NewOrphanedTransferTask,
+			entity: transferTask,
+			exists: true,
+			check:  CheckResultTypeHealthy,
+			fix:    FixResultTypeSkipped,
+		},
+		{
+			name:   "orphaned transfer task",
+			newFn:  NewOrphanedTransferTask,
+			entity: transferTask,
+			check:  CheckResultTypeCorrupted,
+			fix:    FixResultTypeFixed,
+		},
+		{
+			name:        "orphaned transfer task fails to complete",
+			newFn:       NewOrphanedTransferTask,
+			entity:      transferTask,
+			completeErr: errors.New("complete failed"),
+			check:       CheckResultTypeCorrupted,
+			fix:         FixResultTypeFailed,
+		},
+		{
+			name:   "orphaned replication task",
+			newFn:  newReplication(20, nil),
+			entity: replicationTask,
+			check:  CheckResultTypeCorrupted,
+			fix:    FixResultTypeFixed,
+		},
+		{
+			name:   "orphaned replication task not acknowledged by remote clusters",
+			newFn:  newReplication(19, nil),
+			entity: replicationTask,
+			check:  CheckResultTypeCorrupted,
+			fix:    FixResultTypeSkipped,
+		},
+		{
+			name:   "orphaned replication task with unknown ack level",
+			newFn:  newReplication(0, errors.New("shard not found")),
+			entity: replicationTask,
+			check:  CheckResultTypeCorrupted,
+			fix:    FixResultTypeFailed,
+		},
+		{
+			name: "orphaned replication task without ack levels",
+			newFn: func(pr persistence.Retryer, cache cache.DomainCache) Invariant {
+				return NewOrphanedReplicationTask(pr, cache, nil)
+			},
+			entity: replicationTask,
+			check:  CheckResultTypeCorrupted,
+			fix:    FixResultTypeSkipped,
+		},
+		{
+			name:      "persistence error",
+			newFn:     newReplication(20, nil),
+			entity:    replicationTask,
+			existsErr: errors.New("exists failed"),
+			check:     CheckResultTypeFailed,
+			fix:       FixResultTypeFailed,
+		},
+		{
+			name:   "wrong entity type",
+			newFn:  newReplication(20, nil),
+			entity: transferTask,
+			check:  CheckResultTypeFailed,
+			fix:    FixResultTypeFailed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			domainCache := cache.NewMockDomainCache(ctrl)
+			domainCache.EXPECT().GetDomainName("d").Return("domain", nil).AnyTimes()
+			execManager := &mocks.ExecutionManager{}
+			execManager.On("IsWorkflowExecutionExists", mock.Anything, &persistence.IsWorkflowExecutionExistsRequest{
+				DomainID:   "d",
+				DomainName: "domain",
+				WorkflowID: "w",
+				RunID:      "r",
+			}).Return(&persistence.IsWorkflowExecutionExistsResponse{Exists: tc.exists}, tc.existsErr)
+			execManager.On("CompleteTransferTask", mock.Anything, &persistence.CompleteTransferTaskRequest{TaskID: 10}).Return(tc.completeErr)
+			execManager.On("CompleteReplicationTask", mock.Anything, &persistence.CompleteReplicationTaskRequest{TaskID: 20}).Return(tc.completeErr)
+			pr := persistence.NewPersistenceRetryer(execManager, nil, common.CreatePersistenceRetryPolicy())
+
+			i := tc.newFn(pr, domainCache)
+			require.Equal(t, tc.check, i.Check(context.Background(), tc.entity).CheckResultType)
+			require.Equal(t, tc.fix, i.Fix(context.Background(), tc.entity).FixResultType)
+		})
+	}
+}
@@ -0,0 +1,197 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+
+package invariant
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uber/cadence/client/history"
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+const (
+	// parentClosePolicyGracePeriod is how long a parent close policy is allowed to be in progress
+	// after the parent closed before it is considered lost
+	parentClosePolicyGracePeriod = time.Hour
+)
+
+type (
+	parentClosePolicyApplied struct {
+		pr         persistence.Retryer
+		dc         cache.DomainCache
+		hc         history.Client
+		timeSource clock.TimeSource
+	}
+)
+
+// NewParentClosePolicyApplied returns a new invariant which checks that an open child execution
+// does not outlive its closed parent unless the parent close policy is abandon.
+func NewParentClosePolicyApplied(
+	pr persistence.Retryer,
+	dc cache.DomainCache,
+	hc history.Client,
+) Invariant {
+	return &parentClosePolicyApplied{
+		pr:         pr,
+		dc:         dc,
+		hc:         hc,
+		timeSource: clock.NewRealTimeSource(),
+	}
+}
+
+func (p *parentClosePolicyApplied) Check(
+	ctx context.Context,
+	execution interface{},
+) CheckResult {
+	if checkResult := validateCheckContext(ctx, p.Name()); checkResult != nil {
+		return *checkResult
+	}
+
+	_, state, checkResult := getOpenExecutionState(ctx, execution, p.Name(), p.pr, p.dc)
+	if checkResult != nil {
+		return *checkResult
+	}
+
+	executionInfo := state.ExecutionInfo
+	if executionInfo.ParentWorkflowID == "" {
+		return CheckResult{
+			CheckResultType: CheckResultTypeHealthy,
+			InvariantName:   p.Name(),
+		}
+	}
+
+	parentDomainName, err := p.dc.GetDomainName(executionInfo.ParentDomainID)
+	if err != nil {
+		return CheckResult{
+			CheckResultType: CheckResultTypeFailed,
+			InvariantName:   p.Name(),
+			Info:            "failed to check: expected parent DomainName",
+			InfoDetails:     err.Error(),
+		}
+	}
+	resp, err := p.hc.DescribeWorkflowExecution(ctx, &types.HistoryDescribeWorkflowExecutionRequest{
+		DomainUUID: executionInfo.ParentDomainID,
+		Request: &types.DescribeWorkflowExecutionRequest{
+			Domain: parentDomainName,
+			Execution: &types.WorkflowExecution{
+				WorkflowID: executionInfo.ParentWorkflowID,
+				RunID:      executionInfo.ParentRunID,
+			},
+		},
+	})
+	if err != nil {
+		if _, ok := err.(*types.EntityNotExistsError); ok {
+			return CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   p.Name(),
+				Info:            "determined execution was healthy because parent execution no longer exists",
+			}
+		}
+		return CheckResult{
+			CheckResultType: CheckResultTypeFailed,
+			InvariantName:   p.Name(),
+			Info:            "failed to describe parent execution",
+			InfoDetails:     err.Error(),
+		}
+	}
+
+	parentInfo := resp.GetWorkflowExecutionInfo()
+	if parentInfo.CloseStatus == nil {
+		return CheckResult{
+			CheckResultType: CheckResultTypeHealthy,
+			InvariantName:   p.Name(),
+		}
+	}
+	if time.Unix(0, parentInfo.GetCloseTime()).After(p.timeSource.Now().Add(-parentClosePolicyGracePeriod)) {
+		return CheckResult{
+			CheckResultType: CheckResultTypeHealthy,
+			InvariantName:   p.Name(),
+			Info:            "parent close policy may still be in progress",
+		}
+	}
+	for _, child := range resp.PendingChildren {
+		if child.InitiatedID != executionInfo.InitiatedID {
+			continue
+		}
+		if child.ParentClosePolicy == nil || *child.ParentClosePolicy == types.ParentClosePolicyAbandon {
+			break
+		}
+		return CheckResult{
+			CheckResultType: CheckResultTypeCorrupted,
+			InvariantName:   p.Name(),
+			Info:            "child execution is open although parent is closed",
+			InfoDetails:     fmt.Sprintf("ParentClosePolicy: %v, ParentCloseStatus: %v", *child.ParentClosePolicy, parentInfo.GetCloseStatus()),
+		}
+	}
+	return CheckResult{
+		CheckResultType: CheckResultTypeHealthy,
+		InvariantName:   p.Name(),
+	}
+}
+
+// Fix regenerates tasks of the closed parent, which applies its parent close policy to the child again.
+func (p *parentClosePolicyApplied) Fix(
+	ctx context.Context,
+	execution interface{},
+) FixResult {
+	if fixResult := validateFixContext(ctx, p.Name()); fixResult != nil {
+		return *fixResult
+	}
+
+	fixResult, checkResult := checkBeforeFix(ctx, p, execution)
+	if fixResult != nil {
+		return *fixResult
+	}
+	_, state, result := getOpenExecutionState(ctx, execution, p.Name(), p.pr, p.dc)
+	if result != nil {
+		return FixResult{
+			FixResultType: FixResultTypeFailed,
+			InvariantName: p.Name(),
+			CheckResult:   *checkResult,
+			Info:          "failed to reload concrete execution",
+			InfoDetails:   result.Info,
+		}
+	}
+	executionInfo := state.ExecutionInfo
+	fixResult = RefreshExecutionTasks(
+		ctx,
+		executionInfo.ParentDomainID,
+		&types.WorkflowExecution{
+			WorkflowID: executionInfo.ParentWorkflowID,
+			RunID:      executionInfo.ParentRunID,
+		},
+		p.hc,
+		p.dc,
+	)
+	fixResult.CheckResult = *checkResult
+	fixResult.InvariantName = p.Name()
+	return *fixResult
+}
+
+func (p *parentClosePolicyApplied) Name() Name {
+	return ParentClosePolicyApplied
+}
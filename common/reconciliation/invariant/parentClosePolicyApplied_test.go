@@ -0,0 +1,230 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+
+package invariant
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/uber/cadence/client/history"
+	c2 "github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/mocks"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+const (
+	parentDomainID   = "test-parent-domain-id"
+	parentWorkflowID = "test-parent-workflow-id"
+	parentRunID      = "test-parent-run-id"
+)
+
+type ParentClosePolicyAppliedSuite struct {
+	*require.Assertions
+	suite.Suite
+
+	now time.Time
+}
+
+func TestParentClosePolicyAppliedSuite(t *testing.T) {
+	suite.Run(t, new(ParentClosePolicyAppliedSuite))
+}
+
+func (s *ParentClosePolicyAppliedSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.now = time.Unix(1600000000, 0)
+}
+
+func (s *ParentClosePolicyAppliedSuite) TestCheck() {
+	testCases := []struct {
+		name           string
+		hasParent      bool
+		describeResp   *types.DescribeWorkflowExecutionResponse
+		describeErr    error
+		expectedResult CheckResult
+	}{
+		{
+			name: "no parent",
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   ParentClosePolicyApplied,
+			},
+		},
+		{
+			name:        "describe parent failure",
+			hasParent:   true,
+			describeErr: errors.New("describe failure"),
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeFailed,
+				InvariantName:   ParentClosePolicyApplied,
+				Info:            "failed to describe parent execution",
+				InfoDetails:     "describe failure",
+			},
+		},
+		{
+			name:        "parent no longer exists",
+			hasParent:   true,
+			describeErr: &types.EntityNotExistsError{},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   ParentClosePolicyApplied,
+				Info:            "determined execution was healthy because parent execution no longer exists",
+			},
+		},
+		{
+			name:         "parent is open",
+			hasParent:    true,
+			describeResp: s.getParentDescription(nil, time.Time{}, types.ParentClosePolicyTerminate),
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   ParentClosePolicyApplied,
+			},
+		},
+		{
+			name:         "parent closed recently",
+			hasParent:    true,
+			describeResp: s.getParentDescription(types.WorkflowExecutionCloseStatusCompleted.Ptr(), s.now, types.ParentClosePolicyTerminate),
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   ParentClosePolicyApplied,
+				Info:            "parent close policy may still be in progress",
+			},
+		},
+		{
+			name:         "abandon policy",
+			hasParent:    true,
+			describeResp: s.getParentDescription(types.WorkflowExecutionCloseStatusCompleted.Ptr(), s.now.Add(-2*time.Hour), types.ParentClosePolicyAbandon),
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   ParentClosePolicyApplied,
+			},
+		},
+		{
+			name:         "policy not applied",
+			hasParent:    true,
+			describeResp: s.getParentDescription(types.WorkflowExecutionCloseStatusCompleted.Ptr(), s.now.Add(-2*time.Hour), types.ParentClosePolicyTerminate),
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   ParentClosePolicyApplied,
+				Info:            "child execution is open although parent is closed",
+				InfoDetails:     "ParentClosePolicy: TERMINATE, ParentCloseStatus: COMPLETED",
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			historyClient := history.NewMockClient(ctrl)
+			if tc.hasParent {
+				historyClient.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any()).Return(tc.describeResp, tc.describeErr)
+			}
+			i := s.newInvariant(ctrl, tc.hasParent, historyClient)
+			s.Equal(tc.expectedResult, i.Check(context.Background(), getOpenConcreteExecution()))
+		})
+	}
+}
+
+func (s *ParentClosePolicyAppliedSuite) TestFix() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	historyClient := history.NewMockClient(ctrl)
+	historyClient.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any()).Return(
+		s.getParentDescription(types.WorkflowExecutionCloseStatusCompleted.Ptr(), s.now.Add(-2*time.Hour), types.ParentClosePolicyRequestCancel),
+		nil,
+	)
+	historyClient.EXPECT().RefreshWorkflowTasks(gomock.Any(), &types.HistoryRefreshWorkflowTasksRequest{
+		DomainUIID: parentDomainID,
+		Request: &types.RefreshWorkflowTasksRequest{
+			Domain:    "test-domain-name",
+			Execution: &types.WorkflowExecution{WorkflowID: parentWorkflowID, RunID: parentRunID},
+		},
+	}).Return(nil)
+	i := s.newInvariant(ctrl, true, historyClient)
+	s.Equal(FixResult{
+		FixResultType: FixResultTypeFixed,
+		InvariantName: ParentClosePolicyApplied,
+		CheckResult: CheckResult{
+			CheckResultType: CheckResultTypeCorrupted,
+			InvariantName:   ParentClosePolicyApplied,
+			Info:            "child execution is open although parent is closed",
+			InfoDetails:     "ParentClosePolicy: REQUEST_CANCEL, ParentCloseStatus: COMPLETED",
+		},
+	}, i.Fix(context.Background(), getOpenConcreteExecution()))
+}
+
+func (s *ParentClosePolicyAppliedSuite) newInvariant(
+	ctrl *gomock.Controller,
+	hasParent bool,
+	historyClient history.Client,
+) Invariant {
+	domainCache := cache.NewMockDomainCache(ctrl)
+	domainCache.EXPECT().GetDomainName(gomock.Any()).Return("test-domain-name", nil).AnyTimes()
+	state := getExecutionResponse(&persistence.WorkflowMutableState{})
+	if hasParent {
+		state.State.ExecutionInfo.ParentDomainID = parentDomainID
+		state.State.ExecutionInfo.ParentWorkflowID = parentWorkflowID
+		state.State.ExecutionInfo.ParentRunID = parentRunID
+		state.State.ExecutionInfo.InitiatedID = 5
+	}
+	execManager := &mocks.ExecutionManager{}
+	execManager.On("GetWorkflowExecution", mock.Anything, mock.Anything).Return(state, nil)
+	i := NewParentClosePolicyApplied(
+		persistence.NewPersistenceRetryer(execManager, nil, c2.CreatePersistenceRetryPolicy()),
+		domainCache,
+		historyClient,
+	)
+	i.(*parentClosePolicyApplied).timeSource = clock.NewEventTimeSource().Update(s.now)
+	return i
+}
+
+func (s *ParentClosePolicyAppliedSuite) getParentDescription(
+	closeStatus *types.WorkflowExecutionCloseStatus,
+	closeTime time.Time,
+	policy types.ParentClosePolicy,
+) *types.DescribeWorkflowExecutionResponse {
+	return &types.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &types.WorkflowExecutionInfo{
+			CloseStatus: closeStatus,
+			CloseTime:   c2.Int64Ptr(closeTime.UnixNano()),
+		},
+		PendingChildren: []*types.PendingChildExecutionInfo{
+			{
+				WorkflowID:        workflowID,
+				RunID:             runID,
+				InitiatedID:       5,
+				ParentClosePolicy: policy.Ptr(),
+			},
+		},
+	}
+}
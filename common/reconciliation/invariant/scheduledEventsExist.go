@@ -0,0 +1,218 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+
+package invariant
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/uber/cadence/client/history"
+	c "github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/reconciliation/entity"
+	"github.com/uber/cadence/common/types"
+)
+
+type (
+	scheduledEventsExist struct {
+		pr               persistence.Retryer
+		dc               cache.DomainCache
+		hc               history.Client
+		terminateAllowed dynamicconfig.BoolPropertyFnWithDomainFilter
+	}
+)
+
+// NewScheduledEventsExist returns a new invariant which checks that pending activities, timers and
+// child executions of an open execution reference existing history events of the expected type.
+// Executions which cannot be reset are only terminated by Fix in domains for which terminateAllowed is true.
+func NewScheduledEventsExist(
+	pr persistence.Retryer,
+	dc cache.DomainCache,
+	hc history.Client,
+	terminateAllowed dynamicconfig.BoolPropertyFnWithDomainFilter,
+) Invariant {
+	return &scheduledEventsExist{
+		pr:               pr,
+		dc:               dc,
+		hc:               hc,
+		terminateAllowed: terminateAllowed,
+	}
+}
+
+func (s *scheduledEventsExist) Check(
+	ctx context.Context,
+	execution interface{},
+) CheckResult {
+	if checkResult := validateCheckContext(ctx, s.Name()); checkResult != nil {
+		return *checkResult
+	}
+
+	concreteExecution, state, checkResult := getOpenExecutionState(ctx, execution, s.Name(), s.pr, s.dc)
+	if checkResult != nil {
+		return *checkResult
+	}
+
+	if !hasPendingEvents(state) {
+		return CheckResult{
+			CheckResultType: CheckResultTypeHealthy,
+			InvariantName:   s.Name(),
+		}
+	}
+
+	events, err := readHistoryEvents(ctx, s.pr, s.dc, concreteExecution, c.FirstEventID, state.ExecutionInfo.NextEventID)
+	if err != nil {
+		switch err.(type) {
+		case *types.EntityNotExistsError:
+			return CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   s.Name(),
+				Info:            "execution has pending events but history does not exist",
+				InfoDetails:     err.Error(),
+			}
+		default:
+			return CheckResult{
+				CheckResultType: CheckResultTypeFailed,
+				InvariantName:   s.Name(),
+				Info:            "failed to read history",
+				InfoDetails:     err.Error(),
+			}
+		}
+	}
+	missingEvents := missingPendingEvents(state, events)
+	if len(missingEvents) != 0 {
+		var missing []string
+		for eventID, eventType := range missingEvents {
+			missing = append(missing, fmt.Sprintf("%v:%v", eventID, eventType))
+		}
+		sort.Strings(missing)
+		return CheckResult{
+			CheckResultType: CheckResultTypeCorrupted,
+			InvariantName:   s.Name(),
+			Info:            "pending events are missing from history",
+			InfoDetails:     strings.Join(missing, ", "),
+		}
+	}
+	return CheckResult{
+		CheckResultType: CheckResultTypeHealthy,
+		InvariantName:   s.Name(),
+	}
+}
+
+// Fix resets the execution to the last completed decision before the first missing event, so that the decision
+// which scheduled it is made again. Executions without such a decision are terminated if termination is allowed
+// for their domain, and skipped otherwise.
+func (s *scheduledEventsExist) Fix(
+	ctx context.Context,
+	execution interface{},
+) FixResult {
+	if fixResult := validateFixContext(ctx, s.Name()); fixResult != nil {
+		return *fixResult
+	}
+
+	fixResult, checkResult := checkBeforeFix(ctx, s, execution)
+	if fixResult != nil {
+		return *fixResult
+	}
+	concreteExecution, _ := execution.(*entity.ConcreteExecution)
+	fixResult = s.resetBeforeMissingEvents(ctx, concreteExecution)
+	if fixResult == nil {
+		fixResult = TerminateExecutionIfAllowed(ctx, concreteExecution, s.Name(), s.hc, s.dc, s.terminateAllowed)
+	}
+	fixResult.CheckResult = *checkResult
+	fixResult.InvariantName = s.Name()
+	return *fixResult
+}
+
+// resetBeforeMissingEvents returns nil if history has no completed decision before the first missing event
+func (s *scheduledEventsExist) resetBeforeMissingEvents(
+	ctx context.Context,
+	execution *entity.ConcreteExecution,
+) *FixResult {
+	_, state, checkResult := getOpenExecutionState(ctx, execution, s.Name(), s.pr, s.dc)
+	if checkResult != nil {
+		return &FixResult{
+			FixResultType: FixResultTypeFailed,
+			Info:          "failed to load mutable state",
+			InfoDetails:   checkResult.InfoDetails,
+		}
+	}
+	events, err := readHistoryEvents(ctx, s.pr, s.dc, execution, c.FirstEventID, state.ExecutionInfo.NextEventID)
+	if err != nil {
+		if _, ok := err.(*types.EntityNotExistsError); ok {
+			return nil
+		}
+		return &FixResult{
+			FixResultType: FixResultTypeFailed,
+			Info:          "failed to read history",
+			InfoDetails:   err.Error(),
+		}
+	}
+
+	firstMissingEventID := state.ExecutionInfo.NextEventID
+	for eventID := range missingPendingEvents(state, events) {
+		if eventID < firstMissingEventID {
+			firstMissingEventID = eventID
+		}
+	}
+	decisionFinishEventID := lastCompletedDecisionID(events, firstMissingEventID)
+	if decisionFinishEventID == c.EmptyEventID {
+		return nil
+	}
+	return ResetExecution(ctx, execution, s.Name(), decisionFinishEventID, s.hc, s.dc)
+}
+
+func (s *scheduledEventsExist) Name() Name {
+	return ScheduledEventsExist
+}
+
+func hasPendingEvents(state *persistence.WorkflowMutableState) bool {
+	return len(state.ActivityInfos) != 0 || len(state.TimerInfos) != 0 || len(state.ChildExecutionInfos) != 0
+}
+
+// missingPendingEvents returns the events referenced by pending activities, timers and child executions
+// which are not in history with the expected type
+func missingPendingEvents(
+	state *persistence.WorkflowMutableState,
+	events []*types.HistoryEvent,
+) map[int64]types.EventType {
+	expected := make(map[int64]types.EventType)
+	for _, ai := range state.ActivityInfos {
+		expected[ai.ScheduleID] = types.EventTypeActivityTaskScheduled
+	}
+	for _, ti := range state.TimerInfos {
+		expected[ti.StartedID] = types.EventTypeTimerStarted
+	}
+	for _, ci := range state.ChildExecutionInfos {
+		expected[ci.InitiatedID] = types.EventTypeStartChildWorkflowExecutionInitiated
+	}
+	for _, event := range events {
+		eventType, ok := expected[event.ID]
+		if ok && event.GetEventType() == eventType {
+			delete(expected, event.ID)
+		}
+	}
+	return expected
+}
@@ -0,0 +1,302 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+
+package invariant
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/uber/cadence/client/history"
+	c2 "github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/mocks"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+type ScheduledEventsExistSuite struct {
+	*require.Assertions
+	suite.Suite
+}
+
+func TestScheduledEventsExistSuite(t *testing.T) {
+	suite.Run(t, new(ScheduledEventsExistSuite))
+}
+
+func (s *ScheduledEventsExistSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+}
+
+func (s *ScheduledEventsExistSuite) TestCheck() {
+	testCases := []struct {
+		name           string
+		execution      interface{}
+		getExecResp    *persistence.GetWorkflowExecutionResponse
+		getExecErr     error
+		getHistoryResp *persistence.ReadHistoryBranchResponse
+		getHistoryErr  error
+		expectedResult CheckResult
+	}{
+		{
+			name:      "closed execution",
+			execution: getClosedConcreteExecution(),
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   ScheduledEventsExist,
+			},
+		},
+		{
+			name:       "execution no longer exists",
+			execution:  getOpenConcreteExecution(),
+			getExecErr: &types.EntityNotExistsError{},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   ScheduledEventsExist,
+				Info:            "determined execution was healthy because concrete execution no longer exists",
+			},
+		},
+		{
+			name:        "no pending events",
+			execution:   getOpenConcreteExecution(),
+			getExecResp: getExecutionResponse(&persistence.WorkflowMutableState{}),
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   ScheduledEventsExist,
+			},
+		},
+		{
+			name:          "history read failure",
+			execution:     getOpenConcreteExecution(),
+			getExecResp:   getExecutionResponse(getPendingEventsMutableState()),
+			getHistoryErr: errors.New("history read failure"),
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeFailed,
+				InvariantName:   ScheduledEventsExist,
+				Info:            "failed to read history",
+				InfoDetails:     "history read failure",
+			},
+		},
+		{
+			name:        "pending events are missing",
+			execution:   getOpenConcreteExecution(),
+			getExecResp: getExecutionResponse(getPendingEventsMutableState()),
+			getHistoryResp: &persistence.ReadHistoryBranchResponse{
+				HistoryEvents: []*types.HistoryEvent{
+					{ID: 5, EventType: types.EventTypeActivityTaskScheduled.Ptr()},
+					{ID: 6, EventType: types.EventTypeMarkerRecorded.Ptr()},
+				},
+			},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   ScheduledEventsExist,
+				Info:            "pending events are missing from history",
+				InfoDetails:     "6:TimerStarted, 7:StartChildWorkflowExecutionInitiated",
+			},
+		},
+		{
+			name:        "pending events exist",
+			execution:   getOpenConcreteExecution(),
+			getExecResp: getExecutionResponse(getPendingEventsMutableState()),
+			getHistoryResp: &persistence.ReadHistoryBranchResponse{
+				HistoryEvents: []*types.HistoryEvent{
+					{ID: 5, EventType: types.EventTypeActivityTaskScheduled.Ptr()},
+					{ID: 6, EventType: types.EventTypeTimerStarted.Ptr()},
+					{ID: 7, EventType: types.EventTypeStartChildWorkflowExecutionInitiated.Ptr()},
+				},
+			},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   ScheduledEventsExist,
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	domainCache := cache.NewMockDomainCache(ctrl)
+	domainCache.EXPECT().GetDomainName(gomock.Any()).Return("test-domain-name", nil).AnyTimes()
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			execManager := &mocks.ExecutionManager{}
+			historyManager := &mocks.HistoryV2Manager{}
+			execManager.On("GetWorkflowExecution", mock.Anything, mock.Anything).Return(tc.getExecResp, tc.getExecErr)
+			historyManager.On("ReadHistoryBranch", mock.Anything, mock.Anything).Return(tc.getHistoryResp, tc.getHistoryErr)
+			i := NewScheduledEventsExist(
+				persistence.NewPersistenceRetryer(execManager, historyManager, c2.CreatePersistenceRetryPolicy()),
+				domainCache,
+				history.NewMockClient(ctrl),
+				nil,
+			)
+			s.Equal(tc.expectedResult, i.Check(context.Background(), tc.execution))
+		})
+	}
+}
+
+func (s *ScheduledEventsExistSuite) TestFix() {
+	// the timer and the child execution scheduled after the second decision are missing
+	completedDecisionHistory := []*types.HistoryEvent{
+		{ID: 1, EventType: types.EventTypeWorkflowExecutionStarted.Ptr()},
+		{ID: 2, EventType: types.EventTypeDecisionTaskScheduled.Ptr()},
+		{ID: 3, EventType: types.EventTypeDecisionTaskStarted.Ptr()},
+		{ID: 4, EventType: types.EventTypeDecisionTaskCompleted.Ptr()},
+		{ID: 5, EventType: types.EventTypeActivityTaskScheduled.Ptr()},
+		{ID: 6, EventType: types.EventTypeMarkerRecorded.Ptr()},
+		{ID: 7, EventType: types.EventTypeDecisionTaskCompleted.Ptr()},
+	}
+	noCompletedDecisionHistory := []*types.HistoryEvent{
+		{ID: 1, EventType: types.EventTypeWorkflowExecutionStarted.Ptr()},
+		{ID: 2, EventType: types.EventTypeDecisionTaskScheduled.Ptr()},
+		{ID: 3, EventType: types.EventTypeDecisionTaskStarted.Ptr()},
+	}
+	testCases := []struct {
+		name             string
+		history          []*types.HistoryEvent
+		terminateAllowed bool
+		resetErr         error
+		terminateErr     error
+		expectReset      bool
+		expectTerminate  bool
+		expectedResult   FixResult
+	}{
+		{
+			name:        "reset to last completed decision before the first missing event",
+			history:     completedDecisionHistory,
+			expectReset: true,
+			expectedResult: FixResult{
+				FixResultType: FixResultTypeFixed,
+				InvariantName: ScheduledEventsExist,
+				Info:          "reset to DecisionTaskCompleted event 4",
+			},
+		},
+		{
+			name:        "reset failure",
+			history:     completedDecisionHistory,
+			resetErr:    errors.New("reset failure"),
+			expectReset: true,
+			expectedResult: FixResult{
+				FixResultType: FixResultTypeFailed,
+				InvariantName: ScheduledEventsExist,
+				Info:          "failed to reset workflow execution",
+				InfoDetails:   "reset failure",
+			},
+		},
+		{
+			name:    "terminate is not allowed",
+			history: noCompletedDecisionHistory,
+			expectedResult: FixResult{
+				FixResultType: FixResultTypeSkipped,
+				InvariantName: ScheduledEventsExist,
+				Info:          "execution can only be fixed by terminating it, which is not allowed for the domain",
+			},
+		},
+		{
+			name:             "terminated",
+			history:          noCompletedDecisionHistory,
+			terminateAllowed: true,
+			expectTerminate:  true,
+			expectedResult: FixResult{
+				FixResultType: FixResultTypeFixed,
+				InvariantName: ScheduledEventsExist,
+			},
+		},
+		{
+			name:             "terminate failure",
+			history:          noCompletedDecisionHistory,
+			terminateAllowed: true,
+			terminateErr:     errors.New("terminate failure"),
+			expectTerminate:  true,
+			expectedResult: FixResult{
+				FixResultType: FixResultTypeFailed,
+				InvariantName: ScheduledEventsExist,
+				Info:          "failed to terminate workflow execution",
+				InfoDetails:   "terminate failure",
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	domainCache := cache.NewMockDomainCache(ctrl)
+	domainCache.EXPECT().GetDomainName(gomock.Any()).Return("test-domain-name", nil).AnyTimes()
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			execManager := &mocks.ExecutionManager{}
+			historyManager := &mocks.HistoryV2Manager{}
+			execManager.On("GetWorkflowExecution", mock.Anything, mock.Anything).Return(getExecutionResponse(getPendingEventsMutableState()), nil)
+			historyManager.On("ReadHistoryBranch", mock.Anything, mock.Anything).Return(&persistence.ReadHistoryBranchResponse{
+				HistoryEvents: tc.history,
+			}, nil)
+			historyClient := history.NewMockClient(ctrl)
+			if tc.expectReset {
+				historyClient.EXPECT().ResetWorkflowExecution(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, request *types.HistoryResetWorkflowExecutionRequest, _ ...interface{}) (*types.ResetWorkflowExecutionResponse, error) {
+						s.Equal(domainID, request.DomainUUID)
+						s.Equal(workflowID, request.ResetRequest.WorkflowExecution.WorkflowID)
+						s.Equal(int64(4), request.ResetRequest.DecisionFinishEventID)
+						return &types.ResetWorkflowExecutionResponse{}, tc.resetErr
+					},
+				)
+			}
+			if tc.expectTerminate {
+				historyClient.EXPECT().TerminateWorkflowExecution(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, request *types.HistoryTerminateWorkflowExecutionRequest, _ ...interface{}) error {
+						s.Equal(domainID, request.DomainUUID)
+						s.Equal(workflowID, request.TerminateRequest.WorkflowExecution.WorkflowID)
+						s.Equal(runID, request.TerminateRequest.WorkflowExecution.RunID)
+						return tc.terminateErr
+					},
+				)
+			}
+			i := NewScheduledEventsExist(
+				persistence.NewPersistenceRetryer(execManager, historyManager, c2.CreatePersistenceRetryPolicy()),
+				domainCache,
+				historyClient,
+				dynamicconfig.GetBoolPropertyFnFilteredByDomain(tc.terminateAllowed),
+			)
+			result := i.Fix(context.Background(), getOpenConcreteExecution())
+			s.Equal(CheckResultTypeCorrupted, result.CheckResult.CheckResultType)
+			result.CheckResult = CheckResult{}
+			s.Equal(tc.expectedResult, result)
+		})
+	}
+}
+
+func getPendingEventsMutableState() *persistence.WorkflowMutableState {
+	return &persistence.WorkflowMutableState{
+		ActivityInfos: map[int64]*persistence.ActivityInfo{
+			5: {ScheduleID: 5},
+		},
+		TimerInfos: map[string]*persistence.TimerInfo{
+			"timer": {TimerID: "timer", StartedID: 6},
+		},
+		ChildExecutionInfos: map[int64]*persistence.ChildExecutionInfo{
+			7: {InitiatedID: 7},
+		},
+	}
+}
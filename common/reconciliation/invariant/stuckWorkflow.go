@@ -0,0 +1,219 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+
+package invariant
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uber/cadence/client/history"
+	c "github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/reconciliation/entity"
+	"github.com/uber/cadence/common/types"
+)
+
+const (
+	// stuckWorkflowGracePeriod is how long a timeout or backoff is allowed to be overdue
+	// before its task is considered lost
+	stuckWorkflowGracePeriod = time.Hour
+
+	stuckWorkflowInfoOverdueTask      = "pending activity or timer is overdue"
+	stuckWorkflowInfoMissingDecision  = "no decision is pending although last event batch requires one"
+	stuckWorkflowInfoNoFirstDecision  = "first decision was never scheduled"
+	stuckWorkflowInfoNoAutomaticFixes = "execution has processed decisions and cannot be fixed automatically, reset it instead"
+)
+
+type (
+	stuckWorkflow struct {
+		pr         persistence.Retryer
+		dc         cache.DomainCache
+		hc         history.Client
+		timeSource clock.TimeSource
+	}
+)
+
+// NewStuckWorkflow returns a new invariant which checks that an open execution without a pending decision
+// still has outstanding work which will eventually schedule one.
+func NewStuckWorkflow(
+	pr persistence.Retryer,
+	dc cache.DomainCache,
+	hc history.Client,
+) Invariant {
+	return &stuckWorkflow{
+		pr:         pr,
+		dc:         dc,
+		hc:         hc,
+		timeSource: clock.NewRealTimeSource(),
+	}
+}
+
+func (s *stuckWorkflow) Check(
+	ctx context.Context,
+	execution interface{},
+) CheckResult {
+	if checkResult := validateCheckContext(ctx, s.Name()); checkResult != nil {
+		return *checkResult
+	}
+
+	concreteExecution, state, checkResult := getOpenExecutionState(ctx, execution, s.Name(), s.pr, s.dc)
+	if checkResult != nil {
+		return *checkResult
+	}
+
+	executionInfo := state.ExecutionInfo
+	if executionInfo.DecisionScheduleID != c.EmptyEventID {
+		return CheckResult{
+			CheckResultType: CheckResultTypeHealthy,
+			InvariantName:   s.Name(),
+		}
+	}
+
+	deadline := s.timeSource.Now().Add(-stuckWorkflowGracePeriod)
+	for _, ti := range state.TimerInfos {
+		if ti.ExpiryTime.Before(deadline) {
+			return CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   s.Name(),
+				Info:            stuckWorkflowInfoOverdueTask,
+				InfoDetails:     fmt.Sprintf("TimerID: %v, ExpiryTime: %v", ti.TimerID, ti.ExpiryTime),
+			}
+		}
+	}
+	for _, ai := range state.ActivityInfos {
+		timeout := ai.ScheduledTime.Add(time.Duration(ai.ScheduleToStartTimeout) * time.Second)
+		if ai.StartedID != c.EmptyEventID {
+			timeout = ai.StartedTime.Add(time.Duration(ai.StartToCloseTimeout) * time.Second)
+		}
+		if timeout.Before(deadline) {
+			return CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   s.Name(),
+				Info:            stuckWorkflowInfoOverdueTask,
+				InfoDetails:     fmt.Sprintf("ActivityID: %v, Timeout: %v", ai.ActivityID, timeout),
+			}
+		}
+	}
+
+	if len(state.ActivityInfos) != 0 || len(state.TimerInfos) != 0 || len(state.ChildExecutionInfos) != 0 ||
+		len(state.RequestCancelInfos) != 0 || len(state.SignalInfos) != 0 {
+		return CheckResult{
+			CheckResultType: CheckResultTypeHealthy,
+			InvariantName:   s.Name(),
+		}
+	}
+
+	events, err := readHistoryEvents(ctx, s.pr, s.dc, concreteExecution, executionInfo.LastFirstEventID, executionInfo.NextEventID)
+	if err != nil {
+		return CheckResult{
+			CheckResultType: CheckResultTypeFailed,
+			InvariantName:   s.Name(),
+			Info:            "failed to read last event batch",
+			InfoDetails:     err.Error(),
+		}
+	}
+	for _, event := range events {
+		switch event.GetEventType() {
+		case types.EventTypeDecisionTaskCompleted:
+			// the workflow is waiting for an external event, e.g. a signal
+			return CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   s.Name(),
+			}
+		case types.EventTypeWorkflowExecutionStarted:
+			backoff := time.Duration(event.WorkflowExecutionStartedEventAttributes.GetFirstDecisionTaskBackoffSeconds()) * time.Second
+			if executionInfo.StartTimestamp.Add(backoff).After(deadline) {
+				return CheckResult{
+					CheckResultType: CheckResultTypeHealthy,
+					InvariantName:   s.Name(),
+				}
+			}
+		}
+	}
+	if executionInfo.LastProcessedEvent == c.EmptyEventID {
+		return CheckResult{
+			CheckResultType: CheckResultTypeCorrupted,
+			InvariantName:   s.Name(),
+			Info:            stuckWorkflowInfoNoFirstDecision,
+		}
+	}
+	return CheckResult{
+		CheckResultType: CheckResultTypeCorrupted,
+		InvariantName:   s.Name(),
+		Info:            stuckWorkflowInfoMissingDecision,
+		InfoDetails:     fmt.Sprintf("NextEventID: %v, LastProcessedEvent: %v", executionInfo.NextEventID, executionInfo.LastProcessedEvent),
+	}
+}
+
+// Fix regenerates lost tasks for overdue activities and timers, or schedules the first decision.
+func (s *stuckWorkflow) Fix(
+	ctx context.Context,
+	execution interface{},
+) FixResult {
+	if fixResult := validateFixContext(ctx, s.Name()); fixResult != nil {
+		return *fixResult
+	}
+
+	fixResult, checkResult := checkBeforeFix(ctx, s, execution)
+	if fixResult != nil {
+		return *fixResult
+	}
+	concreteExecution, _ := execution.(*entity.ConcreteExecution)
+	workflowExecution := &types.WorkflowExecution{
+		WorkflowID: concreteExecution.WorkflowID,
+		RunID:      concreteExecution.RunID,
+	}
+	switch checkResult.Info {
+	case stuckWorkflowInfoOverdueTask:
+		fixResult = RefreshExecutionTasks(ctx, concreteExecution.DomainID, workflowExecution, s.hc, s.dc)
+	case stuckWorkflowInfoNoFirstDecision:
+		fixResult = &FixResult{
+			FixResultType: FixResultTypeFixed,
+		}
+		if err := s.hc.ScheduleDecisionTask(ctx, &types.ScheduleDecisionTaskRequest{
+			DomainUUID:        concreteExecution.DomainID,
+			WorkflowExecution: workflowExecution,
+			IsFirstDecision:   true,
+		}); err != nil {
+			fixResult = &FixResult{
+				FixResultType: FixResultTypeFailed,
+				Info:          "failed to schedule decision",
+				InfoDetails:   err.Error(),
+			}
+		}
+	default:
+		fixResult = &FixResult{
+			FixResultType: FixResultTypeSkipped,
+			Info:          stuckWorkflowInfoNoAutomaticFixes,
+		}
+	}
+	fixResult.CheckResult = *checkResult
+	fixResult.InvariantName = s.Name()
+	return *fixResult
+}
+
+func (s *stuckWorkflow) Name() Name {
+	return StuckWorkflow
+}
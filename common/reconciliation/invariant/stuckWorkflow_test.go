@@ -0,0 +1,239 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+
+package invariant
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/uber/cadence/client/history"
+	c2 "github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/mocks"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+type StuckWorkflowSuite struct {
+	*require.Assertions
+	suite.Suite
+
+	now time.Time
+}
+
+func TestStuckWorkflowSuite(t *testing.T) {
+	suite.Run(t, new(StuckWorkflowSuite))
+}
+
+func (s *StuckWorkflowSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+	s.now = time.Unix(1600000000, 0)
+}
+
+func (s *StuckWorkflowSuite) TestCheck() {
+	testCases := []struct {
+		name           string
+		state          *persistence.WorkflowMutableState
+		lastBatch      []*types.HistoryEvent
+		expectedResult CheckResult
+	}{
+		{
+			name: "pending decision",
+			state: s.getMutableState(func(info *persistence.WorkflowExecutionInfo) {
+				info.DecisionScheduleID = 9
+			}),
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   StuckWorkflow,
+			},
+		},
+		{
+			name: "overdue timer",
+			state: &persistence.WorkflowMutableState{
+				TimerInfos: map[string]*persistence.TimerInfo{
+					"timer": {TimerID: "timer", ExpiryTime: s.now.Add(-2 * time.Hour)},
+				},
+			},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   StuckWorkflow,
+				Info:            stuckWorkflowInfoOverdueTask,
+				InfoDetails:     "TimerID: timer, ExpiryTime: " + s.now.Add(-2*time.Hour).String(),
+			},
+		},
+		{
+			name: "outstanding activity",
+			state: &persistence.WorkflowMutableState{
+				ActivityInfos: map[int64]*persistence.ActivityInfo{
+					5: {ScheduleID: 5, StartedID: c2.EmptyEventID, ScheduledTime: s.now, ScheduleToStartTimeout: 10},
+				},
+			},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   StuckWorkflow,
+			},
+		},
+		{
+			name:  "waiting for external event",
+			state: &persistence.WorkflowMutableState{},
+			lastBatch: []*types.HistoryEvent{
+				{ID: 8, EventType: types.EventTypeDecisionTaskCompleted.Ptr()},
+				{ID: 9, EventType: types.EventTypeMarkerRecorded.Ptr()},
+			},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   StuckWorkflow,
+			},
+		},
+		{
+			name:      "first decision backoff",
+			state:     s.getMutableState(withoutDecisions(s.now)),
+			lastBatch: []*types.HistoryEvent{getStartedEvent(3600)},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   StuckWorkflow,
+			},
+		},
+		{
+			name:      "first decision not scheduled",
+			state:     s.getMutableState(withoutDecisions(s.now.Add(-2 * time.Hour))),
+			lastBatch: []*types.HistoryEvent{getStartedEvent(0)},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   StuckWorkflow,
+				Info:            stuckWorkflowInfoNoFirstDecision,
+			},
+		},
+		{
+			name:  "decision not scheduled",
+			state: &persistence.WorkflowMutableState{},
+			lastBatch: []*types.HistoryEvent{
+				{ID: 8, EventType: types.EventTypeActivityTaskStarted.Ptr()},
+				{ID: 9, EventType: types.EventTypeActivityTaskCompleted.Ptr()},
+			},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   StuckWorkflow,
+				Info:            stuckWorkflowInfoMissingDecision,
+				InfoDetails:     "NextEventID: 10, LastProcessedEvent: 4",
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			i := s.newInvariant(ctrl, tc.state, tc.lastBatch, history.NewMockClient(ctrl))
+			s.Equal(tc.expectedResult, i.Check(context.Background(), getOpenConcreteExecution()))
+		})
+	}
+}
+
+func (s *StuckWorkflowSuite) TestFix() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	historyClient := history.NewMockClient(ctrl)
+	historyClient.EXPECT().RefreshWorkflowTasks(gomock.Any(), gomock.Any()).Return(nil)
+	i := s.newInvariant(ctrl, &persistence.WorkflowMutableState{
+		ActivityInfos: map[int64]*persistence.ActivityInfo{
+			5: {ActivityID: "activity", ScheduleID: 5, StartedID: 6, StartedTime: s.now.Add(-2 * time.Hour), StartToCloseTimeout: 10},
+		},
+	}, nil, historyClient)
+	result := i.Fix(context.Background(), getOpenConcreteExecution())
+	s.Equal(FixResultTypeFixed, result.FixResultType)
+	s.Equal(stuckWorkflowInfoOverdueTask, result.CheckResult.Info)
+
+	historyClient = history.NewMockClient(ctrl)
+	historyClient.EXPECT().ScheduleDecisionTask(gomock.Any(), &types.ScheduleDecisionTaskRequest{
+		DomainUUID:        domainID,
+		WorkflowExecution: &types.WorkflowExecution{WorkflowID: workflowID, RunID: runID},
+		IsFirstDecision:   true,
+	}).Return(nil)
+	i = s.newInvariant(ctrl, s.getMutableState(withoutDecisions(s.now.Add(-2*time.Hour))), []*types.HistoryEvent{getStartedEvent(0)}, historyClient)
+	result = i.Fix(context.Background(), getOpenConcreteExecution())
+	s.Equal(FixResultTypeFixed, result.FixResultType)
+	s.Equal(stuckWorkflowInfoNoFirstDecision, result.CheckResult.Info)
+
+	i = s.newInvariant(ctrl, &persistence.WorkflowMutableState{}, []*types.HistoryEvent{
+		{ID: 9, EventType: types.EventTypeTimerFired.Ptr()},
+	}, history.NewMockClient(ctrl))
+	result = i.Fix(context.Background(), getOpenConcreteExecution())
+	s.Equal(FixResultTypeSkipped, result.FixResultType)
+	s.Equal(stuckWorkflowInfoNoAutomaticFixes, result.Info)
+}
+
+func (s *StuckWorkflowSuite) newInvariant(
+	ctrl *gomock.Controller,
+	state *persistence.WorkflowMutableState,
+	lastBatch []*types.HistoryEvent,
+	historyClient history.Client,
+) Invariant {
+	domainCache := cache.NewMockDomainCache(ctrl)
+	domainCache.EXPECT().GetDomainName(gomock.Any()).Return("test-domain-name", nil).AnyTimes()
+	execManager := &mocks.ExecutionManager{}
+	historyManager := &mocks.HistoryV2Manager{}
+	execManager.On("GetWorkflowExecution", mock.Anything, mock.Anything).Return(getExecutionResponse(state), nil)
+	historyManager.On("ReadHistoryBranch", mock.Anything, mock.Anything).Return(&persistence.ReadHistoryBranchResponse{
+		HistoryEvents: lastBatch,
+	}, nil)
+	i := NewStuckWorkflow(
+		persistence.NewPersistenceRetryer(execManager, historyManager, c2.CreatePersistenceRetryPolicy()),
+		domainCache,
+		historyClient,
+	)
+	i.(*stuckWorkflow).timeSource = clock.NewEventTimeSource().Update(s.now)
+	return i
+}
+
+func (s *StuckWorkflowSuite) getMutableState(update func(info *persistence.WorkflowExecutionInfo)) *persistence.WorkflowMutableState {
+	state := getExecutionResponse(&persistence.WorkflowMutableState{}).State
+	update(state.ExecutionInfo)
+	return state
+}
+
+func withoutDecisions(startTime time.Time) func(info *persistence.WorkflowExecutionInfo) {
+	return func(info *persistence.WorkflowExecutionInfo) {
+		info.StartTimestamp = startTime
+		info.LastFirstEventID = c2.FirstEventID
+		info.NextEventID = c2.FirstEventID + 1
+		info.LastProcessedEvent = c2.EmptyEventID
+	}
+}
+
+func getStartedEvent(backoffSeconds int32) *types.HistoryEvent {
+	return &types.HistoryEvent{
+		ID:        c2.FirstEventID,
+		EventType: types.EventTypeWorkflowExecutionStarted.Ptr(),
+		WorkflowExecutionStartedEventAttributes: &types.WorkflowExecutionStartedEventAttributes{
+			FirstDecisionTaskBackoffSeconds: c2.Int32Ptr(backoffSeconds),
+		},
+	}
+}
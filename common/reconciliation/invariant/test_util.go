@@ -23,6 +23,7 @@
 package invariant
 
 import (
+	c "github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/reconciliation/entity"
 )
@@ -98,3 +99,19 @@ func getClosedCurrentExecution() *entity.CurrentExecution {
 		CurrentRunID: currentRunID,
 	}
 }
+
+func getExecutionResponse(state *persistence.WorkflowMutableState) *persistence.GetWorkflowExecutionResponse {
+	if state.ExecutionInfo == nil {
+		state.ExecutionInfo = &persistence.WorkflowExecutionInfo{
+			DomainID:           domainID,
+			WorkflowID:         workflowID,
+			RunID:              runID,
+			State:              openState,
+			NextEventID:        10,
+			LastFirstEventID:   8,
+			LastProcessedEvent: 4,
+			DecisionScheduleID: c.EmptyEventID,
+		}
+	}
+	return &persistence.GetWorkflowExecutionResponse{State: state}
+}
@@ -48,11 +48,35 @@ const (
 	OpenCurrentExecution Name = "open_current_execution"
 	// ConcreteExecutionExists asserts that an open current execution must have a valid concrete execution
 	ConcreteExecutionExists Name = "concrete_execution_exists"
+	// ScheduledEventsExist asserts that events referenced by pending activities, timers and child executions exist in history
+	ScheduledEventsExist Name = "scheduled_events_exist"
+	// StuckWorkflow asserts that an open execution has a pending decision or outstanding work which will schedule one
+	StuckWorkflow Name = "stuck_workflow"
+	// ParentClosePolicyApplied asserts that an open child execution does not outlive its closed parent unless the policy is abandon
+	ParentClosePolicyApplied Name = "parent_close_policy_applied"
+	// NextEventIDMatchesHistory asserts that the last event in history is the one before mutable state NextEventID
+	NextEventIDMatchesHistory Name = "next_event_id_matches_history"
+	// OrphanedHistoryBranch asserts that a history branch older than the cleanup threshold has an owning execution
+	OrphanedHistoryBranch Name = "orphaned_history_branch"
+	// AbandonedTaskList asserts that an empty task list has been updated within the grace period
+	AbandonedTaskList Name = "abandoned_task_list"
+	// VisibilityRecordMatches asserts that the visibility record of an execution exists and matches the execution
+	VisibilityRecordMatches Name = "visibility_record_matches"
+	// OrphanedTransferTask asserts that a transfer task has an owning execution
+	OrphanedTransferTask Name = "orphaned_transfer_task"
+	// OrphanedReplicationTask asserts that a replication task has an owning execution
+	OrphanedReplicationTask Name = "orphaned_replication_task"
+	// LongRunningWorkflow asserts that an open execution has not been running longer than expected for its workflow type
+	LongRunningWorkflow Name = "long_running_workflow"
 
 	// CollectionMutableState is the collection of invariants relating to mutable state
 	CollectionMutableState Collection = 0
 	// CollectionHistory is the collection  of invariants relating to history
 	CollectionHistory Collection = 1
+	// CollectionWorkflowState is the collection of invariants relating to consistency of open workflows with history and other workflows
+	CollectionWorkflowState Collection = 2
+	// CollectionVisibility is the collection of invariants relating to consistency of visibility records with executions
+	CollectionVisibility Collection = 3
 )
 
 type (
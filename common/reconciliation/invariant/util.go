@@ -24,10 +24,22 @@ package invariant
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/pborman/uuid"
+
+	"github.com/uber/cadence/client/history"
+	c "github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/dynamicconfig"
 	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/reconciliation/entity"
+	"github.com/uber/cadence/common/types"
+)
+
+const (
+	historyReadPageSize = 100
+	fixerIdentity       = "cadence-sys-executions-fixer"
 )
 
 func checkBeforeFix(
@@ -154,3 +166,264 @@ func validateFixContext(
 
 	return nil
 }
+
+// getOpenExecutionState loads the mutable state of an open concrete execution.
+// A non-nil CheckResult is returned if the check can be concluded without the mutable state,
+// i.e. the entity is of unexpected type, the execution is closed or no longer exists, or loading failed.
+func getOpenExecutionState(
+	ctx context.Context,
+	execution interface{},
+	invariantName Name,
+	pr persistence.Retryer,
+	dc cache.DomainCache,
+) (*entity.ConcreteExecution, *persistence.WorkflowMutableState, *CheckResult) {
+	concreteExecution, ok := execution.(*entity.ConcreteExecution)
+	if !ok {
+		return nil, nil, &CheckResult{
+			CheckResultType: CheckResultTypeFailed,
+			InvariantName:   invariantName,
+			Info:            "failed to check: expected concrete execution",
+		}
+	}
+	if !Open(concreteExecution.State) {
+		return nil, nil, &CheckResult{
+			CheckResultType: CheckResultTypeHealthy,
+			InvariantName:   invariantName,
+		}
+	}
+	domainName, err := dc.GetDomainName(concreteExecution.DomainID)
+	if err != nil {
+		return nil, nil, &CheckResult{
+			CheckResultType: CheckResultTypeFailed,
+			InvariantName:   invariantName,
+			Info:            "failed to check: expected DomainName",
+			InfoDetails:     err.Error(),
+		}
+	}
+	resp, err := pr.GetWorkflowExecution(ctx, &persistence.GetWorkflowExecutionRequest{
+		DomainID: concreteExecution.DomainID,
+		Execution: types.WorkflowExecution{
+			WorkflowID: concreteExecution.WorkflowID,
+			RunID:      concreteExecution.RunID,
+		},
+		DomainName: domainName,
+	})
+	if err != nil {
+		if _, ok := err.(*types.EntityNotExistsError); ok {
+			return nil, nil, &CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   invariantName,
+				Info:            "determined execution was healthy because concrete execution no longer exists",
+			}
+		}
+		return nil, nil, &CheckResult{
+			CheckResultType: CheckResultTypeFailed,
+			InvariantName:   invariantName,
+			Info:            "failed to get concrete execution",
+			InfoDetails:     err.Error(),
+		}
+	}
+	if !Open(resp.State.ExecutionInfo.State) {
+		return nil, nil, &CheckResult{
+			CheckResultType: CheckResultTypeHealthy,
+			InvariantName:   invariantName,
+			Info:            "determined execution was healthy because concrete execution is closed",
+		}
+	}
+	return concreteExecution, resp.State, nil
+}
+
+// readHistoryEvents reads all history events of the execution in range [minEventID, maxEventID).
+func readHistoryEvents(
+	ctx context.Context,
+	pr persistence.Retryer,
+	dc cache.DomainCache,
+	execution *entity.ConcreteExecution,
+	minEventID int64,
+	maxEventID int64,
+) ([]*types.HistoryEvent, error) {
+	domainName, err := dc.GetDomainName(execution.DomainID)
+	if err != nil {
+		return nil, err
+	}
+	var events []*types.HistoryEvent
+	var nextPageToken []byte
+	for {
+		resp, err := pr.ReadHistoryBranch(ctx, &persistence.ReadHistoryBranchRequest{
+			BranchToken:   execution.BranchToken,
+			MinEventID:    minEventID,
+			MaxEventID:    maxEventID,
+			PageSize:      historyReadPageSize,
+			NextPageToken: nextPageToken,
+			ShardID:       c.IntPtr(execution.ShardID),
+			DomainName:    domainName,
+		})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, resp.HistoryEvents...)
+		nextPageToken = resp.NextPageToken
+		if len(nextPageToken) == 0 {
+			return events, nil
+		}
+	}
+}
+
+// lastCompletedDecisionID returns the ID of the last DecisionTaskCompleted event before maxEventID,
+// or EmptyEventID if there is none. Events after a gap cannot be replayed, so only the contiguous
+// part of history from the first event is considered.
+func lastCompletedDecisionID(events []*types.HistoryEvent, maxEventID int64) int64 {
+	decisionFinishEventID := c.EmptyEventID
+	for i, event := range events {
+		if event.ID != c.FirstEventID+int64(i) || event.ID >= maxEventID {
+			break
+		}
+		if event.GetEventType() == types.EventTypeDecisionTaskCompleted {
+			decisionFinishEventID = event.ID
+		}
+	}
+	return decisionFinishEventID
+}
+
+// TerminateExecution terminates an open concrete execution through history service.
+func TerminateExecution(
+	ctx context.Context,
+	execution *entity.ConcreteExecution,
+	invariantName Name,
+	hc history.Client,
+	dc cache.DomainCache,
+) *FixResult {
+	domainName, err := dc.GetDomainName(execution.DomainID)
+	if err != nil {
+		return &FixResult{
+			FixResultType: FixResultTypeFailed,
+			Info:          "failed to fetch domainName",
+			InfoDetails:   err.Error(),
+		}
+	}
+	workflowExecution := &types.WorkflowExecution{
+		WorkflowID: execution.WorkflowID,
+		RunID:      execution.RunID,
+	}
+	if err := hc.TerminateWorkflowExecution(ctx, &types.HistoryTerminateWorkflowExecutionRequest{
+		DomainUUID: execution.DomainID,
+		TerminateRequest: &types.TerminateWorkflowExecutionRequest{
+			Domain:            domainName,
+			WorkflowExecution: workflowExecution,
+			Reason:            fmt.Sprintf("execution failed %v invariant", invariantName),
+			Identity:          fixerIdentity,
+		},
+	}); err != nil {
+		return &FixResult{
+			FixResultType: FixResultTypeFailed,
+			Info:          "failed to terminate workflow execution",
+			InfoDetails:   err.Error(),
+		}
+	}
+	return &FixResult{
+		FixResultType: FixResultTypeFixed,
+	}
+}
+
+// TerminateExecutionIfAllowed terminates an open concrete execution if terminating is allowed for its domain.
+// Terminating loses the progress of the workflow, so it is only done when operators opted in for the domain.
+func TerminateExecutionIfAllowed(
+	ctx context.Context,
+	execution *entity.ConcreteExecution,
+	invariantName Name,
+	hc history.Client,
+	dc cache.DomainCache,
+	terminateAllowed dynamicconfig.BoolPropertyFnWithDomainFilter,
+) *FixResult {
+	domainName, err := dc.GetDomainName(execution.DomainID)
+	if err != nil {
+		return &FixResult{
+			FixResultType: FixResultTypeFailed,
+			Info:          "failed to fetch domainName",
+			InfoDetails:   err.Error(),
+		}
+	}
+	if terminateAllowed == nil || !terminateAllowed(domainName) {
+		return &FixResult{
+			FixResultType: FixResultTypeSkipped,
+			Info:          "execution can only be fixed by terminating it, which is not allowed for the domain",
+		}
+	}
+	return TerminateExecution(ctx, execution, invariantName, hc, dc)
+}
+
+// ResetExecution resets an open concrete execution to the given DecisionTaskCompleted event through history service.
+func ResetExecution(
+	ctx context.Context,
+	execution *entity.ConcreteExecution,
+	invariantName Name,
+	decisionFinishEventID int64,
+	hc history.Client,
+	dc cache.DomainCache,
+) *FixResult {
+	domainName, err := dc.GetDomainName(execution.DomainID)
+	if err != nil {
+		return &FixResult{
+			FixResultType: FixResultTypeFailed,
+			Info:          "failed to fetch domainName",
+			InfoDetails:   err.Error(),
+		}
+	}
+	if _, err := hc.ResetWorkflowExecution(ctx, &types.HistoryResetWorkflowExecutionRequest{
+		DomainUUID: execution.DomainID,
+		ResetRequest: &types.ResetWorkflowExecutionRequest{
+			Domain: domainName,
+			WorkflowExecution: &types.WorkflowExecution{
+				WorkflowID: execution.WorkflowID,
+				RunID:      execution.RunID,
+			},
+			Reason:                fmt.Sprintf("execution failed %v invariant", invariantName),
+			DecisionFinishEventID: decisionFinishEventID,
+			RequestID:             uuid.New(),
+		},
+	}); err != nil {
+		return &FixResult{
+			FixResultType: FixResultTypeFailed,
+			Info:          "failed to reset workflow execution",
+			InfoDetails:   err.Error(),
+		}
+	}
+	return &FixResult{
+		FixResultType: FixResultTypeFixed,
+		Info:          fmt.Sprintf("reset to DecisionTaskCompleted event %v", decisionFinishEventID),
+	}
+}
+
+// RefreshExecutionTasks regenerates transfer and timer tasks of an execution through history service.
+func RefreshExecutionTasks(
+	ctx context.Context,
+	domainID string,
+	workflowExecution *types.WorkflowExecution,
+	hc history.Client,
+	dc cache.DomainCache,
+) *FixResult {
+	domainName, err := dc.GetDomainName(domainID)
+	if err != nil {
+		return &FixResult{
+			FixResultType: FixResultTypeFailed,
+			Info:          "failed to fetch domainName",
+			InfoDetails:   err.Error(),
+		}
+	}
+	if err := hc.RefreshWorkflowTasks(ctx, &types.HistoryRefreshWorkflowTasksRequest{
+		DomainUIID: domainID,
+		Request: &types.RefreshWorkflowTasksRequest{
+			Domain:    domainName,
+			Execution: workflowExecution,
+		},
+	}); err != nil {
+		return &FixResult{
+			FixResultType: FixResultTypeFailed,
+			Info:          "failed to refresh workflow tasks",
+			InfoDetails:   err.Error(),
+		}
+	}
+	return &FixResult{
+		FixResultType: FixResultTypeFixed,
+	}
+}
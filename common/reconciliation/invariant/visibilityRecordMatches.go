@@ -0,0 +1,337 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package invariant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/uber/cadence/client/history"
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/clock"
+	"github.com/uber/cadence/common/definition"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/reconciliation/entity"
+	"github.com/uber/cadence/common/types"
+)
+
+const (
+	// visibilityRecordGracePeriod is how long visibility is allowed to lag behind
+	// the last update of an execution before the record is considered diverged
+	visibilityRecordGracePeriod = 10 * time.Minute
+	visibilityReadPageSize      = 100
+
+	visibilityInfoMissingRecord           = "visibility record is missing"
+	visibilityInfoRecordClosed            = "visibility record is closed but execution is open"
+	visibilityInfoRecordOpen              = "visibility record is open but execution is closed"
+	visibilityInfoCloseStatusMismatch     = "visibility record close status does not match execution"
+	visibilityInfoSearchAttributeMismatch = "visibility record search attributes do not match execution"
+)
+
+type (
+	visibilityRecordMatches struct {
+		pr                      persistence.Retryer
+		dc                      cache.DomainCache
+		vm                      persistence.VisibilityManager
+		hc                      history.Client
+		searchAttributesIndexed dynamicconfig.BoolPropertyFnWithDomainFilter
+		searchAttributeAliases  dynamicconfig.MapPropertyFn
+		timeSource              clock.TimeSource
+	}
+)
+
+// NewVisibilityRecordMatches returns a new invariant which checks that the visibility record of an execution
+// exists and agrees with the execution on being open or closed and on close status.
+// Search attributes are only compared for domains for which searchAttributesIndexed is true,
+// as they are not kept by basic visibility. Executions keep search attributes under the key they were
+// upserted with, while records keep them under the attribute an alias stands for, so keys of the execution
+// are resolved through searchAttributeAliases before being compared.
+func NewVisibilityRecordMatches(
+	pr persistence.Retryer,
+	dc cache.DomainCache,
+	vm persistence.VisibilityManager,
+	hc history.Client,
+	searchAttributesIndexed dynamicconfig.BoolPropertyFnWithDomainFilter,
+	searchAttributeAliases dynamicconfig.MapPropertyFn,
+) Invariant {
+	return &visibilityRecordMatches{
+		pr:                      pr,
+		dc:                      dc,
+		vm:                      vm,
+		hc:                      hc,
+		searchAttributesIndexed: searchAttributesIndexed,
+		searchAttributeAliases:  searchAttributeAliases,
+		timeSource:              clock.NewRealTimeSource(),
+	}
+}
+
+func (v *visibilityRecordMatches) Check(
+	ctx context.Context,
+	execution interface{},
+) CheckResult {
+	if checkResult := validateCheckContext(ctx, v.Name()); checkResult != nil {
+		return *checkResult
+	}
+
+	concreteExecution, ok := execution.(*entity.ConcreteExecution)
+	if !ok {
+		return CheckResult{
+			CheckResultType: CheckResultTypeFailed,
+			InvariantName:   v.Name(),
+			Info:            "failed to check: expected concrete execution",
+		}
+	}
+	domainName, err := v.dc.GetDomainName(concreteExecution.DomainID)
+	if err != nil {
+		return CheckResult{
+			CheckResultType: CheckResultTypeFailed,
+			InvariantName:   v.Name(),
+			Info:            "failed to check: expected DomainName",
+			InfoDetails:     err.Error(),
+		}
+	}
+	resp, err := v.pr.GetWorkflowExecution(ctx, &persistence.GetWorkflowExecutionRequest{
+		DomainID: concreteExecution.DomainID,
+		Execution: types.WorkflowExecution{
+			WorkflowID: concreteExecution.WorkflowID,
+			RunID:      concreteExecution.RunID,
+		},
+		DomainName: domainName,
+	})
+	if err != nil {
+		if _, ok := err.(*types.EntityNotExistsError); ok {
+			return CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   v.Name(),
+				Info:            "determined execution was healthy because concrete execution no longer exists",
+			}
+		}
+		return CheckResult{
+			CheckResultType: CheckResultTypeFailed,
+			InvariantName:   v.Name(),
+			Info:            "failed to get concrete execution",
+			InfoDetails:     err.Error(),
+		}
+	}
+
+	executionInfo := resp.State.ExecutionInfo
+	if executionInfo.State == persistence.WorkflowStateZombie ||
+		executionInfo.State == persistence.WorkflowStateVoid ||
+		executionInfo.State == persistence.WorkflowStateCorrupted {
+		return CheckResult{
+			CheckResultType: CheckResultTypeHealthy,
+			InvariantName:   v.Name(),
+			Info:            "determined execution was healthy because execution is not visible",
+		}
+	}
+	if executionInfo.LastUpdatedTimestamp.After(v.timeSource.Now().Add(-visibilityRecordGracePeriod)) {
+		return CheckResult{
+			CheckResultType: CheckResultTypeHealthy,
+			InvariantName:   v.Name(),
+			Info:            "determined execution was healthy because it was updated within the grace period",
+		}
+	}
+
+	record, recordOpen, err := v.getVisibilityRecord(ctx, domainName, executionInfo)
+	if err != nil {
+		return CheckResult{
+			CheckResultType: CheckResultTypeFailed,
+			InvariantName:   v.Name(),
+			Info:            "failed to get visibility record",
+			InfoDetails:     err.Error(),
+		}
+	}
+	if record == nil {
+		return CheckResult{
+			CheckResultType: CheckResultTypeCorrupted,
+			InvariantName:   v.Name(),
+			Info:            visibilityInfoMissingRecord,
+		}
+	}
+
+	if Open(executionInfo.State) {
+		if !recordOpen {
+			return CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   v.Name(),
+				Info:            visibilityInfoRecordClosed,
+				InfoDetails:     fmt.Sprintf("RecordCloseStatus: %v", record.GetCloseStatus()),
+			}
+		}
+	} else {
+		if recordOpen {
+			return CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   v.Name(),
+				Info:            visibilityInfoRecordOpen,
+			}
+		}
+		closeStatus := persistence.ToInternalWorkflowExecutionCloseStatus(executionInfo.CloseStatus)
+		if closeStatus == nil || record.CloseStatus == nil || *record.CloseStatus != *closeStatus {
+			return CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   v.Name(),
+				Info:            visibilityInfoCloseStatusMismatch,
+				InfoDetails:     fmt.Sprintf("CloseStatus: %v, RecordCloseStatus: %v", closeStatus, record.CloseStatus),
+			}
+		}
+	}
+
+	if v.searchAttributesIndexed(domainName) {
+		if key, ok := searchAttributesMatch(
+			executionInfo.SearchAttributes,
+			record.GetSearchAttributes().GetIndexedFields(),
+			v.searchAttributeAliases(),
+		); !ok {
+			return CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   v.Name(),
+				Info:            visibilityInfoSearchAttributeMismatch,
+				InfoDetails:     fmt.Sprintf("SearchAttribute: %v", key),
+			}
+		}
+	}
+
+	return CheckResult{
+		CheckResultType: CheckResultTypeHealthy,
+		InvariantName:   v.Name(),
+	}
+}
+
+// Fix refreshes the tasks of the execution through history service. This emits new record started,
+// upsert search attributes or close execution tasks, which rewrite the visibility record.
+// Unlike writing the record from here, refreshed tasks get new task IDs and are therefore
+// not dropped by the version check of advanced visibility.
+func (v *visibilityRecordMatches) Fix(
+	ctx context.Context,
+	execution interface{},
+) FixResult {
+	if fixResult := validateFixContext(ctx, v.Name()); fixResult != nil {
+		return *fixResult
+	}
+
+	fixResult, checkResult := checkBeforeFix(ctx, v, execution)
+	if fixResult != nil {
+		return *fixResult
+	}
+	concreteExecution, _ := execution.(*entity.ConcreteExecution)
+	fixResult = RefreshExecutionTasks(ctx, concreteExecution.DomainID, &types.WorkflowExecution{
+		WorkflowID: concreteExecution.WorkflowID,
+		RunID:      concreteExecution.RunID,
+	}, v.hc, v.dc)
+	fixResult.CheckResult = *checkResult
+	fixResult.InvariantName = v.Name()
+	return *fixResult
+}
+
+func (v *visibilityRecordMatches) Name() Name {
+	return VisibilityRecordMatches
+}
+
+// getVisibilityRecord returns the visibility record of the execution and whether it is an open record.
+// A nil record is returned if the execution has neither an open nor a closed record.
+func (v *visibilityRecordMatches) getVisibilityRecord(
+	ctx context.Context,
+	domainName string,
+	executionInfo *persistence.WorkflowExecutionInfo,
+) (*types.WorkflowExecutionInfo, bool, error) {
+	request := &persistence.ListWorkflowExecutionsByWorkflowIDRequest{
+		ListWorkflowExecutionsRequest: persistence.ListWorkflowExecutionsRequest{
+			DomainUUID:   executionInfo.DomainID,
+			Domain:       domainName,
+			EarliestTime: 0,
+			LatestTime:   v.timeSource.Now().UnixNano(),
+			PageSize:     visibilityReadPageSize,
+		},
+		WorkflowID: executionInfo.WorkflowID,
+	}
+	record, err := findVisibilityRecord(ctx, request, executionInfo.RunID, v.vm.ListOpenWorkflowExecutionsByWorkflowID)
+	if err != nil || record != nil {
+		return record, true, err
+	}
+	request.NextPageToken = nil
+	record, err = findVisibilityRecord(ctx, request, executionInfo.RunID, v.vm.ListClosedWorkflowExecutionsByWorkflowID)
+	return record, false, err
+}
+
+func findVisibilityRecord(
+	ctx context.Context,
+	request *persistence.ListWorkflowExecutionsByWorkflowIDRequest,
+	runID string,
+	listFn func(context.Context, *persistence.ListWorkflowExecutionsByWorkflowIDRequest) (*persistence.ListWorkflowExecutionsResponse, error),
+) (*types.WorkflowExecutionInfo, error) {
+	for {
+		resp, err := listFn(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range resp.Executions {
+			if record.GetExecution().GetRunID() == runID {
+				return record, nil
+			}
+		}
+		if len(resp.NextPageToken) == 0 {
+			return nil, nil
+		}
+		request.NextPageToken = resp.NextPageToken
+	}
+}
+
+// searchAttributesMatch returns false and the first mismatching key if any search attribute of the execution
+// is missing from the visibility record or has a different value. Values are compared as decoded JSON.
+func searchAttributesMatch(executionAttributes, recordAttributes map[string][]byte, aliases map[string]interface{}) (string, bool) {
+	keys := make([]string, 0, len(executionAttributes))
+	for key := range executionAttributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		recordValue, ok := recordAttributes[definition.ResolveSearchAttributeAlias(aliases, key)]
+		if !ok {
+			return key, false
+		}
+		expected, err := decodeSearchAttribute(executionAttributes[key])
+		if err != nil {
+			return key, false
+		}
+		actual, err := decodeSearchAttribute(recordValue)
+		if err != nil || !reflect.DeepEqual(expected, actual) {
+			return key, false
+		}
+	}
+	return "", true
+}
+
+func decodeSearchAttribute(data []byte) (interface{}, error) {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err := decoder.Decode(&value)
+	return value, err
+}
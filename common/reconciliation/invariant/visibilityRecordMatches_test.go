@@ -0,0 +1,301 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+
+package invariant
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/uber/cadence/client/history"
+	c2 "github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/mocks"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+type VisibilityRecordMatchesSuite struct {
+	*require.Assertions
+	suite.Suite
+}
+
+func TestVisibilityRecordMatchesSuite(t *testing.T) {
+	suite.Run(t, new(VisibilityRecordMatchesSuite))
+}
+
+func (s *VisibilityRecordMatchesSuite) SetupTest() {
+	s.Assertions = require.New(s.T())
+}
+
+func (s *VisibilityRecordMatchesSuite) TestCheck() {
+	openRecord := &types.WorkflowExecutionInfo{
+		Execution: &types.WorkflowExecution{WorkflowID: workflowID, RunID: runID},
+		SearchAttributes: &types.SearchAttributes{
+			IndexedFields: map[string][]byte{"CustomKeywordField": []byte(`"value"`)},
+		},
+	}
+	completedRecord := &types.WorkflowExecutionInfo{
+		Execution:   &types.WorkflowExecution{WorkflowID: workflowID, RunID: runID},
+		CloseStatus: types.WorkflowExecutionCloseStatusCompleted.Ptr(),
+	}
+	failedRecord := &types.WorkflowExecutionInfo{
+		Execution:   &types.WorkflowExecution{WorkflowID: workflowID, RunID: runID},
+		CloseStatus: types.WorkflowExecutionCloseStatusFailed.Ptr(),
+	}
+	otherRunRecord := &types.WorkflowExecutionInfo{
+		Execution: &types.WorkflowExecution{WorkflowID: workflowID, RunID: "other-run-id"},
+	}
+
+	testCases := []struct {
+		name                    string
+		closed                  bool
+		lastUpdated             time.Time
+		searchAttributes        map[string][]byte
+		searchAttributesIndexed bool
+		openRecords             []*types.WorkflowExecutionInfo
+		closedRecords           []*types.WorkflowExecutionInfo
+		listErr                 error
+		expectedResult          CheckResult
+	}{
+		{
+			name:        "updated within grace period",
+			lastUpdated: time.Now(),
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   VisibilityRecordMatches,
+				Info:            "determined execution was healthy because it was updated within the grace period",
+			},
+		},
+		{
+			name:    "visibility read failure",
+			listErr: errors.New("visibility read failure"),
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeFailed,
+				InvariantName:   VisibilityRecordMatches,
+				Info:            "failed to get visibility record",
+				InfoDetails:     "visibility read failure",
+			},
+		},
+		{
+			name:        "record is missing",
+			openRecords: []*types.WorkflowExecutionInfo{otherRunRecord},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   VisibilityRecordMatches,
+				Info:            visibilityInfoMissingRecord,
+			},
+		},
+		{
+			name:          "record is closed but execution is open",
+			closedRecords: []*types.WorkflowExecutionInfo{completedRecord},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   VisibilityRecordMatches,
+				Info:            visibilityInfoRecordClosed,
+				InfoDetails:     "RecordCloseStatus: COMPLETED",
+			},
+		},
+		{
+			name:        "record is open but execution is closed",
+			closed:      true,
+			openRecords: []*types.WorkflowExecutionInfo{openRecord},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   VisibilityRecordMatches,
+				Info:            visibilityInfoRecordOpen,
+			},
+		},
+		{
+			name:          "close status does not match",
+			closed:        true,
+			closedRecords: []*types.WorkflowExecutionInfo{failedRecord},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   VisibilityRecordMatches,
+				Info:            visibilityInfoCloseStatusMismatch,
+				InfoDetails:     "CloseStatus: COMPLETED, RecordCloseStatus: FAILED",
+			},
+		},
+		{
+			name:          "closed record matches",
+			closed:        true,
+			closedRecords: []*types.WorkflowExecutionInfo{completedRecord},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   VisibilityRecordMatches,
+			},
+		},
+		{
+			name:                    "search attribute does not match",
+			searchAttributes:        map[string][]byte{"CustomKeywordField": []byte(`"other"`)},
+			searchAttributesIndexed: true,
+			openRecords:             []*types.WorkflowExecutionInfo{openRecord},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   VisibilityRecordMatches,
+				Info:            visibilityInfoSearchAttributeMismatch,
+				InfoDetails:     "SearchAttribute: CustomKeywordField",
+			},
+		},
+		{
+			name:                    "aliased search attribute matches",
+			searchAttributes:        map[string][]byte{"CustomAlias": []byte(`"value"`)},
+			searchAttributesIndexed: true,
+			openRecords:             []*types.WorkflowExecutionInfo{openRecord},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   VisibilityRecordMatches,
+			},
+		},
+		{
+			name:                    "aliased search attribute does not match",
+			searchAttributes:        map[string][]byte{"CustomAlias": []byte(`"other"`)},
+			searchAttributesIndexed: true,
+			openRecords:             []*types.WorkflowExecutionInfo{openRecord},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeCorrupted,
+				InvariantName:   VisibilityRecordMatches,
+				Info:            visibilityInfoSearchAttributeMismatch,
+				InfoDetails:     "SearchAttribute: CustomAlias",
+			},
+		},
+		{
+			name:             "search attributes are ignored if not indexed",
+			searchAttributes: map[string][]byte{"CustomKeywordField": []byte(`"other"`)},
+			openRecords:      []*types.WorkflowExecutionInfo{openRecord},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   VisibilityRecordMatches,
+			},
+		},
+		{
+			name:                    "open record matches",
+			searchAttributes:        map[string][]byte{"CustomKeywordField": []byte(` "value" `)},
+			searchAttributesIndexed: true,
+			openRecords:             []*types.WorkflowExecutionInfo{openRecord},
+			expectedResult: CheckResult{
+				CheckResultType: CheckResultTypeHealthy,
+				InvariantName:   VisibilityRecordMatches,
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	domainCache := cache.NewMockDomainCache(ctrl)
+	domainCache.EXPECT().GetDomainName(gomock.Any()).Return("test-domain-name", nil).AnyTimes()
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			lastUpdated := tc.lastUpdated
+			if lastUpdated.IsZero() {
+				lastUpdated = time.Now().Add(-time.Hour)
+			}
+			state := openState
+			closeStatus := persistence.WorkflowCloseStatusNone
+			if tc.closed {
+				state = closedState
+				closeStatus = persistence.WorkflowCloseStatusCompleted
+			}
+			execManager := &mocks.ExecutionManager{}
+			execManager.On("GetWorkflowExecution", mock.Anything, mock.Anything).Return(getExecutionResponse(&persistence.WorkflowMutableState{
+				ExecutionInfo: &persistence.WorkflowExecutionInfo{
+					DomainID:             domainID,
+					WorkflowID:           workflowID,
+					RunID:                runID,
+					State:                state,
+					CloseStatus:          closeStatus,
+					LastUpdatedTimestamp: lastUpdated,
+					SearchAttributes:     tc.searchAttributes,
+				},
+			}), nil)
+			visibilityManager := &mocks.VisibilityManager{}
+			visibilityManager.On("ListOpenWorkflowExecutionsByWorkflowID", mock.Anything, mock.MatchedBy(func(request *persistence.ListWorkflowExecutionsByWorkflowIDRequest) bool {
+				return request.WorkflowID == workflowID && request.DomainUUID == domainID
+			})).Return(&persistence.ListWorkflowExecutionsResponse{Executions: tc.openRecords}, tc.listErr)
+			visibilityManager.On("ListClosedWorkflowExecutionsByWorkflowID", mock.Anything, mock.Anything).Return(&persistence.ListWorkflowExecutionsResponse{Executions: tc.closedRecords}, nil)
+			i := NewVisibilityRecordMatches(
+				persistence.NewPersistenceRetryer(execManager, &mocks.HistoryV2Manager{}, c2.CreatePersistenceRetryPolicy()),
+				domainCache,
+				visibilityManager,
+				history.NewMockClient(ctrl),
+				func(string) bool { return tc.searchAttributesIndexed },
+				func(...dynamicconfig.FilterOption) map[string]interface{} {
+					return map[string]interface{}{"CustomAlias": "CustomKeywordField"}
+				},
+			)
+			s.Equal(tc.expectedResult, i.Check(context.Background(), getOpenConcreteExecution()))
+		})
+	}
+}
+
+func (s *VisibilityRecordMatchesSuite) TestFix() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	domainCache := cache.NewMockDomainCache(ctrl)
+	domainCache.EXPECT().GetDomainName(gomock.Any()).Return("test-domain-name", nil).AnyTimes()
+	execManager := &mocks.ExecutionManager{}
+	execManager.On("GetWorkflowExecution", mock.Anything, mock.Anything).Return(getExecutionResponse(&persistence.WorkflowMutableState{
+		ExecutionInfo: &persistence.WorkflowExecutionInfo{
+			DomainID:             domainID,
+			WorkflowID:           workflowID,
+			RunID:                runID,
+			State:                openState,
+			LastUpdatedTimestamp: time.Now().Add(-time.Hour),
+		},
+	}), nil)
+	visibilityManager := &mocks.VisibilityManager{}
+	visibilityManager.On("ListOpenWorkflowExecutionsByWorkflowID", mock.Anything, mock.Anything).Return(&persistence.ListWorkflowExecutionsResponse{}, nil)
+	visibilityManager.On("ListClosedWorkflowExecutionsByWorkflowID", mock.Anything, mock.Anything).Return(&persistence.ListWorkflowExecutionsResponse{}, nil)
+	historyClient := history.NewMockClient(ctrl)
+	historyClient.EXPECT().RefreshWorkflowTasks(gomock.Any(), &types.HistoryRefreshWorkflowTasksRequest{
+		DomainUIID: domainID,
+		Request: &types.RefreshWorkflowTasksRequest{
+			Domain:    "test-domain-name",
+			Execution: &types.WorkflowExecution{WorkflowID: workflowID, RunID: runID},
+		},
+	}).Return(nil)
+	i := NewVisibilityRecordMatches(
+		persistence.NewPersistenceRetryer(execManager, &mocks.HistoryV2Manager{}, c2.CreatePersistenceRetryPolicy()),
+		domainCache,
+		visibilityManager,
+		historyClient,
+		func(string) bool { return false },
+		dynamicconfig.GetMapPropertyFn(nil),
+	)
+	s.Equal(FixResult{
+		FixResultType: FixResultTypeFixed,
+		InvariantName: VisibilityRecordMatches,
+		CheckResult: CheckResult{
+			CheckResultType: CheckResultTypeCorrupted,
+			InvariantName:   VisibilityRecordMatches,
+			Info:            visibilityInfoMissingRecord,
+		},
+	}, i.Fix(context.Background(), getOpenConcreteExecution()))
+}
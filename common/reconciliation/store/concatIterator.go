@@ -0,0 +1,58 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package store
+
+type (
+	concatIterator struct {
+		iterators []ScanOutputIterator
+	}
+)
+
+// NewConcatIterator constructs a new iterator which returns the entities of the given iterators one after another.
+func NewConcatIterator(iterators ...ScanOutputIterator) ScanOutputIterator {
+	return &concatIterator{
+		iterators: iterators,
+	}
+}
+
+// Next returns the next ScanOutputEntity
+func (i *concatIterator) Next() (*ScanOutputEntity, error) {
+	i.advance()
+	if len(i.iterators) == 0 {
+		return nil, nil
+	}
+	return i.iterators[0].Next()
+}
+
+// HasNext returns true if there is a next ScanOutputEntity false otherwise
+func (i *concatIterator) HasNext() bool {
+	i.advance()
+	return len(i.iterators) > 0
+}
+
+// advance drops exhausted iterators until the first one has a next entity
+func (i *concatIterator) advance() {
+	for len(i.iterators) > 0 && !i.iterators[0].HasNext() {
+		i.iterators = i.iterators[1:]
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/golang/mock/gomock"
 	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -159,3 +160,29 @@ func getExecutions(count int) []*persistence.ListConcreteExecutionsEntity {
 	}
 	return result
 }
+
+func (s *WriterIteratorSuite) TestConcatIterator() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	first := NewMockScanOutputIterator(ctrl)
+	empty := NewMockScanOutputIterator(ctrl)
+	last := NewMockScanOutputIterator(ctrl)
+	entity1 := &ScanOutputEntity{Execution: &entity.HistoryBranch{TreeID: "1"}}
+	entity2 := &ScanOutputEntity{Execution: &entity.HistoryBranch{TreeID: "2"}}
+	first.EXPECT().HasNext().Return(true).Times(2)
+	first.EXPECT().Next().Return(entity1, nil).Times(1)
+	first.EXPECT().HasNext().Return(false).AnyTimes()
+	empty.EXPECT().HasNext().Return(false).AnyTimes()
+	last.EXPECT().HasNext().Return(true).Times(2)
+	last.EXPECT().Next().Return(entity2, nil).Times(1)
+	last.EXPECT().HasNext().Return(false).AnyTimes()
+
+	itr := NewConcatIterator(first, empty, last)
+	var result []*ScanOutputEntity
+	for itr.HasNext() {
+		soe, err := itr.Next()
+		s.NoError(err)
+		result = append(result, soe)
+	}
+	s.Equal([]*ScanOutputEntity{entity1, entity2}, result)
+}
@@ -69,6 +69,7 @@ type (
 		GetMessagingClient() messaging.Client
 		GetBlobstoreClient() blobstore.Client
 		GetDomainReplicationQueue() domain.ReplicationQueue
+		GetDomainAuditLog() domain.AuditLog
 
 		// membership infos
 		GetMembershipResolver() membership.Resolver
@@ -87,6 +87,7 @@ type (
 		archivalMetadata        archiver.ArchivalMetadata
 		archiverProvider        provider.ArchiverProvider
 		domainReplicationQueue  domain.ReplicationQueue
+		domainAuditLog          domain.AuditLog
 
 		// membership infos
 
@@ -194,6 +195,7 @@ func New(
 		params.MetricsClient,
 		logger,
 	)
+	domainAuditLog := domain.NewAuditLog(persistenceBean.GetDomainAuditQueueManager)
 
 	frontendRawClient := clientBean.GetFrontendClient()
 	frontendClient := frontend.NewRetryableClient(
@@ -262,6 +264,7 @@ func New(
 		archivalMetadata:        params.ArchivalMetadata,
 		archiverProvider:        params.ArchiverProvider,
 		domainReplicationQueue:  domainReplicationQueue,
+		domainAuditLog:          domainAuditLog,
 
 		// membership infos
 		membershipResolver: membershipResolver,
@@ -355,6 +358,7 @@ func (h *Impl) Stop() {
 		h.logger.WithTags(tag.Error(err)).Error("failed to stop dispatcher")
 	}
 	h.runtimeMetricsReporter.Stop()
+	h.archiverProvider.Stop(h.serviceName)
 	h.persistenceBean.Close()
 }
 
@@ -425,6 +429,11 @@ func (h *Impl) GetDomainReplicationQueue() domain.ReplicationQueue {
 	return h.domainReplicationQueue
 }
 
+// GetDomainAuditLog return domain audit log
+func (h *Impl) GetDomainAuditLog() domain.AuditLog {
+	return h.domainAuditLog
+}
+
 // GetMembershipResolver return the membership resolver
 func (h *Impl) GetMembershipResolver() membership.Resolver {
 	return h.membershipResolver
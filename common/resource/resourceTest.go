@@ -63,6 +63,7 @@ type (
 		DomainCache             *cache.MockDomainCache
 		DomainMetricsScopeCache cache.DomainMetricsScopeCache
 		DomainReplicationQueue  *domain.MockReplicationQueue
+		DomainAuditLog          *domain.MockAuditLog
 		TimeSource              clock.TimeSource
 		PayloadSerializer       persistence.PayloadSerializer
 		MetricsClient           metrics.Client
@@ -140,6 +141,8 @@ func NewTest(
 	domainReplicationQueue := domain.NewMockReplicationQueue(controller)
 	domainReplicationQueue.EXPECT().Start().AnyTimes()
 	domainReplicationQueue.EXPECT().Stop().AnyTimes()
+	domainAuditLog := domain.NewMockAuditLog(controller)
+	domainAuditLog.EXPECT().Append(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 	persistenceBean := persistenceClient.NewMockBean(controller)
 	persistenceBean.EXPECT().GetDomainManager().Return(metadataMgr).AnyTimes()
 	persistenceBean.EXPECT().GetTaskManager().Return(taskMgr).AnyTimes()
@@ -161,6 +164,7 @@ func NewTest(
 		DomainCache:             cache.NewMockDomainCache(controller),
 		DomainMetricsScopeCache: cache.NewDomainMetricsScopeCache(),
 		DomainReplicationQueue:  domainReplicationQueue,
+		DomainAuditLog:          domainAuditLog,
 		TimeSource:              clock.NewRealTimeSource(),
 		PayloadSerializer:       persistence.NewPayloadSerializer(),
 		MetricsClient:           metrics.NewClient(scope, serviceMetricsIndex),
@@ -242,6 +246,11 @@ func (s *Test) GetDomainReplicationQueue() domain.ReplicationQueue {
 	return s.DomainReplicationQueue
 }
 
+// GetDomainAuditLog for testing
+func (s *Test) GetDomainAuditLog() domain.AuditLog {
+	return s.DomainAuditLog
+}
+
 // GetTimeSource for testing
 func (s *Test) GetTimeSource() clock.TimeSource {
 	return s.TimeSource
@@ -33,6 +33,8 @@ type (
 		EnableReadVisibilityFromES dynamicconfig.BoolPropertyFnWithDomainFilter
 		// AdvancedVisibilityWritingMode is the write mode of visibility
 		AdvancedVisibilityWritingMode dynamicconfig.StringPropertyFn
+		// AdvancedVisibilityWritingPipeline is how visibility records reach advanced visibility store
+		AdvancedVisibilityWritingPipeline dynamicconfig.StringPropertyFn
 
 		// configs for db visibility
 		EnableDBVisibilitySampling                  dynamicconfig.BoolPropertyFn                `yaml:"-" json:"-"`
@@ -44,7 +46,13 @@ type (
 		// configs for es visibility
 		ESIndexMaxResultWindow dynamicconfig.IntPropertyFn `yaml:"-" json:"-"`
 		ValidSearchAttributes  dynamicconfig.MapPropertyFn `yaml:"-" json:"-"`
+		SearchAttributeAliases dynamicconfig.MapPropertyFn `yaml:"-" json:"-"`
 		// deprecated: never read from, all ES reads and writes erroneously use PersistenceMaxQPS
 		ESVisibilityListMaxQPS dynamicconfig.IntPropertyFnWithDomainFilter `yaml:"-" json:"-"`
+		// configs for writing es visibility directly, used when AdvancedVisibilityWritingPipeline is direct
+		ESProcessorNumOfWorkers  dynamicconfig.IntPropertyFn      `yaml:"-" json:"-"`
+		ESProcessorBulkActions   dynamicconfig.IntPropertyFn      `yaml:"-" json:"-"`
+		ESProcessorBulkSize      dynamicconfig.IntPropertyFn      `yaml:"-" json:"-"`
+		ESProcessorFlushInterval dynamicconfig.DurationPropertyFn `yaml:"-" json:"-"`
 	}
 )
@@ -301,3 +301,52 @@ type ListDynamicConfigRequest struct {
 type ListDynamicConfigResponse struct {
 	Entries []*DynamicConfigEntry `json:"entries,omitempty"`
 }
+
+// ListDomainAuditEventsRequest is an internal type (TBD...)
+type ListDomainAuditEventsRequest struct {
+	Domain        string `json:"domain,omitempty"`
+	PageSize      int32  `json:"pageSize,omitempty"`
+	NextPageToken []byte `json:"nextPageToken,omitempty"`
+}
+
+// GetDomain is an internal getter (TBD...)
+func (v *ListDomainAuditEventsRequest) GetDomain() (o string) {
+	if v != nil {
+		return v.Domain
+	}
+	return
+}
+
+// GetPageSize is an internal getter (TBD...)
+func (v *ListDomainAuditEventsRequest) GetPageSize() (o int32) {
+	if v != nil {
+		return v.PageSize
+	}
+	return
+}
+
+// ListDomainAuditEventsResponse is an internal type (TBD...)
+type ListDomainAuditEventsResponse struct {
+	Events        []*DomainAuditEvent `json:"events,omitempty"`
+	NextPageToken []byte              `json:"nextPageToken,omitempty"`
+}
+
+// DomainAuditEvent is an internal type (TBD...)
+type DomainAuditEvent struct {
+	EventID    int64                `json:"eventID,omitempty"`
+	DomainID   string               `json:"domainID,omitempty"`
+	DomainName string               `json:"domainName,omitempty"`
+	Operation  string               `json:"operation,omitempty"`
+	Actor      string               `json:"actor,omitempty"`
+	Cluster    string               `json:"cluster,omitempty"`
+	Timestamp  int64                `json:"timestamp,omitempty"`
+	Request    string               `json:"request,omitempty"`
+	Changes    []*DomainAuditChange `json:"changes,omitempty"`
+}
+
+// DomainAuditChange is an internal type (TBD...)
+type DomainAuditChange struct {
+	Field  string `json:"field,omitempty"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
@@ -1856,6 +1856,7 @@ func FromDomainConfiguration(t *types.DomainConfiguration) *shared.DomainConfigu
 		HistoryArchivalURI:                     &t.HistoryArchivalURI,
 		VisibilityArchivalStatus:               FromArchivalStatus(t.VisibilityArchivalStatus),
 		VisibilityArchivalURI:                  &t.VisibilityArchivalURI,
+		Limits:                                 FromDomainLimits(t.Limits),
 	}
 }
 
@@ -1872,6 +1873,45 @@ func ToDomainConfiguration(t *shared.DomainConfiguration) *types.DomainConfigura
 		HistoryArchivalURI:                     t.GetHistoryArchivalURI(),
 		VisibilityArchivalStatus:               ToArchivalStatus(t.VisibilityArchivalStatus),
 		VisibilityArchivalURI:                  t.GetVisibilityArchivalURI(),
+		Limits:                                 ToDomainLimits(t.Limits),
+	}
+}
+
+// FromDomainLimits converts internal DomainLimits type to thrift
+func FromDomainLimits(t *types.DomainLimits) *shared.DomainLimits {
+	if t == nil {
+		return nil
+	}
+	return &shared.DomainLimits{
+		UserRPS:                                t.UserRPS,
+		WorkerRPS:                              t.WorkerRPS,
+		VisibilityRPS:                          t.VisibilityRPS,
+		BlobSizeLimit:                          t.BlobSizeLimit,
+		HistorySizeLimit:                       t.HistorySizeLimit,
+		HistoryCountLimit:                      t.HistoryCountLimit,
+		SearchAttributesNumberOfKeysLimit:      t.SearchAttributesNumberOfKeysLimit,
+		SearchAttributesSizeOfValueLimit:       t.SearchAttributesSizeOfValueLimit,
+		SearchAttributesTotalSizeLimit:         t.SearchAttributesTotalSizeLimit,
+		MaxExecutionStartToCloseTimeoutSeconds: t.MaxExecutionStartToCloseTimeoutSeconds,
+	}
+}
+
+// ToDomainLimits converts thrift DomainLimits type to internal
+func ToDomainLimits(t *shared.DomainLimits) *types.DomainLimits {
+	if t == nil {
+		return nil
+	}
+	return &types.DomainLimits{
+		UserRPS:                                t.UserRPS,
+		WorkerRPS:                              t.WorkerRPS,
+		VisibilityRPS:                          t.VisibilityRPS,
+		BlobSizeLimit:                          t.BlobSizeLimit,
+		HistorySizeLimit:                       t.HistorySizeLimit,
+		HistoryCountLimit:                      t.HistoryCountLimit,
+		SearchAttributesNumberOfKeysLimit:      t.SearchAttributesNumberOfKeysLimit,
+		SearchAttributesSizeOfValueLimit:       t.SearchAttributesSizeOfValueLimit,
+		SearchAttributesTotalSizeLimit:         t.SearchAttributesTotalSizeLimit,
+		MaxExecutionStartToCloseTimeoutSeconds: t.MaxExecutionStartToCloseTimeoutSeconds,
 	}
 }
 
@@ -5745,7 +5785,8 @@ func FromUpdateDomainRequest(t *types.UpdateDomainRequest) *shared.UpdateDomainR
 		t.HistoryArchivalStatus != nil ||
 		t.HistoryArchivalURI != nil ||
 		t.VisibilityArchivalStatus != nil ||
-		t.VisibilityArchivalURI != nil {
+		t.VisibilityArchivalURI != nil ||
+		t.Limits != nil {
 		request.Configuration = &shared.DomainConfiguration{
 			WorkflowExecutionRetentionPeriodInDays: t.WorkflowExecutionRetentionPeriodInDays,
 			EmitMetric:                             t.EmitMetric,
@@ -5754,6 +5795,7 @@ func FromUpdateDomainRequest(t *types.UpdateDomainRequest) *shared.UpdateDomainR
 			HistoryArchivalURI:                     t.HistoryArchivalURI,
 			VisibilityArchivalStatus:               FromArchivalStatus(t.VisibilityArchivalStatus),
 			VisibilityArchivalURI:                  t.VisibilityArchivalURI,
+			Limits:                                 FromDomainLimits(t.Limits),
 		}
 	}
 	if t.ActiveClusterName != nil || t.Clusters != nil {
@@ -5789,6 +5831,7 @@ func ToUpdateDomainRequest(t *shared.UpdateDomainRequest) *types.UpdateDomainReq
 		request.HistoryArchivalURI = t.Configuration.HistoryArchivalURI
 		request.VisibilityArchivalStatus = ToArchivalStatus(t.Configuration.VisibilityArchivalStatus)
 		request.VisibilityArchivalURI = t.Configuration.VisibilityArchivalURI
+		request.Limits = ToDomainLimits(t.Configuration.Limits)
 	}
 	if t.ReplicationConfiguration != nil {
 		request.ActiveClusterName = t.ReplicationConfiguration.ActiveClusterName
@@ -207,3 +207,37 @@ func TestCrossClusterApplyParentClosePolicyResponse(t *testing.T) {
 		),
 	)
 }
+
+func TestCountWorkflowExecutionsResponse(t *testing.T) {
+	for _, item := range []*types.CountWorkflowExecutionsResponse{nil, {}, &testdata.CountWorkflowExecutionsResponse} {
+		assert.Equal(t, item, thrift.ToCountWorkflowExecutionsResponse(thrift.FromCountWorkflowExecutionsResponse(item)))
+	}
+}
+
+func TestDomainOperation(t *testing.T) {
+	for _, item := range []*types.DomainOperation{
+		nil,
+		types.DomainOperationCreate.Ptr(),
+		types.DomainOperationUpdate.Ptr(),
+	} {
+		assert.Equal(t, item, thrift.ToDomainOperation(thrift.FromDomainOperation(item)))
+	}
+}
+
+func TestDomainLimits(t *testing.T) {
+	for _, item := range []*types.DomainLimits{nil, {}, &testdata.DomainLimits} {
+		assert.Equal(t, item, thrift.ToDomainLimits(thrift.FromDomainLimits(item)))
+	}
+}
+
+func TestDomainConfiguration(t *testing.T) {
+	for _, item := range []*types.DomainConfiguration{nil, &testdata.DomainConfiguration, &testdata.DomainConfiguration_Limits} {
+		assert.Equal(t, item, thrift.ToDomainConfiguration(thrift.FromDomainConfiguration(item)))
+	}
+}
+
+func TestUpdateDomainRequest(t *testing.T) {
+	for _, item := range []*types.UpdateDomainRequest{nil, {}, &testdata.UpdateDomainRequest, &testdata.UpdateDomainRequest_Limits} {
+		assert.Equal(t, item, thrift.ToUpdateDomainRequest(thrift.FromUpdateDomainRequest(item)))
+	}
+}
@@ -1455,6 +1455,20 @@ func (v *DeprecateDomainRequest) GetName() (o string) {
 	return
 }
 
+// DeleteDomainRequest is an internal type (TBD...)
+type DeleteDomainRequest struct {
+	Name          string `json:"name,omitempty"`
+	SecurityToken string `json:"securityToken,omitempty"`
+}
+
+// GetName is an internal getter (TBD...)
+func (v *DeleteDomainRequest) GetName() (o string) {
+	if v != nil {
+		return v.Name
+	}
+	return
+}
+
 // DescribeDomainRequest is an internal type (TBD...)
 type DescribeDomainRequest struct {
 	Name *string `json:"name,omitempty"`
@@ -1728,6 +1742,7 @@ type DomainConfiguration struct {
 	HistoryArchivalURI                     string          `json:"historyArchivalURI,omitempty"`
 	VisibilityArchivalStatus               *ArchivalStatus `json:"visibilityArchivalStatus,omitempty"`
 	VisibilityArchivalURI                  string          `json:"visibilityArchivalURI,omitempty"`
+	Limits                                 *DomainLimits   `json:"limits,omitempty"`
 }
 
 // GetWorkflowExecutionRetentionPeriodInDays is an internal getter (TBD...)
@@ -1786,6 +1801,109 @@ func (v *DomainConfiguration) GetVisibilityArchivalURI() (o string) {
 	return
 }
 
+// GetLimits is an internal getter (TBD...)
+func (v *DomainConfiguration) GetLimits() (o *DomainLimits) {
+	if v != nil && v.Limits != nil {
+		return v.Limits
+	}
+	return
+}
+
+// DomainLimits overrides the dynamic config quotas and limits for a single domain.
+// A nil or non-positive field means the dynamic config value applies.
+type DomainLimits struct {
+	UserRPS                                *int32 `json:"userRPS,omitempty"`
+	WorkerRPS                              *int32 `json:"workerRPS,omitempty"`
+	VisibilityRPS                          *int32 `json:"visibilityRPS,omitempty"`
+	BlobSizeLimit                          *int64 `json:"blobSizeLimit,omitempty"`
+	HistorySizeLimit                       *int64 `json:"historySizeLimit,omitempty"`
+	HistoryCountLimit                      *int64 `json:"historyCountLimit,omitempty"`
+	SearchAttributesNumberOfKeysLimit      *int32 `json:"searchAttributesNumberOfKeysLimit,omitempty"`
+	SearchAttributesSizeOfValueLimit       *int64 `json:"searchAttributesSizeOfValueLimit,omitempty"`
+	SearchAttributesTotalSizeLimit         *int64 `json:"searchAttributesTotalSizeLimit,omitempty"`
+	MaxExecutionStartToCloseTimeoutSeconds *int32 `json:"maxExecutionStartToCloseTimeoutSeconds,omitempty"`
+}
+
+// GetUserRPS is an internal getter (TBD...)
+func (v *DomainLimits) GetUserRPS() (o int32) {
+	if v != nil && v.UserRPS != nil {
+		return *v.UserRPS
+	}
+	return
+}
+
+// GetWorkerRPS is an internal getter (TBD...)
+func (v *DomainLimits) GetWorkerRPS() (o int32) {
+	if v != nil && v.WorkerRPS != nil {
+		return *v.WorkerRPS
+	}
+	return
+}
+
+// GetVisibilityRPS is an internal getter (TBD...)
+func (v *DomainLimits) GetVisibilityRPS() (o int32) {
+	if v != nil && v.VisibilityRPS != nil {
+		return *v.VisibilityRPS
+	}
+	return
+}
+
+// GetBlobSizeLimit is an internal getter (TBD...)
+func (v *DomainLimits) GetBlobSizeLimit() (o int64) {
+	if v != nil && v.BlobSizeLimit != nil {
+		return *v.BlobSizeLimit
+	}
+	return
+}
+
+// GetHistorySizeLimit is an internal getter (TBD...)
+func (v *DomainLimits) GetHistorySizeLimit() (o int64) {
+	if v != nil && v.HistorySizeLimit != nil {
+		return *v.HistorySizeLimit
+	}
+	return
+}
+
+// GetHistoryCountLimit is an internal getter (TBD...)
+func (v *DomainLimits) GetHistoryCountLimit() (o int64) {
+	if v != nil && v.HistoryCountLimit != nil {
+		return *v.HistoryCountLimit
+	}
+	return
+}
+
+// GetSearchAttributesNumberOfKeysLimit is an internal getter (TBD...)
+func (v *DomainLimits) GetSearchAttributesNumberOfKeysLimit() (o int32) {
+	if v != nil && v.SearchAttributesNumberOfKeysLimit != nil {
+		return *v.SearchAttributesNumberOfKeysLimit
+	}
+	return
+}
+
+// GetSearchAttributesSizeOfValueLimit is an internal getter (TBD...)
+func (v *DomainLimits) GetSearchAttributesSizeOfValueLimit() (o int64) {
+	if v != nil && v.SearchAttributesSizeOfValueLimit != nil {
+		return *v.SearchAttributesSizeOfValueLimit
+	}
+	return
+}
+
+// GetSearchAttributesTotalSizeLimit is an internal getter (TBD...)
+func (v *DomainLimits) GetSearchAttributesTotalSizeLimit() (o int64) {
+	if v != nil && v.SearchAttributesTotalSizeLimit != nil {
+		return *v.SearchAttributesTotalSizeLimit
+	}
+	return
+}
+
+// GetMaxExecutionStartToCloseTimeoutSeconds is an internal getter (TBD...)
+func (v *DomainLimits) GetMaxExecutionStartToCloseTimeoutSeconds() (o int32) {
+	if v != nil && v.MaxExecutionStartToCloseTimeoutSeconds != nil {
+		return *v.MaxExecutionStartToCloseTimeoutSeconds
+	}
+	return
+}
+
 // DomainInfo is an internal type (TBD...)
 type DomainInfo struct {
 	Name        string            `json:"name,omitempty"`
@@ -6492,6 +6610,7 @@ type UpdateDomainRequest struct {
 	SecurityToken                          string                             `json:"securityToken,omitempty"`
 	DeleteBadBinary                        *string                            `json:"deleteBadBinary,omitempty"`
 	FailoverTimeoutInSeconds               *int32                             `json:"failoverTimeoutInSeconds,omitempty"`
+	Limits                                 *DomainLimits                      `json:"limits,omitempty"`
 }
 
 // GetName is an internal getter (TBD...)
@@ -6510,6 +6629,14 @@ func (v *UpdateDomainRequest) GetFailoverTimeoutInSeconds() (o int32) {
 	return
 }
 
+// GetLimits is an internal getter (TBD...)
+func (v *UpdateDomainRequest) GetLimits() (o *DomainLimits) {
+	if v != nil && v.Limits != nil {
+		return v.Limits
+	}
+	return
+}
+
 // GetHistoryArchivalURI is an internal getter (TBD...)
 func (v *UpdateDomainRequest) GetHistoryArchivalURI() (o string) {
 	if v != nil && v.HistoryArchivalURI != nil {
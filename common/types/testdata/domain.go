@@ -21,6 +21,7 @@
 package testdata
 
 import (
+	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/types"
 )
 
@@ -79,6 +80,27 @@ var (
 		VisibilityArchivalStatus:               &ArchivalStatus,
 		VisibilityArchivalURI:                  VisibilityArchivalURI,
 	}
+	DomainLimits = types.DomainLimits{
+		UserRPS:                                common.Int32Ptr(100),
+		WorkerRPS:                              common.Int32Ptr(200),
+		VisibilityRPS:                          common.Int32Ptr(10),
+		BlobSizeLimit:                          common.Int64Ptr(1024),
+		HistorySizeLimit:                       common.Int64Ptr(1024 * 1024),
+		HistoryCountLimit:                      common.Int64Ptr(10000),
+		SearchAttributesNumberOfKeysLimit:      common.Int32Ptr(20),
+		SearchAttributesSizeOfValueLimit:       common.Int64Ptr(256),
+		SearchAttributesTotalSizeLimit:         common.Int64Ptr(4096),
+		MaxExecutionStartToCloseTimeoutSeconds: common.Int32Ptr(3600),
+	}
+	DomainConfiguration_Limits = types.DomainConfiguration{
+		WorkflowExecutionRetentionPeriodInDays: DomainRetention,
+		EmitMetric:                             DomainEmitMetric,
+		HistoryArchivalStatus:                  &ArchivalStatus,
+		HistoryArchivalURI:                     HistoryArchivalURI,
+		VisibilityArchivalStatus:               &ArchivalStatus,
+		VisibilityArchivalURI:                  VisibilityArchivalURI,
+		Limits:                                 &DomainLimits,
+	}
 	DomainReplicationConfiguration = types.DomainReplicationConfiguration{
 		ActiveClusterName: ClusterName1,
 		Clusters:          ClusterReplicationConfigurationArray,
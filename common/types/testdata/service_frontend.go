@@ -80,6 +80,11 @@ var (
 		DeleteBadBinary:                        common.StringPtr(DeleteBadBinary),
 		FailoverTimeoutInSeconds:               &Duration1,
 	}
+	UpdateDomainRequest_Limits = types.UpdateDomainRequest{
+		Name:          DomainName,
+		SecurityToken: SecurityToken,
+		Limits:        &DomainLimits,
+	}
 	UpdateDomainResponse = types.UpdateDomainResponse{
 		DomainInfo:               &DomainInfo,
 		Configuration:            &DomainConfiguration,
@@ -86,7 +86,7 @@ func TestElasticsearchIntegrationSuite(t *testing.T) {
 func (s *ElasticSearchIntegrationSuite) SetupSuite() {
 	s.setupSuite()
 	s.esClient = esutils.CreateESClient(s.Suite, s.testClusterConfig.ESConfig.URL.String(), environment.GetESVersion())
-	s.esClient.PutIndexTemplate(s.Suite, "testdata/es_"+environment.GetESVersion()+"_index_template.json", "test-visibility-template")
+	s.esClient.PutIndexTemplate(s.Suite, "testdata/es_"+esutils.GetIndexTemplateVersion(environment.GetESVersion())+"_index_template.json", "test-visibility-template")
 	indexName := s.testClusterConfig.ESConfig.Indices[common.VisibilityAppName]
 	s.esClient.CreateIndex(s.Suite, indexName)
 	s.putIndexSettings(indexName, defaultTestValueOfESIndexMaxResultWindow)
@@ -35,11 +35,12 @@ type (
 	}
 )
 
-func newV7Client(url string) (*v7Client, error) {
-	esClient, err := elastic.NewClient(
+func newV7Client(url string, clientOptFuncs ...elastic.ClientOptionFunc) (*v7Client, error) {
+	clientOptFuncs = append(clientOptFuncs,
 		elastic.SetURL(url),
 		elastic.SetRetrier(elastic.NewBackoffRetrier(elastic.NewExponentialBackoff(128*time.Millisecond, 513*time.Millisecond))),
 	)
+	esClient, err := elastic.NewClient(clientOptFuncs...)
 	return &v7Client{
 		client: esClient,
 	}, err
@@ -22,9 +22,13 @@ package esutils
 
 import (
 	"context"
+	"net/http"
 	"time"
 
+	"github.com/olivere/elastic/v7"
 	"github.com/stretchr/testify/suite"
+
+	es "github.com/uber/cadence/common/elasticsearch"
 )
 
 type (
@@ -46,8 +50,12 @@ func CreateESClient(s suite.Suite, url string, version string) ESClient {
 	switch version {
 	case "v6":
 		client, err = newV6Client(url)
-	case "v7":
+	case "v7", "opensearch":
 		client, err = newV7Client(url)
+	case "v8":
+		client, err = newV7Client(url, elastic.SetHttpClient(&http.Client{
+			Transport: es.NewV7CompatibleTransport(http.DefaultTransport),
+		}))
 	default:
 		s.Fail("not supported ES version")
 	}
@@ -55,6 +63,17 @@ func CreateESClient(s suite.Suite, url string, version string) ESClient {
 	return client
 }
 
+// GetIndexTemplateVersion returns the version of the index template for given ES version,
+// ElasticSearch 8 and OpenSearch use the v7 index template
+func GetIndexTemplateVersion(version string) string {
+	switch version {
+	case "v8", "opensearch":
+		return "v7"
+	default:
+		return version
+	}
+}
+
 func createContext() context.Context {
 	ctx, _ := context.WithTimeout(context.Background(), 90*time.Second)
 	return ctx
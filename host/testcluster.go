@@ -43,6 +43,7 @@ import (
 	"github.com/uber/cadence/common/log/tag"
 	"github.com/uber/cadence/common/messaging"
 	"github.com/uber/cadence/common/messaging/kafka"
+	messagingPersistence "github.com/uber/cadence/common/messaging/persistence"
 	"github.com/uber/cadence/common/metrics"
 	"github.com/uber/cadence/common/mocks"
 	"github.com/uber/cadence/common/persistence"
@@ -95,6 +96,8 @@ type (
 	MessagingClientConfig struct {
 		UseMock     bool
 		KafkaConfig *config.KafkaConfig
+		// PersistenceConfig uses the persistence queue instead of kafka when it is set
+		PersistenceConfig *config.PersistenceMessaging
 	}
 
 	// WorkerConfig is the config for enabling/disabling cadence worker
@@ -116,7 +119,7 @@ func NewCluster(options *TestClusterConfig, logger log.Logger, params persistenc
 	testBase.Setup()
 	setupShards(testBase, options.HistoryConfig.NumHistoryShards, logger)
 	archiverBase := newArchiverBase(options.EnableArchival, logger)
-	messagingClient := getMessagingClient(options.MessagingClientConfig, logger)
+	messagingClient := getMessagingClient(options.MessagingClientConfig, &testBase, logger)
 	pConfig := testBase.Config()
 	pConfig.NumHistoryShards = options.HistoryConfig.NumHistoryShards
 	var esClient elasticsearch.GenericClient
@@ -282,12 +285,17 @@ func newArchiverBase(enabled bool, logger log.Logger) *ArchiverBase {
 	}
 }
 
-func getMessagingClient(config *MessagingClientConfig, logger log.Logger) messaging.Client {
-	if config == nil || config.UseMock {
+func getMessagingClient(clientConfig *MessagingClientConfig, testBase *persistencetests.TestBase, logger log.Logger) messaging.Client {
+	if clientConfig == nil || clientConfig.UseMock {
 		return mocks.NewMockMessagingClient(&mocks.KafkaProducer{}, nil)
 	}
-	checkApp := len(config.KafkaConfig.Applications) != 0
-	return kafka.NewKafkaClient(config.KafkaConfig, metrics.NewNoopMetricsClient(), logger, tally.NoopScope, checkApp)
+	if clientConfig.PersistenceConfig != nil {
+		messagingConfig := &config.Messaging{Type: config.MessagingTypePersistence, Persistence: *clientConfig.PersistenceConfig}
+		messagingConfig.FillDefaults()
+		return messagingPersistence.NewPersistenceClient(&messagingConfig.Persistence, testBase.ExecutionMgrFactory.NewMessagingQueueManager, nil, metrics.NewNoopMetricsClient(), logger)
+	}
+	checkApp := len(clientConfig.KafkaConfig.Applications) != 0
+	return kafka.NewKafkaClient(clientConfig.KafkaConfig, metrics.NewNoopMetricsClient(), logger, tally.NoopScope, checkApp)
 }
 
 // TearDownCluster tears down the test cluster
@@ -470,6 +470,23 @@ func (a *AccessControlledWorkflowAdminHandler) ListDynamicConfig(ctx context.Con
 	return a.AdminHandler.ListDynamicConfig(ctx, request)
 }
 
+func (a *AccessControlledWorkflowAdminHandler) ListDomainAuditEvents(ctx context.Context, request *types.ListDomainAuditEventsRequest) (*types.ListDomainAuditEventsResponse, error) {
+	attr := &authorization.Attributes{
+		APIName:    "ListDomainAuditEvents",
+		DomainName: request.GetDomain(),
+		Permission: authorization.PermissionAdmin,
+	}
+	isAuthorized, err := a.isAuthorized(ctx, attr)
+	if err != nil {
+		return nil, err
+	}
+	if !isAuthorized {
+		return nil, errUnauthorized
+	}
+
+	return a.AdminHandler.ListDomainAuditEvents(ctx, request)
+}
+
 func (a *AccessControlledWorkflowAdminHandler) isAuthorized(
 	ctx context.Context,
 	attr *authorization.Attributes,
@@ -109,7 +109,7 @@ func (a *AccessControlledWorkflowHandler) DeprecateDomain(
 		return errUnauthorized
 	}
 
-	return a.frontendHandler.DeprecateDomain(ctx, request)
+	return a.frontendHandler.DeprecateDomain(authorization.NewContextWithAttributes(ctx, attr), request)
 }
 
 // DescribeDomain API call
@@ -452,7 +452,7 @@ func (a *AccessControlledWorkflowHandler) RegisterDomain(
 		return errUnauthorized
 	}
 
-	return a.frontendHandler.RegisterDomain(ctx, request)
+	return a.frontendHandler.RegisterDomain(authorization.NewContextWithAttributes(ctx, attr), request)
 }
 
 // RequestCancelWorkflowExecution API call
@@ -830,7 +830,7 @@ func (a *AccessControlledWorkflowHandler) UpdateDomain(
 		return nil, errUnauthorized
 	}
 
-	return a.frontendHandler.UpdateDomain(ctx, request)
+	return a.frontendHandler.UpdateDomain(authorization.NewContextWithAttributes(ctx, attr), request)
 }
 
 func (a *AccessControlledWorkflowHandler) isAuthorized(
@@ -96,6 +96,7 @@ type (
 		ListDynamicConfig(context.Context, *types.ListDynamicConfigRequest) (*types.ListDynamicConfigResponse, error)
 		DeleteWorkflow(context.Context, *types.AdminDeleteWorkflowRequest) (*types.AdminDeleteWorkflowResponse, error)
 		MaintainCorruptWorkflow(context.Context, *types.AdminMaintainWorkflowRequest) (*types.AdminMaintainWorkflowResponse, error)
+		ListDomainAuditEvents(context.Context, *types.ListDomainAuditEventsRequest) (*types.ListDomainAuditEventsResponse, error)
 	}
 
 	// adminHandlerImpl is an implementation for admin service independent of wire protocol
@@ -226,10 +227,16 @@ func (adh *adminHandlerImpl) AddSearchAttribute(
 		return adh.error(&types.InternalServiceError{Message: fmt.Sprintf("Failed to get dynamic config, err: %v", err)}, scope)
 	}
 
+	// aliases are usually not configured, in which case the default value is returned with a not found error
+	aliases, _ := adh.params.DynamicConfig.GetMapValue(dc.SearchAttributeAliases, nil)
+
 	for keyName, valueType := range searchAttr {
 		if definition.IsSystemIndexedKey(keyName) {
 			return adh.error(&types.BadRequestError{Message: fmt.Sprintf("Key [%s] is reserved by system", keyName)}, scope)
 		}
+		if _, isAlias := aliases[keyName]; isAlias {
+			return adh.error(&types.BadRequestError{Message: fmt.Sprintf("Key [%s] is an alias of another search attribute", keyName)}, scope)
+		}
 		if currValType, exist := currentValidAttr[keyName]; exist {
 			if currValType != int(valueType) {
 				return adh.error(&types.BadRequestError{Message: fmt.Sprintf("Key [%s] is already whitelisted as a different type", keyName)}, scope)
@@ -1724,6 +1731,29 @@ func (adh *adminHandlerImpl) ListDynamicConfig(ctx context.Context, request *typ
 	}, nil
 }
 
+// ListDomainAuditEvents returns the recorded changes of a domain, oldest first
+func (adh *adminHandlerImpl) ListDomainAuditEvents(
+	ctx context.Context,
+	request *types.ListDomainAuditEventsRequest,
+) (_ *types.ListDomainAuditEventsResponse, retError error) {
+	defer func() { log.CapturePanic(recover(), adh.GetLogger(), &retError) }()
+	scope, sw := adh.startRequestProfile(ctx, metrics.AdminListDomainAuditEventsScope)
+	defer sw.Stop()
+
+	if request == nil {
+		return nil, adh.error(errRequestNotSet, scope)
+	}
+	if request.GetDomain() == "" {
+		return nil, adh.error(errDomainNotSet, scope)
+	}
+
+	response, err := adh.GetDomainAuditLog().List(ctx, request)
+	if err != nil {
+		return nil, adh.error(err, scope)
+	}
+	return response, nil
+}
+
 func convertFromDataBlob(blob *types.DataBlob) (interface{}, error) {
 	switch *blob.EncodingType {
 	case types.EncodingTypeJSON:
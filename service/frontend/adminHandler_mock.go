@@ -281,6 +281,21 @@ func (mr *MockAdminHandlerMockRecorder) GetWorkflowExecutionRawHistoryV2(arg0, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWorkflowExecutionRawHistoryV2", reflect.TypeOf((*MockAdminHandler)(nil).GetWorkflowExecutionRawHistoryV2), arg0, arg1)
 }
 
+// ListDomainAuditEvents mocks base method.
+func (m *MockAdminHandler) ListDomainAuditEvents(arg0 context.Context, arg1 *types.ListDomainAuditEventsRequest) (*types.ListDomainAuditEventsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDomainAuditEvents", arg0, arg1)
+	ret0, _ := ret[0].(*types.ListDomainAuditEventsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDomainAuditEvents indicates an expected call of ListDomainAuditEvents.
+func (mr *MockAdminHandlerMockRecorder) ListDomainAuditEvents(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDomainAuditEvents", reflect.TypeOf((*MockAdminHandler)(nil).ListDomainAuditEvents), arg0, arg1)
+}
+
 // ListDynamicConfig mocks base method.
 func (m *MockAdminHandler) ListDynamicConfig(arg0 context.Context, arg1 *types.ListDynamicConfigRequest) (*types.ListDynamicConfigResponse, error) {
 	m.ctrl.T.Helper()
@@ -569,6 +569,8 @@ func (s *adminHandlerSuite) Test_AddSearchAttribute_Validate() {
 	}
 	dynamicConfig.EXPECT().GetMapValue(dynamicconfig.ValidSearchAttributes, nil).
 		Return(mockValidAttr, nil).AnyTimes()
+	dynamicConfig.EXPECT().GetMapValue(dynamicconfig.SearchAttributeAliases, nil).
+		Return(map[string]interface{}{"testalias": "testkey"}, nil).AnyTimes()
 
 	testCases2 := []test{
 		{
@@ -589,6 +591,15 @@ func (s *adminHandlerSuite) Test_AddSearchAttribute_Validate() {
 			},
 			Expected: &types.BadRequestError{Message: "Key [testkey] is already whitelisted as a different type"},
 		},
+		{
+			Name: "key is alias",
+			Request: &types.AddSearchAttributeRequest{
+				SearchAttribute: map[string]types.IndexedValueType{
+					"testalias": 1,
+				},
+			},
+			Expected: &types.BadRequestError{Message: "Key [testalias] is an alias of another search attribute"},
+		},
 	}
 	for _, testCase := range testCases2 {
 		s.Equal(testCase.Expected, handler.AddSearchAttribute(ctx, testCase.Request))
@@ -764,3 +775,20 @@ func (s *adminHandlerSuite) Test_GetDynamicConfig_FilterMatch() {
 	s.NoError(err)
 	s.Equal(resp.Value.Data, encTrue)
 }
+
+func (s *adminHandlerSuite) Test_ListDomainAuditEvents() {
+	ctx := context.Background()
+	_, err := s.handler.ListDomainAuditEvents(ctx, nil)
+	s.Equal(errRequestNotSet, err)
+	_, err = s.handler.ListDomainAuditEvents(ctx, &types.ListDomainAuditEventsRequest{})
+	s.Equal(errDomainNotSet, err)
+
+	request := &types.ListDomainAuditEventsRequest{Domain: s.domainName}
+	response := &types.ListDomainAuditEventsResponse{
+		Events: []*types.DomainAuditEvent{{DomainName: s.domainName, Operation: "UpdateDomain"}},
+	}
+	s.mockResource.DomainAuditLog.EXPECT().List(ctx, request).Return(response, nil)
+	resp, err := s.handler.ListDomainAuditEvents(ctx, request)
+	s.NoError(err)
+	s.Equal(response, resp)
+}
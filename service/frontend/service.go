@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/cache"
 	"github.com/uber/cadence/common/client"
 	"github.com/uber/cadence/common/domain"
 	"github.com/uber/cadence/common/dynamicconfig"
@@ -87,6 +88,9 @@ type Config struct {
 	BlobSizeLimitError dynamicconfig.IntPropertyFnWithDomainFilter
 	BlobSizeLimitWarn  dynamicconfig.IntPropertyFnWithDomainFilter
 
+	// max workflow execution timeout in seconds, 0 means no limit
+	MaxExecutionStartToCloseTimeoutSeconds dynamicconfig.IntPropertyFnWithDomainFilter
+
 	ThrottledLogRPS dynamicconfig.IntPropertyFn
 
 	// Domain specific config
@@ -97,6 +101,9 @@ type Config struct {
 
 	// ValidSearchAttributes is legal indexed keys that can be used in list APIs
 	ValidSearchAttributes             dynamicconfig.MapPropertyFn
+	SearchAttributeAliases            dynamicconfig.MapPropertyFn
+	DeprecatedSearchAttributes        dynamicconfig.MapPropertyFn
+	DomainScopedSearchAttributes      dynamicconfig.MapPropertyFn
 	SearchAttributesNumberOfKeysLimit dynamicconfig.IntPropertyFnWithDomainFilter
 	SearchAttributesSizeOfValueLimit  dynamicconfig.IntPropertyFnWithDomainFilter
 	SearchAttributesTotalSizeLimit    dynamicconfig.IntPropertyFnWithDomainFilter
@@ -153,6 +160,7 @@ func NewConfig(dc *dynamicconfig.Collection, numHistoryShards int, isAdvancedVis
 		DisableListVisibilityByFilter:               dc.GetBoolPropertyFilteredByDomain(dynamicconfig.DisableListVisibilityByFilter),
 		BlobSizeLimitError:                          dc.GetIntPropertyFilteredByDomain(dynamicconfig.BlobSizeLimitError),
 		BlobSizeLimitWarn:                           dc.GetIntPropertyFilteredByDomain(dynamicconfig.BlobSizeLimitWarn),
+		MaxExecutionStartToCloseTimeoutSeconds:      dc.GetIntPropertyFilteredByDomain(dynamicconfig.FrontendMaxExecutionStartToCloseTimeoutSeconds),
 		ThrottledLogRPS:                             dc.GetIntProperty(dynamicconfig.FrontendThrottledLogRPS),
 		ShutdownDrainDuration:                       dc.GetDurationProperty(dynamicconfig.FrontendShutdownDrainDuration),
 		EnableDomainNotActiveAutoForwarding:         dc.GetBoolPropertyFilteredByDomain(dynamicconfig.EnableDomainNotActiveAutoForwarding),
@@ -161,6 +169,9 @@ func NewConfig(dc *dynamicconfig.Collection, numHistoryShards int, isAdvancedVis
 		DomainFailoverRefreshTimerJitterCoefficient: dc.GetFloat64Property(dynamicconfig.DomainFailoverRefreshTimerJitterCoefficient),
 		EnableClientVersionCheck:                    dc.GetBoolProperty(dynamicconfig.EnableClientVersionCheck),
 		ValidSearchAttributes:                       dc.GetMapProperty(dynamicconfig.ValidSearchAttributes),
+		SearchAttributeAliases:                      dc.GetMapProperty(dynamicconfig.SearchAttributeAliases),
+		DeprecatedSearchAttributes:                  dc.GetMapProperty(dynamicconfig.DeprecatedSearchAttributes),
+		DomainScopedSearchAttributes:                dc.GetMapProperty(dynamicconfig.DomainScopedSearchAttributes),
 		SearchAttributesNumberOfKeysLimit:           dc.GetIntPropertyFilteredByDomain(dynamicconfig.SearchAttributesNumberOfKeysLimit),
 		SearchAttributesSizeOfValueLimit:            dc.GetIntPropertyFilteredByDomain(dynamicconfig.SearchAttributesSizeOfValueLimit),
 		SearchAttributesTotalSizeLimit:              dc.GetIntPropertyFilteredByDomain(dynamicconfig.SearchAttributesTotalSizeLimit),
@@ -240,11 +251,13 @@ func NewService(
 			ESVisibilityListMaxQPS: serviceConfig.ESVisibilityListMaxQPS,
 			ESIndexMaxResultWindow: serviceConfig.ESIndexMaxResultWindow,
 			ValidSearchAttributes:  serviceConfig.ValidSearchAttributes,
+			SearchAttributeAliases: serviceConfig.SearchAttributeAliases,
 		},
 	)
 	if err != nil {
 		return nil, err
 	}
+	serviceConfig.applyDomainLimits(serviceResource.GetDomainCache())
 
 	return &Service{
 		Resource: serviceResource,
@@ -255,6 +268,22 @@ func NewService(
 	}, nil
 }
 
+// applyDomainLimits makes the per domain quotas and limits prefer the values
+// stored in the domain config over the dynamic config ones
+func (c *Config) applyDomainLimits(domainCache cache.DomainCache) {
+	c.GlobalDomainUserRPS = cache.NewDomainLimitFn(domainCache, cache.DomainUserRPSLimit, c.GlobalDomainUserRPS)
+	c.MaxDomainUserRPSPerInstance = cache.NewDomainLimitFn(domainCache, cache.DomainUserRPSLimit, c.MaxDomainUserRPSPerInstance)
+	c.GlobalDomainWorkerRPS = cache.NewDomainLimitFn(domainCache, cache.DomainWorkerRPSLimit, c.GlobalDomainWorkerRPS)
+	c.MaxDomainWorkerRPSPerInstance = cache.NewDomainLimitFn(domainCache, cache.DomainWorkerRPSLimit, c.MaxDomainWorkerRPSPerInstance)
+	c.GlobalDomainVisibilityRPS = cache.NewDomainLimitFn(domainCache, cache.DomainVisibilityRPSLimit, c.GlobalDomainVisibilityRPS)
+	c.MaxDomainVisibilityRPSPerInstance = cache.NewDomainLimitFn(domainCache, cache.DomainVisibilityRPSLimit, c.MaxDomainVisibilityRPSPerInstance)
+	c.BlobSizeLimitError = cache.NewDomainLimitFn(domainCache, cache.DomainBlobSizeLimit, c.BlobSizeLimitError)
+	c.SearchAttributesNumberOfKeysLimit = cache.NewDomainLimitFn(domainCache, cache.DomainSearchAttributesNumberOfKeysLimit, c.SearchAttributesNumberOfKeysLimit)
+	c.SearchAttributesSizeOfValueLimit = cache.NewDomainLimitFn(domainCache, cache.DomainSearchAttributesSizeOfValueLimit, c.SearchAttributesSizeOfValueLimit)
+	c.SearchAttributesTotalSizeLimit = cache.NewDomainLimitFn(domainCache, cache.DomainSearchAttributesTotalSizeLimit, c.SearchAttributesTotalSizeLimit)
+	c.MaxExecutionStartToCloseTimeoutSeconds = cache.NewDomainLimitFn(domainCache, cache.DomainMaxExecutionStartToCloseTimeoutLimit, c.MaxExecutionStartToCloseTimeoutSeconds)
+}
+
 // Start starts the service
 func (s *Service) Start() {
 	if !atomic.CompareAndSwapInt32(&s.status, common.DaemonStatusInitialized, common.DaemonStatusStarted) {
@@ -38,6 +38,7 @@ import (
 	"github.com/uber/cadence/common/backoff"
 	"github.com/uber/cadence/common/cache"
 	"github.com/uber/cadence/common/client"
+	"github.com/uber/cadence/common/definition"
 	"github.com/uber/cadence/common/domain"
 	"github.com/uber/cadence/common/elasticsearch/validator"
 	"github.com/uber/cadence/common/log"
@@ -145,6 +146,8 @@ var (
 	errEmptyReplicationInfo                       = &types.BadRequestError{Message: "Replication task info is not set."}
 	errEmptyQueueType                             = &types.BadRequestError{Message: "Queue type is not set."}
 	errDomainInLockdown                           = &types.BadRequestError{Message: "Domain is not accepting fail overs at this time due to lockdown."}
+	errDomainLimitsNotAllowed                     = &types.BadRequestError{Message: "Domain limits can only be updated by cluster operators through the admin domain update command."}
+	errCountGroupByNotSupported                   = &types.BadRequestError{Message: "GROUP BY is not supported in count queries yet."}
 	errShuttingDown                               = &types.InternalServiceError{Message: "Shutting down"}
 
 	// err for archival
@@ -216,12 +219,16 @@ func NewWorkflowHandler(
 			domain.NewDomainReplicator(replicationMessageSink, resource.GetLogger()),
 			resource.GetArchivalMetadata(),
 			resource.GetArchiverProvider(),
+			resource.GetDomainAuditLog(),
 			resource.GetTimeSource(),
 		),
-		visibilityQueryValidator: validator.NewQueryValidator(config.ValidSearchAttributes),
+		visibilityQueryValidator: validator.NewQueryValidator(config.ValidSearchAttributes, config.SearchAttributeAliases),
 		searchAttributesValidator: validator.NewSearchAttributesValidator(
 			resource.GetLogger(),
 			config.ValidSearchAttributes,
+			config.SearchAttributeAliases,
+			config.DeprecatedSearchAttributes,
+			config.DomainScopedSearchAttributes,
 			config.SearchAttributesNumberOfKeysLimit,
 			config.SearchAttributesSizeOfValueLimit,
 			config.SearchAttributesTotalSizeLimit,
@@ -430,6 +437,12 @@ func (wh *WorkflowHandler) UpdateDomain(
 		return nil, errRequestNotSet
 	}
 
+	if updateRequest.Limits != nil {
+		logger.Error("Domain limits can't be updated through the frontend.",
+			tag.Error(errDomainLimitsNotAllowed))
+		return nil, errDomainLimitsNotAllowed
+	}
+
 	isFailover := isFailoverRequest(updateRequest)
 	isGraceFailover := isGraceFailoverRequest(updateRequest)
 	logger.Info(fmt.Sprintf(
@@ -2099,6 +2112,10 @@ func (wh *WorkflowHandler) StartWorkflowExecution(
 		return nil, wh.error(errInvalidExecutionStartToCloseTimeoutSeconds, scope, tags...)
 	}
 
+	if err := wh.validateMaxExecutionTimeout(startRequest.GetExecutionStartToCloseTimeoutSeconds(), domainName); err != nil {
+		return nil, wh.error(err, scope, tags...)
+	}
+
 	if startRequest.GetTaskStartToCloseTimeoutSeconds() <= 0 {
 		return nil, wh.error(errInvalidTaskStartToCloseTimeoutSeconds, scope, tags...)
 	}
@@ -2689,6 +2706,10 @@ func (wh *WorkflowHandler) SignalWithStartWorkflowExecution(
 		return nil, wh.error(errInvalidExecutionStartToCloseTimeoutSeconds, scope, tags...)
 	}
 
+	if err := wh.validateMaxExecutionTimeout(signalWithStartRequest.GetExecutionStartToCloseTimeoutSeconds(), domainName); err != nil {
+		return nil, wh.error(err, scope, tags...)
+	}
+
 	if signalWithStartRequest.GetTaskStartToCloseTimeoutSeconds() <= 0 {
 		return nil, wh.error(errInvalidTaskStartToCloseTimeoutSeconds, scope, tags...)
 	}
@@ -3495,10 +3516,15 @@ func (wh *WorkflowHandler) CountWorkflowExecutions(
 		return nil, wh.error(createServiceBusyError(), scope)
 	}
 
-	validatedQuery, err := wh.visibilityQueryValidator.ValidateQuery(countRequest.GetQuery())
+	validatedQuery, groupBy, err := wh.visibilityQueryValidator.ValidateCountQuery(countRequest.GetQuery())
 	if err != nil {
 		return nil, wh.error(err, scope)
 	}
+	// the visibility stores can group counts, but the response has no buckets
+	// until they are added to the API
+	if len(groupBy) > 0 {
+		return nil, wh.error(errCountGroupByNotSupported, scope)
+	}
 
 	domain := countRequest.GetDomain()
 	domainID, err := wh.GetDomainCache().GetDomainID(domain)
@@ -3537,7 +3563,18 @@ func (wh *WorkflowHandler) GetSearchAttributes(ctx context.Context) (resp *types
 		return nil, wh.error(err, scope)
 	}
 
-	keys := wh.config.ValidSearchAttributes()
+	validAttr := wh.config.ValidSearchAttributes()
+	aliases := wh.config.SearchAttributeAliases()
+	keys := make(map[string]interface{}, len(validAttr)+len(aliases))
+	for k, v := range validAttr {
+		keys[k] = v
+	}
+	// aliases are usable wherever the attributes they stand for are
+	for alias := range aliases {
+		if valueType, ok := validAttr[definition.ResolveSearchAttributeAlias(aliases, alias)]; ok {
+			keys[alias] = valueType
+		}
+	}
 	resp = &types.GetSearchAttributesResponse{
 		Keys: wh.convertIndexedKeyToThrift(keys),
 	}
@@ -4148,6 +4185,16 @@ func (wh *WorkflowHandler) validateTaskList(t *types.TaskList, scope metrics.Sco
 	return nil
 }
 
+func (wh *WorkflowHandler) validateMaxExecutionTimeout(timeoutSeconds int32, domain string) error {
+	maxTimeoutSeconds := wh.config.MaxExecutionStartToCloseTimeoutSeconds(domain)
+	if maxTimeoutSeconds > 0 && int(timeoutSeconds) > maxTimeoutSeconds {
+		return &types.BadRequestError{
+			Message: fmt.Sprintf("ExecutionStartToCloseTimeoutSeconds exceeds the limit of domain %v: %v seconds.", domain, maxTimeoutSeconds),
+		}
+	}
+	return nil
+}
+
 func validateExecution(w *types.WorkflowExecution) error {
 	if w == nil {
 		return errExecutionNotSet
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/cache"
 	"github.com/uber/cadence/common/config"
 	"github.com/uber/cadence/common/dynamicconfig"
 	"github.com/uber/cadence/common/log"
@@ -61,6 +62,14 @@ type Config struct {
 	WorkflowDeletionJitterRange     dynamicconfig.IntPropertyFnWithDomainFilter
 	MaxResponseSize                 dynamicconfig.IntPropertyFn
 
+	// Configs of the bulk processor writing to advanced visibility store
+	// when AdvancedVisibilityWritingPipeline is direct
+	AdvancedVisibilityWritingPipeline dynamicconfig.StringPropertyFn
+	ESProcessorNumOfWorkers           dynamicconfig.IntPropertyFn
+	ESProcessorBulkActions            dynamicconfig.IntPropertyFn
+	ESProcessorBulkSize               dynamicconfig.IntPropertyFn
+	ESProcessorFlushInterval          dynamicconfig.DurationPropertyFn
+
 	// HistoryCache settings
 	// Change of these configs require shard restart
 	HistoryCacheInitialSize dynamicconfig.IntPropertyFn
@@ -245,6 +254,9 @@ type Config struct {
 
 	// ValidSearchAttributes is legal indexed keys that can be used in list APIs
 	ValidSearchAttributes             dynamicconfig.MapPropertyFn
+	SearchAttributeAliases            dynamicconfig.MapPropertyFn
+	DeprecatedSearchAttributes        dynamicconfig.MapPropertyFn
+	DomainScopedSearchAttributes      dynamicconfig.MapPropertyFn
 	SearchAttributesNumberOfKeysLimit dynamicconfig.IntPropertyFnWithDomainFilter
 	SearchAttributesSizeOfValueLimit  dynamicconfig.IntPropertyFnWithDomainFilter
 	SearchAttributesTotalSizeLimit    dynamicconfig.IntPropertyFnWithDomainFilter
@@ -346,6 +358,11 @@ func New(dc *dynamicconfig.Collection, numberOfShards int, storeType string, isA
 		MaxDecisionStartToCloseSeconds:       dc.GetIntPropertyFilteredByDomain(dynamicconfig.MaxDecisionStartToCloseSeconds),
 		AdvancedVisibilityWritingMode:        dc.GetStringProperty(dynamicconfig.AdvancedVisibilityWritingMode),
 		EmitShardDiffLog:                     dc.GetBoolProperty(dynamicconfig.EmitShardDiffLog),
+		AdvancedVisibilityWritingPipeline:    dc.GetStringProperty(dynamicconfig.AdvancedVisibilityWritingPipeline),
+		ESProcessorNumOfWorkers:              dc.GetIntProperty(dynamicconfig.HistoryESProcessorNumOfWorkers),
+		ESProcessorBulkActions:               dc.GetIntProperty(dynamicconfig.HistoryESProcessorBulkActions),
+		ESProcessorBulkSize:                  dc.GetIntProperty(dynamicconfig.HistoryESProcessorBulkSize),
+		ESProcessorFlushInterval:             dc.GetDurationProperty(dynamicconfig.HistoryESProcessorFlushInterval),
 		HistoryCacheInitialSize:              dc.GetIntProperty(dynamicconfig.HistoryCacheInitialSize),
 		HistoryCacheMaxSize:                  dc.GetIntProperty(dynamicconfig.HistoryCacheMaxSize),
 		HistoryCacheTTL:                      dc.GetDurationProperty(dynamicconfig.HistoryCacheTTL),
@@ -499,6 +516,9 @@ func New(dc *dynamicconfig.Collection, numberOfShards int, storeType string, isA
 		EnableStickyQuery: dc.GetBoolPropertyFilteredByDomain(dynamicconfig.EnableStickyQuery),
 
 		ValidSearchAttributes:                    dc.GetMapProperty(dynamicconfig.ValidSearchAttributes),
+		SearchAttributeAliases:                   dc.GetMapProperty(dynamicconfig.SearchAttributeAliases),
+		DeprecatedSearchAttributes:               dc.GetMapProperty(dynamicconfig.DeprecatedSearchAttributes),
+		DomainScopedSearchAttributes:             dc.GetMapProperty(dynamicconfig.DomainScopedSearchAttributes),
 		SearchAttributesNumberOfKeysLimit:        dc.GetIntPropertyFilteredByDomain(dynamicconfig.SearchAttributesNumberOfKeysLimit),
 		SearchAttributesSizeOfValueLimit:         dc.GetIntPropertyFilteredByDomain(dynamicconfig.SearchAttributesSizeOfValueLimit),
 		SearchAttributesTotalSizeLimit:           dc.GetIntPropertyFilteredByDomain(dynamicconfig.SearchAttributesTotalSizeLimit),
@@ -599,3 +619,14 @@ func NewForTestByShardNumber(shardNumber int) *Config {
 func (config *Config) GetShardID(workflowID string) int {
 	return common.WorkflowIDToHistoryShard(workflowID, config.NumberOfShards)
 }
+
+// ApplyDomainLimits makes the per domain size and search attribute limits prefer
+// the values stored in the domain config over the dynamic config ones
+func (config *Config) ApplyDomainLimits(domainCache cache.DomainCache) {
+	config.BlobSizeLimitError = cache.NewDomainLimitFn(domainCache, cache.DomainBlobSizeLimit, config.BlobSizeLimitError)
+	config.HistorySizeLimitError = cache.NewDomainLimitFn(domainCache, cache.DomainHistorySizeLimit, config.HistorySizeLimitError)
+	config.HistoryCountLimitError = cache.NewDomainLimitFn(domainCache, cache.DomainHistoryCountLimit, config.HistoryCountLimitError)
+	config.SearchAttributesNumberOfKeysLimit = cache.NewDomainLimitFn(domainCache, cache.DomainSearchAttributesNumberOfKeysLimit, config.SearchAttributesNumberOfKeysLimit)
+	config.SearchAttributesSizeOfValueLimit = cache.NewDomainLimitFn(domainCache, cache.DomainSearchAttributesSizeOfValueLimit, config.SearchAttributesSizeOfValueLimit)
+	config.SearchAttributesTotalSizeLimit = cache.NewDomainLimitFn(domainCache, cache.DomainSearchAttributesTotalSizeLimit, config.SearchAttributesTotalSizeLimit)
+}
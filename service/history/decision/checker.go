@@ -80,6 +80,9 @@ func newAttrValidator(
 		searchAttributesValidator: validator.NewSearchAttributesValidator(
 			logger,
 			config.ValidSearchAttributes,
+			config.SearchAttributeAliases,
+			config.DeprecatedSearchAttributes,
+			config.DomainScopedSearchAttributes,
 			config.SearchAttributesNumberOfKeysLimit,
 			config.SearchAttributesSizeOfValueLimit,
 			config.SearchAttributesTotalSizeLimit,
@@ -93,8 +93,9 @@ func New(
 			PersistenceGlobalMaxQPS: config.PersistenceGlobalMaxQPS,
 			ThrottledLoggerMaxRPS:   config.ThrottledLogRPS,
 
-			EnableReadVisibilityFromES:    nil, // history service never read,
-			AdvancedVisibilityWritingMode: config.AdvancedVisibilityWritingMode,
+			EnableReadVisibilityFromES:        nil, // history service never read,
+			AdvancedVisibilityWritingMode:     config.AdvancedVisibilityWritingMode,
+			AdvancedVisibilityWritingPipeline: config.AdvancedVisibilityWritingPipeline,
 
 			EnableDBVisibilitySampling:                  config.EnableVisibilitySampling,
 			EnableReadDBVisibilityFromClosedExecutionV2: nil, // history service never read,
@@ -102,9 +103,14 @@ func New(
 			WriteDBVisibilityOpenMaxQPS:                 config.VisibilityOpenMaxQPS,
 			WriteDBVisibilityClosedMaxQPS:               config.VisibilityClosedMaxQPS,
 
-			ESVisibilityListMaxQPS: nil, // history service never read,
-			ESIndexMaxResultWindow: nil, // history service never read,
-			ValidSearchAttributes:  nil, // history service never read,
+			ESVisibilityListMaxQPS:   nil, // history service never read,
+			ESIndexMaxResultWindow:   nil, // history service never read,
+			ValidSearchAttributes:    config.ValidSearchAttributes,
+			SearchAttributeAliases:   config.SearchAttributeAliases,
+			ESProcessorNumOfWorkers:  config.ESProcessorNumOfWorkers,
+			ESProcessorBulkActions:   config.ESProcessorBulkActions,
+			ESProcessorBulkSize:      config.ESProcessorBulkSize,
+			ESProcessorFlushInterval: config.ESProcessorFlushInterval,
 		},
 	)
 	if err != nil {
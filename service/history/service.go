@@ -68,6 +68,7 @@ func NewService(
 	if err != nil {
 		return nil, err
 	}
+	serviceConfig.ApplyDomainLimits(serviceResource.GetDomainCache())
 
 	return &Service{
 		Resource: serviceResource,
@@ -50,7 +50,13 @@ var (
 	deleteHistoryActivityNonRetryableErrors = []string{"cadenceInternal:Panic", errDeleteNonRetriable.Error()}
 )
 
-func uploadHistoryActivity(ctx context.Context, request ArchiveRequest) (err error) {
+func uploadHistoryActivity(ctx context.Context, request ArchiveRequest) error {
+	return uploadHistory(ctx, request, carchiver.GetHeartbeatArchiveOption())
+}
+
+// uploadHistory archives the history of a workflow. The archivers resume from the activity heartbeat only
+// if the heartbeat archive option is given, callers which heartbeat their own progress must not pass it.
+func uploadHistory(ctx context.Context, request ArchiveRequest, opts ...carchiver.ArchiveOption) (err error) {
 	container := ctx.Value(bootstrapContainerKey).(*BootstrapContainer)
 	scope := container.MetricsClient.Scope(metrics.ArchiverUploadHistoryActivityScope, metrics.DomainTag(request.DomainName))
 	sw := scope.StartTimer(metrics.CadenceLatency)
@@ -85,7 +91,7 @@ func uploadHistoryActivity(ctx context.Context, request ArchiveRequest) (err err
 		BranchToken:          request.BranchToken,
 		NextEventID:          request.NextEventID,
 		CloseFailoverVersion: request.CloseFailoverVersion,
-	}, carchiver.GetHeartbeatArchiveOption(), carchiver.GetNonRetriableErrorOption(errUploadNonRetriable), allowArchivingIncompleteHistoryOpt)
+	}, append(opts, carchiver.GetNonRetriableErrorOption(errUploadNonRetriable), allowArchivingIncompleteHistoryOpt)...)
 	if err == nil {
 		return nil
 	}
@@ -0,0 +1,348 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package archiver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/cadence"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"golang.org/x/time/rate"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+const (
+	// BackfillWorkflowTypeName is the workflow type of the archival backfill workflow
+	BackfillWorkflowTypeName = "cadence-sys-archival-backfill-workflow"
+	// BackfillWorkflowIDPrefix is the prefix of archival backfill workflow ids, the domain name is appended to it
+	BackfillWorkflowIDPrefix = "cadence-archival-backfill-"
+	// BackfillTaskListName is the task list archival backfill workflows are started on
+	BackfillTaskListName = decisionTaskList
+	// BackfillProgressQueryType is the query type which returns the BackfillProgress of a backfill workflow
+	BackfillProgressQueryType = "backfill_progress"
+
+	backfillActivityFnName = "archivalBackfillActivity"
+
+	defaultBackfillRPS      = 10
+	defaultBackfillPageSize = 100
+	// backfillPagesPerActivity bounds the work done by one activity so progress is recorded in workflow history
+	backfillPagesPerActivity = 10
+	// backfillActivitiesPerRun bounds the history size of a single backfill workflow run
+	backfillActivitiesPerRun = 100
+)
+
+var (
+	errBackfillNonRetriable = errors.New("archival backfill non-retriable error")
+
+	backfillActivityOptions = workflow.ActivityOptions{
+		ScheduleToStartTimeout: 10 * time.Minute,
+		StartToCloseTimeout:    time.Hour,
+		HeartbeatTimeout:       5 * time.Minute,
+		RetryPolicy: &cadence.RetryPolicy{
+			InitialInterval:          time.Second,
+			BackoffCoefficient:       2.0,
+			MaximumInterval:          5 * time.Minute,
+			ExpirationInterval:       24 * time.Hour,
+			NonRetriableErrorReasons: []string{"cadenceInternal:Panic", errBackfillNonRetriable.Error()},
+		},
+	}
+)
+
+type (
+	// BackfillRequest is the input of the archival backfill workflow
+	BackfillRequest struct {
+		DomainName string
+		// RPS limits the number of closed workflows archived per second
+		RPS int
+		// PageSize is the number of closed workflows read from visibility at once
+		PageSize int
+		// LatestCloseTime bounds the backfill to workflows closed before it (unix nano),
+		// it defaults to the start time of the backfill. Workflows closed afterwards are archived on the retention path.
+		LatestCloseTime int64
+	}
+
+	// BackfillProgress is the progress of an archival backfill, it is used to resume the backfill
+	BackfillProgress struct {
+		// NextPageToken is the token of the page of closed workflows which is processed next
+		NextPageToken []byte
+		// PageOffset is the number of workflows of that page which were already processed
+		PageOffset int
+		// Scanned is the number of closed workflows read from visibility
+		Scanned int
+		// HistoryArchived is the number of workflows whose history got archived
+		HistoryArchived int
+		// VisibilityArchived is the number of workflows whose visibility record got archived
+		VisibilityArchived int
+		// Skipped is the number of workflows which are no longer retained
+		Skipped int
+		// Failed is the number of workflows which could not be archived
+		Failed int
+		// Done is true once all closed workflows have been processed
+		Done bool
+	}
+
+	// BackfillParams is the input of the archival backfill workflow and activity,
+	// Progress is empty when a backfill is started and carried over between workflow runs
+	BackfillParams struct {
+		Request  BackfillRequest
+		Progress BackfillProgress
+	}
+)
+
+// BackfillWorkflowID returns the archival backfill workflow id of a domain
+func BackfillWorkflowID(domainName string) string {
+	return BackfillWorkflowIDPrefix + domainName
+}
+
+// archivalBackfillWorkflow archives the history and visibility records of the closed workflows of a domain which
+// are still retained. It is used when archival gets enabled on a domain which already has closed workflows,
+// as those are otherwise only archived when they reach the end of retention.
+func archivalBackfillWorkflow(ctx workflow.Context, params BackfillParams) (*BackfillProgress, error) {
+	request, progress := params.Request, params.Progress
+	if err := workflow.SetQueryHandler(ctx, BackfillProgressQueryType, func() (*BackfillProgress, error) {
+		return &progress, nil
+	}); err != nil {
+		return nil, err
+	}
+	if request.LatestCloseTime == 0 {
+		request.LatestCloseTime = workflow.Now(ctx).UnixNano()
+	}
+
+	actCtx := workflow.WithActivityOptions(ctx, backfillActivityOptions)
+	for i := 0; i < backfillActivitiesPerRun; i++ {
+		var result BackfillProgress
+		if err := workflow.ExecuteActivity(actCtx, backfillActivityFnName, BackfillParams{
+			Request:  request,
+			Progress: progress,
+		}).Get(ctx, &result); err != nil {
+			return nil, err
+		}
+		progress = result
+		if progress.Done {
+			return &progress, nil
+		}
+	}
+	return nil, workflow.NewContinueAsNewError(ctx, BackfillWorkflowTypeName, BackfillParams{
+		Request:  request,
+		Progress: progress,
+	})
+}
+
+// archivalBackfillActivity archives a bounded number of pages of closed workflows.
+// Progress is heartbeated after each workflow so a retried activity resumes from the first workflow it did not complete.
+// The history archivers are called without the heartbeat archive option, as their upload progress would overwrite it.
+func archivalBackfillActivity(ctx context.Context, params BackfillParams) (progress BackfillProgress, err error) {
+	container := ctx.Value(bootstrapContainerKey).(*BootstrapContainer)
+	logger := tagLoggerWithActivityInfo(container.Logger, activity.GetInfo(ctx)).WithTags(tag.WorkflowDomainName(params.Request.DomainName))
+	defer func() {
+		if err != nil {
+			err = cadence.NewCustomError(err.Error())
+		}
+	}()
+
+	progress = params.Progress
+	if activity.HasHeartbeatDetails(ctx) {
+		if err := activity.GetHeartbeatDetails(ctx, &progress); err != nil {
+			logger.Warn("failed to recover from last heartbeat, restart from last completed activity", tag.Error(err))
+			progress = params.Progress
+		}
+	}
+
+	domainEntry, err := container.DomainCache.GetDomain(params.Request.DomainName)
+	if err != nil {
+		if _, ok := err.(*types.EntityNotExistsError); ok {
+			return progress, errBackfillNonRetriable
+		}
+		return progress, err
+	}
+	config := domainEntry.GetConfig()
+	archiveHistory := config.HistoryArchivalStatus == types.ArchivalStatusEnabled
+	archiveVisibility := config.VisibilityArchivalStatus == types.ArchivalStatusEnabled
+	if !archiveHistory && !archiveVisibility {
+		logger.Error("archival is not enabled for domain, stopping backfill")
+		return progress, errBackfillNonRetriable
+	}
+
+	rps := params.Request.RPS
+	if rps <= 0 {
+		rps = defaultBackfillRPS
+	}
+	pageSize := params.Request.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultBackfillPageSize
+	}
+	limiter := rate.NewLimiter(rate.Limit(rps), rps)
+
+	for page := 0; page < backfillPagesPerActivity; page++ {
+		resp, err := container.VisibilityManager.ListClosedWorkflowExecutions(ctx, &persistence.ListWorkflowExecutionsRequest{
+			DomainUUID:    domainEntry.GetInfo().ID,
+			Domain:        domainEntry.GetInfo().Name,
+			EarliestTime:  0,
+			LatestTime:    params.Request.LatestCloseTime,
+			PageSize:      pageSize,
+			NextPageToken: progress.NextPageToken,
+		})
+		if err != nil {
+			logger.Error("failed to list closed workflows", tag.Error(err))
+			return progress, err
+		}
+		for i, execution := range resp.Executions {
+			if i < progress.PageOffset {
+				continue
+			}
+			if err := limiter.Wait(ctx); err != nil {
+				return progress, err
+			}
+			if err := backfillExecution(ctx, container, domainEntry, execution, archiveHistory, archiveVisibility, &progress); err != nil {
+				return progress, err
+			}
+			progress.PageOffset++
+			activity.RecordHeartbeat(ctx, progress)
+		}
+		progress.NextPageToken = resp.NextPageToken
+		progress.PageOffset = 0
+		activity.RecordHeartbeat(ctx, progress)
+		if len(progress.NextPageToken) == 0 {
+			progress.Done = true
+			break
+		}
+	}
+	return progress, nil
+}
+
+// backfillExecution archives a single closed workflow and records the outcome in progress.
+// Only transient errors which should fail the activity are returned.
+func backfillExecution(
+	ctx context.Context,
+	container *BootstrapContainer,
+	domainEntry *cache.DomainCacheEntry,
+	execution *types.WorkflowExecutionInfo,
+	archiveHistory bool,
+	archiveVisibility bool,
+	progress *BackfillProgress,
+) error {
+	progress.Scanned++
+	domainInfo := domainEntry.GetInfo()
+	request := ArchiveRequest{
+		DomainID:           domainInfo.ID,
+		DomainName:         domainInfo.Name,
+		WorkflowID:         execution.GetExecution().GetWorkflowID(),
+		RunID:              execution.GetExecution().GetRunID(),
+		ShardID:            common.WorkflowIDToHistoryShard(execution.GetExecution().GetWorkflowID(), container.NumHistoryShards),
+		URI:                domainEntry.GetConfig().HistoryArchivalURI,
+		WorkflowTypeName:   execution.GetType().GetName(),
+		StartTimestamp:     execution.GetStartTime(),
+		ExecutionTimestamp: execution.GetExecutionTime(),
+		CloseTimestamp:     execution.GetCloseTime(),
+		CloseStatus:        execution.GetCloseStatus(),
+		HistoryLength:      execution.HistoryLength,
+		Memo:               execution.Memo,
+		SearchAttributes:   execution.GetSearchAttributes().GetIndexedFields(),
+		VisibilityURI:      domainEntry.GetConfig().VisibilityArchivalURI,
+	}
+	logger := tagLoggerWithHistoryRequest(container.Logger, &request)
+
+	if archiveHistory {
+		exists, err := loadHistoryArchivalFields(ctx, container, &request)
+		if err != nil {
+			logger.Error("failed to load mutable state for archival backfill", tag.Error(err))
+			return err
+		}
+		if !exists {
+			// the workflow reached the end of retention and was archived on the retention path
+			progress.Skipped++
+			return nil
+		}
+		if err := uploadHistory(ctx, request); err != nil {
+			if err.Error() != errUploadNonRetriable.Error() {
+				return err
+			}
+			progress.Failed++
+			return nil
+		}
+		progress.HistoryArchived++
+	}
+	if archiveVisibility {
+		if err := archiveVisibilityActivity(ctx, request); err != nil {
+			if err.Error() != errArchiveVisibilityNonRetriable.Error() {
+				return err
+			}
+			progress.Failed++
+			return nil
+		}
+		progress.VisibilityArchived++
+	}
+	return nil
+}
+
+// loadHistoryArchivalFields fills the branch token, next event id and close failover version of the request
+// from mutable state, it returns false if the workflow no longer exists
+func loadHistoryArchivalFields(ctx context.Context, container *BootstrapContainer, request *ArchiveRequest) (bool, error) {
+	executionManager, err := container.ExecutionManagerProvider.GetExecutionManager(request.ShardID)
+	if err != nil {
+		return false, err
+	}
+	resp, err := executionManager.GetWorkflowExecution(ctx, &persistence.GetWorkflowExecutionRequest{
+		DomainID: request.DomainID,
+		Execution: types.WorkflowExecution{
+			WorkflowID: request.WorkflowID,
+			RunID:      request.RunID,
+		},
+		DomainName: request.DomainName,
+	})
+	if err != nil {
+		if _, ok := err.(*types.EntityNotExistsError); ok {
+			return false, nil
+		}
+		return false, err
+	}
+
+	state := resp.State
+	request.NextEventID = state.ExecutionInfo.NextEventID
+	request.BranchToken = state.ExecutionInfo.BranchToken
+	request.CloseFailoverVersion = common.EmptyVersion
+	if state.ReplicationState != nil {
+		request.CloseFailoverVersion = state.ReplicationState.LastWriteVersion
+	}
+	if state.VersionHistories != nil {
+		versionHistory, err := state.VersionHistories.GetCurrentVersionHistory()
+		if err != nil {
+			return false, err
+		}
+		request.BranchToken = versionHistory.GetBranchToken()
+		if state.ReplicationState == nil {
+			lastItem, err := versionHistory.GetLastItem()
+			if err != nil {
+				return false, err
+			}
+			request.CloseFailoverVersion = lastItem.Version
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,255 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package archiver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/encoded"
+	"go.uber.org/cadence/worker"
+
+	"github.com/uber/cadence/common"
+	carchiver "github.com/uber/cadence/common/archiver"
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/cluster"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/mocks"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/service"
+	"github.com/uber/cadence/common/types"
+)
+
+type testExecutionManagerProvider struct {
+	executionManager persistence.ExecutionManager
+}
+
+func (p *testExecutionManagerProvider) GetExecutionManager(int) (persistence.ExecutionManager, error) {
+	return p.executionManager, nil
+}
+
+func (s *activitiesSuite) TestBackfillWorkflow() {
+	env := s.NewTestWorkflowEnvironment()
+	env.OnActivity(backfillActivityFnName, mock.Anything, mock.Anything).Return(BackfillProgress{
+		NextPageToken: []byte("token"),
+		Scanned:       10,
+	}, nil).Once()
+	env.OnActivity(backfillActivityFnName, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, params BackfillParams) (BackfillProgress, error) {
+			s.Equal([]byte("token"), params.Progress.NextPageToken)
+			s.NotZero(params.Request.LatestCloseTime)
+			return BackfillProgress{Scanned: 15, HistoryArchived: 15, Done: true}, nil
+		}).Once()
+
+	env.ExecuteWorkflow(BackfillWorkflowTypeName, BackfillParams{Request: BackfillRequest{DomainName: testDomainName}})
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var progress BackfillProgress
+	s.NoError(env.GetWorkflowResult(&progress))
+	s.Equal(BackfillProgress{Scanned: 15, HistoryArchived: 15, Done: true}, progress)
+
+	queryResult, err := env.QueryWorkflow(BackfillProgressQueryType)
+	s.NoError(err)
+	s.NoError(queryResult.Get(&progress))
+	s.True(progress.Done)
+}
+
+func (s *activitiesSuite) TestBackfillActivity() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	domainCache := cache.NewMockDomainCache(ctrl)
+	domainCache.EXPECT().GetDomain(testDomainName).Return(cache.NewLocalDomainCacheEntryForTest(
+		&persistence.DomainInfo{ID: testDomainID, Name: testDomainName},
+		&persistence.DomainConfig{
+			HistoryArchivalStatus:    types.ArchivalStatusEnabled,
+			HistoryArchivalURI:       testArchivalURI,
+			VisibilityArchivalStatus: types.ArchivalStatusEnabled,
+			VisibilityArchivalURI:    testArchivalURI,
+		},
+		cluster.TestCurrentClusterName,
+	), nil)
+
+	closeTime := time.Now().UnixNano()
+	visibilityManager := &mocks.VisibilityManager{}
+	visibilityManager.On("ListClosedWorkflowExecutions", mock.Anything, mock.Anything).Return(&persistence.ListWorkflowExecutionsResponse{
+		Executions: []*types.WorkflowExecutionInfo{
+			{
+				Execution:   &types.WorkflowExecution{WorkflowID: testWorkflowID, RunID: testRunID},
+				Type:        &types.WorkflowType{Name: "test-workflow-type"},
+				StartTime:   common.Int64Ptr(closeTime - int64(time.Hour)),
+				CloseTime:   common.Int64Ptr(closeTime),
+				CloseStatus: types.WorkflowExecutionCloseStatusCompleted.Ptr(),
+			},
+			{
+				Execution: &types.WorkflowExecution{WorkflowID: testWorkflowID, RunID: "deleted-run-id"},
+				CloseTime: common.Int64Ptr(closeTime),
+			},
+		},
+	}, nil).Once()
+
+	executionManager := &mocks.ExecutionManager{}
+	executionManager.On("GetWorkflowExecution", mock.Anything, mock.MatchedBy(func(req *persistence.GetWorkflowExecutionRequest) bool {
+		return req.Execution.RunID == testRunID
+	})).Return(&persistence.GetWorkflowExecutionResponse{
+		State: &persistence.WorkflowMutableState{
+			ExecutionInfo: &persistence.WorkflowExecutionInfo{
+				NextEventID: testNextEventID,
+				BranchToken: testBranchToken,
+			},
+		},
+	}, nil).Once()
+	executionManager.On("GetWorkflowExecution", mock.Anything, mock.Anything).Return(nil, &types.EntityNotExistsError{}).Once()
+
+	s.metricsClient.On("Scope", metrics.ArchiverUploadHistoryActivityScope, metrics.DomainTag(testDomainName)).Return(s.metricsScope).Once()
+	s.metricsClient.On("Scope", metrics.ArchiverArchiveVisibilityActivityScope, metrics.DomainTag(testDomainName)).Return(s.metricsScope).Once()
+	// the heartbeat archive option must not be passed, the backfill heartbeats its own progress
+	s.historyArchiver.On("Archive", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	s.archiverProvider.On("GetHistoryArchiver", mock.Anything, service.Worker).Return(s.historyArchiver, nil)
+	s.visibilityArchiver.On("Archive", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	s.archiverProvider.On("GetVisibilityArchiver", mock.Anything, service.Worker).Return(s.visibilityArchiver, nil)
+
+	container := &BootstrapContainer{
+		Logger:           s.logger,
+		MetricsClient:    s.metricsClient,
+		ArchiverProvider: s.archiverProvider,
+		DomainCache:      domainCache,
+		Config: &Config{
+			AllowArchivingIncompleteHistory: dynamicconfig.GetBoolPropertyFn(false),
+		},
+		VisibilityManager:        visibilityManager,
+		ExecutionManagerProvider: &testExecutionManagerProvider{executionManager: executionManager},
+		NumHistoryShards:         1,
+	}
+	env := s.NewTestActivityEnvironment()
+	env.SetWorkerOptions(worker.Options{
+		BackgroundActivityContext: context.WithValue(context.Background(), bootstrapContainerKey, container),
+	})
+	result, err := env.ExecuteActivity(archivalBackfillActivity, BackfillParams{
+		Request: BackfillRequest{DomainName: testDomainName, RPS: 100, LatestCloseTime: closeTime},
+	})
+	s.NoError(err)
+	var progress BackfillProgress
+	s.NoError(result.Get(&progress))
+	s.Equal(BackfillProgress{
+		Scanned:            2,
+		HistoryArchived:    1,
+		VisibilityArchived: 1,
+		Skipped:            1,
+		Done:               true,
+	}, progress)
+	visibilityManager.AssertExpectations(s.T())
+	executionManager.AssertExpectations(s.T())
+}
+
+func (s *activitiesSuite) TestBackfillActivity_ResumesMidPage() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	domainCache := cache.NewMockDomainCache(ctrl)
+	domainCache.EXPECT().GetDomain(testDomainName).Return(cache.NewLocalDomainCacheEntryForTest(
+		&persistence.DomainInfo{ID: testDomainID, Name: testDomainName},
+		&persistence.DomainConfig{
+			HistoryArchivalStatus: types.ArchivalStatusEnabled,
+			HistoryArchivalURI:    testArchivalURI,
+		},
+		cluster.TestCurrentClusterName,
+	), nil).AnyTimes()
+
+	closeTime := time.Now().UnixNano()
+	runIDs := []string{"run-id-0", "run-id-1", "run-id-2"}
+	var executions []*types.WorkflowExecutionInfo
+	for _, runID := range runIDs {
+		executions = append(executions, &types.WorkflowExecutionInfo{
+			Execution: &types.WorkflowExecution{WorkflowID: testWorkflowID, RunID: runID},
+			CloseTime: common.Int64Ptr(closeTime),
+		})
+	}
+	visibilityManager := &mocks.VisibilityManager{}
+	visibilityManager.On("ListClosedWorkflowExecutions", mock.Anything, mock.MatchedBy(func(req *persistence.ListWorkflowExecutionsRequest) bool {
+		return string(req.NextPageToken) == "page-2"
+	})).Return(&persistence.ListWorkflowExecutionsResponse{Executions: executions}, nil).Twice()
+
+	executionManager := &mocks.ExecutionManager{}
+	executionManager.On("GetWorkflowExecution", mock.Anything, mock.Anything).Return(&persistence.GetWorkflowExecutionResponse{
+		State: &persistence.WorkflowMutableState{
+			ExecutionInfo: &persistence.WorkflowExecutionInfo{
+				NextEventID: testNextEventID,
+				BranchToken: testBranchToken,
+			},
+		},
+	}, nil)
+
+	// the activity fails while uploading the second workflow of the page
+	archived := make(map[string]int)
+	isRun := func(runID string) interface{} {
+		return mock.MatchedBy(func(req *carchiver.ArchiveHistoryRequest) bool { return req.RunID == runID })
+	}
+	s.historyArchiver.On("Archive", mock.Anything, mock.Anything, isRun(runIDs[1]), mock.Anything, mock.Anything).Return(errors.New("transient upload error")).Once()
+	s.historyArchiver.On("Archive", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, _ carchiver.URI, req *carchiver.ArchiveHistoryRequest, _ ...carchiver.ArchiveOption) error {
+			archived[req.RunID]++
+			return nil
+		})
+	s.archiverProvider.On("GetHistoryArchiver", mock.Anything, service.Worker).Return(s.historyArchiver, nil)
+	s.metricsClient.On("Scope", metrics.ArchiverUploadHistoryActivityScope, metrics.DomainTag(testDomainName)).Return(s.metricsScope)
+
+	container := &BootstrapContainer{
+		Logger:           s.logger,
+		MetricsClient:    s.metricsClient,
+		ArchiverProvider: s.archiverProvider,
+		DomainCache:      domainCache,
+		Config: &Config{
+			AllowArchivingIncompleteHistory: dynamicconfig.GetBoolPropertyFn(false),
+		},
+		VisibilityManager:        visibilityManager,
+		ExecutionManagerProvider: &testExecutionManagerProvider{executionManager: executionManager},
+		NumHistoryShards:         1,
+	}
+	env := s.NewTestWorkflowEnvironment()
+	env.SetWorkerOptions(worker.Options{
+		BackgroundActivityContext: context.WithValue(context.Background(), bootstrapContainerKey, container),
+	})
+	var heartbeats []BackfillProgress
+	env.SetOnActivityHeartbeatListener(func(_ *activity.Info, details encoded.Values) {
+		var progress BackfillProgress
+		s.NoError(details.Get(&progress))
+		heartbeats = append(heartbeats, progress)
+	})
+
+	env.ExecuteWorkflow(BackfillWorkflowTypeName, BackfillParams{
+		Request:  BackfillRequest{DomainName: testDomainName, RPS: 100, LatestCloseTime: closeTime},
+		Progress: BackfillProgress{NextPageToken: []byte("page-2"), Scanned: 10, HistoryArchived: 10},
+	})
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+
+	// the retried attempt resumed from the heartbeat recorded before the failed upload
+	s.Equal(BackfillProgress{NextPageToken: []byte("page-2"), PageOffset: 1, Scanned: 11, HistoryArchived: 11}, heartbeats[0])
+	s.Equal(map[string]int{runIDs[0]: 1, runIDs[1]: 1, runIDs[2]: 1}, archived)
+	var progress BackfillProgress
+	s.NoError(env.GetWorkflowResult(&progress))
+	s.Equal(BackfillProgress{Scanned: 13, HistoryArchived: 13, Done: true}, progress)
+	visibilityManager.AssertExpectations(s.T())
+}
@@ -60,6 +60,16 @@ type (
 		DomainCache      cache.DomainCache
 		Config           *Config
 		ArchiverProvider provider.ArchiverProvider
+
+		// the following are used by archival backfill to enumerate closed workflows and load their mutable state
+		VisibilityManager        persistence.VisibilityManager
+		ExecutionManagerProvider ExecutionManagerProvider
+		NumHistoryShards         int
+	}
+
+	// ExecutionManagerProvider provides the execution manager of a shard
+	ExecutionManagerProvider interface {
+		GetExecutionManager(shardID int) (persistence.ExecutionManager, error)
 	}
 
 	// Config for ClientWorker
@@ -96,6 +106,9 @@ func init() {
 	activity.RegisterWithOptions(uploadHistoryActivity, activity.RegisterOptions{Name: uploadHistoryActivityFnName})
 	activity.RegisterWithOptions(deleteHistoryActivity, activity.RegisterOptions{Name: deleteHistoryActivityFnName})
 	activity.RegisterWithOptions(archiveVisibilityActivity, activity.RegisterOptions{Name: archiveVisibilityActivityFnName})
+
+	workflow.RegisterWithOptions(archivalBackfillWorkflow, workflow.RegisterOptions{Name: BackfillWorkflowTypeName})
+	activity.RegisterWithOptions(archivalBackfillActivity, activity.RegisterOptions{Name: backfillActivityFnName})
 }
 
 // NewClientWorker returns a new ClientWorker
@@ -22,6 +22,7 @@ package batcher
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -38,6 +39,7 @@ import (
 	"github.com/uber/cadence/common/log/tag"
 	"github.com/uber/cadence/common/metrics"
 	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/service/worker/workercommon"
 )
 
 type (
@@ -51,6 +53,10 @@ const (
 	// BatchWFTypeName is the workflow type
 	BatchWFTypeName   = "cadence-sys-batch-workflow"
 	batchActivityName = "cadence-sys-batch-activity"
+	// batchProgressSignalName is the signal through which the activity reports its progress to the workflow
+	batchProgressSignalName = "cadence-sys-batch-progress"
+	// batchProgressSignalInterval is the min interval between progress signals, to bound the history size
+	batchProgressSignalInterval = 30 * time.Second
 	// InfiniteDuration is a long duration(20 yrs) we used for infinite workflow running
 	InfiniteDuration = 20 * 365 * 24 * time.Hour
 
@@ -160,6 +166,15 @@ type (
 		ErrorCount int
 	}
 
+	// progressDetails is the progress signaled by the activity to the workflow after each page
+	progressDetails struct {
+		TotalEstimate int64
+		SuccessCount  int
+		ErrorCount    int
+		// Errors are the most recent errors of the page
+		Errors []string
+	}
+
 	taskDetail struct {
 		execution types.WorkflowExecution
 		attempts  int
@@ -196,11 +211,58 @@ func BatchWorkflow(ctx workflow.Context, batchParams BatchParams) (HeartBeatDeta
 	if err != nil {
 		return HeartBeatDetails{}, err
 	}
+	progress := workercommon.NewProgress(workflow.Now(ctx))
+	err = workercommon.SetProgressQueryHandler(ctx, func() *workercommon.Progress {
+		return progress
+	})
+	if err != nil {
+		return HeartBeatDetails{}, err
+	}
+	progress.SetPhase(workercommon.ProgressPhaseRunning, workflow.Now(ctx))
+
 	batchActivityOptions.HeartbeatTimeout = batchParams.ActivityHeartBeatTimeout
 	opt := workflow.WithActivityOptions(ctx, batchActivityOptions)
+	future := workflow.ExecuteActivity(opt, batchActivityName, batchParams)
+	progressCh := workflow.GetSignalChannel(ctx, batchProgressSignalName)
 	var result HeartBeatDetails
-	err = workflow.ExecuteActivity(opt, batchActivityName, batchParams).Get(ctx, &result)
-	return result, err
+	for done := false; !done; {
+		selector := workflow.NewSelector(ctx)
+		selector.AddFuture(future, func(f workflow.Future) {
+			err = f.Get(ctx, &result)
+			done = true
+		})
+		selector.AddReceive(progressCh, func(c workflow.Channel, more bool) {
+			var details progressDetails
+			c.Receive(ctx, &details)
+			updateProgress(ctx, progress, details)
+		})
+		selector.Select(ctx)
+	}
+	if err != nil {
+		progress.AddError(err.Error(), workflow.Now(ctx))
+		return result, err
+	}
+	updateProgress(ctx, progress, progressDetails{
+		TotalEstimate: result.TotalEstimate,
+		SuccessCount:  result.SuccessCount,
+		ErrorCount:    result.ErrorCount,
+	})
+	progress.SetPhase(workercommon.ProgressPhaseCompleted, workflow.Now(ctx))
+	return result, nil
+}
+
+// updateProgress applies the counts of the activity, which are totals since the batch started
+func updateProgress(ctx workflow.Context, progress *workercommon.Progress, details progressDetails) {
+	now := workflow.Now(ctx)
+	progress.SetTotal(details.TotalEstimate, now)
+	progress.Add(
+		int64(details.SuccessCount+details.ErrorCount)-progress.Processed,
+		int64(details.ErrorCount)-progress.Failed,
+		now,
+	)
+	for _, err := range details.Errors {
+		progress.AddError(err, now)
+	}
 }
 
 func validateParams(params BatchParams) error {
@@ -310,6 +372,8 @@ func BatchActivity(ctx context.Context, batchParams BatchParams) (HeartBeatDetai
 		go startTaskProcessor(ctx, batchParams, domainID, taskCh, respCh, rateLimiter, client, adminClient)
 	}
 
+	var lastSignalTime time.Time
+	var pendingErrors []string
 	for {
 		// TODO https://github.com/uber/cadence/issues/2154
 		//  Need to improve scan concurrency because it will hold an ES resource until the workflow finishes.
@@ -348,6 +412,7 @@ func BatchActivity(ctx context.Context, batchParams BatchParams) (HeartBeatDetai
 					succCount++
 				} else {
 					errCount++
+					pendingErrors = appendProgressError(pendingErrors, err)
 				}
 				if succCount+errCount == batchCount {
 					break Loop
@@ -362,6 +427,11 @@ func BatchActivity(ctx context.Context, batchParams BatchParams) (HeartBeatDetai
 		hbd.SuccessCount += succCount
 		hbd.ErrorCount += errCount
 		activity.RecordHeartbeat(ctx, hbd)
+		if time.Since(lastSignalTime) >= batchProgressSignalInterval {
+			signalProgress(ctx, client, hbd, pendingErrors)
+			lastSignalTime = time.Now()
+			pendingErrors = nil
+		}
 
 		if len(hbd.PageToken) == 0 {
 			break
@@ -371,6 +441,43 @@ func BatchActivity(ctx context.Context, batchParams BatchParams) (HeartBeatDetai
 	return hbd, nil
 }
 
+// appendProgressError keeps the last MaxProgressLastErrors errors to report, truncated
+func appendProgressError(errs []string, err error) []string {
+	errs = append(errs, workercommon.TruncateProgressError(err.Error()))
+	if len(errs) > workercommon.MaxProgressLastErrors {
+		errs = errs[len(errs)-workercommon.MaxProgressLastErrors:]
+	}
+	return errs
+}
+
+// signalProgress reports the progress to the workflow so that it is durable and can be queried.
+// It is called at most every batchProgressSignalInterval, failures are only logged as the
+// progress is reported again later and the final counts are the result of the activity
+func signalProgress(ctx context.Context, client frontend.Client, hbd HeartBeatDetails, errs []string) {
+	input, err := json.Marshal(progressDetails{
+		TotalEstimate: hbd.TotalEstimate,
+		SuccessCount:  hbd.SuccessCount,
+		ErrorCount:    hbd.ErrorCount,
+		Errors:        errs,
+	})
+	if err == nil {
+		info := activity.GetInfo(ctx)
+		err = client.SignalWorkflowExecution(ctx, &types.SignalWorkflowExecutionRequest{
+			Domain: info.WorkflowDomain,
+			WorkflowExecution: &types.WorkflowExecution{
+				WorkflowID: info.WorkflowExecution.ID,
+				RunID:      info.WorkflowExecution.RunID,
+			},
+			SignalName: batchProgressSignalName,
+			Input:      input,
+			Identity:   BatchWFTypeName,
+		})
+	}
+	if err != nil {
+		getActivityLogger(ctx).Warn("Failed to signal batch progress", tag.Error(err))
+	}
+}
+
 func startTaskProcessor(
 	ctx context.Context,
 	batchParams BatchParams,
@@ -0,0 +1,98 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package batcher
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/cadence/testsuite"
+
+	"github.com/uber/cadence/service/worker/workercommon"
+)
+
+func TestBatchWorkflow_Progress(t *testing.T) {
+	env := (&testsuite.WorkflowTestSuite{}).NewTestWorkflowEnvironment()
+	env.OnActivity(batchActivityName, mock.Anything, mock.Anything).After(time.Hour).Return(HeartBeatDetails{
+		TotalEstimate: 10,
+		SuccessCount:  8,
+		ErrorCount:    2,
+	}, nil)
+
+	queryProgress := func() workercommon.Progress {
+		value, err := env.QueryWorkflow(workercommon.ProgressQueryType)
+		require.NoError(t, err)
+		var progress workercommon.Progress
+		require.NoError(t, value.Get(&progress))
+		return progress
+	}
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(batchProgressSignalName, progressDetails{
+			TotalEstimate: 10,
+			SuccessCount:  4,
+			ErrorCount:    1,
+			Errors:        []string{"some error"},
+		})
+	}, time.Minute)
+	env.RegisterDelayedCallback(func() {
+		progress := queryProgress()
+		assert.Equal(t, workercommon.ProgressPhaseRunning, progress.Phase)
+		assert.Equal(t, int64(10), progress.Total)
+		assert.Equal(t, int64(5), progress.Processed)
+		assert.Equal(t, int64(1), progress.Failed)
+		assert.Equal(t, []string{"some error"}, progress.LastErrors)
+		assert.NotNil(t, progress.ETA)
+	}, 2*time.Minute)
+
+	env.ExecuteWorkflow(BatchWFTypeName, BatchParams{
+		DomainName: "domain",
+		Query:      "WorkflowType = 'type'",
+		Reason:     "reason",
+		BatchType:  BatchTypeTerminate,
+	})
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	progress := queryProgress()
+	assert.Equal(t, workercommon.ProgressPhaseCompleted, progress.Phase)
+	assert.Equal(t, int64(10), progress.Processed)
+	assert.Equal(t, int64(2), progress.Failed)
+	assert.Equal(t, float64(100), progress.Percent())
+	assert.Nil(t, progress.ETA)
+}
+
+func TestAppendProgressError(t *testing.T) {
+	var errs []string
+	for i := 0; i < workercommon.MaxProgressLastErrors+2; i++ {
+		errs = appendProgressError(errs, fmt.Errorf("error %v", i))
+	}
+	require.Len(t, errs, workercommon.MaxProgressLastErrors)
+	assert.Equal(t, "error 2", errs[0])
+
+	errs = appendProgressError(nil, errors.New(strings.Repeat("a", 2*workercommon.MaxProgressErrorLength)))
+	assert.Len(t, errs[0], workercommon.MaxProgressErrorLength+len("..."))
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package domaindeletion
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber-go/tally"
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/worker"
+	"go.uber.org/cadence/workflow"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/domain"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/persistence"
+)
+
+type (
+	// BootstrapParams contains the set of params needed to bootstrap the domain deleter
+	BootstrapParams struct {
+		// ServiceClient is an instance of cadence service client
+		ServiceClient workflowserviceclient.Interface
+		Logger        log.Logger
+		// TallyScope is an instance of tally metrics scope
+		TallyScope tally.Scope
+		// DomainHandler removes the domain record once everything else got purged
+		DomainHandler            domain.Handler
+		DomainManager            persistence.DomainManager
+		VisibilityManager        persistence.VisibilityManager
+		HistoryManager           persistence.HistoryManager
+		TaskManager              persistence.TaskManager
+		ExecutionManagerProvider ExecutionManagerProvider
+		NumHistoryShards         int
+	}
+
+	// ExecutionManagerProvider provides the execution manager of a shard
+	ExecutionManagerProvider interface {
+		GetExecutionManager(shardID int) (persistence.ExecutionManager, error)
+	}
+
+	// DomainDeleter runs the workflows which delete deprecated domains
+	DomainDeleter struct {
+		svcClient                workflowserviceclient.Interface
+		tallyScope               tally.Scope
+		logger                   log.Logger
+		domainHandler            domain.Handler
+		domainManager            persistence.DomainManager
+		visibilityManager        persistence.VisibilityManager
+		historyManager           persistence.HistoryManager
+		taskManager              persistence.TaskManager
+		executionManagerProvider ExecutionManagerProvider
+		numHistoryShards         int
+		worker                   worker.Worker
+	}
+)
+
+// New returns a new instance of DomainDeleter
+func New(params *BootstrapParams) *DomainDeleter {
+	return &DomainDeleter{
+		svcClient:                params.ServiceClient,
+		tallyScope:               params.TallyScope,
+		logger:                   params.Logger.WithTags(tag.ComponentDomainDeletion),
+		domainHandler:            params.DomainHandler,
+		domainManager:            params.DomainManager,
+		visibilityManager:        params.VisibilityManager,
+		historyManager:           params.HistoryManager,
+		taskManager:              params.TaskManager,
+		executionManagerProvider: params.ExecutionManagerProvider,
+		numHistoryShards:         params.NumHistoryShards,
+	}
+}
+
+// Start starts the worker
+func (d *DomainDeleter) Start() error {
+	ctx := context.WithValue(context.Background(), domainDeleterContextKey, d)
+	workerOpts := worker.Options{
+		MetricsScope:              d.tallyScope,
+		BackgroundActivityContext: ctx,
+		Tracer:                    opentracing.GlobalTracer(),
+	}
+	deletionWorker := worker.New(d.svcClient, common.SystemLocalDomainName, TaskListName, workerOpts)
+	deletionWorker.RegisterWorkflowWithOptions(DeletionWorkflow, workflow.RegisterOptions{Name: WorkflowTypeName})
+	deletionWorker.RegisterActivityWithOptions(validateDomainActivity, activity.RegisterOptions{Name: validateDomainActivityName})
+	deletionWorker.RegisterActivityWithOptions(purgeExecutionsActivity, activity.RegisterOptions{Name: purgeExecutionsActivityName})
+	deletionWorker.RegisterActivityWithOptions(purgeTaskListsActivity, activity.RegisterOptions{Name: purgeTaskListsActivityName})
+	deletionWorker.RegisterActivityWithOptions(deleteDomainActivity, activity.RegisterOptions{Name: deleteDomainActivityName})
+	d.worker = deletionWorker
+	return deletionWorker.Start()
+}
+
+// Stop stops the worker
+func (d *DomainDeleter) Stop() {
+	d.worker.Stop()
+}
@@ -0,0 +1,434 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package domaindeletion
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/cadence"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/workflow"
+	"golang.org/x/time/rate"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+type (
+	contextKey string
+)
+
+const (
+	domainDeleterContextKey contextKey = "domainDeleterContext"
+
+	// TaskListName is the task list domain deletion workflows are started on
+	TaskListName = "cadence-sys-domain-deletion-tasklist"
+	// WorkflowTypeName is the workflow type of the domain deletion workflow
+	WorkflowTypeName = "cadence-sys-domain-deletion-workflow"
+	// WorkflowIDPrefix is the prefix of domain deletion workflow ids, the domain name is appended to it
+	WorkflowIDPrefix = "cadence-domain-deletion-"
+	// ProgressQueryType is the query type which returns the DeletionProgress of a deletion workflow
+	ProgressQueryType = "deletion_progress"
+
+	validateDomainActivityName  = "cadence-sys-domain-deletion-validate-activity"
+	purgeExecutionsActivityName = "cadence-sys-domain-deletion-purge-executions-activity"
+	purgeTaskListsActivityName  = "cadence-sys-domain-deletion-purge-tasklists-activity"
+	deleteDomainActivityName    = "cadence-sys-domain-deletion-delete-domain-activity"
+
+	defaultRPS      = 10
+	defaultPageSize = 100
+	// pagesPerActivity bounds the work done by one activity so progress is recorded in workflow history
+	pagesPerActivity = 10
+	// activitiesPerRun bounds the history size of a single deletion workflow run
+	activitiesPerRun = 100
+)
+
+var (
+	errNonRetriable       = errors.New("domain deletion non-retriable error")
+	errDomainNotFound     = errors.New("domain does not exist")
+	errDomainNotDeprecate = errors.New("domain is not deprecated")
+	errOpenWorkflows      = errors.New("domain has open workflows")
+	errGlobalDomain       = errors.New("global domains can't be deleted")
+
+	activityOptions = workflow.ActivityOptions{
+		ScheduleToStartTimeout: 10 * time.Minute,
+		StartToCloseTimeout:    time.Hour,
+		HeartbeatTimeout:       5 * time.Minute,
+		RetryPolicy: &cadence.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    5 * time.Minute,
+			ExpirationInterval: 24 * time.Hour,
+			NonRetriableErrorReasons: []string{
+				"cadenceInternal:Panic",
+				errNonRetriable.Error(),
+				errDomainNotFound.Error(),
+				errDomainNotDeprecate.Error(),
+				errOpenWorkflows.Error(),
+				errGlobalDomain.Error(),
+			},
+		},
+	}
+)
+
+type (
+	// DeletionRequest is the input of the domain deletion workflow
+	DeletionRequest struct {
+		DomainName string
+		// RPS limits the number of closed workflows deleted per second
+		RPS int
+		// PageSize is the number of closed workflows or task lists read at once
+		PageSize int
+	}
+
+	// DeletionProgress is the progress of a domain deletion, it is used to resume the deletion
+	DeletionProgress struct {
+		DomainID string
+		// NextPageToken is the page token of the stage which is in progress
+		NextPageToken []byte
+		// ExecutionsPurged is true once all closed workflows got deleted
+		ExecutionsPurged bool
+		// ExecutionsDeleted is the number of closed workflows whose execution, history and visibility got deleted
+		ExecutionsDeleted int
+		// TaskListsDeleted is the number of task lists which got deleted
+		TaskListsDeleted int
+		// TaskListsPurged is true once all task lists got deleted
+		TaskListsPurged bool
+		// Done is true once the domain record got deleted
+		Done bool
+	}
+
+	// DeletionParams is the input of the domain deletion workflow and activities,
+	// Progress is empty when a deletion is started and carried over between workflow runs
+	DeletionParams struct {
+		Request  DeletionRequest
+		Progress DeletionProgress
+	}
+)
+
+// WorkflowID returns the deletion workflow id of a domain
+func WorkflowID(domainName string) string {
+	return WorkflowIDPrefix + domainName
+}
+
+// DeletionWorkflow deletes a deprecated local domain which has no open workflows. The executions, histories and
+// visibility records of its closed workflows and its task lists are purged first, then the domain record is
+// deleted. Archived histories and visibility records are kept, they outlive the domain by design.
+func DeletionWorkflow(ctx workflow.Context, params DeletionParams) (*DeletionProgress, error) {
+	request, progress := params.Request, params.Progress
+	if err := workflow.SetQueryHandler(ctx, ProgressQueryType, func() (*DeletionProgress, error) {
+		return &progress, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	actCtx := workflow.WithActivityOptions(ctx, activityOptions)
+	if progress.DomainID == "" {
+		if err := workflow.ExecuteActivity(actCtx, validateDomainActivityName, request).Get(ctx, &progress.DomainID); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := 0; i < activitiesPerRun; i++ {
+		var activityName string
+		switch {
+		case !progress.ExecutionsPurged:
+			activityName = purgeExecutionsActivityName
+		case !progress.TaskListsPurged:
+			activityName = purgeTaskListsActivityName
+		default:
+			// new workflows could have been started while closed ones were purged, so the domain is validated again
+			if err := workflow.ExecuteActivity(actCtx, validateDomainActivityName, request).Get(ctx, nil); err != nil {
+				return nil, err
+			}
+			if err := workflow.ExecuteActivity(actCtx, deleteDomainActivityName, request).Get(ctx, nil); err != nil {
+				return nil, err
+			}
+			progress.Done = true
+			return &progress, nil
+		}
+
+		var result DeletionProgress
+		if err := workflow.ExecuteActivity(actCtx, activityName, DeletionParams{
+			Request:  request,
+			Progress: progress,
+		}).Get(ctx, &result); err != nil {
+			return nil, err
+		}
+		progress = result
+	}
+	return nil, workflow.NewContinueAsNewError(ctx, WorkflowTypeName, DeletionParams{
+		Request:  request,
+		Progress: progress,
+	})
+}
+
+// validateDomainActivity checks the domain is a deprecated local domain with no open workflows, it returns the domain id
+func validateDomainActivity(ctx context.Context, request DeletionRequest) (string, error) {
+	deleter := ctx.Value(domainDeleterContextKey).(*DomainDeleter)
+	resp, err := deleter.domainManager.GetDomain(ctx, &persistence.GetDomainRequest{Name: request.DomainName})
+	if err != nil {
+		if _, ok := err.(*types.EntityNotExistsError); ok {
+			return "", cadence.NewCustomError(errDomainNotFound.Error())
+		}
+		return "", err
+	}
+	if resp.IsGlobalDomain {
+		// the deletion is not replicated, fail before any data of the domain is purged
+		return "", cadence.NewCustomError(errGlobalDomain.Error())
+	}
+	if resp.Info.Status != persistence.DomainStatusDeprecated {
+		return "", cadence.NewCustomError(errDomainNotDeprecate.Error())
+	}
+
+	openResp, err := deleter.visibilityManager.ListOpenWorkflowExecutions(ctx, &persistence.ListWorkflowExecutionsRequest{
+		DomainUUID:   resp.Info.ID,
+		Domain:       resp.Info.Name,
+		EarliestTime: 0,
+		LatestTime:   time.Now().UnixNano(),
+		PageSize:     1,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(openResp.Executions) != 0 {
+		return "", cadence.NewCustomError(errOpenWorkflows.Error())
+	}
+	return resp.Info.ID, nil
+}
+
+// purgeExecutionsActivity deletes a bounded number of pages of closed workflows, their history,
+// mutable state and visibility record. Progress is heartbeated after each page so a retried activity
+// resumes from the last page it did not complete.
+func purgeExecutionsActivity(ctx context.Context, params DeletionParams) (DeletionProgress, error) {
+	deleter := ctx.Value(domainDeleterContextKey).(*DomainDeleter)
+	logger := deleter.logger.WithTags(tag.WorkflowDomainName(params.Request.DomainName))
+	progress := recoverProgress(ctx, params)
+	limiter := newLimiter(params.Request)
+
+	for page := 0; page < pagesPerActivity; page++ {
+		resp, err := deleter.visibilityManager.ListClosedWorkflowExecutions(ctx, &persistence.ListWorkflowExecutionsRequest{
+			DomainUUID:    progress.DomainID,
+			Domain:        params.Request.DomainName,
+			EarliestTime:  0,
+			LatestTime:    time.Now().UnixNano(),
+			PageSize:      pageSize(params.Request),
+			NextPageToken: progress.NextPageToken,
+		})
+		if err != nil {
+			logger.Error("failed to list closed workflows", tag.Error(err))
+			return progress, err
+		}
+		for _, execution := range resp.Executions {
+			if err := limiter.Wait(ctx); err != nil {
+				return progress, err
+			}
+			if err := deleter.deleteExecution(ctx, progress.DomainID, params.Request.DomainName, execution); err != nil {
+				logger.Error("failed to delete closed workflow",
+					tag.WorkflowID(execution.GetExecution().GetWorkflowID()),
+					tag.WorkflowRunID(execution.GetExecution().GetRunID()),
+					tag.Error(err))
+				return progress, err
+			}
+			progress.ExecutionsDeleted++
+		}
+		progress.NextPageToken = resp.NextPageToken
+		if len(progress.NextPageToken) == 0 {
+			progress.ExecutionsPurged = true
+			break
+		}
+		activity.RecordHeartbeat(ctx, progress)
+	}
+	return progress, nil
+}
+
+// purgeTaskListsActivity deletes a bounded number of pages of task lists of the domain
+func purgeTaskListsActivity(ctx context.Context, params DeletionParams) (DeletionProgress, error) {
+	deleter := ctx.Value(domainDeleterContextKey).(*DomainDeleter)
+	logger := deleter.logger.WithTags(tag.WorkflowDomainName(params.Request.DomainName))
+	progress := recoverProgress(ctx, params)
+	limiter := newLimiter(params.Request)
+
+	for page := 0; page < pagesPerActivity; page++ {
+		resp, err := deleter.taskManager.ListTaskList(ctx, &persistence.ListTaskListRequest{
+			PageSize:  pageSize(params.Request),
+			PageToken: progress.NextPageToken,
+		})
+		if err != nil {
+			logger.Error("failed to list task lists", tag.Error(err))
+			return progress, err
+		}
+		for _, taskList := range resp.Items {
+			if taskList.DomainID != progress.DomainID {
+				continue
+			}
+			if err := limiter.Wait(ctx); err != nil {
+				return progress, err
+			}
+			if err := deleter.taskManager.DeleteTaskList(ctx, &persistence.DeleteTaskListRequest{
+				DomainID:     taskList.DomainID,
+				DomainName:   params.Request.DomainName,
+				TaskListName: taskList.Name,
+				TaskListType: taskList.TaskType,
+				RangeID:      taskList.RangeID,
+			}); err != nil {
+				if _, ok := err.(*persistence.ConditionFailedError); !ok {
+					logger.Error("failed to delete task list", tag.WorkflowTaskListName(taskList.Name), tag.Error(err))
+					return progress, err
+				}
+				// the task list is still owned by matching, it gets deleted once it is idle by the task list scavenger
+				logger.Warn("task list is still in use, skip deleting it", tag.WorkflowTaskListName(taskList.Name))
+				continue
+			}
+			progress.TaskListsDeleted++
+		}
+		progress.NextPageToken = resp.NextPageToken
+		if len(progress.NextPageToken) == 0 {
+			progress.TaskListsPurged = true
+			break
+		}
+		activity.RecordHeartbeat(ctx, progress)
+	}
+	return progress, nil
+}
+
+// deleteDomainActivity deletes the domain record, which also removes its archival config
+func deleteDomainActivity(ctx context.Context, request DeletionRequest) error {
+	deleter := ctx.Value(domainDeleterContextKey).(*DomainDeleter)
+	err := deleter.domainHandler.DeleteDomain(ctx, &types.DeleteDomainRequest{Name: request.DomainName})
+	if err != nil {
+		if _, ok := err.(*types.EntityNotExistsError); ok {
+			// the domain got deleted by a previous attempt
+			return nil
+		}
+		if _, ok := err.(*types.BadRequestError); ok {
+			return cadence.NewCustomError(errNonRetriable.Error(), err.Error())
+		}
+		return err
+	}
+	deleter.logger.Info("domain deleted", tag.WorkflowDomainName(request.DomainName))
+	return nil
+}
+
+// deleteExecution deletes the history, mutable state and visibility record of a closed workflow,
+// each of them may already be gone if the workflow reached the end of retention or a previous attempt failed
+func (d *DomainDeleter) deleteExecution(
+	ctx context.Context,
+	domainID string,
+	domainName string,
+	execution *types.WorkflowExecutionInfo,
+) error {
+	workflowID := execution.GetExecution().GetWorkflowID()
+	runID := execution.GetExecution().GetRunID()
+	shardID := common.WorkflowIDToHistoryShard(workflowID, d.numHistoryShards)
+	executionManager, err := d.executionManagerProvider.GetExecutionManager(shardID)
+	if err != nil {
+		return err
+	}
+
+	resp, err := executionManager.GetWorkflowExecution(ctx, &persistence.GetWorkflowExecutionRequest{
+		DomainID: domainID,
+		Execution: types.WorkflowExecution{
+			WorkflowID: workflowID,
+			RunID:      runID,
+		},
+		DomainName: domainName,
+	})
+	switch err.(type) {
+	case nil:
+		branchTokens := [][]byte{resp.State.ExecutionInfo.BranchToken}
+		if resp.State.VersionHistories != nil {
+			branchTokens = nil
+			for _, versionHistory := range resp.State.VersionHistories.Histories {
+				branchTokens = append(branchTokens, versionHistory.GetBranchToken())
+			}
+		}
+		for _, branchToken := range branchTokens {
+			if len(branchToken) == 0 {
+				continue
+			}
+			if err := d.historyManager.DeleteHistoryBranch(ctx, &persistence.DeleteHistoryBranchRequest{
+				BranchToken: branchToken,
+				ShardID:     common.IntPtr(shardID),
+				DomainName:  domainName,
+			}); err != nil {
+				return err
+			}
+		}
+		if err := executionManager.DeleteWorkflowExecution(ctx, &persistence.DeleteWorkflowExecutionRequest{
+			DomainID:   domainID,
+			WorkflowID: workflowID,
+			RunID:      runID,
+			DomainName: domainName,
+		}); err != nil {
+			return err
+		}
+		if err := executionManager.DeleteCurrentWorkflowExecution(ctx, &persistence.DeleteCurrentWorkflowExecutionRequest{
+			DomainID:   domainID,
+			WorkflowID: workflowID,
+			RunID:      runID,
+			DomainName: domainName,
+		}); err != nil {
+			return err
+		}
+	case *types.EntityNotExistsError:
+		// mutable state and history are already deleted
+	default:
+		return err
+	}
+
+	return d.visibilityManager.DeleteWorkflowExecution(ctx, &persistence.VisibilityDeleteWorkflowExecutionRequest{
+		DomainID:   domainID,
+		Domain:     domainName,
+		WorkflowID: workflowID,
+		RunID:      runID,
+	})
+}
+
+func recoverProgress(ctx context.Context, params DeletionParams) DeletionProgress {
+	progress := params.Progress
+	if activity.HasHeartbeatDetails(ctx) {
+		var heartbeat DeletionProgress
+		if err := activity.GetHeartbeatDetails(ctx, &heartbeat); err == nil {
+			progress = heartbeat
+		}
+	}
+	return progress
+}
+
+func newLimiter(request DeletionRequest) *rate.Limiter {
+	rps := request.RPS
+	if rps <= 0 {
+		rps = defaultRPS
+	}
+	return rate.NewLimiter(rate.Limit(rps), rps)
+}
+
+func pageSize(request DeletionRequest) int {
+	if request.PageSize <= 0 {
+		return defaultPageSize
+	}
+	return request.PageSize
+}
@@ -0,0 +1,311 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package domaindeletion
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/cadence"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/testsuite"
+	"go.uber.org/cadence/worker"
+	"go.uber.org/cadence/workflow"
+
+	"github.com/uber/cadence/common/domain"
+	"github.com/uber/cadence/common/log/loggerimpl"
+	"github.com/uber/cadence/common/mocks"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+)
+
+const (
+	testDomainID   = "test-domain-id"
+	testDomainName = "test-domain-name"
+)
+
+type (
+	workflowTestSuite struct {
+		suite.Suite
+		testsuite.WorkflowTestSuite
+
+		controller        *gomock.Controller
+		domainHandler     *domain.MockHandler
+		domainManager     *mocks.MetadataManager
+		visibilityManager *mocks.VisibilityManager
+		historyManager    *mocks.HistoryV2Manager
+		taskManager       *mocks.TaskManager
+		executionManager  *mocks.ExecutionManager
+		deleter           *DomainDeleter
+	}
+
+	testExecutionManagerProvider struct {
+		executionManager persistence.ExecutionManager
+	}
+)
+
+func TestWorkflowTestSuite(t *testing.T) {
+	suite.Run(t, new(workflowTestSuite))
+}
+
+func (p *testExecutionManagerProvider) GetExecutionManager(int) (persistence.ExecutionManager, error) {
+	return p.executionManager, nil
+}
+
+func (s *workflowTestSuite) SetupTest() {
+	s.controller = gomock.NewController(s.T())
+	s.domainHandler = domain.NewMockHandler(s.controller)
+	s.domainManager = &mocks.MetadataManager{}
+	s.visibilityManager = &mocks.VisibilityManager{}
+	s.historyManager = &mocks.HistoryV2Manager{}
+	s.taskManager = &mocks.TaskManager{}
+	s.executionManager = &mocks.ExecutionManager{}
+	s.deleter = New(&BootstrapParams{
+		Logger:                   loggerimpl.NewNopLogger(),
+		DomainHandler:            s.domainHandler,
+		DomainManager:            s.domainManager,
+		VisibilityManager:        s.visibilityManager,
+		HistoryManager:           s.historyManager,
+		TaskManager:              s.taskManager,
+		ExecutionManagerProvider: &testExecutionManagerProvider{executionManager: s.executionManager},
+		NumHistoryShards:         4,
+	})
+}
+
+func (s *workflowTestSuite) TearDownTest() {
+	s.controller.Finish()
+	s.domainManager.AssertExpectations(s.T())
+	s.visibilityManager.AssertExpectations(s.T())
+	s.historyManager.AssertExpectations(s.T())
+	s.taskManager.AssertExpectations(s.T())
+	s.executionManager.AssertExpectations(s.T())
+}
+
+func (s *workflowTestSuite) TestWorkflow_Success() {
+	env := s.NewTestWorkflowEnvironment()
+	s.registerWorkflowAndActivities(env)
+	request := DeletionRequest{DomainName: testDomainName}
+	env.OnActivity(validateDomainActivityName, mock.Anything, request).Return(testDomainID, nil).Twice()
+	env.OnActivity(purgeExecutionsActivityName, mock.Anything, mock.Anything).Return(func(_ context.Context, params DeletionParams) (DeletionProgress, error) {
+		s.Equal(testDomainID, params.Progress.DomainID)
+		progress := params.Progress
+		progress.ExecutionsDeleted += 10
+		progress.ExecutionsPurged = progress.ExecutionsDeleted == 20
+		return progress, nil
+	}).Twice()
+	env.OnActivity(purgeTaskListsActivityName, mock.Anything, mock.Anything).Return(func(_ context.Context, params DeletionParams) (DeletionProgress, error) {
+		s.True(params.Progress.ExecutionsPurged)
+		progress := params.Progress
+		progress.TaskListsDeleted = 3
+		progress.TaskListsPurged = true
+		return progress, nil
+	}).Once()
+	env.OnActivity(deleteDomainActivityName, mock.Anything, request).Return(nil).Once()
+
+	env.ExecuteWorkflow(WorkflowTypeName, DeletionParams{Request: request})
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var progress DeletionProgress
+	s.NoError(env.GetWorkflowResult(&progress))
+	s.Equal(DeletionProgress{
+		DomainID:          testDomainID,
+		ExecutionsPurged:  true,
+		ExecutionsDeleted: 20,
+		TaskListsPurged:   true,
+		TaskListsDeleted:  3,
+		Done:              true,
+	}, progress)
+	env.AssertExpectations(s.T())
+}
+
+func (s *workflowTestSuite) TestWorkflow_ValidationFailed() {
+	env := s.NewTestWorkflowEnvironment()
+	s.registerWorkflowAndActivities(env)
+	env.OnActivity(validateDomainActivityName, mock.Anything, mock.Anything).Return("", cadence.NewCustomError(errOpenWorkflows.Error())).Once()
+
+	env.ExecuteWorkflow(WorkflowTypeName, DeletionParams{Request: DeletionRequest{DomainName: testDomainName}})
+	s.True(env.IsWorkflowCompleted())
+	s.Error(env.GetWorkflowError())
+	s.Contains(env.GetWorkflowError().Error(), errOpenWorkflows.Error())
+	env.AssertExpectations(s.T())
+}
+
+func (s *workflowTestSuite) TestValidateDomainActivity() {
+	env := s.newActivityEnvironment()
+	s.domainManager.On("GetDomain", mock.Anything, &persistence.GetDomainRequest{Name: testDomainName}).
+		Return(s.domainResponse(persistence.DomainStatusRegistered), nil).Once()
+	_, err := env.ExecuteActivity(validateDomainActivityName, DeletionRequest{DomainName: testDomainName})
+	s.Contains(err.Error(), errDomainNotDeprecate.Error())
+
+	globalDomain := s.domainResponse(persistence.DomainStatusDeprecated)
+	globalDomain.IsGlobalDomain = true
+	s.domainManager.On("GetDomain", mock.Anything, &persistence.GetDomainRequest{Name: testDomainName}).
+		Return(globalDomain, nil).Once()
+	_, err = env.ExecuteActivity(validateDomainActivityName, DeletionRequest{DomainName: testDomainName})
+	s.Contains(err.Error(), errGlobalDomain.Error())
+
+	s.domainManager.On("GetDomain", mock.Anything, &persistence.GetDomainRequest{Name: testDomainName}).
+		Return(s.domainResponse(persistence.DomainStatusDeprecated), nil).Twice()
+	s.visibilityManager.On("ListOpenWorkflowExecutions", mock.Anything, mock.Anything).Return(&persistence.ListWorkflowExecutionsResponse{
+		Executions: []*types.WorkflowExecutionInfo{{}},
+	}, nil).Once()
+	_, err = env.ExecuteActivity(validateDomainActivityName, DeletionRequest{DomainName: testDomainName})
+	s.Contains(err.Error(), errOpenWorkflows.Error())
+
+	s.visibilityManager.On("ListOpenWorkflowExecutions", mock.Anything, mock.Anything).Return(&persistence.ListWorkflowExecutionsResponse{}, nil).Once()
+	result, err := env.ExecuteActivity(validateDomainActivityName, DeletionRequest{DomainName: testDomainName})
+	s.NoError(err)
+	var domainID string
+	s.NoError(result.Get(&domainID))
+	s.Equal(testDomainID, domainID)
+}
+
+func (s *workflowTestSuite) TestPurgeExecutionsActivity() {
+	env := s.newActivityEnvironment()
+	executions := []*types.WorkflowExecutionInfo{
+		{Execution: &types.WorkflowExecution{WorkflowID: "workflow-1", RunID: "run-1"}},
+		{Execution: &types.WorkflowExecution{WorkflowID: "workflow-2", RunID: "run-2"}},
+	}
+	s.visibilityManager.On("ListClosedWorkflowExecutions", mock.Anything, mock.MatchedBy(func(request *persistence.ListWorkflowExecutionsRequest) bool {
+		return request.DomainUUID == testDomainID && request.NextPageToken == nil
+	})).Return(&persistence.ListWorkflowExecutionsResponse{
+		Executions:    executions[:1],
+		NextPageToken: []byte("token"),
+	}, nil).Once()
+	s.visibilityManager.On("ListClosedWorkflowExecutions", mock.Anything, mock.MatchedBy(func(request *persistence.ListWorkflowExecutionsRequest) bool {
+		return string(request.NextPageToken) == "token"
+	})).Return(&persistence.ListWorkflowExecutionsResponse{
+		Executions: executions[1:],
+	}, nil).Once()
+
+	// the first workflow still has mutable state and history
+	s.executionManager.On("GetWorkflowExecution", mock.Anything, mock.MatchedBy(func(request *persistence.GetWorkflowExecutionRequest) bool {
+		return request.Execution.RunID == "run-1"
+	})).Return(&persistence.GetWorkflowExecutionResponse{
+		State: &persistence.WorkflowMutableState{
+			ExecutionInfo:    &persistence.WorkflowExecutionInfo{},
+			VersionHistories: persistence.NewVersionHistories(persistence.NewVersionHistory([]byte("branch-token"), nil)),
+		},
+	}, nil).Once()
+	s.historyManager.On("DeleteHistoryBranch", mock.Anything, mock.MatchedBy(func(request *persistence.DeleteHistoryBranchRequest) bool {
+		return string(request.BranchToken) == "branch-token"
+	})).Return(nil).Once()
+	s.executionManager.On("DeleteWorkflowExecution", mock.Anything, mock.Anything).Return(nil).Once()
+	s.executionManager.On("DeleteCurrentWorkflowExecution", mock.Anything, mock.Anything).Return(nil).Once()
+	// the second one only has a visibility record left
+	s.executionManager.On("GetWorkflowExecution", mock.Anything, mock.MatchedBy(func(request *persistence.GetWorkflowExecutionRequest) bool {
+		return request.Execution.RunID == "run-2"
+	})).Return(nil, &types.EntityNotExistsError{}).Once()
+	s.visibilityManager.On("DeleteWorkflowExecution", mock.Anything, mock.Anything).Return(nil).Twice()
+
+	result, err := env.ExecuteActivity(purgeExecutionsActivityName, DeletionParams{
+		Request:  DeletionRequest{DomainName: testDomainName, RPS: 1000},
+		Progress: DeletionProgress{DomainID: testDomainID},
+	})
+	s.NoError(err)
+	var progress DeletionProgress
+	s.NoError(result.Get(&progress))
+	s.Equal(DeletionProgress{
+		DomainID:          testDomainID,
+		ExecutionsPurged:  true,
+		ExecutionsDeleted: 2,
+	}, progress)
+}
+
+func (s *workflowTestSuite) TestPurgeTaskListsActivity() {
+	env := s.newActivityEnvironment()
+	s.taskManager.On("ListTaskList", mock.Anything, mock.Anything).Return(&persistence.ListTaskListResponse{
+		Items: []persistence.TaskListInfo{
+			{DomainID: testDomainID, Name: "task-list-1"},
+			{DomainID: "another-domain-id", Name: "task-list-2"},
+			{DomainID: testDomainID, Name: "task-list-3"},
+		},
+	}, nil).Once()
+	s.taskManager.On("DeleteTaskList", mock.Anything, mock.MatchedBy(func(request *persistence.DeleteTaskListRequest) bool {
+		return request.TaskListName == "task-list-1"
+	})).Return(nil).Once()
+	s.taskManager.On("DeleteTaskList", mock.Anything, mock.MatchedBy(func(request *persistence.DeleteTaskListRequest) bool {
+		return request.TaskListName == "task-list-3"
+	})).Return(&persistence.ConditionFailedError{}).Once()
+
+	result, err := env.ExecuteActivity(purgeTaskListsActivityName, DeletionParams{
+		Request:  DeletionRequest{DomainName: testDomainName, RPS: 1000},
+		Progress: DeletionProgress{DomainID: testDomainID, ExecutionsPurged: true},
+	})
+	s.NoError(err)
+	var progress DeletionProgress
+	s.NoError(result.Get(&progress))
+	s.Equal(DeletionProgress{
+		DomainID:         testDomainID,
+		ExecutionsPurged: true,
+		TaskListsPurged:  true,
+		TaskListsDeleted: 1,
+	}, progress)
+}
+
+func (s *workflowTestSuite) TestDeleteDomainActivity() {
+	env := s.newActivityEnvironment()
+	request := DeletionRequest{DomainName: testDomainName}
+	s.domainHandler.EXPECT().DeleteDomain(gomock.Any(), &types.DeleteDomainRequest{Name: testDomainName}).Return(nil)
+	_, err := env.ExecuteActivity(deleteDomainActivityName, request)
+	s.NoError(err)
+
+	s.domainHandler.EXPECT().DeleteDomain(gomock.Any(), gomock.Any()).Return(&types.EntityNotExistsError{})
+	_, err = env.ExecuteActivity(deleteDomainActivityName, request)
+	s.NoError(err)
+
+	s.domainHandler.EXPECT().DeleteDomain(gomock.Any(), gomock.Any()).Return(&types.BadRequestError{Message: "not deprecated"})
+	_, err = env.ExecuteActivity(deleteDomainActivityName, request)
+	s.Contains(err.Error(), errNonRetriable.Error())
+}
+
+func (s *workflowTestSuite) registerWorkflowAndActivities(env *testsuite.TestWorkflowEnvironment) {
+	env.RegisterWorkflowWithOptions(DeletionWorkflow, workflow.RegisterOptions{Name: WorkflowTypeName})
+	env.RegisterActivityWithOptions(validateDomainActivity, activity.RegisterOptions{Name: validateDomainActivityName})
+	env.RegisterActivityWithOptions(purgeExecutionsActivity, activity.RegisterOptions{Name: purgeExecutionsActivityName})
+	env.RegisterActivityWithOptions(purgeTaskListsActivity, activity.RegisterOptions{Name: purgeTaskListsActivityName})
+	env.RegisterActivityWithOptions(deleteDomainActivity, activity.RegisterOptions{Name: deleteDomainActivityName})
+}
+
+func (s *workflowTestSuite) newActivityEnvironment() *testsuite.TestActivityEnvironment {
+	env := s.NewTestActivityEnvironment()
+	env.SetWorkerOptions(worker.Options{
+		BackgroundActivityContext: context.WithValue(context.Background(), domainDeleterContextKey, s.deleter),
+	})
+	env.RegisterActivityWithOptions(validateDomainActivity, activity.RegisterOptions{Name: validateDomainActivityName})
+	env.RegisterActivityWithOptions(purgeExecutionsActivity, activity.RegisterOptions{Name: purgeExecutionsActivityName})
+	env.RegisterActivityWithOptions(purgeTaskListsActivity, activity.RegisterOptions{Name: purgeTaskListsActivityName})
+	env.RegisterActivityWithOptions(deleteDomainActivity, activity.RegisterOptions{Name: deleteDomainActivityName})
+	return env
+}
+
+func (s *workflowTestSuite) domainResponse(status int) *persistence.GetDomainResponse {
+	return &persistence.GetDomainResponse{
+		Info: &persistence.DomainInfo{
+			ID:     testDomainID,
+			Name:   testDomainName,
+			Status: status,
+		},
+	}
+}
@@ -74,6 +74,10 @@ type (
 		ESAnalyzerBufferWaitTime                 dynamicconfig.DurationPropertyFnWithWorkflowTypeFilter
 		ESAnalyzerMinNumWorkflowsForAvg          dynamicconfig.IntPropertyFnWithWorkflowTypeFilter
 		ESAnalyzerWorkflowDurationWarnThresholds dynamicconfig.StringPropertyFn
+		ESAnalyzerStuckWorkflowMitigation        dynamicconfig.StringPropertyFnWithWorkflowTypeFilter
+		ESAnalyzerStuckWorkflowSignalName        dynamicconfig.StringPropertyFnWithWorkflowTypeFilter
+		ESAnalyzerMitigationDomainAllow          dynamicconfig.BoolPropertyFnWithDomainFilter
+		ESAnalyzerMitigationDryRun               dynamicconfig.BoolPropertyFnWithDomainFilter
 	}
 )
 
@@ -36,18 +36,22 @@ import (
 	"go.uber.org/cadence/worker"
 	"go.uber.org/cadence/workflow"
 
+	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/dynamicconfig"
 	"github.com/uber/cadence/common/elasticsearch"
 	esMocks "github.com/uber/cadence/common/elasticsearch/mocks"
 
 	"github.com/uber/cadence/client"
 	"github.com/uber/cadence/client/admin"
+	"github.com/uber/cadence/client/frontend"
+	"github.com/uber/cadence/common/blobstore"
 	"github.com/uber/cadence/common/cache"
 	"github.com/uber/cadence/common/cluster"
 	"github.com/uber/cadence/common/log"
 	"github.com/uber/cadence/common/metrics"
 	"github.com/uber/cadence/common/metrics/mocks"
 	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/reconciliation/store"
 	"github.com/uber/cadence/common/types"
 	"github.com/uber/cadence/service/history/resource"
 )
@@ -60,6 +64,7 @@ type esanalyzerWorkflowTestSuite struct {
 	controller         *gomock.Controller
 	resource           *resource.Test
 	mockAdminClient    *admin.MockClient
+	mockFrontendClient *frontend.MockClient
 	mockDomainCache    *cache.MockDomainCache
 	clientBean         *client.MockBean
 	logger             *log.MockLogger
@@ -111,6 +116,10 @@ func (s *esanalyzerWorkflowTestSuite) SetupTest() {
 		ESAnalyzerBufferWaitTime:                 dynamicconfig.GetDurationPropertyFilteredByWorkflowType(time.Minute * 30),
 		ESAnalyzerMinNumWorkflowsForAvg:          dynamicconfig.GetIntPropertyFilteredByWorkflowType(100),
 		ESAnalyzerWorkflowDurationWarnThresholds: dynamicconfig.GetStringPropertyFn(""),
+		ESAnalyzerStuckWorkflowMitigation:        dynamicconfig.GetStringPropertyFilteredByWorkflowType(string(MitigationRefresh)),
+		ESAnalyzerStuckWorkflowSignalName:        dynamicconfig.GetStringPropertyFilteredByWorkflowType("test-signal"),
+		ESAnalyzerMitigationDomainAllow:          dynamicconfig.GetBoolPropertyFnFilteredByDomain(false),
+		ESAnalyzerMitigationDryRun:               dynamicconfig.GetBoolPropertyFnFilteredByDomain(false),
 	}
 
 	s.activityEnv = s.NewTestActivityEnvironment()
@@ -119,6 +128,7 @@ func (s *esanalyzerWorkflowTestSuite) SetupTest() {
 	s.mockDomainCache = cache.NewMockDomainCache(s.controller)
 	s.resource = resource.NewTest(s.controller, metrics.Worker)
 	s.mockAdminClient = admin.NewMockClient(s.controller)
+	s.mockFrontendClient = frontend.NewMockClient(s.controller)
 	s.clientBean = client.NewMockBean(s.controller)
 	s.logger = &log.MockLogger{}
 	s.mockMetricClient = &mocks.Client{}
@@ -131,6 +141,7 @@ func (s *esanalyzerWorkflowTestSuite) SetupTest() {
 	s.mockDomainCache.EXPECT().GetDomainByID(s.DomainID).Return(activeDomainCache, nil).AnyTimes()
 	s.mockDomainCache.EXPECT().GetDomain(s.DomainName).Return(activeDomainCache, nil).AnyTimes()
 	s.clientBean.EXPECT().GetRemoteAdminClient(cluster.TestCurrentClusterName).Return(s.mockAdminClient).AnyTimes()
+	s.clientBean.EXPECT().GetRemoteFrontendClient(cluster.TestCurrentClusterName).Return(s.mockFrontendClient).AnyTimes()
 
 	// SET UP ANALYZER
 	s.analyzer = &Analyzer{
@@ -140,6 +151,7 @@ func (s *esanalyzerWorkflowTestSuite) SetupTest() {
 		logger:             s.logger,
 		scopedMetricClient: getScopedMetricsClient(s.mockMetricClient),
 		esClient:           s.mockESClient,
+		resource:           s.resource,
 		config:             &s.config,
 	}
 	s.activityEnv.SetTestTimeout(time.Second * 5)
@@ -169,6 +181,10 @@ func (s *esanalyzerWorkflowTestSuite) SetupTest() {
 		s.workflow.getLongRunCheckEntries,
 		activity.RegisterOptions{Name: getLongRunCheckEntriesActivity},
 	)
+	s.workflowEnv.RegisterActivityWithOptions(
+		s.workflow.mitigateWorkflows,
+		activity.RegisterOptions{Name: mitigateWorkflowsActivity},
+	)
 
 	s.activityEnv.RegisterActivityWithOptions(
 		s.workflow.getWorkflowTypes,
@@ -188,6 +204,10 @@ func (s *esanalyzerWorkflowTestSuite) SetupTest() {
 		s.workflow.getLongRunCheckEntries,
 		activity.RegisterOptions{Name: getLongRunCheckEntriesActivity},
 	)
+	s.activityEnv.RegisterActivityWithOptions(
+		s.workflow.mitigateWorkflows,
+		activity.RegisterOptions{Name: mitigateWorkflowsActivity},
+	)
 }
 
 func (s *esanalyzerWorkflowTestSuite) TearDownTest() {
@@ -231,11 +251,73 @@ func (s *esanalyzerWorkflowTestSuite) TestExecuteWorkflow() {
 	s.workflowEnv.OnActivity(findLongRunningWorkflowsActivity, mock.Anything, longRunningWorkflows[0]).
 		Return(workflows, nil).Times(1)
 
-	s.workflowEnv.OnActivity(refreshStuckWorkflowsActivity, mock.Anything, workflows).Return(nil).Times(2)
+	s.workflowEnv.OnActivity(mitigateWorkflowsActivity, mock.Anything, MitigateWorkflowsParams{
+		WorkflowType: s.WorkflowType,
+		Workflows:    workflows,
+	}).Return(&MitigateWorkflowsResult{}, nil).Times(1)
+	s.workflowEnv.OnActivity(mitigateWorkflowsActivity, mock.Anything, MitigateWorkflowsParams{
+		WorkflowType: s.WorkflowType,
+		Workflows:    workflows,
+		Mitigation:   MitigationRefresh,
+	}).Return(&MitigateWorkflowsResult{}, nil).Times(1)
+
+	s.workflowEnv.ExecuteWorkflow(esanalyzerWFTypeName)
+	err := s.workflowEnv.GetWorkflowResult(nil)
+	s.NoError(err)
+}
+
+func (s *esanalyzerWorkflowTestSuite) TestExecuteWorkflowBeforeMitigations() {
+	s.workflowEnv.OnGetVersion(mitigateWorkflowsChangeID, workflow.DefaultVersion, mitigateWorkflowsVersion).
+		Return(workflow.DefaultVersion)
+
+	workflowTypeInfos := []WorkflowTypeInfo{
+		{
+			Name:         s.WorkflowType,
+			NumWorkflows: 564,
+			Duration:     Duration{AvgExecTimeNanoseconds: float64(123 * time.Second)},
+		},
+	}
+	s.workflowEnv.OnActivity(getWorkflowTypesActivity, mock.Anything).
+		Return(workflowTypeInfos, nil).Times(1)
+
+	workflows := []WorkflowInfo{
+		{
+			DomainID:   s.DomainID,
+			WorkflowID: s.WorkflowID,
+			RunID:      s.RunID,
+		},
+	}
+	s.workflowEnv.OnActivity(findStuckWorkflowsActivity, mock.Anything, workflowTypeInfos[0]).
+		Return(workflows, nil).Times(1)
+
+	longRunningWorkflows := []LongRunCheckEntry{
+		{
+			DomainName:   s.DomainName,
+			WorkflowType: s.WorkflowType,
+			Threshold:    time.Hour,
+			Refresh:      true,
+		},
+		{
+			DomainName:   s.DomainName,
+			WorkflowType: s.WorkflowType,
+			Threshold:    2 * time.Hour,
+			Mitigation:   MitigationTerminate,
+		},
+	}
+	s.workflowEnv.OnActivity(getLongRunCheckEntriesActivity, mock.Anything).
+		Return(longRunningWorkflows, nil).Times(1)
+	s.workflowEnv.OnActivity(findLongRunningWorkflowsActivity, mock.Anything, mock.Anything).
+		Return(workflows, nil).Times(2)
+
+	// runs started before mitigations were added only refresh, once for stuck workflows and
+	// once for the entry with Refresh set
+	s.workflowEnv.OnActivity(refreshStuckWorkflowsActivity, mock.Anything, workflows).
+		Return(nil).Times(2)
 
 	s.workflowEnv.ExecuteWorkflow(esanalyzerWFTypeName)
 	err := s.workflowEnv.GetWorkflowResult(nil)
 	s.NoError(err)
+	s.workflowEnv.AssertExpectations(s.T())
 }
 
 func (s *esanalyzerWorkflowTestSuite) TestExecuteWorkflowMultipleWorkflowTypes() {
@@ -275,8 +357,14 @@ func (s *esanalyzerWorkflowTestSuite) TestExecuteWorkflowMultipleWorkflowTypes()
 	s.workflowEnv.OnActivity(getLongRunCheckEntriesActivity, mock.Anything).
 		Return(nil, nil).Times(1)
 
-	s.workflowEnv.OnActivity(refreshStuckWorkflowsActivity, mock.Anything, workflows1).Return(nil).Times(1)
-	s.workflowEnv.OnActivity(refreshStuckWorkflowsActivity, mock.Anything, workflows2).Return(nil).Times(1)
+	s.workflowEnv.OnActivity(mitigateWorkflowsActivity, mock.Anything, MitigateWorkflowsParams{
+		WorkflowType: s.WorkflowType,
+		Workflows:    workflows1,
+	}).Return(&MitigateWorkflowsResult{}, nil).Times(1)
+	s.workflowEnv.OnActivity(mitigateWorkflowsActivity, mock.Anything, MitigateWorkflowsParams{
+		WorkflowType: "another-workflow-type",
+		Workflows:    workflows2,
+	}).Return(&MitigateWorkflowsResult{}, nil).Times(1)
 
 	s.workflowEnv.ExecuteWorkflow(esanalyzerWFTypeName)
 	err := s.workflowEnv.GetWorkflowResult(nil)
@@ -613,3 +701,220 @@ func (s *esanalyzerWorkflowTestSuite) TestGetWorkflowTypesFromConfig() {
 	s.Equal(2, len(results))
 	s.Equal(workflowTypes, results)
 }
+
+func (s *esanalyzerWorkflowTestSuite) TestMitigateWorkflowsRefreshFromConfig() {
+	workflows := []WorkflowInfo{{DomainID: s.DomainID, WorkflowID: s.WorkflowID, RunID: s.RunID}}
+
+	s.mockAdminClient.EXPECT().RefreshWorkflowTasks(gomock.Any(), &types.RefreshWorkflowTasksRequest{
+		Domain:    s.DomainName,
+		Execution: &types.WorkflowExecution{WorkflowID: s.WorkflowID, RunID: s.RunID},
+	}).Return(nil).Times(1)
+	s.logger.On("Info", "Mitigated workflow", mock.Anything).Return().Once()
+	s.scopedMetricClient.On("IncCounter", metrics.ESAnalyzerNumWorkflowsMitigated).Return().Once()
+
+	result := s.executeMitigateWorkflows(MitigateWorkflowsParams{WorkflowType: s.WorkflowType, Workflows: workflows})
+	s.Equal(MitigationRefresh, result.Mitigation)
+	s.Equal(1, result.Mitigated)
+	s.Equal(0, result.Failed)
+	s.Nil(result.ReportKeys)
+}
+
+func (s *esanalyzerWorkflowTestSuite) TestMitigateWorkflowsTerminateNotAllowedIsReported() {
+	workflows := []WorkflowInfo{{DomainID: s.DomainID, WorkflowID: s.WorkflowID, RunID: s.RunID}}
+
+	s.resource.BlobstoreClient.On("Put", mock.Anything, mock.Anything).Return(&blobstore.PutResponse{}, nil).Once()
+	s.logger.On("Warn", "Domain is not allowed to be mitigated, falling back to report", mock.Anything).Return().Once()
+	s.logger.On("Info", "Reported workflows to mitigate", mock.Anything).Return().Once()
+	s.scopedMetricClient.On("AddCounter", metrics.ESAnalyzerNumWorkflowsReported, int64(1)).Return().Once()
+
+	result := s.executeMitigateWorkflows(MitigateWorkflowsParams{
+		WorkflowType: s.WorkflowType,
+		Workflows:    workflows,
+		Mitigation:   MitigationTerminate,
+	})
+	s.Equal(MitigationReport, result.Mitigation)
+	s.Equal(0, result.Mitigated)
+	s.NotNil(result.ReportKeys)
+	s.Equal(store.CorruptedExtension, result.ReportKeys.Extension)
+}
+
+func (s *esanalyzerWorkflowTestSuite) TestMitigateWorkflowsDryRun() {
+	s.config.ESAnalyzerMitigationDomainAllow = dynamicconfig.GetBoolPropertyFnFilteredByDomain(true)
+	s.config.ESAnalyzerMitigationDryRun = dynamicconfig.GetBoolPropertyFnFilteredByDomain(true)
+	workflows := []WorkflowInfo{
+		{DomainID: s.DomainID, WorkflowID: s.WorkflowID, RunID: s.RunID},
+		{DomainID: s.DomainID, WorkflowID: "workflow2", RunID: "run2"},
+	}
+
+	s.resource.BlobstoreClient.On("Put", mock.Anything, mock.Anything).Return(&blobstore.PutResponse{}, nil).Once()
+	s.logger.On("Info", "Reported workflows to mitigate", mock.Anything).Return().Once()
+	s.scopedMetricClient.On("AddCounter", metrics.ESAnalyzerNumWorkflowsReported, int64(2)).Return().Once()
+
+	result := s.executeMitigateWorkflows(MitigateWorkflowsParams{
+		WorkflowType: s.WorkflowType,
+		Workflows:    workflows,
+		Mitigation:   MitigationSignal,
+	})
+	s.Equal(MitigationSignal, result.Mitigation)
+	s.True(result.DryRun)
+	s.Equal(0, result.Mitigated)
+	s.NotNil(result.ReportKeys)
+}
+
+func (s *esanalyzerWorkflowTestSuite) TestMitigateWorkflowsSignal() {
+	s.config.ESAnalyzerMitigationDomainAllow = dynamicconfig.GetBoolPropertyFnFilteredByDomain(true)
+	workflows := []WorkflowInfo{{DomainID: s.DomainID, WorkflowID: s.WorkflowID, RunID: s.RunID}}
+
+	s.mockFrontendClient.EXPECT().SignalWorkflowExecution(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *types.SignalWorkflowExecutionRequest, option ...yarpc.CallOption) error {
+			s.Equal(s.DomainName, request.Domain)
+			s.Equal("test-signal", request.SignalName)
+			s.Equal(s.WorkflowID, request.WorkflowExecution.WorkflowID)
+			return nil
+		}).Times(1)
+	s.logger.On("Info", "Mitigated workflow", mock.Anything).Return().Once()
+	s.scopedMetricClient.On("IncCounter", metrics.ESAnalyzerNumWorkflowsMitigated).Return().Once()
+
+	result := s.executeMitigateWorkflows(MitigateWorkflowsParams{
+		WorkflowType: s.WorkflowType,
+		Workflows:    workflows,
+		Mitigation:   MitigationSignal,
+	})
+	s.Equal(1, result.Mitigated)
+}
+
+func (s *esanalyzerWorkflowTestSuite) TestMitigateWorkflowsReset() {
+	s.config.ESAnalyzerMitigationDomainAllow = dynamicconfig.GetBoolPropertyFnFilteredByDomain(true)
+	workflows := []WorkflowInfo{{DomainID: s.DomainID, WorkflowID: s.WorkflowID, RunID: s.RunID}}
+	decisionCompleted := types.EventTypeDecisionTaskCompleted
+	activityScheduled := types.EventTypeActivityTaskScheduled
+
+	s.mockFrontendClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any()).Return(
+		&types.GetWorkflowExecutionHistoryResponse{
+			History: &types.History{Events: []*types.HistoryEvent{
+				{ID: 4, EventType: &decisionCompleted},
+				{ID: 5, EventType: &activityScheduled},
+			}},
+			NextPageToken: []byte("next"),
+		}, nil).Times(1)
+	s.mockFrontendClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any()).Return(
+		&types.GetWorkflowExecutionHistoryResponse{
+			History: &types.History{Events: []*types.HistoryEvent{
+				{ID: 9, EventType: &decisionCompleted},
+				{ID: 10, EventType: &activityScheduled},
+			}},
+		}, nil).Times(1)
+	s.mockFrontendClient.EXPECT().ResetWorkflowExecution(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *types.ResetWorkflowExecutionRequest, option ...yarpc.CallOption) (*types.ResetWorkflowExecutionResponse, error) {
+			s.Equal(int64(9), request.DecisionFinishEventID)
+			s.Equal(s.RunID, request.WorkflowExecution.RunID)
+			return &types.ResetWorkflowExecutionResponse{}, nil
+		}).Times(1)
+	s.logger.On("Info", "Mitigated workflow", mock.Anything).Return().Once()
+	s.scopedMetricClient.On("IncCounter", metrics.ESAnalyzerNumWorkflowsMitigated).Return().Once()
+
+	result := s.executeMitigateWorkflows(MitigateWorkflowsParams{
+		WorkflowType: s.WorkflowType,
+		Workflows:    workflows,
+		Mitigation:   MitigationReset,
+	})
+	s.Equal(1, result.Mitigated)
+}
+
+func (s *esanalyzerWorkflowTestSuite) TestMitigateWorkflowsResetSkipsMitigatedRun() {
+	s.config.ESAnalyzerMitigationDomainAllow = dynamicconfig.GetBoolPropertyFnFilteredByDomain(true)
+	workflows := []WorkflowInfo{{DomainID: s.DomainID, WorkflowID: s.WorkflowID, RunID: s.RunID}}
+	decisionCompleted := types.EventTypeDecisionTaskCompleted
+	decisionFailed := types.EventTypeDecisionTaskFailed
+	resetCause := types.DecisionTaskFailedCauseResetWorkflow
+
+	// the run was created by an earlier mitigation reset and keeps the start time of the base run
+	s.mockFrontendClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any()).Return(
+		&types.GetWorkflowExecutionHistoryResponse{
+			History: &types.History{Events: []*types.HistoryEvent{
+				{ID: 4, EventType: &decisionCompleted},
+				{ID: 7, EventType: &decisionFailed, DecisionTaskFailedEventAttributes: &types.DecisionTaskFailedEventAttributes{
+					Cause:  &resetCause,
+					Reason: common.StringPtr(mitigationReason),
+				}},
+			}},
+		}, nil).Times(1)
+	s.logger.On("Info", "Skipped workflow already mitigated", mock.Anything).Return().Once()
+
+	result := s.executeMitigateWorkflows(MitigateWorkflowsParams{
+		WorkflowType: s.WorkflowType,
+		Workflows:    workflows,
+		Mitigation:   MitigationReset,
+	})
+	s.Equal(0, result.Mitigated)
+	s.Equal(1, result.Skipped)
+}
+
+func (s *esanalyzerWorkflowTestSuite) TestMitigateWorkflowsResumesFromHeartbeat() {
+	s.config.ESAnalyzerMitigationDomainAllow = dynamicconfig.GetBoolPropertyFnFilteredByDomain(true)
+	workflows := []WorkflowInfo{
+		{DomainID: s.DomainID, WorkflowID: s.WorkflowID, RunID: s.RunID},
+		{DomainID: s.DomainID, WorkflowID: "workflow2", RunID: "run2"},
+	}
+	s.activityEnv.SetHeartbeatDetails(mitigationHeartbeatDetails{Processed: 1, Mitigated: 1})
+
+	s.mockFrontendClient.EXPECT().TerminateWorkflowExecution(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *types.TerminateWorkflowExecutionRequest, option ...yarpc.CallOption) error {
+			s.Equal("workflow2", request.WorkflowExecution.WorkflowID)
+			return nil
+		}).Times(1)
+	s.logger.On("Info", "Mitigated workflow", mock.Anything).Return().Once()
+	s.scopedMetricClient.On("IncCounter", metrics.ESAnalyzerNumWorkflowsMitigated).Return().Once()
+
+	result := s.executeMitigateWorkflows(MitigateWorkflowsParams{
+		WorkflowType: s.WorkflowType,
+		Workflows:    workflows,
+		Mitigation:   MitigationTerminate,
+	})
+	s.Equal(2, result.Mitigated)
+}
+
+func (s *esanalyzerWorkflowTestSuite) TestMitigateWorkflowsTerminateFailure() {
+	s.config.ESAnalyzerMitigationDomainAllow = dynamicconfig.GetBoolPropertyFnFilteredByDomain(true)
+	workflows := []WorkflowInfo{{DomainID: s.DomainID, WorkflowID: s.WorkflowID, RunID: s.RunID}}
+
+	s.mockFrontendClient.EXPECT().TerminateWorkflowExecution(gomock.Any(), gomock.Any()).
+		Return(&types.EntityNotExistsError{}).Times(1)
+	s.logger.On("Error", "Failed to mitigate workflow", mock.Anything).Return().Once()
+	s.scopedMetricClient.On("IncCounter", metrics.ESAnalyzerNumWorkflowsFailedToMitigate).Return().Once()
+
+	result := s.executeMitigateWorkflows(MitigateWorkflowsParams{
+		WorkflowType: s.WorkflowType,
+		Workflows:    workflows,
+		Mitigation:   MitigationTerminate,
+	})
+	s.Equal(0, result.Mitigated)
+	s.Equal(1, result.Failed)
+}
+
+func (s *esanalyzerWorkflowTestSuite) TestGetLongRunCheckEntriesWithMitigation() {
+	s.config.ESAnalyzerWorkflowDurationWarnThresholds = dynamicconfig.GetStringPropertyFn(
+		`[{"DomainName":"d1", "WorkflowType":"t1", "Threshold":"2m", "Mitigation":"signal", "SignalName":"s1"},{"DomainName":"d2", "WorkflowType":"t2", "Threshold":"3m", "Mitigation":"unknown"}]`,
+	)
+	s.logger.On("Error", mock.Anything, mock.Anything).Return().Once()
+
+	actFuture, err := s.activityEnv.ExecuteActivity(s.workflow.getLongRunCheckEntries)
+	s.NoError(err)
+	var longRunningWorkflows []LongRunCheckEntry
+	err = actFuture.Get(&longRunningWorkflows)
+	s.NoError(err)
+	s.Equal(1, len(longRunningWorkflows))
+	s.Equal("d1", longRunningWorkflows[0].DomainName)
+	s.Equal(MitigationSignal, longRunningWorkflows[0].Mitigation)
+	s.Equal("s1", longRunningWorkflows[0].SignalName)
+}
+
+func (s *esanalyzerWorkflowTestSuite) executeMitigateWorkflows(params MitigateWorkflowsParams) *MitigateWorkflowsResult {
+	s.scopedMetricClient.On("Tagged", mock.Anything, mock.Anything).Return(s.scopedMetricClient).Once()
+
+	actFuture, err := s.activityEnv.ExecuteActivity(s.workflow.mitigateWorkflows, params)
+	s.NoError(err)
+	var result MitigateWorkflowsResult
+	s.NoError(actFuture.Get(&result))
+	return &result
+}
@@ -0,0 +1,392 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package esanalyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/pborman/uuid"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/zap"
+
+	"github.com/uber/cadence/client/frontend"
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/reconciliation/entity"
+	"github.com/uber/cadence/common/reconciliation/invariant"
+	"github.com/uber/cadence/common/reconciliation/store"
+	"github.com/uber/cadence/common/types"
+)
+
+const (
+	// MitigationReport writes the workflows to the reconciliation store without touching them
+	MitigationReport MitigationType = "report"
+	// MitigationRefresh regenerates the tasks of the workflows
+	MitigationRefresh MitigationType = "refresh"
+	// MitigationSignal sends a signal to the workflows so that they can act on it
+	MitigationSignal MitigationType = "signal"
+	// MitigationReset resets the workflows to their last completed decision
+	MitigationReset MitigationType = "reset"
+	// MitigationTerminate terminates the workflows
+	MitigationTerminate MitigationType = "terminate"
+
+	mitigationIdentity         = "cadence-sys-es-analyzer"
+	mitigationReason           = "workflow open longer than expected, mitigated by ElasticSearch Analyzer"
+	mitigationHistoryPageSize  = 1000
+	mitigationReportFlushLimit = 100
+)
+
+type (
+	// MitigationType is the action taken by ElasticSearch Analyzer on long running or stuck workflows
+	MitigationType string
+
+	// MitigateWorkflowsParams are the workflows of a single domain and workflow type to mitigate.
+	// Mitigation and SignalName are read from dynamic config when empty.
+	MitigateWorkflowsParams struct {
+		WorkflowType string
+		Workflows    []WorkflowInfo
+		Mitigation   MitigationType
+		SignalName   string
+	}
+
+	// MitigateWorkflowsResult is the outcome of a mitigation
+	MitigateWorkflowsResult struct {
+		Mitigation MitigationType
+		DryRun     bool
+		Mitigated  int
+		Failed     int
+		Skipped    int
+		ReportKeys *store.Keys
+	}
+
+	// mitigationHeartbeatDetails is the progress of mitigateWorkflows, a retried attempt
+	// resumes after the processed workflows so that mitigations are not applied twice
+	mitigationHeartbeatDetails struct {
+		Processed int
+		Mitigated int
+		Failed    int
+		Skipped   int
+	}
+)
+
+// errAlreadyMitigated is returned for workflows which do not need to be mitigated anymore
+var errAlreadyMitigated = errors.New("workflow is already mitigated or closed")
+
+// ParseMitigationType validates a mitigation type read from config
+func ParseMitigationType(s string) (MitigationType, error) {
+	switch m := MitigationType(s); m {
+	case MitigationReport, MitigationRefresh, MitigationSignal, MitigationReset, MitigationTerminate:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown mitigation type: %v", s)
+}
+
+// isDisruptive returns true for mitigations which change the workflow execution
+// and therefore need to be allowed for the domain
+func (m MitigationType) isDisruptive() bool {
+	return m == MitigationSignal || m == MitigationReset || m == MitigationTerminate
+}
+
+// mitigateWorkflows is activity to mitigate long running or stuck workflows from the same workflow type.
+// Mitigations are only reported to the reconciliation store in dry run mode, and signal, reset and
+// terminate fall back to report for domains which are not in the allowlist.
+func (w *Workflow) mitigateWorkflows(
+	ctx context.Context,
+	params MitigateWorkflowsParams,
+) (*MitigateWorkflowsResult, error) {
+	logger := activity.GetLogger(ctx)
+	if len(params.Workflows) == 0 {
+		return &MitigateWorkflowsResult{}, nil
+	}
+
+	domainID := params.Workflows[0].DomainID
+	for _, wf := range params.Workflows {
+		if wf.DomainID != domainID {
+			return nil, types.InternalServiceError{
+				Message: fmt.Sprintf(
+					"Inconsistent worklow. Expected domainID: %v, actual: %v",
+					domainID,
+					wf.DomainID),
+			}
+		}
+	}
+	domainEntry, err := w.analyzer.domainCache.GetDomainByID(domainID)
+	if err != nil {
+		logger.Error("Failed to get domain entry", zap.Error(err), zap.String("DomainID", domainID))
+		return nil, err
+	}
+	domainName := domainEntry.GetInfo().Name
+	clusterName := domainEntry.GetReplicationConfig().ActiveClusterName
+
+	mitigation := params.Mitigation
+	if mitigation == "" {
+		configured := w.analyzer.config.ESAnalyzerStuckWorkflowMitigation(domainName, params.WorkflowType)
+		mitigation, err = ParseMitigationType(configured)
+		if err != nil {
+			logger.Error("Invalid stuck workflow mitigation, falling back to report",
+				zap.Error(err),
+				zap.String("domainName", domainName),
+				zap.String("workflowType", params.WorkflowType))
+			mitigation = MitigationReport
+		}
+	}
+	signalName := params.SignalName
+	if signalName == "" {
+		signalName = w.analyzer.config.ESAnalyzerStuckWorkflowSignalName(domainName, params.WorkflowType)
+	}
+	if mitigation.isDisruptive() && !w.analyzer.config.ESAnalyzerMitigationDomainAllow(domainName) {
+		logger.Warn("Domain is not allowed to be mitigated, falling back to report",
+			zap.String("domainName", domainName),
+			zap.String("workflowType", params.WorkflowType),
+			zap.String("mitigation", string(mitigation)))
+		mitigation = MitigationReport
+	}
+
+	result := &MitigateWorkflowsResult{
+		Mitigation: mitigation,
+		DryRun:     w.analyzer.config.ESAnalyzerMitigationDryRun(domainName),
+	}
+	tagged := w.analyzer.scopedMetricClient.Tagged(
+		metrics.DomainTag(domainName),
+		metrics.WorkflowTypeTag(params.WorkflowType),
+	)
+
+	if result.DryRun || mitigation == MitigationReport {
+		keys, err := w.reportWorkflows(params.Workflows, mitigation, result.DryRun)
+		if err != nil {
+			logger.Error("Failed to report workflows to mitigate", zap.Error(err), zap.String("domainName", domainName))
+			return nil, err
+		}
+		result.ReportKeys = keys
+		tagged.AddCounter(metrics.ESAnalyzerNumWorkflowsReported, int64(len(params.Workflows)))
+		logger.Info("Reported workflows to mitigate",
+			zap.String("domainName", domainName),
+			zap.String("workflowType", params.WorkflowType),
+			zap.String("mitigation", string(mitigation)),
+			zap.Bool("dryRun", result.DryRun),
+			zap.Int("numWorkflows", len(params.Workflows)),
+			zap.Any("reportKeys", keys))
+		return result, nil
+	}
+
+	var details mitigationHeartbeatDetails
+	if activity.HasHeartbeatDetails(ctx) {
+		if err := activity.GetHeartbeatDetails(ctx, &details); err != nil {
+			logger.Error("Failed to recover from last heartbeat, mitigating all workflows", zap.Error(err))
+			details = mitigationHeartbeatDetails{}
+		}
+	}
+
+	for ; details.Processed < len(params.Workflows); details.Processed++ {
+		wf := params.Workflows[details.Processed]
+		execution := &types.WorkflowExecution{WorkflowID: wf.WorkflowID, RunID: wf.RunID}
+		err := w.applyMitigation(ctx, mitigation, domainName, clusterName, execution, signalName)
+		switch {
+		case err == errAlreadyMitigated:
+			logger.Info("Skipped workflow already mitigated",
+				zap.String("mitigation", string(mitigation)),
+				zap.String("domainName", domainName),
+				zap.String("workflowID", wf.WorkflowID),
+				zap.String("runID", wf.RunID),
+			)
+			details.Skipped++
+		case err != nil:
+			// Errors might happen if the workflow is already closed. Instead of failing the workflow
+			// log the error and continue
+			logger.Error("Failed to mitigate workflow",
+				zap.Error(err),
+				zap.String("mitigation", string(mitigation)),
+				zap.String("domainName", domainName),
+				zap.String("workflowID", wf.WorkflowID),
+				zap.String("runID", wf.RunID),
+			)
+			details.Failed++
+			tagged.IncCounter(metrics.ESAnalyzerNumWorkflowsFailedToMitigate)
+		default:
+			logger.Info("Mitigated workflow",
+				zap.String("mitigation", string(mitigation)),
+				zap.String("domainName", domainName),
+				zap.String("workflowID", wf.WorkflowID),
+				zap.String("runID", wf.RunID),
+			)
+			details.Mitigated++
+			tagged.IncCounter(metrics.ESAnalyzerNumWorkflowsMitigated)
+		}
+		activity.RecordHeartbeat(ctx, mitigationHeartbeatDetails{
+			Processed: details.Processed + 1,
+			Mitigated: details.Mitigated,
+			Failed:    details.Failed,
+			Skipped:   details.Skipped,
+		})
+	}
+	result.Mitigated = details.Mitigated
+	result.Failed = details.Failed
+	result.Skipped = details.Skipped
+	return result, nil
+}
+
+func (w *Workflow) applyMitigation(
+	ctx context.Context,
+	mitigation MitigationType,
+	domainName string,
+	clusterName string,
+	execution *types.WorkflowExecution,
+	signalName string,
+) error {
+	switch mitigation {
+	case MitigationRefresh:
+		return w.analyzer.clientBean.GetRemoteAdminClient(clusterName).RefreshWorkflowTasks(ctx, &types.RefreshWorkflowTasksRequest{
+			Domain:    domainName,
+			Execution: execution,
+		})
+	case MitigationSignal:
+		return w.analyzer.clientBean.GetRemoteFrontendClient(clusterName).SignalWorkflowExecution(ctx, &types.SignalWorkflowExecutionRequest{
+			Domain:            domainName,
+			WorkflowExecution: execution,
+			SignalName:        signalName,
+			Identity:          mitigationIdentity,
+			RequestID:         uuid.New(),
+		})
+	case MitigationReset:
+		frontendClient := w.analyzer.clientBean.GetRemoteFrontendClient(clusterName)
+		decisionFinishEventID, err := getResetPoint(ctx, frontendClient, domainName, execution)
+		if err != nil {
+			return err
+		}
+		_, err = frontendClient.ResetWorkflowExecution(ctx, &types.ResetWorkflowExecutionRequest{
+			Domain:                domainName,
+			WorkflowExecution:     execution,
+			Reason:                mitigationReason,
+			DecisionFinishEventID: decisionFinishEventID,
+			RequestID:             uuid.New(),
+		})
+		return err
+	case MitigationTerminate:
+		return w.analyzer.clientBean.GetRemoteFrontendClient(clusterName).TerminateWorkflowExecution(ctx, &types.TerminateWorkflowExecutionRequest{
+			Domain:            domainName,
+			WorkflowExecution: execution,
+			Reason:            mitigationReason,
+			Identity:          mitigationIdentity,
+		})
+	}
+	return fmt.Errorf("unsupported mitigation type: %v", mitigation)
+}
+
+// getResetPoint returns the ID of the last DecisionTaskCompleted event of the workflow.
+// errAlreadyMitigated is returned for closed workflows, such as a run replaced by an earlier reset,
+// and for runs created by a mitigation reset: they keep the start time of the base run so they
+// would otherwise be found and reset again by every run of the analyzer
+func getResetPoint(
+	ctx context.Context,
+	frontendClient frontend.Client,
+	domainName string,
+	execution *types.WorkflowExecution,
+) (int64, error) {
+	decisionFinishEventID := int64(0)
+	var nextPageToken []byte
+	for {
+		resp, err := frontendClient.GetWorkflowExecutionHistory(ctx, &types.GetWorkflowExecutionHistoryRequest{
+			Domain:          domainName,
+			Execution:       execution,
+			MaximumPageSize: mitigationHistoryPageSize,
+			NextPageToken:   nextPageToken,
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, event := range resp.GetHistory().GetEvents() {
+			switch event.GetEventType() {
+			case types.EventTypeDecisionTaskCompleted:
+				decisionFinishEventID = event.ID
+			case types.EventTypeDecisionTaskFailed:
+				attributes := event.DecisionTaskFailedEventAttributes
+				if attributes.GetCause() == types.DecisionTaskFailedCauseResetWorkflow && common.StringDefault(attributes.Reason) == mitigationReason {
+					return 0, errAlreadyMitigated
+				}
+			case types.EventTypeWorkflowExecutionCompleted,
+				types.EventTypeWorkflowExecutionFailed,
+				types.EventTypeWorkflowExecutionTimedOut,
+				types.EventTypeWorkflowExecutionCanceled,
+				types.EventTypeWorkflowExecutionTerminated,
+				types.EventTypeWorkflowExecutionContinuedAsNew:
+				return 0, errAlreadyMitigated
+			}
+		}
+		if len(resp.NextPageToken) == 0 {
+			break
+		}
+		nextPageToken = resp.NextPageToken
+	}
+	if decisionFinishEventID == 0 {
+		return 0, fmt.Errorf("no completed decision found for workflow %v", execution.WorkflowID)
+	}
+	return decisionFinishEventID, nil
+}
+
+// reportWorkflows writes the workflows to the reconciliation store as long running workflows
+func (w *Workflow) reportWorkflows(
+	workflows []WorkflowInfo,
+	mitigation MitigationType,
+	dryRun bool,
+) (*store.Keys, error) {
+	info := fmt.Sprintf("workflow is open longer than expected, mitigation: %v", mitigation)
+	if dryRun {
+		info += " (dry run)"
+	}
+	writer := store.NewBlobstoreWriter(
+		uuid.New(),
+		store.CorruptedExtension,
+		w.analyzer.resource.GetBlobstoreClient(),
+		mitigationReportFlushLimit,
+	)
+	for _, wf := range workflows {
+		err := writer.Add(&store.ScanOutputEntity{
+			Execution: &entity.CurrentExecution{
+				CurrentRunID: wf.RunID,
+				Execution: entity.Execution{
+					DomainID:   wf.DomainID,
+					WorkflowID: wf.WorkflowID,
+					RunID:      wf.RunID,
+					State:      persistence.WorkflowStateRunning,
+				},
+			},
+			Result: invariant.ManagerCheckResult{
+				CheckResultType:          invariant.CheckResultTypeCorrupted,
+				DeterminingInvariantType: invariant.NamePtr(invariant.LongRunningWorkflow),
+				CheckResults: []invariant.CheckResult{{
+					CheckResultType: invariant.CheckResultTypeCorrupted,
+					InvariantName:   invariant.LongRunningWorkflow,
+					Info:            info,
+				}},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, err
+	}
+	return writer.FlushedKeys(), nil
+}
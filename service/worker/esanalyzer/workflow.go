@@ -51,6 +51,12 @@ const (
 	refreshStuckWorkflowsActivity    = "cadence-sys-es-analyzer-refresh-stuck-workflows"
 	findLongRunningWorkflowsActivity = "cadence-sys-es-analyzer-find-long-running-workflows"
 	getLongRunCheckEntriesActivity   = "cadence-sys-es-analyzer-get-long-run-check-entries"
+	mitigateWorkflowsActivity        = "cadence-sys-es-analyzer-mitigate-workflows"
+
+	// mitigateWorkflowsChangeID guards the switch from refreshStuckWorkflowsActivity to
+	// mitigateWorkflowsActivity for runs which were started by older workers
+	mitigateWorkflowsChangeID = "es-analyzer-mitigate-workflows"
+	mitigateWorkflowsVersion  = 1
 )
 
 type (
@@ -90,6 +96,8 @@ type (
 		Threshold       time.Duration
 		Refresh         bool
 		MaxNumWorkflows int
+		Mitigation      MitigationType
+		SignalName      string
 	}
 )
 
@@ -126,6 +134,12 @@ var (
 		StartToCloseTimeout:    1 * time.Minute,
 		RetryPolicy:            &retryPolicy,
 	}
+	mitigateWorkflowsOptions = workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    20 * time.Minute,
+		HeartbeatTimeout:       2 * time.Minute,
+		RetryPolicy:            &retryPolicy,
+	}
 
 	wfOptions = cclient.StartWorkflowOptions{
 		ID:                           esAnalyzerWFID,
@@ -149,6 +163,21 @@ func initWorkflow(a *Analyzer) {
 	activity.RegisterWithOptions(
 		w.getLongRunCheckEntries,
 		activity.RegisterOptions{Name: getLongRunCheckEntriesActivity})
+	activity.RegisterWithOptions(
+		w.mitigateWorkflows,
+		activity.RegisterOptions{Name: mitigateWorkflowsActivity})
+}
+
+// mitigation returns the mitigation to apply to the workflows found by the check,
+// Refresh is kept for entries which predate Mitigation
+func (e LongRunCheckEntry) mitigation() MitigationType {
+	if e.Mitigation != "" {
+		return e.Mitigation
+	}
+	if e.Refresh {
+		return MitigationRefresh
+	}
+	return ""
 }
 
 // workflowFunc queries ElasticSearch to detect issues and mitigates them
@@ -183,11 +212,22 @@ func (w *Workflow) workflowFunc(ctx workflow.Context) error {
 			continue
 		}
 
-		err = workflow.ExecuteActivity(
-			workflow.WithActivityOptions(ctx, refreshStuckWorkflowsOptions),
-			refreshStuckWorkflowsActivity,
-			stuckWorkflows,
-		).Get(ctx, nil)
+		if workflow.GetVersion(ctx, mitigateWorkflowsChangeID, workflow.DefaultVersion, mitigateWorkflowsVersion) == workflow.DefaultVersion {
+			err = workflow.ExecuteActivity(
+				workflow.WithActivityOptions(ctx, refreshStuckWorkflowsOptions),
+				refreshStuckWorkflowsActivity,
+				stuckWorkflows,
+			).Get(ctx, nil)
+		} else {
+			err = workflow.ExecuteActivity(
+				workflow.WithActivityOptions(ctx, mitigateWorkflowsOptions),
+				mitigateWorkflowsActivity,
+				MitigateWorkflowsParams{
+					WorkflowType: info.Name,
+					Workflows:    stuckWorkflows,
+				},
+			).Get(ctx, nil)
+		}
 		if err != nil {
 			return err
 		}
@@ -217,11 +257,31 @@ func (w *Workflow) workflowFunc(ctx workflow.Context) error {
 			continue
 		}
 
-		err = workflow.ExecuteActivity(
-			workflow.WithActivityOptions(ctx, refreshStuckWorkflowsOptions),
-			refreshStuckWorkflowsActivity,
-			longRunningWorkflows,
-		).Get(ctx, nil)
+		if workflow.GetVersion(ctx, mitigateWorkflowsChangeID, workflow.DefaultVersion, mitigateWorkflowsVersion) == workflow.DefaultVersion {
+			// older workers only found long running workflows for entries with Refresh set
+			if !checkInfo.Refresh {
+				continue
+			}
+			err = workflow.ExecuteActivity(
+				workflow.WithActivityOptions(ctx, refreshStuckWorkflowsOptions),
+				refreshStuckWorkflowsActivity,
+				longRunningWorkflows,
+			).Get(ctx, nil)
+		} else {
+			if checkInfo.mitigation() == "" {
+				continue
+			}
+			err = workflow.ExecuteActivity(
+				workflow.WithActivityOptions(ctx, mitigateWorkflowsOptions),
+				mitigateWorkflowsActivity,
+				MitigateWorkflowsParams{
+					WorkflowType: checkInfo.WorkflowType,
+					Workflows:    longRunningWorkflows,
+					Mitigation:   checkInfo.mitigation(),
+					SignalName:   checkInfo.SignalName,
+				},
+			).Get(ctx, nil)
+		}
 		if err != nil {
 			return err
 		}
@@ -291,6 +351,8 @@ func (w *Workflow) getLongRunCheckEntries(ctx context.Context) ([]LongRunCheckEn
 		Threshold       string
 		Refresh         bool
 		MaxNumWorkflows int
+		Mitigation      string
+		SignalName      string
 	}
 	err := json.Unmarshal([]byte(workflowThresholds), &entries)
 	if err != nil {
@@ -309,12 +371,22 @@ func (w *Workflow) getLongRunCheckEntries(ctx context.Context) ([]LongRunCheckEn
 			logger.Error(fmt.Sprintf("Error while parsing threshold %v ", entry.Threshold), zap.Error(err))
 			continue
 		}
+		var mitigation MitigationType
+		if entry.Mitigation != "" {
+			mitigation, err = ParseMitigationType(entry.Mitigation)
+			if err != nil {
+				logger.Error(fmt.Sprintf("Error while parsing mitigation %v ", entry.Mitigation), zap.Error(err))
+				continue
+			}
+		}
 		result = append(result, LongRunCheckEntry{
 			DomainName:      entry.DomainName,
 			WorkflowType:    entry.WorkflowType,
 			Threshold:       threshold,
 			Refresh:         entry.Refresh,
 			MaxNumWorkflows: entry.MaxNumWorkflows,
+			Mitigation:      mitigation,
+			SignalName:      entry.SignalName,
 		})
 	}
 
@@ -337,7 +409,7 @@ func (w *Workflow) findLongRunningWorkflows(ctx context.Context, entry LongRunCh
 	maxWorkflowStartTime := time.Now().Add(-entry.Threshold).UnixNano()
 
 	maxNumWorkflows := 0
-	if entry.Refresh {
+	if entry.mitigation() != "" {
 		if entry.MaxNumWorkflows > 0 {
 			maxNumWorkflows = entry.MaxNumWorkflows
 		} else {
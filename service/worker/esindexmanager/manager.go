@@ -0,0 +1,133 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package esindexmanager
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber-go/tally"
+	"go.uber.org/cadence/.gen/go/cadence/workflowserviceclient"
+	"go.uber.org/cadence/.gen/go/shared"
+	cclient "go.uber.org/cadence/client"
+	"go.uber.org/cadence/worker"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/config"
+	"github.com/uber/cadence/common/dynamicconfig"
+	es "github.com/uber/cadence/common/elasticsearch"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/resource"
+	"github.com/uber/cadence/service/worker/workercommon"
+)
+
+type (
+	// IndexManager is the background sub-system to roll over and delete the managed visibility indices
+	IndexManager struct {
+		svcClient    workflowserviceclient.Interface
+		indexManager *es.IndexManager
+		logger       log.Logger
+		tallyScope   tally.Scope
+		resource     resource.Resource
+		domainCache  cache.DomainCache
+		config       *Config
+	}
+
+	// Config contains all configs for ElasticSearch IndexManager
+	Config struct {
+		ESIndexManagerPause     dynamicconfig.BoolPropertyFn
+		ESIndexRolloverMaxAge   dynamicconfig.DurationPropertyFn
+		ESIndexRolloverMaxSize  dynamicconfig.StringPropertyFn
+		ESIndexRolloverMaxDocs  dynamicconfig.IntPropertyFn
+		ESIndexRetentionEnabled dynamicconfig.BoolPropertyFn
+	}
+)
+
+const startUpDelay = time.Second * 10
+
+// New returns a new instance as daemon
+func New(
+	svcClient workflowserviceclient.Interface,
+	esClient es.GenericClient,
+	esConfig *config.ElasticSearchConfig,
+	logger log.Logger,
+	tallyScope tally.Scope,
+	resource resource.Resource,
+	domainCache cache.DomainCache,
+	config *Config,
+) *IndexManager {
+	return &IndexManager{
+		svcClient:    svcClient,
+		indexManager: es.NewIndexManager(esClient, esConfig.GetVisibilityIndex()),
+		logger:       logger,
+		tallyScope:   tallyScope,
+		resource:     resource,
+		domainCache:  domainCache,
+		config:       config,
+	}
+}
+
+// Start starts the index manager
+func (m *IndexManager) Start() error {
+	ctx := context.Background()
+	m.StartWorkflow(ctx)
+
+	workerOpts := worker.Options{
+		MetricsScope:              m.tallyScope,
+		BackgroundActivityContext: ctx,
+		Tracer:                    opentracing.GlobalTracer(),
+	}
+	esWorker := worker.New(m.svcClient, common.SystemLocalDomainName, taskListName, workerOpts)
+	return esWorker.Start()
+}
+
+func (m *IndexManager) StartWorkflow(ctx context.Context) {
+	initWorkflow(m)
+	go workercommon.StartWorkflowWithRetry(indexManagerWFTypeName, startUpDelay, m.resource, func(client cclient.Client) error {
+		_, err := client.StartWorkflow(ctx, wfOptions, indexManagerWFTypeName)
+		switch err.(type) {
+		case *shared.WorkflowExecutionAlreadyStartedError:
+			return nil
+		default:
+			m.logger.Error("Failed to start ElasticSearch IndexManager", tag.Error(err))
+			return err
+		}
+	})
+}
+
+// getMaxRetention returns the longest retention of all domains, including the sampled longer retention
+func getMaxRetention(domains map[string]*cache.DomainCacheEntry) time.Duration {
+	var maxDays int
+	for _, domain := range domains {
+		days := int(domain.GetConfig().Retention)
+		if sampledDays, err := strconv.Atoi(domain.GetInfo().Data[cache.SampleRetentionKey]); err == nil && sampledDays > days {
+			days = sampledDays
+		}
+		if days > maxDays {
+			maxDays = days
+		}
+	}
+	return time.Duration(maxDays) * 24 * time.Hour
+}
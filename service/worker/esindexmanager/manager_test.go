@@ -0,0 +1,44 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package esindexmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/cadence/common/cache"
+)
+
+func TestGetMaxRetention(t *testing.T) {
+	assert.Equal(t, time.Duration(0), getMaxRetention(nil))
+	assert.Equal(t, 30*24*time.Hour, getMaxRetention(map[string]*cache.DomainCacheEntry{
+		"short":   newTestDomainCacheEntry(7, ""),
+		"long":    newTestDomainCacheEntry(30, ""),
+		"invalid": newTestDomainCacheEntry(3, "invalid"),
+	}))
+	// the sampled retention keeps some workflows longer than the retention of the domain
+	assert.Equal(t, 60*24*time.Hour, getMaxRetention(map[string]*cache.DomainCacheEntry{
+		"long":    newTestDomainCacheEntry(30, ""),
+		"sampled": newTestDomainCacheEntry(7, "60"),
+	}))
+}
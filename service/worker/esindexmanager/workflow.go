@@ -0,0 +1,141 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package esindexmanager
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/cadence"
+	"go.uber.org/cadence/activity"
+	cclient "go.uber.org/cadence/client"
+	"go.uber.org/cadence/workflow"
+	"go.uber.org/zap"
+
+	"github.com/uber/cadence/common/elasticsearch"
+)
+
+const (
+	// workflow constants
+	indexManagerWFID       = "cadence-sys-es-index-manager"
+	taskListName           = "cadence-sys-es-index-manager"
+	indexManagerWFTypeName = "cadence-sys-es-index-manager-workflow"
+	rolloverActivity       = "cadence-sys-es-index-manager-rollover"
+	deleteExpiredActivity  = "cadence-sys-es-index-manager-delete-expired"
+)
+
+type (
+	Workflow struct {
+		manager *IndexManager
+	}
+)
+
+var (
+	retryPolicy = cadence.RetryPolicy{
+		InitialInterval:    10 * time.Second,
+		BackoffCoefficient: 1.7,
+		MaximumInterval:    5 * time.Minute,
+		ExpirationInterval: 10 * time.Minute,
+	}
+
+	activityOptions = workflow.ActivityOptions{
+		ScheduleToStartTimeout: time.Minute,
+		StartToCloseTimeout:    5 * time.Minute,
+		RetryPolicy:            &retryPolicy,
+	}
+
+	wfOptions = cclient.StartWorkflowOptions{
+		ID:                           indexManagerWFID,
+		TaskList:                     taskListName,
+		ExecutionStartToCloseTimeout: time.Hour,
+		CronSchedule:                 "*/10 * * * *", // every 10 minutes
+	}
+)
+
+func initWorkflow(m *IndexManager) {
+	w := Workflow{manager: m}
+	workflow.RegisterWithOptions(w.workflowFunc, workflow.RegisterOptions{Name: indexManagerWFTypeName})
+	activity.RegisterWithOptions(w.rollover, activity.RegisterOptions{Name: rolloverActivity})
+	activity.RegisterWithOptions(w.deleteExpired, activity.RegisterOptions{Name: deleteExpiredActivity})
+}
+
+// workflowFunc rolls over the write index and deletes the indices whose workflows passed retention
+func (w *Workflow) workflowFunc(ctx workflow.Context) error {
+	if w.manager.config.ESIndexManagerPause() {
+		logger := workflow.GetLogger(ctx)
+		logger.Info("Skipping ESIndexManager execution cycle since it was paused")
+		return nil
+	}
+
+	opt := workflow.WithActivityOptions(ctx, activityOptions)
+	if err := workflow.ExecuteActivity(opt, rolloverActivity).Get(ctx, nil); err != nil {
+		return err
+	}
+	return workflow.ExecuteActivity(opt, deleteExpiredActivity).Get(ctx, nil)
+}
+
+// rollover rolls over the write index when any of the configured conditions is met
+func (w *Workflow) rollover(ctx context.Context) error {
+	logger := activity.GetLogger(ctx)
+	config := w.manager.config
+	resp, err := w.manager.indexManager.Rollover(ctx, elasticsearch.RolloverConditions{
+		MaxAge:  config.ESIndexRolloverMaxAge(),
+		MaxSize: config.ESIndexRolloverMaxSize(),
+		MaxDocs: int64(config.ESIndexRolloverMaxDocs()),
+	}, false)
+	if err != nil {
+		logger.Error("Failed to roll over visibility index", zap.Error(err))
+		return err
+	}
+	if resp.RolledOver {
+		logger.Info("Rolled over visibility index",
+			zap.String("OldIndex", resp.OldIndex),
+			zap.String("NewIndex", resp.NewIndex))
+	}
+	return nil
+}
+
+// deleteExpired deletes the indices whose workflows closed before the longest domain retention
+func (w *Workflow) deleteExpired(ctx context.Context) error {
+	if !w.manager.config.ESIndexRetentionEnabled() {
+		return nil
+	}
+
+	logger := activity.GetLogger(ctx)
+	retention := getMaxRetention(w.manager.domainCache.GetAllDomain())
+	if retention == 0 {
+		// domains are not loaded yet
+		return nil
+	}
+	expired, err := w.manager.indexManager.GetExpiredIndices(ctx, retention)
+	if err != nil {
+		logger.Error("Failed to get expired visibility indices", zap.Error(err))
+		return err
+	}
+	for _, index := range expired {
+		if err := w.manager.indexManager.DeleteIndex(ctx, index); err != nil {
+			logger.Error("Failed to delete expired visibility index", zap.String("Index", index), zap.Error(err))
+			return err
+		}
+		logger.Info("Deleted expired visibility index", zap.String("Index", index))
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package esindexmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/cadence/activity"
+	"go.uber.org/cadence/testsuite"
+	"go.uber.org/cadence/workflow"
+
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/cluster"
+	"github.com/uber/cadence/common/definition"
+	"github.com/uber/cadence/common/dynamicconfig"
+	es "github.com/uber/cadence/common/elasticsearch"
+	esMocks "github.com/uber/cadence/common/elasticsearch/mocks"
+	"github.com/uber/cadence/common/log/loggerimpl"
+	"github.com/uber/cadence/common/persistence"
+)
+
+const testAlias = "cadence-visibility-test"
+
+type esIndexManagerWorkflowTestSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+	activityEnv     *testsuite.TestActivityEnvironment
+	workflowEnv     *testsuite.TestWorkflowEnvironment
+	controller      *gomock.Controller
+	mockDomainCache *cache.MockDomainCache
+	mockESClient    *esMocks.GenericClient
+	config          Config
+	workflow        *Workflow
+}
+
+func TestESIndexManagerWorkflowTestSuite(t *testing.T) {
+	suite.Run(t, new(esIndexManagerWorkflowTestSuite))
+}
+
+func (s *esIndexManagerWorkflowTestSuite) SetupTest() {
+	s.config = Config{
+		ESIndexManagerPause:     dynamicconfig.GetBoolPropertyFn(false),
+		ESIndexRolloverMaxAge:   dynamicconfig.GetDurationPropertyFn(7 * 24 * time.Hour),
+		ESIndexRolloverMaxSize:  dynamicconfig.GetStringPropertyFn("50gb"),
+		ESIndexRolloverMaxDocs:  dynamicconfig.GetIntPropertyFn(1000),
+		ESIndexRetentionEnabled: dynamicconfig.GetBoolPropertyFn(true),
+	}
+
+	s.activityEnv = s.NewTestActivityEnvironment()
+	s.workflowEnv = s.NewTestWorkflowEnvironment()
+	s.controller = gomock.NewController(s.T())
+	s.mockDomainCache = cache.NewMockDomainCache(s.controller)
+	s.mockESClient = &esMocks.GenericClient{}
+
+	s.workflow = &Workflow{manager: &IndexManager{
+		indexManager: es.NewIndexManager(s.mockESClient, testAlias),
+		logger:       loggerimpl.NewNopLogger(),
+		domainCache:  s.mockDomainCache,
+		config:       &s.config,
+	}}
+
+	s.workflowEnv.RegisterWorkflowWithOptions(s.workflow.workflowFunc, workflow.RegisterOptions{Name: indexManagerWFTypeName})
+	s.workflowEnv.RegisterActivityWithOptions(s.workflow.rollover, activity.RegisterOptions{Name: rolloverActivity})
+	s.workflowEnv.RegisterActivityWithOptions(s.workflow.deleteExpired, activity.RegisterOptions{Name: deleteExpiredActivity})
+	s.activityEnv.RegisterActivityWithOptions(s.workflow.rollover, activity.RegisterOptions{Name: rolloverActivity})
+	s.activityEnv.RegisterActivityWithOptions(s.workflow.deleteExpired, activity.RegisterOptions{Name: deleteExpiredActivity})
+}
+
+func (s *esIndexManagerWorkflowTestSuite) TearDownTest() {
+	defer s.controller.Finish()
+
+	s.workflowEnv.AssertExpectations(s.T())
+	s.mockESClient.AssertExpectations(s.T())
+}
+
+func (s *esIndexManagerWorkflowTestSuite) TestExecuteWorkflow() {
+	s.workflowEnv.OnActivity(rolloverActivity, mock.Anything).Return(nil).Once()
+	s.workflowEnv.OnActivity(deleteExpiredActivity, mock.Anything).Return(nil).Once()
+
+	s.workflowEnv.ExecuteWorkflow(indexManagerWFTypeName)
+	s.True(s.workflowEnv.IsWorkflowCompleted())
+	s.NoError(s.workflowEnv.GetWorkflowError())
+}
+
+func (s *esIndexManagerWorkflowTestSuite) TestExecuteWorkflow_Paused() {
+	s.config.ESIndexManagerPause = dynamicconfig.GetBoolPropertyFn(true)
+
+	s.workflowEnv.ExecuteWorkflow(indexManagerWFTypeName)
+	s.True(s.workflowEnv.IsWorkflowCompleted())
+	s.NoError(s.workflowEnv.GetWorkflowError())
+}
+
+func (s *esIndexManagerWorkflowTestSuite) TestExecuteWorkflow_RolloverFailed() {
+	s.workflowEnv.OnActivity(rolloverActivity, mock.Anything).Return(errors.New("rollover failed"))
+
+	s.workflowEnv.ExecuteWorkflow(indexManagerWFTypeName)
+	s.True(s.workflowEnv.IsWorkflowCompleted())
+	s.Error(s.workflowEnv.GetWorkflowError())
+}
+
+func (s *esIndexManagerWorkflowTestSuite) TestRollover() {
+	writeIndex := es.NewManagedIndex(testAlias, time.Now().Add(-8*24*time.Hour), 1).Name
+	s.mockESClient.On("GetAliasIndices", mock.Anything, es.GetWriteAlias(testAlias)).
+		Return(map[string]bool{writeIndex: true}, nil).Once()
+	s.mockESClient.On("GetAliasIndices", mock.Anything, testAlias).
+		Return(map[string]bool{writeIndex: false}, nil)
+	var newIndex string
+	s.mockESClient.On("RolloverIndex", mock.Anything, mock.MatchedBy(func(request *es.RolloverIndexRequest) bool {
+		newIndex = request.NewIndex
+		return request.Alias == es.GetWriteAlias(testAlias) &&
+			request.MaxAge == 7*24*time.Hour &&
+			request.MaxSize == "50gb" &&
+			request.MaxDocs == 1000 &&
+			!request.DryRun
+	})).Return(func(_ context.Context, request *es.RolloverIndexRequest) *es.RolloverIndexResponse {
+		return &es.RolloverIndexResponse{OldIndex: writeIndex, NewIndex: request.NewIndex, RolledOver: true}
+	}, nil).Once()
+	// the custom search attributes of the old index are added to the new one, which is added to the read alias
+	s.mockESClient.On("GetMapping", mock.Anything, writeIndex, definition.Attr).
+		Return(map[string]string{"CustomKeywordField": "keyword"}, nil).Once()
+	s.mockESClient.On("GetMapping", mock.Anything, mock.Anything, definition.Attr).
+		Return(map[string]string{}, nil).Once()
+	s.mockESClient.On("PutMapping", mock.Anything, mock.Anything, definition.Attr, "CustomKeywordField", "keyword").
+		Return(nil).Once()
+	s.mockESClient.On("UpdateAliases", mock.Anything, mock.MatchedBy(func(actions []*es.AliasAction) bool {
+		return len(actions) == 1 && actions[0].Index == newIndex && actions[0].Alias == testAlias
+	})).Return(nil).Once()
+
+	_, err := s.activityEnv.ExecuteActivity(rolloverActivity)
+	s.NoError(err)
+}
+
+func (s *esIndexManagerWorkflowTestSuite) TestRollover_ConditionsNotMet() {
+	writeIndex := es.NewManagedIndex(testAlias, time.Now().Add(-time.Hour), 1).Name
+	s.mockESClient.On("GetAliasIndices", mock.Anything, es.GetWriteAlias(testAlias)).
+		Return(map[string]bool{writeIndex: true}, nil).Once()
+	s.mockESClient.On("GetAliasIndices", mock.Anything, testAlias).
+		Return(map[string]bool{writeIndex: false}, nil).Once()
+	s.mockESClient.On("RolloverIndex", mock.Anything, mock.Anything).
+		Return(&es.RolloverIndexResponse{OldIndex: writeIndex}, nil).Once()
+
+	_, err := s.activityEnv.ExecuteActivity(rolloverActivity)
+	s.NoError(err)
+}
+
+func (s *esIndexManagerWorkflowTestSuite) TestRollover_Failed() {
+	s.mockESClient.On("GetAliasIndices", mock.Anything, es.GetWriteAlias(testAlias)).
+		Return(nil, errors.New("ES unavailable")).Once()
+
+	_, err := s.activityEnv.ExecuteActivity(rolloverActivity)
+	s.Error(err)
+}
+
+func (s *esIndexManagerWorkflowTestSuite) TestDeleteExpired() {
+	now := time.Now()
+	expiredIndex := es.NewManagedIndex(testAlias, now.Add(-30*24*time.Hour), 1).Name
+	openIndex := es.NewManagedIndex(testAlias, now.Add(-20*24*time.Hour), 1).Name
+	writeIndex := es.NewManagedIndex(testAlias, now.Add(-10*24*time.Hour), 1).Name
+	s.mockDomainCache.EXPECT().GetAllDomain().Return(map[string]*cache.DomainCacheEntry{
+		"domain": newTestDomainCacheEntry(7, ""),
+	})
+
+	s.mockESClient.On("GetAliasIndices", mock.Anything, testAlias).
+		Return(map[string]bool{expiredIndex: false, openIndex: false, writeIndex: false}, nil).Once()
+	s.mockESClient.On("GetAliasIndices", mock.Anything, es.GetWriteAlias(testAlias)).
+		Return(map[string]bool{writeIndex: true}, nil).Once()
+	s.mockESClient.On("GetAliasIndices", mock.Anything, es.GetReindexAlias(testAlias)).
+		Return(map[string]bool{}, nil).Once()
+	s.mockESClient.On("CountByQuery", mock.Anything, expiredIndex, mock.Anything).Return(int64(0), nil).Once()
+	s.mockESClient.On("SearchRaw", mock.Anything, expiredIndex, mock.Anything).
+		Return(newMaxCloseTimeResponse(now.Add(-8*24*time.Hour)), nil).Once()
+	s.mockESClient.On("CountByQuery", mock.Anything, openIndex, mock.Anything).Return(int64(1), nil).Once()
+	s.mockESClient.On("DeleteIndex", mock.Anything, expiredIndex).Return(nil).Once()
+
+	_, err := s.activityEnv.ExecuteActivity(deleteExpiredActivity)
+	s.NoError(err)
+}
+
+func (s *esIndexManagerWorkflowTestSuite) TestDeleteExpired_Disabled() {
+	s.config.ESIndexRetentionEnabled = dynamicconfig.GetBoolPropertyFn(false)
+
+	_, err := s.activityEnv.ExecuteActivity(deleteExpiredActivity)
+	s.NoError(err)
+}
+
+func (s *esIndexManagerWorkflowTestSuite) TestDeleteExpired_DomainsNotLoaded() {
+	s.mockDomainCache.EXPECT().GetAllDomain().Return(map[string]*cache.DomainCacheEntry{})
+
+	_, err := s.activityEnv.ExecuteActivity(deleteExpiredActivity)
+	s.NoError(err)
+}
+
+func (s *esIndexManagerWorkflowTestSuite) TestDeleteExpired_DeleteFailed() {
+	now := time.Now()
+	expiredIndex := es.NewManagedIndex(testAlias, now.Add(-30*24*time.Hour), 1).Name
+	writeIndex := es.NewManagedIndex(testAlias, now.Add(-10*24*time.Hour), 1).Name
+	s.mockDomainCache.EXPECT().GetAllDomain().Return(map[string]*cache.DomainCacheEntry{
+		"domain": newTestDomainCacheEntry(1, ""),
+	})
+
+	s.mockESClient.On("GetAliasIndices", mock.Anything, testAlias).
+		Return(map[string]bool{expiredIndex: false, writeIndex: false}, nil).Once()
+	s.mockESClient.On("GetAliasIndices", mock.Anything, es.GetWriteAlias(testAlias)).
+		Return(map[string]bool{writeIndex: true}, nil).Once()
+	s.mockESClient.On("GetAliasIndices", mock.Anything, es.GetReindexAlias(testAlias)).
+		Return(map[string]bool{}, nil).Once()
+	s.mockESClient.On("CountByQuery", mock.Anything, expiredIndex, mock.Anything).Return(int64(0), nil).Once()
+	s.mockESClient.On("SearchRaw", mock.Anything, expiredIndex, mock.Anything).
+		Return(&es.RawResponse{}, nil).Once()
+	s.mockESClient.On("DeleteIndex", mock.Anything, expiredIndex).Return(errors.New("ES unavailable")).Once()
+
+	_, err := s.activityEnv.ExecuteActivity(deleteExpiredActivity)
+	s.Error(err)
+}
+
+func newTestDomainCacheEntry(retentionDays int32, sampledRetentionDays string) *cache.DomainCacheEntry {
+	data := map[string]string{}
+	if sampledRetentionDays != "" {
+		data[cache.SampleRetentionKey] = sampledRetentionDays
+	}
+	return cache.NewLocalDomainCacheEntryForTest(
+		&persistence.DomainInfo{Name: "domain", Data: data},
+		&persistence.DomainConfig{Retention: retentionDays},
+		cluster.TestCurrentClusterName,
+	)
+}
+
+func newMaxCloseTimeResponse(closeTime time.Time) *es.RawResponse {
+	value, _ := json.Marshal(map[string]float64{"value": float64(closeTime.UnixNano())})
+	return &es.RawResponse{Aggregations: map[string]json.RawMessage{"maxCloseTime": value}}
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/service/worker/workercommon"
 )
 
 type (
@@ -53,14 +54,24 @@ type (
 
 // RebalanceWorkflow is to rebalance domains across clusters based on rebalance policy.
 func RebalanceWorkflow(ctx workflow.Context, params *RebalanceParams) (*RebalanceResult, error) {
+	progress := workercommon.NewProgress(workflow.Now(ctx))
+	err := workercommon.SetProgressQueryHandler(ctx, func() *workercommon.Progress {
+		return progress
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	// get rebalance domains
 	ao := workflow.WithActivityOptions(ctx, getGetDomainsActivityOptions())
 	var domainData []*DomainRebalanceData
-	err := workflow.ExecuteActivity(ao, getRebalanceDomainsActivityName).Get(ctx, &domainData)
+	err = workflow.ExecuteActivity(ao, getRebalanceDomainsActivityName).Get(ctx, &domainData)
 	if err != nil {
 		return nil, err
 	}
 	domainPerCluster := getDomainsByCluster(domainData)
+	progress.SetTotal(int64(len(domainData)), workflow.Now(ctx))
+	progress.SetPhase(workercommon.ProgressPhaseRunning, workflow.Now(ctx))
 
 	result := &RebalanceResult{
 		SuccessDomains: []string{},
@@ -79,10 +90,12 @@ func RebalanceWorkflow(ctx workflow.Context, params *RebalanceParams) (*Rebalanc
 			failoverParams,
 			func() {},
 			false,
+			progress,
 		)
 		result.SuccessDomains = append(result.SuccessDomains, successDomains...)
 		result.FailedDomains = append(result.FailedDomains, failedDomains...)
 	}
+	progress.SetPhase(workercommon.ProgressPhaseCompleted, workflow.Now(ctx))
 
 	return result, nil
 }
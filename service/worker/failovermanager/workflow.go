@@ -36,6 +36,7 @@ import (
 	"github.com/uber/cadence/client/frontend"
 	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/service/worker/workercommon"
 )
 
 type (
@@ -167,6 +168,11 @@ func FailoverWorkflow(ctx workflow.Context, params *FailoverParams) (*FailoverRe
 	var totalNumOfDomains int
 	wfState := WorkflowInitialized
 	operator := getOperator(ctx)
+	progress := workercommon.NewProgress(workflow.Now(ctx))
+	setState := func(state string) {
+		wfState = state
+		progress.SetPhase(state, workflow.Now(ctx))
+	}
 	err = workflow.SetQueryHandler(ctx, QueryType, func(input []byte) (*QueryResult, error) {
 		return &QueryResult{
 			TotalDomains:        totalNumOfDomains,
@@ -185,6 +191,12 @@ func FailoverWorkflow(ctx workflow.Context, params *FailoverParams) (*FailoverRe
 	if err != nil {
 		return nil, err
 	}
+	err = workercommon.SetProgressQueryHandler(ctx, func() *workercommon.Progress {
+		return progress
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	// get target domains
 	ao := workflow.WithActivityOptions(ctx, getGetDomainsActivityOptions())
@@ -199,6 +211,12 @@ func FailoverWorkflow(ctx workflow.Context, params *FailoverParams) (*FailoverRe
 		return nil, err
 	}
 	totalNumOfDomains = len(domains)
+	if params.DrillWaitTime == 0 {
+		progress.SetTotal(int64(totalNumOfDomains), workflow.Now(ctx))
+	} else {
+		// domains are failed over and then reset in a drill
+		progress.SetTotal(int64(2*totalNumOfDomains), workflow.Now(ctx))
+	}
 
 	pauseCh := workflow.GetSignalChannel(ctx, PauseSignal)
 	resumeCh := workflow.GetSignalChannel(ctx, ResumeSignal)
@@ -206,20 +224,20 @@ func FailoverWorkflow(ctx workflow.Context, params *FailoverParams) (*FailoverRe
 	checkPauseSignal := func() {
 		shouldPause = pauseCh.ReceiveAsync(nil)
 		if shouldPause {
-			wfState = WorkflowPaused
+			setState(WorkflowPaused)
 			resumeCh.Receive(ctx, nil)
 			// clean up all pending pause signal
 			cleanupChannel(pauseCh)
 		}
-		wfState = WorkflowRunning
+		setState(WorkflowRunning)
 	}
 
 	// failover in batch
-	successDomains, failedDomains = failoverDomainsByBatch(ctx, domains, params, checkPauseSignal, false)
+	successDomains, failedDomains = failoverDomainsByBatch(ctx, domains, params, checkPauseSignal, false, progress)
 
 	if params.DrillWaitTime == 0 {
 		// This is a normal failover
-		wfState = WorkflowCompleted
+		setState(WorkflowCompleted)
 		return &FailoverResult{
 			SuccessDomains: successDomains,
 			FailedDomains:  failedDomains,
@@ -228,8 +246,8 @@ func FailoverWorkflow(ctx workflow.Context, params *FailoverParams) (*FailoverRe
 
 	workflow.Sleep(ctx, params.DrillWaitTime)
 	// Reset domains to original cluster
-	successResetDomains, failedResetDomains = failoverDomainsByBatch(ctx, domains, params, checkPauseSignal, true)
-	wfState = WorkflowCompleted
+	successResetDomains, failedResetDomains = failoverDomainsByBatch(ctx, domains, params, checkPauseSignal, true, progress)
+	setState(WorkflowCompleted)
 
 	return &FailoverResult{
 		SuccessDomains:      successDomains,
@@ -245,6 +263,7 @@ func failoverDomainsByBatch(
 	params *FailoverParams,
 	pauseSignalHandler func(),
 	reverseFailover bool,
+	progress *workercommon.Progress,
 ) (successDomains []string, failedDomains []string) {
 
 	totalNumOfDomains := len(domains)
@@ -269,9 +288,15 @@ func failoverDomainsByBatch(
 			// Domains in failed activity can be either failovered or not, but we treated them as failed.
 			// This makes the query result for FailedDomains contains false positive results.
 			failedDomains = append(failedDomains, failoverActivityParams.Domains...)
+			progress.Add(int64(len(failoverActivityParams.Domains)), int64(len(failoverActivityParams.Domains)), workflow.Now(ctx))
+			progress.AddError(err.Error(), workflow.Now(ctx))
 		} else {
 			successDomains = append(successDomains, actResult.SuccessDomains...)
 			failedDomains = append(failedDomains, actResult.FailedDomains...)
+			progress.Add(int64(len(failoverActivityParams.Domains)), int64(len(actResult.FailedDomains)), workflow.Now(ctx))
+			for _, domain := range actResult.FailedDomains {
+				progress.AddError(fmt.Sprintf("failed to failover domain %v to %v", domain, targetCluster), workflow.Now(ctx))
+			}
 		}
 
 		if i != times-1 {
@@ -35,6 +35,7 @@ import (
 	"github.com/uber/cadence/common/metrics"
 	"github.com/uber/cadence/common/resource"
 	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/service/worker/workercommon"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -120,6 +121,16 @@ func (s *failoverWorkflowTestSuite) TestWorkflow_FailoverActivityError() {
 	s.NoError(s.workflowEnv.GetWorkflowResult(&result))
 	s.Equal(0, len(result.SuccessDomains))
 	s.Equal(domains, result.FailedDomains)
+
+	queryResult, err := s.workflowEnv.QueryWorkflow(workercommon.ProgressQueryType)
+	s.NoError(err)
+	var progress workercommon.Progress
+	s.NoError(queryResult.Get(&progress))
+	s.Equal(WorkflowCompleted, progress.Phase)
+	s.Equal(int64(1), progress.Total)
+	s.Equal(int64(1), progress.Processed)
+	s.Equal(int64(1), progress.Failed)
+	s.Len(progress.LastErrors, 1)
 }
 
 func (s *failoverWorkflowTestSuite) TestWorkflow_Success() {
@@ -152,6 +163,16 @@ func (s *failoverWorkflowTestSuite) TestWorkflow_Success() {
 	s.Equal(domains, res.SuccessDomains)
 	s.Equal(0, len(res.FailedDomains))
 	s.Equal(unknownOperator, res.Operator)
+
+	queryResult, err = s.workflowEnv.QueryWorkflow(workercommon.ProgressQueryType)
+	s.NoError(err)
+	var progress workercommon.Progress
+	s.NoError(queryResult.Get(&progress))
+	s.Equal(WorkflowCompleted, progress.Phase)
+	s.Equal(int64(1), progress.Total)
+	s.Equal(int64(1), progress.Processed)
+	s.Equal(int64(0), progress.Failed)
+	s.Equal(float64(100), progress.Percent())
 }
 
 func (s *failoverWorkflowTestSuite) TestWorkflow_Success_Batches() {
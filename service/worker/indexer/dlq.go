@@ -0,0 +1,112 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package indexer
+
+import (
+	"context"
+	"time"
+
+	"github.com/uber/cadence/.gen/go/indexer"
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/backoff"
+	"github.com/uber/cadence/common/codec"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/messaging"
+	"github.com/uber/cadence/common/metrics"
+)
+
+const dlqPublishTimeout = time.Minute
+
+type (
+	// dlqHandler publishes the messages that the indexer fails to process
+	// to the visibility DLQ, with the reason of the failure attached
+	dlqHandler struct {
+		producer      messaging.Producer
+		msgEncoder    codec.BinaryEncoder
+		throttleRetry *backoff.ThrottleRetry
+		metricsClient metrics.Client
+		logger        log.Logger
+	}
+)
+
+func newDLQHandler(producer messaging.Producer, msgEncoder codec.BinaryEncoder, metricsClient metrics.Client, logger log.Logger) *dlqHandler {
+	return &dlqHandler{
+		producer:   producer,
+		msgEncoder: msgEncoder,
+		throttleRetry: backoff.NewThrottleRetry(
+			backoff.WithRetryPolicy(common.CreateDlqPublishRetryPolicy()),
+			backoff.WithRetryableError(func(_ error) bool { return true }),
+		),
+		metricsClient: metricsClient,
+		logger:        logger,
+	}
+}
+
+// publish sends the message to the DLQ, status is the ES response status or 0 if the message never reached ES.
+// The message should be acked if it is published, or nacked otherwise.
+func (h *dlqHandler) publish(msg messaging.Message, reason string, status int) error {
+	dlqMsg := messaging.NewDLQMessage(msg, reason)
+	dlqMsg.Status = status
+	var indexMsg indexer.Message
+	if err := h.msgEncoder.Decode(msg.Value(), &indexMsg); err == nil {
+		dlqMsg.DomainID = indexMsg.GetDomainID()
+		dlqMsg.WorkflowID = indexMsg.GetWorkflowID()
+		dlqMsg.RunID = indexMsg.GetRunID()
+	}
+
+	op := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), dlqPublishTimeout)
+		defer cancel()
+		return h.producer.Publish(ctx, dlqMsg)
+	}
+	if err := h.throttleRetry.Do(context.Background(), op); err != nil {
+		h.metricsClient.IncCounter(metrics.IndexProcessorScope, metrics.IndexProcessorDLQFailures)
+		h.logger.Error("Failed to publish message to visibility DLQ.",
+			tag.KafkaPartition(msg.Partition()),
+			tag.KafkaOffset(msg.Offset()),
+			tag.WorkflowDomainID(dlqMsg.DomainID),
+			tag.WorkflowID(dlqMsg.WorkflowID),
+			tag.WorkflowRunID(dlqMsg.RunID),
+			tag.Error(err))
+		return err
+	}
+
+	h.metricsClient.IncCounter(metrics.IndexProcessorScope, metrics.IndexProcessorDLQMessages)
+	h.logger.Warn("Published message to visibility DLQ.",
+		tag.KafkaPartition(msg.Partition()),
+		tag.KafkaOffset(msg.Offset()),
+		tag.WorkflowDomainID(dlqMsg.DomainID),
+		tag.WorkflowID(dlqMsg.WorkflowID),
+		tag.WorkflowRunID(dlqMsg.RunID),
+		tag.Value(reason))
+	return nil
+}
+
+// sendToDLQ publishes the message to the DLQ and acks it, it falls back
+// to nacking the message when it can't be published
+func (h *dlqHandler) sendToDLQ(msg messaging.Message, reason string, status int) {
+	if err := h.publish(msg, reason, status); err != nil {
+		msg.Nack() //nolint:errcheck
+		return
+	}
+	msg.Ack() //nolint:errcheck
+}
@@ -43,6 +43,7 @@ type (
 	esProcessorImpl struct {
 		processor     es.GenericBulkProcessor
 		mapToKafkaMsg collection.ConcurrentTxMap // used to map ES request to kafka message
+		dlq           *dlqHandler
 		config        *Config
 		logger        log.Logger
 		metricsClient metrics.Client
@@ -62,10 +63,11 @@ const (
 )
 
 // newESProcessorAndStart create new ESProcessor and start
-func newESProcessorAndStart(config *Config, client es.GenericClient, processorName string,
+func newESProcessorAndStart(config *Config, client es.GenericClient, processorName string, dlq *dlqHandler,
 	logger log.Logger, metricsClient metrics.Client, msgEncoder codec.BinaryEncoder) (*esProcessorImpl, error) {
 	p := &esProcessorImpl{
 		config:        config,
+		dlq:           dlq,
 		logger:        logger.WithTags(tag.ComponentIndexerESProcessor),
 		metricsClient: metricsClient,
 		msgEncoder:    msgEncoder,
@@ -122,7 +124,7 @@ func (p *esProcessorImpl) bulkAfterAction(id int64, requests []es.GenericBulkabl
 		// When cluster back to live, processor will re-commit those failure requests
 		p.logger.Error("Error commit bulk request.", tag.Error(err.Details))
 
-		isRetryable := isResponseRetriable(err.Status)
+		isRetryable := es.IsResponseRetriable(err.Status)
 		for _, request := range requests {
 			if !isRetryable {
 				key := p.processor.RetrieveKafkaKey(request, p.logger, p.metricsClient)
@@ -136,7 +138,7 @@ func (p *esProcessorImpl) bulkAfterAction(id int64, requests []es.GenericBulkabl
 					tag.WorkflowID(wid),
 					tag.WorkflowRunID(rid),
 					tag.WorkflowDomainID(domainID))
-				p.nackKafkaMsg(key)
+				p.sendKafkaMsgToDLQ(key, fmt.Sprintf("%v", err.Details), err.Status)
 			} else {
 				p.logger.Error("ES request failed.", tag.ESRequest(request.String()))
 			}
@@ -154,14 +156,20 @@ func (p *esProcessorImpl) bulkAfterAction(id int64, requests []es.GenericBulkabl
 		responseItem := responseItems[i]
 		for _, resp := range responseItem {
 			switch {
-			case isResponseSuccess(resp.Status):
+			case es.IsResponseSuccess(resp.Status):
 				p.ackKafkaMsg(key)
-			case !isResponseRetriable(resp.Status):
+			case !es.IsResponseRetriable(resp.Status):
 				wid, rid, domainID := p.getMsgWithInfo(key)
+				errMsg := es.GetErrorMsgFromBulkResponse(resp)
+				if es.IsMappingConflict(resp) {
+					// the update would be lost until the mapping is fixed and the DLQ message is re-driven
+					p.metricsClient.IncCounter(metrics.ESProcessorScope, metrics.ESProcessorMappingConflicts)
+					errMsg = "mapping conflict: " + errMsg
+				}
 				p.logger.Error("ES request failed.",
-					tag.ESResponseStatus(resp.Status), tag.ESResponseError(getErrorMsgFromESResp(resp)), tag.WorkflowID(wid), tag.WorkflowRunID(rid),
+					tag.ESResponseStatus(resp.Status), tag.ESResponseError(errMsg), tag.WorkflowID(wid), tag.WorkflowRunID(rid),
 					tag.WorkflowDomainID(domainID))
-				p.nackKafkaMsg(key)
+				p.sendKafkaMsgToDLQ(key, errMsg, resp.Status)
 			default: // bulk processor will retry
 				p.logger.Info("ES request retried.", tag.ESResponseStatus(resp.Status))
 				p.metricsClient.IncCounter(metrics.ESProcessorScope, metrics.ESProcessorRetries)
@@ -178,6 +186,22 @@ func (p *esProcessorImpl) nackKafkaMsg(key string) {
 	p.ackKafkaMsgHelper(key, true)
 }
 
+// sendKafkaMsgToDLQ publishes the kafka message of a failed ES request to the visibility DLQ
+func (p *esProcessorImpl) sendKafkaMsgToDLQ(key string, reason string, status int) {
+	kafkaMsg, ok := p.getKafkaMsg(key)
+	if !ok {
+		return
+	}
+
+	if err := p.dlq.publish(kafkaMsg.message, reason, status); err != nil {
+		kafkaMsg.Nack()
+	} else {
+		kafkaMsg.Ack()
+	}
+
+	p.mapToKafkaMsg.Remove(key)
+}
+
 func (p *esProcessorImpl) ackKafkaMsgHelper(key string, nack bool) {
 	kafkaMsg, ok := p.getKafkaMsg(key)
 	if !ok {
@@ -228,37 +252,6 @@ func (p *esProcessorImpl) hashFn(key interface{}) uint32 {
 	return uint32(common.WorkflowIDToHistoryShard(id, numOfShards))
 }
 
-// 409 - Version Conflict
-// 404 - Not Found
-func isResponseSuccess(status int) bool {
-	if status >= 200 && status < 300 || status == 409 || status == 404 {
-		return true
-	}
-	return false
-}
-
-// isResponseRetriable is complaint with GenericBulkProcessorService.RetryItemStatusCodes
-// responses with these status will be kept in queue and retried until success
-// 408 - Request Timeout
-// 429 - Too Many Requests
-// 500 - Node not connected
-// 503 - Service Unavailable
-// 507 - Insufficient Storage
-var retryableStatusCode = map[int]struct{}{408: {}, 429: {}, 500: {}, 503: {}, 507: {}}
-
-func isResponseRetriable(status int) bool {
-	_, ok := retryableStatusCode[status]
-	return ok
-}
-
-func getErrorMsgFromESResp(resp *es.GenericBulkResponseItem) string {
-	var errMsg string
-	if resp.Error != nil {
-		errMsg = fmt.Sprintf("%v", resp.Error)
-	}
-	return errMsg
-}
-
 func newKafkaMessageWithMetrics(kafkaMsg messaging.Message, stopwatch *tally.Stopwatch) *kafkaMessageWithMetrics {
 	return &kafkaMessageWithMetrics{
 		message:        kafkaMsg,
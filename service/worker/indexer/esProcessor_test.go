@@ -22,6 +22,7 @@ package indexer
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -32,6 +33,7 @@ import (
 
 	"github.com/uber/cadence/.gen/go/indexer"
 	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/backoff"
 	"github.com/uber/cadence/common/codec"
 	"github.com/uber/cadence/common/collection"
 	"github.com/uber/cadence/common/dynamicconfig"
@@ -39,9 +41,11 @@ import (
 	es "github.com/uber/cadence/common/elasticsearch"
 	esMocks "github.com/uber/cadence/common/elasticsearch/mocks"
 	"github.com/uber/cadence/common/log/loggerimpl"
+	"github.com/uber/cadence/common/messaging"
 	msgMocks "github.com/uber/cadence/common/messaging/mocks"
 	"github.com/uber/cadence/common/metrics"
 	mmocks "github.com/uber/cadence/common/metrics/mocks"
+	"github.com/uber/cadence/common/mocks"
 )
 
 type esProcessorSuite struct {
@@ -50,6 +54,7 @@ type esProcessorSuite struct {
 	mockBulkProcessor *esMocks.GenericBulkProcessor
 	mockMetricClient  *mmocks.Client
 	mockESClient      *esMocks.GenericClient
+	mockDLQProducer   *mocks.KafkaProducer
 }
 
 var (
@@ -79,6 +84,7 @@ func (s *esProcessorSuite) SetupTest() {
 	}
 	s.mockMetricClient = &mmocks.Client{}
 	s.mockBulkProcessor = &esMocks.GenericBulkProcessor{}
+	s.mockDLQProducer = &mocks.KafkaProducer{}
 
 	zapLogger, err := zap.NewDevelopment()
 	s.Require().NoError(err)
@@ -91,6 +97,7 @@ func (s *esProcessorSuite) SetupTest() {
 	}
 	p.mapToKafkaMsg = collection.NewShardedConcurrentTxMap(1024, p.hashFn)
 	p.processor = s.mockBulkProcessor
+	p.dlq = newDLQHandler(s.mockDLQProducer, p.msgEncoder, s.mockMetricClient, p.logger)
 
 	s.esProcessor = p
 
@@ -101,6 +108,7 @@ func (s *esProcessorSuite) TearDownTest() {
 	s.mockBulkProcessor.AssertExpectations(s.T())
 	s.mockMetricClient.AssertExpectations(s.T())
 	s.mockESClient.AssertExpectations(s.T())
+	s.mockDLQProducer.AssertExpectations(s.T())
 }
 
 func (s *esProcessorSuite) TestNewESProcessorAndStart() {
@@ -122,7 +130,7 @@ func (s *esProcessorSuite) TestNewESProcessorAndStart() {
 		s.NotNil(input.AfterFunc)
 		return true
 	})).Return(&esMocks.GenericBulkProcessor{}, nil).Once()
-	processor, err := newESProcessorAndStart(config, s.mockESClient, processorName, s.esProcessor.logger, &mmocks.Client{}, codec.NewThriftRWEncoder())
+	processor, err := newESProcessorAndStart(config, s.mockESClient, processorName, s.esProcessor.dlq, s.esProcessor.logger, &mmocks.Client{}, codec.NewThriftRWEncoder())
 	s.NoError(err)
 
 	s.NotNil(processor.mapToKafkaMsg)
@@ -207,7 +215,7 @@ func (s *esProcessorSuite) TestBulkAfterActionX() {
 	mockKafkaMsg.AssertExpectations(s.T())
 }
 
-func (s *esProcessorSuite) TestBulkAfterAction_Nack() {
+func (s *esProcessorSuite) TestBulkAfterAction_DLQ() {
 	version := int64(3)
 	testKey := "testKey"
 	request := &esMocks.GenericBulkableRequest{}
@@ -234,11 +242,91 @@ func (s *esProcessorSuite) TestBulkAfterAction_Nack() {
 	domainID := "test-domainID"
 	payload := s.getEncodedMsg(wid, rid, domainID)
 
-	mockKafkaMsg := &msgMocks.Message{}
+	mockKafkaMsg := s.newMockKafkaMsg(payload)
+	mapVal := newKafkaMessageWithMetrics(mockKafkaMsg, &testStopWatch)
+	s.esProcessor.mapToKafkaMsg.Put(testKey, mapVal)
+	mockKafkaMsg.On("Ack").Return(nil).Once()
+	s.mockDLQProducer.On("Publish", mock.Anything, mock.MatchedBy(func(msg *messaging.DLQMessage) bool {
+		return msg.Status == 400 && msg.WorkflowID == wid && msg.RunID == rid && msg.DomainID == domainID &&
+			msg.Partition == 1 && msg.Offset == 2 && string(msg.Payload) == string(payload)
+	})).Return(nil).Once()
+	s.mockMetricClient.On("IncCounter", metrics.IndexProcessorScope, metrics.IndexProcessorDLQMessages).Once()
+	s.mockBulkProcessor.On("RetrieveKafkaKey", request, mock.Anything, mock.Anything).Return(testKey)
+	s.esProcessor.bulkAfterAction(0, requests, response, nil)
+	mockKafkaMsg.AssertExpectations(s.T())
+	s.Equal(0, s.esProcessor.mapToKafkaMsg.Len())
+}
+
+func (s *esProcessorSuite) TestBulkAfterAction_MappingConflict() {
+	testKey := "testKey"
+	request := &esMocks.GenericBulkableRequest{}
+	request.On("String").Return("")
+	requests := []es.GenericBulkableRequest{request}
+
+	mFailed := map[string]*es.GenericBulkResponseItem{
+		"index": {
+			Index:  testIndex,
+			Type:   testType,
+			ID:     testID,
+			Status: 400,
+			Error:  map[string]interface{}{"type": "mapper_parsing_exception", "reason": "failed to parse field [CustomIntField]"},
+		},
+	}
+	response := &es.GenericBulkResponse{
+		Took:   3,
+		Errors: true,
+		Items:  []map[string]*es.GenericBulkResponseItem{mFailed},
+	}
+
+	payload := s.getEncodedMsg("test-workflowID", "test-runID", "test-domainID")
+	mockKafkaMsg := s.newMockKafkaMsg(payload)
+	mapVal := newKafkaMessageWithMetrics(mockKafkaMsg, &testStopWatch)
+	s.esProcessor.mapToKafkaMsg.Put(testKey, mapVal)
+	mockKafkaMsg.On("Ack").Return(nil).Once()
+	s.mockDLQProducer.On("Publish", mock.Anything, mock.MatchedBy(func(msg *messaging.DLQMessage) bool {
+		return strings.HasPrefix(msg.Reason, "mapping conflict: ") && strings.Contains(msg.Reason, "CustomIntField")
+	})).Return(nil).Once()
+	s.mockMetricClient.On("IncCounter", metrics.ESProcessorScope, metrics.ESProcessorMappingConflicts).Once()
+	s.mockMetricClient.On("IncCounter", metrics.IndexProcessorScope, metrics.IndexProcessorDLQMessages).Once()
+	s.mockBulkProcessor.On("RetrieveKafkaKey", request, mock.Anything, mock.Anything).Return(testKey)
+	s.esProcessor.bulkAfterAction(0, requests, response, nil)
+	mockKafkaMsg.AssertExpectations(s.T())
+}
+
+func (s *esProcessorSuite) TestBulkAfterAction_DLQFailure() {
+	testKey := "testKey"
+	request := &esMocks.GenericBulkableRequest{}
+	request.On("String").Return("")
+	requests := []es.GenericBulkableRequest{request}
+
+	mFailed := map[string]*es.GenericBulkResponseItem{
+		"index": {
+			Index:  testIndex,
+			Type:   testType,
+			ID:     testID,
+			Status: 400,
+		},
+	}
+	response := &es.GenericBulkResponse{
+		Took:   3,
+		Errors: true,
+		Items:  []map[string]*es.GenericBulkResponseItem{mFailed},
+	}
+
+	retryPolicy := backoff.NewExponentialRetryPolicy(time.Millisecond)
+	retryPolicy.SetMaximumAttempts(2)
+	s.esProcessor.dlq.throttleRetry = backoff.NewThrottleRetry(
+		backoff.WithRetryPolicy(retryPolicy),
+		backoff.WithRetryableError(func(_ error) bool { return true }),
+	)
+
+	payload := s.getEncodedMsg("test-workflowID", "test-runID", "test-domainID")
+	mockKafkaMsg := s.newMockKafkaMsg(payload)
 	mapVal := newKafkaMessageWithMetrics(mockKafkaMsg, &testStopWatch)
 	s.esProcessor.mapToKafkaMsg.Put(testKey, mapVal)
 	mockKafkaMsg.On("Nack").Return(nil).Once()
-	mockKafkaMsg.On("Value").Return(payload).Once()
+	s.mockDLQProducer.On("Publish", mock.Anything, mock.Anything).Return(fmt.Errorf("some error")).Times(3)
+	s.mockMetricClient.On("IncCounter", metrics.IndexProcessorScope, metrics.IndexProcessorDLQFailures).Once()
 	s.mockBulkProcessor.On("RetrieveKafkaKey", request, mock.Anything, mock.Anything).Return(testKey)
 	s.esProcessor.bulkAfterAction(0, requests, response, nil)
 	mockKafkaMsg.AssertExpectations(s.T())
@@ -271,14 +359,17 @@ func (s *esProcessorSuite) TestBulkAfterAction_Error() {
 	domainID := "test-domainID"
 	payload := s.getEncodedMsg(wid, rid, domainID)
 
-	mockKafkaMsg := &msgMocks.Message{}
+	mockKafkaMsg := s.newMockKafkaMsg(payload)
 	mapVal := newKafkaMessageWithMetrics(mockKafkaMsg, &testStopWatch)
 	s.esProcessor.mapToKafkaMsg.Put(testKey, mapVal)
-	mockKafkaMsg.On("Nack").Return(nil).Once()
-	mockKafkaMsg.On("Value").Return(payload).Once()
+	mockKafkaMsg.On("Ack").Return(nil).Once()
+	s.mockDLQProducer.On("Publish", mock.Anything, mock.MatchedBy(func(msg *messaging.DLQMessage) bool {
+		return msg.Reason == "some error" && msg.Status == 400
+	})).Return(nil).Once()
 	s.mockMetricClient.On("IncCounter", metrics.ESProcessorScope, metrics.ESProcessorFailures).Once()
+	s.mockMetricClient.On("IncCounter", metrics.IndexProcessorScope, metrics.IndexProcessorDLQMessages).Once()
 	s.mockBulkProcessor.On("RetrieveKafkaKey", request, mock.Anything, mock.Anything).Return(testKey)
-	s.esProcessor.bulkAfterAction(0, requests, response, &es.GenericError{Details: fmt.Errorf("some error")})
+	s.esProcessor.bulkAfterAction(0, requests, response, &es.GenericError{Status: 400, Details: fmt.Errorf("some error")})
 }
 
 func (s *esProcessorSuite) TestAckKafkaMsg() {
@@ -322,6 +413,14 @@ func (s *esProcessorSuite) TestHashFn() {
 	s.NotEqual(uint32(0), s.esProcessor.hashFn("test"))
 }
 
+func (s *esProcessorSuite) newMockKafkaMsg(payload []byte) *msgMocks.Message {
+	mockKafkaMsg := &msgMocks.Message{}
+	mockKafkaMsg.On("Value").Return(payload)
+	mockKafkaMsg.On("Partition").Return(int32(1))
+	mockKafkaMsg.On("Offset").Return(int64(2))
+	return mockKafkaMsg
+}
+
 func (s *esProcessorSuite) getEncodedMsg(wid string, rid string, domainID string) []byte {
 	indexMsg := &indexer.Message{
 		DomainID:   common.StringPtr(domainID),
@@ -364,22 +463,22 @@ func (s *esProcessorSuite) TestGetMsgInfo_Error() {
 
 func (s *esProcessorSuite) TestIsResponseSuccess() {
 	for i := 200; i < 300; i++ {
-		s.True(isResponseSuccess(i))
+		s.True(es.IsResponseSuccess(i))
 	}
 	status := []int{409, 404}
 	for _, code := range status {
-		s.True(isResponseSuccess(code))
+		s.True(es.IsResponseSuccess(code))
 	}
 	status = []int{100, 199, 300, 400, 500, 408, 429, 503, 507}
 	for _, code := range status {
-		s.False(isResponseSuccess(code))
+		s.False(es.IsResponseSuccess(code))
 	}
 }
 
 func (s *esProcessorSuite) TestIsResponseRetriable() {
 	status := []int{408, 429, 500, 503, 507}
 	for _, code := range status {
-		s.True(isResponseRetriable(code))
+		s.True(es.IsResponseRetriable(code))
 	}
 }
 
@@ -402,6 +501,6 @@ func (s *esProcessorSuite) TestIsErrorRetriable() {
 		},
 	}
 	for _, test := range tests {
-		s.Equal(test.expected, isResponseRetriable(test.input.Status))
+		s.Equal(test.expected, es.IsResponseRetriable(test.input.Status))
 	}
 }
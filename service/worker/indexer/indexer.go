@@ -43,6 +43,7 @@ type (
 		metricsClient       metrics.Client
 		visibilityProcessor *indexProcessor
 		visibilityIndexName string
+		indexResolver       es.IndexResolver
 	}
 
 	// Config contains all configs for indexer
@@ -53,6 +54,7 @@ type (
 		ESProcessorBulkSize      dynamicconfig.IntPropertyFn // max total size of bytes in bulk
 		ESProcessorFlushInterval dynamicconfig.DurationPropertyFn
 		ValidSearchAttributes    dynamicconfig.MapPropertyFn
+		SearchAttributeAliases   dynamicconfig.MapPropertyFn
 	}
 )
 
@@ -78,6 +80,7 @@ func NewIndexer(
 		logger:              logger,
 		metricsClient:       metricsClient,
 		visibilityIndexName: esConfig.Indices[common.VisibilityAppName],
+		indexResolver:       es.NewIndexResolver(esClient, esConfig, logger),
 	}
 }
 
@@ -85,14 +88,16 @@ func NewIndexer(
 func (x *Indexer) Start() error {
 	visibilityApp := common.VisibilityAppName
 	visConsumerName := getConsumerName(x.visibilityIndexName)
+	x.indexResolver.Start()
 	x.visibilityProcessor = newIndexProcessor(visibilityApp, visConsumerName, x.kafkaClient, x.esClient,
-		visibilityProcessorName, x.visibilityIndexName, x.config, x.logger, x.metricsClient)
+		visibilityProcessorName, x.indexResolver, x.config, x.logger, x.metricsClient)
 	return x.visibilityProcessor.Start()
 }
 
 // Stop indexer
 func (x *Indexer) Stop() {
 	x.visibilityProcessor.Stop()
+	x.indexResolver.Stop()
 }
 
 func getConsumerName(topic string) string {
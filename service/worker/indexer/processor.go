@@ -21,7 +21,7 @@
 package indexer
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -47,8 +47,9 @@ type indexProcessor struct {
 	consumer        messaging.Consumer
 	esClient        es.GenericClient
 	esProcessor     *esProcessorImpl
+	dlq             *dlqHandler
 	esProcessorName string
-	esIndexName     string
+	indexResolver   es.IndexResolver
 	config          *Config
 	logger          log.Logger
 	metricsClient   metrics.Client
@@ -68,14 +69,14 @@ var (
 )
 
 func newIndexProcessor(appName, consumerName string, kafkaClient messaging.Client, esClient es.GenericClient,
-	esProcessorName, esIndexName string, config *Config, logger log.Logger, metricsClient metrics.Client) *indexProcessor {
+	esProcessorName string, indexResolver es.IndexResolver, config *Config, logger log.Logger, metricsClient metrics.Client) *indexProcessor {
 	return &indexProcessor{
 		appName:         appName,
 		consumerName:    consumerName,
 		kafkaClient:     kafkaClient,
 		esClient:        esClient,
 		esProcessorName: esProcessorName,
-		esIndexName:     esIndexName,
+		indexResolver:   indexResolver,
 		config:          config,
 		logger:          logger.WithTags(tag.ComponentIndexerProcessor),
 		metricsClient:   metricsClient,
@@ -101,7 +102,14 @@ func (p *indexProcessor) Start() error {
 		return err
 	}
 
-	esProcessor, err := newESProcessorAndStart(p.config, p.esClient, p.esProcessorName, p.logger, p.metricsClient, p.msgEncoder)
+	dlqProducer, err := p.kafkaClient.NewDLQProducer(p.appName)
+	if err != nil {
+		p.logger.Info("Index processor state changed", tag.LifeCycleStartFailed, tag.Error(err))
+		return err
+	}
+	p.dlq = newDLQHandler(dlqProducer, p.msgEncoder, p.metricsClient, p.logger)
+
+	esProcessor, err := newESProcessorAndStart(p.config, p.esClient, p.esProcessorName, p.dlq, p.logger, p.metricsClient, p.msgEncoder)
 	if err != nil {
 		p.logger.Info("Index processor state changed", tag.LifeCycleStartFailed, tag.Error(err))
 		return err
@@ -161,7 +169,7 @@ func (p *indexProcessor) messageProcessLoop(workerWG *sync.WaitGroup) {
 		err := p.process(msg)
 		sw.Stop()
 		if err != nil {
-			msg.Nack() //nolint:errcheck
+			p.dlq.sendToDLQ(msg, err.Error(), 0)
 		}
 	}
 }
@@ -195,108 +203,113 @@ func (p *indexProcessor) addMessageToES(indexMsg *indexer.Message, kafkaMsg mess
 			tag.WorkflowDomainID(indexMsg.GetDomainID()),
 			tag.WorkflowID(indexMsg.GetWorkflowID()),
 			tag.WorkflowRunID(indexMsg.GetRunID()))
-		kafkaMsg.Nack()
+		p.dlq.sendToDLQ(kafkaMsg, "document ID exceeds size limit", 0)
 		return nil
 	}
 
-	var keyToKafkaMsg string
-	req := &es.GenericBulkableAddRequest{
-		Index:       p.esIndexName,
-		Type:        elasticsearch.GetESDocType(),
-		ID:          docID,
-		VersionType: versionTypeExternal,
-		Version:     indexMsg.GetVersion(),
-	}
+	var indices []string
 	switch indexMsg.GetMessageType() {
-	case indexer.MessageTypeIndex:
-		keyToKafkaMsg = fmt.Sprintf("%v-%v", kafkaMsg.Partition(), kafkaMsg.Offset())
-		doc := p.generateESDoc(indexMsg, keyToKafkaMsg)
-		req.Doc = doc
-		req.RequestType = es.BulkableIndexRequest
+	case indexer.MessageTypeIndex, indexer.MessageTypeCreate:
+		indices = p.indexResolver.GetWriteIndices(indexMsg.Fields[es.StartTime].GetIntData())
 	case indexer.MessageTypeDelete:
-		keyToKafkaMsg = docID
-		req.RequestType = es.BulkableDeleteRequest
-	case indexer.MessageTypeCreate:
-		keyToKafkaMsg = fmt.Sprintf("%v-%v", kafkaMsg.Partition(), kafkaMsg.Offset())
-		doc := p.generateESDoc(indexMsg, keyToKafkaMsg)
-		req.Doc = doc
-		req.RequestType = es.BulkableCreateRequest
+		indices = p.indexResolver.GetDeleteIndices()
 	default:
 		logger.Error("Unknown message type")
 		p.metricsClient.IncCounter(metrics.IndexProcessorScope, metrics.IndexProcessorCorruptedData)
 		return errUnknownMessageType
 	}
+	if len(indices) > 1 {
+		// the kafka message is acked once the requests of all indices are done
+		kafkaMsg = newFanoutMessage(kafkaMsg, len(indices))
+	}
 
-	p.esProcessor.Add(req, keyToKafkaMsg, kafkaMsg)
+	for i, index := range indices {
+		var keyToKafkaMsg string
+		req := &es.GenericBulkableAddRequest{
+			Index:       index,
+			Type:        elasticsearch.GetESDocType(),
+			ID:          docID,
+			VersionType: versionTypeExternal,
+			Version:     indexMsg.GetVersion(),
+		}
+		switch indexMsg.GetMessageType() {
+		case indexer.MessageTypeIndex:
+			keyToKafkaMsg = getKeyToKafkaMsg(kafkaMsg, i)
+			doc := p.generateESDoc(indexMsg, keyToKafkaMsg)
+			req.Doc = doc
+			req.RequestType = es.BulkableIndexRequest
+		case indexer.MessageTypeDelete:
+			keyToKafkaMsg = es.GenerateDeleteKey(index, docID)
+			req.RequestType = es.BulkableDeleteRequest
+		case indexer.MessageTypeCreate:
+			keyToKafkaMsg = getKeyToKafkaMsg(kafkaMsg, i)
+			doc := p.generateESDoc(indexMsg, keyToKafkaMsg)
+			req.Doc = doc
+			req.RequestType = es.BulkableCreateRequest
+		}
+		p.esProcessor.Add(req, keyToKafkaMsg, kafkaMsg)
+	}
 	return nil
 }
 
+// getKeyToKafkaMsg returns the key of the request of a kafka message to the i-th index
+func getKeyToKafkaMsg(kafkaMsg messaging.Message, i int) string {
+	if i == 0 {
+		return fmt.Sprintf("%v-%v", kafkaMsg.Partition(), kafkaMsg.Offset())
+	}
+	return fmt.Sprintf("%v-%v-%v", kafkaMsg.Partition(), kafkaMsg.Offset(), i)
+}
+
 func (p *indexProcessor) generateESDoc(msg *indexer.Message, keyToKafkaMsg string) map[string]interface{} {
-	doc := p.dumpFieldsToMap(msg.Fields)
-	fulfillDoc(doc, msg, keyToKafkaMsg)
-	return doc
+	return es.GenerateVisibilityDoc(msg, keyToKafkaMsg, p.resolveAlias, p.isValidFieldToES, p.onInvalidField)
 }
 
-func (p *indexProcessor) decodeSearchAttrBinary(bytes []byte, key string) interface{} {
-	var val interface{}
-	err := json.Unmarshal(bytes, &val)
-	if err != nil {
-		p.logger.Error("Error when decode search attributes values.", tag.Error(err), tag.ESField(key))
-		p.metricsClient.IncCounter(metrics.IndexProcessorScope, metrics.IndexProcessorCorruptedData)
+func (p *indexProcessor) onInvalidField(field string, err error) {
+	switch {
+	case errors.Is(err, es.ErrUnknownFieldType):
+		// must be bug in code and bad deployment, check data sent from producer
+		p.logger.Fatal("Unknown field type", tag.ESField(field))
+	case errors.Is(err, es.ErrUnregisteredField):
+		p.logger.Error("Unregistered field.", tag.ESField(field))
+	default:
+		p.logger.Error("Error when decode search attributes values.", tag.Error(err), tag.ESField(field))
 	}
-	return val
+	p.metricsClient.IncCounter(metrics.IndexProcessorScope, metrics.IndexProcessorCorruptedData)
 }
 
-func (p *indexProcessor) dumpFieldsToMap(fields map[string]*indexer.Field) map[string]interface{} {
-	doc := make(map[string]interface{})
-	attr := make(map[string]interface{})
-	for k, v := range fields {
-		if !p.isValidFieldToES(k) {
-			p.logger.Error("Unregistered field.", tag.ESField(k))
-			p.metricsClient.IncCounter(metrics.IndexProcessorScope, metrics.IndexProcessorCorruptedData)
-			continue
-		}
+func (p *indexProcessor) resolveAlias(field string) string {
+	return definition.ResolveSearchAttributeAlias(p.config.SearchAttributeAliases(), field)
+}
 
-		// skip VisibilityOperation since it’s not being used for advanced visibility
-		if k == es.VisibilityOperation {
-			continue
-		}
+func (p *indexProcessor) isValidFieldToES(field string) bool {
+	return es.IsValidVisibilityField(field, p.config.ValidSearchAttributes())
+}
 
-		switch v.GetType() {
-		case indexer.FieldTypeString:
-			doc[k] = v.GetStringData()
-		case indexer.FieldTypeInt:
-			doc[k] = v.GetIntData()
-		case indexer.FieldTypeBool:
-			doc[k] = v.GetBoolData()
-		case indexer.FieldTypeBinary:
-			if k == definition.Memo {
-				doc[k] = v.GetBinaryData()
-			} else { // custom search attributes
-				attr[k] = p.decodeSearchAttrBinary(v.GetBinaryData(), k)
-			}
-		default:
-			// must be bug in code and bad deployment, check data sent from producer
-			p.logger.Fatal("Unknown field type")
-		}
-	}
-	doc[definition.Attr] = attr
-	return doc
+// fanoutMessage is a kafka message written to several indices, the message is acked
+// when all requests are acked and nacked when any request is nacked
+type fanoutMessage struct {
+	messaging.Message
+	pending int32
+	nacked  int32
 }
 
-func (p *indexProcessor) isValidFieldToES(field string) bool {
-	if _, ok := p.config.ValidSearchAttributes()[field]; ok {
-		return true
+func newFanoutMessage(kafkaMsg messaging.Message, numRequests int) *fanoutMessage {
+	return &fanoutMessage{
+		Message: kafkaMsg,
+		pending: int32(numRequests),
 	}
-	if field == definition.Memo || field == definition.KafkaKey || field == definition.Encoding || field == es.VisibilityOperation {
-		return true
+}
+
+func (m *fanoutMessage) Ack() error {
+	if atomic.AddInt32(&m.pending, -1) == 0 && atomic.LoadInt32(&m.nacked) == 0 {
+		return m.Message.Ack()
 	}
-	return false
+	return nil
 }
 
-func fulfillDoc(doc map[string]interface{}, msg *indexer.Message, keyToKafkaMsg string) {
-	doc[definition.DomainID] = msg.GetDomainID()
-	doc[definition.WorkflowID] = msg.GetWorkflowID()
-	doc[definition.RunID] = msg.GetRunID()
-	doc[definition.KafkaKey] = keyToKafkaMsg
+func (m *fanoutMessage) Nack() error {
+	if atomic.CompareAndSwapInt32(&m.nacked, 0, 1) {
+		return m.Message.Nack()
+	}
+	return nil
 }
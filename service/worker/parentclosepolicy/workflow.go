@@ -39,6 +39,7 @@ import (
 	"github.com/uber/cadence/common/log/tag"
 	"github.com/uber/cadence/common/metrics"
 	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/service/worker/workercommon"
 )
 
 type (
@@ -99,6 +100,15 @@ func init() {
 
 // ProcessorWorkflow is the workflow that performs actions for ParentClosePolicy
 func ProcessorWorkflow(ctx workflow.Context) error {
+	// the total is unknown as requests keep being signaled while the workflow is running
+	progress := workercommon.NewProgress(workflow.Now(ctx))
+	if err := workercommon.SetProgressQueryHandler(ctx, func() *workercommon.Progress {
+		return progress
+	}); err != nil {
+		return err
+	}
+	progress.SetPhase(workercommon.ProgressPhaseRunning, workflow.Now(ctx))
+
 	requestCh := workflow.GetSignalChannel(ctx, processorChannelName)
 	for {
 		var request Request
@@ -108,8 +118,14 @@ func ProcessorWorkflow(ctx workflow.Context) error {
 		}
 
 		opt := workflow.WithActivityOptions(ctx, activityOptions)
-		_ = workflow.ExecuteActivity(opt, processorActivityName, request).Get(ctx, nil)
+		if err := workflow.ExecuteActivity(opt, processorActivityName, request).Get(ctx, nil); err != nil {
+			progress.Add(int64(len(request.Executions)), int64(len(request.Executions)), workflow.Now(ctx))
+			progress.AddError(err.Error(), workflow.Now(ctx))
+		} else {
+			progress.Add(int64(len(request.Executions)), 0, workflow.Now(ctx))
+		}
 	}
+	progress.SetPhase(workercommon.ProgressPhaseCompleted, workflow.Now(ctx))
 	return nil
 }
 
@@ -30,6 +30,7 @@ import (
 	cclient "go.uber.org/cadence/client"
 	"go.uber.org/cadence/workflow"
 
+	"github.com/uber/cadence/client/history"
 	"github.com/uber/cadence/common/blobstore"
 	"github.com/uber/cadence/common/cache"
 	"github.com/uber/cadence/common/dynamicconfig"
@@ -42,14 +43,20 @@ import (
 
 const (
 	// ConcreteExecutionsScannerWFTypeName defines workflow type name for concrete executions scanner
-	ConcreteExecutionsScannerWFTypeName   = "cadence-sys-executions-scanner-workflow"
-	concreteExecutionsScannerWFID         = "cadence-sys-executions-scanner"
-	concreteExecutionsScannerTaskListName = "cadence-sys-executions-scanner-tasklist-0"
+	ConcreteExecutionsScannerWFTypeName = "cadence-sys-executions-scanner-workflow"
+	concreteExecutionsScannerWFID       = "cadence-sys-executions-scanner"
+	// ConcreteExecutionsScannerTaskListName is the task list of concrete executions scanner
+	ConcreteExecutionsScannerTaskListName = "cadence-sys-executions-scanner-tasklist-0"
+	// ConcreteExecutionsScannerCLIWFID is the workflow id of the concrete executions scanner started from the CLI
+	ConcreteExecutionsScannerCLIWFID = "cadence-sys-executions-scanner-cli"
 
 	// ConcreteExecutionsFixerWFTypeName defines workflow type name for concrete executions fixer
-	ConcreteExecutionsFixerWFTypeName   = "cadence-sys-executions-fixer-workflow"
-	concreteExecutionsFixerWFID         = "cadence-sys-executions-fixer"
-	concreteExecutionsFixerTaskListName = "cadence-sys-executions-fixer-tasklist-0"
+	ConcreteExecutionsFixerWFTypeName = "cadence-sys-executions-fixer-workflow"
+	concreteExecutionsFixerWFID       = "cadence-sys-executions-fixer"
+	// ConcreteExecutionsFixerTaskListName is the task list of concrete executions fixer
+	ConcreteExecutionsFixerTaskListName = "cadence-sys-executions-fixer-tasklist-0"
+	// ConcreteExecutionsFixerCLIWFID is the workflow id of the concrete executions fixer started from the CLI
+	ConcreteExecutionsFixerCLIWFID = "cadence-sys-executions-fixer-cli"
 )
 
 // ConcreteScannerWorkflow starts concrete executions scanner.
@@ -106,8 +113,15 @@ func ScannerManager(
 
 	collections := ParseCollections(params.ScannerConfig)
 
+	var historyClient history.Client
+	if containsCollection(collections, invariant.CollectionWorkflowState) {
+		if scannerCtx, err := shardscanner.GetScannerContext(ctx); err == nil {
+			historyClient = scannerCtx.Resource.GetHistoryClient()
+		}
+	}
+
 	var ivs []invariant.Invariant
-	for _, fn := range ConcreteExecutionType.ToInvariants(collections) {
+	for _, fn := range ConcreteExecutionType.ToInvariants(collections, historyClient, nil) {
 		ivs = append(ivs, fn(pr, domainCache))
 	}
 
@@ -131,13 +145,29 @@ func FixerIterator(ctx context.Context, client blobstore.Client, keys store.Keys
 }
 
 // FixerManager provides invariant manager for concrete execution fixer.
-func FixerManager(_ context.Context, pr persistence.Retryer, _ shardscanner.FixShardActivityParams, domainCache cache.DomainCache) invariant.Manager {
+// The collections passed in the fixer config take precedence over dynamic config.
+func FixerManager(ctx context.Context, pr persistence.Retryer, params shardscanner.FixShardActivityParams, domainCache cache.DomainCache) invariant.Manager {
 	var ivs []invariant.Invariant
 	var collections []invariant.Collection
 
-	collections = append(collections, invariant.CollectionHistory, invariant.CollectionMutableState)
+	fixerCtx, err := shardscanner.GetFixerContext(ctx)
+	if customConfig := params.ResolvedFixerWorkflowConfig.CustomFixerConfig; len(customConfig) > 0 {
+		collections = ParseCollections(customConfig)
+	} else {
+		collections = append(collections, invariant.CollectionHistory, invariant.CollectionMutableState)
+		if err == nil && fixerCtx.Config.DynamicCollection.GetBoolProperty(dynamicconfig.ConcreteExecutionsScannerInvariantCollectionWorkflowState)() {
+			collections = append(collections, invariant.CollectionWorkflowState)
+		}
+	}
+
+	var historyClient history.Client
+	var terminateAllowed dynamicconfig.BoolPropertyFnWithDomainFilter
+	if err == nil && containsCollection(collections, invariant.CollectionWorkflowState) {
+		historyClient = fixerCtx.Resource.GetHistoryClient()
+		terminateAllowed = fixerCtx.Config.DynamicCollection.GetBoolPropertyFilteredByDomain(dynamicconfig.ConcreteExecutionFixerTerminateDomainAllow)
+	}
 
-	for _, fn := range ConcreteExecutionType.ToInvariants(collections) {
+	for _, fn := range ConcreteExecutionType.ToInvariants(collections, historyClient, terminateAllowed) {
 		ivs = append(ivs, fn(pr, domainCache))
 	}
 	return invariant.NewInvariantManager(ivs)
@@ -153,6 +183,9 @@ func ConcreteExecutionConfig(ctx shardscanner.Context) shardscanner.CustomScanne
 	if ctx.Config.DynamicCollection.GetBoolProperty(dynamicconfig.ConcreteExecutionsScannerInvariantCollectionMutableState)() {
 		res[invariant.CollectionMutableState.String()] = strconv.FormatBool(true)
 	}
+	if ctx.Config.DynamicCollection.GetBoolProperty(dynamicconfig.ConcreteExecutionsScannerInvariantCollectionWorkflowState)() {
+		res[invariant.CollectionWorkflowState.String()] = strconv.FormatBool(true)
+	}
 
 	return res
 }
@@ -176,14 +209,14 @@ func ConcreteExecutionScannerConfig(dc *dynamicconfig.Collection) *shardscanner.
 		FixerHooks:        ConcreteExecutionFixerHooks,
 		StartWorkflowOptions: cclient.StartWorkflowOptions{
 			ID:                           concreteExecutionsScannerWFID,
-			TaskList:                     concreteExecutionsScannerTaskListName,
+			TaskList:                     ConcreteExecutionsScannerTaskListName,
 			ExecutionStartToCloseTimeout: 20 * 365 * 24 * time.Hour,
 			WorkflowIDReusePolicy:        cclient.WorkflowIDReusePolicyAllowDuplicate,
 			CronSchedule:                 "* * * * *",
 		},
 		StartFixerOptions: cclient.StartWorkflowOptions{
 			ID:                           concreteExecutionsFixerWFID,
-			TaskList:                     concreteExecutionsFixerTaskListName,
+			TaskList:                     ConcreteExecutionsFixerTaskListName,
 			ExecutionStartToCloseTimeout: 20 * 365 * 24 * time.Hour,
 			WorkflowIDReusePolicy:        cclient.WorkflowIDReusePolicyAllowDuplicate,
 			CronSchedule:                 "* * * * *",
@@ -32,6 +32,7 @@ import (
 	"github.com/uber/cadence/common/reconciliation/store"
 
 	"github.com/uber/cadence/service/worker/scanner/shardscanner"
+	"github.com/uber/cadence/service/worker/workercommon"
 
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/cadence/testsuite"
@@ -234,4 +235,14 @@ func (s *concreteExectionsWorkflowsSuite) TestScannerWorkflow_Success() {
 		}
 	}
 	s.Equal(shardscanner.ShardCorruptKeysResult(expectedCorrupted), shardCorruptKeysResult.Result)
+	progressValue, err := env.QueryWorkflow(workercommon.ProgressQueryType)
+	s.NoError(err)
+	var progress workercommon.Progress
+	s.NoError(progressValue.Get(&progress))
+	s.Equal(workercommon.ProgressPhaseCompleted, progress.Phase)
+	s.Equal(int64(30), progress.Total)
+	s.Equal(int64(30), progress.Processed)
+	s.Equal(int64(6), progress.Failed)
+	s.Len(progress.LastErrors, 6)
+
 }
@@ -88,7 +88,7 @@ func CurrentExecutionManager(
 ) invariant.Manager {
 	var ivs []invariant.Invariant
 	collections := ParseCollections(params.ScannerConfig)
-	for _, fn := range CurrentExecutionType.ToInvariants(collections) {
+	for _, fn := range CurrentExecutionType.ToInvariants(collections, nil, nil) {
 		ivs = append(ivs, fn(pr, domainCache))
 	}
 	return invariant.NewInvariantManager(ivs)
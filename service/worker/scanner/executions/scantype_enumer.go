@@ -29,11 +29,11 @@ import (
 	"strings"
 )
 
-const _ScanTypeName = "ConcreteExecutionTypeCurrentExecutionType"
+const _ScanTypeName = "ConcreteExecutionTypeCurrentExecutionTypeVisibilityExecutionType"
 
-var _ScanTypeIndex = [...]uint8{0, 21, 41}
+var _ScanTypeIndex = [...]uint8{0, 21, 41, 64}
 
-const _ScanTypeLowerName = "concreteexecutiontypecurrentexecutiontype"
+const _ScanTypeLowerName = "concreteexecutiontypecurrentexecutiontypevisibilityexecutiontype"
 
 func (i ScanType) String() string {
 	if i < 0 || i >= ScanType(len(_ScanTypeIndex)-1) {
@@ -48,20 +48,24 @@ func _ScanTypeNoOp() {
 	var x [1]struct{}
 	_ = x[ConcreteExecutionType-(0)]
 	_ = x[CurrentExecutionType-(1)]
+	_ = x[VisibilityExecutionType-(2)]
 }
 
-var _ScanTypeValues = []ScanType{ConcreteExecutionType, CurrentExecutionType}
+var _ScanTypeValues = []ScanType{ConcreteExecutionType, CurrentExecutionType, VisibilityExecutionType}
 
 var _ScanTypeNameToValueMap = map[string]ScanType{
 	_ScanTypeName[0:21]:       ConcreteExecutionType,
 	_ScanTypeLowerName[0:21]:  ConcreteExecutionType,
 	_ScanTypeName[21:41]:      CurrentExecutionType,
 	_ScanTypeLowerName[21:41]: CurrentExecutionType,
+	_ScanTypeName[41:64]:      VisibilityExecutionType,
+	_ScanTypeLowerName[41:64]: VisibilityExecutionType,
 }
 
 var _ScanTypeNames = []string{
 	_ScanTypeName[0:21],
 	_ScanTypeName[21:41],
+	_ScanTypeName[41:64],
 }
 
 // ScanTypeString retrieves an enum value from the enum constants string name.
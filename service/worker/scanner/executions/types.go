@@ -30,7 +30,9 @@ import (
 
 	"github.com/uber/cadence/service/worker/scanner/shardscanner"
 
+	"github.com/uber/cadence/client/history"
 	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/dynamicconfig"
 	"github.com/uber/cadence/common/pagination"
 	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/reconciliation/entity"
@@ -43,6 +45,8 @@ const (
 	ConcreteExecutionType ScanType = iota
 	// CurrentExecutionType current execution entity
 	CurrentExecutionType
+	// VisibilityExecutionType concrete execution entity checked against its visibility record
+	VisibilityExecutionType
 )
 
 // ScanType is the enum for representing different entity types to scan
@@ -59,7 +63,7 @@ type (
 // ToBlobstoreEntity picks struct depending on scanner type.
 func (st ScanType) ToBlobstoreEntity() entity.Entity {
 	switch st {
-	case ConcreteExecutionType:
+	case ConcreteExecutionType, VisibilityExecutionType:
 		return &entity.ConcreteExecution{}
 	case CurrentExecutionType:
 		return &entity.CurrentExecution{}
@@ -70,7 +74,7 @@ func (st ScanType) ToBlobstoreEntity() entity.Entity {
 // ToIterator selects appropriate iterator. It will panic if scan type is unknown.
 func (st ScanType) ToIterator() func(ctx context.Context, retryer persistence.Retryer, pageSize int) pagination.Iterator {
 	switch st {
-	case ConcreteExecutionType:
+	case ConcreteExecutionType, VisibilityExecutionType:
 		return fetcher.ConcreteExecutionIterator
 	case CurrentExecutionType:
 		return fetcher.CurrentExecutionIterator
@@ -82,7 +86,7 @@ func (st ScanType) ToIterator() func(ctx context.Context, retryer persistence.Re
 // ToExecutionFetcher selects appropriate execution fetcher. Fetcher returns single execution entity. It will panic if scan type is unknown.
 func (st ScanType) ToExecutionFetcher() ExecutionFetcher {
 	switch st {
-	case ConcreteExecutionType:
+	case ConcreteExecutionType, VisibilityExecutionType:
 		return fetcher.ConcreteExecution
 	case CurrentExecutionType:
 		return fetcher.CurrentExecution
@@ -92,7 +96,14 @@ func (st ScanType) ToExecutionFetcher() ExecutionFetcher {
 }
 
 // ToInvariants returns list of invariants to be checked depending on scan type.
-func (st ScanType) ToInvariants(collections []invariant.Collection) []InvariantFactory {
+// Invariants of CollectionWorkflowState are fixed through history service, so they are only returned if historyClient is provided.
+// They only terminate executions which cannot be reset in domains for which terminateAllowed is true, nil allows none.
+// Invariants of VisibilityExecutionType also need a visibility manager, so they are only created by the visibility scanner and fixer.
+func (st ScanType) ToInvariants(
+	collections []invariant.Collection,
+	historyClient history.Client,
+	terminateAllowed dynamicconfig.BoolPropertyFnWithDomainFilter,
+) []InvariantFactory {
 	var fns []InvariantFactory
 	switch st {
 	case ConcreteExecutionType:
@@ -103,6 +114,17 @@ func (st ScanType) ToInvariants(collections []invariant.Collection) []InvariantF
 				fns = append(fns, invariant.NewHistoryExists)
 			case invariant.CollectionMutableState:
 				fns = append(fns, invariant.NewOpenCurrentExecution)
+			case invariant.CollectionWorkflowState:
+				if historyClient == nil {
+					continue
+				}
+				fns = append(
+					fns,
+					withTerminateAllowed(invariant.NewScheduledEventsExist, historyClient, terminateAllowed),
+					withTerminateAllowed(invariant.NewNextEventIDMatchesHistory, historyClient, terminateAllowed),
+					withHistoryClient(invariant.NewStuckWorkflow, historyClient),
+					withHistoryClient(invariant.NewParentClosePolicyApplied, historyClient),
+				)
 			}
 		}
 		return fns
@@ -114,11 +136,32 @@ func (st ScanType) ToInvariants(collections []invariant.Collection) []InvariantF
 			}
 		}
 		return fns
+	case VisibilityExecutionType:
+		return nil
 	default:
 		panic("unknown scan type")
 	}
 }
 
+func withHistoryClient(
+	fn func(persistence.Retryer, cache.DomainCache, history.Client) invariant.Invariant,
+	historyClient history.Client,
+) InvariantFactory {
+	return func(retryer persistence.Retryer, domainCache cache.DomainCache) invariant.Invariant {
+		return fn(retryer, domainCache, historyClient)
+	}
+}
+
+func withTerminateAllowed(
+	fn func(persistence.Retryer, cache.DomainCache, history.Client, dynamicconfig.BoolPropertyFnWithDomainFilter) invariant.Invariant,
+	historyClient history.Client,
+	terminateAllowed dynamicconfig.BoolPropertyFnWithDomainFilter,
+) InvariantFactory {
+	return func(retryer persistence.Retryer, domainCache cache.DomainCache) invariant.Invariant {
+		return fn(retryer, domainCache, historyClient, terminateAllowed)
+	}
+}
+
 // ParseCollections converts string based map to list of collections
 func ParseCollections(params shardscanner.CustomScannerConfig) []invariant.Collection {
 	var collections []invariant.Collection
@@ -135,3 +178,12 @@ func ParseCollections(params shardscanner.CustomScannerConfig) []invariant.Colle
 	}
 	return collections
 }
+
+func containsCollection(collections []invariant.Collection, collection invariant.Collection) bool {
+	for _, c := range collections {
+		if c == collection {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package executions
+
+import (
+	"context"
+	"time"
+
+	cclient "go.uber.org/cadence/client"
+	"go.uber.org/cadence/workflow"
+
+	"github.com/uber/cadence/common/blobstore"
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/pagination"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/reconciliation/fetcher"
+	"github.com/uber/cadence/common/reconciliation/invariant"
+	"github.com/uber/cadence/common/reconciliation/store"
+	"github.com/uber/cadence/common/resource"
+	"github.com/uber/cadence/service/worker/scanner/shardscanner"
+)
+
+const (
+	// VisibilityScannerWFTypeName defines workflow type name for visibility scanner
+	VisibilityScannerWFTypeName = "cadence-sys-visibility-scanner-workflow"
+	// VisibilityScannerTaskListName is the task list of visibility scanner
+	VisibilityScannerTaskListName = "cadence-sys-visibility-scanner-tasklist-0"
+	visibilityScannerWFID         = "cadence-sys-visibility-scanner"
+
+	// VisibilityFixerWFTypeName defines workflow type name for visibility fixer
+	VisibilityFixerWFTypeName = "cadence-sys-visibility-fixer-workflow"
+	// VisibilityFixerTaskListName is the task list of visibility fixer
+	VisibilityFixerTaskListName = "cadence-sys-visibility-fixer-tasklist-0"
+	visibilityFixerWFID         = "cadence-sys-visibility-fixer"
+
+	// VisibilityScannerDomainIDKey is the custom scanner config key which limits the scan to a single domain
+	VisibilityScannerDomainIDKey = "domain_id"
+)
+
+// VisibilityScannerWorkflow starts visibility scanner.
+func VisibilityScannerWorkflow(ctx workflow.Context, params shardscanner.ScannerWorkflowParams) error {
+	wf, err := shardscanner.NewScannerWorkflow(ctx, VisibilityScannerWFTypeName, params)
+	if err != nil {
+		return err
+	}
+
+	return wf.Start(ctx)
+}
+
+// VisibilityFixerWorkflow starts visibility fixer.
+func VisibilityFixerWorkflow(
+	ctx workflow.Context,
+	params shardscanner.FixerWorkflowParams,
+) error {
+
+	wf, err := shardscanner.NewFixerWorkflow(ctx, VisibilityFixerWFTypeName, params)
+	if err != nil {
+		return err
+	}
+
+	return wf.Start(ctx)
+}
+
+// VisibilityScannerWorkflowID returns the workflow id of the visibility scanner of a single domain.
+func VisibilityScannerWorkflowID(domainName string) string {
+	return visibilityScannerWFID + "-" + domainName
+}
+
+// VisibilityFixerWorkflowID returns the workflow id of the visibility fixer of a single domain.
+func VisibilityFixerWorkflowID(domainName string) string {
+	return visibilityFixerWFID + "-" + domainName
+}
+
+// VisibilityExecutionHooks provides hooks for visibility scanner
+func VisibilityExecutionHooks() *shardscanner.ScannerHooks {
+	h, err := shardscanner.NewScannerHooks(VisibilityScannerManager, VisibilityScannerIterator)
+	if err != nil {
+		return nil
+	}
+	h.SetConfig(VisibilityExecutionConfig)
+
+	return h
+}
+
+// VisibilityExecutionFixerHooks provides hooks needed for visibility fixer.
+func VisibilityExecutionFixerHooks() *shardscanner.FixerHooks {
+	h, err := shardscanner.NewFixerHooks(VisibilityFixerManager, VisibilityFixerIterator)
+	if err != nil {
+		return nil
+	}
+	return h
+}
+
+// VisibilityScannerManager provides invariant manager for visibility scanner
+func VisibilityScannerManager(
+	ctx context.Context,
+	pr persistence.Retryer,
+	_ shardscanner.ScanShardActivityParams,
+	domainCache cache.DomainCache,
+) invariant.Manager {
+	var ivs []invariant.Invariant
+	if scannerCtx, err := shardscanner.GetScannerContext(ctx); err == nil {
+		ivs = visibilityInvariants(pr, domainCache, scannerCtx.Resource, scannerCtx.Config.DynamicCollection)
+	}
+	return invariant.NewInvariantManager(ivs)
+}
+
+// VisibilityScannerIterator provides iterator for visibility scanner.
+// Only executions of the domain in custom scanner config are returned if it is set.
+func VisibilityScannerIterator(
+	ctx context.Context,
+	pr persistence.Retryer,
+	params shardscanner.ScanShardActivityParams,
+) pagination.Iterator {
+	if domainID := params.ScannerConfig[VisibilityScannerDomainIDKey]; domainID != "" {
+		return fetcher.DomainConcreteExecutionIterator(ctx, pr, params.PageSize, domainID)
+	}
+	it := VisibilityExecutionType.ToIterator()
+	return it(ctx, pr, params.PageSize)
+}
+
+// VisibilityFixerIterator provides iterator for visibility fixer.
+func VisibilityFixerIterator(ctx context.Context, client blobstore.Client, keys store.Keys, _ shardscanner.FixShardActivityParams) store.ScanOutputIterator {
+	return store.NewBlobstoreIterator(ctx, client, keys, VisibilityExecutionType.ToBlobstoreEntity())
+}
+
+// VisibilityFixerManager provides invariant manager for visibility fixer.
+func VisibilityFixerManager(ctx context.Context, pr persistence.Retryer, _ shardscanner.FixShardActivityParams, domainCache cache.DomainCache) invariant.Manager {
+	var ivs []invariant.Invariant
+	if fixerCtx, err := shardscanner.GetFixerContext(ctx); err == nil {
+		ivs = visibilityInvariants(pr, domainCache, fixerCtx.Resource, fixerCtx.Config.DynamicCollection)
+	}
+	return invariant.NewInvariantManager(ivs)
+}
+
+// VisibilityExecutionConfig resolves dynamic config for visibility scanner.
+// The scanner started by worker scans all domains, a scan of a single domain sets
+// VisibilityScannerDomainIDKey through the custom config overwrites of the workflow.
+func VisibilityExecutionConfig(_ shardscanner.Context) shardscanner.CustomScannerConfig {
+	return shardscanner.CustomScannerConfig{}
+}
+
+// VisibilityExecutionScannerConfig configures visibility scanner
+func VisibilityExecutionScannerConfig(dc *dynamicconfig.Collection) *shardscanner.ScannerConfig {
+	return &shardscanner.ScannerConfig{
+		ScannerWFTypeName: VisibilityScannerWFTypeName,
+		FixerWFTypeName:   VisibilityFixerWFTypeName,
+		DynamicParams: shardscanner.DynamicParams{
+			ScannerEnabled:          dc.GetBoolProperty(dynamicconfig.VisibilityScannerEnabled),
+			FixerEnabled:            dc.GetBoolProperty(dynamicconfig.VisibilityFixerEnabled),
+			Concurrency:             dc.GetIntProperty(dynamicconfig.VisibilityScannerConcurrency),
+			PageSize:                dc.GetIntProperty(dynamicconfig.VisibilityScannerPersistencePageSize),
+			BlobstoreFlushThreshold: dc.GetIntProperty(dynamicconfig.VisibilityScannerBlobstoreFlushThreshold),
+			ActivityBatchSize:       dc.GetIntProperty(dynamicconfig.VisibilityScannerActivityBatchSize),
+			AllowDomain:             dc.GetBoolPropertyFilteredByDomain(dynamicconfig.VisibilityFixerDomainAllow),
+		},
+		DynamicCollection: dc,
+		ScannerHooks:      VisibilityExecutionHooks,
+		FixerHooks:        VisibilityExecutionFixerHooks,
+		StartWorkflowOptions: cclient.StartWorkflowOptions{
+			ID:                           visibilityScannerWFID,
+			TaskList:                     VisibilityScannerTaskListName,
+			ExecutionStartToCloseTimeout: 20 * 365 * 24 * time.Hour,
+			WorkflowIDReusePolicy:        cclient.WorkflowIDReusePolicyAllowDuplicate,
+			CronSchedule:                 "* * * * *",
+		},
+		StartFixerOptions: cclient.StartWorkflowOptions{
+			ID:                           visibilityFixerWFID,
+			TaskList:                     VisibilityFixerTaskListName,
+			ExecutionStartToCloseTimeout: 20 * 365 * 24 * time.Hour,
+			WorkflowIDReusePolicy:        cclient.WorkflowIDReusePolicyAllowDuplicate,
+			CronSchedule:                 "* * * * *",
+		},
+	}
+}
+
+// visibilityInvariants returns the invariants of VisibilityExecutionType, which read visibility records
+// through the visibility manager of the worker and are fixed through history service.
+func visibilityInvariants(
+	pr persistence.Retryer,
+	domainCache cache.DomainCache,
+	res resource.Resource,
+	dc *dynamicconfig.Collection,
+) []invariant.Invariant {
+	if res.GetVisibilityManager() == nil {
+		return nil
+	}
+	return []invariant.Invariant{
+		invariant.NewVisibilityRecordMatches(
+			pr,
+			domainCache,
+			res.GetVisibilityManager(),
+			res.GetHistoryClient(),
+			dc.GetBoolPropertyFilteredByDomain(dynamicconfig.EnableReadVisibilityFromES),
+			dc.GetMapProperty(dynamicconfig.SearchAttributeAliases),
+		),
+	}
+}
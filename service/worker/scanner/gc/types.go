@@ -0,0 +1,112 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package gc contains the types shared by the garbage collecting scanners
+// (history and task list scavengers), the executor which deletes reported
+// garbage and the admin CLI which inspects both. Transfer and replication
+// task garbage is handled by the shard scanners in the tasks package.
+package gc
+
+import (
+	"github.com/uber/cadence/common/reconciliation/store"
+)
+
+const (
+	// ReportQueryType is the query type which returns the Report of a garbage collection workflow.
+	// For the cron scanners the report of the last completed run is returned until the current run completes.
+	ReportQueryType = "gc_report"
+
+	// HistoryScannerWorkflowID is the workflow id of the history scanner
+	HistoryScannerWorkflowID = "cadence-sys-history-scanner"
+	// HistoryScannerTaskListName is the task list of the history scanner
+	HistoryScannerTaskListName = "cadence-sys-history-scanner-tasklist-0"
+	// TaskListScannerWorkflowID is the workflow id of the task list scanner
+	TaskListScannerWorkflowID = "cadence-sys-tl-scanner"
+	// TaskListScannerTaskListName is the task list of the task list scanner
+	TaskListScannerTaskListName = "cadence-sys-tl-scanner-tasklist-0"
+
+	// ExecutorWFTypeName is the workflow type of the workflow which deletes reported garbage
+	ExecutorWFTypeName = "cadence-sys-gc-executor-workflow"
+	// ExecutorWorkflowIDPrefix is the prefix of executor workflow ids, the Type is appended to it
+	ExecutorWorkflowIDPrefix = "cadence-sys-gc-executor-"
+
+	// TypeHistory identifies garbage history branches which have no owning execution
+	TypeHistory Type = "history"
+	// TypeTaskList identifies abandoned task lists
+	TypeTaskList Type = "tasklist"
+	// TypeTransfer identifies transfer tasks which have no owning execution.
+	// They are reported and completed by the transfer tasks shard scanner and fixer.
+	TypeTransfer Type = "transfer"
+	// TypeReplication identifies replication tasks which have no owning execution.
+	// They are reported and completed by the replication tasks shard scanner and fixer.
+	TypeReplication Type = "replication"
+)
+
+type (
+	// Type is the kind of garbage collected by a scanner
+	Type string
+
+	// Report is the summary of a garbage collection scan or execution
+	Report struct {
+		Type Type
+		// ReportOnly is true if the garbage was only reported and not deleted
+		ReportOnly bool
+		// Scanned is the number of entities inspected
+		Scanned int
+		// Skipped is the number of entities which were not considered, or which were no longer garbage on execution
+		Skipped int
+		// Garbage is the number of entities identified as garbage
+		Garbage int
+		// Deleted is the number of entities deleted
+		Deleted int
+		// Failed is the number of entities which could not be checked or deleted
+		Failed int
+		// Keys are the blobstore keys of the garbage written in report only mode
+		Keys []store.Keys
+	}
+
+	// ExecutorParams are the input of the executor workflow
+	ExecutorParams struct {
+		Type Type
+		Keys []store.Keys
+	}
+)
+
+// ExecutorWorkflowID returns the executor workflow id for the given type
+func ExecutorWorkflowID(t Type) string {
+	return ExecutorWorkflowIDPrefix + string(t)
+}
+
+// ScannerWorkflowID returns the workflow id of the scanner which reports the given type
+func (t Type) ScannerWorkflowID() string {
+	if t == TypeTaskList {
+		return TaskListScannerWorkflowID
+	}
+	return HistoryScannerWorkflowID
+}
+
+// TaskListName returns the task list of the scanner which reports the given type,
+// the executor for the type is run on the same task list
+func (t Type) TaskListName() string {
+	if t == TypeTaskList {
+		return TaskListScannerTaskListName
+	}
+	return HistoryScannerTaskListName
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package gc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutorWorkflowID(t *testing.T) {
+	assert.Equal(t, "cadence-sys-gc-executor-history", ExecutorWorkflowID(TypeHistory))
+	assert.Equal(t, "cadence-sys-gc-executor-tasklist", ExecutorWorkflowID(TypeTaskList))
+}
+
+func TestType_ScannerWorkflowID(t *testing.T) {
+	tests := []struct {
+		gcType     Type
+		workflowID string
+		taskList   string
+	}{
+		{
+			gcType:     TypeHistory,
+			workflowID: HistoryScannerWorkflowID,
+			taskList:   HistoryScannerTaskListName,
+		},
+		{
+			gcType:     TypeTaskList,
+			workflowID: TaskListScannerWorkflowID,
+			taskList:   TaskListScannerTaskListName,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.gcType), func(t *testing.T) {
+			assert.Equal(t, tt.workflowID, tt.gcType.ScannerWorkflowID())
+			assert.Equal(t, tt.taskList, tt.gcType.TaskListName())
+		})
+	}
+}
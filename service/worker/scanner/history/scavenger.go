@@ -22,6 +22,7 @@ package history
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"go.uber.org/cadence/activity"
@@ -35,6 +36,9 @@ import (
 	"github.com/uber/cadence/common/log/tag"
 	"github.com/uber/cadence/common/metrics"
 	p "github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/reconciliation/entity"
+	"github.com/uber/cadence/common/reconciliation/invariant"
+	"github.com/uber/cadence/common/reconciliation/store"
 	"github.com/uber/cadence/common/types"
 )
 
@@ -46,6 +50,12 @@ type (
 		SkipCount     int
 		ErrorCount    int
 		SuccCount     int
+		// GarbageCount is the number of branches without an owning execution
+		GarbageCount int
+		// DeletedCount is the number of garbage branches deleted
+		DeletedCount int
+		// ReportKeys are the keys of the garbage reported in report only mode, one per activity attempt
+		ReportKeys []store.Keys
 	}
 
 	// Scavenger is the type that holds the state for history scavenger daemon
@@ -60,6 +70,14 @@ type (
 		logger                     log.Logger
 		isInTest                   bool
 		domainCache                cache.DomainCache
+
+		// reportWriter is set in report only mode, garbage is written to it instead of being deleted
+		reportWriter    store.ExecutionWriter
+		reportKeysIndex int
+		// garbageLock guards the garbage counters and reportWriter which are used by task processors
+		garbageLock  sync.Mutex
+		garbageCount int
+		deletedCount int
 	}
 
 	taskDetail struct {
@@ -96,6 +114,10 @@ func getHistoryCleanupThreshold(maxWorkflowRetentionInDays int) time.Duration {
 // each branch, the scavenger will attempt
 //  - describe the corresponding workflow execution
 //  - deletion of history itself, if there are no workflow execution
+//
+// If reportWriter is not nil the scavenger runs in report only mode and
+// garbage branches are written to it instead of being deleted. They can be
+// deleted later on by calling Execute with an iterator over the report.
 func NewScavenger(
 	db p.HistoryManager,
 	rps int,
@@ -105,6 +127,7 @@ func NewScavenger(
 	logger log.Logger,
 	maxWorkflowRetentionInDays dynamicconfig.IntPropertyFn,
 	domainCache cache.DomainCache,
+	reportWriter store.ExecutionWriter,
 ) *Scavenger {
 
 	rateLimiter := rate.NewLimiter(rate.Limit(rps), rps)
@@ -119,6 +142,8 @@ func NewScavenger(
 		metrics:                    metricsClient,
 		logger:                     logger,
 		domainCache:                domainCache,
+		reportWriter:               reportWriter,
+		reportKeysIndex:            -1,
 	}
 }
 
@@ -202,6 +227,12 @@ func (s *Scavenger) Run(ctx context.Context) (ScavengerHeartbeatDetails, error)
 		s.hbd.SuccCount += succCount
 		s.hbd.ErrorCount += errCount + errorsOnSplitting
 		s.hbd.SkipCount += skips
+		garbage, deleted := s.takeGarbageCounts()
+		s.hbd.GarbageCount += garbage
+		s.hbd.DeletedCount += deleted
+		if err := s.flushReport(); err != nil {
+			return s.hbd, err
+		}
 		if !s.isInTest {
 			activity.RecordHeartbeat(ctx, s.hbd)
 		}
@@ -239,64 +270,207 @@ func (s *Scavenger) startTaskProcessor(
 				continue
 			}
 
-			// this checks if the mutableState still exists
-			// if not then the history branch is garbage, we need to delete the history branch
-			_, err = s.client.DescribeMutableState(ctx, &types.DescribeMutableStateRequest{
-				DomainUUID: task.domainID,
-				Execution: &types.WorkflowExecution{
-					WorkflowID: task.workflowID,
-					RunID:      task.runID,
-				},
-			})
-
+			garbage, err := s.isGarbage(ctx, task)
 			if err != nil {
-				if _, ok := err.(*types.EntityNotExistsError); ok {
-					//deleting history branch
-					var branchToken []byte
-					branchToken, err = p.NewHistoryBranchTokenByBranchID(task.treeID, task.branchID)
-					if err != nil {
-						respCh <- err
-						s.logger.Error("encounter error when creating branch token",
-							getTaskLoggingTags(err, task)...)
-						continue
-					}
-					domainName, err := s.domainCache.GetDomainName(task.domainID)
-					if err != nil {
-						respCh <- err
-						s.logger.Error("Unexpected: Encountered error while fetching domain name",
-							getTaskLoggingTags(err, task)...)
-						continue
-					}
-					err = s.db.DeleteHistoryBranch(ctx, &p.DeleteHistoryBranchRequest{
-						BranchToken: branchToken,
-						// This is a required argument but it is not needed for Cassandra.
-						// Since this scanner is only for Cassandra,
-						// we can fill any number here to let to code go through
-						ShardID:    common.IntPtr(1),
-						DomainName: domainName,
-					})
-					if err != nil {
-						respCh <- err
-						s.logger.Error("encounter error when deleting garbage history branch",
-							getTaskLoggingTags(err, task)...)
-					} else {
-						// deleted garbage
-						s.logger.Info("deleted history garbage",
-							getTaskLoggingTags(nil, task)...)
+				respCh <- err
+				continue
+			}
+			if !garbage {
+				respCh <- nil
+				continue
+			}
+			s.addGarbage(false)
+			if s.reportWriter != nil {
+				respCh <- s.report(task)
+				continue
+			}
+			err = s.deleteBranch(ctx, task)
+			if err == nil {
+				s.addGarbage(true)
+			}
+			respCh <- err
+		}
+	}
+}
 
-						respCh <- nil
-					}
-				} else {
-					s.logger.Error("encounter error when describing the mutable state",
-						getTaskLoggingTags(err, task)...)
-					respCh <- err
-				}
+// Execute deletes the history branches reported by a previous run in report only mode.
+// Each branch is checked again before deletion, branches which are no longer garbage are skipped.
+func (s *Scavenger) Execute(ctx context.Context, iterator store.ScanOutputIterator) (ScavengerHeartbeatDetails, error) {
+	// entries handled by previous attempts are skipped
+	handled := s.hbd.SuccCount + s.hbd.ErrorCount + s.hbd.SkipCount
+	for i := 0; iterator.HasNext(); i++ {
+		soe, err := iterator.Next()
+		if err != nil {
+			return s.hbd, err
+		}
+		if i < handled {
+			continue
+		}
+		branch, ok := soe.Execution.(*entity.HistoryBranch)
+		if !ok {
+			s.hbd.ErrorCount++
+			continue
+		}
+		task := taskDetail{
+			domainID:   branch.DomainID,
+			workflowID: branch.WorkflowID,
+			runID:      branch.RunID,
+			treeID:     branch.TreeID,
+			branchID:   branch.BranchID,
+		}
+		if err := s.limiter.Wait(ctx); err != nil {
+			return s.hbd, err
+		}
+
+		garbage, err := s.isGarbage(ctx, task)
+		switch {
+		case err != nil:
+			s.metrics.IncCounter(metrics.HistoryScavengerScope, metrics.HistoryScavengerErrorCount)
+			s.hbd.ErrorCount++
+		case !garbage:
+			s.metrics.IncCounter(metrics.HistoryScavengerScope, metrics.HistoryScavengerSkipCount)
+			s.hbd.SkipCount++
+		default:
+			s.hbd.GarbageCount++
+			if err := s.deleteBranch(ctx, task); err != nil {
+				s.metrics.IncCounter(metrics.HistoryScavengerScope, metrics.HistoryScavengerErrorCount)
+				s.hbd.ErrorCount++
 			} else {
-				// no garbage
-				respCh <- nil
+				s.metrics.IncCounter(metrics.HistoryScavengerScope, metrics.HistoryScavengerSuccessCount)
+				s.hbd.SuccCount++
+				s.hbd.DeletedCount++
 			}
 		}
+		if !s.isInTest {
+			activity.RecordHeartbeat(ctx, s.hbd)
+		}
+	}
+	return s.hbd, nil
+}
+
+// isGarbage checks if the mutableState still exists
+// if not then the history branch is garbage
+func (s *Scavenger) isGarbage(ctx context.Context, task taskDetail) (bool, error) {
+	_, err := s.client.DescribeMutableState(ctx, &types.DescribeMutableStateRequest{
+		DomainUUID: task.domainID,
+		Execution: &types.WorkflowExecution{
+			WorkflowID: task.workflowID,
+			RunID:      task.runID,
+		},
+	})
+	if err == nil {
+		// no garbage
+		return false, nil
+	}
+	if _, ok := err.(*types.EntityNotExistsError); ok {
+		return true, nil
+	}
+	s.logger.Error("encounter error when describing the mutable state",
+		getTaskLoggingTags(err, task)...)
+	return false, err
+}
+
+func (s *Scavenger) deleteBranch(ctx context.Context, task taskDetail) error {
+	branchToken, err := p.NewHistoryBranchTokenByBranchID(task.treeID, task.branchID)
+	if err != nil {
+		s.logger.Error("encounter error when creating branch token",
+			getTaskLoggingTags(err, task)...)
+		return err
+	}
+	domainName, err := s.domainCache.GetDomainName(task.domainID)
+	if err != nil {
+		s.logger.Error("Unexpected: Encountered error while fetching domain name",
+			getTaskLoggingTags(err, task)...)
+		return err
+	}
+	err = s.db.DeleteHistoryBranch(ctx, &p.DeleteHistoryBranchRequest{
+		BranchToken: branchToken,
+		// This is a required argument but it is not needed for Cassandra.
+		// Since this scanner is only for Cassandra,
+		// we can fill any number here to let to code go through
+		ShardID:    common.IntPtr(1),
+		DomainName: domainName,
+	})
+	if err != nil {
+		s.logger.Error("encounter error when deleting garbage history branch",
+			getTaskLoggingTags(err, task)...)
+		return err
+	}
+	// deleted garbage
+	s.logger.Info("deleted history garbage",
+		getTaskLoggingTags(nil, task)...)
+	return nil
+}
+
+func (s *Scavenger) report(task taskDetail) error {
+	s.garbageLock.Lock()
+	defer s.garbageLock.Unlock()
+	err := s.reportWriter.Add(&store.ScanOutputEntity{
+		Execution: &entity.HistoryBranch{
+			DomainID:   task.domainID,
+			WorkflowID: task.workflowID,
+			RunID:      task.runID,
+			TreeID:     task.treeID,
+			BranchID:   task.branchID,
+		},
+		Result: invariant.ManagerCheckResult{
+			CheckResultType:          invariant.CheckResultTypeCorrupted,
+			DeterminingInvariantType: invariant.NamePtr(invariant.OrphanedHistoryBranch),
+			CheckResults: []invariant.CheckResult{{
+				CheckResultType: invariant.CheckResultTypeCorrupted,
+				InvariantName:   invariant.OrphanedHistoryBranch,
+				Info:            "history branch has no owning execution",
+			}},
+		},
+	})
+	if err != nil {
+		s.logger.Error("encounter error when reporting garbage history branch",
+			getTaskLoggingTags(err, task)...)
+		return err
+	}
+	s.logger.Info("reported history garbage",
+		getTaskLoggingTags(nil, task)...)
+	return nil
+}
+
+// addGarbage counts a garbage branch when it is found and once more when it is deleted
+func (s *Scavenger) addGarbage(deleted bool) {
+	s.garbageLock.Lock()
+	defer s.garbageLock.Unlock()
+	if deleted {
+		s.deletedCount++
+		return
+	}
+	s.garbageCount++
+}
+
+func (s *Scavenger) takeGarbageCounts() (int, int) {
+	s.garbageLock.Lock()
+	defer s.garbageLock.Unlock()
+	garbage, deleted := s.garbageCount, s.deletedCount
+	s.garbageCount, s.deletedCount = 0, 0
+	return garbage, deleted
+}
+
+// flushReport flushes the reported garbage and records the keys written by this attempt
+func (s *Scavenger) flushReport() error {
+	if s.reportWriter == nil {
+		return nil
+	}
+	if err := s.reportWriter.Flush(); err != nil {
+		return err
+	}
+	keys := s.reportWriter.FlushedKeys()
+	if keys == nil {
+		return nil
+	}
+	if s.reportKeysIndex < 0 {
+		s.reportKeysIndex = len(s.hbd.ReportKeys)
+		s.hbd.ReportKeys = append(s.hbd.ReportKeys, *keys)
+		return nil
 	}
+	s.hbd.ReportKeys[s.reportKeysIndex] = *keys
+	return nil
 }
 
 func getTaskLoggingTags(err error, task taskDetail) []tag.Tag {
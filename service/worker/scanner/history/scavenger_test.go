@@ -41,6 +41,8 @@ import (
 	"github.com/uber/cadence/common/metrics"
 	"github.com/uber/cadence/common/mocks"
 	p "github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/reconciliation/entity"
+	"github.com/uber/cadence/common/reconciliation/store"
 	"github.com/uber/cadence/common/types"
 )
 
@@ -69,11 +71,18 @@ func (s *ScavengerTestSuite) SetupTest() {
 }
 
 func (s *ScavengerTestSuite) createTestScavenger(rps int) (*mocks.HistoryV2Manager, *history.MockClient, *Scavenger, *gomock.Controller) {
+	return s.createTestScavengerWithReportWriter(rps, nil)
+}
+
+func (s *ScavengerTestSuite) createTestScavengerWithReportWriter(
+	rps int,
+	reportWriter store.ExecutionWriter,
+) (*mocks.HistoryV2Manager, *history.MockClient, *Scavenger, *gomock.Controller) {
 	db := &mocks.HistoryV2Manager{}
 	controller := gomock.NewController(s.T())
 	workflowClient := history.NewMockClient(controller)
 	maxWorkflowRetentionInDays := dynamicconfig.GetIntPropertyFn(dynamicconfig.MaxRetentionDays.DefaultInt())
-	scvgr := NewScavenger(db, rps, workflowClient, ScavengerHeartbeatDetails{}, s.metric, s.logger, maxWorkflowRetentionInDays, s.mockCache)
+	scvgr := NewScavenger(db, rps, workflowClient, ScavengerHeartbeatDetails{}, s.metric, s.logger, maxWorkflowRetentionInDays, s.mockCache, reportWriter)
 	scvgr.isInTest = true
 	return db, workflowClient, scvgr, controller
 }
@@ -368,6 +377,8 @@ func (s *ScavengerTestSuite) TestDeletingBranchesTwoPages() {
 	s.Nil(err)
 	s.Equal(0, hbd.SkipCount)
 	s.Equal(4, hbd.SuccCount)
+	s.Equal(4, hbd.GarbageCount)
+	s.Equal(4, hbd.DeletedCount)
 	s.Equal(0, hbd.ErrorCount)
 	s.Equal(2, hbd.CurrentPage)
 	s.Equal(0, len(hbd.NextPageToken))
@@ -474,3 +485,133 @@ func (s *ScavengerTestSuite) TestMixesTwoPages() {
 	s.Equal(2, hbd.CurrentPage)
 	s.Equal(0, len(hbd.NextPageToken))
 }
+
+func (s *ScavengerTestSuite) TestReportOnlyTwoPages() {
+	controller := gomock.NewController(s.T())
+	defer controller.Finish()
+	writer := store.NewMockExecutionWriter(controller)
+	db, client, scvgr, _ := s.createTestScavengerWithReportWriter(100, writer)
+	db.On("GetAllHistoryTreeBranches", mock.Anything, &p.GetAllHistoryTreeBranchesRequest{
+		PageSize: pageSize,
+	}).Return(&p.GetAllHistoryTreeBranchesResponse{
+		NextPageToken: []byte("page1"),
+		Branches: []p.HistoryBranchDetail{
+			{
+				TreeID:   "treeID1",
+				BranchID: "branchID1",
+				ForkTime: time.Now().Add(-getHistoryCleanupThreshold(dynamicconfig.MaxRetentionDays.DefaultInt()) * 2),
+				Info:     p.BuildHistoryGarbageCleanupInfo("domainID1", "workflowID1", "runID1"),
+			},
+		},
+	}, nil).Once()
+	db.On("GetAllHistoryTreeBranches", mock.Anything, &p.GetAllHistoryTreeBranchesRequest{
+		PageSize:      pageSize,
+		NextPageToken: []byte("page1"),
+	}).Return(&p.GetAllHistoryTreeBranchesResponse{
+		Branches: []p.HistoryBranchDetail{
+			{
+				TreeID:   "treeID2",
+				BranchID: "branchID2",
+				ForkTime: time.Now().Add(-getHistoryCleanupThreshold(dynamicconfig.MaxRetentionDays.DefaultInt()) * 2),
+				Info:     p.BuildHistoryGarbageCleanupInfo("domainID2", "workflowID2", "runID2"),
+			},
+		},
+	}, nil).Once()
+
+	client.EXPECT().DescribeMutableState(gomock.Any(), &types.DescribeMutableStateRequest{
+		DomainUUID: "domainID1",
+		Execution: &types.WorkflowExecution{
+			WorkflowID: "workflowID1",
+			RunID:      "runID1",
+		},
+	}).Return(nil, &types.EntityNotExistsError{})
+	client.EXPECT().DescribeMutableState(gomock.Any(), &types.DescribeMutableStateRequest{
+		DomainUUID: "domainID2",
+		Execution: &types.WorkflowExecution{
+			WorkflowID: "workflowID2",
+			RunID:      "runID2",
+		},
+	}).Return(nil, nil)
+
+	writer.EXPECT().Add(gomock.Any()).DoAndReturn(func(e interface{}) error {
+		soe := e.(*store.ScanOutputEntity)
+		s.Equal(&entity.HistoryBranch{
+			DomainID:   "domainID1",
+			WorkflowID: "workflowID1",
+			RunID:      "runID1",
+			TreeID:     "treeID1",
+			BranchID:   "branchID1",
+		}, soe.Execution)
+		return nil
+	}).Times(1)
+	writer.EXPECT().Flush().Return(nil).Times(2)
+	writer.EXPECT().FlushedKeys().Return(&store.Keys{UUID: "uuid", MinPage: 0, MaxPage: 0}).Times(2)
+
+	hbd, err := scvgr.Run(context.Background())
+	s.Nil(err)
+	s.Equal(0, hbd.SkipCount)
+	s.Equal(2, hbd.SuccCount)
+	s.Equal(0, hbd.ErrorCount)
+	s.Equal(1, hbd.GarbageCount)
+	s.Equal([]store.Keys{{UUID: "uuid", MinPage: 0, MaxPage: 0}}, hbd.ReportKeys)
+	db.AssertNotCalled(s.T(), "DeleteHistoryBranch", mock.Anything, mock.Anything)
+}
+
+func (s *ScavengerTestSuite) TestExecute() {
+	db, client, scvgr, controller := s.createTestScavenger(100)
+	defer controller.Finish()
+	iterator := store.NewMockScanOutputIterator(controller)
+	gomock.InOrder(
+		iterator.EXPECT().HasNext().Return(true),
+		iterator.EXPECT().Next().Return(&store.ScanOutputEntity{Execution: &entity.HistoryBranch{
+			DomainID:   "domainID1",
+			WorkflowID: "workflowID1",
+			RunID:      "runID1",
+			TreeID:     "treeID1",
+			BranchID:   "branchID1",
+		}}, nil),
+		iterator.EXPECT().HasNext().Return(true),
+		iterator.EXPECT().Next().Return(&store.ScanOutputEntity{Execution: &entity.HistoryBranch{
+			DomainID:   "domainID2",
+			WorkflowID: "workflowID2",
+			RunID:      "runID2",
+			TreeID:     "treeID2",
+			BranchID:   "branchID2",
+		}}, nil),
+		iterator.EXPECT().HasNext().Return(false),
+	)
+
+	client.EXPECT().DescribeMutableState(gomock.Any(), &types.DescribeMutableStateRequest{
+		DomainUUID: "domainID1",
+		Execution: &types.WorkflowExecution{
+			WorkflowID: "workflowID1",
+			RunID:      "runID1",
+		},
+	}).Return(nil, &types.EntityNotExistsError{})
+	// the owning execution showed up after the report was written
+	client.EXPECT().DescribeMutableState(gomock.Any(), &types.DescribeMutableStateRequest{
+		DomainUUID: "domainID2",
+		Execution: &types.WorkflowExecution{
+			WorkflowID: "workflowID2",
+			RunID:      "runID2",
+		},
+	}).Return(nil, nil)
+	domainName := "test-domainName"
+	s.mockCache.EXPECT().GetDomainName(gomock.Any()).Return(domainName, nil).AnyTimes()
+	branchToken1, err := p.NewHistoryBranchTokenByBranchID("treeID1", "branchID1")
+	s.Nil(err)
+	db.On("DeleteHistoryBranch", mock.Anything, &p.DeleteHistoryBranchRequest{
+		BranchToken: branchToken1,
+		ShardID:     common.IntPtr(1),
+		DomainName:  domainName,
+	}).Return(nil).Once()
+
+	hbd, err := scvgr.Execute(context.Background(), iterator)
+	s.Nil(err)
+	s.Equal(1, hbd.SkipCount)
+	s.Equal(1, hbd.SuccCount)
+	s.Equal(0, hbd.ErrorCount)
+	s.Equal(1, hbd.GarbageCount)
+	s.Equal(1, hbd.DeletedCount)
+	db.AssertExpectations(s.T())
+}
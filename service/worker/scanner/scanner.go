@@ -39,6 +39,7 @@ import (
 	"github.com/uber/cadence/common/dynamicconfig"
 	"github.com/uber/cadence/common/log/tag"
 	"github.com/uber/cadence/common/resource"
+	"github.com/uber/cadence/service/worker/scanner/gc"
 	"github.com/uber/cadence/service/worker/scanner/shardscanner"
 	"github.com/uber/cadence/service/worker/scanner/tasklist"
 	"github.com/uber/cadence/service/worker/workercommon"
@@ -67,6 +68,10 @@ type (
 		ClusterMetadata cluster.Metadata
 		// HistoryScannerEnabled indicates if history scanner should be started as part of scanner
 		HistoryScannerEnabled dynamicconfig.BoolPropertyFn
+		// TaskListScannerReportOnly indicates if taskList scanner should report abandoned task lists instead of deleting them
+		TaskListScannerReportOnly dynamicconfig.BoolPropertyFn
+		// HistoryScannerReportOnly indicates if history scanner should report garbage branches instead of deleting them
+		HistoryScannerReportOnly dynamicconfig.BoolPropertyFn
 		// ShardScanners is a list of shard scanner configs
 		ShardScanners              []*shardscanner.ScannerConfig
 		MaxWorkflowRetentionInDays dynamicconfig.IntPropertyFn
@@ -128,6 +133,7 @@ func (s *Scanner) Start() error {
 	ctx := context.Background()
 	var workerTaskListNames []string
 	var wtl []string
+	gcEnabled := false
 
 	for _, sc := range s.context.cfg.ShardScanners {
 		ctx, wtl = s.startShardScanner(ctx, sc)
@@ -141,6 +147,7 @@ func (s *Scanner) Start() error {
 				tlScannerWFStartOptions,
 				tlScannerWFTypeName)
 			workerTaskListNames = append(workerTaskListNames, tlScannerTaskListName)
+			gcEnabled = true
 		}
 	}
 	if s.context.cfg.HistoryScannerEnabled() {
@@ -149,6 +156,11 @@ func (s *Scanner) Start() error {
 			historyScannerWFStartOptions,
 			historyScannerWFTypeName)
 		workerTaskListNames = append(workerTaskListNames, historyScannerTaskListName)
+		gcEnabled = true
+	}
+	if gcEnabled {
+		// the gc executor runs on the task list of the scanner whose report it executes
+		ctx = NewScannerContext(ctx, gc.ExecutorWFTypeName, s.context)
 	}
 
 	workerOpts := worker.Options{
@@ -33,6 +33,7 @@ import (
 	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/reconciliation/invariant"
 	"github.com/uber/cadence/common/reconciliation/store"
+	"github.com/uber/cadence/service/worker/workercommon"
 )
 
 const (
@@ -84,6 +85,7 @@ type FixerWorkflow struct {
 	Aggregator *ShardFixResultAggregator
 	Params     FixerWorkflowParams
 	Keys       *FixerCorruptedKeysActivityResult
+	Progress   *workercommon.Progress
 }
 
 // NewFixerWorkflow returns a new instance of fixer workflow
@@ -98,7 +100,13 @@ func NewFixerWorkflow(
 	}
 
 	wf := FixerWorkflow{
-		Params: params,
+		Params:   params,
+		Progress: workercommon.NewProgress(workflow.Now(ctx)),
+	}
+	if err := workercommon.SetProgressQueryHandler(ctx, func() *workercommon.Progress {
+		return wf.Progress
+	}); err != nil {
+		return nil, err
 	}
 
 	corruptKeys, err := GetCorruptedKeys(ctx, wf.Params)
@@ -110,6 +118,7 @@ func NewFixerWorkflow(
 	}
 
 	wf.Keys = corruptKeys
+	wf.Progress.SetTotal(int64(len(corruptKeys.CorruptedKeys)), workflow.Now(ctx))
 	wf.Aggregator = NewShardFixResultAggregator(corruptKeys.CorruptedKeys, *corruptKeys.MinShard, *corruptKeys.MaxShard)
 
 	for name, fn := range setHandlers(wf.Aggregator) {
@@ -125,6 +134,7 @@ func NewFixerWorkflow(
 func (fx *FixerWorkflow) Start(ctx workflow.Context) error {
 
 	resolvedConfig := resolveFixerConfig(fx.Params.FixerWorkflowConfigOverwrites)
+	fx.Progress.SetPhase(workercommon.ProgressPhaseRunning, workflow.Now(ctx))
 	shardReportChan := workflow.GetSignalChannel(ctx, fixShardReportChan)
 
 	for i := 0; i < resolvedConfig.Concurrency; i++ {
@@ -157,13 +167,16 @@ func (fx *FixerWorkflow) Start(ctx workflow.Context) error {
 		var reportErr FixReportError
 		shardReportChan.Receive(ctx, &reportErr)
 		if reportErr.ErrorStr != nil {
+			fx.Progress.AddError(*reportErr.ErrorStr, workflow.Now(ctx))
 			return errors.New(*reportErr.ErrorStr)
 		}
 		for _, report := range reportErr.Reports {
 			fx.Aggregator.AddReport(report)
+			addShardProgress(ctx, fx.Progress, report.ShardID, report.Result.ControlFlowFailure)
 			i++
 		}
 	}
+	fx.Progress.SetPhase(workercommon.ProgressPhaseCompleted, workflow.Now(ctx))
 	return nil
 }
 
@@ -182,6 +195,9 @@ func resolveFixerConfig(overwrites FixerWorkflowConfigOverwrites) ResolvedFixerW
 	if overwrites.ActivityBatchSize != nil {
 		resolvedConfig.ActivityBatchSize = *overwrites.ActivityBatchSize
 	}
+	if overwrites.CustomFixerConfig != nil {
+		resolvedConfig.CustomFixerConfig = *overwrites.CustomFixerConfig
+	}
 	return resolvedConfig
 }
 
@@ -55,6 +55,14 @@ func (s *fixerWorkflowSuite) TestResolveFixerConfig() {
 	}, result)
 }
 
+func (s *fixerWorkflowSuite) TestResolveFixerConfig_CustomFixerConfig() {
+	result := resolveFixerConfig(FixerWorkflowConfigOverwrites{
+		CustomFixerConfig: &CustomScannerConfig{"CollectionWorkflowState": "true"},
+	})
+	s.Equal(CustomScannerConfig{"CollectionWorkflowState": "true"}, result.CustomFixerConfig)
+	s.Equal(25, result.Concurrency)
+}
+
 func (s *fixerWorkflowSuite) TestGetCorruptedKeysBatches() {
 	var keys []CorruptedKeysEntry
 	for i := 5; i < 50; i += 2 {
@@ -25,6 +25,7 @@ package shardscanner
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"go.uber.org/cadence/workflow"
 
@@ -32,6 +33,7 @@ import (
 	"github.com/uber/cadence/common/pagination"
 	"github.com/uber/cadence/common/persistence"
 	"github.com/uber/cadence/common/reconciliation/invariant"
+	"github.com/uber/cadence/service/worker/workercommon"
 )
 
 const (
@@ -72,6 +74,7 @@ type ScannerWorkflow struct {
 	Params     ScannerWorkflowParams
 	Name       string
 	Shards     []int
+	Progress   *workercommon.Progress
 }
 
 // ScannerHooks allows provide manager and iterator for different types of scanners.
@@ -102,13 +105,20 @@ func NewScannerWorkflow(
 		Params:     params,
 		Aggregator: NewShardScanResultAggregator(shards, minShard, maxShard),
 		Shards:     shards,
+		Progress:   workercommon.NewProgress(workflow.Now(ctx)),
 	}
+	wf.Progress.SetTotal(int64(len(shards)), workflow.Now(ctx))
 
 	for name, fn := range getScanHandlers(wf.Aggregator) {
 		if err := workflow.SetQueryHandler(ctx, name, fn); err != nil {
 			return nil, err
 		}
 	}
+	if err := workercommon.SetProgressQueryHandler(ctx, func() *workercommon.Progress {
+		return wf.Progress
+	}); err != nil {
+		return nil, err
+	}
 
 	return &wf, nil
 }
@@ -125,8 +135,10 @@ func (wf *ScannerWorkflow) Start(ctx workflow.Context) error {
 	}
 
 	if !resolvedConfig.GenericScannerConfig.Enabled {
+		wf.Progress.SetPhase(workercommon.ProgressPhaseCompleted, workflow.Now(ctx))
 		return nil
 	}
+	wf.Progress.SetPhase(workercommon.ProgressPhaseRunning, workflow.Now(ctx))
 
 	shardReportChan := workflow.GetSignalChannel(ctx, scanShardReportChan)
 	for i := 0; i < resolvedConfig.GenericScannerConfig.Concurrency; i++ {
@@ -163,13 +175,16 @@ func (wf *ScannerWorkflow) Start(ctx workflow.Context) error {
 		shardReportChan.Receive(ctx, &reportErr)
 
 		if reportErr.ErrorStr != nil {
+			wf.Progress.AddError(*reportErr.ErrorStr, workflow.Now(ctx))
 			return errors.New(*reportErr.ErrorStr)
 		}
 		for _, report := range reportErr.Reports {
 			wf.Aggregator.AddReport(report)
+			addShardProgress(ctx, wf.Progress, report.ShardID, report.Result.ControlFlowFailure)
 			i++
 		}
 	}
+	wf.Progress.SetPhase(workercommon.ProgressPhaseCompleted, workflow.Now(ctx))
 
 	activityCtx = getShortActivityContext(ctx)
 	summary := wf.Aggregator.GetStatusSummary()
@@ -183,6 +198,20 @@ func (wf *ScannerWorkflow) Start(ctx workflow.Context) error {
 
 }
 
+func addShardProgress(
+	ctx workflow.Context,
+	progress *workercommon.Progress,
+	shardID int,
+	failure *ControlFlowFailure,
+) {
+	if failure == nil {
+		progress.Add(1, 0, workflow.Now(ctx))
+		return
+	}
+	progress.Add(1, 1, workflow.Now(ctx))
+	progress.AddError(fmt.Sprintf("shard %v: %v", shardID, failure.Info), workflow.Now(ctx))
+}
+
 func getScanHandlers(aggregator *ShardScanResultAggregator) map[string]interface{} {
 	return map[string]interface{}{
 		ShardReportQuery: func(shardID int) (*ScanReport, error) {
@@ -281,6 +281,7 @@ type (
 		Concurrency             *int
 		BlobstoreFlushThreshold *int
 		ActivityBatchSize       *int
+		CustomFixerConfig       *CustomScannerConfig
 	}
 
 	// ResolvedFixerWorkflowConfig is the resolved config after reading defaults and applying overwrites.
@@ -288,6 +289,7 @@ type (
 		Concurrency             int
 		BlobstoreFlushThreshold int
 		ActivityBatchSize       int
+		CustomFixerConfig       CustomScannerConfig
 	}
 )
 
@@ -25,9 +25,17 @@ import (
 	"sync/atomic"
 	"time"
 
+	"go.uber.org/cadence/activity"
+	"golang.org/x/time/rate"
+
 	"github.com/uber/cadence/common/log/tag"
 	p "github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/reconciliation/entity"
+	"github.com/uber/cadence/common/reconciliation/invariant"
+	"github.com/uber/cadence/common/reconciliation/store"
+	"github.com/uber/cadence/common/types"
 	"github.com/uber/cadence/service/worker/scanner/executor"
+	"github.com/uber/cadence/service/worker/scanner/gc"
 )
 
 type handlerStatus = executor.TaskStatus
@@ -106,6 +114,11 @@ func (s *Scavenger) tryDeleteTaskList(info *p.TaskListInfo) {
 	if delta < taskListGracePeriod {
 		return
 	}
+	atomic.AddInt64(&s.stats.tasklist.nGarbage, 1)
+	if s.reportWriter != nil {
+		s.reportTaskList(info)
+		return
+	}
 	// usually, matching engine is the authoritative owner of a tasklist
 	// and its incorrect for any other entity to mutate executorTask lists (including deleting it)
 	// the delete here is safe because of two reasons:
@@ -115,6 +128,7 @@ func (s *Scavenger) tryDeleteTaskList(info *p.TaskListInfo) {
 	//     do so by updating the rangeID
 	//   - deleteTaskList is a conditional delete where condition is the rangeID
 	if err := s.deleteTaskList(info); err != nil {
+		atomic.AddInt64(&s.stats.tasklist.nFailed, 1)
 		s.logger.Error("deleteTaskList error", tag.Error(err))
 		return
 	}
@@ -122,6 +136,111 @@ func (s *Scavenger) tryDeleteTaskList(info *p.TaskListInfo) {
 	s.logger.Info("tasklist deleted", tag.WorkflowDomainID(info.DomainID), tag.WorkflowTaskListName(info.Name), tag.TaskType(info.TaskType))
 }
 
+func (s *Scavenger) reportTaskList(info *p.TaskListInfo) {
+	s.reportLock.Lock()
+	defer s.reportLock.Unlock()
+	err := s.reportWriter.Add(&store.ScanOutputEntity{
+		Execution: &entity.TaskList{
+			DomainID: info.DomainID,
+			Name:     info.Name,
+			TaskType: info.TaskType,
+			RangeID:  info.RangeID,
+		},
+		Result: invariant.ManagerCheckResult{
+			CheckResultType:          invariant.CheckResultTypeCorrupted,
+			DeterminingInvariantType: invariant.NamePtr(invariant.AbandonedTaskList),
+			CheckResults: []invariant.CheckResult{{
+				CheckResultType: invariant.CheckResultTypeCorrupted,
+				InvariantName:   invariant.AbandonedTaskList,
+				Info:            "task list is empty and idle for longer than the grace period",
+			}},
+		},
+	})
+	if err != nil {
+		atomic.AddInt64(&s.stats.tasklist.nFailed, 1)
+		s.logger.Error("failed to report tasklist", tag.Error(err))
+		return
+	}
+	s.logger.Info("tasklist reported", tag.WorkflowDomainID(info.DomainID), tag.WorkflowTaskListName(info.Name), tag.TaskType(info.TaskType))
+}
+
+// Execute deletes the task lists reported by a previous run in report only mode.
+// A task list is deleted only if it is still empty, and since the delete is conditional
+// on the reported rangeID, task lists which got an owner in the meantime are left alone.
+//
+// Persistence calls are rate limited to rps and the report is heartbeated after every
+// entry. progress is the report heartbeated by a previous attempt, the entries it
+// covers are skipped.
+func (s *Scavenger) Execute(iterator store.ScanOutputIterator, rps int, progress gc.Report) (gc.Report, error) {
+	report := progress
+	report.Type = gc.TypeTaskList
+	limiter := rate.NewLimiter(rate.Limit(rps), rps)
+	handled := report.Scanned
+	for i := 0; iterator.HasNext(); i++ {
+		soe, err := iterator.Next()
+		if err != nil {
+			return report, err
+		}
+		if i < handled {
+			continue
+		}
+		if err := s.executeEntry(soe, limiter, &report); err != nil {
+			return report, err
+		}
+		if !s.isInTest {
+			activity.RecordHeartbeat(s.ctx, report)
+		}
+	}
+	return report, nil
+}
+
+// executeEntry deletes a single reported task list, it only returns an error if the scavenger context is done
+func (s *Scavenger) executeEntry(soe *store.ScanOutputEntity, limiter *rate.Limiter, report *gc.Report) error {
+	report.Scanned++
+	tl, ok := soe.Execution.(*entity.TaskList)
+	if !ok {
+		report.Failed++
+		return nil
+	}
+	info := &p.TaskListInfo{
+		DomainID: tl.DomainID,
+		Name:     tl.Name,
+		TaskType: tl.TaskType,
+		RangeID:  tl.RangeID,
+	}
+	if err := limiter.Wait(s.ctx); err != nil {
+		return err
+	}
+	resp, err := s.getTasks(info, 1)
+	if err != nil {
+		report.Failed++
+		s.logger.Error("getTasks error", tag.Error(err), tag.WorkflowDomainID(info.DomainID), tag.WorkflowTaskListName(info.Name))
+		return nil
+	}
+	if len(resp.Tasks) > 0 {
+		report.Skipped++
+		return nil
+	}
+	report.Garbage++
+	if err := limiter.Wait(s.ctx); err != nil {
+		return err
+	}
+	err = s.deleteTaskList(info)
+	switch err.(type) {
+	case nil:
+		report.Deleted++
+		atomic.AddInt64(&s.stats.tasklist.nDeleted, 1)
+		s.logger.Info("tasklist deleted", tag.WorkflowDomainID(info.DomainID), tag.WorkflowTaskListName(info.Name), tag.TaskType(info.TaskType))
+	case *p.ConditionFailedError, *types.EntityNotExistsError:
+		// the task list was leased or deleted after it was reported
+		report.Skipped++
+	default:
+		report.Failed++
+		s.logger.Error("deleteTaskList error", tag.Error(err), tag.WorkflowDomainID(info.DomainID), tag.WorkflowTaskListName(info.Name))
+	}
+	return nil
+}
+
 func (s *Scavenger) deleteHandlerLog(info *p.TaskListInfo, nProcessed int, nDeleted int, err error) {
 	atomic.AddInt64(&s.stats.task.nDeleted, int64(nDeleted))
 	atomic.AddInt64(&s.stats.task.nProcessed, int64(nProcessed))
@@ -33,7 +33,9 @@ import (
 	"github.com/uber/cadence/common/log/tag"
 	"github.com/uber/cadence/common/metrics"
 	p "github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/reconciliation/store"
 	"github.com/uber/cadence/service/worker/scanner/executor"
+	"github.com/uber/cadence/service/worker/scanner/gc"
 )
 
 const (
@@ -61,12 +63,20 @@ type (
 		maxTasksPerJobFn         dynamicconfig.IntPropertyFn
 		cleanOrphans             dynamicconfig.BoolPropertyFn
 		pollInterval             time.Duration
+		isInTest                 bool
+
+		// reportWriter is set in report only mode, abandoned task lists are written to it instead of being deleted
+		reportWriter store.ExecutionWriter
+		reportLock   sync.Mutex
+		reportKeys   *store.Keys
 	}
 
 	stats struct {
 		tasklist struct {
 			nProcessed int64
 			nDeleted   int64
+			nGarbage   int64
+			nFailed    int64
 		}
 		task struct {
 			nProcessed int64
@@ -108,6 +118,11 @@ type (
 // two conditions
 //  - either all task lists are processed successfully (or)
 //  - Stop() method is called to stop the scavenger
+//
+// If reportWriter is not nil the scavenger runs in report only mode and idle
+// task lists are written to it instead of being deleted. They can be deleted
+// later on by calling Execute with an iterator over the report. Expired tasks
+// are deleted in both modes.
 func NewScavenger(
 	ctx context.Context,
 	db p.TaskManager,
@@ -115,6 +130,7 @@ func NewScavenger(
 	logger log.Logger,
 	opts *Options,
 	cache cache.DomainCache,
+	reportWriter store.ExecutionWriter,
 ) *Scavenger {
 	taskExecutor := executor.NewFixedSizePoolExecutor(
 		taskListBatchSize,
@@ -172,6 +188,7 @@ func NewScavenger(
 		pollInterval:             pollInterval,
 		maxTasksPerJobFn:         maxTasksPerJobFn,
 		getOrphanTasksPageSizeFn: getOrphanTasksPageSize,
+		reportWriter:             reportWriter,
 	}
 }
 
@@ -209,6 +226,7 @@ func (s *Scavenger) Alive() bool {
 // run does a single run over all executorTask lists
 func (s *Scavenger) run() {
 	defer func() {
+		s.flushReport()
 		s.emitStats()
 		go s.Stop()
 		s.stopWG.Done()
@@ -268,6 +286,36 @@ func (s *Scavenger) emitStats() {
 	s.scope.UpdateGauge(metrics.TaskListDeletedCount, float64(s.stats.tasklist.nDeleted))
 }
 
+// Report returns the summary of the scavenger run, it should only be called after the scavenger stopped
+func (s *Scavenger) Report() gc.Report {
+	report := gc.Report{
+		Type:       gc.TypeTaskList,
+		ReportOnly: s.reportWriter != nil,
+		Scanned:    int(atomic.LoadInt64(&s.stats.tasklist.nProcessed)),
+		Garbage:    int(atomic.LoadInt64(&s.stats.tasklist.nGarbage)),
+		Deleted:    int(atomic.LoadInt64(&s.stats.tasklist.nDeleted)),
+		Failed:     int(atomic.LoadInt64(&s.stats.tasklist.nFailed)),
+	}
+	report.Skipped = report.Scanned - report.Garbage
+	if s.reportKeys != nil {
+		report.Keys = []store.Keys{*s.reportKeys}
+	}
+	return report
+}
+
+func (s *Scavenger) flushReport() {
+	if s.reportWriter == nil {
+		return
+	}
+	s.reportLock.Lock()
+	defer s.reportLock.Unlock()
+	if err := s.reportWriter.Flush(); err != nil {
+		s.logger.Error("failed to flush task list report", tag.Error(err))
+		return
+	}
+	s.reportKeys = s.reportWriter.FlushedKeys()
+}
+
 // newTask returns a new instance of an executable task which will process a single task list
 func (s *Scavenger) newTask(info *p.TaskListInfo) executor.Task {
 	return &executorTask{
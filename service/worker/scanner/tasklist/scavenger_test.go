@@ -39,6 +39,9 @@ import (
 	"github.com/uber/cadence/common/metrics"
 	"github.com/uber/cadence/common/mocks"
 	p "github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/reconciliation/entity"
+	"github.com/uber/cadence/common/reconciliation/store"
+	"github.com/uber/cadence/service/worker/scanner/gc"
 )
 
 type (
@@ -87,6 +90,7 @@ func (s *ScavengerTestSuite) SetupTest() {
 			ExecutorPollInterval:     time.Millisecond * 50,
 		},
 		s.mockDomainCache,
+		nil,
 	)
 	s.scvgrCancelFn = scvgrCancelFn
 }
@@ -195,6 +199,133 @@ func (s *ScavengerTestSuite) TestAllExpiredTasksWithErrors() {
 	s.Equal(1, len(result), "expected partial deletion due to transient errors")
 }
 
+func (s *ScavengerTestSuite) TestReportOnlyIdleTaskLists() {
+	nTaskLists := 3
+	for i := 0; i < nTaskLists; i++ {
+		name := fmt.Sprintf("test-idle-tl-%v", i)
+		s.taskListTable.generate(name, true)
+		tt := newMockTaskTable()
+		tt.generate(16, true)
+		s.taskTables[name] = tt
+	}
+	s.taskListTable.generate("test-active-tl", false)
+	s.taskTables["test-active-tl"] = newMockTaskTable()
+
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	writer := store.NewMockExecutionWriter(ctrl)
+	writer.EXPECT().Add(gomock.Any()).Return(nil).Times(nTaskLists)
+	writer.EXPECT().Flush().Return(nil).Times(1)
+	writer.EXPECT().FlushedKeys().Return(&store.Keys{UUID: "uuid"}).Times(1)
+	s.scvgr.reportWriter = writer
+
+	s.mockDomainCache.EXPECT().GetDomainName(gomock.Any()).Return("test_domain_name", nil).AnyTimes()
+	s.setupTaskMgrMocks()
+	s.runScavenger()
+	for tl, tbl := range s.taskTables {
+		s.Equal(0, len(tbl.get(100)), "failed to delete all expired tasks")
+		s.NotNil(s.taskListTable.get(tl), "scavenger deleted a task list in report only mode")
+	}
+	report := s.scvgr.Report()
+	s.True(report.ReportOnly)
+	s.Equal(nTaskLists+1, report.Scanned)
+	s.Equal(nTaskLists, report.Garbage)
+	s.Equal(0, report.Deleted)
+	s.Equal([]store.Keys{{UUID: "uuid"}}, report.Keys)
+}
+
+func (s *ScavengerTestSuite) TestExecute() {
+	s.taskListTable.generate("test-empty-tl", true)
+	s.taskTables["test-empty-tl"] = newMockTaskTable()
+	s.taskListTable.generate("test-reused-tl", true)
+	tt := newMockTaskTable()
+	tt.generate(1, false)
+	s.taskTables["test-reused-tl"] = tt
+
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	iterator := store.NewMockScanOutputIterator(ctrl)
+	gomock.InOrder(
+		iterator.EXPECT().HasNext().Return(true),
+		iterator.EXPECT().Next().Return(&store.ScanOutputEntity{Execution: &entity.TaskList{
+			DomainID: "domainID",
+			Name:     "test-empty-tl",
+			RangeID:  22,
+		}}, nil),
+		iterator.EXPECT().HasNext().Return(true),
+		iterator.EXPECT().Next().Return(&store.ScanOutputEntity{Execution: &entity.TaskList{
+			DomainID: "domainID",
+			Name:     "test-reused-tl",
+			RangeID:  22,
+		}}, nil),
+		iterator.EXPECT().HasNext().Return(false),
+	)
+
+	s.mockDomainCache.EXPECT().GetDomainName(gomock.Any()).Return("test_domain_name", nil).AnyTimes()
+	s.setupTaskMgrMocks()
+	s.scvgr.isInTest = true
+	report, err := s.scvgr.Execute(iterator, 100, gc.Report{})
+	s.NoError(err)
+	s.Equal(gc.TypeTaskList, report.Type)
+	s.Equal(2, report.Scanned)
+	s.Equal(1, report.Deleted)
+	s.Equal(1, report.Skipped)
+	s.Nil(s.taskListTable.get("test-empty-tl"))
+	s.NotNil(s.taskListTable.get("test-reused-tl"))
+}
+
+func (s *ScavengerTestSuite) TestExecute_ResumesFromProgress() {
+	s.taskListTable.generate("test-handled-tl", true)
+	s.taskTables["test-handled-tl"] = newMockTaskTable()
+	s.taskListTable.generate("test-empty-tl", true)
+	s.taskTables["test-empty-tl"] = newMockTaskTable()
+
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	iterator := store.NewMockScanOutputIterator(ctrl)
+	gomock.InOrder(
+		iterator.EXPECT().HasNext().Return(true),
+		iterator.EXPECT().Next().Return(&store.ScanOutputEntity{Execution: &entity.TaskList{
+			DomainID: "domainID",
+			Name:     "test-handled-tl",
+		}}, nil),
+		iterator.EXPECT().HasNext().Return(true),
+		iterator.EXPECT().Next().Return(&store.ScanOutputEntity{Execution: &entity.TaskList{
+			DomainID: "domainID",
+			Name:     "test-empty-tl",
+		}}, nil),
+		iterator.EXPECT().HasNext().Return(false),
+	)
+
+	s.mockDomainCache.EXPECT().GetDomainName(gomock.Any()).Return("test_domain_name", nil).AnyTimes()
+	s.setupTaskMgrMocks()
+	s.scvgr.isInTest = true
+	report, err := s.scvgr.Execute(iterator, 100, gc.Report{Type: gc.TypeTaskList, Scanned: 1, Garbage: 1, Failed: 1})
+	s.NoError(err)
+	s.Equal(gc.Report{Type: gc.TypeTaskList, Scanned: 2, Garbage: 2, Deleted: 1, Failed: 1}, report)
+	s.NotNil(s.taskListTable.get("test-handled-tl"), "entries covered by the progress must be skipped")
+	s.Nil(s.taskListTable.get("test-empty-tl"))
+}
+
+func (s *ScavengerTestSuite) TestExecute_StopsWhenContextIsDone() {
+	s.scvgrCancelFn()
+
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	iterator := store.NewMockScanOutputIterator(ctrl)
+	iterator.EXPECT().HasNext().Return(true)
+	iterator.EXPECT().Next().Return(&store.ScanOutputEntity{Execution: &entity.TaskList{
+		DomainID: "domainID",
+		Name:     "test-empty-tl",
+	}}, nil)
+
+	s.scvgr.isInTest = true
+	report, err := s.scvgr.Execute(iterator, 100, gc.Report{})
+	s.Error(err)
+	s.Equal(1, report.Scanned)
+	s.Equal(0, report.Deleted)
+}
+
 func (s *ScavengerTestSuite) runScavenger() {
 	s.scvgr.Start()
 	timer := time.NewTimer(scavengerTestTimeout)
@@ -0,0 +1,330 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+
+// Package tasks contains the shard scanners which report and complete transfer and
+// replication tasks whose owning execution no longer exists.
+package tasks
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"go.uber.org/cadence/client"
+	"go.uber.org/cadence/workflow"
+
+	"github.com/uber/cadence/common/blobstore"
+	"github.com/uber/cadence/common/cache"
+	"github.com/uber/cadence/common/cluster"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/pagination"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/reconciliation/entity"
+	"github.com/uber/cadence/common/reconciliation/fetcher"
+	"github.com/uber/cadence/common/reconciliation/invariant"
+	"github.com/uber/cadence/common/reconciliation/store"
+	"github.com/uber/cadence/service/worker/scanner/shardscanner"
+)
+
+const (
+	// TransferScannerWFTypeName defines workflow type name for transfer tasks scanner
+	TransferScannerWFTypeName = "cadence-sys-transfer-tasks-scanner-workflow"
+	// TransferScannerWorkflowID is the workflow id of the transfer tasks scanner
+	TransferScannerWorkflowID = "cadence-sys-transfer-tasks-scanner"
+	// TransferScannerTaskListName is the task list of the transfer tasks scanner
+	TransferScannerTaskListName = "cadence-sys-transfer-tasks-scanner-tasklist-0"
+	// TransferFixerWFTypeName defines workflow type name for transfer tasks fixer
+	TransferFixerWFTypeName = "cadence-sys-transfer-tasks-fixer-workflow"
+	// TransferFixerWorkflowID is the workflow id of the transfer tasks fixer
+	TransferFixerWorkflowID = "cadence-sys-transfer-tasks-fixer"
+	// TransferFixerTaskListName is the task list of the transfer tasks fixer
+	TransferFixerTaskListName = "cadence-sys-transfer-tasks-fixer-tasklist-0"
+
+	// ReplicationScannerWFTypeName defines workflow type name for replication tasks scanner
+	ReplicationScannerWFTypeName = "cadence-sys-replication-tasks-scanner-workflow"
+	// ReplicationScannerWorkflowID is the workflow id of the replication tasks scanner
+	ReplicationScannerWorkflowID = "cadence-sys-replication-tasks-scanner"
+	// ReplicationScannerTaskListName is the task list of the replication tasks scanner
+	ReplicationScannerTaskListName = "cadence-sys-replication-tasks-scanner-tasklist-0"
+	// ReplicationFixerWFTypeName defines workflow type name for replication tasks fixer
+	ReplicationFixerWFTypeName = "cadence-sys-replication-tasks-fixer-workflow"
+	// ReplicationFixerWorkflowID is the workflow id of the replication tasks fixer
+	ReplicationFixerWorkflowID = "cadence-sys-replication-tasks-fixer"
+	// ReplicationFixerTaskListName is the task list of the replication tasks fixer
+	ReplicationFixerTaskListName = "cadence-sys-replication-tasks-fixer-tasklist-0"
+)
+
+// TransferScannerWorkflow starts transfer tasks scanner.
+func TransferScannerWorkflow(
+	ctx workflow.Context,
+	params shardscanner.ScannerWorkflowParams,
+) error {
+	wf, err := shardscanner.NewScannerWorkflow(ctx, TransferScannerWFTypeName, params)
+	if err != nil {
+		return err
+	}
+
+	return wf.Start(ctx)
+}
+
+// TransferFixerWorkflow starts transfer tasks fixer.
+func TransferFixerWorkflow(
+	ctx workflow.Context,
+	params shardscanner.FixerWorkflowParams,
+) error {
+	wf, err := shardscanner.NewFixerWorkflow(ctx, TransferFixerWFTypeName, params)
+	if err != nil {
+		return err
+	}
+
+	return wf.Start(ctx)
+}
+
+// ReplicationScannerWorkflow starts replication tasks scanner.
+func ReplicationScannerWorkflow(
+	ctx workflow.Context,
+	params shardscanner.ScannerWorkflowParams,
+) error {
+	wf, err := shardscanner.NewScannerWorkflow(ctx, ReplicationScannerWFTypeName, params)
+	if err != nil {
+		return err
+	}
+
+	return wf.Start(ctx)
+}
+
+// ReplicationFixerWorkflow starts replication tasks fixer.
+func ReplicationFixerWorkflow(
+	ctx workflow.Context,
+	params shardscanner.FixerWorkflowParams,
+) error {
+	wf, err := shardscanner.NewFixerWorkflow(ctx, ReplicationFixerWFTypeName, params)
+	if err != nil {
+		return err
+	}
+
+	return wf.Start(ctx)
+}
+
+// TransferScannerHooks provides hooks for transfer tasks scanner.
+func TransferScannerHooks() *shardscanner.ScannerHooks {
+	h, err := shardscanner.NewScannerHooks(TransferManager, TransferIterator)
+	if err != nil {
+		return nil
+	}
+	return h
+}
+
+// TransferFixerHooks provides hooks for transfer tasks fixer.
+func TransferFixerHooks() *shardscanner.FixerHooks {
+	h, err := shardscanner.NewFixerHooks(TransferFixerManager, TransferFixerIterator)
+	if err != nil {
+		return nil
+	}
+	return h
+}
+
+// ReplicationScannerHooks provides hooks for replication tasks scanner.
+func ReplicationScannerHooks() *shardscanner.ScannerHooks {
+	h, err := shardscanner.NewScannerHooks(ReplicationManager, ReplicationIterator)
+	if err != nil {
+		return nil
+	}
+	return h
+}
+
+// ReplicationFixerHooks provides hooks for replication tasks fixer.
+func ReplicationFixerHooks() *shardscanner.FixerHooks {
+	h, err := shardscanner.NewFixerHooks(ReplicationFixerManager, ReplicationFixerIterator)
+	if err != nil {
+		return nil
+	}
+	return h
+}
+
+// TransferManager provides invariant manager for transfer tasks scanner.
+func TransferManager(
+	_ context.Context,
+	pr persistence.Retryer,
+	_ shardscanner.ScanShardActivityParams,
+	cache cache.DomainCache,
+) invariant.Manager {
+	return invariant.NewInvariantManager([]invariant.Invariant{invariant.NewOrphanedTransferTask(pr, cache)})
+}
+
+// TransferIterator provides iterator for transfer tasks scanner.
+func TransferIterator(
+	ctx context.Context,
+	pr persistence.Retryer,
+	params shardscanner.ScanShardActivityParams,
+) pagination.Iterator {
+	return fetcher.TransferTaskIterator(ctx, pr, params.PageSize)
+}
+
+// TransferFixerManager provides invariant manager for transfer tasks fixer.
+func TransferFixerManager(
+	_ context.Context,
+	pr persistence.Retryer,
+	_ shardscanner.FixShardActivityParams,
+	cache cache.DomainCache,
+) invariant.Manager {
+	return invariant.NewInvariantManager([]invariant.Invariant{invariant.NewOrphanedTransferTask(pr, cache)})
+}
+
+// TransferFixerIterator provides iterator for transfer tasks fixer.
+func TransferFixerIterator(
+	ctx context.Context,
+	client blobstore.Client,
+	keys store.Keys,
+	_ shardscanner.FixShardActivityParams,
+) store.ScanOutputIterator {
+	return store.NewBlobstoreIterator(ctx, client, keys, &entity.TransferTask{})
+}
+
+// ReplicationManager provides invariant manager for replication tasks scanner.
+func ReplicationManager(
+	_ context.Context,
+	pr persistence.Retryer,
+	_ shardscanner.ScanShardActivityParams,
+	cache cache.DomainCache,
+) invariant.Manager {
+	return invariant.NewInvariantManager([]invariant.Invariant{invariant.NewOrphanedReplicationTask(pr, cache, nil)})
+}
+
+// ReplicationIterator provides iterator for replication tasks scanner.
+func ReplicationIterator(
+	ctx context.Context,
+	pr persistence.Retryer,
+	params shardscanner.ScanShardActivityParams,
+) pagination.Iterator {
+	return fetcher.ReplicationTaskIterator(ctx, pr, params.PageSize)
+}
+
+// ReplicationFixerManager provides invariant manager for replication tasks fixer.
+// Orphaned replication tasks are only completed once every remote cluster acknowledged them.
+func ReplicationFixerManager(
+	ctx context.Context,
+	pr persistence.Retryer,
+	_ shardscanner.FixShardActivityParams,
+	cache cache.DomainCache,
+) invariant.Manager {
+	var ackLevel invariant.ReplicationAckLevelFn
+	if fixerCtx, err := shardscanner.GetFixerContext(ctx); err == nil {
+		ackLevel = replicationAckLevel(fixerCtx.Resource.GetShardManager(), fixerCtx.Resource.GetClusterMetadata())
+	}
+	return invariant.NewInvariantManager([]invariant.Invariant{invariant.NewOrphanedReplicationTask(pr, cache, ackLevel)})
+}
+
+// replicationAckLevel returns the minimum replication level of all remote clusters in the shard,
+// the same level up to which history hosts purge the replication queue
+func replicationAckLevel(
+	shardManager persistence.ShardManager,
+	clusterMetadata cluster.Metadata,
+) invariant.ReplicationAckLevelFn {
+	return func(ctx context.Context, shardID int) (int64, error) {
+		resp, err := shardManager.GetShard(ctx, &persistence.GetShardRequest{ShardID: shardID})
+		if err != nil {
+			return 0, err
+		}
+		ackLevel := int64(math.MaxInt64)
+		for clusterName := range clusterMetadata.GetRemoteClusterInfo() {
+			// clusters which never polled the shard have no level and keep every task
+			if level := resp.ShardInfo.ClusterReplicationLevel[clusterName]; level < ackLevel {
+				ackLevel = level
+			}
+		}
+		return ackLevel, nil
+	}
+}
+
+// ReplicationFixerIterator provides iterator for replication tasks fixer.
+func ReplicationFixerIterator(
+	ctx context.Context,
+	client blobstore.Client,
+	keys store.Keys,
+	_ shardscanner.FixShardActivityParams,
+) store.ScanOutputIterator {
+	return store.NewBlobstoreIterator(ctx, client, keys, &entity.ReplicationTask{})
+}
+
+// TransferScannerConfig configures transfer tasks scanner
+func TransferScannerConfig(dc *dynamicconfig.Collection) *shardscanner.ScannerConfig {
+	return &shardscanner.ScannerConfig{
+		ScannerWFTypeName: TransferScannerWFTypeName,
+		FixerWFTypeName:   TransferFixerWFTypeName,
+		DynamicParams: dynamicParams(
+			dc,
+			dynamicconfig.TransferTasksScannerEnabled,
+			dynamicconfig.TransferTasksFixerEnabled,
+			dynamicconfig.TransferTasksFixerDomainAllow,
+		),
+		DynamicCollection:    dc,
+		ScannerHooks:         TransferScannerHooks,
+		FixerHooks:           TransferFixerHooks,
+		StartWorkflowOptions: startWorkflowOptions(TransferScannerWorkflowID, TransferScannerTaskListName),
+		StartFixerOptions:    startWorkflowOptions(TransferFixerWorkflowID, TransferFixerTaskListName),
+	}
+}
+
+// ReplicationScannerConfig configures replication tasks scanner
+func ReplicationScannerConfig(dc *dynamicconfig.Collection) *shardscanner.ScannerConfig {
+	return &shardscanner.ScannerConfig{
+		ScannerWFTypeName: ReplicationScannerWFTypeName,
+		FixerWFTypeName:   ReplicationFixerWFTypeName,
+		DynamicParams: dynamicParams(
+			dc,
+			dynamicconfig.ReplicationTasksScannerEnabled,
+			dynamicconfig.ReplicationTasksFixerEnabled,
+			dynamicconfig.ReplicationTasksFixerDomainAllow,
+		),
+		DynamicCollection:    dc,
+		ScannerHooks:         ReplicationScannerHooks,
+		FixerHooks:           ReplicationFixerHooks,
+		StartWorkflowOptions: startWorkflowOptions(ReplicationScannerWorkflowID, ReplicationScannerTaskListName),
+		StartFixerOptions:    startWorkflowOptions(ReplicationFixerWorkflowID, ReplicationFixerTaskListName),
+	}
+}
+
+func dynamicParams(
+	dc *dynamicconfig.Collection,
+	scannerEnabled dynamicconfig.BoolKey,
+	fixerEnabled dynamicconfig.BoolKey,
+	fixerDomainAllow dynamicconfig.BoolKey,
+) shardscanner.DynamicParams {
+	return shardscanner.DynamicParams{
+		ScannerEnabled:          dc.GetBoolProperty(scannerEnabled),
+		FixerEnabled:            dc.GetBoolProperty(fixerEnabled),
+		Concurrency:             dc.GetIntProperty(dynamicconfig.TaskQueuesScannerConcurrency),
+		PageSize:                dc.GetIntProperty(dynamicconfig.TaskQueuesScannerPersistencePageSize),
+		BlobstoreFlushThreshold: dc.GetIntProperty(dynamicconfig.TaskQueuesScannerBlobstoreFlushThreshold),
+		ActivityBatchSize:       dc.GetIntProperty(dynamicconfig.TaskQueuesScannerActivityBatchSize),
+		AllowDomain:             dc.GetBoolPropertyFilteredByDomain(fixerDomainAllow),
+	}
+}
+
+func startWorkflowOptions(workflowID, taskList string) client.StartWorkflowOptions {
+	return client.StartWorkflowOptions{
+		ID:                           workflowID,
+		TaskList:                     taskList,
+		ExecutionStartToCloseTimeout: 20 * 365 * 24 * time.Hour,
+		WorkflowIDReusePolicy:        client.WorkflowIDReusePolicyAllowDuplicate,
+		CronSchedule:                 "* * * * *",
+	}
+}
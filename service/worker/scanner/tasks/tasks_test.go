@@ -0,0 +1,155 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+
+package tasks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/cadence/testsuite"
+	"go.uber.org/cadence/workflow"
+
+	"github.com/uber/cadence/common/cluster"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/mocks"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/reconciliation/invariant"
+	"github.com/uber/cadence/common/reconciliation/store"
+	"github.com/uber/cadence/service/worker/scanner/shardscanner"
+)
+
+type tasksWorkflowsSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+	controller *gomock.Controller
+}
+
+func TestTasksWorkflowsSuite(t *testing.T) {
+	suite.Run(t, new(tasksWorkflowsSuite))
+}
+
+func (s *tasksWorkflowsSuite) SetupSuite() {
+	workflow.Register(TransferScannerWorkflow)
+	workflow.Register(ReplicationScannerWorkflow)
+	s.controller = gomock.NewController(s.T())
+}
+
+func (s *tasksWorkflowsSuite) TestScannerConfig_SetsHooks() {
+	dc := dynamicconfig.NewCollection(dynamicconfig.NewMockClient(s.controller), log.NewNoop())
+
+	transfer := TransferScannerConfig(dc)
+	s.Equal(TransferScannerWFTypeName, transfer.ScannerWFTypeName)
+	s.Equal(TransferFixerWFTypeName, transfer.FixerWFTypeName)
+	s.Equal(TransferScannerWorkflowID, transfer.StartWorkflowOptions.ID)
+	s.Equal(TransferFixerTaskListName, transfer.StartFixerOptions.TaskList)
+	s.NotNil(transfer.ScannerHooks())
+	s.NotNil(transfer.FixerHooks())
+
+	replication := ReplicationScannerConfig(dc)
+	s.Equal(ReplicationScannerWFTypeName, replication.ScannerWFTypeName)
+	s.Equal(ReplicationFixerWFTypeName, replication.FixerWFTypeName)
+	s.Equal(ReplicationScannerWorkflowID, replication.StartWorkflowOptions.ID)
+	s.Equal(ReplicationFixerTaskListName, replication.StartFixerOptions.TaskList)
+	s.NotNil(replication.ScannerHooks())
+	s.NotNil(replication.FixerHooks())
+}
+
+func (s *tasksWorkflowsSuite) TestScannerWorkflow_Success() {
+	for _, wf := range []interface{}{TransferScannerWorkflow, ReplicationScannerWorkflow} {
+		env := s.NewTestWorkflowEnvironment()
+		env.OnActivity(shardscanner.ActivityScannerConfig, mock.Anything, mock.Anything).Return(shardscanner.ResolvedScannerWorkflowConfig{
+			GenericScannerConfig: shardscanner.GenericScannerConfig{
+				Enabled:           true,
+				Concurrency:       1,
+				ActivityBatchSize: 2,
+			},
+		}, nil)
+		env.OnActivity(shardscanner.ActivityScannerEmitMetrics, mock.Anything, mock.Anything).Return(nil)
+		env.OnActivity(shardscanner.ActivityScanShard, mock.Anything, shardscanner.ScanShardActivityParams{
+			Shards: []int{0, 1},
+		}).Return([]shardscanner.ScanReport{
+			{
+				ShardID: 0,
+				Stats:   shardscanner.ScanStats{EntitiesCount: 5},
+				Result:  shardscanner.ScanResult{ShardScanKeys: &shardscanner.ScanKeys{}},
+			},
+			{
+				ShardID: 1,
+				Stats: shardscanner.ScanStats{
+					EntitiesCount:  5,
+					CorruptedCount: 2,
+					CorruptionByType: map[invariant.Name]int64{
+						invariant.OrphanedTransferTask: 2,
+					},
+				},
+				Result: shardscanner.ScanResult{
+					ShardScanKeys: &shardscanner.ScanKeys{
+						Corrupt: &store.Keys{UUID: "test_uuid", MinPage: 0, MaxPage: 1},
+					},
+				},
+			},
+		}, nil)
+
+		env.ExecuteWorkflow(wf, shardscanner.ScannerWorkflowParams{
+			Shards: shardscanner.Shards{List: []int{0, 1}},
+		})
+		s.True(env.IsWorkflowCompleted())
+		s.NoError(env.GetWorkflowError())
+
+		aggValue, err := env.QueryWorkflow(shardscanner.AggregateReportQuery)
+		s.NoError(err)
+		var agg shardscanner.AggregateScanReportResult
+		s.NoError(aggValue.Get(&agg))
+		s.Equal(int64(10), agg.EntitiesCount)
+		s.Equal(int64(2), agg.CorruptedCount)
+	}
+}
+
+func (s *tasksWorkflowsSuite) TestReplicationAckLevel() {
+	shardManager := &mocks.ShardManager{}
+	shardManager.On("GetShard", mock.Anything, &persistence.GetShardRequest{ShardID: 1}).Return(&persistence.GetShardResponse{
+		ShardInfo: &persistence.ShardInfo{
+			ClusterReplicationLevel: map[string]int64{
+				cluster.TestCurrentClusterName:     100,
+				cluster.TestAlternativeClusterName: 50,
+			},
+		},
+	}, nil)
+	shardManager.On("GetShard", mock.Anything, &persistence.GetShardRequest{ShardID: 2}).Return(&persistence.GetShardResponse{
+		ShardInfo: &persistence.ShardInfo{},
+	}, nil)
+	shardManager.On("GetShard", mock.Anything, &persistence.GetShardRequest{ShardID: 3}).Return(nil, errors.New("shard not found"))
+
+	ackLevel := replicationAckLevel(shardManager, cluster.TestActiveClusterMetadata)
+	level, err := ackLevel(context.Background(), 1)
+	s.NoError(err)
+	s.Equal(int64(50), level, "the level of the current cluster must be ignored")
+	level, err = ackLevel(context.Background(), 2)
+	s.NoError(err)
+	s.Equal(int64(0), level, "clusters which never polled the shard must keep every task")
+	_, err = ackLevel(context.Background(), 3)
+	s.Error(err)
+}
@@ -22,18 +22,25 @@ package scanner
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"github.com/pborman/uuid"
 	"go.uber.org/cadence"
 	"go.uber.org/cadence/activity"
 	cclient "go.uber.org/cadence/client"
 	"go.uber.org/cadence/workflow"
 
 	"github.com/uber/cadence/common/log/tag"
+	"github.com/uber/cadence/common/reconciliation/entity"
+	"github.com/uber/cadence/common/reconciliation/store"
 	"github.com/uber/cadence/service/worker/scanner/executions"
+	"github.com/uber/cadence/service/worker/scanner/gc"
 	"github.com/uber/cadence/service/worker/scanner/history"
 	"github.com/uber/cadence/service/worker/scanner/tasklist"
+	"github.com/uber/cadence/service/worker/scanner/tasks"
 	"github.com/uber/cadence/service/worker/scanner/timers"
+	"github.com/uber/cadence/service/worker/workercommon"
 )
 
 const (
@@ -41,15 +48,19 @@ const (
 	maxConcurrentDecisionTaskExecutionSize = 10
 	infiniteDuration                       = 20 * 365 * 24 * time.Hour
 
-	tlScannerWFID                 = "cadence-sys-tl-scanner"
+	tlScannerWFID                 = gc.TaskListScannerWorkflowID
 	tlScannerWFTypeName           = "cadence-sys-tl-scanner-workflow"
-	tlScannerTaskListName         = "cadence-sys-tl-scanner-tasklist-0"
+	tlScannerTaskListName         = gc.TaskListScannerTaskListName
 	taskListScavengerActivityName = "cadence-sys-tl-scanner-scvg-activity"
 
-	historyScannerWFID           = "cadence-sys-history-scanner"
+	historyScannerWFID           = gc.HistoryScannerWorkflowID
 	historyScannerWFTypeName     = "cadence-sys-history-scanner-workflow"
-	historyScannerTaskListName   = "cadence-sys-history-scanner-tasklist-0"
+	historyScannerTaskListName   = gc.HistoryScannerTaskListName
 	historyScavengerActivityName = "cadence-sys-history-scanner-scvg-activity"
+
+	gcExecutorActivityName = "cadence-sys-gc-executor-activity"
+	// gcReportFlushThreshold is the number of reported entities buffered before they are written to blobstore
+	gcReportFlushThreshold = 1000
 )
 
 var (
@@ -90,43 +101,143 @@ func init() {
 	workflow.RegisterWithOptions(HistoryScannerWorkflow, workflow.RegisterOptions{Name: historyScannerWFTypeName})
 	activity.RegisterWithOptions(HistoryScavengerActivity, activity.RegisterOptions{Name: historyScavengerActivityName})
 
+	workflow.RegisterWithOptions(GCExecutorWorkflow, workflow.RegisterOptions{Name: gc.ExecutorWFTypeName})
+	activity.RegisterWithOptions(GCExecutorActivity, activity.RegisterOptions{Name: gcExecutorActivityName})
+
 	workflow.RegisterWithOptions(executions.ConcreteScannerWorkflow, workflow.RegisterOptions{Name: executions.ConcreteExecutionsScannerWFTypeName})
 	workflow.RegisterWithOptions(executions.CurrentScannerWorkflow, workflow.RegisterOptions{Name: executions.CurrentExecutionsScannerWFTypeName})
 	workflow.RegisterWithOptions(executions.ConcreteFixerWorkflow, workflow.RegisterOptions{Name: executions.ConcreteExecutionsFixerWFTypeName})
 	workflow.RegisterWithOptions(executions.CurrentFixerWorkflow, workflow.RegisterOptions{Name: executions.CurrentExecutionsFixerWFTypeName})
+	workflow.RegisterWithOptions(executions.VisibilityScannerWorkflow, workflow.RegisterOptions{Name: executions.VisibilityScannerWFTypeName})
+	workflow.RegisterWithOptions(executions.VisibilityFixerWorkflow, workflow.RegisterOptions{Name: executions.VisibilityFixerWFTypeName})
 	workflow.RegisterWithOptions(timers.ScannerWorkflow, workflow.RegisterOptions{Name: timers.ScannerWFTypeName})
 	workflow.RegisterWithOptions(timers.FixerWorkflow, workflow.RegisterOptions{Name: timers.FixerWFTypeName})
+	workflow.RegisterWithOptions(tasks.TransferScannerWorkflow, workflow.RegisterOptions{Name: tasks.TransferScannerWFTypeName})
+	workflow.RegisterWithOptions(tasks.TransferFixerWorkflow, workflow.RegisterOptions{Name: tasks.TransferFixerWFTypeName})
+	workflow.RegisterWithOptions(tasks.ReplicationScannerWorkflow, workflow.RegisterOptions{Name: tasks.ReplicationScannerWFTypeName})
+	workflow.RegisterWithOptions(tasks.ReplicationFixerWorkflow, workflow.RegisterOptions{Name: tasks.ReplicationFixerWFTypeName})
 }
 
 // TaskListScannerWorkflow is the workflow that runs the task-list scanner background daemon
 func TaskListScannerWorkflow(
 	ctx workflow.Context,
-) error {
+) (*gc.Report, error) {
 
+	report, err := setGCReportQueryHandler(ctx, gc.TypeTaskList)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := setGCProgressQueryHandler(ctx)
+	if err != nil {
+		return nil, err
+	}
 	future := workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, activityOptions), taskListScavengerActivityName)
-	return future.Get(ctx, nil)
+	if err := future.Get(ctx, report); err != nil {
+		progress.AddError(err.Error(), workflow.Now(ctx))
+		return nil, err
+	}
+	completeGCProgress(ctx, progress, report)
+	return report, nil
 }
 
 // HistoryScannerWorkflow is the workflow that runs the history scanner background daemon
 func HistoryScannerWorkflow(
 	ctx workflow.Context,
-) error {
+) (*gc.Report, error) {
 
+	report, err := setGCReportQueryHandler(ctx, gc.TypeHistory)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := setGCProgressQueryHandler(ctx)
+	if err != nil {
+		return nil, err
+	}
 	future := workflow.ExecuteActivity(
 		workflow.WithActivityOptions(ctx, activityOptions),
 		historyScavengerActivityName,
 	)
-	return future.Get(ctx, nil)
+	if err := future.Get(ctx, report); err != nil {
+		progress.AddError(err.Error(), workflow.Now(ctx))
+		return nil, err
+	}
+	completeGCProgress(ctx, progress, report)
+	return report, nil
+}
+
+// GCExecutorWorkflow is the workflow that deletes the garbage reported by the history or task-list scanner
+func GCExecutorWorkflow(
+	ctx workflow.Context,
+	params gc.ExecutorParams,
+) (*gc.Report, error) {
+
+	report := &gc.Report{Type: params.Type}
+	if err := workflow.SetQueryHandler(ctx, gc.ReportQueryType, func() (*gc.Report, error) {
+		return report, nil
+	}); err != nil {
+		return nil, err
+	}
+	progress, err := setGCProgressQueryHandler(ctx)
+	if err != nil {
+		return nil, err
+	}
+	future := workflow.ExecuteActivity(
+		workflow.WithActivityOptions(ctx, activityOptions),
+		gcExecutorActivityName,
+		params,
+	)
+	if err := future.Get(ctx, report); err != nil {
+		progress.AddError(err.Error(), workflow.Now(ctx))
+		return nil, err
+	}
+	completeGCProgress(ctx, progress, report)
+	return report, nil
+}
+
+// setGCReportQueryHandler registers the report query of a cron scanner workflow.
+// Until the current run completes the report of the last completed run is returned.
+func setGCReportQueryHandler(ctx workflow.Context, gcType gc.Type) (*gc.Report, error) {
+	report := &gc.Report{Type: gcType}
+	if workflow.HasLastCompletionResult(ctx) {
+		if err := workflow.GetLastCompletionResult(ctx, report); err != nil {
+			workflow.GetLogger(ctx).Warn("failed to get last completion result")
+		}
+	}
+	if err := workflow.SetQueryHandler(ctx, gc.ReportQueryType, func() (*gc.Report, error) {
+		return report, nil
+	}); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// setGCProgressQueryHandler registers the progress query of a garbage collection workflow.
+// The counts are only known once the activity of the current run completes.
+func setGCProgressQueryHandler(ctx workflow.Context) (*workercommon.Progress, error) {
+	progress := workercommon.NewProgress(workflow.Now(ctx))
+	progress.SetPhase(workercommon.ProgressPhaseRunning, workflow.Now(ctx))
+	if err := workercommon.SetProgressQueryHandler(ctx, func() *workercommon.Progress {
+		return progress
+	}); err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+func completeGCProgress(ctx workflow.Context, progress *workercommon.Progress, report *gc.Report) {
+	progress.SetTotal(int64(report.Scanned), workflow.Now(ctx))
+	progress.Add(int64(report.Scanned), int64(report.Failed), workflow.Now(ctx))
+	progress.SetPhase(workercommon.ProgressPhaseCompleted, workflow.Now(ctx))
 }
 
 // HistoryScavengerActivity is the activity that runs history scavenger
 func HistoryScavengerActivity(
 	activityCtx context.Context,
-) (history.ScavengerHeartbeatDetails, error) {
+) (gc.Report, error) {
 
 	ctx, err := getScannerContext(activityCtx)
 	if err != nil {
-		return history.ScavengerHeartbeatDetails{}, err
+		return gc.Report{}, err
 	}
 
 	rps := ctx.cfg.ScannerPersistenceMaxQPS()
@@ -138,6 +249,11 @@ func HistoryScavengerActivity(
 			res.GetLogger().Error("Failed to recover from last heartbeat, start over from beginning", tag.Error(err))
 		}
 	}
+	var reportWriter store.ExecutionWriter
+	reportOnly := ctx.cfg.HistoryScannerReportOnly()
+	if reportOnly {
+		reportWriter = store.NewBlobstoreWriter(uuid.New(), store.CorruptedExtension, res.GetBlobstoreClient(), gcReportFlushThreshold)
+	}
 	cache := res.GetDomainCache()
 	scavenger := history.NewScavenger(
 		res.GetHistoryManager(),
@@ -148,19 +264,27 @@ func HistoryScavengerActivity(
 		res.GetLogger(),
 		ctx.cfg.MaxWorkflowRetentionInDays,
 		cache,
+		reportWriter,
 	)
-	return scavenger.Run(activityCtx)
+	hbd, err = scavenger.Run(activityCtx)
+	report := historyGCReport(hbd)
+	report.ReportOnly = reportOnly
+	return report, err
 }
 
 // TaskListScavengerActivity is the activity that runs task list scavenger
 func TaskListScavengerActivity(
 	activityCtx context.Context,
-) error {
+) (gc.Report, error) {
 	ctx, err := getScannerContext(activityCtx)
 	if err != nil {
-		return err
+		return gc.Report{}, err
 	}
 	res := ctx.resource
+	var reportWriter store.ExecutionWriter
+	if ctx.cfg.TaskListScannerReportOnly() {
+		reportWriter = store.NewBlobstoreWriter(uuid.New(), store.CorruptedExtension, res.GetBlobstoreClient(), gcReportFlushThreshold)
+	}
 	scavenger := tasklist.NewScavenger(
 		activityCtx,
 		res.GetTaskManager(),
@@ -168,6 +292,7 @@ func TaskListScavengerActivity(
 		res.GetLogger(),
 		&ctx.cfg.TaskListScannerOptions,
 		res.GetDomainCache(),
+		reportWriter,
 	)
 
 	res.GetLogger().Info("Starting task list scavenger")
@@ -177,9 +302,87 @@ func TaskListScavengerActivity(
 		if activityCtx.Err() != nil {
 			res.GetLogger().Info("activity context error, stopping scavenger", tag.Error(activityCtx.Err()))
 			scavenger.Stop()
-			return activityCtx.Err()
+			return gc.Report{}, activityCtx.Err()
 		}
 		time.Sleep(tlScavengerHBInterval)
 	}
-	return nil
+	return scavenger.Report(), nil
+}
+
+// GCExecutorActivity is the activity that deletes the garbage reported by the history or task-list scanner
+func GCExecutorActivity(
+	activityCtx context.Context,
+	params gc.ExecutorParams,
+) (gc.Report, error) {
+	ctx, err := getScannerContext(activityCtx)
+	if err != nil {
+		return gc.Report{}, err
+	}
+	res := ctx.resource
+
+	var reported entity.Entity
+	switch params.Type {
+	case gc.TypeHistory:
+		reported = &entity.HistoryBranch{}
+	case gc.TypeTaskList:
+		reported = &entity.TaskList{}
+	default:
+		return gc.Report{}, cadence.NewCustomError(fmt.Sprintf("unknown gc type: %v", params.Type))
+	}
+	iterators := make([]store.ScanOutputIterator, 0, len(params.Keys))
+	for _, keys := range params.Keys {
+		iterators = append(iterators, store.NewBlobstoreIterator(activityCtx, res.GetBlobstoreClient(), keys, reported))
+	}
+	iterator := store.NewConcatIterator(iterators...)
+
+	if params.Type == gc.TypeTaskList {
+		scavenger := tasklist.NewScavenger(
+			activityCtx,
+			res.GetTaskManager(),
+			res.GetMetricsClient(),
+			res.GetLogger(),
+			&ctx.cfg.TaskListScannerOptions,
+			res.GetDomainCache(),
+			nil,
+		)
+		progress := gc.Report{}
+		if activity.HasHeartbeatDetails(activityCtx) {
+			if err := activity.GetHeartbeatDetails(activityCtx, &progress); err != nil {
+				res.GetLogger().Error("Failed to recover from last heartbeat, start over from beginning", tag.Error(err))
+			}
+		}
+		return scavenger.Execute(iterator, ctx.cfg.ScannerPersistenceMaxQPS(), progress)
+	}
+
+	hbd := history.ScavengerHeartbeatDetails{}
+	if activity.HasHeartbeatDetails(activityCtx) {
+		if err := activity.GetHeartbeatDetails(activityCtx, &hbd); err != nil {
+			res.GetLogger().Error("Failed to recover from last heartbeat, start over from beginning", tag.Error(err))
+		}
+	}
+	scavenger := history.NewScavenger(
+		res.GetHistoryManager(),
+		ctx.cfg.ScannerPersistenceMaxQPS(),
+		res.GetHistoryClient(),
+		hbd,
+		res.GetMetricsClient(),
+		res.GetLogger(),
+		ctx.cfg.MaxWorkflowRetentionInDays,
+		res.GetDomainCache(),
+		nil,
+	)
+	hbd, err = scavenger.Execute(activityCtx, iterator)
+	return historyGCReport(hbd), err
+}
+
+func historyGCReport(hbd history.ScavengerHeartbeatDetails) gc.Report {
+	return gc.Report{
+		Type:    gc.TypeHistory,
+		Scanned: hbd.SuccCount + hbd.ErrorCount + hbd.SkipCount,
+		Skipped: hbd.SkipCount,
+		Garbage: hbd.GarbageCount,
+		Deleted: hbd.DeletedCount,
+		Failed:  hbd.ErrorCount,
+		Keys:    hbd.ReportKeys,
+	}
 }
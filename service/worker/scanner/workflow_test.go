@@ -32,8 +32,11 @@ import (
 	"github.com/uber/cadence/common/dynamicconfig"
 	"github.com/uber/cadence/common/metrics"
 	p "github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/reconciliation/store"
 	"github.com/uber/cadence/common/resource"
+	"github.com/uber/cadence/service/worker/scanner/gc"
 	"github.com/uber/cadence/service/worker/scanner/tasklist"
+	"github.com/uber/cadence/service/worker/workercommon"
 
 	"go.uber.org/cadence/testsuite"
 	"go.uber.org/cadence/worker"
@@ -55,6 +58,58 @@ func (s *scannerWorkflowTestSuite) TestWorkflow() {
 	s.True(env.IsWorkflowCompleted())
 }
 
+func (s *scannerWorkflowTestSuite) TestHistoryScannerWorkflowReportQuery() {
+	env := s.NewTestWorkflowEnvironment()
+	expected := gc.Report{
+		Type:       gc.TypeHistory,
+		ReportOnly: true,
+		Scanned:    10,
+		Skipped:    7,
+		Garbage:    3,
+		Keys:       []store.Keys{{UUID: "uuid", MinPage: 0, MaxPage: 1, Extension: store.CorruptedExtension}},
+	}
+	env.OnActivity(historyScavengerActivityName, mock.Anything).Return(expected, nil)
+	env.ExecuteWorkflow(historyScannerWFTypeName)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+
+	var result gc.Report
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal(expected, result)
+
+	queryResult, err := env.QueryWorkflow(gc.ReportQueryType)
+	s.NoError(err)
+	var queried gc.Report
+	s.NoError(queryResult.Get(&queried))
+	s.Equal(expected, queried)
+
+	queryResult, err = env.QueryWorkflow(workercommon.ProgressQueryType)
+	s.NoError(err)
+	var progress workercommon.Progress
+	s.NoError(queryResult.Get(&progress))
+	s.Equal(workercommon.ProgressPhaseCompleted, progress.Phase)
+	s.Equal(int64(10), progress.Total)
+	s.Equal(int64(10), progress.Processed)
+	s.Equal(int64(0), progress.Failed)
+}
+
+func (s *scannerWorkflowTestSuite) TestGCExecutorWorkflow() {
+	env := s.NewTestWorkflowEnvironment()
+	params := gc.ExecutorParams{
+		Type: gc.TypeTaskList,
+		Keys: []store.Keys{{UUID: "uuid", MinPage: 0, MaxPage: 0, Extension: store.CorruptedExtension}},
+	}
+	expected := gc.Report{Type: gc.TypeTaskList, Scanned: 2, Garbage: 2, Deleted: 2}
+	env.OnActivity(gcExecutorActivityName, mock.Anything, params).Return(expected, nil)
+	env.ExecuteWorkflow(gc.ExecutorWFTypeName, params)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+
+	var result gc.Report
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal(expected, result)
+}
+
 func (s *scannerWorkflowTestSuite) TestScavengerActivity() {
 	env := s.NewTestActivityEnvironment()
 	controller := gomock.NewController(s.T())
@@ -72,6 +127,7 @@ func (s *scannerWorkflowTestSuite) TestScavengerActivity() {
 				EnableCleaning:           dynamicconfig.GetBoolPropertyFn(true),
 				ExecutorPollInterval:     time.Millisecond * 50,
 			},
+			TaskListScannerReportOnly: dynamicconfig.GetBoolPropertyFn(false),
 		},
 	}
 	env.SetTestTimeout(time.Second * 5)
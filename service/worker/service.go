@@ -38,7 +38,9 @@ import (
 	"github.com/uber/cadence/common/types"
 	"github.com/uber/cadence/service/worker/archiver"
 	"github.com/uber/cadence/service/worker/batcher"
+	"github.com/uber/cadence/service/worker/domaindeletion"
 	"github.com/uber/cadence/service/worker/esanalyzer"
+	"github.com/uber/cadence/service/worker/esindexmanager"
 	"github.com/uber/cadence/service/worker/failovermanager"
 	"github.com/uber/cadence/service/worker/indexer"
 	"github.com/uber/cadence/service/worker/parentclosepolicy"
@@ -47,6 +49,7 @@ import (
 	"github.com/uber/cadence/service/worker/scanner/executions"
 	"github.com/uber/cadence/service/worker/scanner/shardscanner"
 	"github.com/uber/cadence/service/worker/scanner/tasklist"
+	"github.com/uber/cadence/service/worker/scanner/tasks"
 	"github.com/uber/cadence/service/worker/scanner/timers"
 	"github.com/uber/cadence/service/worker/shadower"
 	"github.com/uber/cadence/service/worker/watchdog"
@@ -73,8 +76,10 @@ type (
 		ScannerCfg                          *scanner.Config
 		BatcherCfg                          *batcher.Config
 		ESAnalyzerCfg                       *esanalyzer.Config
+		ESIndexManagerCfg                   *esindexmanager.Config
 		WatchdogConfig                      *watchdog.Config
 		failoverManagerCfg                  *failovermanager.Config
+		DomainConfig                        domain.Config
 		ThrottledLogRPS                     dynamicconfig.IntPropertyFn
 		PersistenceGlobalMaxQPS             dynamicconfig.IntPropertyFn
 		PersistenceMaxQPS                   dynamicconfig.IntPropertyFn
@@ -83,9 +88,14 @@ type (
 		NumParentClosePolicySystemWorkflows dynamicconfig.IntPropertyFn
 		EnableFailoverManager               dynamicconfig.BoolPropertyFn
 		EnableWorkflowShadower              dynamicconfig.BoolPropertyFn
+		EnableDomainDeletion                dynamicconfig.BoolPropertyFn
 		DomainReplicationMaxRetryDuration   dynamicconfig.DurationPropertyFn
 		EnableESAnalyzer                    dynamicconfig.BoolPropertyFn
 		EnableWatchDog                      dynamicconfig.BoolPropertyFn
+		EnableReadVisibilityFromES          dynamicconfig.BoolPropertyFnWithDomainFilter
+		EnableReadFromClosedExecutionV2     dynamicconfig.BoolPropertyFn
+		ESIndexMaxResultWindow              dynamicconfig.IntPropertyFn
+		ValidSearchAttributes               dynamicconfig.MapPropertyFn
 	}
 )
 
@@ -103,7 +113,15 @@ func NewService(
 			PersistenceMaxQPS:       serviceConfig.PersistenceMaxQPS,
 			PersistenceGlobalMaxQPS: serviceConfig.PersistenceGlobalMaxQPS,
 			ThrottledLoggerMaxRPS:   serviceConfig.ThrottledLogRPS,
-			// worker service doesn't need visibility config as it never call visibilityManager API
+
+			// worker service only reads visibility, e.g. in visibility scanner
+			EnableReadVisibilityFromES:    serviceConfig.EnableReadVisibilityFromES,
+			AdvancedVisibilityWritingMode: nil, // worker service never write
+
+			EnableReadDBVisibilityFromClosedExecutionV2: serviceConfig.EnableReadFromClosedExecutionV2,
+
+			ESIndexMaxResultWindow: serviceConfig.ESIndexMaxResultWindow,
+			ValidSearchAttributes:  serviceConfig.ValidSearchAttributes,
 		},
 	)
 	if err != nil {
@@ -141,14 +159,19 @@ func NewConfig(params *resource.Params) *Config {
 				EnableCleaning:           dc.GetBoolProperty(dynamicconfig.EnableCleaningOrphanTaskInTasklistScavenger),
 				MaxTasksPerJobFn:         dc.GetIntProperty(dynamicconfig.ScannerMaxTasksProcessedPerTasklistJob),
 			},
-			Persistence:            &params.PersistenceConfig,
-			ClusterMetadata:        params.ClusterMetadata,
-			TaskListScannerEnabled: dc.GetBoolProperty(dynamicconfig.TaskListScannerEnabled),
-			HistoryScannerEnabled:  dc.GetBoolProperty(dynamicconfig.HistoryScannerEnabled),
+			Persistence:               &params.PersistenceConfig,
+			ClusterMetadata:           params.ClusterMetadata,
+			TaskListScannerEnabled:    dc.GetBoolProperty(dynamicconfig.TaskListScannerEnabled),
+			HistoryScannerEnabled:     dc.GetBoolProperty(dynamicconfig.HistoryScannerEnabled),
+			TaskListScannerReportOnly: dc.GetBoolProperty(dynamicconfig.TaskListScannerReportOnly),
+			HistoryScannerReportOnly:  dc.GetBoolProperty(dynamicconfig.HistoryScannerReportOnly),
 			ShardScanners: []*shardscanner.ScannerConfig{
 				executions.ConcreteExecutionScannerConfig(dc),
 				executions.CurrentExecutionScannerConfig(dc),
+				executions.VisibilityExecutionScannerConfig(dc),
 				timers.ScannerConfig(dc),
+				tasks.TransferScannerConfig(dc),
+				tasks.ReplicationScannerConfig(dc),
 			},
 			MaxWorkflowRetentionInDays: dc.GetIntProperty(dynamicconfig.MaxRetentionDays),
 		},
@@ -172,6 +195,24 @@ func NewConfig(params *resource.Params) *Config {
 			ESAnalyzerBufferWaitTime:                 dc.GetDurationPropertyFilteredByWorkflowType(dynamicconfig.ESAnalyzerBufferWaitTime),
 			ESAnalyzerMinNumWorkflowsForAvg:          dc.GetIntPropertyFilteredByWorkflowType(dynamicconfig.ESAnalyzerMinNumWorkflowsForAvg),
 			ESAnalyzerWorkflowDurationWarnThresholds: dc.GetStringProperty(dynamicconfig.ESAnalyzerWorkflowDurationWarnThresholds),
+			ESAnalyzerStuckWorkflowMitigation:        dc.GetStringPropertyFilteredByWorkflowType(dynamicconfig.ESAnalyzerStuckWorkflowMitigation),
+			ESAnalyzerStuckWorkflowSignalName:        dc.GetStringPropertyFilteredByWorkflowType(dynamicconfig.ESAnalyzerStuckWorkflowSignalName),
+			ESAnalyzerMitigationDomainAllow:          dc.GetBoolPropertyFilteredByDomain(dynamicconfig.ESAnalyzerMitigationDomainAllow),
+			ESAnalyzerMitigationDryRun:               dc.GetBoolPropertyFilteredByDomain(dynamicconfig.ESAnalyzerMitigationDryRun),
+		},
+		ESIndexManagerCfg: &esindexmanager.Config{
+			ESIndexManagerPause:     dc.GetBoolProperty(dynamicconfig.ESIndexManagerPause),
+			ESIndexRolloverMaxAge:   dc.GetDurationProperty(dynamicconfig.ESIndexRolloverMaxAge),
+			ESIndexRolloverMaxSize:  dc.GetStringProperty(dynamicconfig.ESIndexRolloverMaxSize),
+			ESIndexRolloverMaxDocs:  dc.GetIntProperty(dynamicconfig.ESIndexRolloverMaxDocs),
+			ESIndexRetentionEnabled: dc.GetBoolProperty(dynamicconfig.ESIndexRetentionEnabled),
+		},
+		DomainConfig: domain.Config{
+			MaxBadBinaryCount:      dc.GetIntPropertyFilteredByDomain(dynamicconfig.FrontendMaxBadBinaries),
+			MinRetentionDays:       dc.GetIntProperty(dynamicconfig.MinRetentionDays),
+			MaxRetentionDays:       dc.GetIntProperty(dynamicconfig.MaxRetentionDays),
+			FailoverCoolDown:       dc.GetDurationPropertyFilteredByDomain(dynamicconfig.FrontendFailoverCoolDown),
+			RequiredDomainDataKeys: dc.GetMapProperty(dynamicconfig.RequiredDomainDataKeys),
 		},
 		WatchdogConfig: &watchdog.Config{
 			CorruptWorkflowWatchdogPause: dc.GetBoolProperty(dynamicconfig.CorruptWorkflowWatchdogPause),
@@ -183,15 +224,25 @@ func NewConfig(params *resource.Params) *Config {
 		EnableWatchDog:                      dc.GetBoolProperty(dynamicconfig.EnableWatchDog),
 		EnableFailoverManager:               dc.GetBoolProperty(dynamicconfig.EnableFailoverManager),
 		EnableWorkflowShadower:              dc.GetBoolProperty(dynamicconfig.EnableWorkflowShadower),
+		EnableDomainDeletion:                dc.GetBoolProperty(dynamicconfig.EnableDomainDeletion),
 		ThrottledLogRPS:                     dc.GetIntProperty(dynamicconfig.WorkerThrottledLogRPS),
 		PersistenceGlobalMaxQPS:             dc.GetIntProperty(dynamicconfig.WorkerPersistenceGlobalMaxQPS),
 		PersistenceMaxQPS:                   dc.GetIntProperty(dynamicconfig.WorkerPersistenceMaxQPS),
 		DomainReplicationMaxRetryDuration:   dc.GetDurationProperty(dynamicconfig.WorkerReplicationTaskMaxRetryDuration),
+		EnableReadVisibilityFromES:          dc.GetBoolPropertyFilteredByDomain(dynamicconfig.EnableReadVisibilityFromES),
+		EnableReadFromClosedExecutionV2:     dc.GetBoolProperty(dynamicconfig.EnableReadFromClosedExecutionV2),
+		ESIndexMaxResultWindow:              dc.GetIntProperty(dynamicconfig.FrontendESIndexMaxResultWindow),
+		ValidSearchAttributes:               dc.GetMapProperty(dynamicconfig.ValidSearchAttributes),
 	}
 	advancedVisWritingMode := dc.GetStringProperty(
 		dynamicconfig.AdvancedVisibilityWritingMode,
 	)
-	if common.IsAdvancedVisibilityWritingEnabled(advancedVisWritingMode(), params.PersistenceConfig.IsAdvancedVisibilityConfigExist()) {
+	advancedVisWritingPipeline := dc.GetStringProperty(
+		dynamicconfig.AdvancedVisibilityWritingPipeline,
+	)
+	// indexer is not needed when visibility records are written to ElasticSearch directly
+	if common.IsAdvancedVisibilityWritingEnabled(advancedVisWritingMode(), params.PersistenceConfig.IsAdvancedVisibilityConfigExist()) &&
+		advancedVisWritingPipeline() != common.AdvancedVisibilityWritingPipelineDirect {
 		config.IndexerCfg = &indexer.Config{
 			IndexerConcurrency:       dc.GetIntProperty(dynamicconfig.WorkerIndexerConcurrency),
 			ESProcessorNumOfWorkers:  dc.GetIntProperty(dynamicconfig.WorkerESProcessorNumOfWorkers),
@@ -199,6 +250,7 @@ func NewConfig(params *resource.Params) *Config {
 			ESProcessorBulkSize:      dc.GetIntProperty(dynamicconfig.WorkerESProcessorBulkSize),
 			ESProcessorFlushInterval: dc.GetDurationProperty(dynamicconfig.WorkerESProcessorFlushInterval),
 			ValidSearchAttributes:    dc.GetMapProperty(dynamicconfig.ValidSearchAttributes),
+			SearchAttributeAliases:   dc.GetMapProperty(dynamicconfig.SearchAttributeAliases),
 		}
 	}
 	return config
@@ -237,6 +289,9 @@ func (s *Service) Start() {
 	if s.config.EnableESAnalyzer() {
 		s.startESAnalyzer()
 	}
+	if s.params.ESConfig != nil && s.params.ESConfig.ManagedIndices {
+		s.startESIndexManager()
+	}
 	if s.config.EnableWatchDog() {
 		s.startWatchDog()
 	}
@@ -247,6 +302,9 @@ func (s *Service) Start() {
 		s.ensureDomainExists(common.ShadowerLocalDomainName)
 		s.startWorkflowShadower()
 	}
+	if s.config.EnableDomainDeletion() {
+		s.startDomainDeleter()
+	}
 
 	logger.Info("worker started", tag.ComponentWorker)
 	<-s.stopC
@@ -302,6 +360,23 @@ func (s *Service) startESAnalyzer() {
 	}
 }
 
+func (s *Service) startESIndexManager() {
+	indexManager := esindexmanager.New(
+		s.params.PublicClient,
+		s.params.ESClient,
+		s.params.ESConfig,
+		s.GetLogger(),
+		s.params.MetricScope,
+		s.Resource,
+		s.GetDomainCache(),
+		s.config.ESIndexManagerCfg,
+	)
+
+	if err := indexManager.Start(); err != nil {
+		s.GetLogger().Fatal("error starting es index manager", tag.Error(err))
+	}
+}
+
 func (s *Service) startWatchDog() {
 	watchdog := watchdog.New(
 		s.params.PublicClient,
@@ -401,6 +476,10 @@ func (s *Service) startArchiver() {
 		DomainCache:      s.GetDomainCache(),
 		Config:           s.config.ArchiverConfig,
 		ArchiverProvider: s.GetArchiverProvider(),
+
+		VisibilityManager:        s.GetVisibilityManager(),
+		ExecutionManagerProvider: s,
+		NumHistoryShards:         s.params.PersistenceConfig.NumHistoryShards,
 	}
 	clientWorker := archiver.NewClientWorker(bc)
 	if err := clientWorker.Start(); err != nil {
@@ -436,6 +515,35 @@ func (s *Service) startWorkflowShadower() {
 	}
 }
 
+func (s *Service) startDomainDeleter() {
+	params := &domaindeletion.BootstrapParams{
+		ServiceClient: s.params.PublicClient,
+		Logger:        s.GetLogger(),
+		TallyScope:    s.params.MetricScope,
+		DomainHandler: domain.NewHandler(
+			s.config.DomainConfig,
+			s.GetLogger(),
+			s.GetDomainManager(),
+			s.GetClusterMetadata(),
+			domain.NewDomainReplicator(s.GetDomainReplicationQueue(), s.GetLogger()),
+			s.GetArchivalMetadata(),
+			s.GetArchiverProvider(),
+			s.GetDomainAuditLog(),
+			s.GetTimeSource(),
+		),
+		DomainManager:            s.GetDomainManager(),
+		VisibilityManager:        s.GetVisibilityManager(),
+		HistoryManager:           s.GetHistoryManager(),
+		TaskManager:              s.GetTaskManager(),
+		ExecutionManagerProvider: s,
+		NumHistoryShards:         s.params.PersistenceConfig.NumHistoryShards,
+	}
+	if err := domaindeletion.New(params).Start(); err != nil {
+		s.Stop()
+		s.GetLogger().Fatal("error starting domain deleter", tag.Error(err))
+	}
+}
+
 func (s *Service) ensureDomainExists(domain string) {
 	_, err := s.GetDomainManager().GetDomain(context.Background(), &persistence.GetDomainRequest{Name: domain})
 	switch err.(type) {
@@ -0,0 +1,146 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package workercommon
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/cadence/workflow"
+)
+
+const (
+	// ProgressQueryType is the query type which returns the Progress of a system workflow
+	ProgressQueryType = "cadence-sys-progress"
+	// MaxProgressLastErrors is the number of most recent errors kept in a Progress
+	MaxProgressLastErrors = 10
+	// MaxProgressErrorLength is the max length of an error kept in a Progress, longer errors are truncated
+	MaxProgressErrorLength = 1024
+
+	// ProgressPhaseInitialized is the phase of a workflow which has not started processing yet
+	ProgressPhaseInitialized = "initialized"
+	// ProgressPhaseRunning is the phase of a workflow which is processing
+	ProgressPhaseRunning = "running"
+	// ProgressPhaseCompleted is the phase of a workflow which completed processing
+	ProgressPhaseCompleted = "completed"
+)
+
+type (
+	// Progress is the progress of a long-running system workflow, returned by its ProgressQueryType query.
+	// Workflows keep it in their state, so it is rebuilt on replay and survives worker restarts.
+	Progress struct {
+		// Phase is the current phase of the workflow, workflows may define phases other than the common ones
+		Phase string
+		// Total is the number of entities to process, zero if unknown
+		Total int64
+		// Processed is the number of entities processed, including the failed ones
+		Processed int64
+		// Failed is the number of entities which failed to be processed
+		Failed    int64
+		StartedAt time.Time
+		UpdatedAt time.Time
+		// ETA is the estimated completion time, nil if it cannot be estimated
+		ETA *time.Time
+		// LastErrors are the most recent errors, oldest first
+		LastErrors []string
+	}
+)
+
+// NewProgress returns a Progress in initialized phase
+func NewProgress(now time.Time) *Progress {
+	return &Progress{
+		Phase:     ProgressPhaseInitialized,
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// SetProgressQueryHandler registers the ProgressQueryType query of a workflow
+func SetProgressQueryHandler(ctx workflow.Context, getProgress func() *Progress) error {
+	return workflow.SetQueryHandler(ctx, ProgressQueryType, func() (*Progress, error) {
+		return getProgress(), nil
+	})
+}
+
+// SetPhase sets the current phase
+func (p *Progress) SetPhase(phase string, now time.Time) {
+	p.Phase = phase
+	p.update(now)
+}
+
+// SetTotal sets the number of entities to process
+func (p *Progress) SetTotal(total int64, now time.Time) {
+	p.Total = total
+	p.update(now)
+}
+
+// Add records processed entities, failed is the number of them which failed
+func (p *Progress) Add(processed, failed int64, now time.Time) {
+	p.Processed += processed
+	p.Failed += failed
+	p.update(now)
+}
+
+// AddError records an error, only the last MaxProgressLastErrors are kept
+func (p *Progress) AddError(err string, now time.Time) {
+	p.LastErrors = append(p.LastErrors, TruncateProgressError(err))
+	if len(p.LastErrors) > MaxProgressLastErrors {
+		p.LastErrors = p.LastErrors[len(p.LastErrors)-MaxProgressLastErrors:]
+	}
+	p.update(now)
+}
+
+// TruncateProgressError truncates the error to MaxProgressErrorLength bytes, without splitting a character
+func TruncateProgressError(err string) string {
+	if len(err) <= MaxProgressErrorLength {
+		return err
+	}
+	end := MaxProgressErrorLength
+	for end > 0 && !utf8.RuneStart(err[end]) {
+		end--
+	}
+	return err[:end] + "..."
+}
+
+// Percent returns the percentage of processed entities, zero if the total is unknown
+func (p *Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+	if p.Processed >= p.Total {
+		return 100
+	}
+	return float64(p.Processed) * 100 / float64(p.Total)
+}
+
+// update refreshes the update time and the ETA, which assumes the remaining entities
+// are processed at the average rate so far
+func (p *Progress) update(now time.Time) {
+	p.UpdatedAt = now
+	p.ETA = nil
+	if p.Phase == ProgressPhaseCompleted || p.Total <= 0 || p.Processed <= 0 || p.Processed >= p.Total {
+		return
+	}
+	elapsed := now.Sub(p.StartedAt)
+	remaining := time.Duration(float64(elapsed) * float64(p.Total-p.Processed) / float64(p.Processed))
+	eta := now.Add(remaining)
+	p.ETA = &eta
+}
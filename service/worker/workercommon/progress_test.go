@@ -0,0 +1,105 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package workercommon
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/cadence/testsuite"
+	"go.uber.org/cadence/workflow"
+)
+
+func TestProgress(t *testing.T) {
+	startedAt := time.Unix(1000, 0)
+	p := NewProgress(startedAt)
+	assert.Equal(t, ProgressPhaseInitialized, p.Phase)
+	assert.Zero(t, p.Percent())
+	assert.Nil(t, p.ETA)
+
+	p.SetPhase(ProgressPhaseRunning, startedAt)
+	p.Add(10, 0, startedAt.Add(time.Minute))
+	assert.Nil(t, p.ETA, "ETA cannot be estimated without total")
+
+	p.SetTotal(40, startedAt.Add(time.Minute))
+	assert.Equal(t, float64(25), p.Percent())
+	require.NotNil(t, p.ETA)
+	assert.Equal(t, startedAt.Add(4*time.Minute), *p.ETA)
+
+	p.Add(30, 5, startedAt.Add(2*time.Minute))
+	assert.Equal(t, int64(40), p.Processed)
+	assert.Equal(t, int64(5), p.Failed)
+	assert.Equal(t, float64(100), p.Percent())
+	assert.Nil(t, p.ETA)
+	assert.Equal(t, startedAt.Add(2*time.Minute), p.UpdatedAt)
+}
+
+func TestProgress_AddError(t *testing.T) {
+	p := NewProgress(time.Now())
+	for i := 0; i < MaxProgressLastErrors+2; i++ {
+		p.AddError(fmt.Sprintf("error %v", i), time.Now())
+	}
+	require.Len(t, p.LastErrors, MaxProgressLastErrors)
+	assert.Equal(t, "error 2", p.LastErrors[0])
+	assert.Equal(t, fmt.Sprintf("error %v", MaxProgressLastErrors+1), p.LastErrors[MaxProgressLastErrors-1])
+}
+
+func TestTruncateProgressError(t *testing.T) {
+	assert.Equal(t, "some error", TruncateProgressError("some error"))
+
+	truncated := TruncateProgressError(strings.Repeat("a", MaxProgressErrorLength+10))
+	assert.Equal(t, strings.Repeat("a", MaxProgressErrorLength)+"...", truncated)
+
+	// a multi-byte character crossing the limit is dropped entirely
+	truncated = TruncateProgressError(strings.Repeat("a", MaxProgressErrorLength-1) + "é")
+	assert.Equal(t, strings.Repeat("a", MaxProgressErrorLength-1)+"...", truncated)
+}
+
+func TestSetProgressQueryHandler(t *testing.T) {
+	testWorkflow := func(ctx workflow.Context) error {
+		progress := NewProgress(workflow.Now(ctx))
+		if err := SetProgressQueryHandler(ctx, func() *Progress { return progress }); err != nil {
+			return err
+		}
+		progress.SetTotal(10, workflow.Now(ctx))
+		progress.Add(3, 1, workflow.Now(ctx))
+		progress.AddError("some error", workflow.Now(ctx))
+		return nil
+	}
+
+	env := (&testsuite.WorkflowTestSuite{}).NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(testWorkflow)
+	env.ExecuteWorkflow(testWorkflow)
+	require.NoError(t, env.GetWorkflowError())
+
+	value, err := env.QueryWorkflow(ProgressQueryType)
+	require.NoError(t, err)
+	var progress Progress
+	require.NoError(t, value.Get(&progress))
+	assert.Equal(t, int64(10), progress.Total)
+	assert.Equal(t, int64(3), progress.Processed)
+	assert.Equal(t, int64(1), progress.Failed)
+	assert.Equal(t, []string{"some error"}, progress.LastErrors)
+}
@@ -27,6 +27,8 @@ import (
 
 	"github.com/urfave/cli"
 
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/config"
 	"github.com/uber/cadence/common/reconciliation/invariant"
 	"github.com/uber/cadence/service/worker/scanner/executions"
 )
@@ -60,6 +62,14 @@ func newAdminWorkflowCommands() []cli.Command {
 				AdminShowWorkflow(c)
 			},
 		},
+		{
+			Name:  "export",
+			Usage: "Stream raw history batches of a workflow, or of workflows matching a query, as JSON lines or length-delimited proto",
+			Flags: getFlagsForExport(),
+			Action: func(c *cli.Context) {
+				AdminExportWorkflow(c)
+			},
+		},
 		{
 			Name:    "describe",
 			Aliases: []string{"desc"},
@@ -457,6 +467,63 @@ func newAdminDomainCommands() []cli.Command {
 				newDomainCLI(c, false).ListDomains(c)
 			},
 		},
+		{
+			Name:  "delete",
+			Usage: "Delete a deprecated local domain which has no open workflows, together with its closed workflows and task lists",
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  FlagRPS,
+					Value: 10,
+					Usage: "Number of closed workflows or task lists deleted per second",
+				},
+				cli.IntFlag{
+					Name:  FlagPageSizeWithAlias,
+					Value: 100,
+					Usage: "Number of closed workflows or task lists read at once",
+				},
+				cli.BoolFlag{
+					Name:  FlagYes,
+					Usage: "Optional flag to disable confirmation prompt",
+				},
+			},
+			Action: func(c *cli.Context) {
+				AdminDeleteDomain(c)
+			},
+		},
+		{
+			Name:  "history",
+			Usage: "Show who changed the domain and what they changed, oldest change first",
+			Flags: append(getDBFlags(),
+				cli.IntFlag{
+					Name:  FlagPageSizeWithAlias,
+					Value: 100,
+					Usage: "Number of changes read at once",
+				},
+				getFormatFlag(),
+			),
+			Action: func(c *cli.Context) {
+				AdminDomainHistory(c)
+			},
+		},
+		{
+			Name:  "archive-backfill",
+			Usage: "Archive the history and visibility records of already closed workflows of a domain which has archival enabled",
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  FlagRPS,
+					Value: 10,
+					Usage: "Number of closed workflows archived per second",
+				},
+				cli.IntFlag{
+					Name:  FlagPageSizeWithAlias,
+					Value: 100,
+					Usage: "Number of closed workflows read from visibility at once",
+				},
+			},
+			Action: func(c *cli.Context) {
+				AdminDomainArchiveBackfill(c)
+			},
+		},
 	}
 }
 
@@ -536,9 +603,81 @@ func newAdminKafkaCommands() []cli.Command {
 				AdminRereplicate(c)
 			},
 		},
+		{
+			Name:    "read-visibility-dlq",
+			Aliases: []string{"rvdlq"},
+			Usage:   "Read messages of the visibility DLQ topic or queue, with the reason of the indexing failure",
+			Flags: append(getVisibilityDLQFlags(),
+				getFormatFlag(),
+			),
+			Action: func(c *cli.Context) {
+				AdminReadVisibilityDLQ(c)
+			},
+		},
+		{
+			Name:    "redrive-visibility-dlq",
+			Aliases: []string{"rdvdlq"},
+			Usage:   "Publish messages of the visibility DLQ topic or queue back to the visibility topic or queue to be indexed again",
+			Flags: append(getVisibilityDLQFlags(),
+				cli.StringFlag{
+					Name:  FlagVisibilityTopic,
+					Usage: "Visibility topic consumed by the indexer",
+				},
+				cli.BoolFlag{
+					Name:  FlagDryRun,
+					Usage: "Only count the messages that would be re-driven. Messages of the persistence queue are removed from the DLQ once re-driven",
+				},
+			),
+			Action: func(c *cli.Context) {
+				AdminRedriveVisibilityDLQ(c)
+			},
+		},
 	}
 }
 
+func getVisibilityDLQFlags() []cli.Flag {
+	return append([]cli.Flag{
+		cli.StringFlag{
+			Name:  FlagMessagingType,
+			Value: config.MessagingTypeKafka,
+			Usage: "Messaging type of the server, kafka or persistence. With persistence the visibility queue is read from the server config and the database flags",
+		},
+		cli.StringFlag{
+			Name:  FlagBrokers,
+			Usage: "Comma separated list of kafka brokers",
+		},
+		cli.StringFlag{
+			Name:  FlagTopic,
+			Usage: "Visibility DLQ topic",
+		},
+		cli.Int64Flag{
+			Name:  FlagStartOffset,
+			Usage: "Optional offset to start reading every partition from, default to the oldest offset",
+			Value: -1,
+		},
+		cli.IntFlag{
+			Name:  FlagMaxMessageCountWithAlias,
+			Usage: "Optional max number of messages, default to all messages",
+		},
+		cli.StringFlag{
+			Name:  FlagDomainID,
+			Usage: "Optional DomainID to filter messages",
+		},
+		cli.StringFlag{
+			Name:  FlagWorkflowIDWithAlias,
+			Usage: "Optional WorkflowID to filter messages",
+		},
+		cli.StringFlag{
+			Name:  FlagRunIDWithAlias,
+			Usage: "Optional RunID to filter messages",
+		},
+		cli.StringFlag{
+			Name:  FlagReasonWithAlias,
+			Usage: "Optional text that the failure reason of messages must contain",
+		},
+	}, getDBFlags()...)
+}
+
 func newAdminElasticSearchCommands() []cli.Command {
 	return []cli.Command{
 		{
@@ -646,6 +785,148 @@ func newAdminElasticSearchCommands() []cli.Command {
 				GenerateReport(c)
 			},
 		},
+		{
+			Name:  "initIndices",
+			Usage: "Create the first managed visibility index with its read and write aliases",
+			Flags: append(getESIndexManagerFlags(),
+				cli.StringSliceFlag{
+					Name:  FlagExistingIndex,
+					Usage: "Optional existing visibility index to keep under the read alias, can be passed multiple times",
+				},
+			),
+			Action: func(c *cli.Context) {
+				AdminInitManagedIndices(c)
+			},
+		},
+		{
+			Name:    "listIndices",
+			Aliases: []string{"lind"},
+			Usage:   "List managed visibility indices",
+			Flags:   append(getESIndexManagerFlags(), getFormatFlag()),
+			Action: func(c *cli.Context) {
+				AdminListManagedIndices(c)
+			},
+		},
+		{
+			Name:  "rollover",
+			Usage: "Roll over the managed visibility write index if any of the conditions is met",
+			Flags: append(getESIndexManagerFlags(),
+				cli.DurationFlag{
+					Name:  FlagMaxAge,
+					Usage: "Optional max age of the write index, e.g. 168h",
+				},
+				cli.StringFlag{
+					Name:  FlagMaxSize,
+					Usage: "Optional max primary shards size of the write index, e.g. 50gb",
+				},
+				cli.Int64Flag{
+					Name:  FlagMaxDocs,
+					Usage: "Optional max number of documents of the write index",
+				},
+				cli.BoolFlag{
+					Name:  FlagDryRun,
+					Usage: "Only check the conditions",
+				},
+			),
+			Action: func(c *cli.Context) {
+				AdminRolloverManagedIndex(c)
+			},
+		},
+		{
+			Name:  "deleteExpired",
+			Usage: "Delete the managed visibility indices whose workflows all closed before retention",
+			Flags: append(getESIndexManagerFlags(),
+				cli.IntFlag{
+					Name:  FlagRetentionDaysWithAlias,
+					Usage: "Retention in days, should be the longest retention of all domains",
+				},
+				cli.BoolFlag{
+					Name:  FlagDryRun,
+					Usage: "Only print the expired indices",
+				},
+			),
+			Action: func(c *cli.Context) {
+				AdminDeleteExpiredManagedIndices(c)
+			},
+		},
+		{
+			Name:        "reindex",
+			Usage:       "Online reindex of a managed visibility index",
+			Subcommands: newAdminElasticSearchReindexCommands(),
+		},
+	}
+}
+
+func newAdminElasticSearchReindexCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:  "start",
+			Usage: "Create the index replacing given index, write to both and copy the documents of given index",
+			Flags: append(getESIndexManagerFlags(),
+				cli.StringFlag{
+					Name:  FlagIndex,
+					Usage: "Managed index to reindex",
+				},
+				cli.StringSliceFlag{
+					Name:  FlagRenameSearchAttribute,
+					Usage: "Move the values of a custom search attribute to another one in copied documents, in the form of Old:New. Can be passed multiple times",
+				},
+				cli.StringSliceFlag{
+					Name:  FlagRemoveSearchAttribute,
+					Usage: "Drop the values of a custom search attribute in copied documents. Can be passed multiple times",
+				},
+			),
+			Action: func(c *cli.Context) {
+				AdminStartReindex(c)
+			},
+		},
+		{
+			Name:  "status",
+			Usage: "Show the status of the document copy",
+			Flags: append(getESIndexManagerFlags(),
+				cli.StringFlag{
+					Name:  FlagTaskID,
+					Usage: "Task ID returned by reindex start",
+				},
+			),
+			Action: func(c *cli.Context) {
+				AdminDescribeReindex(c)
+			},
+		},
+		{
+			Name:  "swap",
+			Usage: "Replace given index with the reindexed index in the aliases",
+			Flags: append(getESIndexManagerFlags(),
+				cli.StringFlag{
+					Name:  FlagIndex,
+					Usage: "Managed index to replace",
+				},
+				cli.BoolFlag{
+					Name:  FlagDeleteSource,
+					Usage: "Delete the replaced index once no longer written to",
+				},
+			),
+			Action: func(c *cli.Context) {
+				AdminSwapReindex(c)
+			},
+		},
+	}
+}
+
+func getESIndexManagerFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  FlagURL,
+			Usage: "URL of ElasticSearch cluster",
+		},
+		cli.StringFlag{
+			Name:  FlagESVersion,
+			Usage: "Version of ElasticSearch cluster: v6 (default) or v7",
+		},
+		cli.StringFlag{
+			Name:  FlagAlias,
+			Usage: "Visibility index name in server config, which is the read alias of the managed indices",
+		},
 	}
 }
 
@@ -712,6 +993,82 @@ func newAdminClusterCommands() []cli.Command {
 				AdminAddSearchAttribute(c)
 			},
 		},
+		{
+			Name:    "list-search-attr",
+			Aliases: []string{"lsa"},
+			Usage:   "list search attributes and aliases with their deprecation and domain scope",
+			Action: func(c *cli.Context) {
+				AdminListSearchAttributes(c)
+			},
+		},
+		{
+			Name:    "deprecate-search-attr",
+			Aliases: []string{"dsa"},
+			Usage:   "deprecate search attribute, it is still accepted but warnings are logged when it is written",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagSearchAttributesKey,
+					Usage: "Search Attribute key to be deprecated",
+				},
+				cli.StringFlag{
+					Name:  FlagReason,
+					Usage: "Note for the users of the search attribute, e.g. the attribute to use instead",
+				},
+			},
+			Action: func(c *cli.Context) {
+				AdminDeprecateSearchAttribute(c)
+			},
+		},
+		{
+			Name:    "remove-search-attr",
+			Aliases: []string{"rsa"},
+			Usage:   "remove search attribute or alias from whitelist",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagSearchAttributesKey,
+					Usage: "Search Attribute key or alias to be removed",
+				},
+			},
+			Action: func(c *cli.Context) {
+				AdminRemoveSearchAttribute(c)
+			},
+		},
+		{
+			Name:    "alias-search-attr",
+			Aliases: []string{"alsa"},
+			Usage:   "add alias of search attribute, an existing search attribute used as alias is renamed to the target",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagSearchAttributesKey,
+					Usage: "Alias to be added",
+				},
+				cli.StringFlag{
+					Name:  FlagSearchAttributesTarget,
+					Usage: "Search Attribute key the alias stands for",
+				},
+			},
+			Action: func(c *cli.Context) {
+				AdminAliasSearchAttribute(c)
+			},
+		},
+		{
+			Name:    "scope-search-attr",
+			Aliases: []string{"ssa"},
+			Usage:   "restrict search attribute to domains",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagSearchAttributesKey,
+					Usage: "Search Attribute key to be restricted",
+				},
+				cli.StringFlag{
+					Name:  FlagSearchAttributesDomains,
+					Usage: "Comma separated domains allowed to use the search attribute, empty to allow all domains",
+				},
+			},
+			Action: func(c *cli.Context) {
+				AdminScopeSearchAttribute(c)
+			},
+		},
 		{
 			Name:    "describe",
 			Aliases: []string{"d"},
@@ -855,14 +1212,16 @@ func newDBCommands() []cli.Command {
 	var collections cli.StringSlice = invariant.CollectionStrings()
 
 	scanFlag := cli.StringFlag{
-		Name:     FlagScanType,
-		Usage:    "Scan type to use: " + strings.Join(executions.ScanTypeStrings(), ", "),
+		Name: FlagScanType,
+		Usage: "Scan type to use: " + strings.Join(executions.ScanTypeStrings(), ", ") +
+			". " + executions.VisibilityExecutionType.String() + " starts the visibility scanner or fixer workflow for the domain given by --domain",
 		Required: true,
 	}
 
 	collectionsFlag := cli.StringSliceFlag{
-		Name:  FlagInvariantCollection,
-		Usage: "Scan collection type to use: " + strings.Join(collections, ", "),
+		Name: FlagInvariantCollection,
+		Usage: "Scan collection type to use: " + strings.Join(collections, ", ") +
+			". Passing " + invariant.CollectionWorkflowState.String() + " starts the executions scanner or fixer workflow for all shards",
 		Value: &collections,
 	}
 
@@ -934,6 +1293,46 @@ func newDBCommands() []cli.Command {
 				AdminDBClean(c)
 			},
 		},
+		{
+			Name:  "gc-report",
+			Usage: "show the garbage reported or deleted by the last history, task list, transfer or replication task scanner run",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:     FlagGCType,
+					Usage:    "Garbage collection type: history, tasklist, transfer or replication",
+					Required: true,
+				},
+				cli.StringFlag{
+					Name:  FlagWorkflowIDWithAlias,
+					Usage: "Workflow to query, use the gc executor or fixer workflow id to see the result of gc-execute (Default: scanner workflow)",
+				},
+				cli.StringFlag{
+					Name:  FlagRunIDWithAlias,
+					Usage: "RunID",
+				},
+			},
+			Action: func(c *cli.Context) {
+				AdminDBGCReport(c)
+			},
+		},
+		{
+			Name:  "gc-execute",
+			Usage: "delete the garbage reported by the last history or task list scanner run in report only mode, or fix the tasks reported by the last transfer or replication task scanner run",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:     FlagGCType,
+					Usage:    "Garbage collection type: history, tasklist, transfer or replication",
+					Required: true,
+				},
+				cli.StringFlag{
+					Name:  FlagInputFileWithAlias,
+					Usage: "Optional input file with the JSON list of report keys to execute, history and tasklist only (Default: keys of the last scanner run)",
+				},
+			},
+			Action: func(c *cli.Context) {
+				AdminDBGCExecute(c)
+			},
+		},
 		{
 			Name:  "decode_thrift",
 			Usage: "decode thrift object, print into JSON if the data is matching with any supported struct",
@@ -1238,6 +1637,10 @@ func newAdminConfigStoreCommands() []cli.Command {
 					Name:  FlagDynamicConfigValue,
 					Usage: `Optional. Can be specified multiple times for multiple values. ex: --dynamic-config-value '{"Value":true,"Filters":[]}'`,
 				},
+				cli.BoolFlag{
+					Name:  FlagDryRun,
+					Usage: "Validate the values and show which filters, services and hosts the update would affect, without updating",
+				},
 			},
 			Action: func(c *cli.Context) {
 				AdminUpdateDynamicConfig(c)
@@ -1270,5 +1673,76 @@ func newAdminConfigStoreCommands() []cli.Command {
 				AdminListDynamicConfig(c)
 			},
 		},
+		{
+			Name:    "validate",
+			Aliases: []string{"v"},
+			Usage:   "Validate a dynamic config file offline, checking value types, ranges, filters and unknown keys",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagInputFileWithAlias,
+					Usage: "Dynamic config file in YAML format, as used by the file based dynamic config client",
+				},
+				cli.BoolFlag{
+					Name:  FlagAllowUnknownKeys,
+					Usage: "Only warn about unknown keys instead of failing, as the server ignores them when loading the file",
+				},
+				getFormatFlag(),
+			},
+			Action: func(c *cli.Context) {
+				AdminValidateDynamicConfig(c)
+			},
+		},
+	}
+}
+
+func newAdminSystemWorkflowCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:    "list",
+			Aliases: []string{"l"},
+			Usage:   "List running system workflows with their progress",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  FlagDomain,
+					Usage: "System domains to list workflows of, default to cadence-system and cadence-batcher",
+				},
+				cli.StringFlag{
+					Name:  FlagWorkflowTypeWithAlias,
+					Usage: "Optional workflow type to filter workflows",
+				},
+				cli.IntFlag{
+					Name:  FlagPageSizeWithAlias,
+					Value: 100,
+					Usage: "Page size of listing workflows",
+				},
+				getFormatFlag(),
+			},
+			Action: func(c *cli.Context) {
+				AdminListSystemWorkflows(c)
+			},
+		},
+		{
+			Name:    "describe",
+			Aliases: []string{"d"},
+			Usage:   "Describe the progress of a system workflow",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagDomain,
+					Value: common.SystemLocalDomainName,
+					Usage: "System domain of the workflow",
+				},
+				cli.StringFlag{
+					Name:  FlagWorkflowIDWithAlias,
+					Usage: "WorkflowID",
+				},
+				cli.StringFlag{
+					Name:  FlagRunIDWithAlias,
+					Usage: "RunID",
+				},
+			},
+			Action: func(c *cli.Context) {
+				AdminDescribeSystemWorkflow(c)
+			},
+		},
 	}
 }
@@ -0,0 +1,79 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pborman/uuid"
+	"github.com/urfave/cli"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/service/worker/archiver"
+)
+
+const (
+	defaultArchivalBackfillTimeoutInSeconds = 7 * 24 * 60 * 60
+)
+
+// AdminDomainArchiveBackfill starts the workflow which archives the already closed workflows of a domain
+func AdminDomainArchiveBackfill(c *cli.Context) {
+	domainName := getRequiredGlobalOption(c, FlagDomain)
+	params := archiver.BackfillParams{
+		Request: archiver.BackfillRequest{
+			DomainName: domainName,
+			RPS:        c.Int(FlagRPS),
+			PageSize:   c.Int(FlagPageSize),
+		},
+	}
+	input, err := json.Marshal(params)
+	if err != nil {
+		ErrorAndExit("Failed to serialize archival backfill params", err)
+	}
+
+	workflowID := archiver.BackfillWorkflowID(domainName)
+	client := getCadenceClient(c)
+	tcCtx, cancel := newContext(c)
+	defer cancel()
+	resp, err := client.StartWorkflowExecution(tcCtx, &types.StartWorkflowExecutionRequest{
+		Domain:                              common.SystemLocalDomainName,
+		RequestID:                           uuid.New(),
+		WorkflowID:                          workflowID,
+		WorkflowIDReusePolicy:               types.WorkflowIDReusePolicyAllowDuplicate.Ptr(),
+		TaskList:                            &types.TaskList{Name: archiver.BackfillTaskListName},
+		ExecutionStartToCloseTimeoutSeconds: common.Int32Ptr(defaultArchivalBackfillTimeoutInSeconds),
+		TaskStartToCloseTimeoutSeconds:      common.Int32Ptr(defaultDecisionTimeoutInSeconds),
+		WorkflowType:                        &types.WorkflowType{Name: archiver.BackfillWorkflowTypeName},
+		Input:                               input,
+	})
+	if err != nil {
+		if _, ok := err.(*types.WorkflowExecutionAlreadyStartedError); ok {
+			ErrorAndExit(fmt.Sprintf("Archival backfill of domain %v is already running", domainName), err)
+		}
+		ErrorAndExit("Failed to start archival backfill workflow", err)
+	}
+	fmt.Println("Archival backfill workflow started")
+	fmt.Println("wid: " + workflowID)
+	fmt.Println("rid: " + resp.GetRunID())
+	fmt.Printf("Progress can be queried with query type %v in domain %v\n", archiver.BackfillProgressQueryType, common.SystemLocalDomainName)
+}
@@ -21,11 +21,19 @@
 package cli
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/urfave/cli"
 
+	"github.com/uber/cadence/client/admin"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/service"
 	"github.com/uber/cadence/common/types"
 )
 
@@ -45,6 +53,22 @@ type cliFilter struct {
 	Value interface{}
 }
 
+// DynamicConfigValidationRow is an invalid entry of a dynamic config
+type DynamicConfigValidationRow struct {
+	Key         string `header:"Key" json:"key"`
+	Constraints string `header:"Constraints" json:"constraints"`
+	Severity    string `header:"Severity" json:"severity"`
+	Error       string `header:"Error" json:"error"`
+}
+
+// DynamicConfigChangeRow is a value of a dynamic config which would be changed by an update
+type DynamicConfigChangeRow struct {
+	Filters      string `header:"Filters" json:"filters"`
+	CurrentValue string `header:"Current Value" json:"currentValue"`
+	NewValue     string `header:"New Value" json:"newValue"`
+	Change       string `header:"Change" json:"change"`
+}
+
 // AdminGetDynamicConfig gets value of specified dynamic config parameter matching specified filter
 func AdminGetDynamicConfig(c *cli.Context) {
 	adminClient := cFactory.ServerAdminClient(c)
@@ -119,6 +143,7 @@ func AdminUpdateDynamicConfig(c *cli.Context) {
 	defer cancel()
 
 	var parsedValues []*types.DynamicConfigValue
+	var inputValues []*cliValue
 
 	if dcValues != nil {
 		parsedValues = make([]*types.DynamicConfigValue, 0, len(dcValues))
@@ -134,11 +159,17 @@ func AdminUpdateDynamicConfig(c *cli.Context) {
 				ErrorAndExit("Unable to convert from inputValue to DynamicConfigValue", err)
 			}
 			parsedValues = append(parsedValues, parsedValue)
+			inputValues = append(inputValues, parsedInputValue)
 		}
 	} else {
 		parsedValues = nil
 	}
 
+	if c.Bool(FlagDryRun) {
+		dryRunUpdateDynamicConfig(c, ctx, adminClient, dcName, inputValues)
+		return
+	}
+
 	req := &types.UpdateDynamicConfigRequest{
 		ConfigName:   dcName,
 		ConfigValues: parsedValues,
@@ -326,3 +357,217 @@ func parseInputFilterArray(inputFilters []string) ([]*types.DynamicConfigFilter,
 
 	return parsedFilters, nil
 }
+
+// AdminValidateDynamicConfig checks a dynamic config file offline against the metadata of the dynamic config keys
+func AdminValidateDynamicConfig(c *cli.Context) {
+	fileName := getRequiredOption(c, FlagInputFile)
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		ErrorAndExit("Failed to read dynamic config file", err)
+		return
+	}
+
+	errs, err := dynamicconfig.ValidateConfig(content)
+	if err != nil {
+		ErrorAndExit("Failed to parse dynamic config file", err)
+		return
+	}
+	if len(errs) == 0 {
+		fmt.Printf("Dynamic config file %s is valid\n", fileName)
+		return
+	}
+
+	allowUnknown := c.Bool(FlagAllowUnknownKeys)
+	unknownSeverity := "error"
+	if allowUnknown {
+		unknownSeverity = "warning"
+	}
+	invalid, unknown := errs.SplitUnknownKeys()
+	table := make([]DynamicConfigValidationRow, 0, len(errs))
+	for _, e := range invalid {
+		table = append(table, DynamicConfigValidationRow{
+			Key:         e.KeyName,
+			Constraints: dynamicconfig.FormatConstraints(e.Constraints),
+			Severity:    "error",
+			Error:       e.Message,
+		})
+	}
+	for _, e := range unknown {
+		table = append(table, DynamicConfigValidationRow{
+			Key:         e.KeyName,
+			Constraints: dynamicconfig.FormatConstraints(e.Constraints),
+			Severity:    unknownSeverity,
+			Error:       e.Message,
+		})
+	}
+	Render(c, table, RenderOptions{Color: true, DefaultTemplate: templateTable})
+	if len(invalid) > 0 {
+		ErrorAndExit(fmt.Sprintf("Dynamic config file %s is invalid", fileName), fmt.Errorf("found %d invalid entries", len(invalid)))
+	}
+	if !allowUnknown {
+		ErrorAndExit(
+			fmt.Sprintf("Dynamic config file %s is invalid", fileName),
+			fmt.Errorf("found %d entries with unknown keys, pass --%s to only warn about them", len(unknown), FlagAllowUnknownKeys),
+		)
+	}
+	fmt.Printf("Dynamic config file %s is valid, %d entries with unknown keys will be ignored\n", fileName, len(unknown))
+}
+
+// dryRunUpdateDynamicConfig validates the new values and prints which filters, services and hosts the update would affect
+func dryRunUpdateDynamicConfig(
+	c *cli.Context,
+	ctx context.Context,
+	adminClient admin.Client,
+	dcName string,
+	newValues []*cliValue,
+) {
+	key, err := dynamicconfig.GetKeyFromKeyName(dcName)
+	if err != nil {
+		ErrorAndExit("Invalid dynamic config name", err)
+		return
+	}
+
+	var invalid []string
+	for _, value := range newValues {
+		if err := dynamicconfig.ValidateConstrainedValue(key, value.Value, getCLIValueConstraints(value)); err != nil {
+			invalid = append(invalid, err.Error())
+		}
+	}
+
+	var currentValues []*cliValue
+	listResponse, err := adminClient.ListDynamicConfig(ctx, &types.ListDynamicConfigRequest{ConfigName: dcName})
+	if err != nil {
+		fmt.Printf("Current values are unavailable: %v\n", err)
+	} else if listResponse != nil {
+		for _, entry := range listResponse.Entries {
+			if entry == nil || entry.Name != dcName {
+				continue
+			}
+			cliEntry, err := convertToInputEntry(entry)
+			if err != nil {
+				ErrorAndExit("Cannot parse list response", err)
+				return
+			}
+			currentValues = append(currentValues, cliEntry.Values...)
+		}
+	}
+
+	services := getDynamicConfigKeyServices(dcName)
+	fmt.Printf("Dry run, dynamic config %s is not updated.\n", dcName)
+	fmt.Printf("Description: %s\n", key.Description())
+	fmt.Printf("Default value: %v\n", formatCLIValue(key.DefaultValue()))
+	fmt.Printf("Affected services: %s\n", strings.Join(services, ", "))
+	if hosts, err := getServiceHosts(ctx, adminClient, services); err != nil {
+		fmt.Printf("Affected hosts: unknown, failed to describe cluster: %v\n", err)
+	} else {
+		fmt.Printf("Affected hosts (%d): %s\n", len(hosts), strings.Join(hosts, ", "))
+	}
+	fmt.Println()
+
+	changes := getDynamicConfigChanges(currentValues, newValues)
+	if len(changes) == 0 {
+		fmt.Println("No values would change.")
+	} else {
+		Render(c, changes, RenderOptions{Color: true, DefaultTemplate: templateTable})
+	}
+
+	if len(invalid) > 0 {
+		ErrorAndExit("Update would be rejected", fmt.Errorf("%s", strings.Join(invalid, "; ")))
+	}
+}
+
+// getDynamicConfigChanges matches values by their filters and returns the values which would be added, changed or removed
+func getDynamicConfigChanges(currentValues []*cliValue, newValues []*cliValue) []DynamicConfigChangeRow {
+	current := make(map[string]interface{}, len(currentValues))
+	for _, value := range currentValues {
+		current[dynamicconfig.FormatConstraints(getCLIValueConstraints(value))] = value.Value
+	}
+	updated := make(map[string]interface{}, len(newValues))
+	for _, value := range newValues {
+		updated[dynamicconfig.FormatConstraints(getCLIValueConstraints(value))] = value.Value
+	}
+
+	var changes []DynamicConfigChangeRow
+	for filters, newValue := range updated {
+		currentValue, ok := current[filters]
+		row := DynamicConfigChangeRow{Filters: filters, NewValue: formatCLIValue(newValue)}
+		switch {
+		case !ok:
+			row.Change = "added"
+		case reflect.DeepEqual(currentValue, newValue):
+			row.CurrentValue = formatCLIValue(currentValue)
+			row.Change = "unchanged"
+		default:
+			row.CurrentValue = formatCLIValue(currentValue)
+			row.Change = "changed"
+		}
+		changes = append(changes, row)
+	}
+	for filters, currentValue := range current {
+		if _, ok := updated[filters]; !ok {
+			changes = append(changes, DynamicConfigChangeRow{
+				Filters:      filters,
+				CurrentValue: formatCLIValue(currentValue),
+				Change:       "removed",
+			})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Filters < changes[j].Filters
+	})
+	return changes
+}
+
+// getDynamicConfigKeyServices returns the services reading the key, based on the prefix of its name
+func getDynamicConfigKeyServices(keyName string) []string {
+	switch prefix := strings.SplitN(keyName, ".", 2)[0]; prefix {
+	case "admin":
+		return []string{service.Frontend}
+	case "frontend", "history", "matching", "worker":
+		return []string{service.FullName(prefix)}
+	default:
+		return service.List
+	}
+}
+
+func getServiceHosts(ctx context.Context, adminClient admin.Client, services []string) ([]string, error) {
+	response, err := adminClient.DescribeCluster(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var hosts []string
+	if response == nil || response.MembershipInfo == nil {
+		return hosts, nil
+	}
+	for _, ring := range response.MembershipInfo.Rings {
+		for _, s := range services {
+			if ring == nil || ring.Role != s {
+				continue
+			}
+			for _, member := range ring.Members {
+				if member != nil {
+					hosts = append(hosts, member.Identity)
+				}
+			}
+		}
+	}
+	sort.Strings(hosts)
+	return hosts, nil
+}
+
+func getCLIValueConstraints(value *cliValue) map[string]interface{} {
+	constraints := make(map[string]interface{}, len(value.Filters))
+	for _, filter := range value.Filters {
+		constraints[filter.Name] = filter.Value
+	}
+	return constraints
+}
+
+func formatCLIValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
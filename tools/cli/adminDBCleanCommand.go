@@ -42,6 +42,7 @@ import (
 	"github.com/uber/cadence/common/reconciliation/invariant"
 	"github.com/uber/cadence/common/reconciliation/store"
 	"github.com/uber/cadence/service/worker/scanner/executions"
+	"github.com/uber/cadence/service/worker/scanner/shardscanner"
 )
 
 // AdminDBDataDecodeThrift is the command to decode thrift binary into JSON
@@ -109,6 +110,10 @@ func AdminDBClean(c *cli.Context) {
 	if err != nil {
 		ErrorAndExit("unknown scan type", err)
 	}
+	if scanType == executions.VisibilityExecutionType {
+		startVisibilityFixer(c)
+		return
+	}
 	collectionSlice := c.StringSlice(FlagInvariantCollection)
 	blob := scanType.ToBlobstoreEntity()
 
@@ -120,8 +125,12 @@ func AdminDBClean(c *cli.Context) {
 		}
 		collections = append(collections, collection)
 	}
+	if requiresScannerWorkflow(c, scanType, collections) {
+		startConcreteExecutionsFixer(c, collections)
+		return
+	}
 
-	invariants := scanType.ToInvariants(collections)
+	invariants := scanType.ToInvariants(collections, nil, nil)
 	if len(invariants) < 1 {
 		ErrorAndExit(
 			fmt.Sprintf("no invariants for scantype %q and collections %q",
@@ -197,3 +206,21 @@ func fixExecution(
 
 	return invariant.NewInvariantManager(ivs).RunFixes(ctx, execution.Execution)
 }
+
+// startConcreteExecutionsFixer starts the executions fixer workflow for the corruptions
+// reported by the last executions scanner run started from the CLI.
+func startConcreteExecutionsFixer(c *cli.Context, collections []invariant.Collection) {
+	params := shardscanner.FixerWorkflowParams{
+		ScannerWorkflowWorkflowID: executions.ConcreteExecutionsScannerCLIWFID,
+		FixerWorkflowConfigOverwrites: shardscanner.FixerWorkflowConfigOverwrites{
+			CustomFixerConfig: collectionsToScannerConfig(collections),
+		},
+	}
+	startScannerWorkflow(
+		c,
+		params,
+		executions.ConcreteExecutionsFixerCLIWFID,
+		executions.ConcreteExecutionsFixerTaskListName,
+		executions.ConcreteExecutionsFixerWFTypeName,
+	)
+}
@@ -28,6 +28,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/urfave/cli"
@@ -40,6 +41,7 @@ import (
 	"github.com/uber/cadence/common/reconciliation/invariant"
 	"github.com/uber/cadence/common/reconciliation/store"
 	"github.com/uber/cadence/service/worker/scanner/executions"
+	"github.com/uber/cadence/service/worker/scanner/shardscanner"
 )
 
 const (
@@ -55,6 +57,10 @@ func AdminDBScan(c *cli.Context) {
 	}
 
 	numberOfShards := getRequiredIntOption(c, FlagNumberOfShards)
+	if scanType == executions.VisibilityExecutionType {
+		startVisibilityScanner(c, numberOfShards)
+		return
+	}
 	collectionSlice := c.StringSlice(FlagInvariantCollection)
 
 	var collections []invariant.Collection
@@ -65,8 +71,12 @@ func AdminDBScan(c *cli.Context) {
 		}
 		collections = append(collections, collection)
 	}
+	if requiresScannerWorkflow(c, scanType, collections) {
+		startConcreteExecutionsScanner(c, numberOfShards, collections)
+		return
+	}
 
-	invariants := scanType.ToInvariants(collections)
+	invariants := scanType.ToInvariants(collections, nil, nil)
 	if len(invariants) < 1 {
 		ErrorAndExit(
 			fmt.Sprintf("no invariants for scan type %q and collections %q",
@@ -112,6 +122,57 @@ func AdminDBScan(c *cli.Context) {
 	}
 }
 
+// requiresScannerWorkflow returns true if the WorkflowState collection was asked for explicitly.
+// Its invariants need the history service, so they run in the executions scanner and fixer workflows.
+func requiresScannerWorkflow(c *cli.Context, scanType executions.ScanType, collections []invariant.Collection) bool {
+	if !c.IsSet(FlagInvariantCollection) {
+		return false
+	}
+	for _, collection := range collections {
+		if collection != invariant.CollectionWorkflowState {
+			continue
+		}
+		if scanType != executions.ConcreteExecutionType {
+			ErrorAndExit(fmt.Sprintf("collection %q is only supported by scan type %q",
+				invariant.CollectionWorkflowState.String(),
+				executions.ConcreteExecutionType.String()), nil)
+		}
+		return true
+	}
+	return false
+}
+
+// startConcreteExecutionsScanner starts the executions scanner workflow for all shards with the given collections.
+// Corruptions are written to the reconciliation store and can be fixed with the clean command.
+func startConcreteExecutionsScanner(c *cli.Context, numberOfShards int, collections []invariant.Collection) {
+	params := shardscanner.ScannerWorkflowParams{
+		Shards: shardscanner.Shards{
+			Range: &shardscanner.ShardRange{Min: 0, Max: numberOfShards},
+		},
+		ScannerWorkflowConfigOverwrites: shardscanner.ScannerWorkflowConfigOverwrites{
+			GenericScannerConfig: shardscanner.GenericScannerConfigOverwrites{
+				Enabled: common.BoolPtr(true),
+			},
+			CustomScannerConfig: collectionsToScannerConfig(collections),
+		},
+	}
+	startScannerWorkflow(
+		c,
+		params,
+		executions.ConcreteExecutionsScannerCLIWFID,
+		executions.ConcreteExecutionsScannerTaskListName,
+		executions.ConcreteExecutionsScannerWFTypeName,
+	)
+}
+
+func collectionsToScannerConfig(collections []invariant.Collection) *shardscanner.CustomScannerConfig {
+	config := shardscanner.CustomScannerConfig{}
+	for _, collection := range collections {
+		config[collection.String()] = strconv.FormatBool(true)
+	}
+	return &config
+}
+
 func checkExecution(
 	c *cli.Context,
 	numberOfShards int,
@@ -0,0 +1,123 @@
+// The MIT License (MIT)
+//
+// Copyright (c) 2017-2020 Uber Technologies Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pborman/uuid"
+	"github.com/urfave/cli"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/service/worker/scanner/executions"
+	"github.com/uber/cadence/service/worker/scanner/shardscanner"
+)
+
+const (
+	defaultScannerWorkflowTimeoutInSeconds = 24 * 60 * 60
+)
+
+// startVisibilityScanner starts the workflow which compares executions of a domain
+// in all shards with their visibility records. Mismatches are written to the reconciliation store.
+func startVisibilityScanner(c *cli.Context, numberOfShards int) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+	domainID := getDomainID(c, domain)
+
+	params := shardscanner.ScannerWorkflowParams{
+		Shards: shardscanner.Shards{
+			Range: &shardscanner.ShardRange{Min: 0, Max: numberOfShards},
+		},
+		ScannerWorkflowConfigOverwrites: shardscanner.ScannerWorkflowConfigOverwrites{
+			GenericScannerConfig: shardscanner.GenericScannerConfigOverwrites{
+				Enabled: common.BoolPtr(true),
+			},
+			CustomScannerConfig: &shardscanner.CustomScannerConfig{
+				executions.VisibilityScannerDomainIDKey: domainID,
+			},
+		},
+	}
+	startScannerWorkflow(
+		c,
+		params,
+		executions.VisibilityScannerWorkflowID(domain),
+		executions.VisibilityScannerTaskListName,
+		executions.VisibilityScannerWFTypeName,
+	)
+}
+
+// startVisibilityFixer starts the workflow which fixes the mismatches reported by
+// the last visibility scanner run of a domain.
+func startVisibilityFixer(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+
+	params := shardscanner.FixerWorkflowParams{
+		ScannerWorkflowWorkflowID: executions.VisibilityScannerWorkflowID(domain),
+	}
+	startScannerWorkflow(
+		c,
+		params,
+		executions.VisibilityFixerWorkflowID(domain),
+		executions.VisibilityFixerTaskListName,
+		executions.VisibilityFixerWFTypeName,
+	)
+}
+
+func startScannerWorkflow(c *cli.Context, params interface{}, workflowID, taskList, workflowType string) {
+	input, err := json.Marshal(params)
+	if err != nil {
+		ErrorAndExit("Failed to serialize workflow params", err)
+	}
+	client := getCadenceClient(c)
+	tcCtx, cancel := newContext(c)
+	defer cancel()
+	resp, err := client.StartWorkflowExecution(tcCtx, &types.StartWorkflowExecutionRequest{
+		Domain:                              common.SystemLocalDomainName,
+		RequestID:                           uuid.New(),
+		WorkflowID:                          workflowID,
+		WorkflowIDReusePolicy:               types.WorkflowIDReusePolicyAllowDuplicate.Ptr(),
+		TaskList:                            &types.TaskList{Name: taskList},
+		ExecutionStartToCloseTimeoutSeconds: common.Int32Ptr(defaultScannerWorkflowTimeoutInSeconds),
+		TaskStartToCloseTimeoutSeconds:      common.Int32Ptr(defaultDecisionTimeoutInSeconds),
+		WorkflowType:                        &types.WorkflowType{Name: workflowType},
+		Input:                               input,
+	})
+	if err != nil {
+		ErrorAndExit("Failed to start workflow", err)
+	}
+	fmt.Println(workflowType + " started")
+	fmt.Println("wid: " + workflowID)
+	fmt.Println("rid: " + resp.GetRunID())
+}
+
+func getDomainID(c *cli.Context, domain string) string {
+	client := getCadenceClient(c)
+	ctx, cancel := newContext(c)
+	defer cancel()
+	resp, err := client.DescribeDomain(ctx, &types.DescribeDomainRequest{Name: common.StringPtr(domain)})
+	if err != nil {
+		ErrorAndExit("Failed to describe domain", err)
+	}
+	return resp.GetDomainInfo().GetUUID()
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/urfave/cli"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/service/worker/domaindeletion"
+)
+
+const (
+	defaultDomainDeletionTimeoutInSeconds = 7 * 24 * 60 * 60
+)
+
+// DomainAuditRow is a row of the domain history table
+type DomainAuditRow struct {
+	EventID   int64     `header:"Event ID" json:"eventID"`
+	Time      time.Time `header:"Time" json:"time"`
+	Operation string    `header:"Operation" json:"operation"`
+	Actor     string    `header:"Actor" json:"actor"`
+	Cluster   string    `header:"Cluster" json:"cluster"`
+	Changes   []string  `header:"Changes" json:"changes"`
+	Request   string    `json:"request"`
+}
+
+// AdminDeleteDomain starts the workflow which purges the data of a deprecated domain and deletes it
+func AdminDeleteDomain(c *cli.Context) {
+	domainName := getRequiredGlobalOption(c, FlagDomain)
+	if !c.Bool(FlagYes) {
+		prompt(fmt.Sprintf("All closed workflows, task lists and the record of domain %v will be deleted permanently, continue? Y/N", domainName))
+	}
+	params := domaindeletion.DeletionParams{
+		Request: domaindeletion.DeletionRequest{
+			DomainName: domainName,
+			RPS:        c.Int(FlagRPS),
+			PageSize:   c.Int(FlagPageSize),
+		},
+	}
+	input, err := json.Marshal(params)
+	if err != nil {
+		ErrorAndExit("Failed to serialize domain deletion params", err)
+	}
+
+	workflowID := domaindeletion.WorkflowID(domainName)
+	client := getCadenceClient(c)
+	tcCtx, cancel := newContext(c)
+	defer cancel()
+	resp, err := client.StartWorkflowExecution(tcCtx, &types.StartWorkflowExecutionRequest{
+		Domain:                              common.SystemLocalDomainName,
+		RequestID:                           uuid.New(),
+		WorkflowID:                          workflowID,
+		WorkflowIDReusePolicy:               types.WorkflowIDReusePolicyAllowDuplicate.Ptr(),
+		TaskList:                            &types.TaskList{Name: domaindeletion.TaskListName},
+		ExecutionStartToCloseTimeoutSeconds: common.Int32Ptr(defaultDomainDeletionTimeoutInSeconds),
+		TaskStartToCloseTimeoutSeconds:      common.Int32Ptr(defaultDecisionTimeoutInSeconds),
+		WorkflowType:                        &types.WorkflowType{Name: domaindeletion.WorkflowTypeName},
+		Input:                               input,
+	})
+	if err != nil {
+		if _, ok := err.(*types.WorkflowExecutionAlreadyStartedError); ok {
+			ErrorAndExit(fmt.Sprintf("Deletion of domain %v is already running", domainName), err)
+		}
+		ErrorAndExit("Failed to start domain deletion workflow", err)
+	}
+	fmt.Println("Domain deletion workflow started")
+	fmt.Println("wid: " + workflowID)
+	fmt.Println("rid: " + resp.GetRunID())
+	fmt.Printf("Progress can be queried with query type %v in domain %v\n", domaindeletion.ProgressQueryType, common.SystemLocalDomainName)
+}
+
+// AdminDomainHistory prints the audit log of a domain, oldest change first
+func AdminDomainHistory(c *cli.Context) {
+	domainName := getRequiredGlobalOption(c, FlagDomain)
+	auditLog := initializeDomainAuditLog(c)
+
+	ctx, cancel := newContext(c)
+	defer cancel()
+	request := &types.ListDomainAuditEventsRequest{
+		Domain:   domainName,
+		PageSize: int32(c.Int(FlagPageSize)),
+	}
+	table := []DomainAuditRow{}
+	for {
+		response, err := auditLog.List(ctx, request)
+		if err != nil {
+			ErrorAndExit("Failed to list domain audit events", err)
+		}
+		for _, event := range response.Events {
+			changes := make([]string, 0, len(event.Changes))
+			for _, change := range event.Changes {
+				changes = append(changes, fmt.Sprintf("%v: %q -> %q", change.Field, change.Before, change.After))
+			}
+			table = append(table, DomainAuditRow{
+				EventID:   event.EventID,
+				Time:      time.Unix(0, event.Timestamp),
+				Operation: event.Operation,
+				Actor:     event.Actor,
+				Cluster:   event.Cluster,
+				Changes:   changes,
+				Request:   event.Request,
+			})
+		}
+		if len(response.NextPageToken) == 0 {
+			break
+		}
+		request.NextPageToken = response.NextPageToken
+	}
+	Render(c, table, RenderOptions{Color: true, PrintDateTime: true, DefaultTemplate: templateTable})
+}
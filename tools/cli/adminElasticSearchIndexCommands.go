@@ -0,0 +1,234 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/uber/cadence/common/config"
+	"github.com/uber/cadence/common/definition"
+	es "github.com/uber/cadence/common/elasticsearch"
+	"github.com/uber/cadence/common/log"
+)
+
+// ESManagedIndexRow is a row of managed visibility indices output
+type ESManagedIndexRow struct {
+	Index        string `header:"Index"`
+	StartTime    string `header:"Start Time"`
+	Generation   int    `header:"Generation"`
+	IsWriteIndex bool   `header:"Write Index"`
+	IsReindexing bool   `header:"Reindexing"`
+}
+
+// AdminInitManagedIndices creates the first managed visibility index with its aliases
+func AdminInitManagedIndices(c *cli.Context) {
+	manager := newESIndexManager(c)
+	ctx, cancel := newContext(c)
+	defer cancel()
+
+	index, err := manager.Init(ctx, c.StringSlice(FlagExistingIndex))
+	if err != nil {
+		ErrorAndExit("Unable to init managed indices", err)
+	}
+	fmt.Printf("Created index %v\n", index.Name)
+}
+
+// AdminListManagedIndices lists the managed visibility indices
+func AdminListManagedIndices(c *cli.Context) {
+	manager := newESIndexManager(c)
+	ctx, cancel := newContext(c)
+	defer cancel()
+
+	indices, err := manager.ListIndices(ctx)
+	if err != nil {
+		ErrorAndExit("Unable to list managed indices", err)
+	}
+
+	table := []ESManagedIndexRow{}
+	for _, index := range indices {
+		startTime := "-"
+		if !index.StartTime.IsZero() {
+			startTime = index.StartTime.Format(time.RFC3339)
+		}
+		table = append(table, ESManagedIndexRow{
+			Index:        index.Name,
+			StartTime:    startTime,
+			Generation:   index.Generation,
+			IsWriteIndex: index.IsWriteIndex,
+			IsReindexing: index.IsReindexing,
+		})
+	}
+	Render(c, table, RenderOptions{DefaultTemplate: templateTable, Color: true, Border: true})
+}
+
+// AdminRolloverManagedIndex rolls over the managed visibility write index
+func AdminRolloverManagedIndex(c *cli.Context) {
+	manager := newESIndexManager(c)
+	ctx, cancel := newContext(c)
+	defer cancel()
+
+	resp, err := manager.Rollover(ctx, es.RolloverConditions{
+		MaxAge:  c.Duration(FlagMaxAge),
+		MaxSize: c.String(FlagMaxSize),
+		MaxDocs: c.Int64(FlagMaxDocs),
+	}, c.Bool(FlagDryRun))
+	if err != nil {
+		ErrorAndExit("Unable to roll over index", err)
+	}
+	prettyPrintJSONObject(resp)
+}
+
+// AdminDeleteExpiredManagedIndices deletes the managed visibility indices whose workflows passed retention
+func AdminDeleteExpiredManagedIndices(c *cli.Context) {
+	manager := newESIndexManager(c)
+	retention := time.Duration(getRequiredIntOption(c, FlagRetentionDays)) * 24 * time.Hour
+	ctx, cancel := newContext(c)
+	defer cancel()
+
+	expired, err := manager.GetExpiredIndices(ctx, retention)
+	if err != nil {
+		ErrorAndExit("Unable to get expired indices", err)
+	}
+	for _, index := range expired {
+		if c.Bool(FlagDryRun) {
+			fmt.Printf("Index %v is expired\n", index)
+			continue
+		}
+		if err := manager.DeleteIndex(ctx, index); err != nil {
+			ErrorAndExit(fmt.Sprintf("Unable to delete index %v", index), err)
+		}
+		fmt.Printf("Deleted index %v\n", index)
+	}
+}
+
+// AdminStartReindex creates the index replacing given index and starts to copy its documents
+func AdminStartReindex(c *cli.Context) {
+	manager := newESIndexManager(c)
+	index := getRequiredOption(c, FlagIndex)
+	migrations, err := parseSearchAttributeMigrations(c.StringSlice(FlagRenameSearchAttribute), c.StringSlice(FlagRemoveSearchAttribute))
+	if err != nil {
+		ErrorAndExit("Invalid search attribute migration", err)
+	}
+
+	ctx, cancel := newContext(c)
+	dest, err := manager.StartReindex(ctx, index)
+	cancel()
+	if err != nil {
+		ErrorAndExit("Unable to start reindex", err)
+	}
+	fmt.Printf("Created index %v, waiting for writers to write to it\n", dest.Name)
+	// documents are copied after all writers write to both indices, so no update is missed
+	time.Sleep(2 * es.ManagedIndexRefreshInterval)
+
+	ctx, cancel = newContext(c)
+	defer cancel()
+	taskID, err := manager.Backfill(ctx, index, migrations)
+	if err != nil {
+		ErrorAndExit("Unable to start backfill", err)
+	}
+	fmt.Printf("Started backfill of index %v to %v, task ID: %v\n", index, dest.Name, taskID)
+}
+
+// parseSearchAttributeMigrations parses renames in the form of Old:New and removals of search attributes
+func parseSearchAttributeMigrations(renames []string, removals []string) ([]es.SearchAttributeMigration, error) {
+	var migrations []es.SearchAttributeMigration
+	for _, rename := range renames {
+		parts := strings.Split(rename, ":")
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return nil, fmt.Errorf("invalid rename %q, expected Old:New", rename)
+		}
+		migrations = append(migrations, es.SearchAttributeMigration{From: parts[0], To: parts[1]})
+	}
+	for _, removal := range removals {
+		if len(removal) == 0 {
+			return nil, errors.New("empty search attribute to remove")
+		}
+		migrations = append(migrations, es.SearchAttributeMigration{From: removal})
+	}
+	for _, migration := range migrations {
+		if definition.IsSystemIndexedKey(migration.From) || definition.IsSystemIndexedKey(migration.To) {
+			return nil, fmt.Errorf("system search attributes cannot be migrated: %v", migration)
+		}
+	}
+	return migrations, nil
+}
+
+// AdminDescribeReindex shows the status of a backfill
+func AdminDescribeReindex(c *cli.Context) {
+	manager := newESIndexManager(c)
+	taskID := getRequiredOption(c, FlagTaskID)
+	ctx, cancel := newContext(c)
+	defer cancel()
+
+	status, err := manager.GetTask(ctx, taskID)
+	if err != nil {
+		ErrorAndExit("Unable to get reindex status", err)
+	}
+	prettyPrintJSONObject(status)
+}
+
+// AdminSwapReindex replaces given index with the reindexed index
+func AdminSwapReindex(c *cli.Context) {
+	manager := newESIndexManager(c)
+	index := getRequiredOption(c, FlagIndex)
+
+	ctx, cancel := newContext(c)
+	dest, err := manager.SwapReindex(ctx, index)
+	cancel()
+	if err != nil {
+		ErrorAndExit("Unable to swap reindexed index", err)
+	}
+	fmt.Printf("Replaced index %v with %v\n", index, dest)
+	if !c.Bool(FlagDeleteSource) {
+		return
+	}
+
+	// writers may write to the replaced index until they refresh their indices
+	time.Sleep(2 * es.ManagedIndexRefreshInterval)
+	ctx, cancel = newContext(c)
+	defer cancel()
+	if err := manager.DeleteIndex(ctx, index); err != nil {
+		ErrorAndExit(fmt.Sprintf("Unable to delete index %v", index), err)
+	}
+	fmt.Printf("Deleted index %v\n", index)
+}
+
+func newESIndexManager(c *cli.Context) *es.IndexManager {
+	esURL, err := url.Parse(getRequiredOption(c, FlagURL))
+	if err != nil {
+		ErrorAndExit("Invalid ElasticSearch URL", err)
+	}
+	alias := getRequiredOption(c, FlagAlias)
+	client, err := es.NewGenericClient(&config.ElasticSearchConfig{
+		URL:     *esURL,
+		Version: c.String(FlagESVersion),
+	}, log.NewNoop())
+	if err != nil {
+		ErrorAndExit("Unable to create ElasticSearch client", err)
+	}
+	return es.NewIndexManager(client, alias)
+}
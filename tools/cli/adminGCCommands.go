@@ -0,0 +1,197 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pborman/uuid"
+	"github.com/urfave/cli"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/service/worker/scanner/gc"
+	"github.com/uber/cadence/service/worker/scanner/shardscanner"
+	"github.com/uber/cadence/service/worker/scanner/tasks"
+)
+
+const (
+	defaultGCExecutorTimeoutInSeconds = 24 * 60 * 60
+)
+
+type gcShardScanner struct {
+	scannerWorkflowID string
+	fixerWorkflowID   string
+	fixerTaskListName string
+	fixerWFTypeName   string
+}
+
+// gcShardScanners are the gc types whose garbage is reported and fixed by shard scanners
+var gcShardScanners = map[gc.Type]gcShardScanner{
+	gc.TypeTransfer: {
+		scannerWorkflowID: tasks.TransferScannerWorkflowID,
+		fixerWorkflowID:   tasks.TransferFixerWorkflowID,
+		fixerTaskListName: tasks.TransferFixerTaskListName,
+		fixerWFTypeName:   tasks.TransferFixerWFTypeName,
+	},
+	gc.TypeReplication: {
+		scannerWorkflowID: tasks.ReplicationScannerWorkflowID,
+		fixerWorkflowID:   tasks.ReplicationFixerWorkflowID,
+		fixerTaskListName: tasks.ReplicationFixerTaskListName,
+		fixerWFTypeName:   tasks.ReplicationFixerWFTypeName,
+	},
+}
+
+// AdminDBGCReport prints the garbage collection report of the history or task list scanner,
+// or of a gc executor when a workflow id is given. For transfer and replication tasks the
+// aggregate report of the shard scanner, or of its fixer when a workflow id is given, is printed.
+func AdminDBGCReport(c *cli.Context) {
+	gcType := getGCType(c)
+	if shardScanner, ok := gcShardScanners[gcType]; ok {
+		workflowID := shardScanner.scannerWorkflowID
+		if c.IsSet(FlagWorkflowID) {
+			workflowID = c.String(FlagWorkflowID)
+		}
+		var report interface{}
+		queryGCWorkflow(c, workflowID, getRunID(c), shardscanner.AggregateReportQuery, &report)
+		prettyPrintJSONObject(report)
+		return
+	}
+	workflowID := gcType.ScannerWorkflowID()
+	if c.IsSet(FlagWorkflowID) {
+		workflowID = c.String(FlagWorkflowID)
+	}
+	report := queryGCReport(c, workflowID, getRunID(c))
+	prettyPrintJSONObject(report)
+}
+
+// AdminDBGCExecute starts the workflow which deletes the garbage reported by the history or task list scanner.
+// Garbage of the last completed scanner run is deleted unless the report keys are given in an input file.
+// For transfer and replication tasks the shard fixer is started against the last completed scanner run,
+// it only completes tasks of domains allowed by its FixerDomainAllow dynamic config.
+func AdminDBGCExecute(c *cli.Context) {
+	gcType := getGCType(c)
+	if shardScanner, ok := gcShardScanners[gcType]; ok {
+		if c.IsSet(FlagInputFile) {
+			ErrorAndExit(fmt.Sprintf("Input file is not supported for gc type %v", gcType), nil)
+		}
+		startScannerWorkflow(
+			c,
+			shardscanner.FixerWorkflowParams{ScannerWorkflowWorkflowID: shardScanner.scannerWorkflowID},
+			shardScanner.fixerWorkflowID,
+			shardScanner.fixerTaskListName,
+			shardScanner.fixerWFTypeName,
+		)
+		return
+	}
+	params := gc.ExecutorParams{Type: gcType}
+	if c.IsSet(FlagInputFile) {
+		data, err := ioutil.ReadFile(c.String(FlagInputFile))
+		if err != nil {
+			ErrorAndExit("Failed to read input file", err)
+		}
+		if err := json.Unmarshal(data, &params.Keys); err != nil {
+			ErrorAndExit("Input file must contain a JSON list of report keys", err)
+		}
+	} else {
+		report := queryGCReport(c, gcType.ScannerWorkflowID(), "")
+		if !report.ReportOnly {
+			ErrorAndExit("Last scanner run was not in report only mode, there is nothing to execute", nil)
+		}
+		params.Keys = report.Keys
+	}
+	if len(params.Keys) == 0 {
+		fmt.Println("No garbage reported")
+		return
+	}
+
+	input, err := json.Marshal(params)
+	if err != nil {
+		ErrorAndExit("Failed to serialize gc executor params", err)
+	}
+	workflowID := gc.ExecutorWorkflowID(gcType)
+	client := getCadenceClient(c)
+	tcCtx, cancel := newContext(c)
+	defer cancel()
+	resp, err := client.StartWorkflowExecution(tcCtx, &types.StartWorkflowExecutionRequest{
+		Domain:                              common.SystemLocalDomainName,
+		RequestID:                           uuid.New(),
+		WorkflowID:                          workflowID,
+		WorkflowIDReusePolicy:               types.WorkflowIDReusePolicyAllowDuplicate.Ptr(),
+		TaskList:                            &types.TaskList{Name: gcType.TaskListName()},
+		ExecutionStartToCloseTimeoutSeconds: common.Int32Ptr(defaultGCExecutorTimeoutInSeconds),
+		TaskStartToCloseTimeoutSeconds:      common.Int32Ptr(defaultDecisionTimeoutInSeconds),
+		WorkflowType:                        &types.WorkflowType{Name: gc.ExecutorWFTypeName},
+		Input:                               input,
+	})
+	if err != nil {
+		ErrorAndExit("Failed to start gc executor workflow", err)
+	}
+	fmt.Println("GC executor workflow started")
+	fmt.Println("wid: " + workflowID)
+	fmt.Println("rid: " + resp.GetRunID())
+}
+
+func getGCType(c *cli.Context) gc.Type {
+	gcType := gc.Type(getRequiredOption(c, FlagGCType))
+	switch gcType {
+	case gc.TypeHistory, gc.TypeTaskList, gc.TypeTransfer, gc.TypeReplication:
+		return gcType
+	}
+	ErrorAndExit(fmt.Sprintf(
+		"Unknown gc type %q, expected one of %v, %v, %v, %v",
+		gcType, gc.TypeHistory, gc.TypeTaskList, gc.TypeTransfer, gc.TypeReplication,
+	), nil)
+	return gcType
+}
+
+func queryGCReport(c *cli.Context, workflowID string, runID string) *gc.Report {
+	var report gc.Report
+	queryGCWorkflow(c, workflowID, runID, gc.ReportQueryType, &report)
+	return &report
+}
+
+func queryGCWorkflow(c *cli.Context, workflowID string, runID string, queryType string, result interface{}) {
+	client := getCadenceClient(c)
+	tcCtx, cancel := newContext(c)
+	defer cancel()
+	queryResp, err := client.QueryWorkflow(tcCtx, &types.QueryWorkflowRequest{
+		Domain: common.SystemLocalDomainName,
+		Execution: &types.WorkflowExecution{
+			WorkflowID: workflowID,
+			RunID:      runID,
+		},
+		Query: &types.WorkflowQuery{
+			QueryType: queryType,
+		},
+	})
+	if err != nil {
+		ErrorAndExit("Failed to query gc report", err)
+	}
+	if queryResp.GetQueryResult() == nil {
+		ErrorAndExit("QueryResult has no value", nil)
+	}
+	if err := json.Unmarshal(queryResp.GetQueryResult(), result); err != nil {
+		ErrorAndExit("Unable to deserialize gc report", err)
+	}
+}
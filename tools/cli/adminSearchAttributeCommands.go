@@ -0,0 +1,339 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli"
+
+	"github.com/uber/cadence/client/admin"
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/definition"
+	"github.com/uber/cadence/common/dynamicconfig"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/types"
+)
+
+// SearchAttributeRow is a search attribute or an alias with its lifecycle settings
+type SearchAttributeRow struct {
+	Name       string `header:"Name" json:"name"`
+	Type       string `header:"Type" json:"type"`
+	AliasOf    string `header:"Alias Of" json:"aliasOf,omitempty"`
+	Deprecated string `header:"Deprecated" json:"deprecated,omitempty"`
+	Domains    string `header:"Domains" json:"domains,omitempty"`
+}
+
+// searchAttributeLifecycle is the dynamic config describing the search attributes of a cluster
+type searchAttributeLifecycle struct {
+	valid        map[string]interface{}
+	aliases      map[string]interface{}
+	deprecated   map[string]interface{}
+	domainScopes map[string]interface{}
+}
+
+var searchAttributeLifecycleKeys = []dynamicconfig.MapKey{
+	dynamicconfig.ValidSearchAttributes,
+	dynamicconfig.SearchAttributeAliases,
+	dynamicconfig.DeprecatedSearchAttributes,
+	dynamicconfig.DomainScopedSearchAttributes,
+}
+
+// AdminListSearchAttributes lists search attributes and aliases with their deprecation and domain scope
+func AdminListSearchAttributes(c *cli.Context) {
+	adminClient := cFactory.ServerAdminClient(c)
+	ctx, cancel := newContext(c)
+	defer cancel()
+
+	lifecycle := getSearchAttributeLifecycle(ctx, adminClient)
+	Render(c, lifecycle.rows(), RenderOptions{DefaultTemplate: templateTable, Color: true, Border: true})
+}
+
+// AdminDeprecateSearchAttribute marks a search attribute as deprecated
+func AdminDeprecateSearchAttribute(c *cli.Context) {
+	key := getRequiredOption(c, FlagSearchAttributesKey)
+	note := c.String(FlagReason)
+
+	updateSearchAttributeLifecycle(c,
+		fmt.Sprintf("Are you trying to deprecate search attribute [%s]? Y/N", color.YellowString(key)),
+		func(lifecycle *searchAttributeLifecycle) error {
+			return lifecycle.deprecate(key, note)
+		})
+}
+
+// AdminRemoveSearchAttribute removes a search attribute or an alias
+func AdminRemoveSearchAttribute(c *cli.Context) {
+	key := getRequiredOption(c, FlagSearchAttributesKey)
+
+	updateSearchAttributeLifecycle(c,
+		fmt.Sprintf("Are you trying to remove search attribute [%s]? Writes and queries using it will be rejected. Y/N", color.YellowString(key)),
+		func(lifecycle *searchAttributeLifecycle) error {
+			return lifecycle.remove(key)
+		})
+	fmt.Println("Documents keep the values of removed search attributes until they are reindexed, see `cadence admin es reindex start --remove_search_attr`.")
+}
+
+// AdminAliasSearchAttribute adds an alias of a search attribute.
+// If the alias is an existing search attribute, it is renamed to the target.
+func AdminAliasSearchAttribute(c *cli.Context) {
+	alias := getRequiredOption(c, FlagSearchAttributesKey)
+	target := getRequiredOption(c, FlagSearchAttributesTarget)
+
+	updateSearchAttributeLifecycle(c,
+		fmt.Sprintf("Are you trying to make [%s] an alias of search attribute [%s]? Y/N", color.YellowString(alias), color.YellowString(target)),
+		func(lifecycle *searchAttributeLifecycle) error {
+			return lifecycle.alias(alias, target)
+		})
+	fmt.Printf("Existing documents are not changed, run `cadence admin es reindex start --rename_search_attr %s:%s` to migrate them.\n", alias, target)
+}
+
+// AdminScopeSearchAttribute restricts a search attribute to a list of domains, an empty list allows all domains
+func AdminScopeSearchAttribute(c *cli.Context) {
+	key := getRequiredOption(c, FlagSearchAttributesKey)
+	var domains []string
+	for _, domain := range strings.Split(c.String(FlagSearchAttributesDomains), ",") {
+		if domain = strings.TrimSpace(domain); len(domain) != 0 {
+			domains = append(domains, domain)
+		}
+	}
+
+	promptMsg := fmt.Sprintf("Are you trying to allow search attribute [%s] for all domains? Y/N", color.YellowString(key))
+	if len(domains) != 0 {
+		promptMsg = fmt.Sprintf("Are you trying to allow search attribute [%s] only for domains %v? Y/N", color.YellowString(key), domains)
+	}
+	updateSearchAttributeLifecycle(c, promptMsg, func(lifecycle *searchAttributeLifecycle) error {
+		return lifecycle.scope(key, domains)
+	})
+}
+
+// updateSearchAttributeLifecycle applies a change to the search attribute dynamic config and writes the changed keys
+func updateSearchAttributeLifecycle(c *cli.Context, promptMsg string, update func(*searchAttributeLifecycle) error) {
+	adminClient := cFactory.ServerAdminClient(c)
+	ctx, cancel := newContext(c)
+	defer cancel()
+
+	lifecycle := getSearchAttributeLifecycle(ctx, adminClient)
+	before := lifecycle.values()
+	if err := update(lifecycle); err != nil {
+		ErrorAndExit("Invalid search attribute change.", err)
+	}
+	promptFn(promptMsg)
+
+	after := lifecycle.values()
+	for _, key := range searchAttributeLifecycleKeys {
+		if reflect.DeepEqual(before[key], after[key]) {
+			continue
+		}
+		data, err := json.Marshal(after[key])
+		if err != nil {
+			ErrorAndExit("Failed to encode dynamic config value.", err)
+		}
+		err = adminClient.UpdateDynamicConfig(ctx, &types.UpdateDynamicConfigRequest{
+			ConfigName: key.String(),
+			ConfigValues: []*types.DynamicConfigValue{{
+				Value: &types.DataBlob{
+					EncodingType: types.EncodingTypeJSON.Ptr(),
+					Data:         data,
+				},
+			}},
+		})
+		if err != nil {
+			ErrorAndExit(fmt.Sprintf("Failed to update dynamic config %s.", key.String()), err)
+		}
+	}
+	fmt.Println("Success. Note that if DynamicConfig is not stored in database, it MUST be updated separately.")
+}
+
+func getSearchAttributeLifecycle(ctx context.Context, adminClient admin.Client) *searchAttributeLifecycle {
+	values := make(map[dynamicconfig.MapKey]map[string]interface{}, len(searchAttributeLifecycleKeys))
+	for _, key := range searchAttributeLifecycleKeys {
+		value, err := getGlobalMapDynamicConfig(ctx, adminClient, key)
+		if err != nil {
+			ErrorAndExit(fmt.Sprintf("Failed to get dynamic config %s.", key.String()), err)
+		}
+		values[key] = value
+	}
+	return &searchAttributeLifecycle{
+		valid:        values[dynamicconfig.ValidSearchAttributes],
+		aliases:      values[dynamicconfig.SearchAttributeAliases],
+		deprecated:   values[dynamicconfig.DeprecatedSearchAttributes],
+		domainScopes: values[dynamicconfig.DomainScopedSearchAttributes],
+	}
+}
+
+// getGlobalMapDynamicConfig returns the unfiltered value of a map dynamic config, or its default value if it is not set
+func getGlobalMapDynamicConfig(ctx context.Context, adminClient admin.Client, key dynamicconfig.MapKey) (map[string]interface{}, error) {
+	resp, err := adminClient.ListDynamicConfig(ctx, &types.ListDynamicConfigRequest{ConfigName: key.String()})
+	if err != nil {
+		return nil, err
+	}
+	value := make(map[string]interface{})
+	if resp == nil {
+		resp = &types.ListDynamicConfigResponse{}
+	}
+	for _, entry := range resp.Entries {
+		for _, v := range entry.Values {
+			if len(v.Filters) != 0 || v.Value == nil {
+				continue
+			}
+			if err := json.Unmarshal(v.Value.Data, &value); err != nil {
+				return nil, err
+			}
+			return value, nil
+		}
+	}
+	for k, v := range dynamicconfig.MapKeys[key].DefaultValue {
+		value[k] = v
+	}
+	return value, nil
+}
+
+func (l *searchAttributeLifecycle) values() map[dynamicconfig.MapKey]map[string]interface{} {
+	return map[dynamicconfig.MapKey]map[string]interface{}{
+		dynamicconfig.ValidSearchAttributes:        copySearchAttributeMap(l.valid),
+		dynamicconfig.SearchAttributeAliases:       copySearchAttributeMap(l.aliases),
+		dynamicconfig.DeprecatedSearchAttributes:   copySearchAttributeMap(l.deprecated),
+		dynamicconfig.DomainScopedSearchAttributes: copySearchAttributeMap(l.domainScopes),
+	}
+}
+
+// validateCustomKey checks the key is a custom search attribute, not an alias or a system attribute
+func (l *searchAttributeLifecycle) validateCustomKey(key string) error {
+	if definition.IsSystemIndexedKey(key) {
+		return fmt.Errorf("%s is reserved by system", key)
+	}
+	if _, ok := l.aliases[key]; ok {
+		return fmt.Errorf("%s is an alias of %s", key, definition.ResolveSearchAttributeAlias(l.aliases, key))
+	}
+	if _, ok := l.valid[key]; !ok {
+		return fmt.Errorf("%s is not a valid search attribute", key)
+	}
+	return nil
+}
+
+func (l *searchAttributeLifecycle) deprecate(key string, note string) error {
+	if err := l.validateCustomKey(key); err != nil {
+		return err
+	}
+	l.deprecated[key] = note
+	return nil
+}
+
+func (l *searchAttributeLifecycle) remove(key string) error {
+	if _, ok := l.aliases[key]; ok {
+		delete(l.aliases, key)
+		return nil
+	}
+	if err := l.validateCustomKey(key); err != nil {
+		return err
+	}
+	for alias := range l.aliases {
+		if definition.ResolveSearchAttributeAlias(l.aliases, alias) == key {
+			return fmt.Errorf("%s is the target of alias %s, remove the alias first", key, alias)
+		}
+	}
+	delete(l.valid, key)
+	delete(l.deprecated, key)
+	delete(l.domainScopes, key)
+	return nil
+}
+
+func (l *searchAttributeLifecycle) alias(alias string, target string) error {
+	if alias == target {
+		return fmt.Errorf("%s cannot be an alias of itself", alias)
+	}
+	if err := l.validateCustomKey(target); err != nil {
+		return err
+	}
+	if definition.IsSystemIndexedKey(alias) {
+		return fmt.Errorf("%s is reserved by system", alias)
+	}
+	if _, ok := l.valid[alias]; ok {
+		// renaming an existing search attribute, the alias replaces it
+		if err := l.remove(alias); err != nil {
+			return err
+		}
+	}
+	l.aliases[alias] = target
+	return nil
+}
+
+func (l *searchAttributeLifecycle) scope(key string, domains []string) error {
+	if err := l.validateCustomKey(key); err != nil {
+		return err
+	}
+	if len(domains) == 0 {
+		delete(l.domainScopes, key)
+		return nil
+	}
+	l.domainScopes[key] = domains
+	return nil
+}
+
+func (l *searchAttributeLifecycle) rows() []SearchAttributeRow {
+	logger := log.NewNoop()
+	rows := make([]SearchAttributeRow, 0, len(l.valid)+len(l.aliases))
+	for key, valueType := range l.valid {
+		row := SearchAttributeRow{
+			Name: key,
+			Type: common.ConvertIndexedValueTypeToThriftType(valueType, logger).String(),
+		}
+		if note, ok := l.deprecated[key]; ok {
+			row.Deprecated = fmt.Sprintf("%v", note)
+			if len(row.Deprecated) == 0 {
+				row.Deprecated = "yes"
+			}
+		}
+		if domains, ok := l.domainScopes[key]; ok {
+			row.Domains = fmt.Sprintf("%v", domains)
+		}
+		rows = append(rows, row)
+	}
+	for alias := range l.aliases {
+		target := definition.ResolveSearchAttributeAlias(l.aliases, alias)
+		row := SearchAttributeRow{
+			Name:    alias,
+			AliasOf: target,
+		}
+		if valueType, ok := l.valid[target]; ok {
+			row.Type = common.ConvertIndexedValueTypeToThriftType(valueType, logger).String()
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Name < rows[j].Name
+	})
+	return rows
+}
+
+func copySearchAttributeMap(m map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	es "github.com/uber/cadence/common/elasticsearch"
+)
+
+func newTestSearchAttributeLifecycle() *searchAttributeLifecycle {
+	return &searchAttributeLifecycle{
+		valid: map[string]interface{}{
+			"WorkflowID":         float64(1),
+			"CustomKeywordField": float64(1),
+			"CustomIntField":     float64(2),
+			"OldField":           float64(1),
+		},
+		aliases:      map[string]interface{}{"KeywordAlias": "CustomKeywordField"},
+		deprecated:   map[string]interface{}{},
+		domainScopes: map[string]interface{}{},
+	}
+}
+
+func TestSearchAttributeLifecycle_Deprecate(t *testing.T) {
+	lifecycle := newTestSearchAttributeLifecycle()
+	require.NoError(t, lifecycle.deprecate("CustomIntField", "use CustomDoubleField"))
+	assert.Equal(t, "use CustomDoubleField", lifecycle.deprecated["CustomIntField"])
+
+	assert.EqualError(t, lifecycle.deprecate("WorkflowID", ""), "WorkflowID is reserved by system")
+	assert.EqualError(t, lifecycle.deprecate("KeywordAlias", ""), "KeywordAlias is an alias of CustomKeywordField")
+	assert.EqualError(t, lifecycle.deprecate("Unknown", ""), "Unknown is not a valid search attribute")
+}
+
+func TestSearchAttributeLifecycle_Remove(t *testing.T) {
+	lifecycle := newTestSearchAttributeLifecycle()
+	lifecycle.deprecated["CustomIntField"] = ""
+	lifecycle.domainScopes["CustomIntField"] = []string{"domain"}
+	require.NoError(t, lifecycle.remove("CustomIntField"))
+	assert.NotContains(t, lifecycle.valid, "CustomIntField")
+	assert.NotContains(t, lifecycle.deprecated, "CustomIntField")
+	assert.NotContains(t, lifecycle.domainScopes, "CustomIntField")
+
+	assert.EqualError(t, lifecycle.remove("CustomKeywordField"), "CustomKeywordField is the target of alias KeywordAlias, remove the alias first")
+	require.NoError(t, lifecycle.remove("KeywordAlias"))
+	assert.Empty(t, lifecycle.aliases)
+	require.NoError(t, lifecycle.remove("CustomKeywordField"))
+}
+
+func TestSearchAttributeLifecycle_Alias(t *testing.T) {
+	lifecycle := newTestSearchAttributeLifecycle()
+	// renaming OldField to CustomKeywordField
+	require.NoError(t, lifecycle.alias("OldField", "CustomKeywordField"))
+	assert.NotContains(t, lifecycle.valid, "OldField")
+	assert.Equal(t, "CustomKeywordField", lifecycle.aliases["OldField"])
+
+	assert.EqualError(t, lifecycle.alias("NewAlias", "KeywordAlias"), "KeywordAlias is an alias of CustomKeywordField")
+	assert.EqualError(t, lifecycle.alias("RunID", "CustomIntField"), "RunID is reserved by system")
+	assert.EqualError(t, lifecycle.alias("CustomIntField", "CustomIntField"), "CustomIntField cannot be an alias of itself")
+
+	rows := lifecycle.rows()
+	assert.Equal(t, []SearchAttributeRow{
+		{Name: "CustomIntField", Type: "INT"},
+		{Name: "CustomKeywordField", Type: "KEYWORD"},
+		{Name: "KeywordAlias", Type: "KEYWORD", AliasOf: "CustomKeywordField"},
+		{Name: "OldField", Type: "KEYWORD", AliasOf: "CustomKeywordField"},
+		{Name: "WorkflowID", Type: "KEYWORD"},
+	}, rows)
+}
+
+func TestSearchAttributeLifecycle_Scope(t *testing.T) {
+	lifecycle := newTestSearchAttributeLifecycle()
+	require.NoError(t, lifecycle.scope("CustomIntField", []string{"domain-a", "domain-b"}))
+	assert.Equal(t, []string{"domain-a", "domain-b"}, lifecycle.domainScopes["CustomIntField"])
+	require.NoError(t, lifecycle.scope("CustomIntField", nil))
+	assert.NotContains(t, lifecycle.domainScopes, "CustomIntField")
+	assert.EqualError(t, lifecycle.scope("WorkflowID", []string{"domain"}), "WorkflowID is reserved by system")
+}
+
+func TestParseSearchAttributeMigrations(t *testing.T) {
+	migrations, err := parseSearchAttributeMigrations([]string{"Old:New"}, []string{"Removed"})
+	require.NoError(t, err)
+	assert.Equal(t, []es.SearchAttributeMigration{
+		{From: "Old", To: "New"},
+		{From: "Removed"},
+	}, migrations)
+
+	_, err = parseSearchAttributeMigrations([]string{"Old"}, nil)
+	assert.EqualError(t, err, `invalid rename "Old", expected Old:New`)
+	_, err = parseSearchAttributeMigrations(nil, []string{"StartTime"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/uber/cadence/client/frontend"
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/service/worker/workercommon"
+)
+
+const unknownSystemWorkflowPhase = "unknown"
+
+var defaultSystemWorkflowDomains = []string{common.SystemLocalDomainName, common.BatcherLocalDomainName}
+
+type (
+	// SystemWorkflowRow is a running system workflow with its progress
+	SystemWorkflowRow struct {
+		Domain       string                 `header:"Domain" json:"domain"`
+		WorkflowType string                 `header:"Workflow Type" json:"workflowType"`
+		WorkflowID   string                 `header:"Workflow ID" json:"workflowID"`
+		RunID        string                 `header:"Run ID" json:"runID"`
+		StartTime    time.Time              `header:"Start Time" json:"startTime"`
+		Phase        string                 `header:"Phase" json:"phase"`
+		Done         string                 `header:"Done" json:"-"`
+		Failed       int64                  `header:"Failed" json:"-"`
+		ETA          string                 `header:"ETA" json:"-"`
+		Progress     *workercommon.Progress `json:"progress,omitempty"`
+	}
+
+	// SystemWorkflowDescription is the progress of a system workflow
+	SystemWorkflowDescription struct {
+		Domain     string
+		WorkflowID string
+		RunID      string
+		Percent    float64
+		*workercommon.Progress
+	}
+)
+
+// AdminListSystemWorkflows lists the running system workflows with their progress
+func AdminListSystemWorkflows(c *cli.Context) {
+	client := cFactory.ServerFrontendClient(c)
+	domains := c.StringSlice(FlagDomain)
+	if len(domains) == 0 {
+		domains = defaultSystemWorkflowDomains
+	}
+
+	var rows []SystemWorkflowRow
+	for _, domain := range domains {
+		for _, info := range listOpenSystemWorkflows(c, client, domain) {
+			// workflows without the progress query are listed with an unknown phase
+			progress, _ := querySystemWorkflowProgress(c, client, domain, info.Execution)
+			rows = append(rows, newSystemWorkflowRow(domain, info, progress))
+		}
+	}
+	Render(c, rows, RenderOptions{DefaultTemplate: templateTable, Color: true, Border: true})
+}
+
+// AdminDescribeSystemWorkflow describes the progress of a system workflow
+func AdminDescribeSystemWorkflow(c *cli.Context) {
+	client := cFactory.ServerFrontendClient(c)
+	domain := c.String(FlagDomain)
+	execution := &types.WorkflowExecution{
+		WorkflowID: getRequiredOption(c, FlagWorkflowID),
+		RunID:      c.String(FlagRunID),
+	}
+
+	progress, err := querySystemWorkflowProgress(c, client, domain, execution)
+	if err != nil {
+		ErrorAndExit("Failed to query system workflow progress.", err)
+		return
+	}
+	prettyPrintJSONObject(&SystemWorkflowDescription{
+		Domain:     domain,
+		WorkflowID: execution.WorkflowID,
+		RunID:      execution.RunID,
+		Percent:    progress.Percent(),
+		Progress:   progress,
+	})
+}
+
+func listOpenSystemWorkflows(c *cli.Context, client frontend.Client, domain string) []*types.WorkflowExecutionInfo {
+	var executions []*types.WorkflowExecutionInfo
+	request := &types.ListOpenWorkflowExecutionsRequest{
+		Domain:          domain,
+		MaximumPageSize: int32(c.Int(FlagPageSize)),
+		StartTimeFilter: &types.StartTimeFilter{
+			EarliestTime: common.Int64Ptr(0),
+			LatestTime:   common.Int64Ptr(time.Now().UnixNano()),
+		},
+	}
+	if workflowType := c.String(FlagWorkflowType); workflowType != "" {
+		request.TypeFilter = &types.WorkflowTypeFilter{Name: workflowType}
+	}
+	for {
+		ctx, cancel := newContext(c)
+		response, err := client.ListOpenWorkflowExecutions(ctx, request)
+		cancel()
+		if err != nil {
+			ErrorAndExit(fmt.Sprintf("Failed to list open workflows of domain %v.", domain), err)
+			return executions
+		}
+		executions = append(executions, response.Executions...)
+		if len(response.NextPageToken) == 0 {
+			return executions
+		}
+		request.NextPageToken = response.NextPageToken
+	}
+}
+
+func querySystemWorkflowProgress(
+	c *cli.Context,
+	client frontend.Client,
+	domain string,
+	execution *types.WorkflowExecution,
+) (*workercommon.Progress, error) {
+	ctx, cancel := newContext(c)
+	defer cancel()
+	response, err := client.QueryWorkflow(ctx, &types.QueryWorkflowRequest{
+		Domain:    domain,
+		Execution: execution,
+		Query: &types.WorkflowQuery{
+			QueryType: workercommon.ProgressQueryType,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if response.QueryRejected != nil {
+		return nil, fmt.Errorf("query was rejected, workflow is in state: %v", queryRejectedState(response.QueryRejected))
+	}
+	var progress workercommon.Progress
+	if err := json.Unmarshal(response.QueryResult, &progress); err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
+
+func newSystemWorkflowRow(domain string, info *types.WorkflowExecutionInfo, progress *workercommon.Progress) SystemWorkflowRow {
+	row := SystemWorkflowRow{
+		Domain:       domain,
+		WorkflowType: info.GetType().GetName(),
+		WorkflowID:   info.GetExecution().GetWorkflowID(),
+		RunID:        info.GetExecution().GetRunID(),
+		StartTime:    time.Unix(0, info.GetStartTime()),
+		Phase:        unknownSystemWorkflowPhase,
+		Progress:     progress,
+	}
+	if progress == nil {
+		return row
+	}
+	row.Phase = progress.Phase
+	row.Failed = progress.Failed
+	if progress.Total > 0 {
+		row.Done = fmt.Sprintf("%d/%d (%.1f%%)", progress.Processed, progress.Total, progress.Percent())
+	} else {
+		row.Done = fmt.Sprintf("%d", progress.Processed)
+	}
+	if progress.ETA != nil {
+		row.ETA = progress.ETA.Format(defaultDateTimeFormat)
+	}
+	return row
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/service/worker/workercommon"
+)
+
+func TestNewSystemWorkflowRow(t *testing.T) {
+	info := &types.WorkflowExecutionInfo{
+		Execution: &types.WorkflowExecution{WorkflowID: "wid", RunID: "rid"},
+		Type:      &types.WorkflowType{Name: "cadence-sys-batch-workflow"},
+		StartTime: common.Int64Ptr(time.Unix(100, 0).UnixNano()),
+	}
+
+	row := newSystemWorkflowRow(common.BatcherLocalDomainName, info, nil)
+	assert.Equal(t, common.BatcherLocalDomainName, row.Domain)
+	assert.Equal(t, "cadence-sys-batch-workflow", row.WorkflowType)
+	assert.Equal(t, "wid", row.WorkflowID)
+	assert.Equal(t, "rid", row.RunID)
+	assert.Equal(t, time.Unix(100, 0), row.StartTime)
+	assert.Equal(t, unknownSystemWorkflowPhase, row.Phase)
+	assert.Empty(t, row.Done)
+
+	eta := time.Unix(200, 0)
+	row = newSystemWorkflowRow(common.BatcherLocalDomainName, info, &workercommon.Progress{
+		Phase:     workercommon.ProgressPhaseRunning,
+		Total:     40,
+		Processed: 10,
+		Failed:    2,
+		ETA:       &eta,
+	})
+	assert.Equal(t, workercommon.ProgressPhaseRunning, row.Phase)
+	assert.Equal(t, "10/40 (25.0%)", row.Done)
+	assert.Equal(t, int64(2), row.Failed)
+	assert.Equal(t, eta.Format(defaultDateTimeFormat), row.ETA)
+
+	row = newSystemWorkflowRow(common.SystemLocalDomainName, info, &workercommon.Progress{Processed: 7})
+	assert.Equal(t, "7", row.Done)
+	assert.Empty(t, row.ETA)
+}
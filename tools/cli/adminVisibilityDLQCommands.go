@@ -0,0 +1,406 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/urfave/cli"
+
+	"github.com/uber/cadence/.gen/go/indexer"
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/codec"
+	"github.com/uber/cadence/common/config"
+	"github.com/uber/cadence/common/log"
+	"github.com/uber/cadence/common/messaging"
+	messagingPersistence "github.com/uber/cadence/common/messaging/persistence"
+	"github.com/uber/cadence/common/metrics"
+	"github.com/uber/cadence/common/persistence"
+)
+
+const (
+	defaultKafkaPort          = "9092"
+	visibilityDLQReadTimeout  = 10 * time.Second
+	visibilityDLQPageSize     = 100
+	nackedVisibilityDLQReason = "nacked by consumer"
+)
+
+type (
+	// VisibilityDLQRow is a message of the visibility DLQ
+	VisibilityDLQRow struct {
+		Partition  int32            `header:"Partition" json:"partition"`
+		Offset     int64            `header:"Offset" json:"offset"`
+		DomainID   string           `header:"Domain ID" json:"domainID"`
+		WorkflowID string           `header:"Workflow ID" json:"workflowID"`
+		RunID      string           `header:"Run ID" json:"runID"`
+		Status     int              `header:"Status" json:"status,omitempty"`
+		Reason     string           `header:"Reason" json:"reason"`
+		FailedAt   time.Time        `header:"Failed At" json:"failedAt"`
+		Message    *indexer.Message `json:"message,omitempty"`
+
+		// payload is the original indexer message
+		payload []byte
+	}
+
+	visibilityDLQFilter struct {
+		domainID   string
+		workflowID string
+		runID      string
+		reason     string
+	}
+)
+
+// AdminReadVisibilityDLQ lists the messages of the visibility DLQ
+func AdminReadVisibilityDLQ(c *cli.Context) {
+	var rows []VisibilityDLQRow
+	if isPersistenceMessaging(c) {
+		rows = readPersistenceVisibilityDLQ(c, getVisibilityQueues(c))
+	} else {
+		rows = readKafkaVisibilityDLQ(c, newVisibilityDLQFilter(c))
+	}
+	Render(c, rows, RenderOptions{DefaultTemplate: templateTable, Color: true, Border: true})
+}
+
+// AdminRedriveVisibilityDLQ publishes the messages of the visibility DLQ
+// back to the visibility topic or queue, to be processed by the indexer again
+func AdminRedriveVisibilityDLQ(c *cli.Context) {
+	if isPersistenceMessaging(c) {
+		adminRedrivePersistenceVisibilityDLQ(c)
+		return
+	}
+
+	visibilityTopic := getRequiredOption(c, FlagVisibilityTopic)
+	rows := readKafkaVisibilityDLQ(c, newVisibilityDLQFilter(c))
+	if !confirmVisibilityDLQRedrive(c, len(rows), "topic "+visibilityTopic) {
+		return
+	}
+
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Producer.Return.Successes = true
+	producer, err := sarama.NewSyncProducer(getKafkaBrokers(c), saramaConfig)
+	if err != nil {
+		ErrorAndExit("Failed to create kafka producer.", err)
+	}
+	defer producer.Close()
+
+	for i, row := range rows {
+		_, _, err := producer.SendMessage(&sarama.ProducerMessage{
+			Topic: visibilityTopic,
+			Key:   sarama.StringEncoder(row.WorkflowID),
+			Value: sarama.ByteEncoder(row.payload),
+		})
+		if err != nil {
+			ErrorAndExit(fmt.Sprintf("Failed to re-drive DLQ message at partition %d offset %d, %d messages were re-driven.", row.Partition, row.Offset, i), err)
+		}
+	}
+	fmt.Printf("%d DLQ messages were re-driven to topic %s.\n", len(rows), visibilityTopic)
+}
+
+func adminRedrivePersistenceVisibilityDLQ(c *cli.Context) {
+	queues := getVisibilityQueues(c)
+	rows := readPersistenceVisibilityDLQ(c, queues)
+	if !confirmVisibilityDLQRedrive(c, len(rows), "the visibility queue") {
+		return
+	}
+
+	producer, err := getVisibilityMessagingClient(c).NewProducer(common.VisibilityAppName)
+	if err != nil {
+		ErrorAndExit("Failed to create visibility queue producer.", err)
+	}
+	count, err := redrivePersistenceVisibilityDLQ(producer, queues, rows)
+	if err != nil {
+		ErrorAndExit(fmt.Sprintf("Failed to re-drive DLQ messages, %d messages were re-driven.", count), err)
+	}
+	fmt.Printf("%d DLQ messages were re-driven to the visibility queue.\n", count)
+}
+
+// confirmVisibilityDLQRedrive returns whether the messages should be re-driven,
+// which is not the case if there are none or it is a dry run
+func confirmVisibilityDLQRedrive(c *cli.Context, count int, target string) bool {
+	if count == 0 {
+		fmt.Println("No DLQ message matches the filters.")
+		return false
+	}
+	if c.Bool(FlagDryRun) {
+		fmt.Printf("%d DLQ messages would be re-driven to %s.\n", count, target)
+		return false
+	}
+	prompt(fmt.Sprintf("Re-drive %d DLQ messages to %s? (Y/N)", count, target))
+	return true
+}
+
+// readKafkaVisibilityDLQ reads every partition of the kafka DLQ topic up to its current end
+func readKafkaVisibilityDLQ(c *cli.Context, filter *visibilityDLQFilter) []VisibilityDLQRow {
+	topic := getRequiredOption(c, FlagTopic)
+	maxCount := c.Int(FlagMaxMessageCount)
+	startOffset := c.Int64(FlagStartOffset)
+
+	client, err := sarama.NewClient(getKafkaBrokers(c), sarama.NewConfig())
+	if err != nil {
+		ErrorAndExit("Failed to create kafka client.", err)
+	}
+	defer client.Close()
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		ErrorAndExit("Failed to create kafka consumer.", err)
+	}
+	defer consumer.Close()
+
+	partitions, err := client.Partitions(topic)
+	if err != nil {
+		ErrorAndExit(fmt.Sprintf("Failed to get partitions of topic %s.", topic), err)
+	}
+
+	var rows []VisibilityDLQRow
+	for _, partition := range partitions {
+		endOffset, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			ErrorAndExit(fmt.Sprintf("Failed to get end offset of partition %d.", partition), err)
+		}
+		offset := startOffset
+		if offset < 0 {
+			if offset, err = client.GetOffset(topic, partition, sarama.OffsetOldest); err != nil {
+				ErrorAndExit(fmt.Sprintf("Failed to get start offset of partition %d.", partition), err)
+			}
+		}
+		if offset >= endOffset {
+			continue
+		}
+
+		partitionConsumer, err := consumer.ConsumePartition(topic, partition, offset)
+		if err != nil {
+			ErrorAndExit(fmt.Sprintf("Failed to consume partition %d.", partition), err)
+		}
+		for done := false; !done; {
+			select {
+			case msg := <-partitionConsumer.Messages():
+				row := newVisibilityDLQRow(msg.Partition, msg.Offset, msg.Value)
+				if filter.matches(row) {
+					rows = append(rows, *row)
+				}
+				done = msg.Offset >= endOffset-1 || (maxCount > 0 && len(rows) >= maxCount)
+			case <-time.After(visibilityDLQReadTimeout):
+				done = true
+			}
+		}
+		partitionConsumer.Close()
+
+		if maxCount > 0 && len(rows) >= maxCount {
+			break
+		}
+	}
+	return rows
+}
+
+// newVisibilityDLQRow decodes a DLQ message. Messages published by the indexer carry
+// the failure reason, messages nacked by the consumer are the original indexer messages.
+func newVisibilityDLQRow(partition int32, offset int64, value []byte) *VisibilityDLQRow {
+	row := &VisibilityDLQRow{
+		Partition: partition,
+		Offset:    offset,
+		Reason:    nackedVisibilityDLQReason,
+		payload:   value,
+	}
+	if dlqMsg, err := messaging.DecodeDLQMessage(value); err == nil {
+		row.DomainID = dlqMsg.DomainID
+		row.WorkflowID = dlqMsg.WorkflowID
+		row.RunID = dlqMsg.RunID
+		row.Status = dlqMsg.Status
+		row.Reason = dlqMsg.Reason
+		row.FailedAt = dlqMsg.FailedAt
+		row.payload = dlqMsg.Payload
+	}
+
+	var msg indexer.Message
+	if err := codec.NewThriftRWEncoder().Decode(row.payload, &msg); err == nil {
+		row.Message = &msg
+		if row.WorkflowID == "" {
+			row.DomainID = msg.GetDomainID()
+			row.WorkflowID = msg.GetWorkflowID()
+			row.RunID = msg.GetRunID()
+		}
+	}
+	return row
+}
+
+func readPersistenceVisibilityDLQ(c *cli.Context, queues []persistence.QueueManager) []VisibilityDLQRow {
+	rows, err := readPersistenceQueueDLQ(queues, c.Int64(FlagStartOffset), c.Int(FlagMaxMessageCount), newVisibilityDLQFilter(c))
+	if err != nil {
+		ErrorAndExit("Failed to read DLQ of the visibility queue.", err)
+	}
+	return rows
+}
+
+// readPersistenceQueueDLQ reads the DLQ of every partition of the persistence queue
+func readPersistenceQueueDLQ(
+	queues []persistence.QueueManager,
+	startOffset int64,
+	maxCount int,
+	filter *visibilityDLQFilter,
+) ([]VisibilityDLQRow, error) {
+	// the first message ID of a DLQ read is exclusive
+	firstMessageID := startOffset - 1
+	if startOffset < 0 {
+		firstMessageID = -1
+	}
+
+	var rows []VisibilityDLQRow
+	for partition, queue := range queues {
+		var pageToken []byte
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), visibilityDLQReadTimeout)
+			messages, nextPageToken, err := queue.ReadMessagesFromDLQ(ctx, firstMessageID, math.MaxInt64, visibilityDLQPageSize, pageToken)
+			cancel()
+			if err != nil {
+				return nil, fmt.Errorf("partition %d: %w", partition, err)
+			}
+			for _, message := range messages {
+				row := newVisibilityDLQRow(int32(partition), message.ID, message.Payload)
+				if filter.matches(row) {
+					rows = append(rows, *row)
+				}
+				if maxCount > 0 && len(rows) >= maxCount {
+					return rows, nil
+				}
+			}
+			if len(nextPageToken) == 0 {
+				break
+			}
+			pageToken = nextPageToken
+		}
+	}
+	return rows, nil
+}
+
+// redrivePersistenceVisibilityDLQ publishes the messages back to the visibility queue
+// and deletes them from the DLQ, it returns the number of re-driven messages
+func redrivePersistenceVisibilityDLQ(
+	producer messaging.Producer,
+	queues []persistence.QueueManager,
+	rows []VisibilityDLQRow,
+) (int, error) {
+	for i, row := range rows {
+		if row.Message == nil {
+			return i, fmt.Errorf("DLQ message at partition %d offset %d is not an indexer message", row.Partition, row.Offset)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), visibilityDLQReadTimeout)
+		err := producer.Publish(ctx, row.Message)
+		if err == nil {
+			err = queues[row.Partition].DeleteMessageFromDLQ(ctx, row.Offset)
+		}
+		cancel()
+		if err != nil {
+			return i, fmt.Errorf("DLQ message at partition %d offset %d: %w", row.Partition, row.Offset, err)
+		}
+	}
+	return len(rows), nil
+}
+
+// isPersistenceMessaging returns whether the visibility messages go through the persistence queue
+func isPersistenceMessaging(c *cli.Context) bool {
+	switch messagingType := c.String(FlagMessagingType); messagingType {
+	case config.MessagingTypeKafka:
+		return false
+	case config.MessagingTypePersistence:
+		return true
+	default:
+		ErrorAndExit(fmt.Sprintf("Unknown messaging type %v, must be %v or %v.", messagingType, config.MessagingTypeKafka, config.MessagingTypePersistence), nil)
+		return false
+	}
+}
+
+// getPersistenceMessagingConfig returns the persistence messaging config of the server config
+func getPersistenceMessagingConfig(c *cli.Context) *config.PersistenceMessaging {
+	cfg, err := cFactory.ServerConfig(c)
+	if err != nil {
+		ErrorAndExit("Failed to load server config, it is needed for the layout of the visibility queue.", err)
+	}
+	cfg.Messaging.FillDefaults()
+	if !cfg.Messaging.IsPersistence() {
+		ErrorAndExit(fmt.Sprintf("The messaging type of the server config is %v, not %v.", cfg.Messaging.Type, config.MessagingTypePersistence), nil)
+	}
+	return &cfg.Messaging.Persistence
+}
+
+func getVisibilityMessagingClient(c *cli.Context) messaging.Client {
+	return messagingPersistence.NewPersistenceClient(
+		getPersistenceMessagingConfig(c),
+		getPersistenceFactory(c).NewMessagingQueueManager,
+		nil,
+		metrics.NewNoopMetricsClient(),
+		log.NewNoop(),
+	)
+}
+
+// getVisibilityQueues returns the queue manager of every partition of the visibility queue
+func getVisibilityQueues(c *cli.Context) []persistence.QueueManager {
+	queueConfig, err := getPersistenceMessagingConfig(c).GetQueueForApplication(common.VisibilityAppName)
+	if err != nil {
+		ErrorAndExit("Failed to find the visibility queue.", err)
+	}
+
+	queues := make([]persistence.QueueManager, queueConfig.Partitions)
+	for partition := range queues {
+		queueType := messagingPersistence.GetQueueType(queueConfig.QueueID, partition)
+		if queues[partition], err = getPersistenceFactory(c).NewMessagingQueueManager(queueType); err != nil {
+			ErrorAndExit(fmt.Sprintf("Failed to create queue manager of partition %d.", partition), err)
+		}
+	}
+	return queues
+}
+
+func newVisibilityDLQFilter(c *cli.Context) *visibilityDLQFilter {
+	return &visibilityDLQFilter{
+		domainID:   c.String(FlagDomainID),
+		workflowID: c.String(FlagWorkflowID),
+		runID:      c.String(FlagRunID),
+		reason:     c.String(FlagReason),
+	}
+}
+
+func (f *visibilityDLQFilter) matches(row *VisibilityDLQRow) bool {
+	if f.domainID != "" && f.domainID != row.DomainID {
+		return false
+	}
+	if f.workflowID != "" && f.workflowID != row.WorkflowID {
+		return false
+	}
+	if f.runID != "" && f.runID != row.RunID {
+		return false
+	}
+	return f.reason == "" || strings.Contains(row.Reason, f.reason)
+}
+
+func getKafkaBrokers(c *cli.Context) []string {
+	brokers := strings.Split(getRequiredOption(c, FlagBrokers), ",")
+	for i := range brokers {
+		brokers[i] = strings.TrimSpace(brokers[i])
+		if !strings.Contains(brokers[i], ":") {
+			brokers[i] += ":" + defaultKafkaPort
+		}
+	}
+	return brokers
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cli
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/.gen/go/indexer"
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/codec"
+	"github.com/uber/cadence/common/messaging"
+	"github.com/uber/cadence/common/persistence"
+)
+
+func newTestIndexerMessagePayload(t *testing.T) []byte {
+	payload, err := codec.NewThriftRWEncoder().Encode(&indexer.Message{
+		DomainID:   common.StringPtr("domain-id"),
+		WorkflowID: common.StringPtr("workflow-id"),
+		RunID:      common.StringPtr("run-id"),
+	})
+	require.NoError(t, err)
+	return payload
+}
+
+func TestNewVisibilityDLQRow_DLQMessage(t *testing.T) {
+	value, err := (&messaging.DLQMessage{
+		Reason:     "mapping conflict: mapper_parsing_exception",
+		Status:     400,
+		DomainID:   "domain-id",
+		WorkflowID: "workflow-id",
+		RunID:      "run-id",
+		Payload:    newTestIndexerMessagePayload(t),
+	}).Encode()
+	require.NoError(t, err)
+
+	row := newVisibilityDLQRow(1, 2, value)
+	assert.Equal(t, int32(1), row.Partition)
+	assert.Equal(t, int64(2), row.Offset)
+	assert.Equal(t, "domain-id", row.DomainID)
+	assert.Equal(t, "workflow-id", row.WorkflowID)
+	assert.Equal(t, "run-id", row.RunID)
+	assert.Equal(t, 400, row.Status)
+	assert.Equal(t, "mapping conflict: mapper_parsing_exception", row.Reason)
+	require.NotNil(t, row.Message)
+	assert.Equal(t, "workflow-id", row.Message.GetWorkflowID())
+}
+
+func TestNewVisibilityDLQRow_NackedMessage(t *testing.T) {
+	payload := newTestIndexerMessagePayload(t)
+
+	row := newVisibilityDLQRow(1, 2, payload)
+	assert.Equal(t, nackedVisibilityDLQReason, row.Reason)
+	assert.Equal(t, "domain-id", row.DomainID)
+	assert.Equal(t, "workflow-id", row.WorkflowID)
+	assert.Equal(t, "run-id", row.RunID)
+	assert.Equal(t, payload, row.payload)
+}
+
+func TestVisibilityDLQFilter(t *testing.T) {
+	row := &VisibilityDLQRow{
+		DomainID:   "domain-id",
+		WorkflowID: "workflow-id",
+		RunID:      "run-id",
+		Reason:     "mapping conflict: mapper_parsing_exception",
+	}
+
+	assert.True(t, (&visibilityDLQFilter{}).matches(row))
+	assert.True(t, (&visibilityDLQFilter{domainID: "domain-id", workflowID: "workflow-id"}).matches(row))
+	assert.True(t, (&visibilityDLQFilter{runID: "run-id", reason: "mapping conflict"}).matches(row))
+	assert.False(t, (&visibilityDLQFilter{domainID: "other-domain"}).matches(row))
+	assert.False(t, (&visibilityDLQFilter{workflowID: "other-workflow"}).matches(row))
+	assert.False(t, (&visibilityDLQFilter{runID: "other-run"}).matches(row))
+	assert.False(t, (&visibilityDLQFilter{reason: "timeout"}).matches(row))
+}
+
+func TestReadPersistenceQueueDLQ(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	payload := newTestIndexerMessagePayload(t)
+	queue0 := persistence.NewMockQueueManager(ctrl)
+	queue0.EXPECT().ReadMessagesFromDLQ(gomock.Any(), int64(4), int64(math.MaxInt64), visibilityDLQPageSize, nil).
+		Return([]*persistence.QueueMessage{{ID: 5, Payload: payload}}, []byte("token"), nil)
+	queue0.EXPECT().ReadMessagesFromDLQ(gomock.Any(), int64(4), int64(math.MaxInt64), visibilityDLQPageSize, []byte("token")).
+		Return([]*persistence.QueueMessage{{ID: 6, Payload: []byte("not an indexer message")}}, nil, nil)
+	queue1 := persistence.NewMockQueueManager(ctrl)
+	queue1.EXPECT().ReadMessagesFromDLQ(gomock.Any(), int64(4), int64(math.MaxInt64), visibilityDLQPageSize, nil).
+		Return([]*persistence.QueueMessage{{ID: 7, Payload: payload}}, nil, nil)
+
+	rows, err := readPersistenceQueueDLQ([]persistence.QueueManager{queue0, queue1}, 5, 0, &visibilityDLQFilter{workflowID: "workflow-id"})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, int32(0), rows[0].Partition)
+	assert.Equal(t, int64(5), rows[0].Offset)
+	assert.Equal(t, int32(1), rows[1].Partition)
+	assert.Equal(t, int64(7), rows[1].Offset)
+}
+
+func TestRedrivePersistenceVisibilityDLQ(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rows := []VisibilityDLQRow{
+		*newVisibilityDLQRow(1, 7, newTestIndexerMessagePayload(t)),
+		*newVisibilityDLQRow(1, 8, []byte("not an indexer message")),
+	}
+	queue := persistence.NewMockQueueManager(ctrl)
+	queue.EXPECT().DeleteMessageFromDLQ(gomock.Any(), int64(7)).Return(nil)
+	producer := &fakeVisibilityProducer{}
+
+	count, err := redrivePersistenceVisibilityDLQ(producer, []persistence.QueueManager{nil, queue}, rows)
+	assert.Error(t, err)
+	assert.Equal(t, 1, count)
+	require.Len(t, producer.published, 1)
+	assert.Equal(t, "workflow-id", producer.published[0].(*indexer.Message).GetWorkflowID())
+}
+
+type fakeVisibilityProducer struct {
+	published []interface{}
+}
+
+func (p *fakeVisibilityProducer) Publish(_ context.Context, message interface{}) error {
+	p.published = append(p.published, message)
+	return nil
+}
@@ -79,7 +79,43 @@ func NewCliApp() *cli.App {
 			Usage:  "optional argument for transport protocol format, either 'grpc' or 'tchannel'. Defaults to tchannel if not provided",
 			EnvVar: "CADENCE_CLI_TRANSPORT_PROTOCOL",
 		},
+		cli.StringFlag{
+			Name:   FlagTLSCertFile,
+			Usage:  "optional client certificate file for TLS, only supported with grpc transport",
+			EnvVar: "CADENCE_CLI_TLS_CERT_FILE",
+		},
+		cli.StringFlag{
+			Name:   FlagTLSKeyFile,
+			Usage:  "optional client key file for TLS, only supported with grpc transport",
+			EnvVar: "CADENCE_CLI_TLS_KEY_FILE",
+		},
+		cli.StringFlag{
+			Name:   FlagTLSCaFile,
+			Usage:  "optional CA file to verify the frontend certificate, only supported with grpc transport",
+			EnvVar: "CADENCE_CLI_TLS_CA_FILE",
+		},
+		cli.StringFlag{
+			Name:   FlagTLSServerName,
+			Usage:  "optional server name to verify the frontend certificate against",
+			EnvVar: "CADENCE_CLI_TLS_SERVER_NAME",
+		},
+		cli.BoolFlag{
+			Name:   FlagTLSDisableHostVerification,
+			Usage:  "skip verification of the frontend certificate",
+			EnvVar: "CADENCE_CLI_TLS_DISABLE_HOST_VERIFICATION",
+		},
+		cli.StringFlag{
+			Name:   FlagCLIConfig,
+			Usage:  "optional path of the CLI config file holding named contexts. Defaults to ~/.cadence/config.yaml",
+			EnvVar: "CADENCE_CLI_CONFIG",
+		},
+		cli.StringFlag{
+			Name:   FlagCLIContext,
+			Usage:  "optional context from the CLI config file to use instead of the active one. Flags and environment variables take precedence over context settings",
+			EnvVar: "CADENCE_CLI_CONTEXT",
+		},
 	}
+	app.Before = applyCLIContext
 	app.Commands = []cli.Command{
 		{
 			Name:        "domain",
@@ -176,6 +212,12 @@ func NewCliApp() *cli.App {
 					Usage:       "Run admin operation on config store",
 					Subcommands: newAdminConfigStoreCommands(),
 				},
+				{
+					Name:        "system-workflows",
+					Aliases:     []string{"sw"},
+					Usage:       "Run admin operation on system workflows",
+					Subcommands: newAdminSystemWorkflowCommands(),
+				},
 			},
 		},
 		{
@@ -184,6 +226,12 @@ func NewCliApp() *cli.App {
 			Usage:       "Operate cadence cluster",
 			Subcommands: newClusterCommands(),
 		},
+		{
+			Name:        "context",
+			Aliases:     []string{"ctx"},
+			Usage:       "Manage named connection contexts in the CLI config file",
+			Subcommands: newCLIContextCommands(),
+		},
 	}
 
 	// set builder if not customized
@@ -21,6 +21,11 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -31,12 +36,21 @@ import (
 	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/suite"
 	"github.com/urfave/cli"
+	"go.uber.org/yarpc"
 
 	"github.com/uber/cadence/client/admin"
 	"github.com/uber/cadence/client/frontend"
 	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/config"
+	"github.com/uber/cadence/common/reconciliation/store"
 	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/service/worker/archiver"
+	"github.com/uber/cadence/service/worker/domaindeletion"
+	"github.com/uber/cadence/service/worker/scanner/executions"
+	"github.com/uber/cadence/service/worker/scanner/gc"
+	"github.com/uber/cadence/service/worker/scanner/shardscanner"
+	"github.com/uber/cadence/service/worker/scanner/tasks"
+	"github.com/uber/cadence/service/worker/workercommon"
 )
 
 type cliAppSuite struct {
@@ -83,6 +97,8 @@ func TestCLIAppSuite(t *testing.T) {
 
 func (s *cliAppSuite) SetupSuite() {
 	s.app = NewCliApp()
+	// keep a developer's own CLI contexts from leaking into the tests
+	os.Setenv("CADENCE_CLI_CONFIG", filepath.Join(os.TempDir(), "cadence-cli-test-"+uuid.New(), "config.yaml"))
 }
 
 func (s *cliAppSuite) SetupTest() {
@@ -259,6 +275,58 @@ func (s *cliAppSuite) TestDomainDescribe() {
 	s.Nil(err)
 }
 
+func (s *cliAppSuite) TestDomainDescribe_Limits() {
+	resp := &types.DescribeDomainResponse{
+		DomainInfo: describeDomainResponseServer.DomainInfo,
+		Configuration: &types.DomainConfiguration{
+			WorkflowExecutionRetentionPeriodInDays: 3,
+			Limits:                                 &types.DomainLimits{UserRPS: common.Int32Ptr(100), HistoryCountLimit: common.Int64Ptr(0)},
+		},
+		ReplicationConfiguration: describeDomainResponseServer.ReplicationConfiguration,
+	}
+	s.serverFrontendClient.EXPECT().DescribeDomain(gomock.Any(), gomock.Any()).Return(resp, nil)
+	err := s.app.Run([]string{"", "--do", domainName, "domain", "describe"})
+	s.Nil(err)
+	s.Equal([]DomainLimitRow{{Limit: FlagUserRPSLimit, Value: 100}}, newDomainRow(resp).Limits)
+}
+
+func (s *cliAppSuite) TestDomainDescribe_FromCLIContext() {
+	dir, err := ioutil.TempDir("", "cadence-cli-config")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+	configFile := filepath.Join(dir, "config.yaml")
+
+	err = s.app.Run([]string{"", "--cli_config", configFile, "context", "add", "--name", "staging", "--address", "127.0.0.1:7933", "--domain", "context-domain"})
+	s.Nil(err)
+	err = s.app.Run([]string{"", "--cli_config", configFile, "context", "add", "--name", "prod", "--address", "127.0.0.1:7933", "--domain", "prod-domain"})
+	s.Nil(err)
+	cfg, err := loadCLIConfig(configFile)
+	s.NoError(err)
+	s.Equal("staging", cfg.CurrentContext)
+	s.Len(cfg.Contexts, 2)
+
+	expectDomain := func(domain string) {
+		s.serverFrontendClient.EXPECT().DescribeDomain(gomock.Any(), &types.DescribeDomainRequest{Name: &domain}).Return(describeDomainResponseServer, nil)
+	}
+	expectDomain("context-domain")
+	s.Nil(s.app.Run([]string{"", "--cli_config", configFile, "domain", "describe"}))
+	expectDomain(domainName)
+	s.Nil(s.app.Run([]string{"", "--cli_config", configFile, "--do", domainName, "domain", "describe"}))
+	expectDomain("prod-domain")
+	s.Nil(s.app.Run([]string{"", "--cli_config", configFile, "--context", "prod", "domain", "describe"}))
+
+	s.Nil(s.app.Run([]string{"", "--cli_config", configFile, "context", "use", "--name", "prod"}))
+	expectDomain("prod-domain")
+	s.Nil(s.app.Run([]string{"", "--cli_config", configFile, "domain", "describe"}))
+
+	s.Nil(s.app.Run([]string{"", "--cli_config", configFile, "context", "remove", "--name", "prod"}))
+	cfg, err = loadCLIConfig(configFile)
+	s.NoError(err)
+	s.Empty(cfg.CurrentContext)
+	s.Len(cfg.Contexts, 1)
+	s.Error(s.app.Run([]string{"", "--cli_config", configFile, "--context", "prod", "domain", "describe"}))
+}
+
 func (s *cliAppSuite) TestDomainDescribe_DomainNotExist() {
 	resp := describeDomainResponseServer
 	s.serverFrontendClient.EXPECT().DescribeDomain(gomock.Any(), gomock.Any()).Return(resp, &types.EntityNotExistsError{})
@@ -306,6 +374,55 @@ func (s *cliAppSuite) TestShowHistory() {
 	s.Nil(err)
 }
 
+func (s *cliAppSuite) TestExportAndImportValidate() {
+	dir, err := ioutil.TempDir("", "cadence-export")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+	exportFile := filepath.Join(dir, "history.jsonl")
+	historyFile := filepath.Join(dir, "history.json")
+
+	resp := &types.GetWorkflowExecutionHistoryResponse{
+		History: &types.History{
+			Events: []*types.HistoryEvent{
+				{
+					ID:        common.FirstEventID,
+					EventType: types.EventTypeWorkflowExecutionStarted.Ptr(),
+					WorkflowExecutionStartedEventAttributes: &types.WorkflowExecutionStartedEventAttributes{
+						WorkflowType: &types.WorkflowType{Name: "TestWorkflow"},
+						TaskList:     &types.TaskList{Name: "taskList"},
+					},
+				},
+			},
+		},
+	}
+	s.serverFrontendClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any()).Return(resp, nil)
+	err = s.app.Run([]string{"", "--do", domainName, "workflow", "export", "-w", "wid", "--of", exportFile})
+	s.Nil(err)
+
+	err = s.app.Run([]string{"", "--do", domainName, "workflow", "import-validate", "--if", exportFile, "--of", historyFile})
+	s.Nil(err)
+	history, err := (&JSONHistorySerializer{}).Deserialize(readFile(s, historyFile))
+	s.NoError(err)
+	s.Equal(resp.History.Events, history.Events)
+}
+
+func readFile(s *cliAppSuite, name string) []byte {
+	data, err := ioutil.ReadFile(name)
+	s.NoError(err)
+	return data
+}
+
+func (s *cliAppSuite) TestDiffWorkflow() {
+	resp := getWorkflowExecutionHistoryResponse
+	s.serverFrontendClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any()).Return(resp, nil).Times(2)
+	describeResp := &types.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &types.WorkflowExecutionInfo{},
+	}
+	s.serverFrontendClient.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any()).Return(describeResp, nil)
+	err := s.app.Run([]string{"", "--do", domainName, "workflow", "diff", "-w", "wid", "-r", "rid", "--other_run_id", "rid2"})
+	s.Nil(err)
+}
+
 func (s *cliAppSuite) TestShowHistoryWithID() {
 	resp := getWorkflowExecutionHistoryResponse
 	s.serverFrontendClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any()).Return(resp, nil)
@@ -458,6 +575,70 @@ func (s *cliAppSuite) TestQueryWorkflow_Failed() {
 	s.Equal(1, errorCode)
 }
 
+func (s *cliAppSuite) TestAdminListSystemWorkflows() {
+	progress, err := json.Marshal(&workercommon.Progress{Phase: workercommon.ProgressPhaseRunning, Total: 10, Processed: 5})
+	s.NoError(err)
+	s.serverFrontendClient.EXPECT().ListOpenWorkflowExecutions(gomock.Any(), gomock.Any()).Return(listOpenWorkflowExecutionsResponse, nil)
+	s.serverFrontendClient.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any()).Return(&types.QueryWorkflowResponse{QueryResult: progress}, nil)
+	err = s.app.Run([]string{"", "admin", "system-workflows", "list", "--domain", common.SystemLocalDomainName})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestAdminDescribeSystemWorkflow() {
+	progress, err := json.Marshal(&workercommon.Progress{Phase: workercommon.ProgressPhaseRunning, Total: 10, Processed: 5})
+	s.NoError(err)
+	s.serverFrontendClient.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, request *types.QueryWorkflowRequest, _ ...yarpc.CallOption) (*types.QueryWorkflowResponse, error) {
+			s.Equal(common.SystemLocalDomainName, request.Domain)
+			s.Equal("wid", request.Execution.WorkflowID)
+			s.Equal(workercommon.ProgressQueryType, request.Query.QueryType)
+			return &types.QueryWorkflowResponse{QueryResult: progress}, nil
+		})
+	err = s.app.Run([]string{"", "admin", "system-workflows", "describe", "-w", "wid"})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestAdminDescribeSystemWorkflow_Failed() {
+	s.serverFrontendClient.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any()).Return(nil, &types.BadRequestError{Message: "unknown query type"})
+	errorCode := s.RunErrorExitCode([]string{"", "admin", "system-workflows", "describe", "-w", "wid"})
+	s.Equal(1, errorCode)
+}
+
+func (s *cliAppSuite) TestAdminDBScan_WorkflowStateStartsScanner() {
+	resp := &types.StartWorkflowExecutionResponse{RunID: uuid.New()}
+	s.serverFrontendClient.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, request *types.StartWorkflowExecutionRequest, _ ...yarpc.CallOption) (*types.StartWorkflowExecutionResponse, error) {
+			s.Equal(common.SystemLocalDomainName, request.Domain)
+			s.Equal(executions.ConcreteExecutionsScannerCLIWFID, request.WorkflowID)
+			s.Equal(executions.ConcreteExecutionsScannerWFTypeName, request.WorkflowType.Name)
+			var params shardscanner.ScannerWorkflowParams
+			s.NoError(json.Unmarshal(request.Input, &params))
+			s.Equal(16, params.Shards.Range.Max)
+			s.Equal(shardscanner.CustomScannerConfig{"CollectionWorkflowState": "true"}, *params.ScannerWorkflowConfigOverwrites.CustomScannerConfig)
+			return resp, nil
+		})
+	err := s.app.Run([]string{"", "admin", "db", "scan", "--scan_type", "ConcreteExecutionType", "--number_of_shards", "16",
+		"--invariant_collection", "CollectionWorkflowState"})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestAdminDBClean_WorkflowStateStartsFixer() {
+	resp := &types.StartWorkflowExecutionResponse{RunID: uuid.New()}
+	s.serverFrontendClient.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, request *types.StartWorkflowExecutionRequest, _ ...yarpc.CallOption) (*types.StartWorkflowExecutionResponse, error) {
+			s.Equal(executions.ConcreteExecutionsFixerCLIWFID, request.WorkflowID)
+			s.Equal(executions.ConcreteExecutionsFixerWFTypeName, request.WorkflowType.Name)
+			var params shardscanner.FixerWorkflowParams
+			s.NoError(json.Unmarshal(request.Input, &params))
+			s.Equal(executions.ConcreteExecutionsScannerCLIWFID, params.ScannerWorkflowWorkflowID)
+			s.Equal(shardscanner.CustomScannerConfig{"CollectionWorkflowState": "true"}, *params.FixerWorkflowConfigOverwrites.CustomFixerConfig)
+			return resp, nil
+		})
+	err := s.app.Run([]string{"", "admin", "db", "clean", "--scan_type", "ConcreteExecutionType",
+		"--invariant_collection", "CollectionWorkflowState"})
+	s.Nil(err)
+}
+
 var (
 	closeStatus = types.WorkflowExecutionCloseStatusCompleted
 
@@ -623,6 +804,85 @@ func (s *cliAppSuite) TestAdminFailover() {
 	s.Nil(err)
 }
 
+func (s *cliAppSuite) TestAdminDBGCExecute() {
+	report, err := json.Marshal(gc.Report{
+		Type:       gc.TypeHistory,
+		ReportOnly: true,
+		Garbage:    1,
+		Keys:       []store.Keys{{UUID: "uuid", MinPage: 0, MaxPage: 0, Extension: store.CorruptedExtension}},
+	})
+	s.NoError(err)
+	s.serverFrontendClient.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any()).Return(&types.QueryWorkflowResponse{QueryResult: report}, nil)
+	s.serverFrontendClient.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, request *types.StartWorkflowExecutionRequest, _ ...yarpc.CallOption) (*types.StartWorkflowExecutionResponse, error) {
+			s.Equal(gc.ExecutorWFTypeName, request.WorkflowType.Name)
+			s.Equal(gc.HistoryScannerTaskListName, request.TaskList.Name)
+			var params gc.ExecutorParams
+			s.NoError(json.Unmarshal(request.Input, &params))
+			s.Equal(gc.TypeHistory, params.Type)
+			s.Len(params.Keys, 1)
+			return &types.StartWorkflowExecutionResponse{RunID: uuid.New()}, nil
+		})
+	err = s.app.Run([]string{"", "admin", "db", "gc-execute", "--gc_type", "history"})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestAdminDBGCExecute_TransferTasks() {
+	s.serverFrontendClient.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, request *types.StartWorkflowExecutionRequest, _ ...yarpc.CallOption) (*types.StartWorkflowExecutionResponse, error) {
+			s.Equal(tasks.TransferFixerWFTypeName, request.WorkflowType.Name)
+			s.Equal(tasks.TransferFixerTaskListName, request.TaskList.Name)
+			var params shardscanner.FixerWorkflowParams
+			s.NoError(json.Unmarshal(request.Input, &params))
+			s.Equal(tasks.TransferScannerWorkflowID, params.ScannerWorkflowWorkflowID)
+			return &types.StartWorkflowExecutionResponse{RunID: uuid.New()}, nil
+		})
+	err := s.app.Run([]string{"", "admin", "db", "gc-execute", "--gc_type", "transfer"})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestAdminDBGCReport_ReplicationTasks() {
+	report, err := json.Marshal(shardscanner.AggregateScanReportResult{EntitiesCount: 10, CorruptedCount: 1})
+	s.NoError(err)
+	s.serverFrontendClient.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, request *types.QueryWorkflowRequest, _ ...yarpc.CallOption) (*types.QueryWorkflowResponse, error) {
+			s.Equal(tasks.ReplicationScannerWorkflowID, request.Execution.WorkflowID)
+			s.Equal(shardscanner.AggregateReportQuery, request.Query.QueryType)
+			return &types.QueryWorkflowResponse{QueryResult: report}, nil
+		})
+	err = s.app.Run([]string{"", "admin", "db", "gc-report", "--gc_type", "replication"})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestAdminDomainArchiveBackfill() {
+	s.serverFrontendClient.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, request *types.StartWorkflowExecutionRequest, _ ...yarpc.CallOption) (*types.StartWorkflowExecutionResponse, error) {
+			s.Equal(archiver.BackfillWorkflowTypeName, request.WorkflowType.Name)
+			s.Equal(archiver.BackfillWorkflowID(domainName), request.WorkflowID)
+			var params archiver.BackfillParams
+			s.NoError(json.Unmarshal(request.Input, &params))
+			s.Equal(archiver.BackfillRequest{DomainName: domainName, RPS: 5, PageSize: 100}, params.Request)
+			return &types.StartWorkflowExecutionResponse{RunID: uuid.New()}, nil
+		})
+	err := s.app.Run([]string{"", "--do", domainName, "admin", "domain", "archive-backfill", "--rps", "5"})
+	s.Nil(err)
+}
+
+func (s *cliAppSuite) TestAdminDeleteDomain() {
+	s.serverFrontendClient.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, request *types.StartWorkflowExecutionRequest, _ ...yarpc.CallOption) (*types.StartWorkflowExecutionResponse, error) {
+			s.Equal(common.SystemLocalDomainName, request.Domain)
+			s.Equal(domaindeletion.WorkflowTypeName, request.WorkflowType.Name)
+			s.Equal(domaindeletion.WorkflowID(domainName), request.WorkflowID)
+			var params domaindeletion.DeletionParams
+			s.NoError(json.Unmarshal(request.Input, &params))
+			s.Equal(domaindeletion.DeletionRequest{DomainName: domainName, RPS: 10, PageSize: 50}, params.Request)
+			return &types.StartWorkflowExecutionResponse{RunID: uuid.New()}, nil
+		})
+	err := s.app.Run([]string{"", "--do", domainName, "admin", "domain", "delete", "--pagesize", "50", "--yes"})
+	s.Nil(err)
+}
+
 func (s *cliAppSuite) TestDescribeTaskList() {
 	resp := describeTaskListResponse
 	s.serverFrontendClient.EXPECT().DescribeTaskList(gomock.Any(), gomock.Any()).Return(resp, nil)
@@ -950,3 +1210,81 @@ func (s *cliAppSuite) TestConvertArray() {
 		s.Nil(res)
 	}
 }
+
+func (s *cliAppSuite) TestAdminValidateDynamicConfig() {
+	dir, err := ioutil.TempDir("", "validate_dynamic_config")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+
+	valid := filepath.Join(dir, "valid.yaml")
+	s.NoError(ioutil.WriteFile(valid, []byte("frontend.rps:\n- value: 1200\n"), 0644))
+	errorCode := s.RunErrorExitCode([]string{"", "admin", "config", "validate", "--input_file", valid})
+	s.Equal(0, errorCode)
+
+	unknownKey := filepath.Join(dir, "unknown_key.yaml")
+	s.NoError(ioutil.WriteFile(unknownKey, []byte("frontend.rpss:\n- value: 1200\n"), 0644))
+	errorCode = s.RunErrorExitCode([]string{"", "admin", "config", "validate", "--input_file", unknownKey})
+	s.Equal(1, errorCode)
+	errorCode = s.RunErrorExitCode([]string{"", "admin", "config", "validate", "--input_file", unknownKey, "--allow_unknown_keys"})
+	s.Equal(0, errorCode)
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	s.NoError(ioutil.WriteFile(invalid, []byte("frontend.rps:\n- value: fast\nfrontend.rpss:\n- value: 1200\n"), 0644))
+	errorCode = s.RunErrorExitCode([]string{"", "admin", "config", "validate", "--input_file", invalid})
+	s.Equal(1, errorCode)
+}
+
+func (s *cliAppSuite) TestAdminUpdateDynamicConfig_DryRun() {
+	s.serverAdminClient.EXPECT().ListDynamicConfig(gomock.Any(), &types.ListDynamicConfigRequest{ConfigName: "frontend.rps"}).
+		Return(&types.ListDynamicConfigResponse{
+			Entries: []*types.DynamicConfigEntry{
+				{
+					Name: "frontend.rps",
+					Values: []*types.DynamicConfigValue{
+						{
+							Value: &types.DataBlob{EncodingType: types.EncodingTypeJSON.Ptr(), Data: []byte("1200")},
+						},
+					},
+				},
+			},
+		}, nil).Times(2)
+	s.serverAdminClient.EXPECT().DescribeCluster(gomock.Any()).
+		Return(&types.DescribeClusterResponse{
+			MembershipInfo: &types.MembershipInfo{
+				Rings: []*types.RingInfo{
+					{Role: "cadence-frontend", Members: []*types.HostInfo{{Identity: "127.0.0.1:7933"}}},
+					{Role: "cadence-history", Members: []*types.HostInfo{{Identity: "127.0.0.1:7934"}}},
+				},
+			},
+		}, nil).Times(2)
+
+	errorCode := s.RunErrorExitCode([]string{"", "admin", "config", "update-dynamic-config", "--dynamic_config_name", "frontend.rps",
+		"--dynamic_config_value", `{"Value":1500}`, "--dry_run"})
+	s.Equal(0, errorCode)
+
+	errorCode = s.RunErrorExitCode([]string{"", "admin", "config", "update-dynamic-config", "--dynamic_config_name", "frontend.rps",
+		"--dynamic_config_value", `{"Value":1500,"Filters":[{"Name":"domainName","Value":"samples-domain"}]}`, "--dry_run"})
+	s.Equal(1, errorCode)
+}
+
+func (s *cliAppSuite) TestGetDynamicConfigChanges() {
+	current := []*cliValue{
+		{Value: float64(1200)},
+		{Value: float64(100), Filters: []*cliFilter{{Name: "clusterName", Value: "cluster0"}}},
+		{Value: float64(200), Filters: []*cliFilter{{Name: "clusterName", Value: "cluster1"}}},
+	}
+	updated := []*cliValue{
+		{Value: float64(1500)},
+		{Value: float64(100), Filters: []*cliFilter{{Name: "clusterName", Value: "cluster0"}}},
+		{Value: float64(300), Filters: []*cliFilter{{Name: "clusterName", Value: "cluster2"}}},
+	}
+	s.Equal([]DynamicConfigChangeRow{
+		{Filters: "{clusterName:cluster0}", CurrentValue: "100", NewValue: "100", Change: "unchanged"},
+		{Filters: "{clusterName:cluster1}", CurrentValue: "200", Change: "removed"},
+		{Filters: "{clusterName:cluster2}", NewValue: "300", Change: "added"},
+		{Filters: "{}", CurrentValue: "1200", NewValue: "1500", Change: "changed"},
+	}, getDynamicConfigChanges(current, updated))
+	s.Equal([]string{"cadence-history"}, getDynamicConfigKeyServices("history.rps"))
+	s.Equal([]string{"cadence-frontend"}, getDynamicConfigKeyServices("admin.errorInjectionRate"))
+	s.Len(getDynamicConfigKeyServices("system.transactionSizeLimit"), 4)
+}
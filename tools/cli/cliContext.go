@@ -0,0 +1,121 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cli
+
+import "github.com/urfave/cli"
+
+func newCLIContextCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:    "list",
+			Aliases: []string{"l"},
+			Usage:   "List contexts in the CLI config file",
+			Flags:   []cli.Flag{getFormatFlag()},
+			Action: func(c *cli.Context) {
+				ListCLIContexts(c)
+			},
+		},
+		{
+			Name:  "use",
+			Usage: "Set the active context",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagNameWithAlias,
+					Usage: "Context name, required",
+				},
+			},
+			Action: func(c *cli.Context) {
+				UseCLIContext(c)
+			},
+		},
+		{
+			Name:  "add",
+			Usage: "Add a context or overwrite an existing one",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagNameWithAlias,
+					Usage: "Context name, required",
+				},
+				cli.StringFlag{
+					Name:  FlagAddress,
+					Usage: "host:port for cadence frontend service, required",
+				},
+				cli.StringFlag{
+					Name:  FlagTransport,
+					Usage: "Transport protocol, either 'grpc' or 'tchannel'",
+				},
+				cli.StringFlag{
+					Name:  FlagDomain,
+					Usage: "Default domain",
+				},
+				cli.StringFlag{
+					Name:  FlagJWT,
+					Usage: "JWT for authorization",
+				},
+				cli.StringFlag{
+					Name:  FlagJWTPrivateKey,
+					Usage: "Private key path to create JWT",
+				},
+				cli.StringFlag{
+					Name:  FlagTLSCertFile,
+					Usage: "Client certificate file for TLS",
+				},
+				cli.StringFlag{
+					Name:  FlagTLSKeyFile,
+					Usage: "Client key file for TLS",
+				},
+				cli.StringFlag{
+					Name:  FlagTLSCaFile,
+					Usage: "CA file to verify the frontend certificate",
+				},
+				cli.StringFlag{
+					Name:  FlagTLSServerName,
+					Usage: "Server name to verify the frontend certificate against",
+				},
+				cli.BoolFlag{
+					Name:  FlagTLSDisableHostVerification,
+					Usage: "Skip verification of the frontend certificate",
+				},
+				cli.BoolFlag{
+					Name:  FlagActivate,
+					Usage: "Also set the new context as the active one",
+				},
+			},
+			Action: func(c *cli.Context) {
+				AddCLIContext(c)
+			},
+		},
+		{
+			Name:    "remove",
+			Aliases: []string{"rm"},
+			Usage:   "Remove a context",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagNameWithAlias,
+					Usage: "Context name, required",
+				},
+			},
+			Action: func(c *cli.Context) {
+				RemoveCLIContext(c)
+			},
+		},
+	}
+}
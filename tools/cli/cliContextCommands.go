@@ -0,0 +1,272 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultCLIConfigDir  = ".cadence"
+	defaultCLIConfigFile = "config.yaml"
+)
+
+type (
+	// CLIConfig is the content of the CLI config file
+	CLIConfig struct {
+		CurrentContext string                 `yaml:"currentContext,omitempty"`
+		Contexts       map[string]*CLIContext `yaml:"contexts,omitempty"`
+	}
+
+	// CLIContext holds the connection settings for one cluster
+	CLIContext struct {
+		Address       string  `yaml:"address"`
+		Transport     string  `yaml:"transport,omitempty"`
+		Domain        string  `yaml:"domain,omitempty"`
+		JWT           string  `yaml:"jwt,omitempty"`
+		JWTPrivateKey string  `yaml:"jwtPrivateKey,omitempty"`
+		TLS           *CLITLS `yaml:"tls,omitempty"`
+	}
+
+	// CLITLS holds the TLS files used to connect to a cluster over grpc
+	CLITLS struct {
+		CertFile                string `yaml:"certFile,omitempty"`
+		KeyFile                 string `yaml:"keyFile,omitempty"`
+		CaFile                  string `yaml:"caFile,omitempty"`
+		ServerName              string `yaml:"serverName,omitempty"`
+		DisableHostVerification bool   `yaml:"disableHostVerification,omitempty"`
+	}
+
+	// CLIContextRow is a presentation layer entity used to render a table of contexts
+	CLIContextRow struct {
+		Current   string `header:"Current"`
+		Name      string `header:"Name"`
+		Address   string `header:"Address"`
+		Transport string `header:"Transport"`
+		Domain    string `header:"Domain"`
+	}
+)
+
+// ListCLIContexts lists the contexts in the CLI config file
+func ListCLIContexts(c *cli.Context) {
+	cfg, _ := loadCLIConfigOrExit(c)
+	names := make([]string, 0, len(cfg.Contexts))
+	for name := range cfg.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	table := []CLIContextRow{}
+	for _, name := range names {
+		cliContext := cfg.Contexts[name]
+		row := CLIContextRow{
+			Name:      name,
+			Address:   cliContext.Address,
+			Transport: cliContext.Transport,
+			Domain:    cliContext.Domain,
+		}
+		if name == cfg.CurrentContext {
+			row.Current = "*"
+		}
+		table = append(table, row)
+	}
+	Render(c, table, RenderOptions{Color: true, DefaultTemplate: templateTable})
+}
+
+// UseCLIContext sets the active context
+func UseCLIContext(c *cli.Context) {
+	name := getRequiredOption(c, FlagName)
+	cfg, path := loadCLIConfigOrExit(c)
+	if _, ok := cfg.Contexts[name]; !ok {
+		ErrorAndExit(fmt.Sprintf("Context %v does not exist.", name), nil)
+	}
+	cfg.CurrentContext = name
+	saveCLIConfigOrExit(cfg, path)
+	fmt.Printf("Switched to context %v\n", name)
+}
+
+// AddCLIContext adds a context to the CLI config file, replacing one with the same name
+func AddCLIContext(c *cli.Context) {
+	name := getRequiredOption(c, FlagName)
+	cliContext := &CLIContext{
+		Address:       getRequiredOption(c, FlagAddress),
+		Transport:     c.String(FlagTransport),
+		Domain:        c.String(FlagDomain),
+		JWT:           c.String(FlagJWT),
+		JWTPrivateKey: c.String(FlagJWTPrivateKey),
+	}
+	if c.IsSet(FlagTLSCertFile) || c.IsSet(FlagTLSKeyFile) || c.IsSet(FlagTLSCaFile) {
+		cliContext.TLS = &CLITLS{
+			CertFile:                c.String(FlagTLSCertFile),
+			KeyFile:                 c.String(FlagTLSKeyFile),
+			CaFile:                  c.String(FlagTLSCaFile),
+			ServerName:              c.String(FlagTLSServerName),
+			DisableHostVerification: c.Bool(FlagTLSDisableHostVerification),
+		}
+	}
+
+	cfg, path := loadCLIConfigOrExit(c)
+	if cfg.Contexts == nil {
+		cfg.Contexts = make(map[string]*CLIContext)
+	}
+	cfg.Contexts[name] = cliContext
+	if c.Bool(FlagActivate) || cfg.CurrentContext == "" {
+		cfg.CurrentContext = name
+	}
+	saveCLIConfigOrExit(cfg, path)
+	fmt.Printf("Context %v saved to %v\n", name, path)
+}
+
+// RemoveCLIContext removes a context from the CLI config file
+func RemoveCLIContext(c *cli.Context) {
+	name := getRequiredOption(c, FlagName)
+	cfg, path := loadCLIConfigOrExit(c)
+	if _, ok := cfg.Contexts[name]; !ok {
+		ErrorAndExit(fmt.Sprintf("Context %v does not exist.", name), nil)
+	}
+	delete(cfg.Contexts, name)
+	if cfg.CurrentContext == name {
+		cfg.CurrentContext = ""
+	}
+	saveCLIConfigOrExit(cfg, path)
+	fmt.Printf("Context %v removed\n", name)
+}
+
+// applyCLIContext fills global options that were not given as flags or
+// environment variables from the selected context, so options resolve in
+// the order flag > env > context
+func applyCLIContext(c *cli.Context) error {
+	if c.Args().First() == "context" || c.Args().First() == "ctx" {
+		// context commands manage the config file and must work even if it points to a missing context
+		return nil
+	}
+	path, err := getCLIConfigPath(c)
+	if err != nil {
+		return err
+	}
+	cfg, err := loadCLIConfig(path)
+	if err != nil {
+		return err
+	}
+	name := cfg.CurrentContext
+	if c.IsSet(FlagCLIContext) {
+		name = c.String(FlagCLIContext)
+	}
+	if name == "" {
+		return nil
+	}
+	cliContext, ok := cfg.Contexts[name]
+	if !ok {
+		return fmt.Errorf("context %v does not exist in %v", name, path)
+	}
+
+	options := map[string]string{
+		FlagAddress:       cliContext.Address,
+		FlagTransport:     cliContext.Transport,
+		FlagDomain:        cliContext.Domain,
+		FlagJWT:           cliContext.JWT,
+		FlagJWTPrivateKey: cliContext.JWTPrivateKey,
+	}
+	if tls := cliContext.TLS; tls != nil {
+		options[FlagTLSCertFile] = tls.CertFile
+		options[FlagTLSKeyFile] = tls.KeyFile
+		options[FlagTLSCaFile] = tls.CaFile
+		options[FlagTLSServerName] = tls.ServerName
+		if tls.DisableHostVerification {
+			options[FlagTLSDisableHostVerification] = strconv.FormatBool(true)
+		}
+	}
+	for flagName, value := range options {
+		if value == "" || c.IsSet(flagName) {
+			continue
+		}
+		if err := c.Set(flagName, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getCLIConfigPath(c *cli.Context) (string, error) {
+	if path := c.GlobalString(FlagCLIConfig); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, defaultCLIConfigDir, defaultCLIConfigFile), nil
+}
+
+// loadCLIConfig reads the CLI config file, a missing file is treated as an empty config
+func loadCLIConfig(path string) (*CLIConfig, error) {
+	cfg := &CLIConfig{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse CLI config %v: %v", path, err)
+	}
+	return cfg, nil
+}
+
+func saveCLIConfig(cfg *CLIConfig, path string) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	// contexts may hold JWTs, so keep the file private to the user
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+func loadCLIConfigOrExit(c *cli.Context) (*CLIConfig, string) {
+	path, err := getCLIConfigPath(c)
+	if err != nil {
+		ErrorAndExit("Failed to locate CLI config file.", err)
+	}
+	cfg, err := loadCLIConfig(path)
+	if err != nil {
+		ErrorAndExit("Failed to load CLI config file.", err)
+	}
+	return cfg, path
+}
+
+func saveCLIConfigOrExit(cfg *CLIConfig, path string) {
+	if err := saveCLIConfig(cfg, path); err != nil {
+		ErrorAndExit("Failed to save CLI config file.", err)
+	}
+}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/uber/cadence/common"
 	"github.com/uber/cadence/common/config"
+	"github.com/uber/cadence/common/domain"
 	"github.com/uber/cadence/common/dynamicconfig"
 	"github.com/uber/cadence/common/log"
 	"github.com/uber/cadence/common/metrics"
@@ -181,6 +182,10 @@ func initializeDomainManager(c *cli.Context) persistence.DomainManager {
 	return domainManager
 }
 
+func initializeDomainAuditLog(c *cli.Context) domain.AuditLog {
+	return domain.NewAuditLog(getPersistenceFactory(c).NewDomainAuditQueueManager)
+}
+
 var persistenceFactory client.Factory
 
 func getPersistenceFactory(c *cli.Context) client.Factory {
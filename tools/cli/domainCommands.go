@@ -257,6 +257,7 @@ func (d *domainCLIImpl) UpdateDomain(c *cli.Context) {
 			BadBinaries:                            binBinaries,
 			Clusters:                               clusters,
 			DeleteBadBinary:                        badBinaryToDelete,
+			Limits:                                 domainLimits(c),
 		}
 	}
 
@@ -397,6 +398,8 @@ HistoryArchivalStatus: {{.HistoryArchivalStatus}}{{with .HistoryArchivalURI}}
 HistoryArchivalURI: {{.}}{{end}}
 VisibilityArchivalStatus: {{.VisibilityArchivalStatus}}{{with .VisibilityArchivalURI}}
 VisibilityArchivalURI: {{.}}{{end}}
+{{with .Limits}}Domain limits (other limits come from dynamic config):
+{{table .}}{{end}}
 {{with .BadBinaries}}Bad binaries to reset:
 {{table .}}{{end}}
 {{with .FailoverInfo}}Graceful failover info:
@@ -453,6 +456,11 @@ type BadBinaryRow struct {
 	Reason    string    `header:"Reason"`
 }
 
+type DomainLimitRow struct {
+	Limit string `header:"Limit"`
+	Value int64  `header:"Value"`
+}
+
 type FailoverInfoRow struct {
 	FailoverVersion     int64     `header:"Failover Version"`
 	StartTime           time.Time `header:"Start Time"`
@@ -477,6 +485,7 @@ type DomainRow struct {
 	HistoryArchivalURI       string               `header:"History Archival URI"`
 	VisibilityArchivalStatus types.ArchivalStatus `header:"Visibility Archival Status"`
 	VisibilityArchivalURI    string               `header:"Visibility Archival URI"`
+	Limits                   []DomainLimitRow
 	BadBinaries              []BadBinaryRow
 	FailoverInfo             *FailoverInfoRow
 }
@@ -498,6 +507,7 @@ func newDomainRow(domain *types.DescribeDomainResponse) DomainRow {
 		HistoryArchivalURI:       domain.Configuration.GetHistoryArchivalURI(),
 		VisibilityArchivalStatus: domain.Configuration.GetVisibilityArchivalStatus(),
 		VisibilityArchivalURI:    domain.Configuration.GetVisibilityArchivalURI(),
+		Limits:                   newDomainLimitRows(domain.Configuration.GetLimits()),
 		BadBinaries:              newBadBinaryRows(domain.Configuration.BadBinaries),
 		FailoverInfo:             newFailoverInfoRow(domain.FailoverInfo),
 	}
@@ -516,6 +526,33 @@ func newFailoverInfoRow(info *types.FailoverInfo) *FailoverInfoRow {
 	}
 }
 
+func newDomainLimitRows(limits *types.DomainLimits) []DomainLimitRow {
+	if limits == nil {
+		return nil
+	}
+	var rows []DomainLimitRow
+	for _, limit := range []struct {
+		name  string
+		value int64
+	}{
+		{FlagUserRPSLimit, int64(limits.GetUserRPS())},
+		{FlagWorkerRPSLimit, int64(limits.GetWorkerRPS())},
+		{FlagVisibilityRPSLimit, int64(limits.GetVisibilityRPS())},
+		{FlagBlobSizeLimit, limits.GetBlobSizeLimit()},
+		{FlagHistorySizeLimit, limits.GetHistorySizeLimit()},
+		{FlagHistoryCountLimit, limits.GetHistoryCountLimit()},
+		{FlagSearchAttributesKeysLimit, int64(limits.GetSearchAttributesNumberOfKeysLimit())},
+		{FlagSearchAttributesValueSizeLimit, limits.GetSearchAttributesSizeOfValueLimit()},
+		{FlagSearchAttributesTotalSizeLimit, limits.GetSearchAttributesTotalSizeLimit()},
+		{FlagMaxExecutionTimeoutLimit, int64(limits.GetMaxExecutionStartToCloseTimeoutSeconds())},
+	} {
+		if limit.value > 0 {
+			rows = append(rows, DomainLimitRow{Limit: limit.name, Value: limit.value})
+		}
+	}
+	return rows
+}
+
 func newBadBinaryRows(bb *types.BadBinaries) []BadBinaryRow {
 	if bb == nil {
 		return nil
@@ -695,6 +732,45 @@ func archivalStatus(c *cli.Context, statusFlagName string) *types.ArchivalStatus
 	return nil
 }
 
+// domainLimits builds the limits to update from the admin domain update flags, nil if none is set
+func domainLimits(c *cli.Context) *types.DomainLimits {
+	var limits types.DomainLimits
+	if c.IsSet(FlagUserRPSLimit) {
+		limits.UserRPS = common.Int32Ptr(int32(c.Int(FlagUserRPSLimit)))
+	}
+	if c.IsSet(FlagWorkerRPSLimit) {
+		limits.WorkerRPS = common.Int32Ptr(int32(c.Int(FlagWorkerRPSLimit)))
+	}
+	if c.IsSet(FlagVisibilityRPSLimit) {
+		limits.VisibilityRPS = common.Int32Ptr(int32(c.Int(FlagVisibilityRPSLimit)))
+	}
+	if c.IsSet(FlagBlobSizeLimit) {
+		limits.BlobSizeLimit = common.Int64Ptr(c.Int64(FlagBlobSizeLimit))
+	}
+	if c.IsSet(FlagHistorySizeLimit) {
+		limits.HistorySizeLimit = common.Int64Ptr(c.Int64(FlagHistorySizeLimit))
+	}
+	if c.IsSet(FlagHistoryCountLimit) {
+		limits.HistoryCountLimit = common.Int64Ptr(c.Int64(FlagHistoryCountLimit))
+	}
+	if c.IsSet(FlagSearchAttributesKeysLimit) {
+		limits.SearchAttributesNumberOfKeysLimit = common.Int32Ptr(int32(c.Int(FlagSearchAttributesKeysLimit)))
+	}
+	if c.IsSet(FlagSearchAttributesValueSizeLimit) {
+		limits.SearchAttributesSizeOfValueLimit = common.Int64Ptr(c.Int64(FlagSearchAttributesValueSizeLimit))
+	}
+	if c.IsSet(FlagSearchAttributesTotalSizeLimit) {
+		limits.SearchAttributesTotalSizeLimit = common.Int64Ptr(c.Int64(FlagSearchAttributesTotalSizeLimit))
+	}
+	if c.IsSet(FlagMaxExecutionTimeoutLimit) {
+		limits.MaxExecutionStartToCloseTimeoutSeconds = common.Int32Ptr(int32(c.Int(FlagMaxExecutionTimeoutLimit)))
+	}
+	if limits == (types.DomainLimits{}) {
+		return nil
+	}
+	return &limits
+}
+
 func clustersToStrings(clusters []*types.ClusterReplicationConfiguration) []string {
 	var res []string
 	for _, cluster := range clusters {
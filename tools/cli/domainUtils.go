@@ -201,6 +201,49 @@ var (
 		getFormatFlag(),
 	}
 
+	domainLimitsFlags = []cli.Flag{
+		cli.IntFlag{
+			Name:  FlagUserRPSLimit,
+			Usage: "Global user RPS limit of the domain, 0 removes the limit",
+		},
+		cli.IntFlag{
+			Name:  FlagWorkerRPSLimit,
+			Usage: "Global worker RPS limit of the domain, 0 removes the limit",
+		},
+		cli.IntFlag{
+			Name:  FlagVisibilityRPSLimit,
+			Usage: "Global visibility RPS limit of the domain, 0 removes the limit",
+		},
+		cli.Int64Flag{
+			Name:  FlagBlobSizeLimit,
+			Usage: "Blob size limit in bytes of the domain, 0 removes the limit",
+		},
+		cli.Int64Flag{
+			Name:  FlagHistorySizeLimit,
+			Usage: "Workflow history size limit in bytes of the domain, 0 removes the limit",
+		},
+		cli.Int64Flag{
+			Name:  FlagHistoryCountLimit,
+			Usage: "Workflow history event count limit of the domain, 0 removes the limit",
+		},
+		cli.IntFlag{
+			Name:  FlagSearchAttributesKeysLimit,
+			Usage: "Max number of search attributes per workflow of the domain, 0 removes the limit",
+		},
+		cli.Int64Flag{
+			Name:  FlagSearchAttributesValueSizeLimit,
+			Usage: "Search attribute value size limit in bytes of the domain, 0 removes the limit",
+		},
+		cli.Int64Flag{
+			Name:  FlagSearchAttributesTotalSizeLimit,
+			Usage: "Total search attributes size limit in bytes of the domain, 0 removes the limit",
+		},
+		cli.IntFlag{
+			Name:  FlagMaxExecutionTimeoutLimit,
+			Usage: "Max workflow execution start to close timeout in seconds of the domain, 0 removes the limit",
+		},
+	}
+
 	adminDomainCommonFlags = getDBFlags()
 
 	adminRegisterDomainFlags = append(
@@ -209,7 +252,7 @@ var (
 	)
 
 	adminUpdateDomainFlags = append(
-		updateDomainFlags,
+		append(updateDomainFlags, domainLimitsFlags...),
 		adminDomainCommonFlags...,
 	)
 
@@ -250,6 +293,7 @@ func initializeAdminDomainHandler(
 		clusterMetadata,
 		initializeArchivalMetadata(configuration, dynamicConfig),
 		initializeArchivalProvider(configuration, clusterMetadata, metricsClient, logger),
+		initializeDomainAuditLog(context),
 	)
 }
 
@@ -273,6 +317,7 @@ func initializeDomainHandler(
 	clusterMetadata cluster.Metadata,
 	archivalMetadata archiver.ArchivalMetadata,
 	archiverProvider provider.ArchiverProvider,
+	auditLog domain.AuditLog,
 ) domain.Handler {
 
 	domainConfig := domain.Config{
@@ -288,6 +333,7 @@ func initializeDomainHandler(
 		initializeDomainReplicator(logger),
 		archivalMetadata,
 		archiverProvider,
+		auditLog,
 		clock.NewRealTimeSource(),
 	)
 }
@@ -385,6 +431,7 @@ func initializeDynamicConfig(
 	close(doneChan)
 	dynamicConfigClient, err := dynamicconfig.NewFileBasedClient(
 		&serviceConfig.DynamicConfig.FileBased,
+		metrics.NewNoopMetricsClient(),
 		logger,
 		doneChan,
 	)
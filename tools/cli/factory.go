@@ -22,6 +22,7 @@ package cli
 
 import (
 	"context"
+	"crypto/tls"
 	"time"
 
 	"go.uber.org/yarpc/transport/grpc"
@@ -31,7 +32,10 @@ import (
 	"github.com/urfave/cli"
 	"go.uber.org/yarpc"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/peer"
+	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/credentials"
 
 	adminv1 "github.com/uber/cadence-idl/go/proto/admin/v1"
 	apiv1 "github.com/uber/cadence-idl/go/proto/api/v1"
@@ -153,7 +157,20 @@ func (b *clientFactory) ensureDispatcher(c *cli.Context) {
 		b.hostPort = addr
 	}
 
+	tlsConfig, err := getTLSConfig(c)
+	if err != nil {
+		b.logger.Fatal("Failed to load TLS config", zap.Error(err))
+	}
+	if tlsConfig != nil && !shouldUseGrpc {
+		b.logger.Fatal("TLS is only supported with grpc transport")
+	}
+
 	outbounds := transport.Outbounds{Unary: grpc.NewTransport().NewSingleOutbound(b.hostPort)}
+	if tlsConfig != nil {
+		t := grpc.NewTransport()
+		dialer := t.NewDialer(grpc.DialerCredentials(credentials.NewTLS(tlsConfig)))
+		outbounds = transport.Outbounds{Unary: t.NewOutbound(peer.NewSingle(hostport.Identify(b.hostPort), dialer))}
+	}
 	if !shouldUseGrpc {
 		ch, err := tchannel.NewChannelTransport(tchannel.ServiceName(cadenceClientName), tchannel.ListenAddr("127.0.0.1:0"))
 		if err != nil {
@@ -190,6 +207,23 @@ func (vm *versionMiddleware) Call(ctx context.Context, request *transport.Reques
 	return out.Call(ctx, request)
 }
 
+func getTLSConfig(c *cli.Context) (*tls.Config, error) {
+	certFile := c.GlobalString(FlagTLSCertFile)
+	keyFile := c.GlobalString(FlagTLSKeyFile)
+	caFile := c.GlobalString(FlagTLSCaFile)
+	if certFile == "" && keyFile == "" && caFile == "" {
+		return nil, nil
+	}
+	return config.TLS{
+		Enabled:                true,
+		CertFile:               certFile,
+		KeyFile:                keyFile,
+		CaFile:                 caFile,
+		ServerName:             c.GlobalString(FlagTLSServerName),
+		EnableHostVerification: !c.GlobalBool(FlagTLSDisableHostVerification),
+	}.ToTLSConfig()
+}
+
 func getJWT(c *cli.Context) string {
 	return c.GlobalString(FlagJWT)
 }
@@ -93,10 +93,12 @@ const (
 	FlagParallism                         = "input_parallism"
 	FlagScanType                          = "scan_type"
 	FlagInvariantCollection               = "invariant_collection"
+	FlagGCType                            = "gc_type"
 	FlagSkipCurrentOpen                   = "skip_current_open"
 	FlagSkipCurrentCompleted              = "skip_current_completed"
 	FlagSkipBaseIsNotCurrent              = "skip_base_is_not_current"
 	FlagDryRun                            = "dry_run"
+	FlagAllowUnknownKeys                  = "allow_unknown_keys"
 	FlagNonDeterministicOnly              = "only_non_deterministic"
 	FlagInputTopic                        = "input_topic"
 	FlagInputTopicWithAlias               = FlagInputTopic + ", it"
@@ -105,6 +107,9 @@ const (
 	FlagInputCluster                      = "input_cluster"
 	FlagStartOffset                       = "start_offset"
 	FlagTopic                             = "topic"
+	FlagBrokers                           = "brokers"
+	FlagVisibilityTopic                   = "visibility_topic"
+	FlagMessagingType                     = "messaging_type"
 	FlagGroup                             = "group"
 	FlagResult                            = "result"
 	FlagIdentity                          = "identity"
@@ -197,6 +202,13 @@ const (
 	FlagMessageTypeWithAlias              = FlagMessageType + ", mt"
 	FlagURL                               = "url"
 	FlagIndex                             = "index"
+	FlagESVersion                         = "es_version"
+	FlagAlias                             = "alias"
+	FlagExistingIndex                     = "existing_index"
+	FlagMaxAge                            = "max_age"
+	FlagMaxSize                           = "max_size"
+	FlagMaxDocs                           = "max_docs"
+	FlagDeleteSource                      = "delete_source"
 	FlagBatchSize                         = "batch_size"
 	FlagBatchSizeWithAlias                = FlagBatchSize + ", bs"
 	FlagMemoKey                           = "memo_key"
@@ -205,6 +217,10 @@ const (
 	FlagSearchAttributesKey               = "search_attr_key"
 	FlagSearchAttributesVal               = "search_attr_value"
 	FlagSearchAttributesType              = "search_attr_type"
+	FlagSearchAttributesTarget            = "search_attr_target"
+	FlagSearchAttributesDomains           = "search_attr_domains"
+	FlagRenameSearchAttribute             = "rename_search_attr"
+	FlagRemoveSearchAttribute             = "remove_search_attr"
 	FlagAddBadBinary                      = "add_bad_binary"
 	FlagRemoveBadBinary                   = "remove_bad_binary"
 	FlagResetType                         = "reset_type"
@@ -292,6 +308,29 @@ const (
 	FlagTransport                         = "transport"
 	FlagTransportWithAlias                = FlagTransport + ", t"
 	FlagFormat                            = "format"
+	FlagOutputDirectory                   = "output_directory"
+	FlagRawHistory                        = "raw_history"
+	FlagCLIConfig                         = "cli_config"
+	FlagCLIContext                        = "context"
+	FlagActivate                          = "activate"
+	FlagInputFormat                       = "input_format"
+	FlagOtherWorkflowID                   = "other_workflow_id"
+	FlagOtherRunID                        = "other_run_id"
+	FlagTLSCertFile                       = "tls_cert_file"
+	FlagTLSKeyFile                        = "tls_key_file"
+	FlagTLSCaFile                         = "tls_ca_file"
+	FlagTLSServerName                     = "tls_server_name"
+	FlagTLSDisableHostVerification        = "tls_disable_host_verification"
+	FlagUserRPSLimit                      = "user_rps_limit"
+	FlagWorkerRPSLimit                    = "worker_rps_limit"
+	FlagVisibilityRPSLimit                = "visibility_rps_limit"
+	FlagBlobSizeLimit                     = "blob_size_limit"
+	FlagHistorySizeLimit                  = "history_size_limit"
+	FlagHistoryCountLimit                 = "history_count_limit"
+	FlagSearchAttributesKeysLimit         = "search_attributes_keys_limit"
+	FlagSearchAttributesValueSizeLimit    = "search_attributes_value_size_limit"
+	FlagSearchAttributesTotalSizeLimit    = "search_attributes_total_size_limit"
+	FlagMaxExecutionTimeoutLimit          = "max_execution_timeout_limit"
 )
 
 var flagsForExecution = []cli.Flag{
@@ -154,7 +154,7 @@ func newWorkflowCommands() []cli.Command {
 		{
 			Name:    "count",
 			Aliases: []string{"cnt"},
-			Usage:   "count number of workflow executions (need to enable Cadence server on ElasticSearch)",
+			Usage:   "count number of workflow executions (need to enable Cadence server on ElasticSearch or SQL visibility)",
 			Flags:   getFlagsForCount(),
 			Action: func(c *cli.Context) {
 				CountWorkflow(c)
@@ -360,6 +360,47 @@ func newWorkflowCommands() []cli.Command {
 			Usage:       "batch operation on a list of workflows from query.",
 			Subcommands: newBatchCommands(),
 		},
+		{
+			Name:  "export",
+			Usage: "stream workflow history to a file or stdout as JSON lines or length-delimited proto",
+			Flags: getFlagsForExport(),
+			Action: func(c *cli.Context) {
+				ExportWorkflow(c)
+			},
+		},
+		{
+			Name:  "import-validate",
+			Usage: "read an exported workflow history and check its event IDs, versions and event references, without running workflow code",
+			Flags: getFlagsForImportValidate(),
+			Action: func(c *cli.Context) {
+				ImportValidate(c)
+			},
+		},
+		{
+			Name:  "diff",
+			Usage: "compare the history of a run with another run or an exported history file and report the first divergence",
+			Flags: getFlagsForDiff(),
+			Action: func(c *cli.Context) {
+				DiffWorkflow(c)
+			},
+		},
+		{
+			Name:  "tui",
+			Usage: "interactive view of workflow history, grouped events and pending work with live updates and signal, query, reset and terminate actions",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  FlagWorkflowIDWithAlias,
+					Usage: "WorkflowID",
+				},
+				cli.StringFlag{
+					Name:  FlagRunIDWithAlias,
+					Usage: "RunID, optional, default to the current/latest RunID",
+				},
+			},
+			Action: func(c *cli.Context) {
+				WorkflowTUI(c)
+			},
+		},
 	}
 }
 
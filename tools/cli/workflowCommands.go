@@ -684,13 +684,22 @@ func queryWorkflowHelper(c *cli.Context, queryType string) {
 	}
 
 	if queryResponse.QueryRejected != nil {
-		fmt.Printf("Query was rejected, workflow is in state: %v\n", *queryResponse.QueryRejected.CloseStatus)
+		fmt.Printf("Query was rejected, workflow is in state: %v\n", queryRejectedState(queryResponse.QueryRejected))
 	} else {
 		// assume it is json encoded
 		fmt.Print(string(queryResponse.QueryResult))
 	}
 }
 
+// queryRejectedState returns the close status a rejected query reported, or
+// "unknown" if the rejection did not carry one
+func queryRejectedState(rejected *types.QueryRejected) string {
+	if rejected.CloseStatus == nil {
+		return "unknown"
+	}
+	return rejected.CloseStatus.String()
+}
+
 // ListWorkflow list workflow executions based on filters
 func ListWorkflow(c *cli.Context) {
 	displayPagedWorkflows(c, listWorkflows(c), !c.Bool(FlagMore))
@@ -810,10 +819,18 @@ type AutoResetPointRow struct {
 
 func printAutoResetPoints(resp *types.DescribeWorkflowExecutionResponse) {
 	fmt.Println("Auto Reset Points:")
-	table := []AutoResetPointRow{}
-	if resp.WorkflowExecutionInfo.AutoResetPoints == nil || len(resp.WorkflowExecutionInfo.AutoResetPoints.Points) == 0 {
+	table := getAutoResetPointRows(resp)
+	if len(table) == 0 {
 		return
 	}
+	RenderTable(os.Stdout, table, RenderOptions{Color: true, Border: true, PrintDateTime: true})
+}
+
+func getAutoResetPointRows(resp *types.DescribeWorkflowExecutionResponse) []AutoResetPointRow {
+	table := []AutoResetPointRow{}
+	if resp.WorkflowExecutionInfo.AutoResetPoints == nil {
+		return table
+	}
 	for _, pt := range resp.WorkflowExecutionInfo.AutoResetPoints.Points {
 		table = append(table, AutoResetPointRow{
 			BinaryChecksum: pt.GetBinaryChecksum(),
@@ -822,7 +839,7 @@ func printAutoResetPoints(resp *types.DescribeWorkflowExecutionResponse) {
 			EventID:        pt.GetFirstDecisionCompletedID(),
 		})
 	}
-	RenderTable(os.Stdout, table, RenderOptions{Color: true, Border: true, PrintDateTime: true})
+	return table
 }
 
 // describeWorkflowExecutionResponse is used to print datetime instead of print raw time
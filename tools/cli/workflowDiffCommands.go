@@ -0,0 +1,394 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/urfave/cli"
+
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/tools/common/historyexport"
+)
+
+const inputFormatJSON = "json"
+
+// fields that are expected to differ between two runs of the same workflow code
+var ignoredDiffFields = map[string]bool{
+	"Identity":                true,
+	"RequestID":               true,
+	"OriginalExecutionRunID":  true,
+	"FirstExecutionRunID":     true,
+	"ContinuedExecutionRunID": true,
+	"NewExecutionRunID":       true,
+}
+
+type (
+	// HistoryDiffRow is a presentation layer entity used to render a difference between two histories
+	HistoryDiffRow struct {
+		Decision     int    `header:"Decision" json:"decision"`
+		LeftEventID  int64  `header:"Left Event" json:"leftEventId,omitempty"`
+		RightEventID int64  `header:"Right Event" json:"rightEventId,omitempty"`
+		EventType    string `header:"Event Type" json:"eventType"`
+		Field        string `header:"Field" json:"field,omitempty"`
+		Left         string `header:"Left" json:"left"`
+		Right        string `header:"Right" json:"right"`
+	}
+
+	// HistoryDiff is the result of comparing two histories
+	HistoryDiff struct {
+		LeftEvents      int                `json:"leftEvents"`
+		RightEvents     int                `json:"rightEvents"`
+		FirstDivergence *HistoryDiffRow    `json:"firstDivergence,omitempty"`
+		ResetPoint      *AutoResetPointRow `json:"resetPoint,omitempty"`
+		ResetEventID    int64              `json:"resetEventId,omitempty"`
+		Differences     []HistoryDiffRow   `json:"differences"`
+	}
+)
+
+func getFlagsForDiff() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  FlagWorkflowIDWithAlias,
+			Usage: "WorkflowID of the left side, required",
+		},
+		cli.StringFlag{
+			Name:  FlagRunIDWithAlias,
+			Usage: "RunID of the left side, optional, default to the current/latest RunID",
+		},
+		cli.StringFlag{
+			Name:  FlagOtherWorkflowID,
+			Usage: "WorkflowID of the right side, default to the left side WorkflowID",
+		},
+		cli.StringFlag{
+			Name:  FlagOtherRunID,
+			Usage: "RunID of the right side, required unless input_file is given",
+		},
+		cli.StringFlag{
+			Name:  FlagInputFileWithAlias,
+			Usage: "Compare against a history file instead of a second run",
+		},
+		cli.StringFlag{
+			Name:  FlagInputFormat,
+			Value: inputFormatJSON,
+			Usage: "Format of input_file: json (written by show --output_filename), jsonl or proto (written by export)",
+		},
+		cli.BoolFlag{
+			Name:  FlagRawHistory,
+			Usage: "input_file contains raw history batches written by export --" + FlagRawHistory,
+		},
+		cli.IntFlag{
+			Name:  FlagMaxFieldLengthWithAlias,
+			Usage: "Maximum length for each attribute value in table output",
+			Value: defaultMaxFieldLength,
+		},
+		getFormatFlag(),
+	}
+}
+
+// DiffWorkflow compares the history of a run with another run or with a history file
+func DiffWorkflow(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+	wid := getRequiredOption(c, FlagWorkflowID)
+	rid := c.String(FlagRunID)
+	wfClient := getWorkflowClient(c)
+
+	ctx, cancel := newContext(c)
+	defer cancel()
+	left, err := GetHistory(ctx, wfClient, domain, wid, rid)
+	if err != nil {
+		ErrorAndExit(fmt.Sprintf("Failed to get history on workflow id: %s, run id: %s.", wid, rid), err)
+	}
+
+	var right []*types.HistoryEvent
+	if inputFileName := c.String(FlagInputFile); inputFileName != "" {
+		right, err = readHistoryFile(inputFileName, c.String(FlagInputFormat), c.Bool(FlagRawHistory))
+		if err != nil {
+			ErrorAndExit("Failed to read history file.", err)
+		}
+	} else {
+		otherWid := c.String(FlagOtherWorkflowID)
+		if otherWid == "" {
+			otherWid = wid
+		}
+		otherRid := getRequiredOption(c, FlagOtherRunID)
+		history, err := GetHistory(ctx, wfClient, domain, otherWid, otherRid)
+		if err != nil {
+			ErrorAndExit(fmt.Sprintf("Failed to get history on workflow id: %s, run id: %s.", otherWid, otherRid), err)
+		}
+		right = history.Events
+	}
+
+	resp, err := wfClient.DescribeWorkflowExecution(ctx, &types.DescribeWorkflowExecutionRequest{
+		Domain: domain,
+		Execution: &types.WorkflowExecution{
+			WorkflowID: wid,
+			RunID:      rid,
+		},
+	})
+	if err != nil {
+		ErrorAndExit("Describe workflow execution failed", err)
+	}
+
+	diff := diffHistories(left.Events, right)
+	diff.ResetPoint = findResetPoint(getAutoResetPointRows(resp), diff.FirstDivergence)
+	diff.ResetEventID = findResetEvent(right, resp.GetWorkflowExecutionInfo().GetExecution().GetRunID())
+
+	if c.String(FlagFormat) == formatJSON {
+		Render(c, diff, RenderOptions{})
+		return
+	}
+	printHistoryDiffSummary(diff)
+	if len(diff.Differences) == 0 {
+		return
+	}
+	maxFieldLength := c.Int(FlagMaxFieldLength)
+	for i := range diff.Differences {
+		diff.Differences[i].Left = trimText(diff.Differences[i].Left, maxFieldLength)
+		diff.Differences[i].Right = trimText(diff.Differences[i].Right, maxFieldLength)
+	}
+	Render(c, diff.Differences, RenderOptions{Color: true, Border: true, DefaultTemplate: templateTable})
+}
+
+func readHistoryFile(fileName string, format string, raw bool) ([]*types.HistoryEvent, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if format == inputFormatJSON {
+		data, err := ioutil.ReadAll(file)
+		if err != nil {
+			return nil, err
+		}
+		history, err := (&JSONHistorySerializer{}).Deserialize(data)
+		if err != nil {
+			return nil, err
+		}
+		return history.Events, nil
+	}
+	exportFormat, err := historyexport.ParseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	return historyexport.ReadHistory(file, exportFormat, raw)
+}
+
+func printHistoryDiffSummary(diff *HistoryDiff) {
+	fmt.Printf("Left: %v events, Right: %v events\n", diff.LeftEvents, diff.RightEvents)
+	if diff.FirstDivergence == nil {
+		fmt.Println("Histories are identical")
+		return
+	}
+	fmt.Printf("First divergence: decision %v, left event %v, right event %v, %v\n",
+		diff.FirstDivergence.Decision, diff.FirstDivergence.LeftEventID, diff.FirstDivergence.RightEventID, diff.FirstDivergence.EventType)
+	if diff.ResetPoint != nil {
+		fmt.Printf("Last auto reset point before divergence: event %v, binary checksum %v\n", diff.ResetPoint.EventID, diff.ResetPoint.BinaryChecksum)
+	}
+	if diff.ResetEventID != 0 {
+		fmt.Printf("Right side was reset from the left run at event %v\n", diff.ResetEventID)
+	}
+}
+
+// diffHistories aligns two histories by decision boundaries and compares the
+// events of each decision by position, type and attributes
+func diffHistories(left, right []*types.HistoryEvent) *HistoryDiff {
+	diff := &HistoryDiff{
+		LeftEvents:  len(left),
+		RightEvents: len(right),
+		Differences: []HistoryDiffRow{},
+	}
+	leftDecisions := splitByDecision(left)
+	rightDecisions := splitByDecision(right)
+	for d := 0; d < len(leftDecisions) || d < len(rightDecisions); d++ {
+		var leftEvents, rightEvents []*types.HistoryEvent
+		if d < len(leftDecisions) {
+			leftEvents = leftDecisions[d]
+		}
+		if d < len(rightDecisions) {
+			rightEvents = rightDecisions[d]
+		}
+		for i := 0; i < len(leftEvents) || i < len(rightEvents); i++ {
+			var l, r *types.HistoryEvent
+			if i < len(leftEvents) {
+				l = leftEvents[i]
+			}
+			if i < len(rightEvents) {
+				r = rightEvents[i]
+			}
+			diff.Differences = append(diff.Differences, diffEvents(d, l, r)...)
+		}
+	}
+	if len(diff.Differences) > 0 {
+		diff.FirstDivergence = &diff.Differences[0]
+	}
+	return diff
+}
+
+// splitByDecision groups events so that each group ends with the event closing a decision task
+func splitByDecision(events []*types.HistoryEvent) [][]*types.HistoryEvent {
+	var decisions [][]*types.HistoryEvent
+	var current []*types.HistoryEvent
+	for _, e := range events {
+		current = append(current, e)
+		switch e.GetEventType() {
+		case types.EventTypeDecisionTaskCompleted, types.EventTypeDecisionTaskFailed, types.EventTypeDecisionTaskTimedOut:
+			decisions = append(decisions, current)
+			current = nil
+		}
+	}
+	if len(current) > 0 {
+		decisions = append(decisions, current)
+	}
+	return decisions
+}
+
+func diffEvents(decision int, left, right *types.HistoryEvent) []HistoryDiffRow {
+	row := HistoryDiffRow{Decision: decision}
+	if left != nil {
+		row.LeftEventID = left.ID
+		row.EventType = left.GetEventType().String()
+		row.Left = row.EventType
+	}
+	if right != nil {
+		row.RightEventID = right.ID
+		row.Right = right.GetEventType().String()
+		if left == nil {
+			row.EventType = row.Right
+		}
+	}
+	if left == nil || right == nil {
+		return []HistoryDiffRow{row}
+	}
+	if left.GetEventType() != right.GetEventType() {
+		row.EventType = fmt.Sprintf("%v -> %v", left.GetEventType(), right.GetEventType())
+		return []HistoryDiffRow{row}
+	}
+
+	leftFields := map[string]string{}
+	rightFields := map[string]string{}
+	flattenFields("", reflect.ValueOf(getEventAttributes(left)), leftFields)
+	flattenFields("", reflect.ValueOf(getEventAttributes(right)), rightFields)
+	keys := map[string]bool{}
+	for k := range leftFields {
+		keys[k] = true
+	}
+	for k := range rightFields {
+		keys[k] = true
+	}
+	var fields []string
+	for k := range keys {
+		if leftFields[k] != rightFields[k] {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+
+	var rows []HistoryDiffRow
+	for _, field := range fields {
+		fieldRow := row
+		fieldRow.Field = field
+		fieldRow.Left = leftFields[field]
+		fieldRow.Right = rightFields[field]
+		rows = append(rows, fieldRow)
+	}
+	return rows
+}
+
+// flattenFields converts an attributes struct into a map of dotted field paths to printable values
+func flattenFields(prefix string, v reflect.Value, fields map[string]string) {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if stringer, ok := v.Interface().(fmt.Stringer); ok && v.Elem().Kind() != reflect.Struct {
+			fields[prefix] = stringer.String()
+			return
+		}
+		flattenFields(prefix, v.Elem(), fields)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			name := t.Field(i).Name
+			if t.Field(i).PkgPath != "" || ignoredDiffFields[name] {
+				continue
+			}
+			flattenFields(joinFieldPath(prefix, name), v.Field(i), fields)
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Len() > 0 {
+				fields[prefix] = string(v.Bytes())
+			}
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			flattenFields(joinFieldPath(prefix, strconv.Itoa(i)), v.Index(i), fields)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			flattenFields(joinFieldPath(prefix, fmt.Sprint(key.Interface())), v.MapIndex(key), fields)
+		}
+	default:
+		fields[prefix] = fmt.Sprint(v.Interface())
+	}
+}
+
+func joinFieldPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// findResetPoint returns the last auto reset point before the first divergence
+func findResetPoint(points []AutoResetPointRow, divergence *HistoryDiffRow) *AutoResetPointRow {
+	if divergence == nil || divergence.LeftEventID == 0 {
+		return nil
+	}
+	var found *AutoResetPointRow
+	for i := range points {
+		if points[i].EventID < divergence.LeftEventID && (found == nil || points[i].EventID > found.EventID) {
+			found = &points[i]
+		}
+	}
+	return found
+}
+
+// findResetEvent returns the ID of the event that marks events as reset from baseRunID
+func findResetEvent(events []*types.HistoryEvent, baseRunID string) int64 {
+	for _, e := range events {
+		attr := e.DecisionTaskFailedEventAttributes
+		if attr != nil && attr.Cause != nil && *attr.Cause == types.DecisionTaskFailedCauseResetWorkflow && attr.BaseRunID == baseRunID {
+			return e.ID
+		}
+	}
+	return 0
+}
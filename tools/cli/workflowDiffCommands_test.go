@@ -0,0 +1,157 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/types"
+)
+
+func diffTestHistory(activityType string) []*types.HistoryEvent {
+	return []*types.HistoryEvent{
+		{
+			ID:        1,
+			EventType: types.EventTypeWorkflowExecutionStarted.Ptr(),
+			WorkflowExecutionStartedEventAttributes: &types.WorkflowExecutionStartedEventAttributes{
+				WorkflowType: &types.WorkflowType{Name: "workflow"},
+				Identity:     "worker-" + activityType,
+			},
+		},
+		{ID: 2, EventType: types.EventTypeDecisionTaskScheduled.Ptr(), DecisionTaskScheduledEventAttributes: &types.DecisionTaskScheduledEventAttributes{}},
+		{ID: 3, EventType: types.EventTypeDecisionTaskStarted.Ptr(), DecisionTaskStartedEventAttributes: &types.DecisionTaskStartedEventAttributes{ScheduledEventID: 2}},
+		{ID: 4, EventType: types.EventTypeDecisionTaskCompleted.Ptr(), DecisionTaskCompletedEventAttributes: &types.DecisionTaskCompletedEventAttributes{ScheduledEventID: 2, StartedEventID: 3}},
+		{
+			ID:        5,
+			EventType: types.EventTypeActivityTaskScheduled.Ptr(),
+			ActivityTaskScheduledEventAttributes: &types.ActivityTaskScheduledEventAttributes{
+				ActivityID:   "0",
+				ActivityType: &types.ActivityType{Name: activityType},
+				Input:        []byte("input"),
+			},
+		},
+	}
+}
+
+func TestDiffHistories_Identical(t *testing.T) {
+	diff := diffHistories(diffTestHistory("a"), diffTestHistory("a"))
+	assert.Nil(t, diff.FirstDivergence)
+	assert.Empty(t, diff.Differences)
+	assert.Equal(t, 5, diff.LeftEvents)
+}
+
+func TestDiffHistories_IgnoresIdentity(t *testing.T) {
+	left := diffTestHistory("a")
+	right := diffTestHistory("a")
+	right[0].WorkflowExecutionStartedEventAttributes.Identity = "other"
+	assert.Empty(t, diffHistories(left, right).Differences)
+}
+
+func TestDiffHistories_IgnoresRunIDs(t *testing.T) {
+	withRunIDs := func(runID string) []*types.HistoryEvent {
+		history := diffTestHistory("a")
+		attributes := history[0].WorkflowExecutionStartedEventAttributes
+		attributes.OriginalExecutionRunID = runID
+		attributes.FirstExecutionRunID = runID
+		attributes.ContinuedExecutionRunID = "continued-" + runID
+		return append(history, &types.HistoryEvent{
+			ID:        6,
+			EventType: types.EventTypeWorkflowExecutionContinuedAsNew.Ptr(),
+			WorkflowExecutionContinuedAsNewEventAttributes: &types.WorkflowExecutionContinuedAsNewEventAttributes{
+				NewExecutionRunID: "new-" + runID,
+			},
+		})
+	}
+	assert.Empty(t, diffHistories(withRunIDs("run-1"), withRunIDs("run-2")).Differences)
+}
+
+func TestDiffHistories_AttributeDifference(t *testing.T) {
+	diff := diffHistories(diffTestHistory("a"), diffTestHistory("b"))
+	require.NotNil(t, diff.FirstDivergence)
+	assert.Equal(t, []HistoryDiffRow{
+		{
+			Decision:     1,
+			LeftEventID:  5,
+			RightEventID: 5,
+			EventType:    types.EventTypeActivityTaskScheduled.String(),
+			Field:        "ActivityType.Name",
+			Left:         "a",
+			Right:        "b",
+		},
+	}, diff.Differences)
+}
+
+func TestDiffHistories_AlignsByDecision(t *testing.T) {
+	left := diffTestHistory("a")
+	right := diffTestHistory("a")
+	// the right side closes the first decision with a failure and retries it
+	right = append(right[:3],
+		&types.HistoryEvent{ID: 4, EventType: types.EventTypeDecisionTaskFailed.Ptr(), DecisionTaskFailedEventAttributes: &types.DecisionTaskFailedEventAttributes{ScheduledEventID: 2, StartedEventID: 3}},
+		&types.HistoryEvent{ID: 5, EventType: types.EventTypeDecisionTaskScheduled.Ptr(), DecisionTaskScheduledEventAttributes: &types.DecisionTaskScheduledEventAttributes{}},
+	)
+
+	diff := diffHistories(left, right)
+	require.NotNil(t, diff.FirstDivergence)
+	assert.Equal(t, HistoryDiffRow{
+		Decision:     0,
+		LeftEventID:  4,
+		RightEventID: 4,
+		EventType:    "DecisionTaskCompleted -> DecisionTaskFailed",
+		Left:         "DecisionTaskCompleted",
+		Right:        "DecisionTaskFailed",
+	}, *diff.FirstDivergence)
+	assert.Len(t, diff.Differences, 2)
+	assert.Equal(t, types.EventTypeActivityTaskScheduled.String()+" -> "+types.EventTypeDecisionTaskScheduled.String(), diff.Differences[1].EventType)
+}
+
+func TestDiffHistories_MissingEvents(t *testing.T) {
+	left := diffTestHistory("a")
+	diff := diffHistories(left, left[:4])
+	require.Len(t, diff.Differences, 1)
+	assert.Equal(t, int64(5), diff.Differences[0].LeftEventID)
+	assert.Equal(t, int64(0), diff.Differences[0].RightEventID)
+}
+
+func TestFindResetPoint(t *testing.T) {
+	points := []AutoResetPointRow{{EventID: 4, BinaryChecksum: "a"}, {EventID: 10, BinaryChecksum: "b"}, {EventID: 20, BinaryChecksum: "c"}}
+	assert.Nil(t, findResetPoint(points, nil))
+	assert.Nil(t, findResetPoint(points, &HistoryDiffRow{LeftEventID: 3}))
+	assert.Equal(t, "b", findResetPoint(points, &HistoryDiffRow{LeftEventID: 15}).BinaryChecksum)
+}
+
+func TestFindResetEvent(t *testing.T) {
+	events := diffTestHistory("a")
+	assert.Equal(t, int64(0), findResetEvent(events, "base"))
+	events = append(events, &types.HistoryEvent{
+		ID:        6,
+		EventType: types.EventTypeDecisionTaskFailed.Ptr(),
+		DecisionTaskFailedEventAttributes: &types.DecisionTaskFailedEventAttributes{
+			Cause:     types.DecisionTaskFailedCauseResetWorkflow.Ptr(),
+			BaseRunID: "base",
+		},
+		Version: common.EmptyVersion,
+	})
+	assert.Equal(t, int64(6), findResetEvent(events, "base"))
+}
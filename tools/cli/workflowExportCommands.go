@@ -0,0 +1,303 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/urfave/cli"
+
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/tools/common/historyexport"
+)
+
+const defaultPageSizeForRawHistory = 1000
+
+func getFlagsForExport() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  FlagWorkflowIDWithAlias,
+			Usage: "WorkflowID",
+		},
+		cli.StringFlag{
+			Name:  FlagRunIDWithAlias,
+			Usage: "RunID, optional, default to the current/latest RunID",
+		},
+		cli.StringFlag{
+			Name:  FlagListQueryWithAlias,
+			Usage: "Visibility query selecting the workflows to export, one file per run is written to output_directory",
+		},
+		cli.StringFlag{
+			Name:  FlagFormat,
+			Value: string(historyexport.FormatJSONL),
+			Usage: "Export format: jsonl (one proto JSON message per line) or proto (length-delimited binary proto)",
+		},
+		cli.BoolFlag{
+			Name:  FlagRawHistory,
+			Usage: "Export the persisted history batches as DataBlobs instead of decoded events, preserving exact bytes",
+		},
+		cli.StringFlag{
+			Name:  FlagOutputFilenameWithAlias,
+			Usage: "Output file for a single workflow, default to stdout",
+		},
+		cli.StringFlag{
+			Name:  FlagOutputDirectory,
+			Usage: "Output directory when exporting the workflows matching a query",
+		},
+		cli.IntFlag{
+			Name:  FlagPageSize,
+			Value: defaultPageSizeForScan,
+			Usage: "Page size used when scanning workflows matching a query",
+		},
+	}
+}
+
+func getFlagsForImportValidate() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  FlagInputFileWithAlias,
+			Usage: "Exported history file, required",
+		},
+		cli.StringFlag{
+			Name:  FlagFormat,
+			Value: string(historyexport.FormatJSONL),
+			Usage: "Format of the exported history: jsonl or proto",
+		},
+		cli.BoolFlag{
+			Name:  FlagRawHistory,
+			Usage: "The file contains raw history batches exported with --" + FlagRawHistory,
+		},
+		cli.StringFlag{
+			Name:  FlagOutputFilenameWithAlias,
+			Usage: "Also write the validated history as a JSON file, to be replayed against the workflow code with the client replayer",
+		},
+	}
+}
+
+// ExportWorkflow streams the history of one workflow, or of every workflow matching a query
+func ExportWorkflow(c *cli.Context) {
+	exportWorkflow(c, c.Bool(FlagRawHistory))
+}
+
+// AdminExportWorkflow streams raw history batches of one workflow, or of every workflow matching a query
+func AdminExportWorkflow(c *cli.Context) {
+	exportWorkflow(c, true)
+}
+
+func exportWorkflow(c *cli.Context, raw bool) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+	format, err := historyexport.ParseFormat(c.String(FlagFormat))
+	if err != nil {
+		ErrorAndExit("Invalid export format.", err)
+	}
+
+	ctx, cancel := newIndefiniteContext(c)
+	defer cancel()
+
+	if !c.IsSet(FlagListQuery) {
+		wid := getRequiredOption(c, FlagWorkflowID)
+		rid := c.String(FlagRunID)
+		output := io.Writer(os.Stdout)
+		if outputFileName := c.String(FlagOutputFilename); outputFileName != "" {
+			file, err := os.Create(outputFileName)
+			if err != nil {
+				ErrorAndExit("Failed to create output file.", err)
+			}
+			defer file.Close()
+			output = file
+		}
+		if err := exportExecution(ctx, c, output, domain, wid, rid, format, raw); err != nil {
+			ErrorAndExit("Failed to export workflow history.", err)
+		}
+		return
+	}
+
+	outputDirectory := getRequiredOption(c, FlagOutputDirectory)
+	if err := os.MkdirAll(outputDirectory, 0755); err != nil {
+		ErrorAndExit("Failed to create output directory.", err)
+	}
+	wfClient := getWorkflowClient(c)
+	query := c.String(FlagListQuery)
+	pageSize := c.Int(FlagPageSize)
+	if pageSize <= 0 {
+		pageSize = defaultPageSizeForScan
+	}
+
+	exported := 0
+	var nextPageToken []byte
+	for {
+		var executions []*types.WorkflowExecutionInfo
+		executions, nextPageToken = scanWorkflowExecutions(wfClient, pageSize, nextPageToken, query, c)
+		for _, execution := range executions {
+			wid := execution.GetExecution().GetWorkflowID()
+			rid := execution.GetExecution().GetRunID()
+			fileName := filepath.Join(outputDirectory, url.PathEscape(wid)+"_"+rid+format.Extension())
+			if err := exportExecutionToFile(ctx, c, fileName, domain, wid, rid, format, raw); err != nil {
+				ErrorAndExit(fmt.Sprintf("Failed to export workflow %v, run %v.", wid, rid), err)
+			}
+			exported++
+		}
+		if len(nextPageToken) == 0 {
+			break
+		}
+	}
+	fmt.Printf("Exported %v workflow histories to %v\n", exported, outputDirectory)
+}
+
+func exportExecutionToFile(
+	ctx context.Context,
+	c *cli.Context,
+	fileName string,
+	domain string,
+	wid string,
+	rid string,
+	format historyexport.Format,
+	raw bool,
+) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return exportExecution(ctx, c, file, domain, wid, rid, format, raw)
+}
+
+func exportExecution(
+	ctx context.Context,
+	c *cli.Context,
+	output io.Writer,
+	domain string,
+	wid string,
+	rid string,
+	format historyexport.Format,
+	raw bool,
+) error {
+	writer := historyexport.NewWriter(output, format)
+	if raw {
+		if err := exportRawHistory(ctx, c, writer, domain, wid, rid); err != nil {
+			return err
+		}
+		return writer.Flush()
+	}
+
+	iterator, err := GetWorkflowHistoryIterator(ctx, getWorkflowClient(c), domain, wid, rid, false, types.HistoryEventFilterTypeAllEvent.Ptr())
+	if err != nil {
+		return err
+	}
+	for iterator.HasNext() {
+		entity, err := iterator.Next()
+		if err != nil {
+			return err
+		}
+		if err := writer.WriteEvent(entity.(*types.HistoryEvent)); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+func exportRawHistory(
+	ctx context.Context,
+	c *cli.Context,
+	writer *historyexport.Writer,
+	domain string,
+	wid string,
+	rid string,
+) error {
+	if rid == "" {
+		// raw history is read per run, so resolve the current run first
+		resp, err := getWorkflowClient(c).DescribeWorkflowExecution(ctx, &types.DescribeWorkflowExecutionRequest{
+			Domain:    domain,
+			Execution: &types.WorkflowExecution{WorkflowID: wid},
+		})
+		if err != nil {
+			return err
+		}
+		rid = resp.GetWorkflowExecutionInfo().GetExecution().GetRunID()
+	}
+
+	adminClient := cFactory.ServerAdminClient(c)
+	var nextPageToken []byte
+	for {
+		resp, err := adminClient.GetWorkflowExecutionRawHistoryV2(ctx, &types.GetWorkflowExecutionRawHistoryV2Request{
+			Domain: domain,
+			Execution: &types.WorkflowExecution{
+				WorkflowID: wid,
+				RunID:      rid,
+			},
+			MaximumPageSize: defaultPageSizeForRawHistory,
+			NextPageToken:   nextPageToken,
+		})
+		if err != nil {
+			return err
+		}
+		for _, blob := range resp.HistoryBatches {
+			if err := writer.WriteBlob(blob); err != nil {
+				return err
+			}
+		}
+		nextPageToken = resp.NextPageToken
+		if len(nextPageToken) == 0 {
+			return nil
+		}
+	}
+}
+
+// ImportValidate reads an exported history and checks its structure, it does not run any workflow code
+func ImportValidate(c *cli.Context) {
+	inputFileName := getRequiredOption(c, FlagInputFile)
+	format, err := historyexport.ParseFormat(c.String(FlagFormat))
+	if err != nil {
+		ErrorAndExit("Invalid export format.", err)
+	}
+
+	file, err := os.Open(inputFileName)
+	if err != nil {
+		ErrorAndExit("Failed to open input file.", err)
+	}
+	defer file.Close()
+
+	events, err := historyexport.ReadHistory(file, format, c.Bool(FlagRawHistory))
+	if err != nil {
+		ErrorAndExit("Failed to read exported history.", err)
+	}
+	if err := historyexport.Validate(events); err != nil {
+		ErrorAndExit("Exported history is invalid.", err)
+	}
+
+	if outputFileName := c.String(FlagOutputFilename); outputFileName != "" {
+		serializer := &JSONHistorySerializer{}
+		data, err := serializer.Serialize(&types.History{Events: events})
+		if err != nil {
+			ErrorAndExit("Failed to serialize history data.", err)
+		}
+		if err := ioutil.WriteFile(outputFileName, data, 0666); err != nil {
+			ErrorAndExit("Failed to write history data file.", err)
+		}
+	}
+	fmt.Printf("Validated %v events successfully\n", len(events))
+}
@@ -0,0 +1,512 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/uber/cadence/common/types"
+)
+
+type (
+	tuiView       int
+	tuiActionKind int
+
+	// eventGroup collects the events of one activity, timer, child workflow or decision
+	eventGroup struct {
+		Kind   string
+		Key    string
+		Name   string
+		Events []*types.HistoryEvent
+	}
+
+	// tuiAction is an operation requested by a key press that needs the frontend client
+	tuiAction struct {
+		Kind    tuiActionKind
+		EventID int64
+		Values  []string
+	}
+
+	tuiPrompt struct {
+		action  tuiActionKind
+		eventID int64
+		labels  []string
+		values  []string
+		buffer  string
+	}
+
+	// workflowTUI is the state of the interactive workflow view. It is kept free of
+	// terminal and client handling so key bindings and rendering can be tested.
+	workflowTUI struct {
+		wid      string
+		rid      string
+		events   []*types.HistoryEvent
+		groups   []*eventGroup
+		describe *types.DescribeWorkflowExecutionResponse
+		view     tuiView
+		cursor   [tuiViewCount]int
+		offset   [tuiViewCount]int
+		detail   bool
+		width    int
+		height   int
+		status   string
+		prompt   *tuiPrompt
+	}
+)
+
+const (
+	tuiViewHistory tuiView = iota
+	tuiViewGroups
+	tuiViewPending
+	tuiViewCount
+)
+
+const (
+	tuiActionNone tuiActionKind = iota
+	tuiActionQuit
+	tuiActionRefresh
+	tuiActionSignal
+	tuiActionQuery
+	tuiActionReset
+	tuiActionTerminate
+)
+
+// key names produced by the terminal input parser
+const (
+	tuiKeyUp        = "up"
+	tuiKeyDown      = "down"
+	tuiKeyPageUp    = "pgup"
+	tuiKeyPageDown  = "pgdn"
+	tuiKeyEnter     = "enter"
+	tuiKeyEscape    = "esc"
+	tuiKeyTab       = "tab"
+	tuiKeyBackspace = "backspace"
+)
+
+var tuiViewNames = [tuiViewCount]string{"History", "Grouped", "Pending"}
+
+const tuiHelp = "[tab] view  [enter] details  [j/k] move  [g/G] top/bottom  [s]ignal  [y] query  [R]eset to event  [T]erminate  [r]efresh  [q]uit"
+
+func newWorkflowTUI(wid, rid string, width, height int) *workflowTUI {
+	return &workflowTUI{
+		wid:    wid,
+		rid:    rid,
+		width:  width,
+		height: height,
+	}
+}
+
+// addEvents appends newly received history events and regroups them
+func (t *workflowTUI) addEvents(events ...*types.HistoryEvent) {
+	t.events = append(t.events, events...)
+	t.groups = groupHistoryEvents(t.events)
+}
+
+func (t *workflowTUI) setDescribe(resp *types.DescribeWorkflowExecutionResponse) {
+	t.describe = resp
+	if rid := resp.GetWorkflowExecutionInfo().GetExecution().GetRunID(); rid != "" {
+		t.rid = rid
+	}
+}
+
+func (t *workflowTUI) resize(width, height int) {
+	t.width = width
+	t.height = height
+}
+
+// handleKey applies a key press to the view state and returns the action it requests
+func (t *workflowTUI) handleKey(key string) tuiAction {
+	if t.prompt != nil {
+		return t.handlePromptKey(key)
+	}
+	t.status = ""
+	switch key {
+	case "q":
+		return tuiAction{Kind: tuiActionQuit}
+	case tuiKeyEscape:
+		t.detail = false
+	case tuiKeyTab:
+		t.view = (t.view + 1) % tuiViewCount
+		t.detail = false
+	case tuiKeyEnter:
+		t.detail = !t.detail
+	case tuiKeyUp, "k":
+		t.move(-1)
+	case tuiKeyDown, "j":
+		t.move(1)
+	case tuiKeyPageUp:
+		t.move(-t.pageSize())
+	case tuiKeyPageDown:
+		t.move(t.pageSize())
+	case "g":
+		t.move(-t.rowCount())
+	case "G":
+		t.move(t.rowCount())
+	case "r":
+		return tuiAction{Kind: tuiActionRefresh}
+	case "s":
+		t.startPrompt(tuiActionSignal, 0, "Signal name", "Signal input (JSON)")
+	case "y":
+		t.startPrompt(tuiActionQuery, 0, "Query type", "Query args (JSON)")
+	case "R":
+		if event := t.selectedEvent(); event != nil {
+			t.startPrompt(tuiActionReset, event.ID, fmt.Sprintf("Reset to event %v, reason", event.ID))
+		} else {
+			t.status = "Select an event to reset to"
+		}
+	case "T":
+		t.startPrompt(tuiActionTerminate, 0, "Terminate reason")
+	}
+	return tuiAction{}
+}
+
+func (t *workflowTUI) handlePromptKey(key string) tuiAction {
+	p := t.prompt
+	switch key {
+	case tuiKeyEscape:
+		t.prompt = nil
+		t.status = "Cancelled"
+	case tuiKeyEnter:
+		p.values = append(p.values, p.buffer)
+		p.buffer = ""
+		if len(p.values) < len(p.labels) {
+			return tuiAction{}
+		}
+		t.prompt = nil
+		return tuiAction{Kind: p.action, EventID: p.eventID, Values: p.values}
+	case tuiKeyBackspace:
+		if len(p.buffer) > 0 {
+			p.buffer = p.buffer[:len(p.buffer)-1]
+		}
+	default:
+		if len(key) == 1 {
+			p.buffer += key
+		}
+	}
+	return tuiAction{}
+}
+
+func (t *workflowTUI) startPrompt(action tuiActionKind, eventID int64, labels ...string) {
+	t.prompt = &tuiPrompt{
+		action:  action,
+		eventID: eventID,
+		labels:  labels,
+	}
+}
+
+func (t *workflowTUI) move(delta int) {
+	count := t.rowCount()
+	cursor := t.cursor[t.view] + delta
+	if cursor >= count {
+		cursor = count - 1
+	}
+	if cursor < 0 {
+		cursor = 0
+	}
+	t.cursor[t.view] = cursor
+}
+
+// pageSize is the number of list rows that fit between the header and the footer
+func (t *workflowTUI) pageSize() int {
+	if size := t.height - 3; size > 0 {
+		return size
+	}
+	return 1
+}
+
+func (t *workflowTUI) rowCount() int {
+	return len(t.rows())
+}
+
+// selectedEvent returns the highlighted event, or the last event of the highlighted group
+func (t *workflowTUI) selectedEvent() *types.HistoryEvent {
+	cursor := t.cursor[t.view]
+	switch t.view {
+	case tuiViewHistory:
+		if cursor < len(t.events) {
+			return t.events[cursor]
+		}
+	case tuiViewGroups:
+		if cursor < len(t.groups) {
+			events := t.groups[cursor].Events
+			return events[len(events)-1]
+		}
+	}
+	return nil
+}
+
+func (t *workflowTUI) rows() []string {
+	switch t.view {
+	case tuiViewHistory:
+		rows := make([]string, 0, len(t.events))
+		for _, e := range t.events {
+			rows = append(rows, formatTUIEvent(e))
+		}
+		return rows
+	case tuiViewGroups:
+		rows := make([]string, 0, len(t.groups))
+		for _, g := range t.groups {
+			rows = append(rows, fmt.Sprintf("%-9s %-24s %-24s %-36s events:%-3d [%v..%v]",
+				g.Kind, g.Key, g.Name, g.Events[len(g.Events)-1].GetEventType(), len(g.Events), g.Events[0].ID, g.Events[len(g.Events)-1].ID))
+		}
+		return rows
+	default:
+		return pendingRows(t.describe)
+	}
+}
+
+func (t *workflowTUI) detailRows() []string {
+	switch t.view {
+	case tuiViewHistory:
+		event := t.selectedEvent()
+		if event == nil {
+			return nil
+		}
+		rows := []string{fmt.Sprintf("Event %v %v  version:%v  time:%v", event.ID, event.GetEventType(), event.Version, convertTime(event.GetTimestamp(), false))}
+		return append(rows, formatFields(event)...)
+	case tuiViewGroups:
+		cursor := t.cursor[t.view]
+		if cursor >= len(t.groups) {
+			return nil
+		}
+		var rows []string
+		for _, e := range t.groups[cursor].Events {
+			rows = append(rows, formatTUIEvent(e))
+		}
+		return rows
+	default:
+		return t.rows()
+	}
+}
+
+// render writes a full screen frame
+func (t *workflowTUI) render() string {
+	var buf bytes.Buffer
+	buf.WriteString("\x1b[H\x1b[2J")
+
+	status := "Running"
+	if info := t.describe.GetWorkflowExecutionInfo(); info != nil && info.CloseStatus != nil {
+		status = info.CloseStatus.String()
+	}
+	header := fmt.Sprintf("%v | WorkflowID: %v  RunID: %v  Status: %v  Events: %v", tuiViewNames[t.view], t.wid, t.rid, status, len(t.events))
+	t.writeLine(&buf, "\x1b[7m", header)
+
+	rows := t.rows()
+	if t.detail {
+		rows = t.detailRows()
+	}
+	pageSize := t.pageSize()
+	cursor := t.cursor[t.view]
+	offset := t.offset[t.view]
+	if t.detail {
+		offset = 0
+	} else {
+		if cursor < offset {
+			offset = cursor
+		}
+		if cursor >= offset+pageSize {
+			offset = cursor - pageSize + 1
+		}
+		t.offset[t.view] = offset
+	}
+	for i := offset; i < len(rows) && i < offset+pageSize; i++ {
+		if !t.detail && i == cursor {
+			t.writeLine(&buf, "\x1b[7m", rows[i])
+		} else {
+			t.writeLine(&buf, "", rows[i])
+		}
+	}
+
+	buf.WriteString(fmt.Sprintf("\x1b[%d;1H", t.height))
+	switch {
+	case t.prompt != nil:
+		t.writeText(&buf, "", fmt.Sprintf("%v: %v", t.prompt.labels[len(t.prompt.values)], t.prompt.buffer))
+	case t.status != "":
+		t.writeText(&buf, "", t.status)
+	default:
+		t.writeText(&buf, "", tuiHelp)
+	}
+	return buf.String()
+}
+
+func (t *workflowTUI) writeLine(buf *bytes.Buffer, style, text string) {
+	t.writeText(buf, style, text)
+	buf.WriteString("\r\n")
+}
+
+func (t *workflowTUI) writeText(buf *bytes.Buffer, style, text string) {
+	text = strings.ReplaceAll(text, "\n", " ")
+	if t.width > 0 && len(text) > t.width {
+		text = text[:t.width]
+	}
+	if style != "" {
+		buf.WriteString(style)
+		buf.WriteString(text)
+		buf.WriteString("\x1b[0m")
+		return
+	}
+	buf.WriteString(text)
+}
+
+func formatTUIEvent(e *types.HistoryEvent) string {
+	return fmt.Sprintf("%6d  %-19s  %-44s %s", e.ID, convertTime(e.GetTimestamp(), false), e.GetEventType(), strings.Join(formatFields(e), ", "))
+}
+
+func formatFields(e *types.HistoryEvent) []string {
+	fields := map[string]string{}
+	flattenFields("", reflect.ValueOf(getEventAttributes(e)), fields)
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	rows := make([]string, 0, len(keys))
+	for _, k := range keys {
+		rows = append(rows, fmt.Sprintf("%v:%v", k, fields[k]))
+	}
+	return rows
+}
+
+func pendingRows(resp *types.DescribeWorkflowExecutionResponse) []string {
+	if resp == nil {
+		return []string{"Loading..."}
+	}
+	var rows []string
+	if d := resp.PendingDecision; d != nil {
+		state := ""
+		if d.State != nil {
+			state = d.State.String()
+		}
+		rows = append(rows, fmt.Sprintf("decision  state:%v  attempt:%v", state, d.Attempt))
+	}
+	for _, a := range resp.PendingActivities {
+		rows = append(rows, fmt.Sprintf("activity  id:%v  type:%v  state:%v  attempt:%v  last failure:%v",
+			a.ActivityID, a.ActivityType.GetName(), a.GetState(), a.Attempt, a.GetLastFailureReason()))
+	}
+	for _, c := range resp.PendingChildren {
+		rows = append(rows, fmt.Sprintf("child     workflowID:%v  runID:%v  type:%v  initiated:%v",
+			c.WorkflowID, c.RunID, c.WorkflowTypeName, c.InitiatedID))
+	}
+	if len(rows) == 0 {
+		rows = append(rows, "No pending activities, children or decision")
+	}
+	return rows
+}
+
+// groupHistoryEvents groups events by the activity, timer, child workflow or
+// decision they belong to. Other events form groups of their own.
+func groupHistoryEvents(events []*types.HistoryEvent) []*eventGroup {
+	var groups []*eventGroup
+	byInitiatedID := map[int64]*eventGroup{}
+	timers := map[string]*eventGroup{}
+	activities := map[string]*eventGroup{}
+	newGroup := func(kind, key, name string, e *types.HistoryEvent) *eventGroup {
+		g := &eventGroup{Kind: kind, Key: key, Name: name, Events: []*types.HistoryEvent{e}}
+		groups = append(groups, g)
+		return g
+	}
+	appendTo := func(initiatedID int64, kind string, e *types.HistoryEvent) {
+		if g, ok := byInitiatedID[initiatedID]; ok {
+			g.Events = append(g.Events, e)
+			return
+		}
+		newGroup(kind, "", "", e)
+	}
+
+	for _, e := range events {
+		switch e.GetEventType() {
+		case types.EventTypeDecisionTaskScheduled:
+			byInitiatedID[e.ID] = newGroup("decision", fmt.Sprint(e.ID), "", e)
+		case types.EventTypeDecisionTaskStarted:
+			appendTo(e.DecisionTaskStartedEventAttributes.GetScheduledEventID(), "decision", e)
+		case types.EventTypeDecisionTaskCompleted:
+			appendTo(e.DecisionTaskCompletedEventAttributes.ScheduledEventID, "decision", e)
+		case types.EventTypeDecisionTaskFailed:
+			appendTo(e.DecisionTaskFailedEventAttributes.ScheduledEventID, "decision", e)
+		case types.EventTypeDecisionTaskTimedOut:
+			appendTo(e.DecisionTaskTimedOutEventAttributes.ScheduledEventID, "decision", e)
+
+		case types.EventTypeActivityTaskScheduled:
+			attr := e.ActivityTaskScheduledEventAttributes
+			byInitiatedID[e.ID] = newGroup("activity", attr.GetActivityID(), attr.GetActivityType().GetName(), e)
+			activities[attr.GetActivityID()] = byInitiatedID[e.ID]
+		case types.EventTypeActivityTaskStarted:
+			appendTo(e.ActivityTaskStartedEventAttributes.GetScheduledEventID(), "activity", e)
+		case types.EventTypeActivityTaskCompleted:
+			appendTo(e.ActivityTaskCompletedEventAttributes.GetScheduledEventID(), "activity", e)
+		case types.EventTypeActivityTaskFailed:
+			appendTo(e.ActivityTaskFailedEventAttributes.GetScheduledEventID(), "activity", e)
+		case types.EventTypeActivityTaskTimedOut:
+			appendTo(e.ActivityTaskTimedOutEventAttributes.ScheduledEventID, "activity", e)
+		case types.EventTypeActivityTaskCanceled:
+			appendTo(e.ActivityTaskCanceledEventAttributes.ScheduledEventID, "activity", e)
+		case types.EventTypeActivityTaskCancelRequested:
+			if g, ok := activities[e.ActivityTaskCancelRequestedEventAttributes.GetActivityID()]; ok {
+				g.Events = append(g.Events, e)
+			} else {
+				newGroup("activity", e.ActivityTaskCancelRequestedEventAttributes.GetActivityID(), "", e)
+			}
+
+		case types.EventTypeTimerStarted:
+			timerID := e.TimerStartedEventAttributes.GetTimerID()
+			timers[timerID] = newGroup("timer", timerID, "", e)
+		case types.EventTypeTimerFired:
+			appendToTimer(timers, e.TimerFiredEventAttributes.GetTimerID(), e, newGroup)
+		case types.EventTypeTimerCanceled:
+			appendToTimer(timers, e.TimerCanceledEventAttributes.GetTimerID(), e, newGroup)
+
+		case types.EventTypeStartChildWorkflowExecutionInitiated:
+			attr := e.StartChildWorkflowExecutionInitiatedEventAttributes
+			byInitiatedID[e.ID] = newGroup("child", attr.GetWorkflowID(), attr.GetWorkflowType().GetName(), e)
+		case types.EventTypeStartChildWorkflowExecutionFailed:
+			appendTo(e.StartChildWorkflowExecutionFailedEventAttributes.GetInitiatedEventID(), "child", e)
+		case types.EventTypeChildWorkflowExecutionStarted:
+			appendTo(e.ChildWorkflowExecutionStartedEventAttributes.GetInitiatedEventID(), "child", e)
+		case types.EventTypeChildWorkflowExecutionCompleted:
+			appendTo(e.ChildWorkflowExecutionCompletedEventAttributes.GetInitiatedEventID(), "child", e)
+		case types.EventTypeChildWorkflowExecutionFailed:
+			appendTo(e.ChildWorkflowExecutionFailedEventAttributes.GetInitiatedEventID(), "child", e)
+		case types.EventTypeChildWorkflowExecutionCanceled:
+			appendTo(e.ChildWorkflowExecutionCanceledEventAttributes.GetInitiatedEventID(), "child", e)
+		case types.EventTypeChildWorkflowExecutionTimedOut:
+			appendTo(e.ChildWorkflowExecutionTimedOutEventAttributes.GetInitiatedEventID(), "child", e)
+		case types.EventTypeChildWorkflowExecutionTerminated:
+			appendTo(e.ChildWorkflowExecutionTerminatedEventAttributes.GetInitiatedEventID(), "child", e)
+
+		default:
+			newGroup("workflow", "", "", e)
+		}
+	}
+	return groups
+}
+
+func appendToTimer(timers map[string]*eventGroup, timerID string, e *types.HistoryEvent, newGroup func(kind, key, name string, e *types.HistoryEvent) *eventGroup) {
+	if g, ok := timers[timerID]; ok {
+		g.Events = append(g.Events, e)
+		delete(timers, timerID)
+		return
+	}
+	newGroup("timer", timerID, "", e)
+}
@@ -0,0 +1,308 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/urfave/cli"
+	"golang.org/x/term"
+
+	"github.com/uber/cadence/client/frontend"
+	"github.com/uber/cadence/common/types"
+)
+
+const tuiResizePollInterval = time.Second
+
+type tuiSession struct {
+	c        *cli.Context
+	client   frontend.Client
+	domain   string
+	wid      string
+	model    *workflowTUI
+	out      io.Writer
+	events   chan *types.HistoryEvent
+	statuses chan string
+}
+
+// WorkflowTUI opens an interactive view of a workflow execution
+func WorkflowTUI(c *cli.Context) {
+	domain := getRequiredGlobalOption(c, FlagDomain)
+	wid := getRequiredOption(c, FlagWorkflowID)
+	rid := c.String(FlagRunID)
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		ErrorAndExit("workflow tui requires an interactive terminal, use workflow show instead.", nil)
+	}
+	// create the client before switching to raw mode, as it may exit on a bad config
+	client := getWorkflowClient(c)
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		ErrorAndExit("Failed to get terminal size.", err)
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		ErrorAndExit("Failed to switch terminal to raw mode.", err)
+	}
+	// switch to the alternate screen so the shell scrollback is left untouched
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		term.Restore(fd, state)
+	}()
+
+	session := &tuiSession{
+		c:        c,
+		client:   client,
+		domain:   domain,
+		wid:      wid,
+		model:    newWorkflowTUI(wid, rid, width, height),
+		out:      os.Stdout,
+		events:   make(chan *types.HistoryEvent, 1000),
+		statuses: make(chan string, 10),
+	}
+	session.run(rid)
+}
+
+func (s *tuiSession) run(rid string) {
+	ctx, cancel := newIndefiniteContext(s.c)
+	defer cancel()
+
+	s.refreshDescribe()
+	if s.model.rid != "" {
+		rid = s.model.rid
+	}
+	go s.pollHistory(ctx, rid)
+
+	keys := make(chan string)
+	go readTUIKeys(os.Stdin, keys)
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, syscall.SIGTERM)
+	defer signal.Stop(interrupts)
+	resize := time.NewTicker(tuiResizePollInterval)
+	defer resize.Stop()
+
+	s.draw()
+	for {
+		select {
+		case key, ok := <-keys:
+			if !ok {
+				return
+			}
+			action := s.model.handleKey(key)
+			if action.Kind == tuiActionQuit {
+				return
+			}
+			s.execute(action)
+		case e := <-s.events:
+			batch := []*types.HistoryEvent{e}
+			for len(s.events) > 0 {
+				batch = append(batch, <-s.events)
+			}
+			s.model.addEvents(batch...)
+		case status := <-s.statuses:
+			s.model.status = status
+		case <-resize.C:
+			if width, height, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+				s.model.resize(width, height)
+			}
+		case <-interrupts:
+			return
+		}
+		s.draw()
+	}
+}
+
+func (s *tuiSession) draw() {
+	fmt.Fprint(s.out, s.model.render())
+}
+
+// pollHistory streams history events with long poll until the workflow closes
+func (s *tuiSession) pollHistory(ctx context.Context, rid string) {
+	iterator, err := GetWorkflowHistoryIterator(ctx, s.client, s.domain, s.wid, rid, true, types.HistoryEventFilterTypeAllEvent.Ptr())
+	if err != nil {
+		s.statuses <- fmt.Sprintf("Failed to get history: %v", err)
+		return
+	}
+	for iterator.HasNext() {
+		entity, err := iterator.Next()
+		if err != nil {
+			s.statuses <- fmt.Sprintf("Failed to get history: %v", err)
+			return
+		}
+		select {
+		case s.events <- entity.(*types.HistoryEvent):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *tuiSession) refreshDescribe() {
+	ctx, cancel := newContext(s.c)
+	defer cancel()
+	resp, err := s.client.DescribeWorkflowExecution(ctx, &types.DescribeWorkflowExecutionRequest{
+		Domain:    s.domain,
+		Execution: s.execution(),
+	})
+	if err != nil {
+		s.model.status = fmt.Sprintf("Describe workflow execution failed: %v", err)
+		return
+	}
+	s.model.setDescribe(resp)
+}
+
+func (s *tuiSession) execution() *types.WorkflowExecution {
+	return &types.WorkflowExecution{
+		WorkflowID: s.wid,
+		RunID:      s.model.rid,
+	}
+}
+
+func (s *tuiSession) execute(action tuiAction) {
+	if action.Kind == tuiActionNone {
+		return
+	}
+	ctx, cancel := newContext(s.c)
+	defer cancel()
+
+	var err error
+	switch action.Kind {
+	case tuiActionRefresh:
+		s.refreshDescribe()
+		return
+	case tuiActionSignal:
+		err = s.client.SignalWorkflowExecution(ctx, &types.SignalWorkflowExecutionRequest{
+			Domain:            s.domain,
+			WorkflowExecution: s.execution(),
+			SignalName:        action.Values[0],
+			Input:             []byte(action.Values[1]),
+			Identity:          getCliIdentity(),
+			RequestID:         uuid.New(),
+		})
+		if err == nil {
+			s.model.status = fmt.Sprintf("Signal %v sent", action.Values[0])
+		}
+	case tuiActionQuery:
+		request := &types.QueryWorkflowRequest{
+			Domain:    s.domain,
+			Execution: s.execution(),
+			Query:     &types.WorkflowQuery{QueryType: action.Values[0]},
+		}
+		if action.Values[1] != "" {
+			request.Query.QueryArgs = []byte(action.Values[1])
+		}
+		var resp *types.QueryWorkflowResponse
+		resp, err = s.client.QueryWorkflow(ctx, request)
+		if err == nil {
+			if resp.QueryRejected != nil {
+				s.model.status = fmt.Sprintf("Query was rejected, workflow is in state: %v", queryRejectedState(resp.QueryRejected))
+			} else {
+				s.model.status = fmt.Sprintf("Query result: %s", resp.QueryResult)
+			}
+		}
+	case tuiActionReset:
+		var resp *types.ResetWorkflowExecutionResponse
+		resp, err = s.client.ResetWorkflowExecution(ctx, &types.ResetWorkflowExecutionRequest{
+			Domain:                s.domain,
+			WorkflowExecution:     s.execution(),
+			Reason:                fmt.Sprintf("%v:%v", getCurrentUserFromEnv(), action.Values[0]),
+			DecisionFinishEventID: action.EventID,
+			RequestID:             uuid.New(),
+		})
+		if err == nil {
+			s.model.status = fmt.Sprintf("Reset to event %v, new run: %v", action.EventID, resp.GetRunID())
+		}
+	case tuiActionTerminate:
+		err = s.client.TerminateWorkflowExecution(ctx, &types.TerminateWorkflowExecutionRequest{
+			Domain:            s.domain,
+			WorkflowExecution: s.execution(),
+			Reason:            action.Values[0],
+			Identity:          getCliIdentity(),
+		})
+		if err == nil {
+			s.model.status = "Workflow terminated"
+		}
+	}
+	if err != nil {
+		s.model.status = fmt.Sprintf("Failed: %v", err)
+		return
+	}
+	s.refreshDescribe()
+}
+
+// readTUIKeys converts raw terminal input into key names understood by workflowTUI.handleKey
+func readTUIKeys(r io.Reader, keys chan<- string) {
+	defer close(keys)
+	reader := bufio.NewReader(r)
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return
+		}
+		switch b {
+		case '\r', '\n':
+			keys <- tuiKeyEnter
+		case '\t':
+			keys <- tuiKeyTab
+		case 127, '\b':
+			keys <- tuiKeyBackspace
+		case 3: // ctrl-c is not turned into a signal in raw mode
+			keys <- "q"
+		case 27:
+			keys <- readEscapeSequence(reader)
+		default:
+			keys <- string(b)
+		}
+	}
+}
+
+func readEscapeSequence(reader *bufio.Reader) string {
+	if reader.Buffered() == 0 {
+		return tuiKeyEscape
+	}
+	if b, _ := reader.ReadByte(); b != '[' {
+		return tuiKeyEscape
+	}
+	b, _ := reader.ReadByte()
+	switch b {
+	case 'A':
+		return tuiKeyUp
+	case 'B':
+		return tuiKeyDown
+	case '5', '6':
+		reader.ReadByte() // trailing '~'
+		if b == '5' {
+			return tuiKeyPageUp
+		}
+		return tuiKeyPageDown
+	}
+	return ""
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cli
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+
+	"github.com/uber/cadence/client/frontend"
+	"github.com/uber/cadence/common/types"
+)
+
+func tuiTestHistory() []*types.HistoryEvent {
+	events := diffTestHistory("activityType")
+	return append(events,
+		&types.HistoryEvent{ID: 6, EventType: types.EventTypeTimerStarted.Ptr(), TimerStartedEventAttributes: &types.TimerStartedEventAttributes{TimerID: "timer"}},
+		&types.HistoryEvent{ID: 7, EventType: types.EventTypeActivityTaskStarted.Ptr(), ActivityTaskStartedEventAttributes: &types.ActivityTaskStartedEventAttributes{ScheduledEventID: 5}},
+		&types.HistoryEvent{ID: 8, EventType: types.EventTypeActivityTaskCompleted.Ptr(), ActivityTaskCompletedEventAttributes: &types.ActivityTaskCompletedEventAttributes{ScheduledEventID: 5, StartedEventID: 7}},
+		&types.HistoryEvent{ID: 9, EventType: types.EventTypeTimerFired.Ptr(), TimerFiredEventAttributes: &types.TimerFiredEventAttributes{TimerID: "timer", StartedEventID: 6}},
+	)
+}
+
+func TestGroupHistoryEvents(t *testing.T) {
+	groups := groupHistoryEvents(tuiTestHistory())
+	require.Len(t, groups, 4)
+
+	assert.Equal(t, "workflow", groups[0].Kind)
+	assert.Equal(t, "decision", groups[1].Kind)
+	assert.Len(t, groups[1].Events, 3)
+	assert.Equal(t, "activity", groups[2].Kind)
+	assert.Equal(t, "0", groups[2].Key)
+	assert.Equal(t, "activityType", groups[2].Name)
+	assert.Equal(t, []int64{5, 7, 8}, eventIDs(groups[2].Events))
+	assert.Equal(t, "timer", groups[3].Kind)
+	assert.Equal(t, []int64{6, 9}, eventIDs(groups[3].Events))
+}
+
+func TestWorkflowTUINavigation(t *testing.T) {
+	model := newWorkflowTUI("wid", "rid", 120, 5)
+	model.addEvents(tuiTestHistory()...)
+
+	model.handleKey("j")
+	model.handleKey(tuiKeyDown)
+	assert.Equal(t, int64(3), model.selectedEvent().ID)
+	model.handleKey("G")
+	assert.Equal(t, int64(9), model.selectedEvent().ID)
+	model.handleKey(tuiKeyPageUp)
+	assert.Equal(t, int64(7), model.selectedEvent().ID)
+	model.handleKey("g")
+	assert.Equal(t, int64(1), model.selectedEvent().ID)
+
+	model.handleKey(tuiKeyTab)
+	assert.Equal(t, tuiViewGroups, model.view)
+	model.handleKey("j")
+	model.handleKey("j")
+	assert.Equal(t, int64(8), model.selectedEvent().ID)
+
+	model.handleKey(tuiKeyTab)
+	model.handleKey(tuiKeyTab)
+	assert.Equal(t, tuiViewHistory, model.view)
+	assert.Equal(t, tuiAction{Kind: tuiActionQuit}, model.handleKey("q"))
+}
+
+func TestWorkflowTUIPrompt(t *testing.T) {
+	model := newWorkflowTUI("wid", "rid", 120, 10)
+	model.addEvents(tuiTestHistory()...)
+	model.handleKey("G")
+
+	assert.Equal(t, tuiAction{}, model.handleKey("R"))
+	for _, key := range []string{"b", "a", "d", "x", tuiKeyBackspace} {
+		model.handleKey(key)
+	}
+	assert.Contains(t, model.render(), "Reset to event 9, reason: bad")
+	assert.Equal(t, tuiAction{Kind: tuiActionReset, EventID: 9, Values: []string{"bad"}}, model.handleKey(tuiKeyEnter))
+	assert.Nil(t, model.prompt)
+
+	model.handleKey("s")
+	model.handleKey("n")
+	assert.Equal(t, tuiAction{}, model.handleKey(tuiKeyEnter))
+	assert.Equal(t, tuiAction{Kind: tuiActionSignal, Values: []string{"n", ""}}, model.handleKey(tuiKeyEnter))
+
+	model.handleKey("T")
+	model.handleKey(tuiKeyEscape)
+	assert.Nil(t, model.prompt)
+}
+
+func TestWorkflowTUIRender(t *testing.T) {
+	model := newWorkflowTUI("wid", "rid", 80, 6)
+	model.addEvents(tuiTestHistory()...)
+	screen := model.render()
+	assert.Contains(t, screen, "WorkflowID: wid")
+	assert.Contains(t, screen, "WorkflowExecutionStarted")
+	assert.Contains(t, screen, tuiHelp[:40])
+	for _, line := range strings.Split(screen, "\r\n") {
+		assert.True(t, len(stripANSI(line)) <= 80+len("\x1b[H\x1b[2J"))
+	}
+
+	model.handleKey(tuiKeyTab)
+	model.handleKey(tuiKeyTab)
+	assert.Contains(t, model.render(), "Loading...")
+	model.setDescribe(&types.DescribeWorkflowExecutionResponse{
+		PendingActivities: []*types.PendingActivityInfo{{ActivityID: "1", ActivityType: &types.ActivityType{Name: "activityType"}}},
+	})
+	assert.Contains(t, model.render(), "activity  id:1  type:activityType")
+}
+
+func TestReadTUIKeys(t *testing.T) {
+	keys := make(chan string, 10)
+	readTUIKeys(strings.NewReader("j\x1b[A\x1b[6~\r\t\x7f"), keys)
+	var got []string
+	for key := range keys {
+		got = append(got, key)
+	}
+	assert.Equal(t, []string{"j", tuiKeyUp, tuiKeyPageDown, tuiKeyEnter, tuiKeyTab, tuiKeyBackspace}, got)
+}
+
+func eventIDs(events []*types.HistoryEvent) []int64 {
+	var ids []int64
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func stripANSI(s string) string {
+	for _, code := range []string{"\x1b[7m", "\x1b[0m"} {
+		s = strings.ReplaceAll(s, code, "")
+	}
+	return s
+}
+
+func TestWorkflowTUIQueryRejectedWithoutCloseStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := frontend.NewMockClient(ctrl)
+	client.EXPECT().QueryWorkflow(gomock.Any(), gomock.Any()).Return(&types.QueryWorkflowResponse{
+		QueryRejected: &types.QueryRejected{},
+	}, nil)
+	client.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any()).Return(&types.DescribeWorkflowExecutionResponse{}, nil)
+	s := &tuiSession{
+		c:      cli.NewContext(nil, flag.NewFlagSet("test", 0), nil),
+		client: client,
+		domain: "domain",
+		wid:    "wid",
+		model:  newWorkflowTUI("wid", "rid", 120, 5),
+	}
+
+	s.execute(tuiAction{Kind: tuiActionQuery, Values: []string{"query", ""}})
+	assert.Equal(t, "Query was rejected, workflow is in state: unknown", s.model.status)
+}
+
+func TestQueryRejectedState(t *testing.T) {
+	assert.Equal(t, "unknown", queryRejectedState(&types.QueryRejected{}))
+	assert.Equal(t, "FAILED", queryRejectedState(&types.QueryRejected{CloseStatus: types.WorkflowExecutionCloseStatusFailed.Ptr()}))
+}
@@ -0,0 +1,247 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package historyexport implements the on-disk formats used by the CLI to
+// stream workflow histories out of a cluster and to read them back for validation.
+package historyexport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gogo/protobuf/jsonpb"
+	gogoproto "github.com/gogo/protobuf/proto"
+	apiv1 "github.com/uber/cadence-idl/go/proto/api/v1"
+
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/common/types/mapper/proto"
+)
+
+// Format is the encoding used for an exported history stream
+type Format string
+
+const (
+	// FormatJSONL writes one proto JSON message per line
+	FormatJSONL Format = "jsonl"
+	// FormatProto writes varint length-delimited binary proto messages
+	FormatProto Format = "proto"
+)
+
+// maxMessageSize bounds a single encoded message when reading a stream
+const maxMessageSize = 64 * 1024 * 1024
+
+// ParseFormat converts a user supplied string into a Format
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatJSONL, FormatProto:
+		return Format(s), nil
+	default:
+		return "", fmt.Errorf("unknown export format %q, expected %q or %q", s, FormatJSONL, FormatProto)
+	}
+}
+
+// Extension returns the file extension for the format
+func (f Format) Extension() string {
+	if f == FormatProto {
+		return ".pb"
+	}
+	return ".jsonl"
+}
+
+type (
+	// Writer streams history events or raw history blobs to an io.Writer
+	Writer struct {
+		w         *bufio.Writer
+		format    Format
+		marshaler *jsonpb.Marshaler
+	}
+
+	// Reader reads back a stream produced by Writer
+	Reader struct {
+		r       *bufio.Reader
+		scanner *bufio.Scanner
+		format  Format
+	}
+)
+
+// NewWriter creates a Writer for the given format
+func NewWriter(w io.Writer, format Format) *Writer {
+	return &Writer{
+		w:         bufio.NewWriter(w),
+		format:    format,
+		marshaler: &jsonpb.Marshaler{},
+	}
+}
+
+// WriteEvent writes a single history event using the public proto representation
+func (w *Writer) WriteEvent(event *types.HistoryEvent) error {
+	if event == nil || event.EventType == nil {
+		return errors.New("history event or event type is not set")
+	}
+	protoEvent := proto.FromHistoryEvent(event)
+	if protoEvent.Attributes == nil {
+		return fmt.Errorf("event %v of type %v has no public proto representation, export in raw mode instead", event.ID, event.GetEventType())
+	}
+	return w.write(protoEvent)
+}
+
+// WriteBlob writes a single raw history batch exactly as it is persisted
+func (w *Writer) WriteBlob(blob *types.DataBlob) error {
+	if blob == nil {
+		return errors.New("history blob is not set")
+	}
+	return w.write(proto.FromDataBlob(blob))
+}
+
+// Flush writes any buffered data to the underlying io.Writer
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+func (w *Writer) write(msg gogoproto.Message) error {
+	switch w.format {
+	case FormatJSONL:
+		var buf bytes.Buffer
+		if err := w.marshaler.Marshal(&buf, msg); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		_, err := w.w.Write(buf.Bytes())
+		return err
+	case FormatProto:
+		data, err := gogoproto.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		var prefix [binary.MaxVarintLen64]byte
+		n := binary.PutUvarint(prefix[:], uint64(len(data)))
+		if _, err := w.w.Write(prefix[:n]); err != nil {
+			return err
+		}
+		_, err = w.w.Write(data)
+		return err
+	default:
+		return fmt.Errorf("unknown export format %q", w.format)
+	}
+}
+
+// NewReader creates a Reader for the given format
+func NewReader(r io.Reader, format Format) *Reader {
+	reader := &Reader{format: format}
+	if format == FormatJSONL {
+		reader.scanner = bufio.NewScanner(r)
+		reader.scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	} else {
+		reader.r = bufio.NewReader(r)
+	}
+	return reader
+}
+
+// ReadEvent reads the next history event, returning io.EOF at the end of the stream
+func (r *Reader) ReadEvent() (*types.HistoryEvent, error) {
+	event := &apiv1.HistoryEvent{}
+	if err := r.read(event); err != nil {
+		return nil, err
+	}
+	return proto.ToHistoryEvent(event), nil
+}
+
+// ReadBlob reads the next raw history batch, returning io.EOF at the end of the stream
+func (r *Reader) ReadBlob() (*types.DataBlob, error) {
+	blob := &apiv1.DataBlob{}
+	if err := r.read(blob); err != nil {
+		return nil, err
+	}
+	return proto.ToDataBlob(blob), nil
+}
+
+func (r *Reader) read(msg gogoproto.Message) error {
+	switch r.format {
+	case FormatJSONL:
+		for r.scanner.Scan() {
+			line := bytes.TrimSpace(r.scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			return jsonpb.Unmarshal(bytes.NewReader(line), msg)
+		}
+		if err := r.scanner.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	case FormatProto:
+		size, err := binary.ReadUvarint(r.r)
+		if err != nil {
+			return err
+		}
+		if size > maxMessageSize {
+			return fmt.Errorf("message of %v bytes exceeds limit of %v bytes", size, maxMessageSize)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r.r, data); err != nil {
+			if err == io.EOF {
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		return gogoproto.Unmarshal(data, msg)
+	default:
+		return fmt.Errorf("unknown export format %q", r.format)
+	}
+}
+
+// ReadHistory reads a complete exported history. When raw is set the stream is
+// expected to contain persisted history batches, which are decoded into events.
+func ReadHistory(r io.Reader, format Format, raw bool) ([]*types.HistoryEvent, error) {
+	reader := NewReader(r, format)
+	serializer := persistence.NewPayloadSerializer()
+	var events []*types.HistoryEvent
+	for {
+		if raw {
+			blob, err := reader.ReadBlob()
+			if err == io.EOF {
+				return events, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			batch, err := serializer.DeserializeBatchEvents(persistence.NewDataBlobFromInternal(blob))
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, batch...)
+			continue
+		}
+
+		event, err := reader.ReadEvent()
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package historyexport
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/persistence"
+	"github.com/uber/cadence/common/types"
+	"github.com/uber/cadence/common/types/testdata"
+)
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("jsonl")
+	require.NoError(t, err)
+	assert.Equal(t, FormatJSONL, format)
+	format, err = ParseFormat("proto")
+	require.NoError(t, err)
+	assert.Equal(t, FormatProto, format)
+	_, err = ParseFormat("table")
+	assert.Error(t, err)
+}
+
+func TestEventRoundTrip(t *testing.T) {
+	for _, format := range []Format{FormatJSONL, FormatProto} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			writer := NewWriter(&buf, format)
+			for _, event := range testdata.HistoryEventArray {
+				require.NoError(t, writer.WriteEvent(event))
+			}
+			require.NoError(t, writer.Flush())
+
+			events, err := ReadHistory(&buf, format, false)
+			require.NoError(t, err)
+			assert.Equal(t, testdata.HistoryEventArray, events)
+		})
+	}
+}
+
+func TestBlobRoundTrip(t *testing.T) {
+	serializer := persistence.NewPayloadSerializer()
+	history := validHistory()
+	batches := [][]*types.HistoryEvent{history[:2], history[2:4], history[4:]}
+	for _, format := range []Format{FormatJSONL, FormatProto} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			writer := NewWriter(&buf, format)
+			for _, batch := range batches {
+				blob, err := serializer.SerializeBatchEvents(batch, common.EncodingTypeThriftRW)
+				require.NoError(t, err)
+				require.NoError(t, writer.WriteBlob(blob.ToInternal()))
+			}
+			require.NoError(t, writer.Flush())
+
+			events, err := ReadHistory(&buf, format, true)
+			require.NoError(t, err)
+			assert.Equal(t, history, events)
+		})
+	}
+}
+
+func TestWriteEventWithoutProtoRepresentation(t *testing.T) {
+	writer := NewWriter(&bytes.Buffer{}, FormatJSONL)
+	assert.Error(t, writer.WriteEvent(&types.HistoryEvent{}))
+}
+
+func TestReadTruncatedProto(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewWriter(&buf, FormatProto)
+	require.NoError(t, writer.WriteEvent(testdata.HistoryEventArray[0]))
+	require.NoError(t, writer.Flush())
+
+	reader := NewReader(bytes.NewReader(buf.Bytes()[:buf.Len()-1]), FormatProto)
+	_, err := reader.ReadEvent()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
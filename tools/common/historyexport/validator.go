@@ -0,0 +1,267 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package historyexport
+
+import (
+	"fmt"
+
+	"github.com/uber/cadence/common/types"
+)
+
+type (
+	// ValidationError describes the first event at which an imported history
+	// diverged from what a workflow execution could have produced
+	ValidationError struct {
+		EventID int64
+		Reason  string
+	}
+
+	validator struct {
+		lastEventID int64
+		lastVersion int64
+		closed      bool
+
+		decisionScheduled map[int64]bool
+		decisionStarted   map[int64]int64
+		activityScheduled map[int64]bool
+		activityStarted   map[int64]int64
+		timers            map[string]int64
+		childInitiated    map[int64]bool
+		signalInitiated   map[int64]bool
+		cancelInitiated   map[int64]bool
+	}
+)
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("validation failed at event %v: %v", e.EventID, e.Reason)
+}
+
+// Validate checks the structure of an imported history: IDs are contiguous, versions
+// never decrease, nothing follows a close event and every event references an initiated,
+// scheduled or started event that is still pending. It does not run any workflow code,
+// use the client replayer on the history to check it against the workflow definition.
+func Validate(events []*types.HistoryEvent) error {
+	if len(events) == 0 {
+		return &ValidationError{Reason: "history is empty"}
+	}
+	r := &validator{
+		decisionScheduled: make(map[int64]bool),
+		decisionStarted:   make(map[int64]int64),
+		activityScheduled: make(map[int64]bool),
+		activityStarted:   make(map[int64]int64),
+		timers:            make(map[string]int64),
+		childInitiated:    make(map[int64]bool),
+		signalInitiated:   make(map[int64]bool),
+		cancelInitiated:   make(map[int64]bool),
+	}
+	for _, event := range events {
+		if err := r.apply(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *validator) apply(event *types.HistoryEvent) error {
+	if event == nil || event.EventType == nil {
+		return &ValidationError{EventID: r.lastEventID + 1, Reason: "event type is not set"}
+	}
+	fail := func(format string, args ...interface{}) error {
+		return &ValidationError{EventID: event.ID, Reason: fmt.Sprintf(format, args...)}
+	}
+	if event.ID != r.lastEventID+1 {
+		return fail("expected event ID %v", r.lastEventID+1)
+	}
+	if r.lastEventID > 0 && event.Version < r.lastVersion {
+		return fail("version %v is lower than previous version %v", event.Version, r.lastVersion)
+	}
+	if r.closed {
+		return fail("%v follows workflow close event", event.GetEventType())
+	}
+	if r.lastEventID == 0 && event.GetEventType() != types.EventTypeWorkflowExecutionStarted {
+		return fail("history must start with %v, got %v", types.EventTypeWorkflowExecutionStarted, event.GetEventType())
+	}
+	r.lastEventID = event.ID
+	r.lastVersion = event.Version
+
+	switch event.GetEventType() {
+	case types.EventTypeWorkflowExecutionStarted:
+		if event.ID != 1 {
+			return fail("duplicate %v", event.GetEventType())
+		}
+	case types.EventTypeWorkflowExecutionCompleted,
+		types.EventTypeWorkflowExecutionFailed,
+		types.EventTypeWorkflowExecutionTimedOut,
+		types.EventTypeWorkflowExecutionCanceled,
+		types.EventTypeWorkflowExecutionTerminated,
+		types.EventTypeWorkflowExecutionContinuedAsNew:
+		r.closed = true
+
+	case types.EventTypeDecisionTaskScheduled:
+		r.decisionScheduled[event.ID] = true
+	case types.EventTypeDecisionTaskStarted:
+		scheduledID := event.DecisionTaskStartedEventAttributes.GetScheduledEventID()
+		if !r.decisionScheduled[scheduledID] {
+			return fail("decision task %v is not scheduled", scheduledID)
+		}
+		delete(r.decisionScheduled, scheduledID)
+		r.decisionStarted[event.ID] = scheduledID
+	case types.EventTypeDecisionTaskCompleted:
+		attr := event.DecisionTaskCompletedEventAttributes
+		if attr == nil {
+			return fail("%v attributes are not set", event.GetEventType())
+		}
+		return r.completeDecision(fail, attr.ScheduledEventID, attr.StartedEventID)
+	case types.EventTypeDecisionTaskFailed:
+		attr := event.DecisionTaskFailedEventAttributes
+		if attr == nil {
+			return fail("%v attributes are not set", event.GetEventType())
+		}
+		return r.completeDecision(fail, attr.ScheduledEventID, attr.StartedEventID)
+	case types.EventTypeDecisionTaskTimedOut:
+		attr := event.DecisionTaskTimedOutEventAttributes
+		if attr == nil {
+			return fail("%v attributes are not set", event.GetEventType())
+		}
+		if attr.StartedEventID == 0 {
+			if !r.decisionScheduled[attr.ScheduledEventID] {
+				return fail("decision task %v is not scheduled", attr.ScheduledEventID)
+			}
+			delete(r.decisionScheduled, attr.ScheduledEventID)
+			return nil
+		}
+		return r.completeDecision(fail, attr.ScheduledEventID, attr.StartedEventID)
+
+	case types.EventTypeActivityTaskScheduled:
+		r.activityScheduled[event.ID] = true
+	case types.EventTypeActivityTaskStarted:
+		scheduledID := event.ActivityTaskStartedEventAttributes.GetScheduledEventID()
+		if !r.activityScheduled[scheduledID] {
+			return fail("activity %v is not scheduled", scheduledID)
+		}
+		r.activityStarted[event.ID] = scheduledID
+	case types.EventTypeActivityTaskCompleted:
+		attr := event.ActivityTaskCompletedEventAttributes
+		return r.closeActivity(fail, attr.GetScheduledEventID(), attr.GetStartedEventID())
+	case types.EventTypeActivityTaskFailed:
+		attr := event.ActivityTaskFailedEventAttributes
+		return r.closeActivity(fail, attr.GetScheduledEventID(), attr.GetStartedEventID())
+	case types.EventTypeActivityTaskTimedOut:
+		attr := event.ActivityTaskTimedOutEventAttributes
+		if attr == nil {
+			return fail("%v attributes are not set", event.GetEventType())
+		}
+		return r.closeActivity(fail, attr.ScheduledEventID, attr.StartedEventID)
+	case types.EventTypeActivityTaskCanceled:
+		attr := event.ActivityTaskCanceledEventAttributes
+		if attr == nil {
+			return fail("%v attributes are not set", event.GetEventType())
+		}
+		return r.closeActivity(fail, attr.ScheduledEventID, attr.StartedEventID)
+
+	case types.EventTypeTimerStarted:
+		timerID := event.TimerStartedEventAttributes.GetTimerID()
+		if _, ok := r.timers[timerID]; ok {
+			return fail("timer %q is already started", timerID)
+		}
+		r.timers[timerID] = event.ID
+	case types.EventTypeTimerFired:
+		return r.closeTimer(fail, event.TimerFiredEventAttributes.GetTimerID())
+	case types.EventTypeTimerCanceled:
+		return r.closeTimer(fail, event.TimerCanceledEventAttributes.GetTimerID())
+
+	case types.EventTypeStartChildWorkflowExecutionInitiated:
+		r.childInitiated[event.ID] = true
+	case types.EventTypeChildWorkflowExecutionStarted:
+		return r.checkInitiated(fail, "child workflow", r.childInitiated, event.ChildWorkflowExecutionStartedEventAttributes.GetInitiatedEventID(), false)
+	case types.EventTypeStartChildWorkflowExecutionFailed:
+		return r.checkInitiated(fail, "child workflow", r.childInitiated, event.StartChildWorkflowExecutionFailedEventAttributes.GetInitiatedEventID(), true)
+	case types.EventTypeChildWorkflowExecutionCompleted:
+		return r.checkInitiated(fail, "child workflow", r.childInitiated, event.ChildWorkflowExecutionCompletedEventAttributes.GetInitiatedEventID(), true)
+	case types.EventTypeChildWorkflowExecutionFailed:
+		return r.checkInitiated(fail, "child workflow", r.childInitiated, event.ChildWorkflowExecutionFailedEventAttributes.GetInitiatedEventID(), true)
+	case types.EventTypeChildWorkflowExecutionCanceled:
+		return r.checkInitiated(fail, "child workflow", r.childInitiated, event.ChildWorkflowExecutionCanceledEventAttributes.GetInitiatedEventID(), true)
+	case types.EventTypeChildWorkflowExecutionTimedOut:
+		return r.checkInitiated(fail, "child workflow", r.childInitiated, event.ChildWorkflowExecutionTimedOutEventAttributes.GetInitiatedEventID(), true)
+	case types.EventTypeChildWorkflowExecutionTerminated:
+		return r.checkInitiated(fail, "child workflow", r.childInitiated, event.ChildWorkflowExecutionTerminatedEventAttributes.GetInitiatedEventID(), true)
+
+	case types.EventTypeSignalExternalWorkflowExecutionInitiated:
+		r.signalInitiated[event.ID] = true
+	case types.EventTypeExternalWorkflowExecutionSignaled:
+		return r.checkInitiated(fail, "external signal", r.signalInitiated, event.ExternalWorkflowExecutionSignaledEventAttributes.GetInitiatedEventID(), true)
+	case types.EventTypeSignalExternalWorkflowExecutionFailed:
+		return r.checkInitiated(fail, "external signal", r.signalInitiated, event.SignalExternalWorkflowExecutionFailedEventAttributes.GetInitiatedEventID(), true)
+
+	case types.EventTypeRequestCancelExternalWorkflowExecutionInitiated:
+		r.cancelInitiated[event.ID] = true
+	case types.EventTypeExternalWorkflowExecutionCancelRequested:
+		return r.checkInitiated(fail, "external cancellation", r.cancelInitiated, event.ExternalWorkflowExecutionCancelRequestedEventAttributes.GetInitiatedEventID(), true)
+	case types.EventTypeRequestCancelExternalWorkflowExecutionFailed:
+		return r.checkInitiated(fail, "external cancellation", r.cancelInitiated, event.RequestCancelExternalWorkflowExecutionFailedEventAttributes.GetInitiatedEventID(), true)
+	}
+	return nil
+}
+
+func (r *validator) completeDecision(fail func(string, ...interface{}) error, scheduledID, startedID int64) error {
+	if r.decisionStarted[startedID] != scheduledID || scheduledID == 0 {
+		return fail("decision task %v is not started by event %v", scheduledID, startedID)
+	}
+	delete(r.decisionStarted, startedID)
+	return nil
+}
+
+func (r *validator) closeActivity(fail func(string, ...interface{}) error, scheduledID, startedID int64) error {
+	if !r.activityScheduled[scheduledID] {
+		return fail("activity %v is not pending", scheduledID)
+	}
+	if startedID != 0 && r.activityStarted[startedID] != scheduledID {
+		return fail("activity %v is not started by event %v", scheduledID, startedID)
+	}
+	delete(r.activityScheduled, scheduledID)
+	delete(r.activityStarted, startedID)
+	return nil
+}
+
+func (r *validator) closeTimer(fail func(string, ...interface{}) error, timerID string) error {
+	if _, ok := r.timers[timerID]; !ok {
+		return fail("timer %q is not started", timerID)
+	}
+	delete(r.timers, timerID)
+	return nil
+}
+
+func (r *validator) checkInitiated(
+	fail func(string, ...interface{}) error,
+	kind string,
+	pending map[int64]bool,
+	initiatedID int64,
+	close bool,
+) error {
+	if !pending[initiatedID] {
+		return fail("%v %v is not initiated", kind, initiatedID)
+	}
+	if close {
+		delete(pending, initiatedID)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2022 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package historyexport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/cadence/common"
+	"github.com/uber/cadence/common/types"
+)
+
+func newEvent(id int64, eventType types.EventType, setAttributes func(*types.HistoryEvent)) *types.HistoryEvent {
+	event := &types.HistoryEvent{
+		ID:        id,
+		Version:   common.EmptyVersion,
+		EventType: eventType.Ptr(),
+	}
+	if setAttributes != nil {
+		setAttributes(event)
+	}
+	return event
+}
+
+// validHistory returns a completed workflow that ran one activity and one timer
+func validHistory() []*types.HistoryEvent {
+	return []*types.HistoryEvent{
+		newEvent(1, types.EventTypeWorkflowExecutionStarted, func(e *types.HistoryEvent) {
+			e.WorkflowExecutionStartedEventAttributes = &types.WorkflowExecutionStartedEventAttributes{
+				WorkflowType: &types.WorkflowType{Name: "workflow"},
+				TaskList:     &types.TaskList{Name: "tasklist"},
+			}
+		}),
+		newEvent(2, types.EventTypeDecisionTaskScheduled, func(e *types.HistoryEvent) {
+			e.DecisionTaskScheduledEventAttributes = &types.DecisionTaskScheduledEventAttributes{}
+		}),
+		newEvent(3, types.EventTypeDecisionTaskStarted, func(e *types.HistoryEvent) {
+			e.DecisionTaskStartedEventAttributes = &types.DecisionTaskStartedEventAttributes{ScheduledEventID: 2}
+		}),
+		newEvent(4, types.EventTypeDecisionTaskCompleted, func(e *types.HistoryEvent) {
+			e.DecisionTaskCompletedEventAttributes = &types.DecisionTaskCompletedEventAttributes{ScheduledEventID: 2, StartedEventID: 3}
+		}),
+		newEvent(5, types.EventTypeActivityTaskScheduled, func(e *types.HistoryEvent) {
+			e.ActivityTaskScheduledEventAttributes = &types.ActivityTaskScheduledEventAttributes{ActivityID: "activity"}
+		}),
+		newEvent(6, types.EventTypeTimerStarted, func(e *types.HistoryEvent) {
+			e.TimerStartedEventAttributes = &types.TimerStartedEventAttributes{TimerID: "timer"}
+		}),
+		newEvent(7, types.EventTypeActivityTaskStarted, func(e *types.HistoryEvent) {
+			e.ActivityTaskStartedEventAttributes = &types.ActivityTaskStartedEventAttributes{ScheduledEventID: 5}
+		}),
+		newEvent(8, types.EventTypeActivityTaskCompleted, func(e *types.HistoryEvent) {
+			e.ActivityTaskCompletedEventAttributes = &types.ActivityTaskCompletedEventAttributes{ScheduledEventID: 5, StartedEventID: 7}
+		}),
+		newEvent(9, types.EventTypeTimerFired, func(e *types.HistoryEvent) {
+			e.TimerFiredEventAttributes = &types.TimerFiredEventAttributes{TimerID: "timer", StartedEventID: 6}
+		}),
+		newEvent(10, types.EventTypeDecisionTaskScheduled, func(e *types.HistoryEvent) {
+			e.DecisionTaskScheduledEventAttributes = &types.DecisionTaskScheduledEventAttributes{}
+		}),
+		newEvent(11, types.EventTypeDecisionTaskStarted, func(e *types.HistoryEvent) {
+			e.DecisionTaskStartedEventAttributes = &types.DecisionTaskStartedEventAttributes{ScheduledEventID: 10}
+		}),
+		newEvent(12, types.EventTypeDecisionTaskCompleted, func(e *types.HistoryEvent) {
+			e.DecisionTaskCompletedEventAttributes = &types.DecisionTaskCompletedEventAttributes{ScheduledEventID: 10, StartedEventID: 11}
+		}),
+		newEvent(13, types.EventTypeWorkflowExecutionCompleted, func(e *types.HistoryEvent) {
+			e.WorkflowExecutionCompletedEventAttributes = &types.WorkflowExecutionCompletedEventAttributes{DecisionTaskCompletedEventID: 12}
+		}),
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(validHistory()))
+}
+
+func TestValidateFailures(t *testing.T) {
+	tests := map[string]struct {
+		mutate  func([]*types.HistoryEvent) []*types.HistoryEvent
+		eventID int64
+	}{
+		"empty history": {
+			mutate:  func([]*types.HistoryEvent) []*types.HistoryEvent { return nil },
+			eventID: 0,
+		},
+		"missing start event": {
+			mutate: func(h []*types.HistoryEvent) []*types.HistoryEvent {
+				h[0].EventType = types.EventTypeDecisionTaskScheduled.Ptr()
+				return h
+			},
+			eventID: 1,
+		},
+		"gap in event IDs": {
+			mutate:  func(h []*types.HistoryEvent) []*types.HistoryEvent { return append(h[:4], h[5:]...) },
+			eventID: 6,
+		},
+		"decreasing version": {
+			mutate: func(h []*types.HistoryEvent) []*types.HistoryEvent {
+				for _, e := range h[:5] {
+					e.Version = 10
+				}
+				return h
+			},
+			eventID: 6,
+		},
+		"activity not scheduled": {
+			mutate: func(h []*types.HistoryEvent) []*types.HistoryEvent {
+				h[6].ActivityTaskStartedEventAttributes.ScheduledEventID = 4
+				return h
+			},
+			eventID: 7,
+		},
+		"timer not started": {
+			mutate: func(h []*types.HistoryEvent) []*types.HistoryEvent {
+				h[8].TimerFiredEventAttributes.TimerID = "other"
+				return h
+			},
+			eventID: 9,
+		},
+		"decision started twice": {
+			mutate: func(h []*types.HistoryEvent) []*types.HistoryEvent {
+				h[10].DecisionTaskStartedEventAttributes.ScheduledEventID = 2
+				return h
+			},
+			eventID: 11,
+		},
+		"event after close": {
+			mutate: func(h []*types.HistoryEvent) []*types.HistoryEvent {
+				return append(h, newEvent(14, types.EventTypeDecisionTaskScheduled, nil))
+			},
+			eventID: 14,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := Validate(tt.mutate(validHistory()))
+			if assert.IsType(t, &ValidationError{}, err) {
+				assert.Equal(t, tt.eventID, err.(*ValidationError).EventID)
+			}
+		})
+	}
+}